@@ -0,0 +1,174 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ossf/allstar/pkg/ghclients"
+	"github.com/ossf/allstar/pkg/installations"
+)
+
+const installationsUsage = `Usage: allstar installations <command> [flags]
+
+Commands:
+  list            List installations of the App, and whether each is allowed.
+  remove          Remove disallowed installations, confirming each one.
+  suspend-report  List suspended installations.
+`
+
+// runInstallations runs the "installations" subcommand, for operators to
+// review the installations of the App, and remove those on organizations not
+// in GITHUB_ALLOWED_ORGS. Allstar skips disallowed installations, it does not
+// remove them on its own. output is the default of the -output flag, set by the
+// global -output flag.
+func runInstallations(ctx context.Context, args []string, output string, in io.Reader, out io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(out, installationsUsage)
+		return fmt.Errorf("missing installations command")
+	}
+	cmd := args[0]
+	fs := flag.NewFlagSet("installations "+cmd, flag.ContinueOnError)
+	outputArg := fs.String("output", output, "Output format: text, json, yaml.")
+	var yes bool
+	var id int64
+	if cmd == "remove" {
+		fs.BoolVar(&yes, "yes", false, "Remove without confirming each installation.")
+		fs.Int64Var(&id, "id", 0, "Remove the installation with this ID, even if allowed, instead of all disallowed installations.")
+	}
+	switch cmd {
+	case "list", "remove", "suspend-report":
+	default:
+		fmt.Fprint(out, installationsUsage)
+		return fmt.Errorf("unknown installations command %q", cmd)
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *outputArg != outputText && *outputArg != outputJSON && *outputArg != outputYAML {
+		return fmt.Errorf("unsupported output format %q", *outputArg)
+	}
+
+	ghc, err := ghclients.NewGHClients(ctx, http.DefaultTransport)
+	if err != nil {
+		return err
+	}
+	ac, err := ghc.Get(0)
+	if err != nil {
+		return err
+	}
+	insts, err := installations.List(ctx, ac)
+	if err != nil {
+		return err
+	}
+
+	switch cmd {
+	case "list":
+		return writeInstallations(out, *outputArg, insts)
+	case "suspend-report":
+		var suspended []installations.Installation
+		for _, i := range insts {
+			if i.SuspendedAt != nil {
+				suspended = append(suspended, i)
+			}
+		}
+		return writeInstallations(out, *outputArg, suspended)
+	}
+
+	var remove []installations.Installation
+	for _, i := range insts {
+		if (id == 0 && !i.Allowed) || (id != 0 && i.ID == id) {
+			remove = append(remove, i)
+		}
+	}
+	if id != 0 && len(remove) == 0 {
+		return fmt.Errorf("installation %v not found", id)
+	}
+	if len(remove) == 0 {
+		fmt.Fprintln(out, "No disallowed installations.")
+		return nil
+	}
+	r := bufio.NewReader(in)
+	for _, i := range remove {
+		if !yes {
+			ok, err := confirm(r, out, fmt.Sprintf("Remove installation %v on %v (%v)?", i.ID, i.Account, status(i)))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Fprintf(out, "Skipped installation %v on %v.\n", i.ID, i.Account)
+				continue
+			}
+		}
+		if err := installations.Remove(ctx, ac, i); err != nil {
+			return fmt.Errorf("while removing installation %v on %v: %w", i.ID, i.Account, err)
+		}
+		fmt.Fprintf(out, "Removed installation %v on %v.\n", i.ID, i.Account)
+	}
+	return nil
+}
+
+// confirm prompts for a yes or no answer, default no.
+func confirm(r *bufio.Reader, out io.Writer, prompt string) (bool, error) {
+	fmt.Fprintf(out, "%v [y/N] ", prompt)
+	line, err := r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+func status(i installations.Installation) string {
+	s := "disallowed"
+	if i.Allowed {
+		s = "allowed"
+	}
+	if i.SuspendedAt != nil {
+		s += ", suspended"
+	}
+	return s
+}
+
+func writeInstallations(out io.Writer, format string, insts []installations.Installation) error {
+	if format != outputText {
+		if insts == nil {
+			insts = []installations.Installation{}
+		}
+		return writeOutput(out, format, insts)
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tACCOUNT\tTYPE\tREPOS\tSTATUS\tSUSPENDED BY\tSUSPENDED AT")
+	for _, i := range insts {
+		at := ""
+		if i.SuspendedAt != nil {
+			at = i.SuspendedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+			i.ID, i.Account, i.AccountType, i.RepositorySelection, status(i), i.SuspendedBy, at)
+	}
+	return w.Flush()
+}
@@ -21,21 +21,103 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
-	"time"
 
+	"github.com/ossf/allstar/pkg/api"
+	"github.com/ossf/allstar/pkg/audit"
+	_ "github.com/ossf/allstar/pkg/audit/bucket"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/operator"
+	"github.com/ossf/allstar/pkg/config/schema"
 	"github.com/ossf/allstar/pkg/enforce"
 	"github.com/ossf/allstar/pkg/ghclients"
+	"github.com/ossf/allstar/pkg/leader"
+	_ "github.com/ossf/allstar/pkg/leader/dynamodb"
+	_ "github.com/ossf/allstar/pkg/leader/gcs"
+	_ "github.com/ossf/allstar/pkg/leader/kubernetes"
+	"github.com/ossf/allstar/pkg/ocsf"
 	"github.com/ossf/allstar/pkg/policies"
+	"github.com/ossf/allstar/pkg/policies/lifecycle"
+	"github.com/ossf/allstar/pkg/revert"
+	"github.com/ossf/allstar/pkg/state"
+	_ "github.com/ossf/allstar/pkg/state/bucket"
+	_ "github.com/ossf/allstar/pkg/state/sqlite"
+	"github.com/ossf/allstar/pkg/storage"
+	_ "github.com/ossf/allstar/pkg/storage/sqlite"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
 func main() {
+	os.Exit(run())
+}
+
+// run runs Allstar and returns the exit code. It returns, rather than exits,
+// so that the stores and the audit log are closed first.
+func run() int {
 	setupLog()
 	ctx, cf := context.WithCancel(context.Background())
+	defer cf()
+
+	var supportedPolicies []string
+	for _, p := range policies.GetPolicies() {
+		supportedPolicies = append(supportedPolicies, p.Name())
+	}
+	for _, p := range policies.GetOrgPolicies() {
+		supportedPolicies = append(supportedPolicies, p.Name())
+	}
+	supportedPoliciesMsg := strings.Join(supportedPolicies, ", ")
+	var runOnce bool
+	flag.BoolVar(&runOnce, "once", false, "Run EnforceAll once, instead of in a continuous loop.")
+
+	specificPolicyArg := flag.String("policy", "", fmt.Sprintf("Run specific policy checks, comma separated. Supported policies: %s", supportedPoliciesMsg))
+	specificRepoArg := flag.String("repo", "", "Run on specific repos, comma separated \"owner/repo\" glob patterns. For example \"ossf/allstar\" or \"myorg/service-*\"")
+	orgArg := flag.String("org", "", "Run on the installations of specific orgs, comma separated glob patterns. For example \"ossf\" or \"team-*\"")
+	outputArg := flag.String("output", outputText, fmt.Sprintf("Output format of -once results: %s. Structured formats are written to stdout, or -output-file.", strings.Join(outputFormats, ", ")))
+	outputFileArg := flag.String("output-file", "", "File to write the -once results to, instead of stdout. Defaults the -output format to json.")
+	maxFailuresArg := flag.Int("max-failures", -1, fmt.Sprintf("Exit with status %d if a -once run has more than this number of failing policy results. Negative to disable.", exitFailures))
+	dumpSchemaArg := flag.String("dump-schema", "", "Write JSON Schema for the Allstar and policy config files to the given directory, then exit.")
+
+	flag.Parse()
+
+	// Subcommands follow the global flags, eg: "allstar -output json
+	// installations list".
+	switch flag.Arg(0) {
+	case "":
+	case "installations":
+		if err := runInstallations(ctx, flag.Args()[1:], *outputArg, os.Stdin, os.Stdout); err != nil {
+			log.Fatal().
+				Err(err).
+				Msg("Unexpected error managing installations.")
+		}
+		return exitOK
+	case "revert":
+		if err := runRevert(ctx, flag.Args()[1:], os.Stdin, os.Stdout); err != nil {
+			log.Fatal().
+				Err(err).
+				Msg("Unexpected error reverting fixes.")
+		}
+		return exitOK
+	default:
+		log.Fatal().
+			Str("command", flag.Arg(0)).
+			Msg("Unknown command, supported commands: installations, revert.")
+	}
+
+	if *dumpSchemaArg != "" {
+		if err := schema.Write(*dumpSchemaArg); err != nil {
+			log.Fatal().
+				Err(err).
+				Msg("Unexpected error writing config schema.")
+		}
+		log.Info().
+			Str("dir", *dumpSchemaArg).
+			Msg("Wrote config schema.")
+		return exitOK
+	}
 
 	ghc, err := ghclients.NewGHClients(ctx, http.DefaultTransport)
 	if err != nil {
@@ -43,60 +125,210 @@ func main() {
 			Err(err).
 			Msg("Could not load app secret, shutting down")
 	}
-	var supportedPolicies = policies.GetPolicies()
-	supportedPoliciesMap := map[string]string{}
-	var supportedPoliciesMsg = ""
+	ghclients.ReloadOnSIGHUP(ctx, ghc.Key())
+	ghc.StartTokenRefresh(ctx)
+	config.SetBaseClients(ghc)
 
-	for i, p := range supportedPolicies {
-		var policyName = p.Name()
-		supportedPoliciesMap[policyName] = policyName
-		if i < len(supportedPolicies)-1 {
-			supportedPoliciesMsg += policyName + ", "
-		} else {
-			supportedPoliciesMsg += policyName
+	shard, err := ghclients.NewShard(operator.ShardIndex, operator.ShardCount)
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("Invalid shard configuration, shutting down")
+	}
+	enforce.SetShard(shard)
+
+	if operator.StorageURL != "" {
+		s, err := storage.Open(ctx, operator.StorageURL)
+		if err != nil {
+			log.Fatal().
+				Err(err).
+				Msg("Could not open results storage, shutting down")
 		}
+		defer s.Close()
+		enforce.SetStorage(s)
 	}
-	var runOnce bool
-	flag.BoolVar(&runOnce, "once", false, "Run EnforceAll once, instead of in a continuous loop.")
 
-	specificPolicyArg := flag.String("policy", "", fmt.Sprintf("Run a specific policy check. Supported policies: %s", supportedPoliciesMsg))
-	specificRepoArg := flag.String("repo", "", "Run on a specific \"owner/repo\". For example \"ossf/allstar\"")
+	closeAudit, err := openAudit(ctx, ghc)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Could not open audit log, shutting down")
+		return exitError
+	}
+	defer closeAudit()
 
-	flag.Parse()
+	st, err := state.Open(ctx, operator.StateURL)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Could not open state store, shutting down")
+		return exitError
+	}
+	defer st.Close()
+	enforce.SetState(st)
+	revert.SetState(st)
+	lifecycle.SetState(st)
+
+	if operator.ResultCacheTTL > 0 {
+		if err := enforce.EnableResultCache(ctx, operator.ResultCacheTTL); err != nil {
+			log.Error().
+				Err(err).
+				Msg("Could not load result cache, shutting down")
+			return exitError
+		}
+	}
+
+	if *outputFileArg != "" && *outputArg == outputText {
+		*outputArg = outputJSON
+	}
+	if !validOutput(*outputArg) {
+		log.Error().Err(fmt.Errorf("Unsupported output flag %s", *outputArg)).Msg(fmt.Sprintf("Supported output formats: %s", strings.Join(outputFormats, ", ")))
+		return exitError
+	}
 
+	var policyFilter map[string]bool
 	if *specificPolicyArg != "" {
-		if v, exists := supportedPoliciesMap[*specificPolicyArg]; exists {
-			log.Info().
-				Str("Policy filtering", *specificPolicyArg).
-				Msg(fmt.Sprintf("Allstar will only run on policy %s", v))
-		} else {
-			log.Fatal().Err(fmt.Errorf("Unsupported policy flag %s", *specificPolicyArg)).Msg(fmt.Sprintf("Supported policies: %s", supportedPoliciesMsg))
+		if policyFilter, err = enforce.ParsePolicyFilter(*specificPolicyArg); err != nil {
+			log.Error().Err(fmt.Errorf("Unsupported policy flag: %w", err)).Msg(fmt.Sprintf("Supported policies: %s", supportedPoliciesMsg))
+			return exitError
 		}
+		log.Info().
+			Str("Policy filtering", *specificPolicyArg).
+			Msg(fmt.Sprintf("Allstar will only run policies %s", *specificPolicyArg))
+	}
+
+	if err := verifyApp(ctx, ghc, policyFilter); err != nil {
+		log.Error().
+			Err(err).
+			Msg("GitHub App verification failed, shutting down")
+		return exitError
 	}
 
 	if *specificRepoArg != "" {
+		if _, err := enforce.ParseRepoFilter(*specificRepoArg); err != nil {
+			log.Error().Err(err).Msg("Unsupported repo flag")
+			return exitError
+		}
 		log.Info().
 			Str("Repository filtering", *specificRepoArg).
-			Msg(fmt.Sprintf("Allstar will only run on repository %s", *specificRepoArg))
+			Msg(fmt.Sprintf("Allstar will only run on repositories matching %s", *specificRepoArg))
+	}
+
+	if *orgArg != "" {
+		orgs, err := enforce.ParseOrgFilter(*orgArg)
+		if err != nil {
+			log.Error().Err(err).Msg("Unsupported org flag")
+			return exitError
+		}
+		enforce.SetOrgFilter(orgs)
+		log.Info().
+			Str("Organization filtering", *orgArg).
+			Msg(fmt.Sprintf("Allstar will only run on organizations matching %s", *orgArg))
 	}
 
 	if runOnce {
-		_, err := enforce.EnforceAll(ctx, ghc, *specificPolicyArg, *specificRepoArg)
+		run, err := enforce.EnforceAllRun(ctx, ghc, *specificPolicyArg, *specificRepoArg)
+		r := runReport{
+			Policy: *specificPolicyArg,
+			Repo:   *specificRepoArg,
+		}
+		if run != nil {
+			r.RunID = run.RunID
+			r.Started = &run.Started
+			r.Finished = &run.Finished
+			r.Results = run.Summary
+			r.Counts = run.Counts
+			r.Failures = countFailures(run.Summary)
+		}
 		if err != nil {
-			log.Fatal().
+			r.Error = err.Error()
+		}
+		if *outputArg != outputText {
+			var v interface{} = r
+			if *outputArg == outputOCSF {
+				v = ocsf.Findings(run)
+			}
+			if err := writeOutputFile(*outputFileArg, *outputArg, v); err != nil {
+				log.Error().
+					Err(err).
+					Msg("Unexpected error writing output.")
+				return exitError
+			}
+		}
+		if err != nil {
+			log.Error().
 				Err(err).
 				Msg("Unexpected error enforcing policies.")
+			return exitError
+		}
+		if code := exitCode(r.Failures, *maxFailuresArg); code != exitOK {
+			log.Error().
+				Int("failures", r.Failures).
+				Int("maxFailures", *maxFailuresArg).
+				Msg("Policy failures over the maximum, exiting with failure status.")
+			return code
 		}
 	} else {
+		sched, err := enforce.NewScheduler()
+		if err != nil {
+			log.Error().
+				Err(err).
+				Msg("Could not parse enforcement schedule, shutting down")
+			return exitError
+		}
+		enforceJob := func(ctx context.Context) error {
+			return enforce.EnforceJob(ctx, ghc, sched, *specificPolicyArg, *specificRepoArg)
+		}
+		var el *leader.Elector
+		if operator.LeaderLockURL != "" {
+			lock, err := leader.Open(ctx, operator.LeaderLockURL)
+			if err != nil {
+				log.Error().
+					Err(err).
+					Msg("Could not open leader lock, shutting down")
+				return exitError
+			}
+			defer lock.Close()
+			// Only the leader runs the reconcile job, standby replicas
+			// still serve the operator API.
+			el = leader.NewElector(lock, leader.Holder(), operator.LeaderLockTTL)
+			job := enforceJob
+			enforceJob = func(ctx context.Context) error {
+				return el.Run(ctx, job)
+			}
+		}
 		var wg sync.WaitGroup
 		// Kickoff webhook listener, delayed enforce, reconcile job...
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 			log.Info().
-				Err(enforce.EnforceJob(ctx, ghc, (5 * time.Minute), *specificPolicyArg, *specificRepoArg)).
+				Err(enforceJob(ctx)).
 				Msg("Enforce job shutting down.")
 		}()
+		as := api.NewServer(ctx, ghc)
+		as.SetScheduler(sched)
+		if el != nil {
+			as.SetElector(el)
+		}
+		if operator.APIAddr != "" {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				log.Info().
+					Err(as.ListenAndServe(operator.APIAddr)).
+					Msg("Operator API shutting down.")
+			}()
+		}
+		if operator.HealthAddr != "" {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				log.Info().
+					Err(as.ListenAndServeHealth(operator.HealthAddr)).
+					Msg("Health endpoints shutting down.")
+			}()
+		}
 		sigs := make(chan os.Signal, 1)
 		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 		s := <-sigs
@@ -106,6 +338,53 @@ func main() {
 			Msg("Signal received, shutting down gracefully")
 		wg.Wait()
 	}
+	return exitOK
+}
+
+// openAudit opens the audit log, if ALLSTAR_AUDIT_URL is set, and returns a
+// function that flushes and closes it.
+func openAudit(ctx context.Context, ghc *ghclients.GHClients) (func(), error) {
+	if operator.AuditURL == "" {
+		return func() {}, nil
+	}
+	audit.SetGitHubClients(ghc)
+	a, err := audit.Open(ctx, operator.AuditURL)
+	if err != nil {
+		return nil, err
+	}
+	audit.SetSink(a)
+	return func() {
+		if err := audit.Close(context.Background()); err != nil {
+			log.Error().
+				Err(err).
+				Msg("Unexpected error closing audit log.")
+		}
+	}, nil
+}
+
+// verifyApp verifies the GitHub App credentials, and that the app has the
+// permissions needed by the policies in filter, all if nil. Missing fix
+// permissions are only an error with operator.RequireFixPermissions.
+func verifyApp(ctx context.Context, ghc *ghclients.GHClients, filter map[string]bool) error {
+	c, err := ghc.Get(0)
+	if err != nil {
+		return err
+	}
+	check, fix := policies.RequiredPermissions(filter)
+	app, missing, err := ghclients.VerifyApp(ctx, c, append(check, fix...))
+	if err != nil {
+		return err
+	}
+	missingFix := ghclients.MissingPermissions(ghclients.PermissionsMap(app.Permissions), fix)
+	if len(missing) > len(missingFix) || (len(missingFix) > 0 && operator.RequireFixPermissions) {
+		return ghclients.MissingPermissionsError(app, missing)
+	}
+	if len(missingFix) > 0 {
+		log.Warn().
+			Err(ghclients.MissingPermissionsError(app, missingFix)).
+			Msg("Policies with the fix action will fail to fix, set ALLSTAR_REQUIRE_FIX_PERMISSIONS to fail instead.")
+	}
+	return nil
 }
 
 func setupLog() {
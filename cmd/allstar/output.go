@@ -0,0 +1,149 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ossf/allstar/pkg/enforce"
+	"github.com/ossf/allstar/pkg/ocsf"
+	"github.com/ossf/allstar/pkg/storage"
+	"sigs.k8s.io/yaml"
+)
+
+// Formats of the -output flag. Text is the default, only log lines are
+// written. Structured formats write a single document to stdout, logs are
+// still written to stderr. OCSF writes a Compliance Finding event for each
+// policy result, one JSON object per line, for SIEM ingestion.
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputYAML = "yaml"
+	outputOCSF = "ocsf"
+)
+
+var outputFormats = []string{outputText, outputJSON, outputYAML, outputOCSF}
+
+// Exit codes of a -once run. A run that did not complete exits with 1, as any
+// fatal error.
+const (
+	exitOK       = 0
+	exitError    = 1
+	exitFailures = 2
+)
+
+func validOutput(format string) bool {
+	for _, f := range outputFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// runReport is the machine-readable result of a -once run.
+type runReport struct {
+	// RunID is the ID of the run, as included in logs and issues.
+	RunID string `json:"runId,omitempty"`
+	// Started and Finished are when the run started and finished.
+	Started  *time.Time `json:"started,omitempty"`
+	Finished *time.Time `json:"finished,omitempty"`
+	// Policy is the -policy filter, if any.
+	Policy string `json:"policy,omitempty"`
+	// Repo is the -repo filter, if any.
+	Repo string `json:"repo,omitempty"`
+	// Results are the policy failure counts, keyed by policy name.
+	Results enforce.EnforceAllResults `json:"results"`
+	// Counts are the counts of the run that are not policy results, such as
+	// the skipped repos.
+	Counts storage.RunCounts `json:"counts"`
+	// Failures is the total number of failing policy results, not including
+	// repos in their grace period.
+	Failures int `json:"failures"`
+	// Error is set if the run did not complete.
+	Error string `json:"error,omitempty"`
+}
+
+// countFailures returns the total number of failing policy results in
+// results.
+func countFailures(results enforce.EnforceAllResults) int {
+	var n int
+	for _, counts := range results {
+		n += counts["totalFailed"]
+	}
+	return n
+}
+
+// exitCode returns the exit code of a completed run with failures failing
+// policy results. Runs with more than maxFailures failures exit with
+// exitFailures, unless maxFailures is negative.
+func exitCode(failures, maxFailures int) int {
+	if maxFailures >= 0 && failures > maxFailures {
+		return exitFailures
+	}
+	return exitOK
+}
+
+// writeOutputFile writes v in the provided structured format to path, or to
+// stdout if path is empty.
+func writeOutputFile(path, format string, v interface{}) error {
+	if path == "" {
+		return writeOutput(os.Stdout, format, v)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeOutput(f, format, v); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeOutput writes v to w in the provided structured format.
+func writeOutput(w io.Writer, format string, v interface{}) error {
+	switch format {
+	case outputJSON:
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		return e.Encode(v)
+	case outputYAML:
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	case outputOCSF:
+		fs, ok := v.([]ocsf.Finding)
+		if !ok {
+			return fmt.Errorf("unsupported value for output format %q", format)
+		}
+		e := json.NewEncoder(w)
+		for _, f := range fs {
+			if err := e.Encode(f); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+}
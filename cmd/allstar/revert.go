@@ -0,0 +1,128 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ossf/allstar/pkg/config/operator"
+	"github.com/ossf/allstar/pkg/ghclients"
+	"github.com/ossf/allstar/pkg/revert"
+	"github.com/ossf/allstar/pkg/state"
+)
+
+// runRevert runs the "revert" subcommand, for operators to restore the
+// settings that fix actions changed on a repo, when a fix proves disruptive.
+// The settings are recorded in the state store before each fix, see the
+// revert package.
+func runRevert(ctx context.Context, args []string, in io.Reader, out io.Writer) error {
+	fs := flag.NewFlagSet("revert", flag.ContinueOnError)
+	repoArg := fs.String("repo", "", "Repository to revert the fixes of, \"owner/repo\". Required.")
+	policyArg := fs.String("policy", "", "Only revert the fixes of this policy, eg: \"Branch Protection\".")
+	sinceArg := fs.String("since", "24h", "Revert the fixes made since this time, as a duration ago, eg: \"72h\", or an RFC 3339 time.")
+	dryRun := fs.Bool("dry-run", false, "List the fixes that would be reverted, without reverting them.")
+	yes := fs.Bool("yes", false, "Revert without confirming.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	owner, repo, ok := strings.Cut(*repoArg, "/")
+	if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return fmt.Errorf("invalid -repo %q, expected \"owner/repo\"", *repoArg)
+	}
+	since, err := parseSince(*sinceArg, time.Now())
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(operator.StateURL, "mem://") {
+		return errors.New("fixes are recorded in the state store, set ALLSTAR_STATE_URL to the persistent store Allstar runs with")
+	}
+
+	st, err := state.Open(ctx, operator.StateURL)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	revert.SetState(st)
+	snaps, err := revert.List(ctx, owner, repo, *policyArg, since)
+	if err != nil {
+		return err
+	}
+	if len(snaps) == 0 {
+		fmt.Fprintf(out, "No fixes to revert on %v/%v since %v.\n", owner, repo, since.Format(time.RFC3339))
+		return nil
+	}
+	fmt.Fprintf(out, "Fixes on %v/%v, reverted newest first:\n", owner, repo)
+	for _, s := range snaps {
+		fmt.Fprintf(out, "  %v  %v  %v\n", s.Time.Format(time.RFC3339), s.Policy, s.Target)
+	}
+	if *dryRun {
+		return nil
+	}
+	if !*yes {
+		ok, err := confirm(bufio.NewReader(in), out, fmt.Sprintf("Revert %v fixes?", len(snaps)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Fprintln(out, "Nothing reverted.")
+			return nil
+		}
+	}
+
+	ghc, err := ghclients.NewGHClients(ctx, http.DefaultTransport)
+	if err != nil {
+		return err
+	}
+	closeAudit, err := openAudit(ctx, ghc)
+	if err != nil {
+		return err
+	}
+	defer closeAudit()
+	ac, err := ghc.Get(0)
+	if err != nil {
+		return err
+	}
+	inst, _, err := ac.Apps.FindRepositoryInstallation(ctx, owner, repo)
+	if err != nil {
+		return fmt.Errorf("while finding the installation on %v/%v: %w", owner, repo, err)
+	}
+	c, err := ghc.Get(inst.GetID())
+	if err != nil {
+		return err
+	}
+	n, err := revert.Revert(ctx, c, snaps)
+	fmt.Fprintf(out, "Reverted %v of %v fixes.\n", n, len(snaps))
+	return err
+}
+
+// parseSince parses the -since flag, a duration before now, or a time.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid -since %q, expected a duration or RFC 3339 time", s)
+	}
+	return t, nil
+}
@@ -18,6 +18,7 @@ import (
 	"flag"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/ossf/allstar/pkg/reviewbot"
 	"github.com/rs/zerolog"
@@ -28,6 +29,7 @@ const defaultAppID = 169668
 const defaultMinReviewsRequired = 2
 const defaultPort = 8080
 const defaultSecretToken = "FooBar"
+const defaultSecretTTL = time.Hour
 
 func main() {
 	setupLog()
@@ -38,6 +40,10 @@ func main() {
 		log.Fatal().Err(err).Msg("Error determining configuration")
 	}
 
+	if config.GitHub.SecretTokenSecret == "" && config.GitHub.SecretToken == defaultSecretToken {
+		log.Warn().Msg("Using the default webhook secret token, set SECRET_TOKEN or SECRET_TOKEN_SECRET to the webhook secret of the GitHub App")
+	}
+
 	if err := reviewbot.HandleWebhooks(&config); err != nil {
 		log.Fatal().Err(err).Msg("Error listening to webhooks")
 	}
@@ -68,17 +74,38 @@ func determineConfigFromEnv(config *reviewbot.Config) error {
 		config.GitHub.PrivateKeyPath = envPrivateKeyPath
 	}
 
+	if envPrivateKeySecret, ok := os.LookupEnv("PRIVATE_KEY_SECRET"); ok {
+		config.GitHub.PrivateKeySecret = envPrivateKeySecret
+	}
+
 	if envSecretToken, ok := os.LookupEnv("SECRET_TOKEN"); ok {
 		config.GitHub.SecretToken = envSecretToken
 	}
 
+	if envSecretTokenSecret, ok := os.LookupEnv("SECRET_TOKEN_SECRET"); ok {
+		config.GitHub.SecretTokenSecret = envSecretTokenSecret
+	}
+
+	if envSecretTTL, ok := os.LookupEnv("SECRET_TTL"); ok {
+		secretTTL, err := time.ParseDuration(envSecretTTL)
+
+		if err != nil {
+			return err
+		}
+
+		config.GitHub.SecretTTL = secretTTL
+	}
+
 	return nil
 }
 
 func determineConfigFromFlags(config *reviewbot.Config) error {
 	flagAppID := flag.Int64("app-id", defaultAppID, "A GitHub App Id")
 	flagPrivateKeyPath := flag.String("private-key-path", "", "A path to a GitHub Private Key")
-	flagSecretToken := flag.String("secret-token", defaultSecretToken, "GitHub Private Key")
+	flagPrivateKeySecret := flag.String("private-key-secret", "", "A secret containing a GitHub Private Key, eg: gcpsecretmanager://..., file://..., or env:NAME")
+	flagSecretToken := flag.String("secret-token", defaultSecretToken, "A GitHub webhook secret token")
+	flagSecretTokenSecret := flag.String("secret-token-secret", "", "A secret containing a GitHub webhook secret token, eg: gcpsecretmanager://..., file://..., or env:NAME")
+	flagSecretTTL := flag.Duration("secret-ttl", defaultSecretTTL, "How long secrets are used before they are read again, 0 to only read again on SIGHUP")
 	flagMinReviewsRequired := flag.Uint64("min-reviews-required", defaultMinReviewsRequired, "The global minimum number of reviews required")
 	flagPort := flag.Uint64("port", defaultPort, "A port to listen on")
 
@@ -92,8 +119,20 @@ func determineConfigFromFlags(config *reviewbot.Config) error {
 		config.GitHub.PrivateKeyPath = *flagPrivateKeyPath
 	}
 
+	if *flagPrivateKeySecret != "" {
+		config.GitHub.PrivateKeySecret = *flagPrivateKeySecret
+	}
+
 	if *flagSecretToken != defaultSecretToken {
-		config.GitHub.PrivateKeyPath = *flagSecretToken
+		config.GitHub.SecretToken = *flagSecretToken
+	}
+
+	if *flagSecretTokenSecret != "" {
+		config.GitHub.SecretTokenSecret = *flagSecretTokenSecret
+	}
+
+	if *flagSecretTTL != defaultSecretTTL {
+		config.GitHub.SecretTTL = *flagSecretTTL
 	}
 
 	if *flagMinReviewsRequired != defaultMinReviewsRequired {
@@ -111,6 +150,7 @@ func determineConfig(config *reviewbot.Config) error {
 	// Set defaults
 	config.GitHub.AppId = defaultAppID
 	config.GitHub.SecretToken = defaultSecretToken
+	config.GitHub.SecretTTL = defaultSecretTTL
 	config.MinReviewsRequired = defaultMinReviewsRequired
 	config.Port = defaultPort
 
@@ -0,0 +1,510 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package api implements the operator API, an authenticated HTTP API that
+// lets operators and org admins trigger an immediate enforcement on a repo,
+// instead of waiting for the next enforcement cycle. It also serves the
+// unauthenticated health, readiness, and status endpoints.
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ossf/allstar/pkg/config/operator"
+	"github.com/ossf/allstar/pkg/enforce"
+	"github.com/ossf/allstar/pkg/enforceid"
+	"github.com/ossf/allstar/pkg/ghclients"
+	"github.com/ossf/allstar/pkg/leader"
+	"github.com/ossf/allstar/pkg/policies"
+	"github.com/ossf/allstar/pkg/policydef"
+
+	"github.com/rs/zerolog/log"
+)
+
+// EnforcePath is the path of the endpoint that triggers an enforcement.
+const EnforcePath = "/api/v1/enforce"
+
+// HealthPath is the path of the unauthenticated health endpoint, which reports
+// when enforcement next runs, and the status of the last run.
+const HealthPath = "/healthz"
+
+// ReadyPath is the path of the unauthenticated readiness endpoint, which
+// responds 503 Service Unavailable when the server is shutting down, or the
+// last enforcement run failed.
+const ReadyPath = "/readyz"
+
+// StatusPath is the path of the unauthenticated status page, a human readable
+// summary of the health endpoint.
+const StatusPath = "/statusz"
+
+// maxBodySize is the maximum size of a request body.
+const maxBodySize = 4096
+
+// EnforceRequest is the body of a request to EnforcePath.
+type EnforceRequest struct {
+	// Org is the GitHub org or user that owns the repo.
+	Org string `json:"org"`
+
+	// Repo is the name of the repo, without the org.
+	Repo string `json:"repo"`
+
+	// Policy is the optional name of the single policy to run, eg: "Branch
+	// Protection". All policies are run if empty.
+	Policy string `json:"policy,omitempty"`
+}
+
+// EnforceResponse is the body of an accepted request to EnforcePath.
+type EnforceResponse struct {
+	// RunID is the ID of the scheduled enforcement run, included in the logs,
+	// issues, and stored results of the run.
+	RunID string `json:"runId"`
+}
+
+// HealthResponse is the body of a response from HealthPath.
+type HealthResponse struct {
+	// Status is "ok" while the server is serving.
+	Status string `json:"status"`
+
+	// Schedule is the schedule of the enforcement job, if it is running.
+	Schedule *enforce.ScheduleStatus `json:"schedule,omitempty"`
+
+	// Run is the status of the enforcement runs, if the job is running.
+	Run *enforce.RunStatus `json:"run,omitempty"`
+
+	// Leader is whether this replica runs the enforcement job, if leader
+	// election is enabled.
+	Leader *bool `json:"leader,omitempty"`
+}
+
+// ReadyResponse is the body of a response from ReadyPath.
+type ReadyResponse struct {
+	// Status is "ok" if ready, otherwise "unavailable".
+	Status string `json:"status"`
+
+	// Reason is why the server is not ready.
+	Reason string `json:"reason,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+var enforceAll func(context.Context, ghclients.GhClientsInterface, string, string) (enforce.EnforceAllResults, error)
+var enforceStatus func() enforce.RunStatus
+var policiesGetPolicies func() []policydef.Policy
+var timeNow func() time.Time
+
+func init() {
+	enforceAll = enforce.EnforceAll
+	enforceStatus = enforce.Status
+	policiesGetPolicies = policies.GetPolicies
+	timeNow = time.Now
+}
+
+// Server is the operator API server. Enforcements are run in the background,
+// and are canceled when the context provided to NewServer is done.
+type Server struct {
+	ctx       context.Context
+	ghc       ghclients.GhClientsInterface
+	tokens    map[string]string
+	rateLimit time.Duration
+	sched     *enforce.Scheduler
+	elector   *leader.Elector
+
+	mu      sync.Mutex
+	last    map[string]time.Time
+	running map[string]bool
+	wg      sync.WaitGroup
+}
+
+// NewServer returns a new operator API server, authenticating callers with
+// operator.APITokens, and rate limiting per repo with operator.APIRateLimit.
+func NewServer(ctx context.Context, ghc ghclients.GhClientsInterface) *Server {
+	return &Server{
+		ctx:       ctx,
+		ghc:       ghc,
+		tokens:    operator.APITokens,
+		rateLimit: operator.APIRateLimit,
+		last:      make(map[string]time.Time),
+		running:   make(map[string]bool),
+	}
+}
+
+// SetScheduler sets the scheduler of the enforcement job, to report its
+// schedule from HealthPath.
+func (s *Server) SetScheduler(sched *enforce.Scheduler) {
+	s.sched = sched
+}
+
+// SetElector sets the leader elector of the enforcement job, to report
+// whether this replica is the leader from HealthPath. Readiness does not depend
+// on the runs of a replica that is not the leader.
+func (s *Server) SetElector(e *leader.Elector) {
+	s.elector = e
+}
+
+// Handler returns the HTTP handler of the API.
+func (s *Server) Handler() http.Handler {
+	mux := s.healthMux()
+	mux.HandleFunc(EnforcePath, s.handleEnforce)
+	return mux
+}
+
+// HealthHandler returns the HTTP handler of only the health, readiness, and
+// status endpoints.
+func (s *Server) HealthHandler() http.Handler {
+	return s.healthMux()
+}
+
+func (s *Server) healthMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc(HealthPath, s.handleHealth)
+	mux.HandleFunc(ReadyPath, s.handleReady)
+	mux.HandleFunc(StatusPath, s.handleStatus)
+	return mux
+}
+
+// ListenAndServe serves the API on addr until the context provided to
+// NewServer is done, then waits for triggered enforcements to return.
+func (s *Server) ListenAndServe(addr string) error {
+	log.Info().
+		Str("area", "api").
+		Str("addr", addr).
+		Msg("Operator API listening.")
+	return s.serve(addr, s.Handler())
+}
+
+// ListenAndServeHealth serves only the health, readiness, and status
+// endpoints on addr until the context provided to NewServer is done.
+func (s *Server) ListenAndServeHealth(addr string) error {
+	log.Info().
+		Str("area", "api").
+		Str("addr", addr).
+		Msg("Health endpoints listening.")
+	return s.serve(addr, s.HealthHandler())
+}
+
+func (s *Server) serve(addr string, h http.Handler) error {
+	hs := &http.Server{
+		Addr:              addr,
+		Handler:           h,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-s.ctx.Done()
+		ctx, cf := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cf()
+		if err := hs.Shutdown(ctx); err != nil {
+			log.Error().
+				Str("area", "api").
+				Err(err).
+				Msg("Unexpected error shutting down API server.")
+		}
+	}()
+	err := hs.ListenAndServe()
+	s.wg.Wait()
+	if errors.Is(err, http.ErrServerClosed) {
+		return s.ctx.Err()
+	}
+	return err
+}
+
+func (s *Server) handleEnforce(w http.ResponseWriter, r *http.Request) {
+	caller, ok := s.authenticate(r)
+	if !ok {
+		log.Warn().
+			Str("area", "api").
+			Str("remoteAddr", r.RemoteAddr).
+			Msg("Rejected unauthenticated API request.")
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "missing or invalid bearer token"})
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+	var req EnforceRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("invalid request body: %v", err)})
+		return
+	}
+	if err := validate(req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+
+	fullName := req.Org + "/" + req.Repo
+	if wait, ok := s.reserve(strings.ToLower(fullName)); !ok {
+		log.Warn().
+			Str("area", "api").
+			Str("caller", caller).
+			Str("remoteAddr", r.RemoteAddr).
+			Str("org", req.Org).
+			Str("repo", req.Repo).
+			Str("policy", req.Policy).
+			Msg("Rate limited API enforcement request.")
+		secs := int(math.Ceil(wait.Seconds()))
+		if secs < 1 {
+			secs = 1
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(secs))
+		writeJSON(w, http.StatusTooManyRequests, errorResponse{Error: "enforcement on this repo was recently triggered, retry later"})
+		return
+	}
+
+	ctx := enforceid.WithRun(s.ctx)
+	runID := enforceid.Run(ctx)
+	log.Info().
+		Str("area", "api").
+		Str("caller", caller).
+		Str("remoteAddr", r.RemoteAddr).
+		Str("org", req.Org).
+		Str("repo", req.Repo).
+		Str("policy", req.Policy).
+		Str("runId", runID).
+		Msg("Enforcement triggered through the API.")
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.release(strings.ToLower(fullName))
+		results, err := enforceAll(ctx, s.ghc, req.Policy, fullName)
+		if err != nil {
+			log.Error().
+				Str("area", "api").
+				Str("caller", caller).
+				Str("org", req.Org).
+				Str("repo", req.Repo).
+				Str("runId", runID).
+				Err(err).
+				Msg("Unexpected error in enforcement triggered through the API.")
+			return
+		}
+		log.Info().
+			Str("area", "api").
+			Str("caller", caller).
+			Str("org", req.Org).
+			Str("repo", req.Repo).
+			Str("runId", runID).
+			Interface("results", results).
+			Msg("Enforcement triggered through the API complete.")
+	}()
+
+	writeJSON(w, http.StatusAccepted, EnforceResponse{RunID: runID})
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	writeJSON(w, http.StatusOK, s.health())
+}
+
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	if reason := s.notReady(); reason != "" {
+		writeJSON(w, http.StatusServiceUnavailable, ReadyResponse{Status: "unavailable", Reason: reason})
+		return
+	}
+	writeJSON(w, http.StatusOK, ReadyResponse{Status: "ok"})
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	data := struct {
+		HealthResponse
+		Ready string
+		Now   time.Time
+	}{
+		HealthResponse: s.health(),
+		Ready:          s.notReady(),
+		Now:            timeNow(),
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := statusTemplate.Execute(w, data); err != nil {
+		log.Error().
+			Str("area", "api").
+			Err(err).
+			Msg("Failed to write status page")
+	}
+}
+
+// health returns the health of the server and enforcement job.
+func (s *Server) health() HealthResponse {
+	resp := HealthResponse{Status: "ok"}
+	if s.sched != nil {
+		st := s.sched.Status(timeNow())
+		resp.Schedule = &st
+		run := enforceStatus()
+		resp.Run = &run
+	}
+	if s.elector != nil {
+		l := s.elector.IsLeader()
+		resp.Leader = &l
+	}
+	return resp
+}
+
+// notReady returns why the server is not ready, or empty if it is ready.
+func (s *Server) notReady() string {
+	if s.ctx.Err() != nil {
+		return "shutting down"
+	}
+	if s.sched == nil || (s.elector != nil && !s.elector.IsLeader()) {
+		return ""
+	}
+	if enforceStatus().LastRunFailed {
+		return "last enforcement run failed"
+	}
+	return ""
+}
+
+// authenticate returns the name of the caller of r, and whether its bearer
+// token is one of the configured tokens.
+func (s *Server) authenticate(r *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return "", false
+	}
+	for name, t := range s.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// reserve records an enforcement on repo, unless one is running or was
+// triggered within the rate limit. If not, the time until the next allowed
+// enforcement is returned.
+func (s *Server) reserve(repo string) (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := timeNow()
+	next := s.last[repo].Add(s.rateLimit)
+	if s.running[repo] || now.Before(next) {
+		return next.Sub(now), false
+	}
+	s.last[repo] = now
+	s.running[repo] = true
+	return 0, true
+}
+
+func (s *Server) release(repo string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.running, repo)
+}
+
+func validate(req EnforceRequest) error {
+	if req.Org == "" || req.Repo == "" {
+		return errors.New("org and repo are required")
+	}
+	if strings.Contains(req.Org, "/") || strings.Contains(req.Repo, "/") {
+		return errors.New("org and repo must not contain \"/\"")
+	}
+	// Enforcement takes repo filter patterns, API requests are for a single
+	// repo.
+	if strings.ContainsAny(req.Org+req.Repo, "*?[]{},!\\") {
+		return errors.New("org and repo must not contain patterns")
+	}
+	if req.Policy == "" {
+		return nil
+	}
+	for _, p := range policiesGetPolicies() {
+		if p.Name() == req.Policy {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported policy %q", req.Policy)
+}
+
+// allowGet responds 405 Method Not Allowed, and returns false, if r is not a
+// GET or HEAD request.
+func allowGet(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return true
+	}
+	w.Header().Set("Allow", "GET, HEAD")
+	writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error().
+			Str("area", "api").
+			Err(err).
+			Msg("Failed to write http response")
+	}
+}
+
+var statusTemplate = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Allstar status</title></head>
+<body>
+<h1>Allstar status</h1>
+<p>Ready: {{if .Ready}}no, {{.Ready}}{{else}}yes{{end}}</p>
+{{with .Leader}}<p>Leader: {{if .}}yes{{else}}no, on standby{{end}}</p>{{end}}
+{{with .Schedule}}<p>Next run: {{.NextRun.Format "2006-01-02 15:04:05 MST"}}</p>{{end}}
+{{with .Run}}
+{{if .LastRunID}}
+<h2>Last run</h2>
+<table>
+<tr><td>Run ID</td><td>{{.LastRunID}}</td></tr>
+<tr><td>Started</td><td>{{.LastRunStarted.Format "2006-01-02 15:04:05 MST"}}</td></tr>
+<tr><td>Finished</td><td>{{.LastRunFinished.Format "2006-01-02 15:04:05 MST"}}</td></tr>
+<tr><td>Failed</td><td>{{.LastRunFailed}}</td></tr>
+<tr><td>Installations</td><td>{{.Installations}}</td></tr>
+<tr><td>Repos</td><td>{{.Repos}}</td></tr>
+<tr><td>Repos skipped with errors</td><td>{{.RepoErrors}}</td></tr>
+</table>
+{{else}}
+<p>No enforcement run completed yet.</p>
+{{end}}
+{{if .Errors}}
+<h2>Recent errors</h2>
+<table>
+<tr><th>Time</th><th>Run ID</th><th>Org</th><th>Repo</th><th>Error</th></tr>
+{{range .Errors}}<tr><td>{{.Time.Format "2006-01-02 15:04:05 MST"}}</td><td>{{.RunID}}</td><td>{{.Org}}</td><td>{{.Repo}}</td><td>{{.Error}}</td></tr>
+{{end}}</table>
+{{end}}
+{{else}}
+<p>The enforcement job is not running in this process.</p>
+{{end}}
+<p>Generated {{.Now.Format "2006-01-02 15:04:05 MST"}}</p>
+</body>
+</html>
+`))
@@ -0,0 +1,411 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/enforce"
+	"github.com/ossf/allstar/pkg/ghclients"
+	"github.com/ossf/allstar/pkg/leader"
+	"github.com/ossf/allstar/pkg/policydef"
+)
+
+type pol struct{}
+
+func (p pol) Name() string { return "Branch Protection" }
+
+func (p pol) IsEnabled(ctx context.Context, c *github.Client, owner, repo string) (bool, error) {
+	return true, nil
+}
+
+func (p pol) Check(ctx context.Context, c *github.Client, owner, repo string) (*policydef.Result, error) {
+	return nil, nil
+}
+
+func (p pol) Fix(ctx context.Context, c *github.Client, owner, repo string) error {
+	return nil
+}
+
+func (p pol) GetAction(ctx context.Context, c *github.Client, owner, repo string) string {
+	return "log"
+}
+
+type enforceCall struct {
+	Policy string
+	Repo   string
+}
+
+func TestHandleEnforce(t *testing.T) {
+	policiesGetPolicies = func() []policydef.Policy {
+		return []policydef.Policy{pol{}}
+	}
+	now := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	calls := make(chan enforceCall, 10)
+	enforceAll = func(ctx context.Context, ghc ghclients.GhClientsInterface, policy, repo string) (enforce.EnforceAllResults, error) {
+		calls <- enforceCall{Policy: policy, Repo: repo}
+		return nil, nil
+	}
+
+	s := NewServer(context.Background(), nil)
+	s.tokens = map[string]string{"alice": "s3cr3t"}
+	s.rateLimit = time.Minute
+	h := s.Handler()
+
+	tests := []struct {
+		Name       string
+		Method     string
+		Token      string
+		Body       string
+		Advance    time.Duration
+		ExpStatus  int
+		ExpCall    *enforceCall
+		ExpHeaders map[string]string
+	}{
+		{
+			Name:      "NoToken",
+			Method:    http.MethodPost,
+			Body:      `{"org": "thisorg", "repo": "thisrepo"}`,
+			ExpStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:      "BadToken",
+			Method:    http.MethodPost,
+			Token:     "guess",
+			Body:      `{"org": "thisorg", "repo": "thisrepo"}`,
+			ExpStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:      "Get",
+			Method:    http.MethodGet,
+			Token:     "s3cr3t",
+			ExpStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			Name:      "InvalidBody",
+			Method:    http.MethodPost,
+			Token:     "s3cr3t",
+			Body:      `{"org": "thisorg", "repository": "thisrepo"}`,
+			ExpStatus: http.StatusBadRequest,
+		},
+		{
+			Name:      "MissingRepo",
+			Method:    http.MethodPost,
+			Token:     "s3cr3t",
+			Body:      `{"org": "thisorg"}`,
+			ExpStatus: http.StatusBadRequest,
+		},
+		{
+			Name:      "RepoPattern",
+			Method:    http.MethodPost,
+			Token:     "s3cr3t",
+			Body:      `{"org": "thisorg", "repo": "*"}`,
+			ExpStatus: http.StatusBadRequest,
+		},
+		{
+			Name:      "UnknownPolicy",
+			Method:    http.MethodPost,
+			Token:     "s3cr3t",
+			Body:      `{"org": "thisorg", "repo": "thisrepo", "policy": "Nope"}`,
+			ExpStatus: http.StatusBadRequest,
+		},
+		{
+			Name:      "Accepted",
+			Method:    http.MethodPost,
+			Token:     "s3cr3t",
+			Body:      `{"org": "thisorg", "repo": "thisrepo", "policy": "Branch Protection"}`,
+			ExpStatus: http.StatusAccepted,
+			ExpCall:   &enforceCall{Policy: "Branch Protection", Repo: "thisorg/thisrepo"},
+		},
+		{
+			Name:      "RateLimited",
+			Method:    http.MethodPost,
+			Token:     "s3cr3t",
+			Body:      `{"org": "ThisOrg", "repo": "ThisRepo"}`,
+			Advance:   20 * time.Second,
+			ExpStatus: http.StatusTooManyRequests,
+			ExpHeaders: map[string]string{
+				"Retry-After": "40",
+			},
+		},
+		{
+			Name:      "OtherRepo",
+			Method:    http.MethodPost,
+			Token:     "s3cr3t",
+			Body:      `{"org": "thisorg", "repo": "otherrepo"}`,
+			ExpStatus: http.StatusAccepted,
+			ExpCall:   &enforceCall{Repo: "thisorg/otherrepo"},
+		},
+		{
+			Name:      "AfterRateLimit",
+			Method:    http.MethodPost,
+			Token:     "s3cr3t",
+			Body:      `{"org": "thisorg", "repo": "thisrepo"}`,
+			Advance:   time.Minute,
+			ExpStatus: http.StatusAccepted,
+			ExpCall:   &enforceCall{Repo: "thisorg/thisrepo"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			now = now.Add(test.Advance)
+			req := httptest.NewRequest(test.Method, EnforcePath, strings.NewReader(test.Body))
+			if test.Token != "" {
+				req.Header.Set("Authorization", "Bearer "+test.Token)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != test.ExpStatus {
+				t.Fatalf("Unexpected status. want %v, got %v: %v", test.ExpStatus, rec.Code, rec.Body.String())
+			}
+			for k, v := range test.ExpHeaders {
+				if got := rec.Header().Get(k); got != v {
+					t.Errorf("Unexpected header %v. want %v, got %v", k, v, got)
+				}
+			}
+			if test.ExpCall == nil {
+				return
+			}
+			var resp EnforceResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("Unexpected error decoding response: %v", err)
+			}
+			if resp.RunID == "" {
+				t.Errorf("Expected a run ID")
+			}
+			s.wg.Wait()
+			select {
+			case got := <-calls:
+				if diff := cmp.Diff(*test.ExpCall, got); diff != "" {
+					t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+				}
+			default:
+				t.Errorf("Expected an enforcement to be triggered")
+			}
+		})
+	}
+	if len(calls) != 0 {
+		t.Errorf("Unexpected enforcements triggered: %v", len(calls))
+	}
+}
+
+func TestReserveRunning(t *testing.T) {
+	now := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	s := NewServer(context.Background(), nil)
+	s.rateLimit = 0
+
+	if _, ok := s.reserve("thisorg/thisrepo"); !ok {
+		t.Fatalf("Expected first enforcement to be allowed")
+	}
+	if _, ok := s.reserve("thisorg/thisrepo"); ok {
+		t.Errorf("Expected enforcement to be rejected while running")
+	}
+	s.release("thisorg/thisrepo")
+	if _, ok := s.reserve("thisorg/thisrepo"); !ok {
+		t.Errorf("Expected enforcement to be allowed after release")
+	}
+}
+
+func TestHandleHealth(t *testing.T) {
+	now := time.Date(2025, 9, 1, 12, 1, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	run := enforce.RunStatus{LastRunID: "run", Installations: 2, Repos: 5}
+	enforceStatus = func() enforce.RunStatus { return run }
+	sched, err := enforce.NewScheduler()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	next := time.Date(2025, 9, 1, 12, 5, 0, 0, time.UTC)
+	tests := []struct {
+		Name      string
+		Method    string
+		Sched     *enforce.Scheduler
+		ExpStatus int
+		Exp       HealthResponse
+	}{
+		{
+			Name:      "NoJob",
+			Method:    http.MethodGet,
+			ExpStatus: http.StatusOK,
+			Exp:       HealthResponse{Status: "ok"},
+		},
+		{
+			Name:      "Job",
+			Method:    http.MethodGet,
+			Sched:     sched,
+			ExpStatus: http.StatusOK,
+			Exp: HealthResponse{
+				Status:   "ok",
+				Schedule: &enforce.ScheduleStatus{NextRun: next},
+				Run:      &run,
+			},
+		},
+		{
+			Name:      "Post",
+			Method:    http.MethodPost,
+			ExpStatus: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			s := NewServer(context.Background(), nil)
+			s.SetScheduler(test.Sched)
+			rec := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rec, httptest.NewRequest(test.Method, HealthPath, nil))
+			if rec.Code != test.ExpStatus {
+				t.Fatalf("Unexpected status: %v expect: %v", rec.Code, test.ExpStatus)
+			}
+			if test.ExpStatus != http.StatusOK {
+				return
+			}
+			var resp HealthResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("Unexpected error decoding response: %v", err)
+			}
+			if diff := cmp.Diff(test.Exp, resp); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHandleReady(t *testing.T) {
+	sched, err := enforce.NewScheduler()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	standby := leader.NewElector(leader.NewMemory(), "replica", time.Minute)
+	tests := []struct {
+		Name      string
+		Sched     *enforce.Scheduler
+		Elector   *leader.Elector
+		Failed    bool
+		Canceled  bool
+		ExpStatus int
+		ExpReason string
+	}{
+		{
+			Name:      "NoJob",
+			Failed:    true,
+			ExpStatus: http.StatusOK,
+		},
+		{
+			Name:      "Job",
+			Sched:     sched,
+			ExpStatus: http.StatusOK,
+		},
+		{
+			Name:      "LastRunFailed",
+			Sched:     sched,
+			Failed:    true,
+			ExpStatus: http.StatusServiceUnavailable,
+			ExpReason: "last enforcement run failed",
+		},
+		{
+			Name:      "Standby",
+			Sched:     sched,
+			Elector:   standby,
+			Failed:    true,
+			ExpStatus: http.StatusOK,
+		},
+		{
+			Name:      "ShuttingDown",
+			Canceled:  true,
+			ExpStatus: http.StatusServiceUnavailable,
+			ExpReason: "shutting down",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			enforceStatus = func() enforce.RunStatus {
+				return enforce.RunStatus{LastRunFailed: test.Failed}
+			}
+			ctx, cf := context.WithCancel(context.Background())
+			defer cf()
+			if test.Canceled {
+				cf()
+			}
+			s := NewServer(ctx, nil)
+			s.SetScheduler(test.Sched)
+			if test.Elector != nil {
+				s.SetElector(test.Elector)
+			}
+			rec := httptest.NewRecorder()
+			s.HealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, ReadyPath, nil))
+			if rec.Code != test.ExpStatus {
+				t.Fatalf("Unexpected status: %v expect: %v", rec.Code, test.ExpStatus)
+			}
+			var resp ReadyResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("Unexpected error decoding response: %v", err)
+			}
+			if resp.Reason != test.ExpReason {
+				t.Errorf("Unexpected reason: %q", resp.Reason)
+			}
+		})
+	}
+}
+
+func TestHandleStatus(t *testing.T) {
+	now := time.Date(2025, 9, 1, 12, 1, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	enforceStatus = func() enforce.RunStatus {
+		return enforce.RunStatus{
+			LastRunID:       "run-1",
+			LastRunStarted:  now.Add(-time.Minute),
+			LastRunFinished: now,
+			Installations:   3,
+			Repos:           42,
+			Errors: []enforce.RunError{
+				{Time: now, Org: "acme", Repo: "widgets", Error: "<boom>"},
+			},
+		}
+	}
+	sched, err := enforce.NewScheduler()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s := NewServer(context.Background(), nil)
+	s.SetScheduler(sched)
+	rec := httptest.NewRecorder()
+	s.HealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, StatusPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Unexpected status: %v", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{"run-1", "<td>3</td>", "<td>42</td>", "widgets", "&lt;boom&gt;", "Ready: yes"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected status page to contain %q, got:\n%s", want, body)
+		}
+	}
+
+	// The enforce endpoint is not served by the health handler.
+	rec = httptest.NewRecorder()
+	s.HealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, EnforcePath, nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Unexpected status for enforce on health handler: %v", rec.Code)
+	}
+}
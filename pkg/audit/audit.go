@@ -0,0 +1,278 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit records the changes Allstar makes on GitHub, such as branch
+// protection updates and issues created or edited, so that security teams can
+// review what it changed. Every GitHub API request other than a read is
+// recorded as an Event by the clients from ghclients, once a Sink is set with
+// SetSink.
+//
+// Sinks implement Sink and register an opener for their URL scheme with
+// Register, as with the state package. The "file" scheme, a JSONL file, and
+// the "github" scheme, JSONL files in a repository, are always registered. See
+// the bucket subpackage for object storage.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ossf/allstar/pkg/enforceid"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maxBuffered is the number of events buffered before they are written to the
+// sink, without waiting for Flush.
+const maxBuffered = 100
+
+// maxRetained is the number of events kept to be written again while the sink
+// is failing, the oldest are dropped beyond it.
+const maxRetained = 10 * maxBuffered
+
+// maxValueLen is the length after which request payload values are truncated
+// in the summary.
+const maxValueLen = 100
+
+// Event is a change made on GitHub.
+type Event struct {
+	Time          time.Time `json:"time"`
+	RunID         string    `json:"runId,omitempty"`
+	EnforcementID string    `json:"enforcementId,omitempty"`
+	Org           string    `json:"org,omitempty"`
+	Repo          string    `json:"repo,omitempty"`
+	Policy        string    `json:"policy,omitempty"`
+
+	// Method and Path are of the GitHub API request, eg: "PUT" and
+	// "/repos/acme/app/branches/main/protection".
+	Method string `json:"method"`
+	Path   string `json:"path"`
+
+	// Request summarizes the request payload, see Summarize.
+	Request map[string]string `json:"request,omitempty"`
+
+	// Status is the response status code, or Error is set if no response
+	// was received.
+	Status int    `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Sink is implemented by audit log destinations. Implementations must be
+// safe for concurrent use.
+type Sink interface {
+	// Write appends events to the audit log.
+	Write(ctx context.Context, events []Event) error
+
+	// Close releases any resources held by the sink.
+	Close() error
+}
+
+// Opener opens a sink from a URL, eg: "file:///var/log/allstar/audit.jsonl".
+type Opener func(ctx context.Context, url string) (Sink, error)
+
+var openers = make(map[string]Opener)
+var openersMu sync.Mutex
+
+func init() {
+	Register("file", openFile)
+	Register("github", openRepo)
+}
+
+// Register makes a sink available to Open for URLs with the provided scheme.
+// It panics if the scheme is registered twice.
+func Register(scheme string, o Opener) {
+	openersMu.Lock()
+	defer openersMu.Unlock()
+	if _, ok := openers[scheme]; ok {
+		panic(fmt.Sprintf("audit: Register called twice for scheme %v", scheme))
+	}
+	openers[scheme] = o
+}
+
+// Open opens the sink registered for the scheme of url.
+func Open(ctx context.Context, url string) (Sink, error) {
+	scheme, _, ok := strings.Cut(url, "://")
+	if !ok {
+		return nil, fmt.Errorf("audit: invalid URL %q, expected scheme://", url)
+	}
+	openersMu.Lock()
+	o, ok := openers[scheme]
+	openersMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("audit: unknown scheme %q, registered: %v", scheme, schemes())
+	}
+	return o(ctx, url)
+}
+
+func schemes() []string {
+	openersMu.Lock()
+	defer openersMu.Unlock()
+	var s []string
+	for k := range openers {
+		s = append(s, k)
+	}
+	sort.Strings(s)
+	return s
+}
+
+var mu sync.Mutex
+var sink Sink
+var buffered []Event
+
+// flushMu serializes writes to the sink, so that events are written in order.
+var flushMu sync.Mutex
+
+var timeNow = time.Now
+
+// SetSink sets the sink events are written to. Events are not recorded until
+// it is set.
+func SetSink(s Sink) {
+	mu.Lock()
+	defer mu.Unlock()
+	sink = s
+}
+
+// Enabled returns whether events are recorded.
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return sink != nil
+}
+
+type policyKey struct{}
+type skipKey struct{}
+
+// WithPolicy returns a copy of ctx that changes made with are recorded as
+// made by the named policy.
+func WithPolicy(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, policyKey{}, name)
+}
+
+// withoutRecording returns a copy of ctx that changes made with are not
+// recorded, for the writes of the sinks themselves.
+func withoutRecording(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipKey{}, true)
+}
+
+// Record records e, made with ctx, adding the time, and the run, enforcement,
+// and policy of ctx. Events are buffered, and written to the sink by Flush, or
+// once maxBuffered are buffered.
+func Record(ctx context.Context, e Event) {
+	if skip, _ := ctx.Value(skipKey{}).(bool); skip {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = timeNow()
+	}
+	e.RunID = enforceid.Run(ctx)
+	e.EnforcementID = enforceid.Evaluation(ctx)
+	if p, ok := ctx.Value(policyKey{}).(string); ok {
+		e.Policy = p
+	}
+	mu.Lock()
+	if sink == nil {
+		mu.Unlock()
+		return
+	}
+	buffered = append(buffered, e)
+	full := len(buffered) >= maxBuffered
+	mu.Unlock()
+	if full {
+		if err := Flush(context.WithoutCancel(ctx)); err != nil {
+			logFlushError(err)
+		}
+	}
+}
+
+func logFlushError(err error) {
+	log.Error().
+		Str("area", "audit").
+		Err(err).
+		Msg("Unexpected error writing audit log.")
+}
+
+// Flush writes the buffered events to the sink. Events that fail to be
+// written are kept, to be written by the next Flush.
+func Flush(ctx context.Context) error {
+	flushMu.Lock()
+	defer flushMu.Unlock()
+	mu.Lock()
+	s := sink
+	events := buffered
+	buffered = nil
+	mu.Unlock()
+	if s == nil || len(events) == 0 {
+		return nil
+	}
+	if err := s.Write(withoutRecording(ctx), events); err != nil {
+		mu.Lock()
+		buffered = append(events, buffered...)
+		dropped := len(buffered) - maxRetained
+		if dropped > 0 {
+			buffered = buffered[dropped:]
+			err = fmt.Errorf("%w, dropped %d events", err, dropped)
+		}
+		mu.Unlock()
+		return fmt.Errorf("audit: writing %d events: %w", len(events), err)
+	}
+	return nil
+}
+
+// Close flushes the buffered events, and closes the sink.
+func Close(ctx context.Context) error {
+	err := Flush(ctx)
+	mu.Lock()
+	s := sink
+	sink = nil
+	mu.Unlock()
+	if s == nil {
+		return err
+	}
+	if cerr := s.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Summarize returns a summary of a JSON request payload, its top-level fields
+// with values truncated to maxValueLen, so that the audit log shows what was
+// changed without copying whole issue bodies or file contents. Payloads that
+// are not JSON objects are summarized by their size.
+func Summarize(body []byte) map[string]string {
+	if len(body) == 0 {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return map[string]string{"": fmt.Sprintf("(%d bytes)", len(body))}
+	}
+	summary := make(map[string]string, len(fields))
+	for k, raw := range fields {
+		v := string(raw)
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			v = s
+		}
+		if len(v) > maxValueLen {
+			v = fmt.Sprintf("%s... (%d bytes)", v[:maxValueLen], len(v))
+		}
+		summary[k] = v
+	}
+	return summary
+}
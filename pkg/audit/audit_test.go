@@ -0,0 +1,252 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ossf/allstar/pkg/enforceid"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type memSink struct {
+	mu      sync.Mutex
+	events  []Event
+	err     error
+	skipped bool
+	closed  bool
+}
+
+func (m *memSink) Write(ctx context.Context, events []Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if skip, _ := ctx.Value(skipKey{}).(bool); skip {
+		m.skipped = true
+	}
+	if m.err != nil {
+		return m.err
+	}
+	m.events = append(m.events, events...)
+	return nil
+}
+
+func (m *memSink) Close() error {
+	m.closed = true
+	return nil
+}
+
+func reset(t *testing.T, s Sink) {
+	t.Helper()
+	SetSink(s)
+	mu.Lock()
+	buffered = nil
+	mu.Unlock()
+	now := time.Date(2025, 1, 31, 15, 4, 5, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() {
+		SetSink(nil)
+		mu.Lock()
+		buffered = nil
+		mu.Unlock()
+		timeNow = time.Now
+	})
+}
+
+func TestRecord(t *testing.T) {
+	m := &memSink{}
+	reset(t, m)
+	ctx := enforceid.WithEvaluation(enforceid.WithRun(context.Background()))
+	Record(WithPolicy(ctx, "Branch Protection"), Event{
+		Org:    "acme",
+		Repo:   "app",
+		Method: "PUT",
+		Path:   "/repos/acme/app/branches/main/protection",
+		Status: 200,
+	})
+	Record(withoutRecording(ctx), Event{Method: "PUT", Path: "/repos/acme/audit/contents/a"})
+	if len(m.events) != 0 {
+		t.Errorf("Expected events to be buffered until Flush, got %d written", len(m.events))
+	}
+	if err := Flush(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []Event{{
+		Time:          timeNow(),
+		RunID:         enforceid.Run(ctx),
+		EnforcementID: enforceid.Evaluation(ctx),
+		Org:           "acme",
+		Repo:          "app",
+		Policy:        "Branch Protection",
+		Method:        "PUT",
+		Path:          "/repos/acme/app/branches/main/protection",
+		Status:        200,
+	}}
+	if diff := cmp.Diff(want, m.events); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+	if !m.skipped {
+		t.Errorf("Expected the sink to write with recording disabled")
+	}
+}
+
+func TestRecordDisabled(t *testing.T) {
+	reset(t, nil)
+	if Enabled() {
+		t.Errorf("Expected recording to be disabled without a sink")
+	}
+	Record(context.Background(), Event{Method: "POST"})
+	if len(buffered) != 0 {
+		t.Errorf("Expected nothing buffered without a sink, got %d", len(buffered))
+	}
+}
+
+func TestRecordFlushesWhenFull(t *testing.T) {
+	m := &memSink{}
+	reset(t, m)
+	for i := 0; i < maxBuffered; i++ {
+		Record(context.Background(), Event{Method: "POST"})
+	}
+	if len(m.events) != maxBuffered {
+		t.Errorf("Expected %d events written, got %d", maxBuffered, len(m.events))
+	}
+}
+
+func TestFlushRetains(t *testing.T) {
+	m := &memSink{err: errors.New("unavailable")}
+	reset(t, m)
+	for i := 0; i < maxRetained+10; i++ {
+		Record(context.Background(), Event{Method: "POST", Path: "/" + string(rune('a'+i%26))})
+	}
+	err := Flush(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "unavailable") {
+		t.Fatalf("Expected write error, got: %v", err)
+	}
+	if len(buffered) != maxRetained {
+		t.Errorf("Expected %d events retained, got %d", maxRetained, len(buffered))
+	}
+	m.err = nil
+	if err := Close(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(m.events) != maxRetained {
+		t.Errorf("Expected %d events written, got %d", maxRetained, len(m.events))
+	}
+	if !m.closed {
+		t.Errorf("Expected sink to be closed")
+	}
+	if Enabled() {
+		t.Errorf("Expected recording to be disabled after Close")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	long := strings.Repeat("x", maxValueLen+20)
+	tests := []struct {
+		Name string
+		Body string
+		Exp  map[string]string
+	}{
+		{
+			Name: "Empty",
+		},
+		{
+			Name: "Fields",
+			Body: `{"title":"Security Policy not enabled","labels":["allstar"],"required_signatures":{"enabled":true}}`,
+			Exp: map[string]string{
+				"title":               "Security Policy not enabled",
+				"labels":              `["allstar"]`,
+				"required_signatures": `{"enabled":true}`,
+			},
+		},
+		{
+			Name: "Truncated",
+			Body: `{"body":"` + long + `"}`,
+			Exp: map[string]string{
+				"body": strings.Repeat("x", maxValueLen) + "... (120 bytes)",
+			},
+		},
+		{
+			Name: "NotObject",
+			Body: `["a","b"]`,
+			Exp:  map[string]string{"": "(9 bytes)"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got := Summarize([]byte(test.Body))
+			if diff := cmp.Diff(test.Exp, got); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOpen(t *testing.T) {
+	ctx := context.Background()
+	if _, err := Open(ctx, "nope://x"); err == nil || !strings.Contains(err.Error(), "unknown scheme") {
+		t.Errorf("Expected unknown scheme error, got: %v", err)
+	}
+	if _, err := Open(ctx, "audit.jsonl"); err == nil {
+		t.Errorf("Expected error for URL without scheme")
+	}
+}
+
+func TestFile(t *testing.T) {
+	ctx := context.Background()
+	p := filepath.Join(t.TempDir(), "audit.jsonl")
+	events := []Event{
+		{Time: time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), Method: "POST", Path: "/repos/acme/app/issues", Status: 201},
+		{Time: time.Date(2025, 1, 31, 0, 0, 1, 0, time.UTC), Method: "PATCH", Path: "/repos/acme/app/issues/1", Error: "timeout"},
+	}
+	for _, e := range events {
+		s, err := Open(ctx, "file://"+p)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := s.Write(ctx, []Event{e}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := s.Close(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer f.Close()
+	var got []Event
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e Event
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		got = append(got, e)
+	}
+	if diff := cmp.Diff(events, got); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bucket is an object storage audit log sink. Importing it registers
+// the "gs" (Google Cloud Storage) and "s3" (Amazon S3) schemes, eg:
+// "gs://my-bucket?prefix=audit/". URLs are opened with gocloud.dev/blob, see
+// its documentation for the supported parameters.
+package bucket
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ossf/allstar/pkg/audit"
+	"github.com/ossf/allstar/pkg/enforceid"
+
+	"gocloud.dev/blob"
+	_ "gocloud.dev/blob/fileblob"
+	_ "gocloud.dev/blob/gcsblob"
+	_ "gocloud.dev/blob/s3blob"
+)
+
+func init() {
+	for _, scheme := range []string{"gs", "s3"} {
+		audit.Register(scheme, func(ctx context.Context, url string) (audit.Sink, error) {
+			return Open(ctx, url)
+		})
+	}
+}
+
+// Bucket is an audit.Sink writing each batch of events as a JSONL object,
+// named by the time of its first event, eg: "2025/01/31/150405-<id>.jsonl", as
+// objects can not be appended to.
+type Bucket struct {
+	b *blob.Bucket
+}
+
+// Open opens the bucket at url.
+func Open(ctx context.Context, url string) (*Bucket, error) {
+	b, err := blob.OpenBucket(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return &Bucket{b: b}, nil
+}
+
+// Write implements audit.Sink.
+func (b *Bucket) Write(ctx context.Context, events []audit.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	v, err := audit.MarshalLines(events)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("%s-%s.jsonl", events[0].Time.UTC().Format("2006/01/02/150405"), enforceid.New())
+	return b.b.WriteAll(ctx, key, v, &blob.WriterOptions{ContentType: "application/jsonl"})
+}
+
+// Close implements audit.Sink.
+func (b *Bucket) Close() error {
+	return b.b.Close()
+}
@@ -0,0 +1,62 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bucket
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ossf/allstar/pkg/audit"
+)
+
+func TestBucket(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	b, err := Open(ctx, "file://"+dir+"?metadata=skip")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer b.Close()
+	events := []audit.Event{
+		{Time: time.Date(2025, 1, 31, 15, 4, 5, 0, time.UTC), Method: "POST", Path: "/repos/acme/app/issues"},
+		{Time: time.Date(2025, 1, 31, 15, 4, 6, 0, time.UTC), Method: "PATCH", Path: "/repos/acme/app/issues/1"},
+	}
+	for _, e := range events {
+		if err := b.Write(ctx, []audit.Event{e}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := b.Write(ctx, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, "2025", "01", "31", "*.jsonl"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("Expected 2 objects, got %v", matches)
+	}
+	want, _ := audit.MarshalLines(events[:1])
+	got, err := os.ReadFile(matches[0])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("Unexpected object contents: %q, want %q", got, want)
+	}
+}
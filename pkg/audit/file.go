@@ -0,0 +1,72 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+)
+
+// File is a Sink appending events as JSON lines to a local file.
+type File struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+func openFile(ctx context.Context, url string) (Sink, error) {
+	return OpenFile(strings.TrimPrefix(url, "file://"))
+}
+
+// OpenFile opens, or creates, the JSONL file at path for appending.
+func OpenFile(path string) (*File, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &File{f: f}, nil
+}
+
+// Write implements Sink.
+func (f *File) Write(ctx context.Context, events []Event) error {
+	b, err := MarshalLines(events)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, err = f.f.Write(b)
+	return err
+}
+
+// Close implements Sink.
+func (f *File) Close() error {
+	return f.f.Close()
+}
+
+// MarshalLines encodes events as JSON lines.
+func MarshalLines(events []Event) ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return nil, err
+		}
+	}
+	return b.Bytes(), nil
+}
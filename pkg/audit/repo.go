@@ -0,0 +1,188 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v59/github"
+)
+
+// defaultRepoDir is the directory of the audit log files in the repository,
+// unless set with the "path" URL parameter.
+const defaultRepoDir = "audit"
+
+// maxRepoFileSize is the size after which a daily audit log file is continued
+// in a new part, as the contents API only returns files up to 1 MB.
+const maxRepoFileSize = 900 * 1024
+
+// Clients gets GitHub clients by installation ID, or 0 for the app, as
+// ghclients.GHClients does.
+type Clients interface {
+	Get(i int64) (*github.Client, error)
+}
+
+var clients Clients
+var clientsMu sync.Mutex
+
+// SetGitHubClients sets the clients used by the "github" sink, which must be
+// set before it is opened.
+func SetGitHubClients(c Clients) {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	clients = c
+}
+
+// Repo is a Sink appending events to daily JSONL files in a GitHub repository
+// of an Allstar installation, eg: "github://acme/allstar-audit?path=audit"
+// writes "audit/2025-01-31.jsonl" in acme/allstar-audit. Each Write is one
+// commit per day of the events written. Files over maxRepoFileSize are
+// continued in "2025-01-31.1.jsonl" and so on.
+type Repo struct {
+	mu    sync.Mutex
+	owner string
+	repo  string
+	dir   string
+	cs    Clients
+	c     *github.Client
+}
+
+func openRepo(ctx context.Context, url string) (Sink, error) {
+	u, err := neturl.Parse(url)
+	if err != nil {
+		return nil, err
+	}
+	repo := strings.Trim(u.Path, "/")
+	if u.Host == "" || repo == "" || strings.Contains(repo, "/") {
+		return nil, fmt.Errorf("audit: invalid URL %q, expected github://owner/repo", url)
+	}
+	dir := u.Query().Get("path")
+	if dir == "" {
+		dir = defaultRepoDir
+	}
+	clientsMu.Lock()
+	cs := clients
+	clientsMu.Unlock()
+	if cs == nil {
+		return nil, errors.New("audit: GitHub clients not set for the github sink")
+	}
+	return &Repo{
+		owner: u.Host,
+		repo:  repo,
+		dir:   dir,
+		cs:    cs,
+	}, nil
+}
+
+// client returns the client of the installation on the repository.
+func (r *Repo) client(ctx context.Context) (*github.Client, error) {
+	if r.c != nil {
+		return r.c, nil
+	}
+	ac, err := r.cs.Get(0)
+	if err != nil {
+		return nil, err
+	}
+	inst, _, err := ac.Apps.FindRepositoryInstallation(ctx, r.owner, r.repo)
+	if err != nil {
+		return nil, fmt.Errorf("finding the installation on %v/%v: %w", r.owner, r.repo, err)
+	}
+	c, err := r.cs.Get(inst.GetID())
+	if err != nil {
+		return nil, err
+	}
+	r.c = c
+	return c, nil
+}
+
+// Write implements Sink.
+func (r *Repo) Write(ctx context.Context, events []Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, err := r.client(ctx)
+	if err != nil {
+		return err
+	}
+	byDay := make(map[string][]Event)
+	for _, e := range events {
+		day := e.Time.UTC().Format("2006-01-02")
+		byDay[day] = append(byDay[day], e)
+	}
+	days := make([]string, 0, len(byDay))
+	for d := range byDay {
+		days = append(days, d)
+	}
+	sort.Strings(days)
+	for _, d := range days {
+		b, err := MarshalLines(byDay[d])
+		if err != nil {
+			return err
+		}
+		if err := r.appendDay(ctx, c, d, b, len(byDay[d])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendDay appends b, of n events, to the last part of the audit log file of
+// day that has room for it.
+func (r *Repo) appendDay(ctx context.Context, c *github.Client, day string, b []byte, n int) error {
+	msg := fmt.Sprintf("Allstar audit log: %d changes", n)
+	for part := 0; ; part++ {
+		name := day + ".jsonl"
+		if part > 0 {
+			name = fmt.Sprintf("%s.%d.jsonl", day, part)
+		}
+		p := path.Join(r.dir, name)
+		fc, _, rsp, err := c.Repositories.GetContents(ctx, r.owner, r.repo, p, nil)
+		if err != nil && (rsp == nil || rsp.StatusCode != http.StatusNotFound) {
+			return err
+		}
+		if err != nil {
+			_, _, err := c.Repositories.CreateFile(ctx, r.owner, r.repo, p, &github.RepositoryContentFileOptions{
+				Message: &msg,
+				Content: b,
+			})
+			return err
+		}
+		if fc.GetSize()+len(b) > maxRepoFileSize {
+			continue
+		}
+		content, err := fc.GetContent()
+		if err != nil {
+			return err
+		}
+		_, _, err = c.Repositories.UpdateFile(ctx, r.owner, r.repo, p, &github.RepositoryContentFileOptions{
+			Message: &msg,
+			Content: append([]byte(content), b...),
+			SHA:     fc.SHA,
+		})
+		return err
+	}
+}
+
+// Close implements Sink.
+func (r *Repo) Close() error {
+	return nil
+}
@@ -0,0 +1,177 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	neturl "net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+)
+
+type fakeClients struct {
+	c   *github.Client
+	ids []int64
+}
+
+func (f *fakeClients) Get(i int64) (*github.Client, error) {
+	f.ids = append(f.ids, i)
+	return f.c, nil
+}
+
+// fakeRepo serves the installation and contents APIs of acme/allstar-audit
+// from files.
+type fakeRepo struct {
+	files   map[string]string
+	commits []string
+}
+
+func (f *fakeRepo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/repos/acme/allstar-audit/installation" {
+		fmt.Fprint(w, `{"id": 7}`)
+		return
+	}
+	p, ok := strings.CutPrefix(r.URL.Path, "/repos/acme/allstar-audit/contents/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		content, ok := f.files[p]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(&github.RepositoryContent{
+			Type:     github.String("file"),
+			Encoding: github.String("base64"),
+			Size:     github.Int(len(content)),
+			Content:  github.String(base64.StdEncoding.EncodeToString([]byte(content))),
+			SHA:      github.String(fmt.Sprintf("sha%d", len(f.commits))),
+		})
+	case http.MethodPut:
+		var opts struct {
+			Message string `json:"message"`
+			Content []byte `json:"content"`
+			SHA     string `json:"sha"`
+		}
+		json.NewDecoder(r.Body).Decode(&opts)
+		if _, exists := f.files[p]; exists && opts.SHA == "" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		f.files[p] = string(opts.Content)
+		f.commits = append(f.commits, opts.Message)
+		fmt.Fprint(w, `{}`)
+	}
+}
+
+func TestRepo(t *testing.T) {
+	ctx := context.Background()
+	if _, err := openRepo(ctx, "github://acme/allstar-audit"); err == nil {
+		t.Errorf("Expected error opening without GitHub clients")
+	}
+	fr := &fakeRepo{files: make(map[string]string)}
+	srv := httptest.NewServer(fr)
+	defer srv.Close()
+	c := github.NewClient(nil)
+	c.BaseURL, _ = neturl.Parse(srv.URL + "/")
+	fc := &fakeClients{c: c}
+	SetGitHubClients(fc)
+	defer SetGitHubClients(nil)
+
+	for _, url := range []string{"github://acme", "github://acme/a/b"} {
+		if _, err := Open(ctx, url); err == nil {
+			t.Errorf("Expected error opening %q", url)
+		}
+	}
+	s, err := Open(ctx, "github://acme/allstar-audit?path=logs/audit")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	day1 := time.Date(2025, 1, 31, 23, 0, 0, 0, time.UTC)
+	day2 := day1.Add(2 * time.Hour)
+	e1 := Event{Time: day1, Method: "POST", Path: "/repos/acme/app/issues"}
+	e2 := Event{Time: day1, Method: "PATCH", Path: "/repos/acme/app/issues/1"}
+	e3 := Event{Time: day2, Method: "PUT", Path: "/repos/acme/app/branches/main/protection"}
+	if err := s.Write(ctx, []Event{e1}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := s.Write(ctx, []Event{e2, e3}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	lines := func(events ...Event) string {
+		b, err := MarshalLines(events)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return string(b)
+	}
+	want := map[string]string{
+		"logs/audit/2025-01-31.jsonl": lines(e1, e2),
+		"logs/audit/2025-02-01.jsonl": lines(e3),
+	}
+	if diff := cmp.Diff(want, fr.files); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]int64{0, 7}, fc.ids); diff != "" {
+		t.Errorf("Unexpected installation clients. (-want +got):\n%s", diff)
+	}
+	if len(fr.commits) != 3 {
+		t.Errorf("Expected 3 commits, got %v", fr.commits)
+	}
+}
+
+func TestRepoRotates(t *testing.T) {
+	ctx := context.Background()
+	full := strings.Repeat("x", maxRepoFileSize)
+	fr := &fakeRepo{files: map[string]string{"audit/2025-01-31.jsonl": full}}
+	srv := httptest.NewServer(fr)
+	defer srv.Close()
+	c := github.NewClient(nil)
+	c.BaseURL, _ = neturl.Parse(srv.URL + "/")
+	SetGitHubClients(&fakeClients{c: c})
+	defer SetGitHubClients(nil)
+
+	s, err := Open(ctx, "github://acme/allstar-audit")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	e := Event{Time: time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), Method: "POST"}
+	if err := s.Write(ctx, []Event{e}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	b, _ := MarshalLines([]Event{e})
+	want := map[string]string{
+		"audit/2025-01-31.jsonl":   full,
+		"audit/2025-01-31.1.jsonl": string(b),
+	}
+	if diff := cmp.Diff(want, fr.files); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cache provides thread-safe, size limited caches of compiled globs
+// and semver versions and constraints, for use by config and policies.
+package cache
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/gobwas/glob"
+)
+
+// DefaultSize is the default maximum number of entries held by a cache.
+const DefaultSize = 1000
+
+// Stats are counters describing cache usage.
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+}
+
+// lru is a least recently used store. It is not thread-safe, callers must
+// hold a lock.
+type lru[V any] struct {
+	max     int
+	order   *list.List
+	entries map[string]*list.Element
+	stats   Stats
+}
+
+type entry[V any] struct {
+	key   string
+	value V
+}
+
+func newLRU[V any](max int) *lru[V] {
+	if max <= 0 {
+		max = DefaultSize
+	}
+	return &lru[V]{
+		max:     max,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (l *lru[V]) get(k string) (V, bool) {
+	if e, ok := l.entries[k]; ok {
+		l.stats.Hits++
+		l.order.MoveToFront(e)
+		return e.Value.(*entry[V]).value, true
+	}
+	l.stats.Misses++
+	var zero V
+	return zero, false
+}
+
+func (l *lru[V]) add(k string, v V) {
+	if e, ok := l.entries[k]; ok {
+		e.Value.(*entry[V]).value = v
+		l.order.MoveToFront(e)
+		return
+	}
+	l.entries[k] = l.order.PushFront(&entry[V]{key: k, value: v})
+	for l.order.Len() > l.max {
+		e := l.order.Back()
+		l.order.Remove(e)
+		delete(l.entries, e.Value.(*entry[V]).key)
+		l.stats.Evictions++
+	}
+}
+
+func (l *lru[V]) getStats() Stats {
+	s := l.stats
+	s.Size = l.order.Len()
+	return s
+}
+
+// GlobCache is a cache for compiled globs.
+type GlobCache struct {
+	mu    sync.Mutex
+	globs *lru[glob.Glob]
+}
+
+// NewGlobCache returns a new GlobCache holding at most max globs. If max is
+// not positive, DefaultSize is used.
+func NewGlobCache(max int) *GlobCache {
+	return &GlobCache{
+		globs: newLRU[glob.Glob](max),
+	}
+}
+
+// Compile returns cached glob if present, otherwise attempts glob.Compile.
+func (c *GlobCache) Compile(s string) (glob.Glob, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if g, ok := c.globs.get(s); ok {
+		return g, nil
+	}
+	g, err := glob.Compile(s)
+	if err != nil {
+		return nil, err
+	}
+	c.globs.add(s, g)
+	return g, nil
+}
+
+// Stats returns the current usage counters.
+func (c *GlobCache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.globs.getStats()
+}
+
+// SemverCache is a cache for compiled versions and constraints.
+type SemverCache struct {
+	mu          sync.Mutex
+	versions    *lru[*semver.Version]
+	constraints *lru[*semver.Constraints]
+}
+
+// NewSemverCache returns a new SemverCache holding at most max versions and
+// max constraints. If max is not positive, DefaultSize is used.
+func NewSemverCache(max int) *SemverCache {
+	return &SemverCache{
+		versions:    newLRU[*semver.Version](max),
+		constraints: newLRU[*semver.Constraints](max),
+	}
+}
+
+// Version returns cached Version if present, otherwise attempts
+// semver.NewVersion.
+func (c *SemverCache) Version(s string) (*semver.Version, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v, ok := c.versions.get(s); ok {
+		return v, nil
+	}
+	v, err := semver.NewVersion(s)
+	if err != nil {
+		return nil, err
+	}
+	c.versions.add(s, v)
+	return v, nil
+}
+
+// Constraints returns cached Constraints if present, otherwise attempts
+// semver.NewConstraint.
+func (c *SemverCache) Constraints(s string) (*semver.Constraints, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v, ok := c.constraints.get(s); ok {
+		return v, nil
+	}
+	v, err := semver.NewConstraint(s)
+	if err != nil {
+		return nil, err
+	}
+	c.constraints.add(s, v)
+	return v, nil
+}
+
+// Stats returns the current usage counters, combined for versions and
+// constraints.
+func (c *SemverCache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v := c.versions.getStats()
+	n := c.constraints.getStats()
+	return Stats{
+		Hits:      v.Hits + n.Hits,
+		Misses:    v.Misses + n.Misses,
+		Evictions: v.Evictions + n.Evictions,
+		Size:      v.Size + n.Size,
+	}
+}
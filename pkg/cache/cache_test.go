@@ -0,0 +1,114 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGlobCache(t *testing.T) {
+	c := NewGlobCache(2)
+	g, err := c.Compile("foo-*")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !g.Match("foo-bar") {
+		t.Error("Expected glob to match")
+	}
+	if _, err := c.Compile("foo-*"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := c.Compile("bar-*"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Evicts "bar-*", as "foo-*" was used more recently.
+	if _, err := c.Compile("foo-*"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := c.Compile("baz-*"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := c.Compile("["); err == nil {
+		t.Error("Expected error compiling bad glob")
+	}
+	exp := Stats{
+		Hits:      2,
+		Misses:    4,
+		Evictions: 1,
+		Size:      2,
+	}
+	if diff := cmp.Diff(exp, c.Stats()); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+}
+
+func TestSemverCache(t *testing.T) {
+	c := NewSemverCache(0)
+	v, err := c.Version("v1.2.3")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	n, err := c.Constraints(">= 1.2")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !n.Check(v) {
+		t.Error("Expected constraint to be satisfied")
+	}
+	if _, err := c.Version("v1.2.3"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := c.Version("not-a-version"); err == nil {
+		t.Error("Expected error parsing bad version")
+	}
+	if _, err := c.Constraints("not a constraint"); err == nil {
+		t.Error("Expected error parsing bad constraint")
+	}
+	exp := Stats{
+		Hits:   1,
+		Misses: 4,
+		Size:   2,
+	}
+	if diff := cmp.Diff(exp, c.Stats()); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+}
+
+func TestGlobCacheConcurrent(t *testing.T) {
+	c := NewGlobCache(10)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Compile(fmt.Sprintf("repo-%d-*", i%20)); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	s := c.Stats()
+	if s.Size > 10 {
+		t.Errorf("Cache exceeded max size: %v", s.Size)
+	}
+	if s.Hits+s.Misses != 50 {
+		t.Errorf("Expected 50 lookups, got %v", s.Hits+s.Misses)
+	}
+}
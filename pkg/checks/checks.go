@@ -0,0 +1,261 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package checks implements the "check" action, which publishes policy
+// results as GitHub check runs on the head commit of the default branch, and
+// optionally of open pull requests.
+package checks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/operator"
+	"github.com/ossf/allstar/pkg/enforceid"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+const namePrefix = "Allstar: "
+
+const conclusionSuccess = "success"
+const conclusionFailure = "failure"
+
+// maxPullRequests is the maximum number of open pull requests, most recently
+// updated first, that results are published on.
+const maxPullRequests = 50
+
+// maxSummary is the maximum length of a check run output summary, as limited
+// by GitHub.
+const maxSummary = 65535
+
+var configGetAppConfigs func(context.Context, *github.Client, string, string) (*config.OrgConfig, *config.RepoConfig, *config.RepoConfig)
+var getHead func(context.Context, *github.Client, string, string) (string, error)
+var listPullHeads func(context.Context, *github.Client, string, string) ([]string, error)
+var listCheckRuns func(context.Context, *github.Client, string, string, string, string) ([]*github.CheckRun, error)
+var createCheckRun func(context.Context, *github.Client, string, string, github.CreateCheckRunOptions) error
+var updateCheckRun func(context.Context, *github.Client, string, string, int64, github.UpdateCheckRunOptions) error
+var timeNow func() time.Time
+
+func init() {
+	configGetAppConfigs = config.GetAppConfigs
+	getHead = getHeadReal
+	listPullHeads = listPullHeadsReal
+	listCheckRuns = listCheckRunsReal
+	createCheckRun = createCheckRunReal
+	updateCheckRun = updateCheckRunReal
+	timeNow = time.Now
+}
+
+// Update publishes the result of the policy on the provided repo as a check
+// run named "Allstar: <policy>". An existing check run on the same commit is
+// updated, and left as is if the result has not changed. The text is the
+// policy's notify text, and only used when the policy fails.
+func Update(ctx context.Context, c *github.Client, owner, repo, policy string, pass bool, text string) error {
+	oc, orc, rc := configGetAppConfigs(ctx, c, owner, repo)
+	cc := mergeChecksConfig(oc, orc, rc)
+
+	head, err := getHead(ctx, c, owner, repo)
+	if err != nil {
+		return err
+	}
+	var shas []string
+	if head != "" {
+		shas = append(shas, head)
+	}
+	if cc != nil && cc.PullRequests {
+		prs, err := listPullHeads(ctx, c, owner, repo)
+		if err != nil {
+			return err
+		}
+		shas = append(shas, prs...)
+	}
+	if len(shas) == 0 {
+		log.Info().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", policy).
+			Msg("Action set to check, but the repo has no commits.")
+		return nil
+	}
+
+	conclusion, output := checkOutput(ctx, policy, pass, text)
+	name := namePrefix + policy
+	done := make(map[string]bool)
+	for _, sha := range shas {
+		if done[sha] {
+			continue
+		}
+		done[sha] = true
+		if err := ensure(ctx, c, owner, repo, sha, name, conclusion, output); err != nil {
+			return fmt.Errorf("while publishing check run on %v: %w", sha, err)
+		}
+	}
+	return nil
+}
+
+// Resolve marks a failing check run of the policy on the head commit of the
+// default branch as passed. Unlike Update, no check run is created if there is
+// none, for results that are only published as check runs while failing.
+func Resolve(ctx context.Context, c *github.Client, owner, repo, policy string) error {
+	head, err := getHead(ctx, c, owner, repo)
+	if err != nil || head == "" {
+		return err
+	}
+	name := namePrefix + policy
+	runs, err := listCheckRuns(ctx, c, owner, repo, head, name)
+	if err != nil {
+		return err
+	}
+	if len(runs) == 0 || runs[0].GetConclusion() == conclusionSuccess {
+		return nil
+	}
+	conclusion, output := checkOutput(ctx, policy, true, "")
+	return ensure(ctx, c, owner, repo, head, name, conclusion, output)
+}
+
+// ensure creates or updates the named check run on sha.
+func ensure(ctx context.Context, c *github.Client, owner, repo, sha, name, conclusion string, output *github.CheckRunOutput) error {
+	runs, err := listCheckRuns(ctx, c, owner, repo, sha, name)
+	if err != nil {
+		return err
+	}
+	completed := &github.Timestamp{Time: timeNow()}
+	if len(runs) == 0 {
+		return createCheckRun(ctx, c, owner, repo, github.CreateCheckRunOptions{
+			Name:        name,
+			HeadSHA:     sha,
+			Status:      github.String("completed"),
+			Conclusion:  &conclusion,
+			CompletedAt: completed,
+			Output:      output,
+		})
+	}
+	// Runs are listed most recent first.
+	run := runs[0]
+	if run.GetConclusion() == conclusion &&
+		run.GetOutput().GetSummary() == output.GetSummary() {
+		return nil
+	}
+	return updateCheckRun(ctx, c, owner, repo, run.GetID(), github.UpdateCheckRunOptions{
+		Name:        name,
+		Status:      github.String("completed"),
+		Conclusion:  &conclusion,
+		CompletedAt: completed,
+		Output:      output,
+	})
+}
+
+// checkOutput returns the conclusion and output of a check run for the policy
+// result. The enforcement IDs are only in the output text, so that a new run
+// with the same result does not change the summary.
+func checkOutput(ctx context.Context, policy string, pass bool, text string) (string, *github.CheckRunOutput) {
+	conclusion := conclusionSuccess
+	title := fmt.Sprintf("%v policy passed", policy)
+	summary := fmt.Sprintf("This repository is in compliance with the %v policy.", policy)
+	if !pass {
+		conclusion = conclusionFailure
+		title = fmt.Sprintf("%v policy failed", policy)
+		summary = text
+		if len(summary) > maxSummary {
+			summary = summary[:maxSummary]
+		}
+	}
+	output := &github.CheckRunOutput{
+		Title:   &title,
+		Summary: &summary,
+	}
+	if id := enforceid.Evaluation(ctx); id != "" {
+		t := fmt.Sprintf("Allstar enforcement ID: %v", id)
+		output.Text = &t
+	}
+	return conclusion, output
+}
+
+func mergeChecksConfig(oc *config.OrgConfig, orc, rc *config.RepoConfig) *config.ChecksConfig {
+	cc := oc.Checks
+	if orc.Checks != nil {
+		cc = orc.Checks
+	}
+	if rc.Checks != nil && !oc.OptConfig.DisableRepoOverride {
+		cc = rc.Checks
+	}
+	return cc
+}
+
+// getHeadReal returns the head commit SHA of the default branch, or empty if
+// the repo is empty.
+func getHeadReal(ctx context.Context, c *github.Client, owner, repo string) (string, error) {
+	r, _, err := c.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return "", err
+	}
+	b, rsp, err := c.Repositories.GetBranch(ctx, owner, repo, r.GetDefaultBranch(), 1)
+	if err != nil {
+		if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+			return "", nil
+		}
+		return "", err
+	}
+	return b.GetCommit().GetSHA(), nil
+}
+
+// listPullHeadsReal returns the head commit SHAs of the most recently updated
+// open pull requests.
+func listPullHeadsReal(ctx context.Context, c *github.Client, owner, repo string) ([]string, error) {
+	prs, _, err := c.PullRequests.List(ctx, owner, repo, &github.PullRequestListOptions{
+		State:     "open",
+		Sort:      "updated",
+		Direction: "desc",
+		ListOptions: github.ListOptions{
+			PerPage: maxPullRequests,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	shas := make([]string, 0, len(prs))
+	for _, pr := range prs {
+		shas = append(shas, pr.GetHead().GetSHA())
+	}
+	return shas, nil
+}
+
+func listCheckRunsReal(ctx context.Context, c *github.Client, owner, repo, sha, name string) ([]*github.CheckRun, error) {
+	opts := &github.ListCheckRunsOptions{
+		CheckName: &name,
+	}
+	if operator.AppID != 0 {
+		opts.AppID = &operator.AppID
+	}
+	rs, _, err := c.Checks.ListCheckRunsForRef(ctx, owner, repo, sha, opts)
+	if err != nil {
+		return nil, err
+	}
+	return rs.CheckRuns, nil
+}
+
+func createCheckRunReal(ctx context.Context, c *github.Client, owner, repo string, opts github.CreateCheckRunOptions) error {
+	_, _, err := c.Checks.CreateCheckRun(ctx, owner, repo, opts)
+	return err
+}
+
+func updateCheckRunReal(ctx context.Context, c *github.Client, owner, repo string, id int64, opts github.UpdateCheckRunOptions) error {
+	_, _, err := c.Checks.UpdateCheckRun(ctx, owner, repo, id, opts)
+	return err
+}
@@ -0,0 +1,174 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/enforceid"
+)
+
+func TestUpdate(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	failing := &github.CheckRun{
+		ID:         github.Int64(1),
+		Conclusion: github.String("failure"),
+		Output: &github.CheckRunOutput{
+			Summary: github.String("Policy text"),
+		},
+	}
+	tests := []struct {
+		Name      string
+		Org       config.OrgConfig
+		Repo      config.RepoConfig
+		Head      string
+		Runs      map[string][]*github.CheckRun
+		Pass      bool
+		ExpCreate []string
+		ExpUpdate []int64
+	}{
+		{
+			Name:      "CreateFailure",
+			Head:      "abc",
+			Pass:      false,
+			ExpCreate: []string{"abc"},
+		},
+		{
+			Name:      "CreateSuccess",
+			Head:      "abc",
+			Pass:      true,
+			ExpCreate: []string{"abc"},
+		},
+		{
+			Name: "Unchanged",
+			Head: "abc",
+			Runs: map[string][]*github.CheckRun{
+				"abc": {failing},
+			},
+			Pass: false,
+		},
+		{
+			Name: "Update",
+			Head: "abc",
+			Runs: map[string][]*github.CheckRun{
+				"abc": {failing},
+			},
+			Pass:      true,
+			ExpUpdate: []int64{1},
+		},
+		{
+			Name: "EmptyRepo",
+			Head: "",
+			Pass: false,
+		},
+		{
+			Name: "PullRequests",
+			Org: config.OrgConfig{
+				Checks: &config.ChecksConfig{PullRequests: true},
+			},
+			Head: "abc",
+			Runs: map[string][]*github.CheckRun{
+				"abc": {failing},
+			},
+			Pass:      false,
+			ExpCreate: []string{"pr1", "pr2"},
+		},
+		{
+			Name: "PullRequestsRepoDisabled",
+			Org: config.OrgConfig{
+				Checks: &config.ChecksConfig{PullRequests: true},
+			},
+			Repo: config.RepoConfig{
+				Checks: &config.ChecksConfig{PullRequests: false},
+			},
+			Head:      "abc",
+			Pass:      false,
+			ExpCreate: []string{"abc"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configGetAppConfigs = func(context.Context, *github.Client, string, string) (*config.OrgConfig, *config.RepoConfig, *config.RepoConfig) {
+				return &test.Org, &config.RepoConfig{}, &test.Repo
+			}
+			getHead = func(context.Context, *github.Client, string, string) (string, error) {
+				return test.Head, nil
+			}
+			listPullHeads = func(context.Context, *github.Client, string, string) ([]string, error) {
+				return []string{"pr1", "abc", "pr2", "pr1"}, nil
+			}
+			listCheckRuns = func(ctx context.Context, c *github.Client, owner, repo, sha, name string) ([]*github.CheckRun, error) {
+				if name != "Allstar: thispolicy" {
+					t.Errorf("Unexpected check name: %v", name)
+				}
+				return test.Runs[sha], nil
+			}
+			var created []string
+			createCheckRun = func(ctx context.Context, c *github.Client, owner, repo string, opts github.CreateCheckRunOptions) error {
+				exp := "failure"
+				if test.Pass {
+					exp = "success"
+				}
+				if opts.GetConclusion() != exp {
+					t.Errorf("Unexpected conclusion: %v", opts.GetConclusion())
+				}
+				created = append(created, opts.HeadSHA)
+				return nil
+			}
+			var updated []int64
+			updateCheckRun = func(ctx context.Context, c *github.Client, owner, repo string, id int64, opts github.UpdateCheckRunOptions) error {
+				updated = append(updated, id)
+				return nil
+			}
+
+			text := ""
+			if !test.Pass {
+				text = "Policy text"
+			}
+			err := Update(context.Background(), nil, "thisorg", "thisrepo", "thispolicy", test.Pass, text)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.ExpCreate, created); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.ExpUpdate, updated); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCheckOutput(t *testing.T) {
+	ctx := enforceid.WithEvaluation(context.Background())
+	conclusion, output := checkOutput(ctx, "thispolicy", false, "Policy text")
+	if conclusion != "failure" {
+		t.Errorf("Unexpected conclusion: %v", conclusion)
+	}
+	exp := &github.CheckRunOutput{
+		Title:   github.String("thispolicy policy failed"),
+		Summary: github.String("Policy text"),
+		Text:    github.String("Allstar enforcement ID: " + enforceid.Evaluation(ctx)),
+	}
+	if diff := cmp.Diff(exp, output); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+}
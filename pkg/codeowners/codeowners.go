@@ -0,0 +1,55 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package codeowners parses CODEOWNERS files, see
+// https://docs.github.com/en/repositories/managing-your-repositorys-settings-and-features/customizing-your-repository/about-code-owners
+package codeowners
+
+import (
+	"strings"
+)
+
+// Paths are the locations GitHub reads CODEOWNERS from, in order.
+var Paths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// Rule is a line of a CODEOWNERS file.
+type Rule struct {
+	// Pattern is the pattern of the files the rule applies to, which follows
+	// gitignore rules.
+	Pattern string
+
+	// Owners are the users, teams, and emails assigned to the files, eg:
+	// "@user", "@org/team", "user@example.com". A rule without owners leaves
+	// the files without owners.
+	Owners []string
+}
+
+// Parse parses the rules of a CODEOWNERS file, in order. Only the last rule
+// matching a file applies.
+func Parse(content string) []Rule {
+	var rules []Rule
+	for _, l := range strings.Split(content, "\n") {
+		l, _, _ = strings.Cut(l, "#")
+		fs := strings.Fields(l)
+		if len(fs) == 0 {
+			continue
+		}
+		r := Rule{Pattern: fs[0]}
+		if len(fs) > 1 {
+			r.Owners = fs[1:]
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
@@ -0,0 +1,40 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse(t *testing.T) {
+	content := `# Default owners
+*                @thisorg/maintainers
+
+*.go             @gopher     # Go code
+build/           @builder someone@example.com
+vendor/
+`
+	exp := []Rule{
+		{Pattern: "*", Owners: []string{"@thisorg/maintainers"}},
+		{Pattern: "*.go", Owners: []string{"@gopher"}},
+		{Pattern: "build/", Owners: []string{"@builder", "someone@example.com"}},
+		{Pattern: "vendor/"},
+	}
+	if diff := cmp.Diff(exp, Parse(content)); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/ossf/allstar/pkg/ghclients"
+
+	"github.com/rs/zerolog/log"
+)
+
+// getOwnerRepositories returns the client of the app's installation on owner,
+// set with SetBaseClients.
+var getOwnerRepositories func(ctx context.Context, owner string) (repositories, error)
+
+// SetBaseClients configures fetching base configs hosted in another org with
+// the app's installation on that org, so that private base config repos can be
+// shared across orgs. Without it, or if the app is not installed on the org of
+// the base, the base is fetched with the installation of the org being
+// configured, which can only read public repos of other orgs.
+func SetBaseClients(ghc ghclients.GhClientsInterface) {
+	var mu sync.Mutex
+	ids := make(map[string]int64)
+	getOwnerRepositories = func(ctx context.Context, owner string) (repositories, error) {
+		key := strings.ToLower(owner)
+		mu.Lock()
+		id, ok := ids[key]
+		mu.Unlock()
+		if !ok {
+			ac, err := ghc.Get(0)
+			if err != nil {
+				return nil, err
+			}
+			inst, rsp, err := ac.Apps.FindOrganizationInstallation(ctx, owner)
+			if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+				inst, _, err = ac.Apps.FindUserInstallation(ctx, owner)
+			}
+			if err != nil {
+				return nil, err
+			}
+			id = inst.GetID()
+			mu.Lock()
+			ids[key] = id
+			mu.Unlock()
+		}
+		ic, err := ghc.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		return ic.Repositories, nil
+	}
+}
+
+// baseRepositories returns the client to fetch a base config hosted in the
+// repos of baseOwner, from a config of owner fetched with r.
+func baseRepositories(ctx context.Context, r repositories, owner, baseOwner string) repositories {
+	if getOwnerRepositories == nil || strings.EqualFold(owner, baseOwner) {
+		return r
+	}
+	br, err := getOwnerRepositories(ctx, baseOwner)
+	if err != nil {
+		log.Debug().
+			Str("org", owner).
+			Str("baseOrg", baseOwner).
+			Err(err).
+			Msg("No installation on org of baseConfig, fetching with the installation of the org.")
+		return r
+	}
+	return br
+}
@@ -22,8 +22,9 @@ import (
 	"path"
 	"strings"
 
-	"github.com/gobwas/glob"
+	"github.com/ossf/allstar/pkg/cache"
 	"github.com/ossf/allstar/pkg/config/operator"
+	"github.com/ossf/allstar/pkg/selector"
 
 	jsonpatch "github.com/evanphx/json-patch/v5"
 	"github.com/google/go-github/v59/github"
@@ -55,6 +56,13 @@ type OrgConfig struct {
 	// created issues from a previous setting.
 	IssueRepo string `json:"issueRepo"`
 
+	// IssueFallbackRepo is the name of a repository in the organization to
+	// create issues in for repositories that have issues disabled, when
+	// IssueRepo is not set. If left unset, the violation is published as a
+	// failing check run on the repository instead, which requires the Checks
+	// write permission.
+	IssueFallbackRepo string `json:"issueFallbackRepo"`
+
 	// IssueFooter is a custom message to add to the end of all Allstar created
 	// issues in the GitHub organization. It does not supercede the bot-level
 	// footer (found in pkg/config/operator) but is added in addition to that
@@ -63,8 +71,57 @@ type OrgConfig struct {
 	// policies.
 	IssueFooter string `json:"issueFooter"`
 
+	// Issues configures the assignees, additional labels, and milestone of
+	// issues created by Allstar in the organization.
+	Issues *IssueConfig `json:"issues"`
+
+	// SummaryIssue configures an org-level summary issue in IssueRepo, listing
+	// the repositories failing each policy.
+	SummaryIssue SummaryIssueConfig `json:"summaryIssue"`
+
 	// Schedule specifies whether to perform certain actions on specific days.
 	Schedule *ScheduleConfig `json:"schedule"`
+
+	// Notify configures where the "notify" action sends policy violations.
+	// Required for any policy configured with the "notify" action.
+	Notify *NotifyConfig `json:"notify"`
+
+	// Checks configures where the "check" action publishes policy results.
+	Checks *ChecksConfig `json:"checks"`
+
+	// PolicyIntervals overrides the operator configured minimum duration
+	// between scheduled runs of each policy in this organization. Keys are
+	// policy names and values are durations, eg: "Scorecard": "24h". Only
+	// applies to the continuous enforcement job, not single runs.
+	PolicyIntervals map[string]string `json:"policyIntervals"`
+
+	// GracePeriodDays is the number of days after a repository is created
+	// during which policy violations are only logged, as new repositories are
+	// often still being set up. No issues, notifications, or fixes are made for
+	// the repository until the grace period ends. Default 0, no grace period.
+	GracePeriodDays int `json:"gracePeriodDays"`
+
+	// GracePeriods sets a grace period for violations of each policy, keyed by
+	// policy name with durations as values, eg: "Branch Protection": "72h".
+	// When a policy first fails on a repository, the "issue" and "fix" actions
+	// are only taken once the violation has persisted for the grace period,
+	// other actions are taken immediately. Default none.
+	GracePeriods map[string]string `json:"gracePeriods"`
+
+	// Parameters are values shared across config files in this organization,
+	// eg: "runbookURL": "https://wiki.example.com/security". Any string value
+	// in an Allstar or policy config file, at any level, can reference a
+	// parameter as "${params.runbookURL}", including issue footers and
+	// templates.
+	Parameters map[string]string `json:"parameters"`
+
+	// RepoGroups defines named groups of repositories in this organization,
+	// selected by name, language, or topic. The org-level config file of each
+	// policy, and of Allstar itself, may set "groupOverrides", keyed by group
+	// name, with repo-level config applied to the repositories in that group.
+	// Group overrides are applied on top of the org-level config, and below the
+	// org-repo and repo-level config.
+	RepoGroups []*RepoGroup `json:"repoGroups"`
 }
 
 // OrgOptConfig is used in Allstar and policy-specific org-level config to
@@ -79,6 +136,14 @@ type OrgOptConfig struct {
 	// OptOutRepos is the list of repos to opt-out when in opt-out strategy.
 	OptOutRepos []string `json:"optOutRepos"`
 
+	// OptInRepoSelectors selects more repos to opt-in when in opt-in strategy,
+	// by name, language, or topic.
+	OptInRepoSelectors []*selector.RepoSelector `json:"optInRepoSelectors"`
+
+	// OptOutRepoSelectors selects more repos to opt-out when in opt-out
+	// strategy, by name, language, or topic.
+	OptOutRepoSelectors []*selector.RepoSelector `json:"optOutRepoSelectors"`
+
 	// OptOutPrivateRepos : set to true to not access private repos.
 	OptOutPrivateRepos bool `json:"optOutPrivateRepos"`
 
@@ -106,8 +171,18 @@ type RepoConfig struct {
 	// regardless of Optconfig.DisableRepoOverride.
 	IssueLabel string `json:"issueLabel"`
 
+	// Issues overrides the org-level issue config for this repository, only
+	// for the settings present.
+	Issues *IssueConfig `json:"issues"`
+
 	// Schedule specifies days during which to not send notifications,
 	Schedule *ScheduleConfig `json:"schedule"`
+
+	// Notify overrides the org-level notify config, only if present.
+	Notify *NotifyConfig `json:"notify"`
+
+	// Checks overrides the org-level checks config, only if present.
+	Checks *ChecksConfig `json:"checks"`
 }
 
 // RepoOptConfig is used in Allstar and policy-specific repo-level config to
@@ -132,7 +207,89 @@ type ScheduleConfig struct {
 	Days []string `json:"days"`
 }
 
-type globCache map[string]glob.Glob
+// IssueConfig is used to route issues created by the "issue" action to their
+// owners.
+type IssueConfig struct {
+	// Assignees are assigned to new issues. Each is a GitHub username, or a
+	// team as "org/team-slug", which is expanded to the team's members.
+	// Assignees must have access to the repository issues are created in.
+	Assignees []string `json:"assignees"`
+
+	// Labels are added to new issues, in addition to IssueLabel.
+	Labels []string `json:"labels"`
+
+	// Milestone is the title of an open milestone, in the repository issues
+	// are created in, to add new issues to.
+	Milestone string `json:"milestone"`
+
+	// Escalation escalates issues that are not resolved after a number of
+	// pings.
+	Escalation *EscalationConfig `json:"escalation"`
+
+	// Policies overrides the settings above for specific policies, keyed by
+	// policy name, eg: "Branch Protection". Only settings present are
+	// overridden.
+	Policies map[string]*IssueConfig `json:"policies"`
+}
+
+// EscalationConfig is used to escalate issues created by the "issue" action
+// that are still not resolved after a number of pings.
+type EscalationConfig struct {
+	// AfterPings is the number of pings, made each ping interval while the
+	// issue is open, after which the issue is escalated. Default 0, issues are
+	// not escalated.
+	AfterPings int `json:"afterPings"`
+
+	// Labels are added to the issue when escalated.
+	Labels []string `json:"labels"`
+
+	// Assignees are added to the issue when escalated, such as the security
+	// team. Each is a GitHub username, or a team as "org/team-slug".
+	Assignees []string `json:"assignees"`
+
+	// Notify sends the escalation to the notify endpoint configured in the
+	// org, see NotifyConfig.
+	Notify bool `json:"notify"`
+}
+
+// SummaryIssueConfig is used to configure the org-level summary issue, which
+// Allstar updates after each enforcement run.
+type SummaryIssueConfig struct {
+	// Enabled creates and maintains the summary issue. IssueRepo must also be
+	// set.
+	Enabled bool `json:"enabled"`
+
+	// DisableRepoIssues stops creating an issue for each repository and policy
+	// with the "issue" action, so that only the summary issue is used. Existing
+	// issues are still closed when the policy passes.
+	DisableRepoIssues bool `json:"disableRepoIssues"`
+}
+
+// NotifyConfig is used to configure the "notify" action, which POSTs policy
+// violations to a Slack incoming webhook or a generic HTTP endpoint.
+type NotifyConfig struct {
+	// Type is the kind of endpoint, either "slack" or "webhook". Default
+	// "webhook".
+	Type string `json:"type"`
+
+	// URL is the endpoint to POST to. For Slack, this is the incoming webhook
+	// URL.
+	URL string `json:"url"`
+
+	// Template is an optional Go text/template for the message. Available
+	// fields are .Owner, .Repo, .Policy, and .Text. The default includes all
+	// of them.
+	Template string `json:"template"`
+}
+
+// ChecksConfig is used to configure the "check" action, which publishes
+// policy results as GitHub check runs.
+type ChecksConfig struct {
+	// PullRequests also publishes results on the head commit of open pull
+	// requests, so that a required check can block merging. By default,
+	// results are only published on the head commit of the default branch.
+	PullRequests bool `json:"pullRequests"`
+}
 
 const githubConfRepo = ".github"
 
@@ -158,7 +315,9 @@ var walkGC func(context.Context, repositories, string, string, string,
 	*github.RepositoryContentGetOptions) (*github.RepositoryContent,
 	[]*github.RepositoryContent, *github.Response, error)
 
-var gc = globCache{}
+var gc = cache.NewGlobCache(cache.DefaultSize)
+
+var strictConfig = operator.StrictConfig
 
 func init() {
 	walkGC = walkGetContents
@@ -167,6 +326,8 @@ func init() {
 type repositories interface {
 	Get(context.Context, string, string) (*github.Repository,
 		*github.Response, error)
+	ListLanguages(context.Context, string, string) (map[string]int,
+		*github.Response, error)
 	GetContents(context.Context, string, string, string,
 		*github.RepositoryContentGetOptions) (*github.RepositoryContent,
 		[]*github.RepositoryContent, *github.Response, error)
@@ -178,22 +339,49 @@ func FetchConfig(ctx context.Context, c *github.Client, owner, repo, name string
 }
 
 func fetchConfig(ctx context.Context, r repositories, owner, repoIn, name string, cl ConfigLevel, out interface{}) error {
+	if cl == OrgRepoLevel {
+		applyGroupOverrides(ctx, r, owner, repoIn, name, out)
+	}
+	repo, p, conJSON, err := readConfig(ctx, r, owner, repoIn, name, cl, out)
+	if err != nil || conJSON == nil {
+		return err
+	}
+	if err := json.Unmarshal(conJSON, out); err != nil {
+		log.Warn().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("file", p).
+			Err(err).
+			Msg("Malformed config file, using defaults.")
+		if strictConfig {
+			setProblems(owner, repo, p, []Problem{{Repo: repo, Path: p, Err: err.Error()}})
+		}
+		return nil
+	}
+	return nil
+}
+
+// readConfig reads a yaml config file from github, merged with its base config
+// and with parameters expanded, and returns it as JSON along with the repo and
+// path it was read from. The JSON is nil if the file does not exist. If out is
+// not nil, the file is checked against out in strict mode.
+func readConfig(ctx context.Context, r repositories, owner, repoIn, name string, cl ConfigLevel, out interface{}) (string, string, []byte, error) {
 	il, err := getInstLoc(ctx, r, owner)
 	if err != nil {
-		return err
+		return "", "", nil, err
 	}
 	var repo string
 	var p string
 	switch cl {
 	case OrgLevel:
 		if !il.Exists {
-			return nil
+			return "", "", nil, nil
 		}
 		repo = il.Repo
 		p = path.Join(il.Path, name)
 	case OrgRepoLevel:
 		if !il.Exists {
-			return nil
+			return "", "", nil, nil
 		}
 		repo = il.Repo
 		p = path.Join(il.Path, repoIn, name)
@@ -204,45 +392,63 @@ func fetchConfig(ctx context.Context, r repositories, owner, repoIn, name string
 	cf, _, rsp, err := walkGC(ctx, r, owner, repo, p, nil)
 	if err != nil {
 		if rsp != nil && rsp.StatusCode == http.StatusNotFound {
-			return nil
+			if strictConfig && out != nil {
+				setProblems(owner, repo, p, nil)
+			}
+			return repo, p, nil, nil
 		}
-		return err
+		return repo, p, nil, err
 	}
 	con, err := cf.GetContent()
 	if err != nil {
-		return err
+		return repo, p, nil, err
 	}
 	conJSON, err := yaml.YAMLToJSON([]byte(con))
 	if err != nil {
-		return err
+		if strictConfig && out != nil {
+			setProblems(owner, repo, p, []Problem{{Repo: repo, Path: p, Err: err.Error()}})
+		}
+		return repo, p, nil, err
+	}
+	if strictConfig && out != nil {
+		var extra []string
+		if cl == OrgLevel {
+			extra = append(extra, "baseConfig", "groupOverrides")
+		}
+		checkUnknownFields(owner, repo, p, conJSON, out, extra...)
 	}
 	if cl == OrgLevel {
-		mergedJSON, err := checkAndMergeBase(ctx, r, p, conJSON)
+		mergedJSON, err := checkAndMergeBase(ctx, r, owner, repo, p, conJSON)
 		if err != nil {
-			return err
+			return repo, p, nil, err
 		}
 		conJSON = mergedJSON
 	}
-	if err := json.Unmarshal(conJSON, out); err != nil {
-		log.Warn().
-			Str("org", owner).
-			Str("repo", repo).
-			Str("file", p).
-			Err(err).
-			Msg("Malformed config file, using defaults.")
-		return nil
+	if paramRe.Match(conJSON) {
+		conJSON = expandParams(owner, p, conJSON, getParams(ctx, r, owner, name, cl, conJSON))
 	}
-	return nil
+	return repo, p, conJSON, nil
 }
 
 type anyWithBase struct {
 	BaseConfig *string `json:"baseConfig"`
 }
 
+// maxBaseDepth is the maximum number of base configs in a chain, a base
+// referencing a base, and so on.
+const maxBaseDepth = 5
+
 // checkAndMergeBase checks the contents for a field "baseConfig". If found
 // reads that as "org/repo" then pulls the same path from there and uses it as
-// a base config to merge this contents on top of. Returns JSON.
-func checkAndMergeBase(ctx context.Context, r repositories, path string, contents []byte) ([]byte, error) {
+// a base config to merge this contents on top of. The base may itself have a
+// base, up to maxBaseDepth, and bases already in the chain are ignored.
+// Returns JSON.
+func checkAndMergeBase(ctx context.Context, r repositories, owner, repo, path string, contents []byte) ([]byte, error) {
+	return mergeBase(ctx, r, owner, repo, path, contents, map[string]bool{})
+}
+
+func mergeBase(ctx context.Context, r repositories, owner, repo, path string, contents []byte, seen map[string]bool) ([]byte, error) {
+	seen[baseKey(owner, repo, path)] = true
 	var b anyWithBase
 	if err := json.Unmarshal(contents, &b); err != nil {
 		return nil, err
@@ -250,19 +456,39 @@ func checkAndMergeBase(ctx context.Context, r repositories, path string, content
 	if b.BaseConfig == nil {
 		return contents, nil
 	}
-	sp := strings.Split(*b.BaseConfig, "/")
-	if len(sp) != 2 {
+	bOwner, bRepo, bPath, ok := parseBaseConfig(*b.BaseConfig, path)
+	if !ok {
 		log.Warn().
 			Str("file", path).
 			Str("baseConfig", *b.BaseConfig).
-			Msg("Expect baseConfig to be a GitHub \"owner/repo\", ignoring.")
+			Msg("Expect baseConfig to be a GitHub \"owner/repo\" or \"owner/repo/dir\", ignoring.")
 		return contents, nil
 	}
-	cf, _, rsp, err := r.GetContents(ctx, sp[0], sp[1], path, nil)
+	if seen[baseKey(bOwner, bRepo, bPath)] {
+		log.Warn().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("file", path).
+			Str("baseConfig", *b.BaseConfig).
+			Msg("Cycle in baseConfig chain, ignoring base.")
+		return contents, nil
+	}
+	if len(seen) > maxBaseDepth {
+		log.Warn().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("file", path).
+			Str("baseConfig", *b.BaseConfig).
+			Int("maxDepth", maxBaseDepth).
+			Msg("Too many base configs in baseConfig chain, ignoring base.")
+		return contents, nil
+	}
+	br := baseRepositories(ctx, r, owner, bOwner)
+	cf, _, rsp, err := br.GetContents(ctx, bOwner, bRepo, bPath, nil)
 	if err != nil {
 		if rsp != nil && rsp.StatusCode == http.StatusNotFound {
 			log.Warn().
-				Str("file", path).
+				Str("file", bPath).
 				Str("baseConfig", *b.BaseConfig).
 				Msg("Path in specified baseConfig does not exist.")
 			return contents, nil
@@ -280,6 +506,10 @@ func checkAndMergeBase(ctx context.Context, r repositories, path string, content
 	if string(baseJSON) == "null" {
 		baseJSON = []byte("{}")
 	}
+	baseJSON, err = mergeBase(ctx, br, bOwner, bRepo, bPath, baseJSON, seen)
+	if err != nil {
+		return nil, err
+	}
 	mergedJSON, err := jsonpatch.MergePatch(baseJSON, contents)
 	if err != nil {
 		return nil, err
@@ -287,6 +517,28 @@ func checkAndMergeBase(ctx context.Context, r repositories, path string, content
 	return mergedJSON, nil
 }
 
+// parseBaseConfig parses a baseConfig of the form "owner/repo", for the file
+// at the same path in that repo, or "owner/repo/dir", for the file of the same
+// name in dir of that repo.
+func parseBaseConfig(base, p string) (owner, repo, file string, ok bool) {
+	sp := strings.SplitN(base, "/", 3)
+	if len(sp) < 2 || sp[0] == "" || sp[1] == "" {
+		return "", "", "", false
+	}
+	if len(sp) == 2 {
+		return sp[0], sp[1], p, true
+	}
+	dir := strings.Trim(sp[2], "/")
+	if dir == "" {
+		return "", "", "", false
+	}
+	return sp[0], sp[1], path.Join(dir, path.Base(p)), true
+}
+
+func baseKey(owner, repo, p string) string {
+	return strings.ToLower(path.Join(owner, repo, p))
+}
+
 // IsEnabled determines if a repo is enabled by interpreting the provided
 // org-level, org-repo-level, and repo-level OptConfigs.
 func IsEnabled(ctx context.Context, o OrgOptConfig, orc, r RepoOptConfig, c *github.Client, owner, repo string) (bool, error) {
@@ -301,9 +553,10 @@ func isEnabled(ctx context.Context, o OrgOptConfig, orc, r RepoOptConfig, rep re
 		return false, err
 	}
 
+	sr := selector.NewRepo(rep, owner, repo).WithRepository(gr)
 	if o.OptOutStrategy {
 		enabled = true
-		if matches(o.OptOutRepos, repo, gc) {
+		if matches(o.OptOutRepos, repo, gc) || matchesSelectors(ctx, o.OptOutRepoSelectors, sr) {
 			enabled = false
 		}
 		if o.OptOutPrivateRepos && gr.GetPrivate() {
@@ -326,7 +579,7 @@ func isEnabled(ctx context.Context, o OrgOptConfig, orc, r RepoOptConfig, rep re
 		}
 	} else {
 		enabled = false
-		if matches(o.OptInRepos, repo, gc) {
+		if matches(o.OptInRepos, repo, gc) || matchesSelectors(ctx, o.OptInRepoSelectors, sr) {
 			enabled = true
 		}
 		if orc.OptIn {
@@ -409,9 +662,28 @@ func getAppConfigs(ctx context.Context, r repositories, owner, repo string) (*Or
 	return oc, orc, rc
 }
 
-func matches(s []string, e string, gc globCache) bool {
+// GetOrgConfig gets the org-level Allstar configuration.
+func GetOrgConfig(ctx context.Context, c *github.Client, owner string) *OrgConfig {
+	return getOrgConfig(ctx, c.Repositories, owner)
+}
+
+func getOrgConfig(ctx context.Context, r repositories, owner string) *OrgConfig {
+	oc := &OrgConfig{}
+	if err := fetchConfig(ctx, r, owner, "", operator.AppConfigFile, OrgLevel, oc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("configLevel", "orgLevel").
+			Str("area", "bot").
+			Str("file", operator.AppConfigFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	return oc
+}
+
+func matches(s []string, e string, gc *cache.GlobCache) bool {
 	for _, v := range s {
-		g, err := gc.compileGlob(v)
+		g, err := gc.Compile(v)
 		if err != nil {
 			log.Warn().
 				Str("repo", e).
@@ -426,15 +698,14 @@ func matches(s []string, e string, gc globCache) bool {
 	return false
 }
 
-// compileGlob returns cached glob if present, otherwise attempts glob.Compile.
-func (g globCache) compileGlob(s string) (glob.Glob, error) {
-	if glob, ok := g[s]; ok {
-		return glob, nil
-	}
-	c, err := glob.Compile(s)
+func matchesSelectors(ctx context.Context, ss []*selector.RepoSelector, repo *selector.Repo) bool {
+	match, err := selector.MatchAny(ctx, ss, repo)
 	if err != nil {
-		return nil, err
+		log.Warn().
+			Str("org", repo.Owner).
+			Str("repo", repo.Name).
+			Err(err).
+			Msg("Unexpected error matching repo selectors.")
 	}
-	g[s] = c
-	return c, nil
+	return match
 }
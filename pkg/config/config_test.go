@@ -25,6 +25,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-github/v59/github"
 	"github.com/ossf/allstar/pkg/config/operator"
+	"github.com/ossf/allstar/pkg/selector"
 	"sigs.k8s.io/yaml"
 )
 
@@ -35,6 +36,9 @@ var getContents func(context.Context, string, string, string,
 var get func(context.Context, string, string) (*github.Repository,
 	*github.Response, error)
 
+var listLanguages func(context.Context, string, string) (map[string]int,
+	*github.Response, error)
+
 type mockRepos struct{}
 
 func (m mockRepos) GetContents(ctx context.Context, owner, repo, path string,
@@ -48,6 +52,11 @@ func (m mockRepos) Get(ctx context.Context, owner, repo string) (*github.Reposit
 	return get(ctx, owner, repo)
 }
 
+func (m mockRepos) ListLanguages(ctx context.Context, owner, repo string) (map[string]int,
+	*github.Response, error) {
+	return listLanguages(ctx, owner, repo)
+}
+
 func TestFetchConfig(t *testing.T) {
 	tests := []struct {
 		Name   string
@@ -446,6 +455,37 @@ func TestIsEnabled(t *testing.T) {
 			IsPrivateRepo: false,
 			Expect:        false,
 		},
+		{
+			Name: "OptInSelector",
+			Org: OrgOptConfig{
+				OptOutStrategy: false,
+				OptInRepoSelectors: []*selector.RepoSelector{
+					{Language: []string{"python"}},
+					{Language: []string{"go"}},
+				},
+			},
+			Expect: true,
+		},
+		{
+			Name: "OptOutSelector",
+			Org: OrgOptConfig{
+				OptOutStrategy: true,
+				OptOutRepoSelectors: []*selector.RepoSelector{
+					{Name: "this*", Exclude: []*selector.RepoSelector{{Language: []string{"python"}}}},
+				},
+			},
+			Expect: false,
+		},
+		{
+			Name: "OptOutSelectorExcluded",
+			Org: OrgOptConfig{
+				OptOutStrategy: true,
+				OptOutRepoSelectors: []*selector.RepoSelector{
+					{Name: "this*", Exclude: []*selector.RepoSelector{{Language: []string{"go"}}}},
+				},
+			},
+			Expect: true,
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.Name, func(t *testing.T) {
@@ -457,6 +497,10 @@ func TestIsEnabled(t *testing.T) {
 					Fork:     &test.IsForkedRepo,
 				}, nil, nil
 			}
+			listLanguages = func(context.Context, string, string) (map[string]int,
+				*github.Response, error) {
+				return map[string]int{"Go": 5000}, nil, nil
+			}
 			got, _ := isEnabled(context.Background(), test.Org, test.OrgRepo, test.Repo, mockRepos{}, "thisorg", "thisrepo")
 			if got != test.Expect {
 				t.Errorf("Unexpected results on %v. Expected: %v", test.Name, test.Expect)
@@ -754,7 +798,144 @@ foo: asdf
 			if err != nil {
 				t.Fatalf("Unexpected error: %v", err)
 			}
-			got, err := checkAndMergeBase(context.Background(), mockRepos{}, "path", conJSON)
+			got, err := checkAndMergeBase(context.Background(), mockRepos{}, "owner", "repo", "path", conJSON)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			gotYAML, err := yaml.JSONToYAML(got)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.Expect, string(gotYAML)); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+type mockBaseRepos map[string]string
+
+func (m mockBaseRepos) GetContents(ctx context.Context, owner, repo, path string,
+	opts *github.RepositoryContentGetOptions) (*github.RepositoryContent,
+	[]*github.RepositoryContent, *github.Response, error) {
+	c, ok := m[owner+"/"+repo+"/"+path]
+	if !ok {
+		return nil, nil, &github.Response{
+			Response: &http.Response{StatusCode: http.StatusNotFound},
+		}, errors.New("not found")
+	}
+	e := "base64"
+	c = base64.StdEncoding.EncodeToString([]byte(c))
+	return &github.RepositoryContent{
+		Encoding: &e,
+		Content:  &c,
+	}, nil, nil, nil
+}
+
+func (m mockBaseRepos) Get(ctx context.Context, owner, repo string) (*github.Repository,
+	*github.Response, error) {
+	return nil, nil, nil
+}
+
+func (m mockBaseRepos) ListLanguages(ctx context.Context, owner, repo string) (map[string]int,
+	*github.Response, error) {
+	return nil, nil, nil
+}
+
+func TestMergeChain(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Input  string
+		Files  mockBaseRepos
+		Expect string
+	}{
+		{
+			Name: "Chain",
+			Input: `
+baseConfig: org/base
+foo: asdf
+`,
+			Files: mockBaseRepos{
+				"org/base/path": `
+baseConfig: enterprise/base
+barBaz: qwer
+foo: foo
+`,
+				"enterprise/base/path": `
+barBaz: zxcv
+qux: uiop
+`,
+			},
+			Expect: `barBaz: qwer
+baseConfig: org/base
+foo: asdf
+qux: uiop
+`,
+		},
+		{
+			Name: "Dir",
+			Input: `
+baseConfig: enterprise/configs/allstar
+foo: asdf
+`,
+			Files: mockBaseRepos{
+				"enterprise/configs/allstar/path": `
+qux: uiop
+`,
+			},
+			Expect: `baseConfig: enterprise/configs/allstar
+foo: asdf
+qux: uiop
+`,
+		},
+		{
+			Name: "Cycle",
+			Input: `
+baseConfig: org/base
+foo: asdf
+`,
+			Files: mockBaseRepos{
+				"org/base/path": `
+baseConfig: Owner/Repo
+qux: uiop
+`,
+			},
+			Expect: `baseConfig: org/base
+foo: asdf
+qux: uiop
+`,
+		},
+		{
+			Name: "Depth",
+			Input: `
+baseConfig: org/b1
+b0: b0
+`,
+			Files: mockBaseRepos{
+				"org/b1/path": "baseConfig: org/b2\nb1: b1\n",
+				"org/b2/path": "baseConfig: org/b3\nb2: b2\n",
+				"org/b3/path": "baseConfig: org/b4\nb3: b3\n",
+				"org/b4/path": "baseConfig: org/b5\nb4: b4\n",
+				"org/b5/path": "baseConfig: org/b6\nb5: b5\n",
+				"org/b6/path": "b6: b6\n",
+			},
+			Expect: `b0: b0
+b1: b1
+b2: b2
+b3: b3
+b4: b4
+b5: b5
+baseConfig: org/b1
+`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			conJSON, err := yaml.YAMLToJSON([]byte(test.Input))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got, err := checkAndMergeBase(context.Background(), test.Files, "owner", "repo", "path", conJSON)
 			if err != nil {
 				t.Fatalf("Unexpected error: %v", err)
 			}
@@ -768,3 +949,44 @@ foo: asdf
 		})
 	}
 }
+
+func TestMergeCrossOrg(t *testing.T) {
+	t.Cleanup(func() { getOwnerRepositories = nil })
+	var gotOwners []string
+	getOwnerRepositories = func(ctx context.Context, owner string) (repositories, error) {
+		gotOwners = append(gotOwners, owner)
+		if owner == "missing" {
+			return nil, errors.New("not installed")
+		}
+		// Without an installation on missing, its base is fetched with the
+		// installation on enterprise.
+		return mockBaseRepos{
+			"enterprise/base/path": "baseConfig: missing/base\nqux: uiop\n",
+			"missing/base/path":    "public: true\n",
+		}, nil
+	}
+	r := mockBaseRepos{}
+	conJSON, err := yaml.YAMLToJSON([]byte("baseConfig: enterprise/base\nfoo: asdf\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got, err := checkAndMergeBase(context.Background(), r, "owner", "repo", "path", conJSON)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	gotYAML, err := yaml.JSONToYAML(got)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expect := `baseConfig: enterprise/base
+foo: asdf
+public: true
+qux: uiop
+`
+	if diff := cmp.Diff(expect, string(gotYAML)); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"enterprise", "missing"}, gotOwners); diff != "" {
+		t.Errorf("Unexpected owners. (-want +got):\n%s", diff)
+	}
+}
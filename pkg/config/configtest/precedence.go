@@ -0,0 +1,280 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package configtest generates config precedence tests for policies. Given a
+// policy's OrgConfig and RepoConfig types, TestPrecedence checks every config
+// field with the same cases as the hand-written precedence tables of the
+// policies: org-only, org-level repo override, repo override, and repo
+// override disallowed by the org. A field added to a config type without
+// precedence handling in mergeConfig fails the test.
+//
+// Fields are matched by name: each RepoConfig field must have an OrgConfig
+// field or a merged config field of the same name, and each OrgConfig field
+// must have a merged config field of the same name. Fields that are merged
+// differently, such as lists of repos, are listed in Precedence.Ignore.
+package configtest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/ossf/allstar/pkg/config"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+)
+
+// FetchConfig is the signature of config.FetchConfig, which policies mock
+// through their configFetchConfig package variable.
+type FetchConfig = func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+
+// Precedence describes the config of a policy under test.
+type Precedence struct {
+	// Org is the zero value of the policy's OrgConfig type.
+	Org interface{}
+
+	// Repo is the zero value of the policy's RepoConfig type.
+	Repo interface{}
+
+	// Fetch is the policy's configFetchConfig variable, replaced with a mock
+	// for the duration of the test.
+	Fetch *FetchConfig
+
+	// Merge returns the merged config of repo "thisrepo", by calling the
+	// policy's getConfig and mergeConfig.
+	Merge func() interface{}
+
+	// Append are the names of list fields where repo-level values are added
+	// to the org-level values, instead of replacing them.
+	Append []string
+
+	// Ignore are the names of config fields not checked, as they are not
+	// merged by name.
+	Ignore []string
+}
+
+// Repo is the repo name the merged config is requested for.
+const Repo = "thisrepo"
+
+// optConfig is the name of the opt in/out config field, which is not merged.
+const optConfig = "OptConfig"
+
+type precedenceCase struct {
+	name       string
+	org        interface{}
+	orgRepo    interface{}
+	repo       interface{}
+	disallowed bool
+	exp        interface{}
+}
+
+// TestPrecedence runs the generated precedence tests for each field of
+// p.Org and p.Repo.
+func TestPrecedence(t *testing.T, p Precedence) {
+	t.Helper()
+	orig := *p.Fetch
+	defer func() { *p.Fetch = orig }()
+	*p.Fetch = func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error {
+		return nil
+	}
+
+	orgType := reflect.TypeOf(p.Org)
+	repoType := reflect.TypeOf(p.Repo)
+	mergedType := reflect.Indirect(reflect.ValueOf(p.Merge())).Type()
+	ignore := make(map[string]bool)
+	for _, n := range p.Ignore {
+		ignore[n] = true
+	}
+	appends := make(map[string]bool)
+	for _, n := range p.Append {
+		appends[n] = true
+	}
+
+	checked := make(map[string]bool)
+	for i := 0; i < repoType.NumField(); i++ {
+		rf := repoType.Field(i)
+		if !rf.IsExported() || rf.Name == optConfig || ignore[rf.Name] {
+			continue
+		}
+		checked[rf.Name] = true
+		mf, ok := mergedType.FieldByName(rf.Name)
+		if !ok {
+			t.Errorf("RepoConfig field %v has no merged config field, add it to mergeConfig or Ignore", rf.Name)
+			continue
+		}
+		// Overrides are pointers, or lists and maps, which are nil unless set.
+		vt := rf.Type
+		switch rf.Type.Kind() {
+		case reflect.Pointer:
+			vt = rf.Type.Elem()
+		case reflect.Slice, reflect.Map:
+		default:
+			t.Errorf("RepoConfig field %v is not a pointer, list, or map, overrides can not be detected", rf.Name)
+			continue
+		}
+		v1 := generate(vt, 1)
+		v2 := generate(vt, 2)
+		var cases []precedenceCase
+		if of, ok := orgType.FieldByName(rf.Name); ok {
+			if of.Type != vt {
+				t.Errorf("OrgConfig field %v is %v, not %v", rf.Name, of.Type, vt)
+				continue
+			}
+			exp := v2
+			if appends[rf.Name] {
+				exp = reflect.AppendSlice(reflect.ValueOf(v1), reflect.ValueOf(v2)).Interface()
+			}
+			cases = append(cases,
+				precedenceCase{name: "OrgOnly", org: v1, exp: v1},
+				precedenceCase{name: "OrgRepoOverOrg", org: v1, orgRepo: v2, exp: exp},
+				precedenceCase{name: "RepoOverOrg", org: v1, repo: v2, exp: exp},
+				precedenceCase{name: "RepoDisallowed", org: v1, repo: v2, disallowed: true, exp: v1},
+			)
+		} else {
+			cases = append(cases,
+				precedenceCase{name: "OrgRepo", orgRepo: v1, exp: v1},
+				precedenceCase{name: "RepoOverOrgRepo", orgRepo: v1, repo: v2, exp: v2},
+				precedenceCase{name: "RepoDisallowed", orgRepo: v1, repo: v2, disallowed: true, exp: v1},
+			)
+		}
+		for _, c := range cases {
+			t.Run(fmt.Sprintf("%v/%v", rf.Name, c.name), func(t *testing.T) {
+				runCase(t, p, rf.Name, mf, c)
+			})
+		}
+	}
+
+	for i := 0; i < orgType.NumField(); i++ {
+		of := orgType.Field(i)
+		if !of.IsExported() || of.Name == optConfig || ignore[of.Name] || checked[of.Name] {
+			continue
+		}
+		mf, ok := mergedType.FieldByName(of.Name)
+		if !ok {
+			// Org-only settings, such as the list of repos to enforce on,
+			// may be used directly from OrgConfig.
+			continue
+		}
+		v1 := generate(of.Type, 1)
+		t.Run(fmt.Sprintf("%v/OrgOnly", of.Name), func(t *testing.T) {
+			runCase(t, p, of.Name, mf, precedenceCase{org: v1, exp: v1})
+		})
+	}
+}
+
+func runCase(t *testing.T, p Precedence, field string, mf reflect.StructField, c precedenceCase) {
+	t.Helper()
+	oc := reflect.New(reflect.TypeOf(p.Org)).Elem()
+	orc := reflect.New(reflect.TypeOf(p.Repo)).Elem()
+	rc := reflect.New(reflect.TypeOf(p.Repo)).Elem()
+	if c.org != nil {
+		oc.FieldByName(field).Set(reflect.ValueOf(c.org))
+	}
+	if c.orgRepo != nil {
+		setOverride(orc.FieldByName(field), c.orgRepo)
+	}
+	if c.repo != nil {
+		setOverride(rc.FieldByName(field), c.repo)
+	}
+	if c.disallowed {
+		oo := oc.FieldByName(optConfig)
+		if !oo.IsValid() {
+			t.Fatalf("OrgConfig has no %v field", optConfig)
+		}
+		oo.FieldByName("DisableRepoOverride").SetBool(true)
+	}
+
+	*p.Fetch = func(ctx context.Context, gc *github.Client, owner, repo, path string,
+		ol config.ConfigLevel, out interface{}) error {
+		var v reflect.Value
+		switch ol {
+		case config.OrgLevel:
+			v = oc
+		case config.OrgRepoLevel:
+			v = orc
+		case config.RepoLevel:
+			v = rc
+		default:
+			return nil
+		}
+		reflect.ValueOf(out).Elem().Set(v)
+		return nil
+	}
+
+	got := reflect.Indirect(reflect.ValueOf(p.Merge())).FieldByIndex(mf.Index)
+	exp := reflect.ValueOf(c.exp)
+	if exp.Type() != mf.Type {
+		if !exp.Type().ConvertibleTo(mf.Type) {
+			t.Fatalf("Merged config field %v is %v, not %v", field, mf.Type, exp.Type())
+		}
+		exp = exp.Convert(mf.Type)
+	}
+	if diff := cmp.Diff(exp.Interface(), got.Interface()); diff != "" {
+		t.Errorf("Unexpected merged config field %v. (-want +got):\n%s", field, diff)
+	}
+}
+
+// setOverride sets the override field f of a RepoConfig to v.
+func setOverride(f reflect.Value, v interface{}) {
+	if f.Kind() != reflect.Pointer {
+		f.Set(reflect.ValueOf(v))
+		return
+	}
+	ptr := reflect.New(f.Type().Elem())
+	ptr.Elem().Set(reflect.ValueOf(v))
+	f.Set(ptr)
+}
+
+// generate returns a value of type t, which differs for each n and from the
+// zero value, except for bools, where 1 is true and 2 is false.
+func generate(t reflect.Type, n int) interface{} {
+	return generateValue(t, n, 0).Interface()
+}
+
+func generateValue(t reflect.Type, n, depth int) reflect.Value {
+	v := reflect.New(t).Elem()
+	if depth > 4 {
+		return v
+	}
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(fmt.Sprintf("value%d", n))
+	case reflect.Bool:
+		v.SetBool(n == 1)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(int64(n))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(float64(n))
+	case reflect.Pointer:
+		v.Set(reflect.New(t.Elem()))
+		v.Elem().Set(generateValue(t.Elem(), n, depth+1))
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(t, 1, 1))
+		v.Index(0).Set(generateValue(t.Elem(), n, depth+1))
+	case reflect.Map:
+		v.Set(reflect.MakeMap(t))
+		v.SetMapIndex(generateValue(t.Key(), n, depth+1), generateValue(t.Elem(), n, depth+1))
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).IsExported() {
+				v.Field(i).Set(generateValue(t.Field(i).Type, n, depth+1))
+			}
+		}
+	}
+	return v
+}
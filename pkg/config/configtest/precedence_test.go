@@ -0,0 +1,132 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configtest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ossf/allstar/pkg/config"
+
+	"github.com/google/go-github/v59/github"
+)
+
+type orgConfig struct {
+	OptConfig config.OrgOptConfig
+	Action    string
+	Paths     []string
+	Globs     []string
+	Interval  time.Duration
+}
+
+type repoConfig struct {
+	OptConfig config.RepoOptConfig
+	Action    *string
+	Paths     *[]string
+	Globs     []string
+	Exempt    *bool
+}
+
+type mergedConfig struct {
+	Action   string
+	Paths    []string
+	Globs    []string
+	Interval time.Duration
+	Exempt   bool
+}
+
+var fetch FetchConfig
+
+func getConfig(ctx context.Context) (*orgConfig, *repoConfig, *repoConfig) {
+	oc := &orgConfig{}
+	orc := &repoConfig{}
+	rc := &repoConfig{}
+	_ = fetch(ctx, nil, "", "", "", config.OrgLevel, oc)
+	_ = fetch(ctx, nil, "", Repo, "", config.OrgRepoLevel, orc)
+	_ = fetch(ctx, nil, "", Repo, "", config.RepoLevel, rc)
+	return oc, orc, rc
+}
+
+func mergeConfig(oc *orgConfig, orc, rc *repoConfig) *mergedConfig {
+	mc := &mergedConfig{
+		Action:   oc.Action,
+		Paths:    oc.Paths,
+		Globs:    append([]string{}, oc.Globs...),
+		Interval: oc.Interval,
+	}
+	mc = mergeInRepoConfig(mc, orc)
+	if !oc.OptConfig.DisableRepoOverride {
+		mc = mergeInRepoConfig(mc, rc)
+	}
+	return mc
+}
+
+func mergeInRepoConfig(mc *mergedConfig, rc *repoConfig) *mergedConfig {
+	if rc.Action != nil {
+		mc.Action = *rc.Action
+	}
+	if rc.Paths != nil {
+		mc.Paths = *rc.Paths
+	}
+	mc.Globs = append(mc.Globs, rc.Globs...)
+	if rc.Exempt != nil {
+		mc.Exempt = *rc.Exempt
+	}
+	return mc
+}
+
+func TestTestPrecedence(t *testing.T) {
+	fetch = func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error {
+		t.Fatal("Unexpected call to unmocked fetch")
+		return nil
+	}
+	TestPrecedence(t, Precedence{
+		Org:   orgConfig{},
+		Repo:  repoConfig{},
+		Fetch: &fetch,
+		Merge: func() interface{} {
+			oc, orc, rc := getConfig(context.Background())
+			return mergeConfig(oc, orc, rc)
+		},
+		Append: []string{"Globs"},
+	})
+}
+
+func TestGenerate(t *testing.T) {
+	types := []interface{}{
+		"",
+		false,
+		0,
+		time.Duration(0),
+		[]string{},
+		map[string]int{},
+		&struct{ Name string }{},
+	}
+	for _, v := range types {
+		typ := reflect.TypeOf(v)
+		t.Run(typ.String(), func(t *testing.T) {
+			v1 := generate(typ, 1)
+			v2 := generate(typ, 2)
+			if reflect.DeepEqual(v1, v2) {
+				t.Errorf("Expected different values, got %v", v1)
+			}
+			if reflect.ValueOf(v1).IsZero() {
+				t.Errorf("Expected a non-zero value, got %v", v1)
+			}
+		})
+	}
+}
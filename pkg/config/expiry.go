@@ -0,0 +1,64 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// ExpiryWarning is how long before an exemption expires that policies warn
+// about it.
+const ExpiryWarning = 14 * 24 * time.Hour
+
+// ExpiryFormat is the format of exemption expiry dates, eg: "2025-09-01".
+const ExpiryFormat = "2006-01-02"
+
+// ExpiryStatus is the state of a time-bound exemption.
+type ExpiryStatus int
+
+const (
+	// ExpiryNone is an exemption without an expiry date.
+	ExpiryNone ExpiryStatus = iota
+	// ExpiryActive is an exemption that does not expire soon.
+	ExpiryActive
+	// ExpirySoon is an exemption that expires within ExpiryWarning.
+	ExpirySoon
+	// ExpiryLapsed is an exemption that has expired, and is not applied.
+	ExpiryLapsed
+)
+
+// CheckExpiry returns the status at now of an exemption expiring on the date
+// expires, in ExpiryFormat. Exemptions apply through the end of the expiry
+// date, in UTC. An invalid date is returned as an error with ExpiryLapsed, so
+// that a mistyped date does not exempt forever.
+func CheckExpiry(expires string, now time.Time) (ExpiryStatus, error) {
+	if expires == "" {
+		return ExpiryNone, nil
+	}
+	d, err := time.Parse(ExpiryFormat, expires)
+	if err != nil {
+		return ExpiryLapsed, fmt.Errorf("invalid expiry date %q, expected YYYY-MM-DD: %w", expires, err)
+	}
+	end := d.Add(24 * time.Hour)
+	switch {
+	case !now.Before(end):
+		return ExpiryLapsed, nil
+	case !now.Before(end.Add(-ExpiryWarning)):
+		return ExpirySoon, nil
+	default:
+		return ExpiryActive, nil
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckExpiry(t *testing.T) {
+	now := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		Name     string
+		Expires  string
+		Exp      ExpiryStatus
+		ExpError bool
+	}{
+		{
+			Name: "None",
+			Exp:  ExpiryNone,
+		},
+		{
+			Name:    "Active",
+			Expires: "2025-12-31",
+			Exp:     ExpiryActive,
+		},
+		{
+			Name:    "Soon",
+			Expires: "2025-09-10",
+			Exp:     ExpirySoon,
+		},
+		{
+			Name:    "LastDay",
+			Expires: "2025-09-01",
+			Exp:     ExpirySoon,
+		},
+		{
+			Name:    "Lapsed",
+			Expires: "2025-08-31",
+			Exp:     ExpiryLapsed,
+		},
+		{
+			Name:     "Invalid",
+			Expires:  "09/01/2025",
+			Exp:      ExpiryLapsed,
+			ExpError: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got, err := CheckExpiry(test.Expires, now)
+			if (err != nil) != test.ExpError {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if got != test.Exp {
+				t.Errorf("Unexpected results. want %v, got %v", test.Exp, got)
+			}
+		})
+	}
+}
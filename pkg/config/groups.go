@@ -0,0 +1,95 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ossf/allstar/pkg/selector"
+
+	"github.com/rs/zerolog/log"
+)
+
+// RepoGroup is a named group of repositories in an organization, used to
+// apply overrides to the config of each policy for the repositories in the
+// group.
+type RepoGroup struct {
+	// Name is the name used to identify the group in "groupOverrides".
+	Name string `json:"name"`
+
+	// Repos is the set of RepoSelectors selecting the repositories in the
+	// group. A repository is in the group if it matches any selector.
+	Repos []*selector.RepoSelector `json:"repos"`
+}
+
+// Match returns whether repo is in the group.
+func (g *RepoGroup) Match(ctx context.Context, repo *selector.Repo) (bool, error) {
+	return selector.MatchAny(ctx, g.Repos, repo)
+}
+
+type withGroupOverrides struct {
+	GroupOverrides map[string]json.RawMessage `json:"groupOverrides"`
+}
+
+// applyGroupOverrides writes to out the "groupOverrides" in the org-level
+// config file name for each of the RepoGroups of the org that repo is in. The
+// groups are applied in the order they are defined, so a later group overrides
+// an earlier one.
+func applyGroupOverrides(ctx context.Context, r repositories, owner, repo, name string, out interface{}) {
+	// Errors are logged when the org-level config itself is fetched.
+	_, p, conJSON, err := readConfig(ctx, r, owner, "", name, OrgLevel, nil)
+	if err != nil || conJSON == nil {
+		return
+	}
+	var wg withGroupOverrides
+	if err := json.Unmarshal(conJSON, &wg); err != nil || len(wg.GroupOverrides) == 0 {
+		return
+	}
+	groups := getOrgConfig(ctx, r, owner).RepoGroups
+	if len(groups) == 0 {
+		return
+	}
+	sr := selector.NewRepo(r, owner, repo)
+	for _, g := range groups {
+		o, ok := wg.GroupOverrides[g.Name]
+		if !ok {
+			continue
+		}
+		match, err := g.Match(ctx, sr)
+		if err != nil {
+			log.Error().
+				Str("org", owner).
+				Str("repo", repo).
+				Str("file", p).
+				Str("group", g.Name).
+				Err(err).
+				Msg("Unexpected error matching repo group, not applying group override.")
+			continue
+		}
+		if !match {
+			continue
+		}
+		if err := json.Unmarshal(o, out); err != nil {
+			log.Warn().
+				Str("org", owner).
+				Str("repo", repo).
+				Str("file", p).
+				Str("group", g.Name).
+				Err(err).
+				Msg("Malformed group override, ignoring.")
+		}
+	}
+}
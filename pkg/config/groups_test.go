@@ -0,0 +1,139 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+)
+
+type groupsTestConfig struct {
+	Action   *string  `json:"action"`
+	Count    *int     `json:"count"`
+	Branches []string `json:"branches"`
+}
+
+func TestFetchConfigGroupOverrides(t *testing.T) {
+	files := map[string]string{
+		".allstar/allstar.yaml": `
+repoGroups:
+- name: critical
+  repos:
+  - topics: [critical]
+  - name: api-*
+- name: go
+  repos:
+  - language: [go]
+`,
+		".allstar/groups.yaml": `
+action: log
+groupOverrides:
+  critical:
+    action: issue
+    count: 2
+  go:
+    count: 3
+  other:
+    action: fix
+`,
+		".allstar/web/groups.yaml": `
+branches: [main]
+`,
+		".allstar/api-server/groups.yaml": `
+count: 4
+`,
+	}
+	repos := map[string]*github.Repository{
+		"web": {Topics: []string{"critical"}},
+	}
+	langs := map[string]map[string]int{
+		"api-server": {"Go": 10000},
+		"web":        {"TypeScript": 10000, "Go": 100},
+	}
+
+	get = func(ctx context.Context, owner, repo string) (*github.Repository,
+		*github.Response, error) {
+		r, ok := repos[repo]
+		if !ok {
+			return &github.Repository{}, nil, nil
+		}
+		r.Name = github.String(repo)
+		return r, nil, nil
+	}
+	listLanguages = func(ctx context.Context, owner, repo string) (map[string]int,
+		*github.Response, error) {
+		return langs[repo], nil, nil
+	}
+	walkGC = func(ctx context.Context, r repositories, owner, repo, path string,
+		opts *github.RepositoryContentGetOptions) (*github.RepositoryContent,
+		[]*github.RepositoryContent, *github.Response, error) {
+		f, ok := files[repo+"/"+path]
+		if !ok {
+			return nil, nil, &github.Response{
+				Response: &http.Response{StatusCode: http.StatusNotFound},
+			}, errors.New("Not found")
+		}
+		e := "base64"
+		c := base64.StdEncoding.EncodeToString([]byte(f))
+		return &github.RepositoryContent{
+			Encoding: &e,
+			Content:  &c,
+		}, nil, nil, nil
+	}
+
+	tests := []struct {
+		Repo   string
+		Expect *groupsTestConfig
+	}{
+		{
+			// In both groups, the go group applies last, and the org-repo file
+			// on top.
+			Repo: "api-server",
+			Expect: &groupsTestConfig{
+				Action: github.String("issue"),
+				Count:  github.Int(4),
+			},
+		},
+		{
+			Repo: "web",
+			Expect: &groupsTestConfig{
+				Action:   github.String("issue"),
+				Count:    github.Int(2),
+				Branches: []string{"main"},
+			},
+		},
+		{
+			Repo:   "docs",
+			Expect: &groupsTestConfig{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Repo, func(t *testing.T) {
+			got := &groupsTestConfig{}
+			if err := fetchConfig(context.Background(), mockRepos{}, "groupsorg", test.Repo, "groups.yaml", OrgRepoLevel, got); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.Expect, got); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
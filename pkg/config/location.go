@@ -20,6 +20,8 @@ import (
 	"sync"
 
 	"github.com/ossf/allstar/pkg/config/operator"
+
+	"github.com/google/go-github/v59/github"
 )
 
 type instLoc struct {
@@ -59,6 +61,21 @@ func getInstLoc(ctx context.Context, r repositories, owner string) (*instLoc, er
 	return il, nil
 }
 
+// GetOrgConfigLocation returns the repository holding the org-level config of
+// owner, and the directory within it, either ".allstar" at its root, or
+// ".github" in the "allstar" directory. The repository is empty if the org has
+// neither.
+func GetOrgConfigLocation(ctx context.Context, c *github.Client, owner string) (repo, dir string, err error) {
+	il, err := getInstLoc(ctx, c.Repositories, owner)
+	if err != nil {
+		return "", "", err
+	}
+	if !il.Exists {
+		return "", "", nil
+	}
+	return il.Repo, il.Path, nil
+}
+
 // Function ClearInstLoc clears any saved config locations for an org/installation
 func ClearInstLoc(owner string) {
 	mMutex.RLock()
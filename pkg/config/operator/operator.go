@@ -37,11 +37,29 @@ var PrivateKey string
 // KeySecret should be set to the name of a secret containing a private key for
 // the App. See:
 // https://docs.github.com/en/developers/apps/building-github-apps/authenticating-with-github-apps#generating-a-private-key
-// The secret is retrieved with gocloud.dev/runtimevar.
+// The secret backend is selected by the URL scheme, see ghclients.NewSecret,
+// eg: "gcpsecretmanager://", "awssecretsmanager://", "vault://", "file://" or
+// "env:".
 const setKeySecret = "gcpsecretmanager://projects/allstar-ossf/secrets/allstar-private-key?decoder=bytes"
 
 var KeySecret string
 
+// KeySecretTTL is how long the private key read from KeySecret is used before
+// it is read again, to pick up a rotated key. The key is also read again on
+// SIGHUP. Zero disables the TTL. Can be configured with the environment
+// variable KEY_SECRET_TTL as a duration, eg: "1h".
+const setKeySecretTTL = time.Hour
+
+var KeySecretTTL time.Duration
+
+// MaxInstallationClients is the maximum number of installation GitHub clients
+// kept for re-use, with their tokens. The least recently used are evicted
+// beyond it. Can be configured with the environment variable
+// ALLSTAR_MAX_INSTALLATION_CLIENTS.
+const setMaxInstallationClients = 1000
+
+var MaxInstallationClients int
+
 // GitHubEnterpriseUrl allows to configure the usage a GitHub enterprise instance
 var GitHubEnterpriseUrl string
 
@@ -92,6 +110,20 @@ Issue created by Allstar. See https://github.com/ossf/allstar/ for more informat
 // organizations and repos while restricting installation of the app
 var AllowedOrganizations []string
 
+// AllowedRepos are glob patterns of repositories, as "owner/repo", on which
+// this Allstar instance enforces policies, regardless of org config. Can be
+// configured with the environment variable ALLSTAR_ALLOWED_REPOS as a comma
+// separated list, eg: "acme/*,other/service-*". Default empty, all
+// repositories are allowed.
+var AllowedRepos []string
+
+// DeniedRepos are glob patterns of repositories, as "owner/repo", on which
+// this Allstar instance never enforces policies, regardless of org config,
+// eg: mirrors or archived forks. Takes precedence over AllowedRepos. Can be
+// configured with the environment variable ALLSTAR_DENIED_REPOS as a comma
+// separated list. Default empty.
+var DeniedRepos []string
+
 // NoticePingDuration is the duration (in hours) to wait between pinging notice actions,
 // such as updating a GitHub issue.
 const setNoticePingDurationHrs = (24 * time.Hour)
@@ -104,6 +136,213 @@ const setNumWorkers = 5
 
 var NumWorkers int
 
+// NumRepoWorkers is the number of repos within each organization/installation
+// the Allstar binary will enforce policies on concurrently. Can be configured
+// with the environment variable ALLSTAR_NUM_REPO_WORKERS.
+const setNumRepoWorkers = 4
+
+var NumRepoWorkers int
+
+// ShardCount is the number of Allstar replicas that installations are sharded
+// across, each enforcing policies only on the installations of its shard. Can
+// be configured with the environment variable ALLSTAR_SHARD_COUNT. Default 1,
+// not sharded.
+const setShardCount = 1
+
+var ShardCount int
+
+// ShardIndex is the shard of this replica, from 0 to ShardCount-1. Can be
+// configured with the environment variable ALLSTAR_SHARD_INDEX. Default 0.
+var ShardIndex int
+
+// LeaderLockURL is the lock backend used to elect a single replica, out of
+// several deployed for availability, to run the enforcement loop, eg:
+// "gs://my-bucket/allstar/leader" or "kubernetes://allstar/allstar-leader".
+// Other replicas serve the operator API and take over if the leader stops.
+// Can be configured with the environment variable ALLSTAR_LEADER_LOCK_URL.
+// Default empty, no election, the enforcement loop always runs.
+var LeaderLockURL string
+
+// LeaderLockTTL is how long the leader lock is held without being renewed,
+// the longest a standby replica waits to take over from a leader that
+// stopped. Can be configured with the environment variable
+// ALLSTAR_LEADER_LOCK_TTL as a duration. Default 30s.
+const setLeaderLockTTL = 30 * time.Second
+
+var LeaderLockTTL time.Duration
+
+// RateLimitReserve is the number of remaining GitHub API requests of an
+// installation below which Allstar will pause enforcing on that installation
+// until the rate limit resets. Can be configured with the environment variable
+// ALLSTAR_RATE_LIMIT_RESERVE.
+const setRateLimitReserve = 100
+
+var RateLimitReserve int
+
+// ChaosRate is the fraction, from 0 to 1, of GitHub API requests that fail
+// with a synthetic error, for resilience testing in staging. Never set this in
+// production. Can be configured with the environment variable
+// ALLSTAR_CHAOS_RATE. Default 0, disabled.
+var ChaosRate float64
+
+// ChaosFailures are the kinds of synthetic failures injected when ChaosRate is
+// set, any of: "ratelimit", "secondary", "403", "404", "timeout". Can be
+// configured with the environment variable ALLSTAR_CHAOS_FAILURES as a comma
+// separated list. Default all kinds.
+var ChaosFailures []string
+
+var allChaosFailures = []string{"ratelimit", "secondary", "403", "404", "timeout"}
+
+// PolicyIntervals is the minimum duration between runs of each policy, keyed
+// by policy name, when enforcing on a schedule. Policies not present run on
+// every enforcement cycle. Can be configured with the environment variable
+// ALLSTAR_POLICY_INTERVALS as a comma separated list of name=duration pairs,
+// eg: "Scorecard=24h,GitHub Actions=1h". Organizations may override these in
+// their allstar.yaml.
+var PolicyIntervals map[string]time.Duration
+
+// EnforceSchedule is the cron expression of the full enforcement sweeps, which
+// enforce policies on every repo of each installation, eg: "0 */6 * * *". The
+// five field standard format and descriptors such as "@hourly" or
+// "@every 30m" are supported. Can be configured with the environment variable
+// ALLSTAR_ENFORCE_SCHEDULE.
+const setEnforceSchedule = "*/5 * * * *"
+
+var EnforceSchedule string
+
+// IncrementalSchedule is the cron expression of the incremental enforcement
+// sweeps, which only enforce policies on the repos pushed to or updated since
+// the previous sweep of their installation. Can be configured with the
+// environment variable ALLSTAR_INCREMENTAL_SCHEDULE. Default empty, only full
+// sweeps are run.
+var IncrementalSchedule string
+
+// OrgEnforceSchedules overrides EnforceSchedule for the installations of the
+// keyed orgs. Can be configured with the environment variable
+// ALLSTAR_ORG_ENFORCE_SCHEDULES as a semicolon separated list of org=cron
+// pairs, eg: "acme=0 2 * * *;other=@hourly".
+var OrgEnforceSchedules map[string]string
+
+// OrgIncrementalSchedules overrides IncrementalSchedule for the installations
+// of the keyed orgs. Can be configured with the environment variable
+// ALLSTAR_ORG_INCREMENTAL_SCHEDULES, in the same format as
+// ALLSTAR_ORG_ENFORCE_SCHEDULES.
+var OrgIncrementalSchedules map[string]string
+
+// OperatorNotifyURL is the endpoint alerted of operator-level events, such as
+// an installation being suspended. Can be configured with the environment
+// variable ALLSTAR_OPERATOR_NOTIFY_URL. Default empty, alerts are only logged.
+var OperatorNotifyURL string
+
+// OperatorNotifyType is the kind of OperatorNotifyURL endpoint, either "slack"
+// or "webhook". Can be configured with the environment variable
+// ALLSTAR_OPERATOR_NOTIFY_TYPE. Default "webhook".
+var OperatorNotifyType string
+
+// StorageURL is the results storage backend that the result of each
+// enforcement run is saved to, eg: "sqlite:///var/lib/allstar/results.db". Can
+// be configured with the environment variable ALLSTAR_STORAGE_URL. Default
+// empty, results are not stored.
+var StorageURL string
+
+// StorageRetention is how long stored run results are kept before they are
+// pruned. Can be configured with the environment variable
+// ALLSTAR_STORAGE_RETENTION as a duration, eg: "168h".
+const setStorageRetention = 30 * 24 * time.Hour
+
+var StorageRetention time.Duration
+
+// StateURL is the state store backend that state is persisted to across
+// enforcement runs and restarts, eg: "gs://my-bucket?prefix=allstar/" or
+// "sqlite:///var/lib/allstar/state.db". Can be configured with the environment
+// variable ALLSTAR_STATE_URL. Default "mem://", state is kept in memory.
+const setStateURL = "mem://"
+
+var StateURL string
+
+// ResultCacheTTL is how long the result of a repo that passed all policies is
+// reused, without running the policies again, while the repo and the config
+// repos of its org are unchanged. The cache is saved to the state store. Can
+// be configured with the environment variable ALLSTAR_RESULT_CACHE_TTL as a
+// duration, eg: "6h". Default zero, policies always run.
+var ResultCacheTTL time.Duration
+
+// StrictConfig enables recording unknown fields, such as misspelled settings,
+// and parse errors when fetching config files. Problems are reported by the
+// Config Health policy. Can be configured with the environment variable
+// ALLSTAR_STRICT_CONFIG, where the value should be a string equivalent of a
+// bool, as accepted by strconv.ParseBool. Default false.
+var StrictConfig bool
+
+// APIAddr is the address, eg: ":8080", that the operator API listens on. The
+// API lets operators and org admins trigger an immediate enforcement on a
+// repo. Can be configured with the environment variable ALLSTAR_API_ADDR.
+// Default empty, the API is disabled.
+var APIAddr string
+
+// APITokens are the bearer tokens accepted by the operator API, keyed by the
+// name of the caller, which is recorded in the audit log of each request. Can
+// be configured with the environment variable ALLSTAR_API_TOKENS as a comma
+// separated list of name=token pairs, eg: "alice=s3cr3t,ci=t0k3n". The API
+// rejects all requests if no tokens are set.
+var APITokens map[string]string
+
+// APIRateLimit is the minimum duration between enforcements triggered through
+// the operator API on the same repo. Can be configured with the environment
+// variable ALLSTAR_API_RATE_LIMIT as a duration, eg: "5m".
+const setAPIRateLimit = time.Minute
+
+var APIRateLimit time.Duration
+
+// HealthAddr is the address, eg: ":8081", of an unauthenticated listener that
+// only serves the health, readiness, and status endpoints, for Kubernetes
+// probes. These are also served by the operator API. Can be configured with
+// the environment variable ALLSTAR_HEALTH_ADDR. Default empty, disabled.
+var HealthAddr string
+
+// MaxIssueBodySize is the maximum size, in bytes, of the issue bodies and
+// comments Allstar creates. Longer policy result text is truncated with a note
+// of how many lines were left out. Can be configured with the environment
+// variable ALLSTAR_MAX_ISSUE_BODY_SIZE, up to GitHubMaxIssueBodySize.
+const setMaxIssueBodySize = 60000
+
+// GitHubMaxIssueBodySize is GitHub's limit on the size of issue bodies and
+// comments.
+const GitHubMaxIssueBodySize = 65536
+
+var MaxIssueBodySize int
+
+// MaxSummaryRepos is the maximum number of failing repos listed for each
+// policy in the summary issue. Can be configured with the environment variable
+// ALLSTAR_MAX_SUMMARY_REPOS.
+const setMaxSummaryRepos = 100
+
+var MaxSummaryRepos int
+
+// IssueOverflowCheckRun enables publishing the full policy result text as a
+// check run on the default branch of the repo when it is truncated in an
+// issue, and linking to it from the issue. Requires the App to have the Checks
+// write permission. Can be configured with the environment variable
+// ALLSTAR_ISSUE_OVERFLOW_CHECK_RUN, where the value should be a string
+// equivalent of a bool, as accepted by strconv.ParseBool. Default false.
+var IssueOverflowCheckRun bool
+
+// RequireFixPermissions makes Allstar fail at startup when the GitHub App is
+// missing permissions only needed by policies with the "fix" action, eg:
+// administration:write for Branch Protection. Otherwise those are logged as a
+// warning, and the fixes fail when run. Missing permissions needed to check
+// policies always fail startup. Can be configured with the environment
+// variable ALLSTAR_REQUIRE_FIX_PERMISSIONS, where the value should be a string
+// equivalent of a bool, as accepted by strconv.ParseBool. Default false.
+var RequireFixPermissions bool
+
+// AuditURL is the audit log sink that the changes Allstar makes on GitHub are
+// recorded to, eg: "file:///var/log/allstar/audit.jsonl",
+// "gs://my-bucket?prefix=audit/", or "github://my-org/allstar-audit". Can be
+// configured with the environment variable ALLSTAR_AUDIT_URL. Default empty,
+// changes are not recorded.
+var AuditURL string
+
 var osGetenv func(string) string
 
 func init() {
@@ -128,6 +367,18 @@ func setVars() {
 	} else {
 		KeySecret = setKeySecret
 	}
+	kst, err := time.ParseDuration(osGetenv("KEY_SECRET_TTL"))
+	if err == nil && kst >= 0 {
+		KeySecretTTL = kst
+	} else {
+		KeySecretTTL = setKeySecretTTL
+	}
+	mic, err := strconv.Atoi(osGetenv("ALLSTAR_MAX_INSTALLATION_CLIENTS"))
+	if err == nil && mic > 0 {
+		MaxInstallationClients = mic
+	} else {
+		MaxInstallationClients = setMaxInstallationClients
+	}
 
 	GitHubEnterpriseUrl = osGetenv("ALLSTAR_GHE_URL")
 
@@ -158,6 +409,8 @@ func setVars() {
 
 	allowedOrgs := osGetenv("GITHUB_ALLOWED_ORGS")
 	AllowedOrganizations = strings.Split(allowedOrgs, ",")
+	AllowedRepos = parseList(osGetenv("ALLSTAR_ALLOWED_REPOS"))
+	DeniedRepos = parseList(osGetenv("ALLSTAR_DENIED_REPOS"))
 
 	nws := osGetenv("ALLSTAR_NUM_WORKERS")
 	nw, err := strconv.Atoi(nws)
@@ -166,4 +419,174 @@ func setVars() {
 	} else {
 		NumWorkers = setNumWorkers
 	}
+
+	nrws := osGetenv("ALLSTAR_NUM_REPO_WORKERS")
+	nrw, err := strconv.Atoi(nrws)
+	if err == nil && nrw > 0 {
+		NumRepoWorkers = nrw
+	} else {
+		NumRepoWorkers = setNumRepoWorkers
+	}
+
+	// An invalid shard is kept, to fail on start rather than enforce on
+	// the installations of other shards.
+	ShardCount = setShardCount
+	if scs := osGetenv("ALLSTAR_SHARD_COUNT"); scs != "" {
+		ShardCount, err = strconv.Atoi(scs)
+		if err != nil {
+			ShardCount = 0
+		}
+	}
+	ShardIndex = 0
+	if sis := osGetenv("ALLSTAR_SHARD_INDEX"); sis != "" {
+		ShardIndex, err = strconv.Atoi(sis)
+		if err != nil {
+			ShardIndex = -1
+		}
+	}
+
+	LeaderLockURL = osGetenv("ALLSTAR_LEADER_LOCK_URL")
+	llt, err := time.ParseDuration(osGetenv("ALLSTAR_LEADER_LOCK_TTL"))
+	if err == nil && llt > 0 {
+		LeaderLockTTL = llt
+	} else {
+		LeaderLockTTL = setLeaderLockTTL
+	}
+
+	rlrs := osGetenv("ALLSTAR_RATE_LIMIT_RESERVE")
+	rlr, err := strconv.Atoi(rlrs)
+	if err == nil {
+		RateLimitReserve = rlr
+	} else {
+		RateLimitReserve = setRateLimitReserve
+	}
+
+	chaosRate, err := strconv.ParseFloat(osGetenv("ALLSTAR_CHAOS_RATE"), 64)
+	if err == nil && chaosRate >= 0 && chaosRate <= 1 {
+		ChaosRate = chaosRate
+	} else {
+		ChaosRate = 0
+	}
+
+	ChaosFailures = allChaosFailures
+	if cfs := osGetenv("ALLSTAR_CHAOS_FAILURES"); cfs != "" {
+		ChaosFailures = strings.Split(cfs, ",")
+	}
+
+	PolicyIntervals = parsePolicyIntervals(osGetenv("ALLSTAR_POLICY_INTERVALS"))
+
+	EnforceSchedule = strings.TrimSpace(osGetenv("ALLSTAR_ENFORCE_SCHEDULE"))
+	if EnforceSchedule == "" {
+		EnforceSchedule = setEnforceSchedule
+	}
+	IncrementalSchedule = strings.TrimSpace(osGetenv("ALLSTAR_INCREMENTAL_SCHEDULE"))
+	OrgEnforceSchedules = parseOrgSchedules(osGetenv("ALLSTAR_ORG_ENFORCE_SCHEDULES"))
+	OrgIncrementalSchedules = parseOrgSchedules(osGetenv("ALLSTAR_ORG_INCREMENTAL_SCHEDULES"))
+
+	OperatorNotifyURL = osGetenv("ALLSTAR_OPERATOR_NOTIFY_URL")
+	OperatorNotifyType = osGetenv("ALLSTAR_OPERATOR_NOTIFY_TYPE")
+
+	StrictConfig, _ = strconv.ParseBool(osGetenv("ALLSTAR_STRICT_CONFIG"))
+
+	StorageURL = osGetenv("ALLSTAR_STORAGE_URL")
+	sr, err := time.ParseDuration(osGetenv("ALLSTAR_STORAGE_RETENTION"))
+	if err == nil && sr > 0 {
+		StorageRetention = sr
+	} else {
+		StorageRetention = setStorageRetention
+	}
+
+	rct, err := time.ParseDuration(osGetenv("ALLSTAR_RESULT_CACHE_TTL"))
+	if err == nil && rct > 0 {
+		ResultCacheTTL = rct
+	} else {
+		ResultCacheTTL = 0
+	}
+
+	StateURL = osGetenv("ALLSTAR_STATE_URL")
+	if StateURL == "" {
+		StateURL = setStateURL
+	}
+
+	APIAddr = osGetenv("ALLSTAR_API_ADDR")
+	HealthAddr = osGetenv("ALLSTAR_HEALTH_ADDR")
+	APITokens = parseAPITokens(osGetenv("ALLSTAR_API_TOKENS"))
+	arl, err := time.ParseDuration(osGetenv("ALLSTAR_API_RATE_LIMIT"))
+	if err == nil && arl >= 0 {
+		APIRateLimit = arl
+	} else {
+		APIRateLimit = setAPIRateLimit
+	}
+
+	mibs, err := strconv.Atoi(osGetenv("ALLSTAR_MAX_ISSUE_BODY_SIZE"))
+	if err == nil && mibs > 0 && mibs <= GitHubMaxIssueBodySize {
+		MaxIssueBodySize = mibs
+	} else {
+		MaxIssueBodySize = setMaxIssueBodySize
+	}
+	msr, err := strconv.Atoi(osGetenv("ALLSTAR_MAX_SUMMARY_REPOS"))
+	if err == nil && msr > 0 {
+		MaxSummaryRepos = msr
+	} else {
+		MaxSummaryRepos = setMaxSummaryRepos
+	}
+	IssueOverflowCheckRun, _ = strconv.ParseBool(osGetenv("ALLSTAR_ISSUE_OVERFLOW_CHECK_RUN"))
+	RequireFixPermissions, _ = strconv.ParseBool(osGetenv("ALLSTAR_REQUIRE_FIX_PERMISSIONS"))
+	AuditURL = osGetenv("ALLSTAR_AUDIT_URL")
+}
+
+func parseList(s string) []string {
+	var l []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			l = append(l, v)
+		}
+	}
+	return l
+}
+
+func parseAPITokens(s string) map[string]string {
+	tokens := make(map[string]string)
+	for _, kv := range strings.Split(s, ",") {
+		name, token, ok := strings.Cut(kv, "=")
+		name = strings.TrimSpace(name)
+		token = strings.TrimSpace(token)
+		if !ok || name == "" || token == "" {
+			continue
+		}
+		tokens[name] = token
+	}
+	return tokens
+}
+
+func parsePolicyIntervals(s string) map[string]time.Duration {
+	pi := make(map[string]time.Duration)
+	for _, kv := range strings.Split(s, ",") {
+		name, ds, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(ds))
+		if err != nil {
+			continue
+		}
+		pi[strings.TrimSpace(name)] = d
+	}
+	return pi
+}
+
+// parseOrgSchedules parses org=cron pairs separated by semicolons, as cron
+// expressions may contain commas. Org names are lowercased.
+func parseOrgSchedules(s string) map[string]string {
+	schedules := make(map[string]string)
+	for _, kv := range strings.Split(s, ";") {
+		org, spec, ok := strings.Cut(kv, "=")
+		org = strings.ToLower(strings.TrimSpace(org))
+		spec = strings.TrimSpace(spec)
+		if !ok || org == "" || spec == "" {
+			continue
+		}
+		schedules[org] = spec
+	}
+	return schedules
 }
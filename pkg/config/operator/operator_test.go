@@ -214,3 +214,679 @@ func TestSetVars(t *testing.T) {
 		})
 	}
 }
+
+func TestParsePolicyIntervals(t *testing.T) {
+	tests := []struct {
+		Name string
+		In   string
+		Exp  map[string]time.Duration
+	}{
+		{
+			Name: "Empty",
+			In:   "",
+			Exp:  map[string]time.Duration{},
+		},
+		{
+			Name: "Multiple",
+			In:   "Scorecard=24h, GitHub Actions=1h",
+			Exp: map[string]time.Duration{
+				"Scorecard":      24 * time.Hour,
+				"GitHub Actions": time.Hour,
+			},
+		},
+		{
+			Name: "SkipMalformed",
+			In:   "Scorecard=daily,Branch Protection,Admin=30m",
+			Exp: map[string]time.Duration{
+				"Admin": 30 * time.Minute,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			if diff := cmp.Diff(test.Exp, parsePolicyIntervals(test.In)); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSetRepoWorkersAndReserve(t *testing.T) {
+	tests := []struct {
+		Name                string
+		NumRepoWorkers      string
+		RateLimitReserve    string
+		ExpNumRepoWorkers   int
+		ExpRateLimitReserve int
+	}{
+		{
+			Name:                "Defaults",
+			ExpNumRepoWorkers:   setNumRepoWorkers,
+			ExpRateLimitReserve: setRateLimitReserve,
+		},
+		{
+			Name:                "Set",
+			NumRepoWorkers:      "10",
+			RateLimitReserve:    "500",
+			ExpNumRepoWorkers:   10,
+			ExpRateLimitReserve: 500,
+		},
+		{
+			Name:                "ZeroWorkers",
+			NumRepoWorkers:      "0",
+			RateLimitReserve:    "0",
+			ExpNumRepoWorkers:   setNumRepoWorkers,
+			ExpRateLimitReserve: 0,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			osGetenv = func(in string) string {
+				if in == "ALLSTAR_NUM_REPO_WORKERS" {
+					return test.NumRepoWorkers
+				}
+				if in == "ALLSTAR_RATE_LIMIT_RESERVE" {
+					return test.RateLimitReserve
+				}
+				return ""
+			}
+			setVars()
+			if diff := cmp.Diff(test.ExpNumRepoWorkers, NumRepoWorkers); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.ExpRateLimitReserve, RateLimitReserve); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSetChaos(t *testing.T) {
+	tests := []struct {
+		Name             string
+		ChaosRate        string
+		ChaosFailures    string
+		ExpChaosRate     float64
+		ExpChaosFailures []string
+	}{
+		{
+			Name:             "Defaults",
+			ExpChaosRate:     0,
+			ExpChaosFailures: allChaosFailures,
+		},
+		{
+			Name:             "Set",
+			ChaosRate:        "0.25",
+			ChaosFailures:    "404,timeout",
+			ExpChaosRate:     0.25,
+			ExpChaosFailures: []string{"404", "timeout"},
+		},
+		{
+			Name:             "OutOfRange",
+			ChaosRate:        "2",
+			ExpChaosRate:     0,
+			ExpChaosFailures: allChaosFailures,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			osGetenv = func(in string) string {
+				if in == "ALLSTAR_CHAOS_RATE" {
+					return test.ChaosRate
+				}
+				if in == "ALLSTAR_CHAOS_FAILURES" {
+					return test.ChaosFailures
+				}
+				return ""
+			}
+			setVars()
+			if diff := cmp.Diff(test.ExpChaosRate, ChaosRate); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.ExpChaosFailures, ChaosFailures); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSetOperatorNotify(t *testing.T) {
+	osGetenv = func(in string) string {
+		switch in {
+		case "ALLSTAR_OPERATOR_NOTIFY_URL":
+			return "https://example.com/hook"
+		case "ALLSTAR_OPERATOR_NOTIFY_TYPE":
+			return "slack"
+		}
+		return ""
+	}
+	setVars()
+	if diff := cmp.Diff("https://example.com/hook", OperatorNotifyURL); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("slack", OperatorNotifyType); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+}
+
+func TestSetStorage(t *testing.T) {
+	tests := []struct {
+		Name         string
+		URL          string
+		Retention    string
+		ExpURL       string
+		ExpRetention time.Duration
+	}{
+		{
+			Name:         "Defaults",
+			ExpRetention: setStorageRetention,
+		},
+		{
+			Name:         "Set",
+			URL:          "sqlite:///tmp/results.db",
+			Retention:    "168h",
+			ExpURL:       "sqlite:///tmp/results.db",
+			ExpRetention: 168 * time.Hour,
+		},
+		{
+			Name:         "InvalidRetention",
+			Retention:    "-1h",
+			ExpRetention: setStorageRetention,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			osGetenv = func(in string) string {
+				switch in {
+				case "ALLSTAR_STORAGE_URL":
+					return test.URL
+				case "ALLSTAR_STORAGE_RETENTION":
+					return test.Retention
+				}
+				return ""
+			}
+			setVars()
+			if diff := cmp.Diff(test.ExpURL, StorageURL); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.ExpRetention, StorageRetention); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSetStrictConfig(t *testing.T) {
+	osGetenv = func(in string) string {
+		if in == "ALLSTAR_STRICT_CONFIG" {
+			return "true"
+		}
+		return ""
+	}
+	setVars()
+	if !StrictConfig {
+		t.Errorf("Expected StrictConfig to be set")
+	}
+	osGetenv = func(in string) string {
+		return ""
+	}
+	setVars()
+	if StrictConfig {
+		t.Errorf("Expected StrictConfig to default to false")
+	}
+}
+
+func TestSetRequireFixPermissions(t *testing.T) {
+	osGetenv = func(in string) string {
+		if in == "ALLSTAR_REQUIRE_FIX_PERMISSIONS" {
+			return "true"
+		}
+		return ""
+	}
+	setVars()
+	if !RequireFixPermissions {
+		t.Errorf("Expected RequireFixPermissions to be set")
+	}
+	osGetenv = func(in string) string {
+		return ""
+	}
+	setVars()
+	if RequireFixPermissions {
+		t.Errorf("Expected RequireFixPermissions to default to false")
+	}
+}
+
+func TestSetAuditURL(t *testing.T) {
+	osGetenv = func(in string) string {
+		if in == "ALLSTAR_AUDIT_URL" {
+			return "github://acme/allstar-audit"
+		}
+		return ""
+	}
+	setVars()
+	if AuditURL != "github://acme/allstar-audit" {
+		t.Errorf("Unexpected AuditURL: %q", AuditURL)
+	}
+	osGetenv = func(in string) string {
+		return ""
+	}
+	setVars()
+	if AuditURL != "" {
+		t.Errorf("Expected AuditURL to default to empty, got %q", AuditURL)
+	}
+}
+
+func TestSetAPI(t *testing.T) {
+	tests := []struct {
+		Name         string
+		Addr         string
+		HealthAddr   string
+		Tokens       string
+		RateLimit    string
+		ExpAddr      string
+		ExpHealth    string
+		ExpTokens    map[string]string
+		ExpRateLimit time.Duration
+	}{
+		{
+			Name:         "Defaults",
+			ExpTokens:    map[string]string{},
+			ExpRateLimit: setAPIRateLimit,
+		},
+		{
+			Name:       "Set",
+			Addr:       ":8080",
+			HealthAddr: ":8081",
+			Tokens:     "alice=s3cr3t, ci = t0k3n",
+			RateLimit:  "5m",
+			ExpAddr:    ":8080",
+			ExpHealth:  ":8081",
+			ExpTokens: map[string]string{
+				"alice": "s3cr3t",
+				"ci":    "t0k3n",
+			},
+			ExpRateLimit: 5 * time.Minute,
+		},
+		{
+			Name:         "InvalidTokens",
+			Tokens:       "s3cr3t,alice=,=t0k3n",
+			RateLimit:    "soon",
+			ExpTokens:    map[string]string{},
+			ExpRateLimit: setAPIRateLimit,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			osGetenv = func(in string) string {
+				switch in {
+				case "ALLSTAR_API_ADDR":
+					return test.Addr
+				case "ALLSTAR_HEALTH_ADDR":
+					return test.HealthAddr
+				case "ALLSTAR_API_TOKENS":
+					return test.Tokens
+				case "ALLSTAR_API_RATE_LIMIT":
+					return test.RateLimit
+				}
+				return ""
+			}
+			setVars()
+			if diff := cmp.Diff(test.ExpAddr, APIAddr); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.ExpHealth, HealthAddr); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.ExpTokens, APITokens); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.ExpRateLimit, APIRateLimit); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSetKeySecretTTL(t *testing.T) {
+	tests := map[string]time.Duration{
+		"":     setKeySecretTTL,
+		"15m":  15 * time.Minute,
+		"0":    0,
+		"-1h":  setKeySecretTTL,
+		"soon": setKeySecretTTL,
+	}
+	for ttl, exp := range tests {
+		osGetenv = func(in string) string {
+			if in == "KEY_SECRET_TTL" {
+				return ttl
+			}
+			return ""
+		}
+		setVars()
+		if KeySecretTTL != exp {
+			t.Errorf("Unexpected KeySecretTTL for %q: %v", ttl, KeySecretTTL)
+		}
+	}
+}
+
+func TestSetRepoLists(t *testing.T) {
+	osGetenv = func(in string) string {
+		switch in {
+		case "ALLSTAR_ALLOWED_REPOS":
+			return "acme/*, other/service-*,"
+		case "ALLSTAR_DENIED_REPOS":
+			return "acme/*-mirror"
+		}
+		return ""
+	}
+	setVars()
+	if diff := cmp.Diff([]string{"acme/*", "other/service-*"}, AllowedRepos); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"acme/*-mirror"}, DeniedRepos); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+
+	osGetenv = func(in string) string { return "" }
+	setVars()
+	if AllowedRepos != nil || DeniedRepos != nil {
+		t.Errorf("Unexpected lists: %v %v", AllowedRepos, DeniedRepos)
+	}
+}
+
+func TestSetMaxInstallationClients(t *testing.T) {
+	tests := map[string]int{
+		"":     setMaxInstallationClients,
+		"50":   50,
+		"0":    setMaxInstallationClients,
+		"-5":   setMaxInstallationClients,
+		"many": setMaxInstallationClients,
+	}
+	for max, exp := range tests {
+		osGetenv = func(in string) string {
+			if in == "ALLSTAR_MAX_INSTALLATION_CLIENTS" {
+				return max
+			}
+			return ""
+		}
+		setVars()
+		if MaxInstallationClients != exp {
+			t.Errorf("Unexpected MaxInstallationClients for %q: %v", max, MaxInstallationClients)
+		}
+	}
+}
+
+func TestSetIssueLimits(t *testing.T) {
+	tests := []struct {
+		Name            string
+		BodySize        string
+		SummaryRepos    string
+		CheckRun        string
+		ExpBodySize     int
+		ExpSummaryRepos int
+		ExpCheckRun     bool
+	}{
+		{
+			Name:            "Defaults",
+			ExpBodySize:     setMaxIssueBodySize,
+			ExpSummaryRepos: setMaxSummaryRepos,
+		},
+		{
+			Name:            "Set",
+			BodySize:        "30000",
+			SummaryRepos:    "20",
+			CheckRun:        "true",
+			ExpBodySize:     30000,
+			ExpSummaryRepos: 20,
+			ExpCheckRun:     true,
+		},
+		{
+			Name:            "Invalid",
+			BodySize:        "100000",
+			SummaryRepos:    "-1",
+			CheckRun:        "sometimes",
+			ExpBodySize:     setMaxIssueBodySize,
+			ExpSummaryRepos: setMaxSummaryRepos,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			osGetenv = func(in string) string {
+				switch in {
+				case "ALLSTAR_MAX_ISSUE_BODY_SIZE":
+					return test.BodySize
+				case "ALLSTAR_MAX_SUMMARY_REPOS":
+					return test.SummaryRepos
+				case "ALLSTAR_ISSUE_OVERFLOW_CHECK_RUN":
+					return test.CheckRun
+				}
+				return ""
+			}
+			setVars()
+			if diff := cmp.Diff(test.ExpBodySize, MaxIssueBodySize); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.ExpSummaryRepos, MaxSummaryRepos); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.ExpCheckRun, IssueOverflowCheckRun); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSetSchedules(t *testing.T) {
+	tests := []struct {
+		Name           string
+		Enforce        string
+		Incremental    string
+		OrgEnforce     string
+		OrgIncremental string
+		ExpEnforce     string
+		ExpIncremental string
+		ExpOrgEnforce  map[string]string
+		ExpOrgIncr     map[string]string
+	}{
+		{
+			Name:          "Defaults",
+			ExpEnforce:    setEnforceSchedule,
+			ExpOrgEnforce: map[string]string{},
+			ExpOrgIncr:    map[string]string{},
+		},
+		{
+			Name:           "Set",
+			Enforce:        " 0 */6 * * * ",
+			Incremental:    "*/10 * * * *",
+			OrgEnforce:     "Acme=0 2 * * 1,3,5; other = @hourly;bad;=@daily",
+			OrgIncremental: "acme=@every 30m",
+			ExpEnforce:     "0 */6 * * *",
+			ExpIncremental: "*/10 * * * *",
+			ExpOrgEnforce: map[string]string{
+				"acme":  "0 2 * * 1,3,5",
+				"other": "@hourly",
+			},
+			ExpOrgIncr: map[string]string{
+				"acme": "@every 30m",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			osGetenv = func(in string) string {
+				switch in {
+				case "ALLSTAR_ENFORCE_SCHEDULE":
+					return test.Enforce
+				case "ALLSTAR_INCREMENTAL_SCHEDULE":
+					return test.Incremental
+				case "ALLSTAR_ORG_ENFORCE_SCHEDULES":
+					return test.OrgEnforce
+				case "ALLSTAR_ORG_INCREMENTAL_SCHEDULES":
+					return test.OrgIncremental
+				}
+				return ""
+			}
+			setVars()
+			if EnforceSchedule != test.ExpEnforce {
+				t.Errorf("Unexpected EnforceSchedule: %q", EnforceSchedule)
+			}
+			if IncrementalSchedule != test.ExpIncremental {
+				t.Errorf("Unexpected IncrementalSchedule: %q", IncrementalSchedule)
+			}
+			if diff := cmp.Diff(test.ExpOrgEnforce, OrgEnforceSchedules); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.ExpOrgIncr, OrgIncrementalSchedules); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSetResultCache(t *testing.T) {
+	tests := []struct {
+		Name        string
+		TTL         string
+		StateURL    string
+		ExpTTL      time.Duration
+		ExpStateURL string
+	}{
+		{
+			Name:        "Defaults",
+			ExpTTL:      0,
+			ExpStateURL: setStateURL,
+		},
+		{
+			Name:        "Set",
+			TTL:         "6h",
+			StateURL:    "sqlite:///var/lib/allstar/state.db",
+			ExpTTL:      6 * time.Hour,
+			ExpStateURL: "sqlite:///var/lib/allstar/state.db",
+		},
+		{
+			Name:        "Invalid",
+			TTL:         "-1h",
+			ExpTTL:      0,
+			ExpStateURL: setStateURL,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			osGetenv = func(in string) string {
+				switch in {
+				case "ALLSTAR_RESULT_CACHE_TTL":
+					return test.TTL
+				case "ALLSTAR_STATE_URL":
+					return test.StateURL
+				}
+				return ""
+			}
+			setVars()
+			if ResultCacheTTL != test.ExpTTL {
+				t.Errorf("Unexpected ResultCacheTTL: %v", ResultCacheTTL)
+			}
+			if StateURL != test.ExpStateURL {
+				t.Errorf("Unexpected StateURL: %q", StateURL)
+			}
+		})
+	}
+}
+
+func TestSetShard(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Count    string
+		Index    string
+		ExpCount int
+		ExpIndex int
+	}{
+		{
+			Name:     "Defaults",
+			ExpCount: 1,
+			ExpIndex: 0,
+		},
+		{
+			Name:     "Set",
+			Count:    "3",
+			Index:    "2",
+			ExpCount: 3,
+			ExpIndex: 2,
+		},
+		{
+			Name:     "Invalid",
+			Count:    "three",
+			Index:    "two",
+			ExpCount: 0,
+			ExpIndex: -1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			osGetenv = func(in string) string {
+				switch in {
+				case "ALLSTAR_SHARD_COUNT":
+					return test.Count
+				case "ALLSTAR_SHARD_INDEX":
+					return test.Index
+				}
+				return ""
+			}
+			setVars()
+			if ShardCount != test.ExpCount {
+				t.Errorf("Unexpected ShardCount: %v", ShardCount)
+			}
+			if ShardIndex != test.ExpIndex {
+				t.Errorf("Unexpected ShardIndex: %v", ShardIndex)
+			}
+		})
+	}
+}
+
+func TestSetLeaderLock(t *testing.T) {
+	tests := []struct {
+		Name   string
+		URL    string
+		TTL    string
+		ExpURL string
+		ExpTTL time.Duration
+	}{
+		{
+			Name:   "Defaults",
+			ExpURL: "",
+			ExpTTL: 30 * time.Second,
+		},
+		{
+			Name:   "Set",
+			URL:    "kubernetes://allstar/allstar-leader",
+			TTL:    "1m",
+			ExpURL: "kubernetes://allstar/allstar-leader",
+			ExpTTL: time.Minute,
+		},
+		{
+			Name:   "InvalidTTL",
+			TTL:    "-5s",
+			ExpURL: "",
+			ExpTTL: 30 * time.Second,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			osGetenv = func(in string) string {
+				switch in {
+				case "ALLSTAR_LEADER_LOCK_URL":
+					return test.URL
+				case "ALLSTAR_LEADER_LOCK_TTL":
+					return test.TTL
+				}
+				return ""
+			}
+			setVars()
+			if LeaderLockURL != test.ExpURL {
+				t.Errorf("Unexpected LeaderLockURL: %q", LeaderLockURL)
+			}
+			if LeaderLockTTL != test.ExpTTL {
+				t.Errorf("Unexpected LeaderLockTTL: %v", LeaderLockTTL)
+			}
+		})
+	}
+}
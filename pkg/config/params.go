@@ -0,0 +1,67 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+
+	"github.com/ossf/allstar/pkg/config/operator"
+	"github.com/rs/zerolog/log"
+)
+
+// paramRe matches a parameter reference, eg: "${params.runbookURL}".
+var paramRe = regexp.MustCompile(`\$\{\s*params\.([A-Za-z0-9_-]+)\s*\}`)
+
+type withParams struct {
+	Parameters map[string]string `json:"parameters"`
+}
+
+// getParams returns the parameters of the org-level Allstar config. conJSON is
+// used instead of fetching, if it is the org-level Allstar config itself.
+func getParams(ctx context.Context, r repositories, owner, name string, cl ConfigLevel, conJSON []byte) map[string]string {
+	if cl == OrgLevel && name == operator.AppConfigFile {
+		var wp withParams
+		if err := json.Unmarshal(conJSON, &wp); err != nil {
+			return nil
+		}
+		return wp.Parameters
+	}
+	return getOrgConfig(ctx, r, owner).Parameters
+}
+
+// expandParams replaces the parameter references in the string values of
+// conJSON. References to parameters that are not defined are left as-is.
+func expandParams(owner, p string, conJSON []byte, params map[string]string) []byte {
+	return paramRe.ReplaceAllFunc(conJSON, func(ref []byte) []byte {
+		name := string(paramRe.FindSubmatch(ref)[1])
+		v, ok := params[name]
+		if !ok {
+			log.Warn().
+				Str("org", owner).
+				Str("file", p).
+				Str("parameter", name).
+				Msg("Config references undefined parameter, leaving as-is.")
+			return ref
+		}
+		// Escape the value for the JSON string it is placed in.
+		b, err := json.Marshal(v)
+		if err != nil {
+			return ref
+		}
+		return b[1 : len(b)-1]
+	})
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+)
+
+type paramsTestConfig struct {
+	Text     string            `json:"text"`
+	List     []string          `json:"list"`
+	Map      map[string]string `json:"map"`
+	Template string            `json:"template"`
+}
+
+func TestFetchConfigParams(t *testing.T) {
+	files := map[string]string{
+		".allstar/allstar.yaml": `
+parameters:
+  runbook: https://wiki.example.com/security
+  team: "@example/security"
+  quoted: 'say "hi"'
+issueFooter: See ${params.runbook}
+`,
+		".allstar/params.yaml": `
+text: Contact ${ params.team } or see ${params.runbook}/allstar.
+list:
+- ${params.team}
+map:
+  key: ${params.quoted}
+template: "{{.Owner}} ${params.undefined}"
+`,
+		"thisrepo/.allstar/params.yaml": `
+text: Repo ${params.team}
+`,
+	}
+
+	get = func(ctx context.Context, owner, repo string) (*github.Repository,
+		*github.Response, error) {
+		return nil, nil, nil
+	}
+	walkGC = func(ctx context.Context, r repositories, owner, repo, path string,
+		opts *github.RepositoryContentGetOptions) (*github.RepositoryContent,
+		[]*github.RepositoryContent, *github.Response, error) {
+		f, ok := files[repo+"/"+path]
+		if !ok {
+			return nil, nil, &github.Response{
+				Response: &http.Response{StatusCode: http.StatusNotFound},
+			}, errors.New("Not found")
+		}
+		e := "base64"
+		c := base64.StdEncoding.EncodeToString([]byte(f))
+		return &github.RepositoryContent{
+			Encoding: &e,
+			Content:  &c,
+		}, nil, nil, nil
+	}
+
+	oc := getOrgConfig(context.Background(), mockRepos{}, "paramsorg")
+	if oc.IssueFooter != "See https://wiki.example.com/security" {
+		t.Errorf("Unexpected issue footer: %q", oc.IssueFooter)
+	}
+
+	got := &paramsTestConfig{}
+	if err := fetchConfig(context.Background(), mockRepos{}, "paramsorg", "", "params.yaml", OrgLevel, got); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	exp := &paramsTestConfig{
+		Text:     "Contact @example/security or see https://wiki.example.com/security/allstar.",
+		List:     []string{"@example/security"},
+		Map:      map[string]string{"key": `say "hi"`},
+		Template: "{{.Owner}} ${params.undefined}",
+	}
+	if diff := cmp.Diff(exp, got); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+
+	got = &paramsTestConfig{}
+	if err := fetchConfig(context.Background(), mockRepos{}, "paramsorg", "thisrepo", "params.yaml", RepoLevel, got); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(&paramsTestConfig{Text: "Repo @example/security"}, got); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,348 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package schema generates JSON Schema documents for the Allstar and policy
+// config files. Organizations can use these to validate their config
+// repositories in editors and CI.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/operator"
+	"github.com/ossf/allstar/pkg/exemptions"
+	"github.com/ossf/allstar/pkg/policies/action"
+	"github.com/ossf/allstar/pkg/policies/admin"
+	"github.com/ossf/allstar/pkg/policies/allowedactions"
+	"github.com/ossf/allstar/pkg/policies/bestpractices"
+	"github.com/ossf/allstar/pkg/policies/binary"
+	"github.com/ossf/allstar/pkg/policies/branch"
+	"github.com/ossf/allstar/pkg/policies/cachepoisoning"
+	"github.com/ossf/allstar/pkg/policies/checkfreshness"
+	"github.com/ossf/allstar/pkg/policies/codeowners"
+	"github.com/ossf/allstar/pkg/policies/codescanning"
+	"github.com/ossf/allstar/pkg/policies/confighealth"
+	"github.com/ossf/allstar/pkg/policies/configprotection"
+	"github.com/ossf/allstar/pkg/policies/deprecations"
+	"github.com/ossf/allstar/pkg/policies/externalaccess"
+	"github.com/ossf/allstar/pkg/policies/forkdeploy"
+	"github.com/ossf/allstar/pkg/policies/forkpr"
+	"github.com/ossf/allstar/pkg/policies/integrations"
+	"github.com/ossf/allstar/pkg/policies/lifecycle"
+	"github.com/ossf/allstar/pkg/policies/mergemessage"
+	"github.com/ossf/allstar/pkg/policies/moderation"
+	"github.com/ossf/allstar/pkg/policies/orgactions"
+	"github.com/ossf/allstar/pkg/policies/orgsettings"
+	"github.com/ossf/allstar/pkg/policies/outside"
+	"github.com/ossf/allstar/pkg/policies/publishedactions"
+	"github.com/ossf/allstar/pkg/policies/releasekeys"
+	"github.com/ossf/allstar/pkg/policies/requiredworkflows"
+	"github.com/ossf/allstar/pkg/policies/scorecard"
+	"github.com/ossf/allstar/pkg/policies/secretscanning"
+	"github.com/ossf/allstar/pkg/policies/security"
+	"github.com/ossf/allstar/pkg/policies/triageboard"
+	"github.com/ossf/allstar/pkg/policies/twofactor"
+	"github.com/ossf/allstar/pkg/policies/updatelatency"
+	"github.com/ossf/allstar/pkg/policies/vulnalerts"
+	"github.com/ossf/allstar/pkg/policies/workflow"
+)
+
+const draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Levels of generated schemas. Repo-level schemas apply both to files in a
+// repository's .allstar directory and to per-repo files in the org config
+// repository.
+const (
+	OrgLevel  = "org"
+	RepoLevel = "repo"
+)
+
+// Schema is a JSON Schema document, or a subschema within one.
+type Schema struct {
+	Schema      string `json:"$schema,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Ref         string `json:"$ref,omitempty"`
+	Type        string `json:"type,omitempty"`
+
+	Properties map[string]*Schema `json:"properties,omitempty"`
+
+	// AdditionalProperties is either a bool or a *Schema.
+	AdditionalProperties interface{} `json:"additionalProperties,omitempty"`
+
+	Items *Schema            `json:"items,omitempty"`
+	Defs  map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// File is the generated schema for one config file at one level.
+type File struct {
+	// Name is the name of the config file, eg: "branch_protection.yaml".
+	Name string
+
+	// Policy is the name of the policy, empty for the Allstar config file.
+	Policy string
+
+	// Level is OrgLevel or RepoLevel.
+	Level string
+
+	// Schema is the generated schema.
+	Schema *Schema
+}
+
+// Filename returns the name the schema is written to, eg:
+// "branch_protection.org.schema.json".
+func (f File) Filename() string {
+	return fmt.Sprintf("%v.%v.schema.json", strings.TrimSuffix(f.Name, path.Ext(f.Name)), f.Level)
+}
+
+type policyConfig struct {
+	// name must match the Name() of the policy.
+	name string
+	file string
+	org  interface{}
+	// repo is nil for policies without a repo-level config.
+	repo interface{}
+}
+
+var policyConfigs = []policyConfig{
+	{"Binary Artifacts", "binary_artifacts.yaml", binary.OrgConfig{}, binary.RepoConfig{}},
+	{"Branch Protection", "branch_protection.yaml", branch.OrgConfig{}, branch.RepoConfig{}},
+	{"CODEOWNERS", "codeowners.yaml", codeowners.OrgConfig{}, codeowners.RepoConfig{}},
+	{"Outside Collaborators", "outside.yaml", outside.OrgConfig{}, outside.RepoConfig{}},
+	{"OpenSSF Scorecard", "scorecard.yaml", scorecard.OrgConfig{}, scorecard.RepoConfig{}},
+	{"SECURITY.md", "security.yaml", security.OrgConfig{}, security.RepoConfig{}},
+	{"Dangerous Workflow", "dangerous_workflow.yaml", workflow.OrgConfig{}, workflow.RepoConfig{}},
+	{"GitHub Actions", "actions.yaml", action.OrgConfig{}, nil},
+	{"Repository Administrators", "admin.yaml", admin.OrgConfig{}, admin.RepoConfig{}},
+	{"Allowed Actions", "allowed_actions.yaml", allowedactions.OrgConfig{}, allowedactions.RepoConfig{}},
+	{"Security Triage Board", "triage_board.yaml", triageboard.OrgConfig{}, nil},
+	{"Fork PR Workflows", "fork_pr_workflows.yaml", forkpr.OrgConfig{}, forkpr.RepoConfig{}},
+	{"Secret Scanning", "secret_scanning.yaml", secretscanning.OrgConfig{}, secretscanning.RepoConfig{}},
+	{"Vulnerability Alerts", "vulnerability_alerts.yaml", vulnalerts.OrgConfig{}, vulnalerts.RepoConfig{}},
+	{"Organization Moderation", "moderation.yaml", moderation.OrgConfig{}, nil},
+	{"Code Scanning", "code_scanning.yaml", codescanning.OrgConfig{}, codescanning.RepoConfig{}},
+	{"OpenSSF Best Practices", "best_practices.yaml", bestpractices.OrgConfig{}, bestpractices.RepoConfig{}},
+	{"Cache Poisoning", "cache_poisoning.yaml", cachepoisoning.OrgConfig{}, cachepoisoning.RepoConfig{}},
+	{"Dependency Update Latency", "dependency_update_latency.yaml", updatelatency.OrgConfig{}, updatelatency.RepoConfig{}},
+	{"Fork PR Deployments", "fork_pr_deployments.yaml", forkdeploy.OrgConfig{}, forkdeploy.RepoConfig{}},
+	{"Published Actions", "published_actions.yaml", publishedactions.OrgConfig{}, publishedactions.RepoConfig{}},
+	{"Required Integrations", "required_integrations.yaml", integrations.OrgConfig{}, integrations.RepoConfig{}},
+	{"Repository Lifecycle", "repo_lifecycle.yaml", lifecycle.OrgConfig{}, nil},
+	{"Status Check Freshness", "status_check_freshness.yaml", checkfreshness.OrgConfig{}, checkfreshness.RepoConfig{}},
+	{"External Access", "external_access.yaml", externalaccess.OrgConfig{}, externalaccess.RepoConfig{}},
+	{"Workflow Deprecations", "workflow_deprecations.yaml", deprecations.OrgConfig{}, deprecations.RepoConfig{}},
+	{"Merge Commit Messages", "merge_commit_messages.yaml", mergemessage.OrgConfig{}, mergemessage.RepoConfig{}},
+	{"Config Protection", "config_protection.yaml", configprotection.OrgConfig{}, configprotection.RepoConfig{}},
+	{"Release Signing Keys", "release_signing_keys.yaml", releasekeys.OrgConfig{}, releasekeys.RepoConfig{}},
+	{"Required Workflows", "required_workflows.yaml", requiredworkflows.OrgConfig{}, requiredworkflows.RepoConfig{}},
+	{"Config Health", "config_health.yaml", confighealth.OrgConfig{}, confighealth.RepoConfig{}},
+	{"Organization Actions Settings", "org_actions.yaml", orgactions.OrgConfig{}, nil},
+	{"Two-Factor Authentication", "two_factor.yaml", twofactor.OrgConfig{}, nil},
+	{"Organization Repository Settings", "org_repo_settings.yaml", orgsettings.OrgConfig{}, nil},
+}
+
+// Files returns the schemas for the Allstar config file, the exemption
+// registry, and every policy config file, at each level they are read from.
+func Files() []File {
+	files := []File{
+		newFile(operator.AppConfigFile, "", OrgLevel, config.OrgConfig{}, config.RepoConfig{}),
+		newFile(operator.AppConfigFile, "", RepoLevel, config.RepoConfig{}, nil),
+		newFile(exemptions.ConfigFile, "", OrgLevel, exemptions.OrgConfig{}, nil),
+	}
+	for _, p := range policyConfigs {
+		files = append(files, newFile(p.file, p.name, OrgLevel, p.org, p.repo))
+		if p.repo != nil {
+			files = append(files, newFile(p.file, p.name, RepoLevel, p.repo, nil))
+		}
+	}
+	return files
+}
+
+// newFile generates the schema of config file name at level, from the config
+// struct v. If overrides is not nil, it is the repo-level config struct used
+// for the "groupOverrides" of an org-level file.
+func newFile(name, policy, level string, v, overrides interface{}) File {
+	s := generate(v, overrides)
+	title := "Allstar"
+	if policy != "" {
+		title = fmt.Sprintf("Allstar %v policy", policy)
+	}
+	s.Title = fmt.Sprintf("%v %v-level config (%v)", title, level, name)
+	if level == OrgLevel {
+		// Org-level files may be merged on top of a base config, see
+		// checkAndMergeBase in pkg/config.
+		s.Properties["baseConfig"] = &Schema{
+			Type:        "string",
+			Description: "GitHub \"owner/repo\" containing a base config to merge this file on top of.",
+		}
+	}
+	return File{
+		Name:   name,
+		Policy: policy,
+		Level:  level,
+		Schema: s,
+	}
+}
+
+// Write writes the schema of every config file to dir as indented JSON,
+// creating dir if needed.
+func Write(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, f := range Files() {
+		b, err := json.MarshalIndent(f.Schema, "", "  ")
+		if err != nil {
+			return err
+		}
+		b = append(b, '\n')
+		if err := os.WriteFile(filepath.Join(dir, f.Filename()), b, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Generate returns a JSON Schema for the config struct v, following the same
+// json tags used when the config is unmarshaled. Named struct types other than
+// v itself are placed in $defs so that recursive types are supported.
+func Generate(v interface{}) *Schema {
+	return generate(v, nil)
+}
+
+func generate(v, overrides interface{}) *Schema {
+	g := &generator{
+		defs:  make(map[string]*Schema),
+		names: make(map[reflect.Type]string),
+	}
+	s := g.object(structType(v))
+	if overrides != nil {
+		// See RepoGroups in pkg/config.
+		s.Properties["groupOverrides"] = &Schema{
+			Type:                 "object",
+			Description:          "Repo-level config to apply to the repositories of each repo group, keyed by group name.",
+			AdditionalProperties: g.ref(structType(overrides)),
+		}
+	}
+	s.Schema = draft
+	if len(g.defs) > 0 {
+		s.Defs = g.defs
+	}
+	return s
+}
+
+func structType(v interface{}) reflect.Type {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+type generator struct {
+	defs  map[string]*Schema
+	names map[reflect.Type]string
+}
+
+func (g *generator) schema(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schema(t.Elem())
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{
+			Type:  "array",
+			Items: g.schema(t.Elem()),
+		}
+	case reflect.Map:
+		return &Schema{
+			Type:                 "object",
+			AdditionalProperties: g.schema(t.Elem()),
+		}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return g.ref(t)
+	}
+	// Interfaces and anything else accept any value.
+	return &Schema{}
+}
+
+func (g *generator) ref(t reflect.Type) *Schema {
+	name, ok := g.names[t]
+	if !ok {
+		name = t.Name()
+		if _, taken := g.defs[name]; taken {
+			name = path.Base(t.PkgPath()) + "." + name
+		}
+		g.names[t] = name
+		// Reserve the name before descending, for recursive types.
+		g.defs[name] = nil
+		g.defs[name] = g.object(t)
+	}
+	return &Schema{Ref: "#/$defs/" + name}
+}
+
+func (g *generator) object(t reflect.Type) *Schema {
+	s := &Schema{
+		Type:                 "object",
+		Properties:           make(map[string]*Schema),
+		AdditionalProperties: false,
+	}
+	g.fields(t, s.Properties)
+	return s
+}
+
+func (g *generator) fields(t reflect.Type, props map[string]*Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			// Embedded struct fields are promoted, as in encoding/json.
+			g.fields(ft, props)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = g.schema(f.Type)
+	}
+}
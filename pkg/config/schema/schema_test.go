@@ -0,0 +1,179 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ossf/allstar/pkg/exemptions"
+	"github.com/ossf/allstar/pkg/policies"
+)
+
+type inner struct {
+	Name  string   `json:"name"`
+	Child *inner   `json:"child"`
+	List  []*inner `json:"list"`
+}
+
+type Embedded struct {
+	Promoted bool `json:"promoted"`
+}
+
+type outer struct {
+	Embedded
+	Action   *string             `json:"action"`
+	Count    int                 `json:"count"`
+	Ratio    float64             `json:"ratio"`
+	Branches map[string][]string `json:"branches"`
+	Inner    inner               `json:"inner"`
+	Skipped  string              `json:"-"`
+	NoTag    bool
+	hidden   string
+}
+
+func TestGenerate(t *testing.T) {
+	innerRef := &Schema{Ref: "#/$defs/inner"}
+	exp := &Schema{
+		Schema: draft,
+		Type:   "object",
+		Properties: map[string]*Schema{
+			"promoted": {Type: "boolean"},
+			"action":   {Type: "string"},
+			"count":    {Type: "integer"},
+			"ratio":    {Type: "number"},
+			"branches": {
+				Type: "object",
+				AdditionalProperties: &Schema{
+					Type:  "array",
+					Items: &Schema{Type: "string"},
+				},
+			},
+			"inner": innerRef,
+			"NoTag": {Type: "boolean"},
+		},
+		AdditionalProperties: false,
+		Defs: map[string]*Schema{
+			"inner": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"name":  {Type: "string"},
+					"child": innerRef,
+					"list": {
+						Type:  "array",
+						Items: innerRef,
+					},
+				},
+				AdditionalProperties: false,
+			},
+		},
+	}
+	got := Generate(&outer{})
+	if diff := cmp.Diff(exp, got); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+}
+
+func TestAllPolicies(t *testing.T) {
+	var want []string
+	for _, p := range policies.GetPolicies() {
+		want = append(want, p.Name())
+	}
+	for _, p := range policies.GetOrgPolicies() {
+		want = append(want, p.Name())
+	}
+	var got []string
+	for _, p := range policyConfigs {
+		got = append(got, p.name)
+	}
+	sort.Strings(want)
+	sort.Strings(got)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Schemas out of sync with policies. (-want +got):\n%s", diff)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	files := Files()
+	// Org-scope policies are enabled on the org, with no opt in/out config.
+	orgPolicies := map[string]bool{}
+	for _, p := range policies.GetOrgPolicies() {
+		orgPolicies[p.Name()] = true
+	}
+	names := map[string]bool{}
+	for _, f := range files {
+		if names[f.Filename()] {
+			t.Errorf("Duplicate schema file: %v", f.Filename())
+		}
+		names[f.Filename()] = true
+		_, hasBase := f.Schema.Properties["baseConfig"]
+		if hasBase != (f.Level == OrgLevel) {
+			t.Errorf("Unexpected baseConfig property in %v: %v", f.Filename(), hasBase)
+		}
+		if _, ok := f.Schema.Properties["groupOverrides"]; ok && f.Level != OrgLevel {
+			t.Errorf("Unexpected groupOverrides property in %v", f.Filename())
+		}
+		if _, ok := f.Schema.Properties["optConfig"]; !ok && f.Policy != "GitHub Actions" && !orgPolicies[f.Policy] && f.Name != exemptions.ConfigFile {
+			t.Errorf("Missing optConfig property in %v", f.Filename())
+		}
+	}
+	for _, n := range []string{
+		"allstar.org.schema.json",
+		"allstar.repo.schema.json",
+		"branch_protection.org.schema.json",
+		"branch_protection.repo.schema.json",
+		"actions.org.schema.json",
+		"exemptions.org.schema.json",
+	} {
+		if !names[n] {
+			t.Errorf("Missing schema file: %v", n)
+		}
+	}
+	if names["actions.repo.schema.json"] {
+		t.Errorf("Unexpected repo-level schema for actions.yaml")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "schema")
+	if err := Write(dir); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "branch_protection.org.schema.json"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var s map[string]interface{}
+	if err := json.Unmarshal(b, &s); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if s["$schema"] != draft {
+		t.Errorf("Unexpected $schema: %v", s["$schema"])
+	}
+	props := s["properties"].(map[string]interface{})
+	for _, p := range []string{"optConfig", "enforceBranches", "requireStatusChecks", "baseConfig", "groupOverrides"} {
+		if _, ok := props[p]; !ok {
+			t.Errorf("Missing property %v", p)
+		}
+	}
+	overrides := props["groupOverrides"].(map[string]interface{})["additionalProperties"].(map[string]interface{})
+	if overrides["$ref"] != "#/$defs/RepoConfig" {
+		t.Errorf("Unexpected groupOverrides schema: %v", overrides)
+	}
+}
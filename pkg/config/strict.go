@@ -0,0 +1,257 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Problem is a problem found in a config file when operator.StrictConfig is
+// enabled.
+type Problem struct {
+	// Repo is the repository containing the config file.
+	Repo string
+
+	// Path is the path of the config file in Repo.
+	Path string
+
+	// Field is the path of the unknown field, eg: "groups[0].rules[1].nmae".
+	// Empty if the file could not be parsed.
+	Field string
+
+	// Suggestion is the known field most similar to Field, if any.
+	Suggestion string
+
+	// Err is set if the file could not be parsed.
+	Err string
+}
+
+// String returns a human readable description of the problem.
+func (p Problem) String() string {
+	if p.Field == "" {
+		return fmt.Sprintf("`%v`: could not be parsed, defaults are used: %v", p.Path, p.Err)
+	}
+	s := fmt.Sprintf("`%v`: unknown field `%v`", p.Path, p.Field)
+	if p.Suggestion != "" {
+		s += fmt.Sprintf(", did you mean `%v`?", p.Suggestion)
+	}
+	return s
+}
+
+type problemKey struct {
+	owner string
+	repo  string
+	path  string
+}
+
+// problems records the problems found in the most recent fetch of each config
+// file.
+var problems = make(map[problemKey][]Problem)
+var problemsMu sync.Mutex
+
+// GetProblems returns the problems recorded for config files in the provided
+// repo, from the most recent fetch of each file, sorted by path.
+func GetProblems(owner, repo string) []Problem {
+	problemsMu.Lock()
+	defer problemsMu.Unlock()
+	var ps []Problem
+	for k, v := range problems {
+		if k.owner == owner && k.repo == repo {
+			ps = append(ps, v...)
+		}
+	}
+	sort.SliceStable(ps, func(i, j int) bool {
+		if ps[i].Path != ps[j].Path {
+			return ps[i].Path < ps[j].Path
+		}
+		return ps[i].Field < ps[j].Field
+	})
+	return ps
+}
+
+func setProblems(owner, repo, path string, ps []Problem) {
+	problemsMu.Lock()
+	defer problemsMu.Unlock()
+	k := problemKey{owner, repo, path}
+	if len(ps) == 0 {
+		delete(problems, k)
+		return
+	}
+	problems[k] = ps
+}
+
+// checkUnknownFields records any fields in conJSON that are not known to out,
+// or that conJSON can not be parsed. Field names are matched case
+// insensitively, as encoding/json does. The extra names are allowed at the top
+// level.
+func checkUnknownFields(owner, repo, path string, conJSON []byte, out interface{}, extra ...string) {
+	var v interface{}
+	if err := json.Unmarshal(conJSON, &v); err != nil {
+		setProblems(owner, repo, path, []Problem{{Repo: repo, Path: path, Err: err.Error()}})
+		return
+	}
+	if m, ok := v.(map[string]interface{}); ok {
+		for _, e := range extra {
+			delete(m, e)
+		}
+	}
+	var ps []Problem
+	for _, f := range unknownFields(v, reflect.TypeOf(out), "") {
+		f.Repo = repo
+		f.Path = path
+		ps = append(ps, f)
+	}
+	setProblems(owner, repo, path, ps)
+}
+
+func unknownFields(v interface{}, t reflect.Type, prefix string) []Problem {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var ps []Problem
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		fields := make(map[string]reflect.Type)
+		jsonFields(t, fields)
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			name, ft := lookupField(fields, k)
+			if ft == nil {
+				ps = append(ps, Problem{
+					Field:      prefix + k,
+					Suggestion: suggest(fields, k),
+				})
+				continue
+			}
+			ps = append(ps, unknownFields(m[k], ft, prefix+name+".")...)
+		}
+	case reflect.Slice, reflect.Array:
+		l, ok := v.([]interface{})
+		if !ok {
+			return nil
+		}
+		p := strings.TrimSuffix(prefix, ".")
+		for i, e := range l {
+			ps = append(ps, unknownFields(e, t.Elem(), fmt.Sprintf("%v[%v].", p, i))...)
+		}
+	case reflect.Map:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			ps = append(ps, unknownFields(m[k], t.Elem(), prefix+k+".")...)
+		}
+	}
+	return ps
+}
+
+// jsonFields adds the json names and types of the fields of struct t to
+// fields, including promoted fields of embedded structs.
+func jsonFields(t reflect.Type, fields map[string]reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			jsonFields(ft, fields)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+}
+
+func lookupField(fields map[string]reflect.Type, k string) (string, reflect.Type) {
+	if t, ok := fields[k]; ok {
+		return k, t
+	}
+	for name, t := range fields {
+		if strings.EqualFold(name, k) {
+			return name, t
+		}
+	}
+	return "", nil
+}
+
+// suggest returns the known field closest to k, if it is close enough to be a
+// likely typo.
+func suggest(fields map[string]reflect.Type, k string) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var best string
+	bestDist := len(k)/5 + 2
+	for _, name := range names {
+		if d := editDistance(strings.ToLower(k), strings.ToLower(name)); d < bestDist {
+			best = name
+			bestDist = d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,189 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+)
+
+type strictTestConfig struct {
+	OptConfig     OrgOptConfig               `json:"optConfig"`
+	ApprovalCount int                        `json:"approvalCount"`
+	Checks        []strictTestCheck          `json:"checks"`
+	Branches      map[string][]string        `json:"branches"`
+	Nested        *strictTestCheck           `json:"nested"`
+	Groups        map[string]strictTestCheck `json:"groups"`
+}
+
+type strictTestCheck struct {
+	Context string `json:"context"`
+	AppID   *int64 `json:"appID"`
+}
+
+func TestFetchConfigStrict(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Level  ConfigLevel
+		Input  string
+		Status int
+		Exp    []Problem
+	}{
+		{
+			Name:  "NoProblems",
+			Level: OrgLevel,
+			Input: `
+optConfig:
+  optOutStrategy: true
+ApprovalCount: 1
+baseConfig: other/.allstar
+`,
+		},
+		{
+			Name:  "Typos",
+			Level: OrgLevel,
+			Input: `
+optConfig:
+  optOutStrategyy: true
+aprovalCount: 1
+checks:
+- context: build
+  appid: 1
+- contxt: test
+branches:
+  main: [a]
+nested:
+  context: x
+  extra: true
+groups:
+  one:
+    contex: y
+unrelated: true
+`,
+			Exp: []Problem{
+				{Repo: ".allstar", Path: "strict.yaml", Field: "aprovalCount", Suggestion: "approvalCount"},
+				{Repo: ".allstar", Path: "strict.yaml", Field: "checks[1].contxt", Suggestion: "context"},
+				{Repo: ".allstar", Path: "strict.yaml", Field: "groups.one.contex", Suggestion: "context"},
+				{Repo: ".allstar", Path: "strict.yaml", Field: "nested.extra"},
+				{Repo: ".allstar", Path: "strict.yaml", Field: "optConfig.optOutStrategyy", Suggestion: "optOutStrategy"},
+				{Repo: ".allstar", Path: "strict.yaml", Field: "unrelated"},
+			},
+		},
+		{
+			Name:  "BaseConfigOnlyOrgLevel",
+			Level: RepoLevel,
+			Input: `
+baseConfig: other/.allstar
+`,
+			Exp: []Problem{
+				{Repo: "thisrepo", Path: ".allstar/strict.yaml", Field: "baseConfig"},
+			},
+		},
+		{
+			Name:  "Malformed",
+			Level: RepoLevel,
+			Input: `
+approvalCount: many
+`,
+			Exp: []Problem{
+				{
+					Repo: "thisrepo",
+					Path: ".allstar/strict.yaml",
+					Err:  "json: cannot unmarshal string into Go struct field strictTestConfig.approvalCount of type int",
+				},
+			},
+		},
+		{
+			Name:   "NotFoundClears",
+			Level:  RepoLevel,
+			Status: http.StatusNotFound,
+		},
+	}
+
+	strictConfig = true
+	defer func() {
+		strictConfig = false
+	}()
+	get = func(ctx context.Context, owner, repo string) (*github.Repository,
+		*github.Response, error) {
+		return nil, nil, nil
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			walkGC = func(ctx context.Context, r repositories, owner, repo, path string,
+				opts *github.RepositoryContentGetOptions) (*github.RepositoryContent,
+				[]*github.RepositoryContent, *github.Response, error) {
+				if test.Status != 0 {
+					return nil, nil, &github.Response{
+						Response: &http.Response{StatusCode: test.Status},
+					}, errors.New("error")
+				}
+				e := "base64"
+				c := base64.StdEncoding.EncodeToString([]byte(test.Input))
+				return &github.RepositoryContent{
+					Encoding: &e,
+					Content:  &c,
+				}, nil, nil, nil
+			}
+			repo := "thisrepo"
+			path := ".allstar/strict.yaml"
+			if test.Level == OrgLevel {
+				repo = ".allstar"
+				path = "strict.yaml"
+			}
+			// Problems from an earlier fetch of the same file are replaced.
+			setProblems("strictorg", repo, path, []Problem{{Field: "old"}})
+			out := &strictTestConfig{}
+			if err := fetchConfig(context.Background(), mockRepos{}, "strictorg", "thisrepo", "strict.yaml", test.Level, out); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.Exp, GetProblems("strictorg", repo)); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+			setProblems("strictorg", repo, path, nil)
+		})
+	}
+}
+
+func TestProblemString(t *testing.T) {
+	tests := []struct {
+		P   Problem
+		Exp string
+	}{
+		{
+			P:   Problem{Path: "a.yaml", Field: "aprovalCount", Suggestion: "approvalCount"},
+			Exp: "`a.yaml`: unknown field `aprovalCount`, did you mean `approvalCount`?",
+		},
+		{
+			P:   Problem{Path: "a.yaml", Field: "zzz"},
+			Exp: "`a.yaml`: unknown field `zzz`",
+		},
+		{
+			P:   Problem{Path: "a.yaml", Err: "bad"},
+			Exp: "`a.yaml`: could not be parsed, defaults are used: bad",
+		},
+	}
+	for _, test := range tests {
+		if diff := cmp.Diff(test.Exp, test.P.String()); diff != "" {
+			t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforce
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ossf/allstar/pkg/config/operator"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/robfig/cron/v3"
+)
+
+// sweep is the kind of enforcement run on an installation.
+type sweep int
+
+const (
+	// sweepNone skips the installation, it is not due.
+	sweepNone sweep = iota
+	// sweepIncremental enforces the repos changed since the previous sweep.
+	sweepIncremental
+	// sweepFull enforces all repos, and the org-scope policies.
+	sweepFull
+)
+
+func (s sweep) String() string {
+	switch s {
+	case sweepIncremental:
+		return "incremental"
+	case sweepFull:
+		return "full"
+	}
+	return "none"
+}
+
+// Scheduler decides when EnforceJob sweeps each installation, from the cron
+// schedules in the operator config. It also tracks when each policy last ran,
+// for policies with a configured interval.
+type Scheduler struct {
+	full           cron.Schedule
+	incremental    cron.Schedule
+	orgFull        map[string]cron.Schedule
+	orgIncremental map[string]cron.Schedule
+	policies       *policySchedule
+
+	mu              sync.Mutex
+	nextFull        map[string]time.Time
+	nextIncremental map[string]time.Time
+	lastSweep       map[string]time.Time
+}
+
+// ScheduleStatus is the status of a Scheduler, reported by the health
+// endpoint.
+type ScheduleStatus struct {
+	// NextRun is when EnforceJob next runs.
+	NextRun time.Time `json:"nextRun"`
+
+	// Orgs is the schedule of each installation swept at least once, keyed by
+	// the lowercase org name.
+	Orgs map[string]OrgScheduleStatus `json:"orgs,omitempty"`
+}
+
+// OrgScheduleStatus is the schedule of an installation.
+type OrgScheduleStatus struct {
+	// LastSweep is when the last completed sweep started.
+	LastSweep time.Time `json:"lastSweep,omitempty"`
+
+	// NextFull is when the next full sweep is due.
+	NextFull time.Time `json:"nextFull"`
+
+	// NextIncremental is when the next incremental sweep is due, if
+	// incremental sweeps are configured.
+	NextIncremental *time.Time `json:"nextIncremental,omitempty"`
+}
+
+// NewScheduler returns a Scheduler for the schedules set in the operator
+// config: operator.EnforceSchedule, operator.IncrementalSchedule, and their
+// per-org overrides. An error is returned if any schedule is malformed.
+func NewScheduler() (*Scheduler, error) {
+	s := &Scheduler{
+		orgFull:         make(map[string]cron.Schedule),
+		orgIncremental:  make(map[string]cron.Schedule),
+		policies:        newPolicySchedule(),
+		nextFull:        make(map[string]time.Time),
+		nextIncremental: make(map[string]time.Time),
+		lastSweep:       make(map[string]time.Time),
+	}
+	var err error
+	if s.full, err = parseSchedule("enforce", operator.EnforceSchedule); err != nil {
+		return nil, err
+	}
+	if operator.IncrementalSchedule != "" {
+		if s.incremental, err = parseSchedule("incremental", operator.IncrementalSchedule); err != nil {
+			return nil, err
+		}
+	}
+	for org, spec := range operator.OrgEnforceSchedules {
+		if s.orgFull[org], err = parseSchedule(org+" enforce", spec); err != nil {
+			return nil, err
+		}
+	}
+	for org, spec := range operator.OrgIncrementalSchedules {
+		if s.orgIncremental[org], err = parseSchedule(org+" incremental", spec); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func parseSchedule(name, spec string) (cron.Schedule, error) {
+	cs, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("malformed %s schedule %q: %w", name, spec, err)
+	}
+	return cs, nil
+}
+
+func (s *Scheduler) fullSchedule(org string) cron.Schedule {
+	if cs, ok := s.orgFull[org]; ok {
+		return cs
+	}
+	return s.full
+}
+
+func (s *Scheduler) incrementalSchedule(org string) cron.Schedule {
+	if cs, ok := s.orgIncremental[org]; ok {
+		return cs
+	}
+	return s.incremental
+}
+
+// sweep returns the kind of sweep due on the org at now, and advances its
+// schedule. An installation is swept in full the first time it is seen. A
+// nil Scheduler always returns sweepFull.
+func (s *Scheduler) sweep(owner string, now time.Time) sweep {
+	if s == nil {
+		return sweepFull
+	}
+	org := strings.ToLower(owner)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next, ok := s.nextFull[org]
+	kind := sweepNone
+	if !ok || !now.Before(next) {
+		kind = sweepFull
+	} else if next, ok := s.nextIncremental[org]; ok && !now.Before(next) {
+		kind = sweepIncremental
+	}
+	// An incremental sweep needs a previous sweep to compare against.
+	if _, ok := s.lastSweep[org]; kind == sweepIncremental && !ok {
+		kind = sweepFull
+	}
+	if kind == sweepFull {
+		s.nextFull[org] = s.fullSchedule(org).Next(now)
+	}
+	if kind != sweepNone {
+		if cs := s.incrementalSchedule(org); cs != nil {
+			s.nextIncremental[org] = cs.Next(now)
+		}
+	}
+	return kind
+}
+
+// duePolicies returns the set of policy names that are due to run on the org
+// at now. A nil Scheduler returns nil, which indicates all policies are due.
+func (s *Scheduler) duePolicies(ctx context.Context, c *github.Client, owner string, now time.Time) map[string]bool {
+	if s == nil {
+		return nil
+	}
+	return s.policies.duePolicies(ctx, c, owner, now)
+}
+
+// markRun records that the due policies ran on the org at now.
+func (s *Scheduler) markRun(owner string, due map[string]bool, now time.Time) {
+	if s == nil {
+		return
+	}
+	s.policies.markRun(owner, due, now)
+}
+
+// swept records that a sweep of the org, started at start, completed.
+func (s *Scheduler) swept(owner string, start time.Time) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastSweep[strings.ToLower(owner)] = start
+}
+
+// since returns when the last completed sweep of the org started.
+func (s *Scheduler) since(owner string) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastSweep[strings.ToLower(owner)]
+}
+
+// prune forgets the orgs with a sweep due by t, which were not seen by a
+// run started at t, such as uninstalled orgs, so that they don't keep
+// EnforceJob awake.
+func (s *Scheduler) prune(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for org, next := range s.nextFull {
+		if !next.After(t) {
+			delete(s.nextFull, org)
+			delete(s.lastSweep, org)
+		}
+	}
+	for org, next := range s.nextIncremental {
+		if !next.After(t) {
+			delete(s.nextIncremental, org)
+		}
+	}
+}
+
+// Next returns when EnforceJob next needs to run after now: the earliest
+// sweep due on any org. It is before now if a sweep was missed while
+// enforcing.
+func (s *Scheduler) Next(now time.Time) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.next(now)
+}
+
+func (s *Scheduler) next(now time.Time) time.Time {
+	next := s.full.Next(now)
+	earliest := func(t time.Time) {
+		if !t.IsZero() && t.Before(next) {
+			next = t
+		}
+	}
+	if s.incremental != nil {
+		earliest(s.incremental.Next(now))
+	}
+	for _, cs := range s.orgFull {
+		earliest(cs.Next(now))
+	}
+	for _, cs := range s.orgIncremental {
+		earliest(cs.Next(now))
+	}
+	for _, t := range s.nextFull {
+		earliest(t)
+	}
+	for _, t := range s.nextIncremental {
+		earliest(t)
+	}
+	return next
+}
+
+// Status returns the schedule of EnforceJob after now.
+func (s *Scheduler) Status(now time.Time) ScheduleStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := ScheduleStatus{
+		NextRun: s.next(now),
+	}
+	for org, t := range s.nextFull {
+		if st.Orgs == nil {
+			st.Orgs = make(map[string]OrgScheduleStatus)
+		}
+		o := OrgScheduleStatus{
+			LastSweep: s.lastSweep[org],
+			NextFull:  t,
+		}
+		if t, ok := s.nextIncremental[org]; ok {
+			o.NextIncremental = &t
+		}
+		st.Orgs[org] = o
+	}
+	return st
+}
+
+// changedRepos returns the repos pushed to or updated since the time given.
+// All repos are returned if the org config repo changed, as it may change the
+// policies of every repo.
+func changedRepos(repos []*github.Repository, since time.Time) ([]*github.Repository, bool) {
+	var changed []*github.Repository
+	for _, r := range repos {
+		if !r.GetPushedAt().Time.After(since) && !r.GetUpdatedAt().Time.After(since) {
+			continue
+		}
+		if n := r.GetName(); n == operator.OrgConfigRepo || n == ".github" {
+			return repos, true
+		}
+		changed = append(changed, r)
+	}
+	return changed, false
+}
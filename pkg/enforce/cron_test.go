@@ -0,0 +1,266 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforce
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ossf/allstar/pkg/config/operator"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+)
+
+func setSchedules(t *testing.T, full, incremental string, orgFull map[string]string) {
+	oldFull := operator.EnforceSchedule
+	oldIncremental := operator.IncrementalSchedule
+	oldOrgFull := operator.OrgEnforceSchedules
+	t.Cleanup(func() {
+		operator.EnforceSchedule = oldFull
+		operator.IncrementalSchedule = oldIncremental
+		operator.OrgEnforceSchedules = oldOrgFull
+	})
+	operator.EnforceSchedule = full
+	operator.IncrementalSchedule = incremental
+	operator.OrgEnforceSchedules = orgFull
+}
+
+func TestNewSchedulerMalformed(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Full        string
+		Incremental string
+		OrgFull     map[string]string
+	}{
+		{Name: "Full", Full: "every five minutes"},
+		{Name: "Incremental", Full: "*/5 * * * *", Incremental: "* * *"},
+		{Name: "Org", Full: "*/5 * * * *", OrgFull: map[string]string{"acme": "@sometimes"}},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			setSchedules(t, test.Full, test.Incremental, test.OrgFull)
+			if _, err := NewScheduler(); err == nil {
+				t.Errorf("Expected error for malformed schedule")
+			}
+		})
+	}
+}
+
+func TestSweep(t *testing.T) {
+	setSchedules(t, "0 * * * *", "*/10 * * * *", map[string]string{"acme": "0 0 * * *"})
+	s, err := NewScheduler()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	at := func(h, m int) time.Time {
+		return time.Date(2025, 9, 1, h, m, 0, 0, time.UTC)
+	}
+	steps := []struct {
+		Time   time.Time
+		Swept  bool
+		ExpOrg sweep
+		ExpAcm sweep
+	}{
+		{at(12, 0), true, sweepFull, sweepFull},
+		{at(12, 5), true, sweepNone, sweepNone},
+		{at(12, 10), true, sweepIncremental, sweepIncremental},
+		{at(12, 15), true, sweepNone, sweepNone},
+		{at(13, 0), false, sweepFull, sweepIncremental},
+		// The previous sweep of "org" failed, but it still has one to compare
+		// against.
+		{at(13, 10), true, sweepIncremental, sweepIncremental},
+	}
+	for _, step := range steps {
+		if got := s.sweep("org", step.Time); got != step.ExpOrg {
+			t.Errorf("Unexpected sweep of org at %v: %v expect: %v", step.Time, got, step.ExpOrg)
+		}
+		if got := s.sweep("Acme", step.Time); got != step.ExpAcm {
+			t.Errorf("Unexpected sweep of acme at %v: %v expect: %v", step.Time, got, step.ExpAcm)
+		}
+		if step.Swept {
+			s.swept("org", step.Time)
+			s.swept("Acme", step.Time)
+		}
+	}
+
+	// An incremental sweep without a completed sweep is full.
+	s, err = NewScheduler()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s.sweep("org", at(12, 0))
+	if got := s.sweep("org", at(12, 10)); got != sweepFull {
+		t.Errorf("Unexpected sweep without a previous sweep: %v", got)
+	}
+
+	// A nil Scheduler always sweeps in full.
+	var ns *Scheduler
+	if got := ns.sweep("org", at(12, 5)); got != sweepFull {
+		t.Errorf("Unexpected sweep of nil Scheduler: %v", got)
+	}
+}
+
+func TestNextAndPrune(t *testing.T) {
+	setSchedules(t, "0 * * * *", "", map[string]string{"acme": "*/20 * * * *"})
+	s, err := NewScheduler()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	at := func(h, m int) time.Time {
+		return time.Date(2025, 9, 1, h, m, 0, 0, time.UTC)
+	}
+	if got := s.Next(at(12, 1)); !got.Equal(at(12, 20)) {
+		t.Errorf("Unexpected next run: %v", got)
+	}
+	s.sweep("org", at(12, 0))
+	s.sweep("gone", at(11, 0))
+	// A run overran the 12:00 sweep of "gone", it is due immediately.
+	if got := s.Next(at(12, 1)); !got.Equal(at(12, 0)) {
+		t.Errorf("Unexpected next run: %v", got)
+	}
+	// The run started at 12:00 did not see "gone", it was uninstalled.
+	s.prune(at(12, 0))
+	if got := s.Next(at(12, 1)); !got.Equal(at(12, 20)) {
+		t.Errorf("Unexpected next run after prune: %v", got)
+	}
+	exp := ScheduleStatus{
+		NextRun: at(12, 20),
+		Orgs: map[string]OrgScheduleStatus{
+			"org": {NextFull: at(13, 0)},
+		},
+	}
+	if diff := cmp.Diff(exp, s.Status(at(12, 1))); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+}
+
+func TestChangedRepos(t *testing.T) {
+	since := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	repo := func(name string, pushed, updated time.Duration) *github.Repository {
+		return &github.Repository{
+			Name:      github.String(name),
+			PushedAt:  &github.Timestamp{Time: since.Add(pushed)},
+			UpdatedAt: &github.Timestamp{Time: since.Add(updated)},
+		}
+	}
+	names := func(rs []*github.Repository) []string {
+		var n []string
+		for _, r := range rs {
+			n = append(n, r.GetName())
+		}
+		return n
+	}
+	repos := []*github.Repository{
+		repo("pushed", time.Minute, -time.Hour),
+		repo("updated", -time.Hour, time.Minute),
+		repo("unchanged", -time.Hour, -time.Hour),
+		{Name: github.String("never")},
+	}
+	got, all := changedRepos(repos, since)
+	if all {
+		t.Errorf("Unexpected all repos changed")
+	}
+	if diff := cmp.Diff([]string{"pushed", "updated"}, names(got)); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+
+	repos = append(repos, repo(operator.OrgConfigRepo, time.Minute, 0))
+	got, all = changedRepos(repos, since)
+	if !all || len(got) != len(repos) {
+		t.Errorf("Expected all repos after config change, got %v", names(got))
+	}
+}
+
+func TestEnforceAllIncremental(t *testing.T) {
+	setSchedules(t, "0 0 1 1 *", "*/5 * * * *", nil)
+	login := "org"
+	getAppInstallations = func(ctx context.Context, ac *github.Client) ([]*github.Installation, error) {
+		id := int64(1)
+		return []*github.Installation{
+			{ID: &id, Account: &github.User{Login: &login}},
+		}, nil
+	}
+	now := time.Now()
+	getAppInstallationRepos = func(ctx context.Context, ic *github.Client) ([]*github.Repository, *github.Response, error) {
+		return []*github.Repository{
+			{Name: github.String("old"), Owner: &github.User{Login: &login},
+				PushedAt: &github.Timestamp{Time: now.Add(-2 * time.Hour)}},
+			{Name: github.String("new"), Owner: &github.User{Login: &login},
+				PushedAt: &github.Timestamp{Time: now.Add(-time.Minute)}},
+		}, nil, nil
+	}
+	getRateLimit = func(ctx context.Context, c *github.Client) (*github.Rate, error) {
+		return &github.Rate{Remaining: 5000}, nil
+	}
+	configIsBotEnabled = func(ctx context.Context, c *github.Client, owner, repo string) bool {
+		return true
+	}
+	var mu sync.Mutex
+	var ran []string
+	runPolicies = func(ctx context.Context, c *github.Client, owner, repo string, enabled, grace bool, due map[string]bool) (EnforceRepoResults, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		ran = append(ran, repo)
+		return EnforceRepoResults{"Test policy": true}, nil
+	}
+	s, err := NewScheduler()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	steps := []struct {
+		Name string
+		Prep func()
+		Exp  []string
+	}{
+		{
+			Name: "First",
+			Prep: func() {},
+			Exp:  []string{"new", "old"},
+		},
+		{
+			Name: "NotDue",
+			Prep: func() {
+				s.nextIncremental[login] = now.Add(time.Hour)
+			},
+			Exp: nil,
+		},
+		{
+			Name: "Incremental",
+			Prep: func() {
+				s.nextIncremental[login] = now.Add(-time.Hour)
+				s.lastSweep[login] = now.Add(-time.Hour)
+			},
+			Exp: []string{"new"},
+		},
+	}
+	for _, step := range steps {
+		t.Run(step.Name, func(t *testing.T) {
+			ran = nil
+			step.Prep()
+			if _, err := enforceAll(context.Background(), &MockGhClients{}, s, "", ""); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			sort.Strings(ran)
+			if diff := cmp.Diff(step.Exp, ran); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
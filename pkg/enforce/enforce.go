@@ -18,17 +18,30 @@ package enforce
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/ossf/allstar/pkg/audit"
+	"github.com/ossf/allstar/pkg/cache"
+	"github.com/ossf/allstar/pkg/checks"
 	"github.com/ossf/allstar/pkg/config"
 	"github.com/ossf/allstar/pkg/config/operator"
+	"github.com/ossf/allstar/pkg/enforceid"
 	"github.com/ossf/allstar/pkg/ghclients"
+	"github.com/ossf/allstar/pkg/installations"
 	"github.com/ossf/allstar/pkg/issue"
+	"github.com/ossf/allstar/pkg/notify"
 	"github.com/ossf/allstar/pkg/policies"
 	"github.com/ossf/allstar/pkg/policydef"
 	"github.com/ossf/allstar/pkg/scorecard"
+	"github.com/ossf/allstar/pkg/state"
+	"github.com/ossf/allstar/pkg/storage"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/google/go-github/v59/github"
@@ -36,51 +49,178 @@ import (
 )
 
 type EnforceRepoResults = map[string]bool
+
+// EnforceAllResults are the counts of each policy, keyed by policy name. The
+// counts of a run that are not the result of a policy are in
+// storage.RunCounts.
 type EnforceAllResults = map[string]map[string]int
 
+// gracePeriodCount is the EnforceAllResults key, under each policy, counting
+// repos failing the policy during their grace period, or with actions held
+// back in the policy's grace period, which are not counted as failed.
+const gracePeriodCount = "totalGracePeriod"
+
+// apiCallsCount and apiCostCount are the EnforceAllResults keys, under each
+// policy, counting the GitHub API requests made to evaluate the policy, and
+// how many of them counted against the rate limit.
+const apiCallsCount = "apiCalls"
+const apiCostCount = "apiCost"
+
+// issueFallbackCount is the EnforceAllResults key, under each policy,
+// counting failing repos that have issues disabled, so the violation was
+// reported in a fallback instead of an issue in the repo.
+const issueFallbackCount = "totalIssueFallback"
+
+// rateLimitCheckInterval is the number of repos enforced on between checks of
+// the installation's remaining rate limit.
+const rateLimitCheckInterval = 50
+
 var doNothingOnOptOut = operator.DoNothingOnOptOut
 var policiesGetPolicies func() []policydef.Policy
-var issueEnsure func(context.Context, *github.Client, string, string, string, string) error
+var issueEnsure func(context.Context, *github.Client, string, string, string, string) (*issue.Fallback, error)
 var issueClose func(context.Context, *github.Client, string, string, string) error
+var issueEnsureSummary func(context.Context, *github.Client, string, *issue.SummaryRun) error
+var notifySend func(context.Context, *github.Client, string, string, string, string) error
+var notifyClear func(string, string, string)
+var checksUpdate func(context.Context, *github.Client, string, string, string, bool, string) error
 var configIsBotEnabled func(context.Context, *github.Client, string, string) bool
+var configGetOrgConfig func(context.Context, *github.Client, string) *config.OrgConfig
 var getAppInstallations func(context.Context, *github.Client) ([]*github.Installation, error)
 var getAppInstallationRepos func(context.Context, *github.Client) ([]*github.Repository, *github.Response, error)
-var runPolicies func(context.Context, *github.Client, string, string, bool, string) (EnforceRepoResults, error)
-var deleteInstallation func(context.Context, *github.Client, int64) (*github.Response, error)
+var runPolicies func(context.Context, *github.Client, string, string, bool, bool, map[string]bool) (EnforceRepoResults, error)
 var listInstallations func(context.Context, *github.Client) ([]*github.Installation, error)
+var getRateLimit func(context.Context, *github.Client) (*github.Rate, error)
+var notifySendOperator func(context.Context, string, string, string) error
+
+// suspensions records the installations known to be suspended, by ID, with
+// the time of suspension. It is saved to the state store, so that the operator
+// is alerted once per suspension, across EnforceJob cycles and restarts.
+var suspensions map[int64]time.Time
+var suspensionsMu sync.Mutex
+
+// shard is the share of installations enforced by this replica.
+var shard ghclients.Shard
+
+// stateStore persists state across enforcement runs and restarts.
+var stateStore state.Interface = state.NewMemory()
+
+// gc caches the compiled operator repo allow and deny list globs.
+var gc = cache.NewGlobCache(cache.DefaultSize)
+
+// resultStore is where the result of each enforcement run is saved, if set.
+var resultStore storage.Interface
 
 func init() {
 	policiesGetPolicies = policies.GetPolicies
 	issueEnsure = issue.Ensure
 	issueClose = issue.Close
+	issueEnsureSummary = issue.EnsureSummary
+	notifySend = notify.Send
+	notifyClear = notify.Clear
+	checksUpdate = checks.Update
 	configIsBotEnabled = config.IsBotEnabled
+	configGetOrgConfig = config.GetOrgConfig
 	getAppInstallations = getAppInstallationsReal
 	getAppInstallationRepos = getAppInstallationReposReal
 	runPolicies = runPoliciesReal
-	deleteInstallation = deleteInstallationReal
 	listInstallations = listInstallationsReal
+	getRateLimit = getRateLimitReal
+	notifySendOperator = notify.SendOperator
 }
 
 // EnforceAll iterates through all available installations and repos Allstar
 // has access to and runs policies on those repos. It is meant to be a
 // reconciliation job to check repos which a webhook event may have been lost.
+// If specificRepoArg is set, only the repos matching it are enforced on, see
+// ParseRepoFilter.
 //
 // TBD: determine if this should remain exported, or if it will only be called
 // from EnforceJob.
 func EnforceAll(ctx context.Context, ghc ghclients.GhClientsInterface, specificPolicyArg string, specificRepoArg string) (EnforceAllResults, error) {
+	run, err := enforceAll(ctx, ghc, nil, specificPolicyArg, specificRepoArg)
+	if run == nil {
+		return nil, err
+	}
+	return run.Summary, err
+}
+
+// EnforceAllRun is EnforceAll, returning the full result of the run, including
+// the result of each policy on each repo, for exporting. The run is nil if it
+// failed before enforcing any installation.
+func EnforceAllRun(ctx context.Context, ghc ghclients.GhClientsInterface, specificPolicyArg string, specificRepoArg string) (*storage.RunResult, error) {
+	return enforceAll(ctx, ghc, nil, specificPolicyArg, specificRepoArg)
+}
+
+// SetState configures the state store that enforcement persists state to,
+// such as known suspended installations and the result cache. The default is
+// in memory.
+func SetState(s state.Interface) {
+	stateStore = s
+	suspensionsMu.Lock()
+	suspensions = nil
+	suspensionsMu.Unlock()
+}
+
+// SetShard configures the share of installations that this replica enforces
+// policies on, when installations are sharded across replicas. Enforcing a
+// specific repo is not sharded. The default is all installations.
+func SetShard(s ghclients.Shard) {
+	shard = s
+}
+
+// SetStorage configures the results storage backend that the result of each
+// enforcement run is saved to. A nil backend disables saving results.
+func SetStorage(s storage.Interface) {
+	resultStore = s
+}
+
+// enforceAll is EnforceAll, only sweeping the installations, and running the
+// policies, that are due according to the provided schedule. A nil schedule
+// sweeps all installations in full and runs all policies. The run ID of ctx
+// is used if set, otherwise a new one is generated. The run is returned, with
+// the aggregated counts in its Summary.
+func enforceAll(ctx context.Context, ghc ghclients.GhClientsInterface, sched *Scheduler, specificPolicyArg string, specificRepoArg string) (*storage.RunResult, error) {
 	var repoCount int
 	var enforceAllResults = make(EnforceAllResults)
+	var counts storage.RunCounts
+	var policyResults []storage.PolicyResult
+	started := time.Now()
+	if enforceid.Run(ctx) == "" {
+		ctx = enforceid.WithRun(ctx)
+	}
+	// fail records a run that failed before enforcing any installation.
+	fail := func(err error) (*storage.RunResult, error) {
+		recordError(enforceid.Run(ctx), "", "", err)
+		if specificRepoArg == "" {
+			recordRun(enforceid.Run(ctx), started, time.Now(), 0, 0, 0, err)
+		}
+		return nil, err
+	}
 	ac, err := ghc.Get(0)
 	if err != nil {
-		return nil, err
+		return fail(err)
 	}
 	insts, err := getAppInstallations(ctx, ac)
 	if err != nil {
-		return nil, err
+		return fail(err)
+	}
+	if specificRepoArg == "" {
+		insts = shardInstallations(insts)
+	}
+	insts = filterOrgs(insts)
+	repoPatterns, err := ParseRepoFilter(specificRepoArg)
+	if err != nil {
+		return fail(err)
+	}
+	policyFilter, err := ParsePolicyFilter(specificPolicyArg)
+	if err != nil {
+		return fail(err)
 	}
 
 	log.Info().
 		Str("area", "bot").
+		Str("runId", enforceid.Run(ctx)).
+		Str("shard", shard.String()).
 		Int("count", len(insts)).
 		Msg("Enforcing policies on installations.")
 
@@ -92,12 +232,23 @@ func EnforceAll(ctx context.Context, ghc ghclients.GhClientsInterface, specificP
 		if ctx.Err() != nil {
 			break
 		}
+		// Only installations of owners matching the repo filter can have the
+		// specific repos, skip listing the repos of all others.
+		if ok, _ := repoFilterOwner(repoPatterns, i.GetAccount().GetLogin()); len(repoPatterns) > 0 && !ok {
+			continue
+		}
 		if i.SuspendedAt != nil {
-			log.Info().
-				Str("area", "bot").
-				Int64("instId", i.GetID()).
-				Str("instTarget", i.GetAccount().GetLogin()).
-				Msg("Installation is suspended, skipping.")
+			handleSuspended(ctx, i)
+			// Workers add their counts concurrently.
+			mu.Lock()
+			counts.Suspended += 1
+			counts.NotMonitored = append(counts.NotMonitored, i.GetAccount().GetLogin())
+			mu.Unlock()
+			continue
+		}
+		clearSuspended(ctx, i)
+		kind := sched.sweep(i.GetAccount().GetLogin(), started)
+		if kind == sweepNone {
 			continue
 		}
 		ic, err := ghc.Get(i.GetID())
@@ -107,23 +258,26 @@ func EnforceAll(ctx context.Context, ghc ghclients.GhClientsInterface, specificP
 				Int64("instId", i.GetID()).
 				Str("instTarget", i.GetAccount().GetLogin()).
 				Msg("Unexpected error getting installation client.")
-			return nil, err
+			return fail(err)
 		}
 		iid := i.GetID()
+		login := i.GetAccount().GetLogin()
+		// Org-scope policies are not run when enforcing specific repos, or in
+		// incremental sweeps.
+		orgInst := i.GetTargetType() == orgTargetType && specificRepoArg == ""
+		gates := permissionGates(i, policyNames())
 
 		g.Go(func() error {
+			logGates(login, gates)
+			ctx := withGates(ctx, gates)
 
 			repos, _, err := getAppInstallationRepos(ctx, ic)
+			// Before the repos are filtered, which may leave out the config
+			// repos.
+			repoCache.setConfig(login, repos)
 
-			if specificRepoArg != "" {
-				searchRepos := repos
-				repos = make([]*github.Repository, 0, 1)
-				for _, r := range searchRepos {
-					if r.GetFullName() == specificRepoArg {
-						repos = append(repos, r)
-						break
-					}
-				}
+			if len(repoPatterns) > 0 {
+				repos = filterSpecificRepos(repos, repoPatterns)
 			}
 
 			// FIXME, not getting a rsp for this one, instead I think it is a special
@@ -139,25 +293,64 @@ func EnforceAll(ctx context.Context, ghc ghclients.GhClientsInterface, specificP
 				log.Error().
 					Err(err).
 					Msg("Unexpected error listing installation repos.")
+				recordError(enforceid.Run(ctx), login, "", err)
 				// return nil, err
 				return nil
 			}
 
+			repos, excluded := filterRepos(repos)
+			if kind == sweepIncremental {
+				var all bool
+				if repos, all = changedRepos(repos, sched.since(login)); all {
+					kind = sweepFull
+				}
+			}
+			isOrg := orgInst && kind == sweepFull
+
 			log.Info().
 				Str("area", "bot").
 				Int64("id", iid).
+				Str("sweep", kind.String()).
 				Int("count", len(repos)).
 				Msg("Enforcing policies on repos of installation.")
 
-			instResults, err := runPoliciesOnInstRepos(ctx, repos, ic, specificPolicyArg)
+			start := time.Now()
+			due := filterPolicies(sched.duePolicies(ctx, ic, login, start), policyFilter)
+			instResults, instCounts, instPolicyResults, err := runPoliciesOnInstRepos(ctx, repos, ic, due)
+			if err == nil {
+				sched.swept(login, start)
+			}
+			// Incremental sweeps don't see every repo, so policy intervals and
+			// the summary are only updated by full sweeps.
+			if err == nil && kind == sweepFull {
+				sched.markRun(login, due, start)
+				ensureSummary(ctx, ic, login, repoPatterns, due, instPolicyResults)
+			}
+			if isOrg && err == nil {
+				orgResults, orgPolicyResults := runOrgPolicies(ctx, ic, login, due)
+				for policyName, results := range orgResults {
+					if instResults[policyName] == nil {
+						instResults[policyName] = make(map[string]int)
+					}
+					for k, v := range results {
+						instResults[policyName][k] += v
+					}
+				}
+				instPolicyResults = append(instPolicyResults, orgPolicyResults...)
+			}
 
 			mu.Lock()
 			repoCount = repoCount + len(repos)
+			instCounts.Excluded = excluded
+			counts.Add(instCounts)
+			policyResults = append(policyResults, instPolicyResults...)
 			for policyName, results := range instResults {
 				if enforceAllResults[policyName] == nil {
 					enforceAllResults[policyName] = make(map[string]int)
 				}
-				enforceAllResults[policyName]["totalFailed"] += results["totalFailed"]
+				for k, v := range results {
+					enforceAllResults[policyName][k] += v
+				}
 			}
 			ghc.Free(iid)
 			mu.Unlock()
@@ -166,49 +359,548 @@ func EnforceAll(ctx context.Context, ghc ghclients.GhClientsInterface, specificP
 				log.Error().
 					Err(err).
 					Msg("Unexpected error running policies.")
+				recordError(enforceid.Run(ctx), login, "", err)
 				return nil
 			}
 			return nil
 		})
 	}
-	if err := g.Wait(); err != nil {
-		return enforceAllResults, err
+	err = g.Wait()
+	run := &storage.RunResult{
+		RunID:    enforceid.Run(ctx),
+		Started:  started,
+		Finished: time.Now(),
+		Policy:   specificPolicyArg,
+		Repo:     specificRepoArg,
+		Summary:  enforceAllResults,
+		Counts:   counts,
+		Results:  policyResults,
+	}
+	if err != nil {
+		run.Error = err.Error()
+	}
+	if specificRepoArg == "" {
+		recordRun(run.RunID, run.Started, run.Finished, len(insts), repoCount, len(counts.Errors), err)
+	}
+	if n := len(counts.Errors); n > 0 {
+		log.Warn().
+			Str("area", "bot").
+			Str("runId", enforceid.Run(ctx)).
+			Int("count", n).
+			Interface("repos", counts.Errors).
+			Msg("Policies failed with errors on some repos, which were skipped.")
+	}
+	saveRun(context.WithoutCancel(ctx), run)
+	if err := audit.Flush(context.WithoutCancel(ctx)); err != nil {
+		log.Error().
+			Str("area", "bot").
+			Err(err).
+			Msg("Unexpected error writing audit log.")
+	}
+	if err := repoCache.save(context.WithoutCancel(ctx), time.Now()); err != nil {
+		log.Error().
+			Err(err).
+			Msg("Unexpected error saving result cache.")
+	}
+	if err != nil {
+		return run, err
 	}
 	log.Info().
 		Str("area", "bot").
+		Str("runId", enforceid.Run(ctx)).
 		Int("count", repoCount).
 		Interface("results", enforceAllResults).
+		Interface("counts", counts).
+		Interface("retryStats", ghclients.GetRetryStats()).
+		Interface("clientCacheStats", ghclients.GetClientCacheStats()).
+		Interface("resultCacheStats", GetResultCacheStats()).
 		Msg("EnforceAll complete.")
-	return enforceAllResults, nil
+	return run, nil
 }
 
-func runPoliciesOnInstRepos(ctx context.Context, repos []*github.Repository, ghclient *github.Client, specificPolicyArg string) (
-	EnforceAllResults, error) {
-	var instResults = make(EnforceAllResults)
-	var repoLoopErr error
-	var owner string
+// handleSuspended records a suspended installation, which is not monitored
+// until it is unsuspended. The operator is alerted the first time each
+// suspension is seen.
+func handleSuspended(ctx context.Context, i *github.Installation) {
+	login := i.GetAccount().GetLogin()
+	at := i.GetSuspendedAt().Time
+	suspensionsMu.Lock()
+	loadSuspensions(ctx)
+	prev, known := suspensions[i.GetID()]
+	suspensions[i.GetID()] = at
+	suspensionsMu.Unlock()
+	if known && prev.Equal(at) {
+		log.Info().
+			Str("area", "bot").
+			Int64("instId", i.GetID()).
+			Str("instTarget", login).
+			Msg("Installation is suspended, skipping.")
+		return
+	}
+	log.Warn().
+		Str("area", "bot").
+		Int64("instId", i.GetID()).
+		Str("instTarget", login).
+		Time("suspendedAt", at).
+		Str("suspendedBy", i.GetSuspendedBy().GetLogin()).
+		Msg("Installation is suspended, not monitored until unsuspended.")
+	if err := state.PutJSON(ctx, stateStore, suspensionKey(i.GetID()), at); err != nil {
+		log.Error().
+			Str("area", "bot").
+			Int64("instId", i.GetID()).
+			Err(err).
+			Msg("Unexpected error saving suspended installation state.")
+	}
+	text := fmt.Sprintf("The Allstar installation on %v (ID %v) was suspended by %v at %v. "+
+		"Its repositories are not monitored until the installation is unsuspended.",
+		login, i.GetID(), i.GetSuspendedBy().GetLogin(), at.Format(time.RFC3339))
+	if err := notifySendOperator(ctx, login, "Installation suspended", text); err != nil {
+		log.Error().
+			Str("area", "bot").
+			Int64("instId", i.GetID()).
+			Str("instTarget", login).
+			Err(err).
+			Msg("Unexpected error alerting operator of suspended installation.")
+	}
+}
+
+// clearSuspended forgets a previously suspended installation that is active
+// again.
+func clearSuspended(ctx context.Context, i *github.Installation) {
+	suspensionsMu.Lock()
+	loadSuspensions(ctx)
+	_, known := suspensions[i.GetID()]
+	delete(suspensions, i.GetID())
+	suspensionsMu.Unlock()
+	if !known {
+		return
+	}
+	log.Info().
+		Str("area", "bot").
+		Int64("instId", i.GetID()).
+		Str("instTarget", i.GetAccount().GetLogin()).
+		Msg("Installation is no longer suspended, resuming.")
+	if err := stateStore.Delete(ctx, suspensionKey(i.GetID())); err != nil {
+		log.Error().
+			Str("area", "bot").
+			Int64("instId", i.GetID()).
+			Err(err).
+			Msg("Unexpected error clearing suspended installation state.")
+	}
+}
+
+func suspensionKey(id int64) string {
+	return state.Key("enforce", "suspension", strconv.FormatInt(id, 10))
+}
+
+// loadSuspensions reads the known suspended installations from the state
+// store, the first time it is called after SetState. Callers must hold
+// suspensionsMu. Errors are logged, and the operator may be alerted again of
+// known suspensions.
+func loadSuspensions(ctx context.Context) {
+	if suspensions != nil {
+		return
+	}
+	suspensions = make(map[int64]time.Time)
+	prefix := state.Key("enforce", "suspension") + "/"
+	keys, err := stateStore.List(ctx, prefix)
+	if err != nil {
+		log.Error().
+			Str("area", "bot").
+			Err(err).
+			Msg("Unexpected error loading suspended installation state.")
+		return
+	}
+	for _, k := range keys {
+		id, err := strconv.ParseInt(strings.TrimPrefix(k, prefix), 10, 64)
+		if err != nil {
+			continue
+		}
+		var at time.Time
+		if err := state.GetJSON(ctx, stateStore, k, &at); err != nil {
+			continue
+		}
+		suspensions[id] = at
+	}
+}
+
+// filterRepos removes the repos excluded by the operator repo allow and deny
+// lists, returning the remaining repos and the number removed.
+func filterRepos(repos []*github.Repository) ([]*github.Repository, int) {
+	if len(operator.AllowedRepos) == 0 && len(operator.DeniedRepos) == 0 {
+		return repos, 0
+	}
+	var allowed []*github.Repository
 	for _, r := range repos {
-		enabled := configIsBotEnabled(ctx, ghclient, *r.Owner.Login, *r.Name)
-		enforceResults, err := runPolicies(ctx, ghclient, *r.Owner.Login, *r.Name, enabled, specificPolicyArg)
+		if repoAllowed(r.GetFullName()) {
+			allowed = append(allowed, r)
+		} else {
+			log.Debug().
+				Str("area", "bot").
+				Str("repo", r.GetFullName()).
+				Msg("Repo excluded by operator repo lists.")
+		}
+	}
+	return allowed, len(repos) - len(allowed)
+}
+
+// repoAllowed returns whether policies are enforced on the repo full name,
+// according to the operator repo allow and deny lists. Deny takes precedence.
+func repoAllowed(name string) bool {
+	name = strings.ToLower(name)
+	if matches(operator.DeniedRepos, name) {
+		return false
+	}
+	return len(operator.AllowedRepos) == 0 || matches(operator.AllowedRepos, name)
+}
+
+func matches(s []string, e string) bool {
+	for _, v := range s {
+		g, err := gc.Compile(strings.ToLower(v))
 		if err != nil {
-			// scope of err doesn't extend outside the for loop
-			repoLoopErr = err
+			log.Warn().
+				Str("repo", e).
+				Str("glob", v).
+				Err(err).
+				Msg("Unexpected error compiling the glob.")
+		} else if g.Match(e) {
+			return true
+		}
+	}
+	return false
+}
+
+// runPoliciesOnInstRepos runs policies on the repos of an installation, up to
+// operator.NumRepoWorkers repos at a time. An error on one repo, such as the
+// repo being deleted or transferred mid-run, is logged and the repo is counted
+// as skipped in the returned counts, the remaining repos are still enforced. Only cancellation of ctx,
+// or failing to wait for the rate limit, stops the run and returns an error.
+func runPoliciesOnInstRepos(ctx context.Context, repos []*github.Repository, ghclient *github.Client, due map[string]bool) (
+	EnforceAllResults, storage.RunCounts, []storage.PolicyResult, error) {
+	repoResults := make([]EnforceRepoResults, len(repos))
+	evaluations := make([]string, len(repos))
+	skipped := make([]bool, len(repos))
+	attempts := make([]int, len(repos))
+	errs := make([]error, len(repos))
+	grace := make([]bool, len(repos))
+	costs := make([]map[string]apiCost, len(repos))
+	fallbacks := make([]map[string]string, len(repos))
+	held := make([]map[string]bool, len(repos))
+	var graceStart time.Time
+	if len(repos) > 0 {
+		graceStart = gracePeriodStart(ctx, ghclient, repos[0].GetOwner().GetLogin(), time.Now())
+	}
+	uncached := repoCache.uncached(due)
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(operator.NumRepoWorkers)
+	var rateErr error
+	for i, r := range repos {
+		if i%rateLimitCheckInterval == 0 {
+			if rateErr = waitForRateLimit(gctx, ghclient); rateErr != nil {
+				break
+			}
+		}
+		if gctx.Err() != nil {
 			break
 		}
-		if owner == "" {
-			owner = *r.Owner.Login
+		i := i
+		r := r
+		owner := r.GetOwner().GetLogin()
+		repo := r.GetName()
+		grace[i] = !graceStart.IsZero() && r.GetCreatedAt().After(graceStart)
+		g.Go(func() error {
+			ectx := enforceid.WithEvaluation(gctx)
+			evaluations[i] = enforceid.Evaluation(ectx)
+			ectx, costs[i] = withAPICosts(ectx)
+			ectx, fallbacks[i] = withIssueFallbacks(ectx)
+			ectx, held[i] = withHeld(ectx)
+			now := time.Now()
+			runDue := due
+			cached, hit := repoCache.get(r, due, now)
+			if hit {
+				if len(uncached) == 0 {
+					repoResults[i] = cached
+					return nil
+				}
+				// Settings policies are run even if the repo is unchanged.
+				runDue = uncached
+			}
+			enabled := configIsBotEnabled(ectx, ghclient, owner, repo)
+			enforceResults, n, err := runPoliciesRetry(ectx, ghclient, owner, repo, enabled, grace[i], runDue)
+			if err != nil {
+				if gctx.Err() != nil {
+					return err
+				}
+				logRepoError(ectx, owner, repo, err)
+				skipped[i] = true
+				attempts[i] = n
+				errs[i] = err
+				return nil
+			}
+			for p, pass := range cached {
+				enforceResults[p] = pass
+			}
+			repoResults[i] = enforceResults
+			// Only the results of all policies are cached.
+			if due == nil && !hit {
+				repoCache.put(r, enforceResults, now)
+			}
+			return nil
+		})
+	}
+	repoLoopErr := g.Wait()
+	if repoLoopErr == nil {
+		repoLoopErr = rateErr
+	}
+
+	// Aggregate in repo order, so results don't depend on scheduling.
+	var instResults = make(EnforceAllResults)
+	var counts storage.RunCounts
+	var policyResults []storage.PolicyResult
+	for i, enforceResults := range repoResults {
+		if skipped[i] {
+			counts.Skipped += 1
+			if !isNotFound(errs[i]) {
+				if counts.Errors == nil {
+					counts.Errors = make(map[string]int)
+				}
+				name := repos[i].GetOwner().GetLogin() + "/" + repos[i].GetName()
+				counts.Errors[name] = attempts[i]
+			}
+			continue
 		}
-		for policyName, passed := range enforceResults {
-			if !passed {
+		names := make([]string, 0, len(enforceResults))
+		for policyName := range enforceResults {
+			names = append(names, policyName)
+		}
+		sort.Strings(names)
+		for _, policyName := range names {
+			passed := enforceResults[policyName]
+			inGrace := !passed && (grace[i] || held[i][policyName])
+			cost := costs[i][policyName]
+			policyResults = append(policyResults, storage.PolicyResult{
+				Owner:         repos[i].GetOwner().GetLogin(),
+				Repo:          repos[i].GetName(),
+				Policy:        policyName,
+				Pass:          passed,
+				EnforcementID: evaluations[i],
+				GracePeriod:   inGrace,
+				APICalls:      cost.calls,
+				APICost:       cost.cost,
+				IssueFallback: fallbacks[i][policyName],
+			})
+			if cost.calls > 0 {
+				if instResults[policyName] == nil {
+					instResults[policyName] = make(map[string]int)
+				}
+				instResults[policyName][apiCallsCount] += cost.calls
+				instResults[policyName][apiCostCount] += cost.cost
+			}
+			if inGrace {
+				if instResults[policyName] == nil {
+					instResults[policyName] = make(map[string]int)
+				}
+				instResults[policyName][gracePeriodCount] += 1
+			} else if !passed {
 				if instResults[policyName] == nil {
 					instResults[policyName] = make(map[string]int)
 				}
 				instResults[policyName]["totalFailed"] += 1
 			}
+			if _, ok := fallbacks[i][policyName]; ok {
+				if instResults[policyName] == nil {
+					instResults[policyName] = make(map[string]int)
+				}
+				instResults[policyName][issueFallbackCount] += 1
+			}
+		}
+	}
+	if len(repos) > 0 {
+		config.ClearInstLoc(repos[0].GetOwner().GetLogin())
+	}
+	return instResults, counts, policyResults, repoLoopErr
+}
+
+type apiCostsKey struct{}
+
+// apiCost is the cost of evaluating a policy on a repo.
+type apiCost struct {
+	calls, cost int
+}
+
+// withAPICosts returns a copy of ctx that runPoliciesReal records the API cost
+// of evaluating each policy to, by policy name.
+func withAPICosts(ctx context.Context) (context.Context, map[string]apiCost) {
+	costs := make(map[string]apiCost)
+	return context.WithValue(ctx, apiCostsKey{}, costs), costs
+}
+
+type issueFallbacksKey struct{}
+
+// withIssueFallbacks returns a copy of ctx that runPoliciesReal records the
+// reason of each issue action reported in a fallback to, by policy name.
+func withIssueFallbacks(ctx context.Context) (context.Context, map[string]string) {
+	fallbacks := make(map[string]string)
+	return context.WithValue(ctx, issueFallbacksKey{}, fallbacks), fallbacks
+}
+
+// shardInstallations returns the installations of insts in the shard of this
+// replica.
+func shardInstallations(insts []*github.Installation) []*github.Installation {
+	if shard.Count <= 1 {
+		return insts
+	}
+	var owned []*github.Installation
+	for _, i := range insts {
+		if shard.Owns(i.GetID()) {
+			owned = append(owned, i)
+		}
+	}
+	return owned
+}
+
+// gracePeriodStart returns the earliest creation time of repos of owner that
+// are in their grace period at now, or the zero time if the org has no grace
+// period.
+func gracePeriodStart(ctx context.Context, c *github.Client, owner string, now time.Time) time.Time {
+	oc := configGetOrgConfig(ctx, c, owner)
+	if oc.GracePeriodDays <= 0 {
+		return time.Time{}
+	}
+	return now.AddDate(0, 0, -oc.GracePeriodDays)
+}
+
+// ensureSummary updates the org-level summary issue of owner with the results
+// of the policies run on its repos. Errors are logged, as the per-repo actions
+// are already taken.
+func ensureSummary(ctx context.Context, ic *github.Client, owner string, repoPatterns []string,
+	due map[string]bool, results []storage.PolicyResult) {
+	run := &issue.SummaryRun{
+		Policies: due,
+	}
+	if _, names := repoFilterOwner(repoPatterns, owner); len(names) > 0 {
+		run.Repo = strings.Join(names, ",")
+	}
+	for _, name := range policyNames() {
+		if g, ok := gateFor(ctx, name); ok && (due == nil || due[name]) {
+			if run.Downgraded == nil {
+				run.Downgraded = make(map[string]string)
+			}
+			run.Downgraded[name] = g.String()
 		}
 	}
-	config.ClearInstLoc(owner)
-	return instResults, repoLoopErr
+	for _, r := range results {
+		// Repos in their grace period are not reported in issues.
+		run.Results = append(run.Results, issue.SummaryResult{
+			Repo:   r.Repo,
+			Policy: r.Policy,
+			Pass:   r.Pass || r.GracePeriod,
+		})
+	}
+	if err := issueEnsureSummary(ctx, ic, owner, run); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("area", "bot").
+			Str("runId", enforceid.Run(ctx)).
+			Err(err).
+			Msg("Unexpected error updating summary issue.")
+	}
+}
+
+// saveRun saves the result of an enforcement run to the results storage, if
+// configured, then prunes runs older than the operator configured retention.
+// Errors are logged, as storage is not required to enforce policies.
+func saveRun(ctx context.Context, r *storage.RunResult) {
+	if resultStore == nil {
+		return
+	}
+	if err := resultStore.SaveRunResult(ctx, r); err != nil {
+		log.Error().
+			Err(err).
+			Msg("Unexpected error saving run result.")
+		return
+	}
+	n, err := resultStore.Prune(ctx, r.Started.Add(-operator.StorageRetention))
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Unexpected error pruning stored run results.")
+		return
+	}
+	log.Info().
+		Str("area", "bot").
+		Int64("id", r.ID).
+		Str("runId", r.RunID).
+		Int("pruned", n).
+		Msg("Saved run result.")
+}
+
+// logRepoError logs an error running policies on a repo that is being
+// skipped. Not found is expected when a repo is deleted, renamed, or
+// transferred during a run.
+func logRepoError(ctx context.Context, owner, repo string, err error) {
+	if isNotFound(err) {
+		log.Warn().
+			Str("org", owner).
+			Str("repo", repo).
+			Fields(enforceid.Fields(ctx)).
+			Err(err).
+			Msg("Repo not found while running policies, skipping.")
+		return
+	}
+	log.Error().
+		Str("org", owner).
+		Str("repo", repo).
+		Fields(enforceid.Fields(ctx)).
+		Err(err).
+		Msg("Unexpected error running policies on repo, skipping.")
+	recordError(enforceid.Run(ctx), owner, repo, err)
+}
+
+// isNotFound returns whether err is a GitHub not found error, such as for a
+// repo deleted during the run.
+func isNotFound(err error) bool {
+	var e *github.ErrorResponse
+	return errors.As(err, &e) && e.Response != nil && e.Response.StatusCode == http.StatusNotFound
+}
+
+// waitForRateLimit blocks until the installation's rate limit resets if fewer
+// than operator.RateLimitReserve requests remain.
+func waitForRateLimit(ctx context.Context, c *github.Client) error {
+	rate, err := getRateLimit(ctx, c)
+	if err != nil {
+		log.Warn().
+			Str("area", "bot").
+			Err(err).
+			Msg("Unable to get installation rate limit, continuing.")
+		return nil
+	}
+	if rate == nil || rate.Remaining >= operator.RateLimitReserve {
+		return nil
+	}
+	d := time.Until(rate.Reset.Time)
+	if d <= 0 {
+		return nil
+	}
+	log.Warn().
+		Str("area", "bot").
+		Int("remaining", rate.Remaining).
+		Time("reset", rate.Reset.Time).
+		Msg("Installation rate limit low, pausing until reset.")
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+func getRateLimitReal(ctx context.Context, c *github.Client) (*github.Rate, error) {
+	rl, _, err := c.RateLimit.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return rl.GetCore(), nil
 }
 
 func listInstallationsReal(ctx context.Context, ac *github.Client) ([]*github.Installation, error) {
@@ -242,32 +934,16 @@ func getAppInstallationsReal(ctx context.Context, ac *github.Client) ([]*github.
 		return nil, err
 	}
 
-	if len(operator.AllowedOrganizations) == 0 ||
-		(len(operator.AllowedOrganizations) == 1 && operator.AllowedOrganizations[0] == "") {
-		return is, nil
-	}
-
 	var insts []*github.Installation
 	for _, i := range is {
-		var m bool
-		for _, ao := range operator.AllowedOrganizations {
-			// Account.Login holds the name of the GitHub Organization
-			// that Allstar is installed on
-			if i.Account != nil && ao == i.Account.GetLogin() {
-				m = true
-			}
-		}
-		if !m {
-			resp, err := deleteInstallation(ctx, ac, i.GetID())
-			if err != nil || resp.StatusCode != 200 {
-				log.Error().
-					Err(err).
-					Str("area", "bot").
-					Str("account", i.Account.GetLogin()).
-					Int64("id", i.GetID()).
-					Msg("couldn't disable allstar on disallowed repo")
-			}
-
+		// Account.Login holds the name of the GitHub Organization
+		// that Allstar is installed on
+		if !installations.Allowed(i.GetAccount().GetLogin()) {
+			log.Warn().
+				Str("area", "bot").
+				Str("account", i.GetAccount().GetLogin()).
+				Int64("id", i.GetID()).
+				Msg("Installation on a disallowed organization, skipping. Remove it with: allstar installations remove")
 			continue
 		}
 		insts = append(insts, i)
@@ -276,11 +952,6 @@ func getAppInstallationsReal(ctx context.Context, ac *github.Client) ([]*github.
 	return insts, nil
 }
 
-func deleteInstallationReal(ctx context.Context, ic *github.Client, instID int64) (*github.Response, error) {
-	resp, err := ic.Apps.DeleteInstallation(ctx, instID)
-	return resp, err
-}
-
 func getAppInstallationReposReal(ctx context.Context, ic *github.Client) ([]*github.Repository, *github.Response, error) {
 	var repos []*github.Repository
 	opt := &github.ListOptions{
@@ -303,45 +974,60 @@ func getAppInstallationReposReal(ctx context.Context, ic *github.Client) ([]*git
 	return repos, resp, err
 }
 
-// EnforceJob is a reconciliation job that enforces policies on all repos every
-// d duration. It runs forever until the context is done.
-func EnforceJob(ctx context.Context, ghc *ghclients.GHClients, d time.Duration, specificPolicyArg string, specificRepoArg string) error {
+// EnforceJob is a reconciliation job that sweeps installations as scheduled
+// by sched. It runs forever until the context is done. Policies with a
+// configured interval (see operator.PolicyIntervals) are skipped until due.
+func EnforceJob(ctx context.Context, ghc *ghclients.GHClients, sched *Scheduler, specificPolicyArg string, specificRepoArg string) error {
 	for {
-		_, err := EnforceAll(ctx, ghc, specificPolicyArg, specificRepoArg)
+		start := time.Now()
+		_, err := enforceAll(ctx, ghc, sched, specificPolicyArg, specificRepoArg)
 		if err != nil {
 			log.Error().
 				Err(err).
 				Msg("Unexpected error enforcing policies.")
 		}
+		sched.prune(start)
+		next := sched.Next(time.Now())
+		log.Info().
+			Str("area", "bot").
+			Time("nextRun", next).
+			Msg("Next enforcement scheduled.")
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case <-time.After(d):
+		case <-time.After(time.Until(next)):
 		}
 	}
 }
 
 // runPoliciesReal enforces policies on the provided repo. It is meant to be called
-// from either jobs, webhooks, or delayed checks. TODO: implement concurrency
-// check to only run a single instance per repo at a time.
-func runPoliciesReal(ctx context.Context, c *github.Client, owner, repo string, enabled bool, specificPolicyArg string) (EnforceRepoResults, error) {
+// from either jobs, webhooks, or delayed checks. If due is not nil, only the
+// policies in due are run. A new evaluation ID is added to ctx if it has none.
+// If the repo is in its grace period, failing policies are only logged. If the
+// violation of a policy is in the policy's grace period, its issue and fix
+// actions are held back.
+// TODO: implement concurrency check to only run a single instance per repo at
+// a time.
+func runPoliciesReal(ctx context.Context, c *github.Client, owner, repo string, enabled, grace bool, due map[string]bool) (EnforceRepoResults, error) {
 	var enforceResults = make(EnforceRepoResults)
-	ps := policiesGetPolicies()
-	if specificPolicyArg != "" {
-		var found policydef.Policy
-		for _, p := range ps {
-			var policyName = p.Name()
-			if policyName == specificPolicyArg {
-				found = p
-			}
-		}
-		ps = make([]policydef.Policy, 1)
-		ps[0] = found
+	if enforceid.Evaluation(ctx) == "" {
+		ctx = enforceid.WithEvaluation(ctx)
 	}
+	ids := enforceid.Fields(ctx)
+	oc := configGetOrgConfig(ctx, c, owner)
+	ps := policiesGetPolicies()
 
 	defer scorecard.Close(fmt.Sprintf("%s/%s", owner, repo))
 	for _, p := range ps {
-		repo_enabled, err := p.IsEnabled(ctx, c, owner, repo)
+		if due != nil && !due[p.Name()] {
+			continue
+		}
+		if g, ok := gateFor(ctx, p.Name()); ok && g.skip {
+			continue
+		}
+		ctx := audit.WithPolicy(ctx, p.Name())
+		pctx, counter := ghclients.WithAPICounter(ctx)
+		repo_enabled, err := p.IsEnabled(pctx, c, owner, repo)
 		if err != nil {
 			return nil, err
 		}
@@ -350,33 +1036,99 @@ func runPoliciesReal(ctx context.Context, c *github.Client, owner, repo string,
 				Str("org", owner).
 				Str("repo", repo).
 				Str("area", p.Name()).
+				Fields(ids).
 				Msg("Policy run skipped as repo is not enabled and doNothingOnOptOut is configured.")
 			continue
 		}
 
-		r, err := p.Check(ctx, c, owner, repo)
+		r, err := p.Check(pctx, c, owner, repo)
 		if err != nil {
 			return nil, err
 		}
+		r.APICalls = counter.Calls()
+		r.APICost = counter.Cost()
 		log.Info().
 			Str("org", owner).
 			Str("repo", repo).
 			Str("area", p.Name()).
+			Fields(ids).
 			Bool("result", r.Pass).
 			Bool("enabled", r.Enabled).
 			Str("notify", r.NotifyText).
 			Interface("details", r.Details).
+			Int("apiCalls", r.APICalls).
+			Int("apiCost", r.APICost).
 			Msg("Policy run result.")
 		if !r.Enabled {
 			continue
 		}
-		a := p.GetAction(ctx, c, owner, repo)
+		if costs, ok := ctx.Value(apiCostsKey{}).(map[string]apiCost); ok {
+			costs[p.Name()] = apiCost{calls: r.APICalls, cost: r.APICost}
+		}
+		a := gatedAction(ctx, p.Name(), p.GetAction(ctx, c, owner, repo))
 		enforceResults[p.Name()] = r.Pass
-		if !r.Pass {
+		var hold bool
+		if period := policyGracePeriod(oc, owner, p.Name()); period > 0 {
+			if r.Pass {
+				clearFirstSeen(ctx, owner, repo, p.Name())
+			} else {
+				hold = violationInGrace(ctx, owner, repo, p.Name(), period, time.Now()) &&
+					(a == "issue" || a == "fix")
+			}
+		}
+		if !r.Pass && grace {
+			log.Info().
+				Str("org", owner).
+				Str("repo", repo).
+				Str("area", p.Name()).
+				Fields(ids).
+				Str("action", a).
+				Msg("Policy failed, but repo is in its grace period, action skipped.")
+		} else if !r.Pass && hold {
+			log.Info().
+				Str("org", owner).
+				Str("repo", repo).
+				Str("area", p.Name()).
+				Fields(ids).
+				Str("action", a).
+				Msg("Policy failed, but violation is in its grace period, action skipped.")
+			if held, ok := ctx.Value(heldKey{}).(map[string]bool); ok {
+				held[p.Name()] = true
+			}
+		} else if !r.Pass {
 			switch a {
 			case "log":
 			case "issue":
-				err := issueEnsure(ctx, c, owner, repo, p.Name(), r.NotifyText)
+				f, err := issueEnsure(ctx, c, owner, repo, p.Name(), r.NotifyText)
+				if err != nil {
+					return nil, err
+				}
+				if f != nil {
+					log.Warn().
+						Str("org", owner).
+						Str("repo", repo).
+						Str("area", p.Name()).
+						Fields(ids).
+						Str("fallback", f.String()).
+						Msg("Policy failed, issue reported in a fallback.")
+					if fallbacks, ok := ctx.Value(issueFallbacksKey{}).(map[string]string); ok {
+						fallbacks[p.Name()] = f.String()
+					}
+				}
+			case "notify":
+				err := notifySend(ctx, c, owner, repo, p.Name(), r.NotifyText)
+				if err != nil {
+					// Don't fail the run on an unreachable external endpoint.
+					log.Error().
+						Str("org", owner).
+						Str("repo", repo).
+						Str("area", p.Name()).
+						Fields(ids).
+						Err(err).
+						Msg("Unexpected error sending notification.")
+				}
+			case "check":
+				err := checksUpdate(ctx, c, owner, repo, p.Name(), false, r.NotifyText)
 				if err != nil {
 					return nil, err
 				}
@@ -385,6 +1137,7 @@ func runPoliciesReal(ctx context.Context, c *github.Client, owner, repo string,
 					Str("org", owner).
 					Str("repo", repo).
 					Str("area", p.Name()).
+					Fields(ids).
 					Msg("Email action configured, but not implemented yet.")
 			case "fix":
 				err := p.Fix(ctx, c, owner, repo)
@@ -396,10 +1149,20 @@ func runPoliciesReal(ctx context.Context, c *github.Client, owner, repo string,
 					Str("org", owner).
 					Str("repo", repo).
 					Str("area", p.Name()).
+					Fields(ids).
 					Str("action", a).
 					Msg("Unknown action configured.")
 			}
 		}
+		if r.Pass && a == "notify" {
+			notifyClear(owner, repo, p.Name())
+		}
+		if r.Pass && a == "check" {
+			err := checksUpdate(ctx, c, owner, repo, p.Name(), true, "")
+			if err != nil {
+				return nil, err
+			}
+		}
 		if r.Pass && (a == "issue" || a == "fix") {
 			err := issueClose(ctx, c, owner, repo, p.Name())
 			if err != nil {
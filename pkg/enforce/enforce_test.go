@@ -18,15 +18,25 @@ import (
 	"context"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"net/http"
 	"reflect"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
 	"github.com/ossf/allstar/pkg/config/operator"
+	"github.com/ossf/allstar/pkg/ghclients"
+	"github.com/ossf/allstar/pkg/issue"
 	"github.com/ossf/allstar/pkg/policydef"
+	"github.com/ossf/allstar/pkg/state"
+	"github.com/ossf/allstar/pkg/storage"
 )
 
 var policy1Results policyRepoResults
@@ -36,6 +46,18 @@ var fixCalled bool
 
 type policyRepoResults map[string]policydef.Result
 
+func init() {
+	issueEnsureSummary = func(context.Context, *github.Client, string, *issue.SummaryRun) error {
+		return nil
+	}
+	configGetOrgConfig = func(context.Context, *github.Client, string) *config.OrgConfig {
+		return &config.OrgConfig{}
+	}
+	policiesGetOrgPolicies = func() []policydef.OrgPolicy {
+		return nil
+	}
+}
+
 type pol struct{}
 
 func (p pol) Name() string {
@@ -98,23 +120,39 @@ func TestRunPolicies(t *testing.T) {
 		}
 	}
 	ensureCalled := false
-	issueEnsure = func(ctx context.Context, c *github.Client, owner, repo, policy, text string) error {
+	issueEnsure = func(ctx context.Context, c *github.Client, owner, repo, policy, text string) (*issue.Fallback, error) {
 		ensureCalled = true
-		return nil
+		return nil, nil
 	}
 	closeCalled := false
 	issueClose = func(ctx context.Context, c *github.Client, owner, repo, policy string) error {
 		closeCalled = true
 		return nil
 	}
+	notifyCalled := false
+	notifySend = func(ctx context.Context, c *github.Client, owner, repo, policy, text string) error {
+		notifyCalled = true
+		return errors.New("unreachable")
+	}
+	notifyClear = func(owner, repo, policy string) {}
+	var checkCalled, checkPass bool
+	checksUpdate = func(ctx context.Context, c *github.Client, owner, repo, policy string, pass bool, text string) error {
+		checkCalled = true
+		checkPass = pass
+		return nil
+	}
 	repo := "fake-repo"
 	tests := []struct {
 		Name              string
 		Res               policyRepoResults
 		Action            string
+		Grace             bool
 		ShouldFix         bool
 		ShouldEnsure      bool
 		ShouldClose       bool
+		ShouldNotify      bool
+		ShouldCheck       bool
+		ExpCheckPass      bool
 		ExpEnforceResults EnforceRepoResults
 	}{
 		{
@@ -182,6 +220,81 @@ func TestRunPolicies(t *testing.T) {
 				"Test policy": true,
 			},
 		},
+		{
+			Name: "GracePeriod",
+			Res: policyRepoResults{
+				"fake-repo": policydef.Result{Enabled: true, Pass: false},
+			},
+			Action:       "fix",
+			Grace:        true,
+			ShouldFix:    false,
+			ShouldEnsure: false,
+			ShouldClose:  false,
+			ExpEnforceResults: EnforceRepoResults{
+				"Test policy": false,
+			},
+		},
+		{
+			Name: "GracePeriodPass",
+			Res: policyRepoResults{
+				"fake-repo": policydef.Result{Enabled: true, Pass: true},
+			},
+			Action:       "issue",
+			Grace:        true,
+			ShouldFix:    false,
+			ShouldEnsure: false,
+			ShouldClose:  true,
+			ExpEnforceResults: EnforceRepoResults{
+				"Test policy": true,
+			},
+		},
+		{
+			Name: "NotifyErrorDoesNotFail",
+			Res: policyRepoResults{
+				"fake-repo": policydef.Result{Enabled: true, Pass: false},
+			},
+			Action:       "notify",
+			ShouldNotify: true,
+			ExpEnforceResults: EnforceRepoResults{
+				"Test policy": false,
+			},
+		},
+		{
+			Name: "CheckFail",
+			Res: policyRepoResults{
+				"fake-repo": policydef.Result{Enabled: true, Pass: false},
+			},
+			Action:       "check",
+			ShouldCheck:  true,
+			ExpCheckPass: false,
+			ExpEnforceResults: EnforceRepoResults{
+				"Test policy": false,
+			},
+		},
+		{
+			Name: "CheckPass",
+			Res: policyRepoResults{
+				"fake-repo": policydef.Result{Enabled: true, Pass: true},
+			},
+			Action:       "check",
+			ShouldCheck:  true,
+			ExpCheckPass: true,
+			ExpEnforceResults: EnforceRepoResults{
+				"Test policy": true,
+			},
+		},
+		{
+			Name: "CheckGracePeriod",
+			Res: policyRepoResults{
+				"fake-repo": policydef.Result{Enabled: true, Pass: false},
+			},
+			Action:      "check",
+			Grace:       true,
+			ShouldCheck: false,
+			ExpEnforceResults: EnforceRepoResults{
+				"Test policy": false,
+			},
+		},
 		{
 			Name: "PolicyDisabled",
 			Res: policyRepoResults{
@@ -199,10 +312,12 @@ func TestRunPolicies(t *testing.T) {
 			fixCalled = false
 			ensureCalled = false
 			closeCalled = false
+			notifyCalled = false
+			checkCalled = false
 			policy1Results = test.Res
 			action = test.Action
 
-			enforceResults, err := runPoliciesReal(context.Background(), nil, "", repo, true, "")
+			enforceResults, err := runPoliciesReal(context.Background(), nil, "", repo, true, test.Grace, nil)
 			if err != nil {
 				t.Fatalf("Unexpected error: %v", err)
 			}
@@ -227,6 +342,23 @@ func TestRunPolicies(t *testing.T) {
 					t.Error("Close called unexpectedly.")
 				}
 			}
+			if test.ShouldNotify != notifyCalled {
+				if test.ShouldNotify {
+					t.Error("Expected Send to be called")
+				} else {
+					t.Error("Send called unexpectedly.")
+				}
+			}
+			if test.ShouldCheck != checkCalled {
+				if test.ShouldCheck {
+					t.Error("Expected Update to be called")
+				} else {
+					t.Error("Update called unexpectedly.")
+				}
+			}
+			if checkCalled && test.ExpCheckPass != checkPass {
+				t.Errorf("Unexpected check result: %v", checkPass)
+			}
 			if diff := cmp.Diff(test.ExpEnforceResults, enforceResults); diff != "" {
 				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
 			}
@@ -235,6 +367,9 @@ func TestRunPolicies(t *testing.T) {
 }
 
 func TestRunPoliciesOnInstRepos(t *testing.T) {
+	getRateLimit = func(ctx context.Context, c *github.Client) (*github.Rate, error) {
+		return &github.Rate{Remaining: 5000}, nil
+	}
 	configIsBotEnabled = func(ctx context.Context, c *github.Client, owner, repo string) bool {
 		return true
 	}
@@ -244,16 +379,25 @@ func TestRunPoliciesOnInstRepos(t *testing.T) {
 	fakeOwner := "fake-owner"
 
 	tests := []struct {
-		Name           string
-		EnforceResults EnforceRepoResults
-		ExpResults     EnforceAllResults
-		ExpError       error
-		ShouldError    bool
+		Name             string
+		EnforceResults   EnforceRepoResults
+		GracePeriodDays  int
+		ExpResults       EnforceAllResults
+		ExpCounts        storage.RunCounts
+		ExpPolicyResults []storage.PolicyResult
+		ExpError         error
+		ShouldError      bool
 	}{
 		{
-			Name:        "ReturnsExpectedError",
+			Name:        "SkipsRepoOnError",
 			ShouldError: true,
-			ExpError:    failErr,
+			ExpResults:  EnforceAllResults{},
+			ExpCounts: storage.RunCounts{
+				Skipped: 1,
+				Errors: map[string]int{
+					"fake-owner/repo1": 1,
+				},
+			},
 		},
 		{
 			Name: "ReturnsExpectedOwner",
@@ -261,19 +405,64 @@ func TestRunPoliciesOnInstRepos(t *testing.T) {
 				"Test policy": true,
 			},
 			ExpResults: EnforceAllResults{},
+			ExpPolicyResults: []storage.PolicyResult{
+				{Owner: "fake-owner", Repo: "repo1", Policy: "Test policy", Pass: true},
+			},
 		},
 		{
 			Name: "ReturnsExpectedResults",
+			EnforceResults: EnforceRepoResults{
+				"Test policy2": true,
+				"Test policy":  false,
+			},
+			ExpResults: EnforceAllResults{
+				"Test policy": {
+					"totalFailed": 1,
+				},
+			},
+			ExpPolicyResults: []storage.PolicyResult{
+				{Owner: "fake-owner", Repo: "repo1", Policy: "Test policy", Pass: false},
+				{Owner: "fake-owner", Repo: "repo1", Policy: "Test policy2", Pass: true},
+			},
+		},
+		{
+			Name: "GracePeriod",
+			EnforceResults: EnforceRepoResults{
+				"Test policy2": true,
+				"Test policy":  false,
+			},
+			GracePeriodDays: 7,
+			ExpResults: EnforceAllResults{
+				"Test policy": {
+					gracePeriodCount: 1,
+				},
+			},
+			ExpPolicyResults: []storage.PolicyResult{
+				{Owner: "fake-owner", Repo: "repo1", Policy: "Test policy", Pass: false, GracePeriod: true},
+				{Owner: "fake-owner", Repo: "repo1", Policy: "Test policy2", Pass: true},
+			},
+		},
+		{
+			Name: "GracePeriodEnded",
 			EnforceResults: EnforceRepoResults{
 				"Test policy": false,
 			},
+			GracePeriodDays: 1,
 			ExpResults: EnforceAllResults{
 				"Test policy": {
 					"totalFailed": 1,
 				},
 			},
+			ExpPolicyResults: []storage.PolicyResult{
+				{Owner: "fake-owner", Repo: "repo1", Policy: "Test policy", Pass: false},
+			},
 		},
 	}
+	defer func() {
+		configGetOrgConfig = func(context.Context, *github.Client, string) *config.OrgConfig {
+			return &config.OrgConfig{}
+		}
+	}()
 
 	for _, test := range tests {
 		t.Run(test.Name, func(t *testing.T) {
@@ -284,17 +473,24 @@ func TestRunPoliciesOnInstRepos(t *testing.T) {
 					Owner: &github.User{
 						Login: &fakeOwner,
 					},
+					CreatedAt: &github.Timestamp{Time: time.Now().AddDate(0, 0, -3)},
 				},
 			}
+			configGetOrgConfig = func(context.Context, *github.Client, string) *config.OrgConfig {
+				return &config.OrgConfig{GracePeriodDays: test.GracePeriodDays}
+			}
 
-			runPolicies = func(ctx context.Context, c *github.Client, owner, repo string, enabled bool, specificPolicyArg string) (EnforceRepoResults, error) {
+			runPolicies = func(ctx context.Context, c *github.Client, owner, repo string, enabled, grace bool, due map[string]bool) (EnforceRepoResults, error) {
+				if grace != (test.GracePeriodDays > 3) {
+					t.Errorf("Unexpected grace: %v", grace)
+				}
 				if test.ShouldError {
 					return nil, failErr
 				}
 				return test.EnforceResults, nil
 			}
 
-			instResults, err := runPoliciesOnInstRepos(context.Background(), repos, client, "")
+			instResults, counts, policyResults, err := runPoliciesOnInstRepos(context.Background(), repos, client, nil)
 			if test.ExpError != nil && !errors.Is(test.ExpError, err) {
 				t.Fatalf("Error %v does not match expected error %v", err, test.ExpError)
 			}
@@ -305,11 +501,142 @@ func TestRunPoliciesOnInstRepos(t *testing.T) {
 				if diff := cmp.Diff(test.ExpResults, instResults); diff != "" {
 					t.Errorf("Unexpected results. (-want +got):\n%s", diff)
 				}
+				if diff := cmp.Diff(test.ExpCounts, counts); diff != "" {
+					t.Errorf("Unexpected counts. (-want +got):\n%s", diff)
+				}
+				for _, r := range policyResults {
+					if r.EnforcementID == "" {
+						t.Errorf("Missing enforcement ID: %+v", r)
+					}
+				}
+				if diff := cmp.Diff(test.ExpPolicyResults, policyResults,
+					cmpopts.IgnoreFields(storage.PolicyResult{}, "EnforcementID")); diff != "" {
+					t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+				}
 			}
 		})
 	}
 }
 
+func TestRunPoliciesOnInstReposErrorIsolation(t *testing.T) {
+	configIsBotEnabled = func(ctx context.Context, c *github.Client, owner, repo string) bool {
+		return true
+	}
+	getRateLimit = func(ctx context.Context, c *github.Client) (*github.Rate, error) {
+		return nil, nil
+	}
+	notFound := &github.ErrorResponse{
+		Response: &http.Response{StatusCode: http.StatusNotFound},
+		Message:  "Branch not found",
+	}
+	owner := "fake-owner"
+	var repos []*github.Repository
+	for _, n := range []string{"repo1", "gone", "repo3", "broken"} {
+		n := n
+		repos = append(repos, &github.Repository{
+			Name:  &n,
+			Owner: &github.User{Login: &owner},
+		})
+	}
+	var mu sync.Mutex
+	var ran []string
+	runPolicies = func(ctx context.Context, c *github.Client, owner, repo string, enabled, grace bool, due map[string]bool) (EnforceRepoResults, error) {
+		mu.Lock()
+		ran = append(ran, repo)
+		mu.Unlock()
+		switch repo {
+		case "gone":
+			return nil, notFound
+		case "broken":
+			return nil, errors.New("fail")
+		}
+		return EnforceRepoResults{"Test policy": false}, nil
+	}
+
+	instResults, counts, _, err := runPoliciesOnInstRepos(context.Background(), repos, github.NewClient(&http.Client{}), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := EnforceAllResults{
+		"Test policy": {
+			"totalFailed": 2,
+		},
+	}
+	if diff := cmp.Diff(want, instResults); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+	wantCounts := storage.RunCounts{
+		Skipped: 2,
+		Errors: map[string]int{
+			"fake-owner/broken": 1,
+		},
+	}
+	if diff := cmp.Diff(wantCounts, counts); diff != "" {
+		t.Errorf("Unexpected counts. (-want +got):\n%s", diff)
+	}
+	if len(ran) != len(repos) {
+		t.Errorf("Expected policies to run on %v repos, ran on %v", len(repos), ran)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runPolicies = func(ctx context.Context, c *github.Client, owner, repo string, enabled, grace bool, due map[string]bool) (EnforceRepoResults, error) {
+		cancel()
+		return nil, ctx.Err()
+	}
+	if _, _, _, err := runPoliciesOnInstRepos(ctx, repos, github.NewClient(&http.Client{}), nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestRunPoliciesOnInstReposAPICost(t *testing.T) {
+	configIsBotEnabled = func(ctx context.Context, c *github.Client, owner, repo string) bool {
+		return true
+	}
+	getRateLimit = func(ctx context.Context, c *github.Client) (*github.Rate, error) {
+		return nil, nil
+	}
+	owner := "fake-owner"
+	var repos []*github.Repository
+	for _, n := range []string{"repo1", "repo2"} {
+		n := n
+		repos = append(repos, &github.Repository{
+			Name:  &n,
+			Owner: &github.User{Login: &owner},
+		})
+	}
+	runPolicies = func(ctx context.Context, c *github.Client, owner, repo string, enabled, grace bool, due map[string]bool) (EnforceRepoResults, error) {
+		costs := ctx.Value(apiCostsKey{}).(map[string]apiCost)
+		costs["Test policy"] = apiCost{calls: 3, cost: 2}
+		return EnforceRepoResults{"Test policy": true, "Test policy2": false}, nil
+	}
+
+	instResults, _, policyResults, err := runPoliciesOnInstRepos(context.Background(), repos, github.NewClient(&http.Client{}), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := EnforceAllResults{
+		"Test policy": {
+			apiCallsCount: 6,
+			apiCostCount:  4,
+		},
+		"Test policy2": {
+			"totalFailed": 2,
+		},
+	}
+	if diff := cmp.Diff(want, instResults); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+	wantResults := []storage.PolicyResult{
+		{Owner: owner, Repo: "repo1", Policy: "Test policy", Pass: true, APICalls: 3, APICost: 2},
+		{Owner: owner, Repo: "repo1", Policy: "Test policy2"},
+		{Owner: owner, Repo: "repo2", Policy: "Test policy", Pass: true, APICalls: 3, APICost: 2},
+		{Owner: owner, Repo: "repo2", Policy: "Test policy2"},
+	}
+	if diff := cmp.Diff(wantResults, policyResults, cmpopts.IgnoreFields(storage.PolicyResult{}, "EnforcementID")); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+}
+
 func TestDoNothingOnOptOut(t *testing.T) {
 	policiesGetPolicies = func() []policydef.Policy {
 		return []policydef.Policy{
@@ -351,7 +678,7 @@ func TestDoNothingOnOptOut(t *testing.T) {
 			policy1Results = test.Res
 
 			doNothingOnOptOut = test.doNothingOnOptOut
-			enforceResults, err := runPoliciesReal(context.Background(), nil, "", repo, test.Enabled, "")
+			enforceResults, err := runPoliciesReal(context.Background(), nil, "", repo, test.Enabled, false, nil)
 			if err != nil {
 				t.Fatalf("Unexpected error: %v", err)
 			}
@@ -405,6 +732,10 @@ func TestEnforceAll(t *testing.T) {
 		return true
 	}
 
+	getRateLimit = func(ctx context.Context, c *github.Client) (*github.Rate, error) {
+		return &github.Rate{Remaining: 5000}, nil
+	}
+
 	mockGhc := &MockGhClients{}
 
 	// set back to real value to avoid test interference
@@ -563,11 +894,13 @@ func TestEnforceAll(t *testing.T) {
 func TestSuspendedEnforce(t *testing.T) {
 	var suspended bool
 	var gaicalled bool
+	login := "suspendedorg"
 	getAppInstallations = func(ctx context.Context, ac *github.Client) ([]*github.Installation, error) {
 		var insts []*github.Installation
 		appID := int64(123456)
 		inst := &github.Installation{
-			ID: &appID,
+			ID:      &appID,
+			Account: &github.User{Login: &login},
 		}
 		if suspended {
 			inst.SuspendedAt = &github.Timestamp{}
@@ -579,6 +912,11 @@ func TestSuspendedEnforce(t *testing.T) {
 		gaicalled = true
 		return nil, nil, nil
 	}
+	var alerts []string
+	notifySendOperator = func(ctx context.Context, owner, event, text string) error {
+		alerts = append(alerts, owner)
+		return nil
+	}
 	suspended = false
 	gaicalled = false
 	if _, err := EnforceAll(context.Background(), &MockGhClients{}, "", ""); err != nil {
@@ -588,12 +926,304 @@ func TestSuspendedEnforce(t *testing.T) {
 		t.Errorf("Expected getAppInstallationRepos() to be called, but wasn't")
 	}
 	suspended = true
-	gaicalled = false
+	for run := 0; run < 2; run++ {
+		gaicalled = false
+		run, err := EnforceAllRun(context.Background(), &MockGhClients{}, "", "")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if gaicalled {
+			t.Errorf("Expected getAppInstallationRepos() to not be called, but was")
+		}
+		if diff := cmp.Diff(EnforceAllResults{}, run.Summary); diff != "" {
+			t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+		}
+		exp := storage.RunCounts{
+			Suspended:    1,
+			NotMonitored: []string{login},
+		}
+		if diff := cmp.Diff(exp, run.Counts); diff != "" {
+			t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+		}
+	}
+	// The operator is alerted once per suspension.
+	if diff := cmp.Diff([]string{login}, alerts); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+	suspended = false
+	if _, err := EnforceAll(context.Background(), &MockGhClients{}, "", ""); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	suspended = true
 	if _, err := EnforceAll(context.Background(), &MockGhClients{}, "", ""); err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
-	if gaicalled {
-		t.Errorf("Expected getAppInstallationRepos() to not be called, but was")
+	if diff := cmp.Diff([]string{login, login}, alerts); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+}
+
+// TestSuspendedConcurrentEnforce counts a suspended installation while
+// another installation is enforced, run with -race.
+func TestSuspendedConcurrentEnforce(t *testing.T) {
+	SetState(state.NewMemory())
+	t.Cleanup(func() { SetState(state.NewMemory()) })
+	active, suspended := "activeorg", "suspendedorg"
+	getAppInstallations = func(ctx context.Context, ac *github.Client) ([]*github.Installation, error) {
+		return []*github.Installation{
+			{
+				ID:      github.Int64(1),
+				Account: &github.User{Login: &active},
+			},
+			{
+				ID:          github.Int64(2),
+				Account:     &github.User{Login: &suspended},
+				SuspendedAt: &github.Timestamp{},
+			},
+		}, nil
+	}
+	getAppInstallationRepos = func(ctx context.Context, ic *github.Client) ([]*github.Repository, *github.Response, error) {
+		return []*github.Repository{
+			{
+				Name:     github.String("repo"),
+				FullName: github.String(active + "/repo"),
+				Owner:    &github.User{Login: &active},
+			},
+		}, nil, nil
+	}
+	getRateLimit = func(ctx context.Context, c *github.Client) (*github.Rate, error) {
+		return &github.Rate{Remaining: 5000}, nil
+	}
+	configIsBotEnabled = func(ctx context.Context, c *github.Client, owner, repo string) bool {
+		return true
+	}
+	runPolicies = func(ctx context.Context, c *github.Client, owner, repo string, enabled, grace bool, due map[string]bool) (EnforceRepoResults, error) {
+		return EnforceRepoResults{"Test policy": false}, nil
+	}
+	notifySendOperator = func(ctx context.Context, owner, event, text string) error {
+		return nil
+	}
+
+	run, err := EnforceAllRun(context.Background(), &MockGhClients{}, "", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(EnforceAllResults{"Test policy": {"totalFailed": 1}}, run.Summary); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+	exp := storage.RunCounts{
+		Suspended:    1,
+		NotMonitored: []string{suspended},
+	}
+	if diff := cmp.Diff(exp, run.Counts); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+}
+
+func TestSuspendedRestart(t *testing.T) {
+	ctx := context.Background()
+	st := state.NewMemory()
+	SetState(st)
+	t.Cleanup(func() { SetState(state.NewMemory()) })
+	var alerts int
+	notifySendOperator = func(ctx context.Context, owner, event, text string) error {
+		alerts++
+		return nil
+	}
+	id := int64(42)
+	at := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	inst := &github.Installation{
+		ID:          &id,
+		Account:     &github.User{Login: github.String("acme")},
+		SuspendedAt: &github.Timestamp{Time: at},
+	}
+
+	handleSuspended(ctx, inst)
+	// Restarting loads the known suspensions from the state store.
+	SetState(st)
+	handleSuspended(ctx, inst)
+	if alerts != 1 {
+		t.Errorf("Expected one alert across restarts, got %v", alerts)
+	}
+
+	clearSuspended(ctx, inst)
+	if keys, _ := st.List(ctx, ""); len(keys) != 0 {
+		t.Errorf("Expected suspension state to be cleared, got: %v", keys)
+	}
+}
+
+type mockStore struct {
+	saved    []*storage.RunResult
+	prunedAt []time.Time
+	saveErr  error
+}
+
+func (m *mockStore) SaveRunResult(ctx context.Context, r *storage.RunResult) error {
+	if m.saveErr != nil {
+		return m.saveErr
+	}
+	r.ID = int64(len(m.saved) + 1)
+	m.saved = append(m.saved, r)
+	return nil
+}
+
+func (m *mockStore) GetLatest(ctx context.Context) (*storage.RunResult, error) {
+	return nil, storage.ErrNotFound
+}
+
+func (m *mockStore) ListRuns(ctx context.Context, limit int) ([]*storage.RunResult, error) {
+	return nil, nil
+}
+
+func (m *mockStore) Prune(ctx context.Context, before time.Time) (int, error) {
+	m.prunedAt = append(m.prunedAt, before)
+	return 0, nil
+}
+
+func (m *mockStore) Close() error {
+	return nil
+}
+
+func TestEnforceAllSavesRun(t *testing.T) {
+	login := "org"
+	getAppInstallations = func(ctx context.Context, ac *github.Client) ([]*github.Installation, error) {
+		id := int64(1)
+		return []*github.Installation{
+			{ID: &id, Account: &github.User{Login: &login}},
+		}, nil
+	}
+	getAppInstallationRepos = func(ctx context.Context, ic *github.Client) ([]*github.Repository, *github.Response, error) {
+		name := "repo1"
+		return []*github.Repository{
+			{Name: &name, Owner: &github.User{Login: &login}},
+		}, nil, nil
+	}
+	getRateLimit = func(ctx context.Context, c *github.Client) (*github.Rate, error) {
+		return &github.Rate{Remaining: 5000}, nil
+	}
+	configIsBotEnabled = func(ctx context.Context, c *github.Client, owner, repo string) bool {
+		return true
+	}
+	runPolicies = func(ctx context.Context, c *github.Client, owner, repo string, enabled, grace bool, due map[string]bool) (EnforceRepoResults, error) {
+		return EnforceRepoResults{"Test policy": false}, nil
+	}
+
+	ms := &mockStore{}
+	SetStorage(ms)
+	defer SetStorage(nil)
+
+	if _, err := EnforceAll(context.Background(), &MockGhClients{}, "Test policy", ""); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(ms.saved) != 1 {
+		t.Fatalf("Expected one saved run, got %v", len(ms.saved))
+	}
+	r := ms.saved[0]
+	if r.ID != 1 || r.Policy != "Test policy" || r.Finished.Before(r.Started) || r.RunID == "" {
+		t.Errorf("Unexpected run: %+v", r)
+	}
+	if len(r.Results) == 1 && r.Results[0].EnforcementID == "" {
+		t.Errorf("Missing enforcement ID: %+v", r.Results[0])
+	}
+	exp := []storage.PolicyResult{
+		{Owner: login, Repo: "repo1", Policy: "Test policy", Pass: false},
+	}
+	if diff := cmp.Diff(exp, r.Results, cmpopts.IgnoreFields(storage.PolicyResult{}, "EnforcementID")); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(EnforceAllResults{"Test policy": {"totalFailed": 1}}, r.Summary); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]time.Time{r.Started.Add(-operator.StorageRetention)}, ms.prunedAt); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+
+	// Storage errors don't fail the run, and skip pruning.
+	ms.saveErr = errors.New("disk full")
+	if _, err := EnforceAll(context.Background(), &MockGhClients{}, "", ""); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(ms.prunedAt) != 1 {
+		t.Errorf("Expected no prune after failed save, got %v", len(ms.prunedAt))
+	}
+}
+
+func TestEnforceAllSummary(t *testing.T) {
+	login := "org"
+	getAppInstallations = func(ctx context.Context, ac *github.Client) ([]*github.Installation, error) {
+		id := int64(1)
+		return []*github.Installation{
+			{ID: &id, Account: &github.User{Login: &login}},
+		}, nil
+	}
+	getAppInstallationRepos = func(ctx context.Context, ic *github.Client) ([]*github.Repository, *github.Response, error) {
+		repo1 := "repo1"
+		repo2 := "repo2"
+		return []*github.Repository{
+			{Name: &repo1, FullName: github.String("org/repo1"), Owner: &github.User{Login: &login}},
+			{Name: &repo2, FullName: github.String("org/repo2"), Owner: &github.User{Login: &login}},
+		}, nil, nil
+	}
+	getRateLimit = func(ctx context.Context, c *github.Client) (*github.Rate, error) {
+		return &github.Rate{Remaining: 5000}, nil
+	}
+	configIsBotEnabled = func(ctx context.Context, c *github.Client, owner, repo string) bool {
+		return true
+	}
+	runPolicies = func(ctx context.Context, c *github.Client, owner, repo string, enabled, grace bool, due map[string]bool) (EnforceRepoResults, error) {
+		return EnforceRepoResults{"Test policy": repo == "repo1"}, nil
+	}
+	defer func() {
+		issueEnsureSummary = func(context.Context, *github.Client, string, *issue.SummaryRun) error {
+			return nil
+		}
+	}()
+
+	tests := []struct {
+		Name   string
+		Policy string
+		Repo   string
+		Exp    *issue.SummaryRun
+	}{
+		{
+			Name: "All",
+			Exp: &issue.SummaryRun{
+				Results: []issue.SummaryResult{
+					{Repo: "repo1", Policy: "Test policy", Pass: true},
+					{Repo: "repo2", Policy: "Test policy", Pass: false},
+				},
+			},
+		},
+		{
+			Name:   "SpecificPolicyRepo",
+			Policy: "Test policy",
+			Repo:   "org/repo2",
+			Exp: &issue.SummaryRun{
+				Policies: map[string]bool{"Test policy": true},
+				Repo:     "repo2",
+				Results: []issue.SummaryResult{
+					{Repo: "repo2", Policy: "Test policy", Pass: false},
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var got *issue.SummaryRun
+			issueEnsureSummary = func(ctx context.Context, c *github.Client, owner string, run *issue.SummaryRun) error {
+				if owner != login {
+					t.Errorf("Unexpected owner: %v", owner)
+				}
+				got = run
+				return errors.New("issues disabled")
+			}
+			if _, err := EnforceAll(context.Background(), &MockGhClients{}, test.Policy, test.Repo); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.Exp, got); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
 	}
 }
 
@@ -606,11 +1236,10 @@ func injective(s string) int64 {
 
 func TestAllowedRepositories(t *testing.T) {
 	tests := []struct {
-		desc       string
-		orgs       []string
-		allowlist  []string
-		expected   []string
-		disallowed []string
+		desc      string
+		orgs      []string
+		allowlist []string
+		expected  []string
 	}{
 		{
 			desc:      "all explicitly allowed",
@@ -625,11 +1254,10 @@ func TestAllowedRepositories(t *testing.T) {
 			expected:  []string{"org-1", "org-1"},
 		},
 		{
-			desc:       "none allowed",
-			orgs:       []string{"org-1", "org-1", "org-2"},
-			allowlist:  []string{"org-3"},
-			expected:   []string{},
-			disallowed: []string{"org-1", "org-1", "org-2"},
+			desc:      "none allowed",
+			orgs:      []string{"org-1", "org-1", "org-2"},
+			allowlist: []string{"org-3"},
+			expected:  []string{},
 		},
 		{
 			desc:      "empty allowlist allows all by default",
@@ -652,12 +1280,6 @@ func TestAllowedRepositories(t *testing.T) {
 				return repos, nil
 			}
 
-			removed := []int64{}
-			deleteInstallation = func(ctx context.Context, ic *github.Client, instID int64) (*github.Response, error) {
-				removed = append(removed, instID)
-				return &github.Response{Response: &http.Response{StatusCode: 200}}, nil
-			}
-
 			getAppInstallations = getAppInstallationsReal
 			operator.AllowedOrganizations = tt.allowlist
 			insts, err := getAppInstallations(context.Background(), &github.Client{})
@@ -665,20 +1287,6 @@ func TestAllowedRepositories(t *testing.T) {
 				t.Fatalf("unexpected error: %v", err)
 			}
 
-			// Ensure that all repos expected to be disallowed are also uninstalled
-			for _, r := range tt.disallowed {
-				i := injective(r)
-				var contains bool
-				for _, r := range removed {
-					if r == i {
-						contains = true
-					}
-				}
-				if !contains {
-					t.Errorf("expected disallowed repo %s to be removed but wasn't", r)
-				}
-			}
-
 			// Ensure that returned repos as expected
 			rn := []string{}
 			for _, r := range insts {
@@ -690,3 +1298,275 @@ func TestAllowedRepositories(t *testing.T) {
 		})
 	}
 }
+
+func TestRunPoliciesOnInstReposConcurrent(t *testing.T) {
+	configIsBotEnabled = func(ctx context.Context, c *github.Client, owner, repo string) bool {
+		return true
+	}
+	getRateLimit = func(ctx context.Context, c *github.Client) (*github.Rate, error) {
+		return &github.Rate{Remaining: 5000}, nil
+	}
+	saved := operator.NumRepoWorkers
+	defer func() { operator.NumRepoWorkers = saved }()
+	operator.NumRepoWorkers = 3
+
+	var mu sync.Mutex
+	var running, maxRunning int
+	runPolicies = func(ctx context.Context, c *github.Client, owner, repo string, enabled, grace bool, due map[string]bool) (EnforceRepoResults, error) {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return EnforceRepoResults{
+			"Test policy":  repo != "repo0",
+			"Test policy2": false,
+		}, nil
+	}
+
+	owner := "fake-owner"
+	var repos []*github.Repository
+	for i := 0; i < 20; i++ {
+		repos = append(repos, &github.Repository{
+			Name:  github.String(fmt.Sprintf("repo%d", i)),
+			Owner: &github.User{Login: &owner},
+		})
+	}
+	instResults, _, _, err := runPoliciesOnInstRepos(context.Background(), repos, github.NewClient(&http.Client{}), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	exp := EnforceAllResults{
+		"Test policy":  {"totalFailed": 1},
+		"Test policy2": {"totalFailed": 20},
+	}
+	if diff := cmp.Diff(exp, instResults); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+	if maxRunning > 3 {
+		t.Errorf("Expected at most 3 concurrent repos, got %v", maxRunning)
+	}
+}
+
+func TestWaitForRateLimit(t *testing.T) {
+	saved := operator.RateLimitReserve
+	defer func() { operator.RateLimitReserve = saved }()
+	operator.RateLimitReserve = 100
+
+	tests := []struct {
+		Name   string
+		Rate   *github.Rate
+		Err    error
+		ExpErr bool
+	}{
+		{
+			Name: "Plenty",
+			Rate: &github.Rate{Remaining: 1000, Reset: github.Timestamp{Time: time.Now().Add(time.Hour)}},
+		},
+		{
+			Name: "LowAlreadyReset",
+			Rate: &github.Rate{Remaining: 10, Reset: github.Timestamp{Time: time.Now().Add(-time.Minute)}},
+		},
+		{
+			Name:   "LowWaits",
+			Rate:   &github.Rate{Remaining: 10, Reset: github.Timestamp{Time: time.Now().Add(time.Hour)}},
+			ExpErr: true,
+		},
+		{
+			Name: "ErrorContinues",
+			Err:  errors.New("fail"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			getRateLimit = func(ctx context.Context, c *github.Client) (*github.Rate, error) {
+				return test.Rate, test.Err
+			}
+			// A cancelled context stops the wait, so a wait shows as an error.
+			ctx, cf := context.WithCancel(context.Background())
+			cf()
+			err := waitForRateLimit(ctx, nil)
+			if test.ExpErr != (err != nil) {
+				t.Errorf("Unexpected error result: %v", err)
+			}
+		})
+	}
+}
+
+func TestOperatorRepoLists(t *testing.T) {
+	savedAllowed, savedDenied := operator.AllowedRepos, operator.DeniedRepos
+	defer func() {
+		operator.AllowedRepos, operator.DeniedRepos = savedAllowed, savedDenied
+	}()
+	login := "acme"
+	getAppInstallations = func(ctx context.Context, ac *github.Client) ([]*github.Installation, error) {
+		id := int64(123)
+		return []*github.Installation{{ID: &id, Account: &github.User{Login: &login}}}, nil
+	}
+	getAppInstallationRepos = func(ctx context.Context, ic *github.Client) ([]*github.Repository, *github.Response, error) {
+		var repos []*github.Repository
+		for _, n := range []string{"api", "api-mirror", "web", "Tools"} {
+			repos = append(repos, &github.Repository{
+				Name:     github.String(n),
+				FullName: github.String(login + "/" + n),
+				Owner:    &github.User{Login: &login},
+			})
+		}
+		return repos, nil, nil
+	}
+	configIsBotEnabled = func(ctx context.Context, c *github.Client, owner, repo string) bool {
+		return true
+	}
+	configGetOrgConfig = func(ctx context.Context, c *github.Client, owner string) *config.OrgConfig {
+		return &config.OrgConfig{}
+	}
+	getRateLimit = func(ctx context.Context, c *github.Client) (*github.Rate, error) {
+		return &github.Rate{Remaining: 5000}, nil
+	}
+	issueEnsureSummary = func(ctx context.Context, c *github.Client, owner string, run *issue.SummaryRun) error {
+		return nil
+	}
+	var mu sync.Mutex
+	var enforced []string
+	runPolicies = func(ctx context.Context, c *github.Client, owner, repo string, enabled, grace bool, due map[string]bool) (EnforceRepoResults, error) {
+		mu.Lock()
+		enforced = append(enforced, repo)
+		mu.Unlock()
+		return EnforceRepoResults{}, nil
+	}
+
+	tests := []struct {
+		desc     string
+		allowed  []string
+		denied   []string
+		expected []string
+		excluded int
+	}{
+		{
+			desc:     "no lists",
+			expected: []string{"Tools", "api", "api-mirror", "web"},
+		},
+		{
+			desc:     "allow list",
+			allowed:  []string{"acme/api*"},
+			expected: []string{"api", "api-mirror"},
+			excluded: 2,
+		},
+		{
+			desc:     "deny takes precedence",
+			allowed:  []string{"acme/api*"},
+			denied:   []string{"acme/*-mirror"},
+			expected: []string{"api"},
+			excluded: 3,
+		},
+		{
+			desc:     "case insensitive",
+			denied:   []string{"ACME/tools"},
+			expected: []string{"api", "api-mirror", "web"},
+			excluded: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			operator.AllowedRepos = test.allowed
+			operator.DeniedRepos = test.denied
+			enforced = nil
+			run, err := EnforceAllRun(context.Background(), &MockGhClients{}, "", "")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			sort.Strings(enforced)
+			if diff := cmp.Diff(test.expected, enforced); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+			if got := run.Counts.Excluded; got != test.excluded {
+				t.Errorf("Expected %v excluded, got %v", test.excluded, got)
+			}
+		})
+	}
+}
+
+type recordingGhClients struct {
+	MockGhClients
+	mu  sync.Mutex
+	ids []int64
+}
+
+func (m *recordingGhClients) Get(i int64) (*github.Client, error) {
+	if i != 0 {
+		m.mu.Lock()
+		m.ids = append(m.ids, i)
+		m.mu.Unlock()
+	}
+	return m.MockGhClients.Get(i)
+}
+
+func TestEnforceAllShard(t *testing.T) {
+	getAppInstallations = func(ctx context.Context, ac *github.Client) ([]*github.Installation, error) {
+		var insts []*github.Installation
+		for id := int64(1); id <= 6; id++ {
+			insts = append(insts, &github.Installation{
+				ID:      github.Int64(id),
+				Account: &github.User{Login: github.String(fmt.Sprintf("org%d", id))},
+			})
+		}
+		return insts, nil
+	}
+	getAppInstallationRepos = func(ctx context.Context, ic *github.Client) ([]*github.Repository, *github.Response, error) {
+		return []*github.Repository{
+			{
+				Name:     github.String("repo"),
+				FullName: github.String("org3/repo"),
+				Owner:    &github.User{Login: github.String("org3")},
+			},
+		}, nil, nil
+	}
+	getRateLimit = func(ctx context.Context, c *github.Client) (*github.Rate, error) {
+		return &github.Rate{Remaining: 5000}, nil
+	}
+	configIsBotEnabled = func(ctx context.Context, c *github.Client, owner, repo string) bool {
+		return true
+	}
+	runPolicies = func(ctx context.Context, c *github.Client, owner, repo string, enabled, grace bool, due map[string]bool) (EnforceRepoResults, error) {
+		return EnforceRepoResults{"Test policy": true}, nil
+	}
+	defer SetShard(ghclients.Shard{})
+
+	// Each installation is enforced by exactly one shard.
+	seen := make(map[int64]int)
+	for i := 0; i < 2; i++ {
+		SetShard(ghclients.Shard{Index: i, Count: 2})
+		ghc := &recordingGhClients{}
+		if _, err := EnforceAll(context.Background(), ghc, "", ""); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(ghc.ids) == 0 || len(ghc.ids) == 6 {
+			t.Errorf("Shard %d enforced %d installations", i, len(ghc.ids))
+		}
+		for _, id := range ghc.ids {
+			seen[id]++
+		}
+	}
+	for id := int64(1); id <= 6; id++ {
+		if seen[id] != 1 {
+			t.Errorf("Installation %d enforced by %d shards", id, seen[id])
+		}
+	}
+
+	// A specific repo is enforced by any shard.
+	for i := 0; i < 2; i++ {
+		SetShard(ghclients.Shard{Index: i, Count: 2})
+		ghc := &recordingGhClients{}
+		if _, err := EnforceAll(context.Background(), ghc, "", "org3/repo"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if diff := cmp.Diff([]int64{3}, ghc.ids); diff != "" {
+			t.Errorf("Unexpected installations. (-want +got):\n%s", diff)
+		}
+	}
+}
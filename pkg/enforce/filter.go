@@ -0,0 +1,169 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforce
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/google/go-github/v59/github"
+)
+
+// orgFilter are glob patterns of the orgs that policies are enforced on, set
+// with SetOrgFilter.
+var orgFilter []string
+
+// SetOrgFilter limits enforcement to the installations of the orgs, or users,
+// matching one of the glob patterns, eg: "myorg" or "team-*". Nil enforces on
+// all installations, the default. Patterns are matched case-insensitively.
+func SetOrgFilter(orgs []string) {
+	orgFilter = orgs
+}
+
+// ParseOrgFilter splits an org filter, comma separated glob patterns such as
+// "myorg,team-*", into its patterns. An error is returned if a pattern is not
+// a valid glob.
+func ParseOrgFilter(s string) ([]string, error) {
+	var patterns []string
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid org pattern %q: %w", p, err)
+		}
+		patterns = append(patterns, p)
+	}
+	if len(patterns) == 0 && strings.TrimSpace(s) != "" {
+		return nil, errors.New("empty org filter")
+	}
+	return patterns, nil
+}
+
+// ParseRepoFilter splits a repo filter, comma separated "owner/repo" glob
+// patterns such as "myorg/service-*,otherorg/api", into its patterns. An
+// error is returned if a pattern is not of the form "owner/repo", or is not a
+// valid glob.
+func ParseRepoFilter(s string) ([]string, error) {
+	var patterns []string
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		owner, repo, ok := strings.Cut(p, "/")
+		if !ok || owner == "" || repo == "" {
+			return nil, fmt.Errorf("invalid repo pattern %q, expected \"owner/repo\"", p)
+		}
+		if _, err := gc.Compile(strings.ToLower(p)); err != nil {
+			return nil, fmt.Errorf("invalid repo pattern %q: %w", p, err)
+		}
+		patterns = append(patterns, p)
+	}
+	if len(patterns) == 0 && strings.TrimSpace(s) != "" {
+		return nil, errors.New("empty repo filter")
+	}
+	return patterns, nil
+}
+
+// filterOrgs returns the installations of insts on orgs in the org filter.
+func filterOrgs(insts []*github.Installation) []*github.Installation {
+	if len(orgFilter) == 0 {
+		return insts
+	}
+	var kept []*github.Installation
+	for _, i := range insts {
+		if matches(orgFilter, strings.ToLower(i.GetAccount().GetLogin())) {
+			kept = append(kept, i)
+		}
+	}
+	return kept
+}
+
+// repoFilterOwner returns whether any repo of owner may match one of the
+// patterns of the repo filter, and the repo name patterns that apply to
+// owner.
+func repoFilterOwner(patterns []string, owner string) (bool, []string) {
+	var names []string
+	for _, p := range patterns {
+		o, name, _ := strings.Cut(p, "/")
+		if matches([]string{o}, strings.ToLower(owner)) {
+			names = append(names, name)
+		}
+	}
+	return len(names) > 0, names
+}
+
+// filterSpecificRepos returns the repos whose full name matches one of the
+// patterns of the repo filter.
+func filterSpecificRepos(repos []*github.Repository, patterns []string) []*github.Repository {
+	var kept []*github.Repository
+	for _, r := range repos {
+		if matches(patterns, strings.ToLower(r.GetFullName())) {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// ParsePolicyFilter splits a policy filter, comma separated policy names such
+// as "Branch Protection,Binary Artifacts", into the set of policies to run. An
+// empty filter returns nil, to run all policies. An error is returned if a
+// name is not a repo or org-scope policy.
+func ParsePolicyFilter(s string) (map[string]bool, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	known := make(map[string]bool)
+	for _, p := range policiesGetPolicies() {
+		known[p.Name()] = true
+	}
+	for _, p := range policiesGetOrgPolicies() {
+		known[p.Name()] = true
+	}
+	set := make(map[string]bool)
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !known[name] {
+			return nil, fmt.Errorf("unsupported policy %q", name)
+		}
+		set[name] = true
+	}
+	if len(set) == 0 {
+		return nil, errors.New("empty policy filter")
+	}
+	return set, nil
+}
+
+// filterPolicies returns the due policies that are also in the policy filter.
+// Nil for either means all policies.
+func filterPolicies(due, filter map[string]bool) map[string]bool {
+	if filter == nil {
+		return due
+	}
+	rv := make(map[string]bool)
+	for p := range filter {
+		if due == nil || due[p] {
+			rv[p] = true
+		}
+	}
+	return rv
+}
@@ -0,0 +1,281 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforce
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/policydef"
+)
+
+func TestParseOrgFilter(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Filter string
+		Exp    []string
+		ExpErr bool
+	}{
+		{
+			Name: "Empty",
+		},
+		{
+			Name:   "List",
+			Filter: "ossf, team-*,",
+			Exp:    []string{"ossf", "team-*"},
+		},
+		{
+			Name:   "InvalidGlob",
+			Filter: "ossf,team-[",
+			ExpErr: true,
+		},
+		{
+			Name:   "OnlyCommas",
+			Filter: ",,",
+			ExpErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got, err := ParseOrgFilter(test.Filter)
+			if (err != nil) != test.ExpErr {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.Exp, got); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseRepoFilter(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Filter string
+		Exp    []string
+		ExpErr bool
+	}{
+		{
+			Name: "Empty",
+		},
+		{
+			Name:   "Single",
+			Filter: "ossf/allstar",
+			Exp:    []string{"ossf/allstar"},
+		},
+		{
+			Name:   "List",
+			Filter: "myorg/service-*, otherorg/api,",
+			Exp:    []string{"myorg/service-*", "otherorg/api"},
+		},
+		{
+			Name:   "NoOwner",
+			Filter: "allstar",
+			ExpErr: true,
+		},
+		{
+			Name:   "InvalidGlob",
+			Filter: "myorg/service-[",
+			ExpErr: true,
+		},
+		{
+			Name:   "OnlyCommas",
+			Filter: ",,",
+			ExpErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got, err := ParseRepoFilter(test.Filter)
+			if (err != nil) != test.ExpErr {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.Exp, got); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEnforceAllFilter(t *testing.T) {
+	getAppInstallations = func(ctx context.Context, ac *github.Client) ([]*github.Installation, error) {
+		var insts []*github.Installation
+		for i, login := range []string{"myorg", "otherorg", "team-a"} {
+			insts = append(insts, &github.Installation{
+				ID:      github.Int64(int64(i + 1)),
+				Account: &github.User{Login: github.String(login)},
+			})
+		}
+		return insts, nil
+	}
+	// Each installation has the same repo names.
+	getAppInstallationRepos = func(ctx context.Context, ic *github.Client) ([]*github.Repository, *github.Response, error) {
+		login := ic.BaseURL.Path
+		var repos []*github.Repository
+		for _, name := range []string{"service-a", "service-b", "api"} {
+			repos = append(repos, &github.Repository{
+				Name:     github.String(name),
+				FullName: github.String(login + "/" + name),
+				Owner:    &github.User{Login: github.String(login)},
+			})
+		}
+		return repos, nil, nil
+	}
+	getRateLimit = func(ctx context.Context, c *github.Client) (*github.Rate, error) {
+		return &github.Rate{Remaining: 5000}, nil
+	}
+	configIsBotEnabled = func(ctx context.Context, c *github.Client, owner, repo string) bool {
+		return true
+	}
+	var mu sync.Mutex
+	var enforced []string
+	runPolicies = func(ctx context.Context, c *github.Client, owner, repo string, enabled, grace bool, due map[string]bool) (EnforceRepoResults, error) {
+		mu.Lock()
+		enforced = append(enforced, owner+"/"+repo)
+		mu.Unlock()
+		return EnforceRepoResults{"Test policy": true}, nil
+	}
+	defer SetOrgFilter(nil)
+
+	tests := []struct {
+		Name  string
+		Orgs  []string
+		Repos string
+		Exp   []string
+	}{
+		{
+			Name:  "RepoGlob",
+			Repos: "myorg/service-*",
+			Exp:   []string{"myorg/service-a", "myorg/service-b"},
+		},
+		{
+			Name:  "RepoList",
+			Repos: "MyOrg/api,otherorg/service-b",
+			Exp:   []string{"myorg/api", "otherorg/service-b"},
+		},
+		{
+			Name:  "OwnerGlob",
+			Repos: "*/api",
+			Exp:   []string{"myorg/api", "otherorg/api", "team-a/api"},
+		},
+		{
+			Name: "Org",
+			Orgs: []string{"team-*"},
+			Exp:  []string{"team-a/api", "team-a/service-a", "team-a/service-b"},
+		},
+		{
+			Name:  "OrgAndRepo",
+			Orgs:  []string{"myorg", "otherorg"},
+			Repos: "*/service-a",
+			Exp:   []string{"myorg/service-a", "otherorg/service-a"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			enforced = nil
+			SetOrgFilter(test.Orgs)
+			if _, err := EnforceAll(context.Background(), &loginGhClients{}, "", test.Repos); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			sort.Strings(enforced)
+			if diff := cmp.Diff(test.Exp, enforced); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// loginGhClients returns clients identifying the login of their installation
+// in the path of their base URL, for fakes of the installation repos.
+type loginGhClients struct {
+	MockGhClients
+}
+
+func (m *loginGhClients) Get(i int64) (*github.Client, error) {
+	c := github.NewClient(nil)
+	c.BaseURL.Path = map[int64]string{1: "myorg", 2: "otherorg", 3: "team-a"}[i]
+	return c, nil
+}
+
+func TestParsePolicyFilter(t *testing.T) {
+	policiesGetPolicies = func() []policydef.Policy {
+		return []policydef.Policy{pol{}, pol2{}}
+	}
+	policiesGetOrgPolicies = func() []policydef.OrgPolicy {
+		return []policydef.OrgPolicy{orgPol{name: "Org policy"}}
+	}
+	defer func() {
+		policiesGetOrgPolicies = func() []policydef.OrgPolicy { return nil }
+	}()
+	tests := []struct {
+		Name   string
+		Filter string
+		Exp    map[string]bool
+		ExpErr bool
+	}{
+		{
+			Name: "Empty",
+		},
+		{
+			Name:   "Single",
+			Filter: "Test policy",
+			Exp:    map[string]bool{"Test policy": true},
+		},
+		{
+			Name:   "List",
+			Filter: "Test policy, Test policy2,Org policy",
+			Exp:    map[string]bool{"Test policy": true, "Test policy2": true, "Org policy": true},
+		},
+		{
+			Name:   "Unknown",
+			Filter: "Test policy,Nope",
+			ExpErr: true,
+		},
+		{
+			Name:   "OnlyCommas",
+			Filter: ",",
+			ExpErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got, err := ParsePolicyFilter(test.Filter)
+			if (err != nil) != test.ExpErr {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.Exp, got); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFilterPolicies(t *testing.T) {
+	due := map[string]bool{"A": true, "B": true}
+	if got := filterPolicies(due, nil); !cmp.Equal(due, got) {
+		t.Errorf("Expected due without filter, got: %v", got)
+	}
+	filter := map[string]bool{"B": true, "C": true}
+	if diff := cmp.Diff(filter, filterPolicies(nil, filter)); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]bool{"B": true}, filterPolicies(due, filter)); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+}
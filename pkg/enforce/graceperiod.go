@@ -0,0 +1,96 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforce
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/state"
+
+	"github.com/rs/zerolog/log"
+)
+
+// policyGracePeriod gets the grace period of violations of the policy in the
+// org, zero if none is configured.
+func policyGracePeriod(oc *config.OrgConfig, owner, name string) time.Duration {
+	ds, ok := oc.GracePeriods[name]
+	if !ok {
+		return 0
+	}
+	d, err := time.ParseDuration(ds)
+	if err != nil || d < 0 {
+		log.Warn().
+			Str("org", owner).
+			Str("area", name).
+			Str("gracePeriod", ds).
+			Err(err).
+			Msg("Malformed policy grace period in org config, ignoring.")
+		return 0
+	}
+	return d
+}
+
+func firstSeenKey(owner, repo, policy string) string {
+	return state.Key("enforce", "firstseen", owner, repo, policy)
+}
+
+// violationInGrace records when the policy was first seen failing on the repo,
+// if not already recorded, and returns whether the violation is still within
+// the grace period at now. State store errors are logged and the violation is
+// treated as past its grace period, so actions are not held back indefinitely.
+func violationInGrace(ctx context.Context, owner, repo, policy string, period time.Duration, now time.Time) bool {
+	key := firstSeenKey(owner, repo, policy)
+	var first time.Time
+	err := state.GetJSON(ctx, stateStore, key, &first)
+	if errors.Is(err, state.ErrNotFound) {
+		first = now
+		err = state.PutJSON(ctx, stateStore, key, first)
+	}
+	if err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", policy).
+			Err(err).
+			Msg("Unexpected error recording first seen violation, grace period not applied.")
+		return false
+	}
+	return now.Before(first.Add(period))
+}
+
+// clearFirstSeen forgets when the policy was first seen failing on the repo,
+// so the next violation starts a new grace period.
+func clearFirstSeen(ctx context.Context, owner, repo, policy string) {
+	if err := stateStore.Delete(ctx, firstSeenKey(owner, repo, policy)); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", policy).
+			Err(err).
+			Msg("Unexpected error clearing first seen violation.")
+	}
+}
+
+type heldKey struct{}
+
+// withHeld returns a copy of ctx that runPoliciesReal records the policies
+// whose actions were held back in their grace period to.
+func withHeld(ctx context.Context) (context.Context, map[string]bool) {
+	held := make(map[string]bool)
+	return context.WithValue(ctx, heldKey{}, held), held
+}
@@ -0,0 +1,139 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforce
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/issue"
+	"github.com/ossf/allstar/pkg/policydef"
+	"github.com/ossf/allstar/pkg/state"
+)
+
+func TestPolicyGracePeriod(t *testing.T) {
+	oc := &config.OrgConfig{
+		GracePeriods: map[string]string{
+			"Test policy":  "72h",
+			"Test policy2": "soon",
+		},
+	}
+	if got := policyGracePeriod(oc, "org", "Test policy"); got != 72*time.Hour {
+		t.Errorf("Unexpected grace period: %v", got)
+	}
+	if got := policyGracePeriod(oc, "org", "Test policy2"); got != 0 {
+		t.Errorf("Unexpected grace period for malformed duration: %v", got)
+	}
+	if got := policyGracePeriod(oc, "org", "Other"); got != 0 {
+		t.Errorf("Unexpected grace period for unset policy: %v", got)
+	}
+}
+
+func TestRunPoliciesViolationGrace(t *testing.T) {
+	policiesGetPolicies = func() []policydef.Policy {
+		return []policydef.Policy{
+			pol{},
+		}
+	}
+	configGetOrgConfig = func(context.Context, *github.Client, string) *config.OrgConfig {
+		return &config.OrgConfig{
+			GracePeriods: map[string]string{"Test policy": "72h"},
+		}
+	}
+	defer func() {
+		configGetOrgConfig = func(context.Context, *github.Client, string) *config.OrgConfig {
+			return &config.OrgConfig{}
+		}
+	}()
+	ensureCalled := false
+	issueEnsure = func(ctx context.Context, c *github.Client, owner, repo, policy, text string) (*issue.Fallback, error) {
+		ensureCalled = true
+		return nil, nil
+	}
+	issueClose = func(ctx context.Context, c *github.Client, owner, repo, policy string) error {
+		return nil
+	}
+	notifyCalled := false
+	notifySend = func(ctx context.Context, c *github.Client, owner, repo, policy, text string) error {
+		notifyCalled = true
+		return nil
+	}
+	notifyClear = func(owner, repo, policy string) {}
+	saved := stateStore
+	defer func() { stateStore = saved }()
+	stateStore = state.NewMemory()
+	ctx := context.Background()
+	key := firstSeenKey("fake-owner", "fake-repo", "Test policy")
+
+	run := func() map[string]bool {
+		ensureCalled = false
+		notifyCalled = false
+		hctx, held := withHeld(ctx)
+		if _, err := runPoliciesReal(hctx, nil, "fake-owner", "fake-repo", true, false, nil); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return held
+	}
+
+	// First detection is held back.
+	action = "issue"
+	policy1Results = policyRepoResults{
+		"fake-repo": policydef.Result{Enabled: true, Pass: false},
+	}
+	if held := run(); !held["Test policy"] {
+		t.Error("Expected action to be held")
+	}
+	if ensureCalled {
+		t.Error("Ensure called unexpectedly in grace period.")
+	}
+	var first time.Time
+	if err := state.GetJSON(ctx, stateStore, key, &first); err != nil {
+		t.Fatalf("Expected first seen to be recorded: %v", err)
+	}
+
+	// Notifications are not held back.
+	action = "notify"
+	if held := run(); held["Test policy"] {
+		t.Error("Notify action held unexpectedly")
+	}
+	if !notifyCalled {
+		t.Error("Expected Send to be called")
+	}
+
+	// Still failing after the grace period.
+	action = "issue"
+	if err := state.PutJSON(ctx, stateStore, key, first.Add(-73*time.Hour)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if held := run(); held["Test policy"] {
+		t.Error("Action held unexpectedly after grace period")
+	}
+	if !ensureCalled {
+		t.Error("Expected Ensure to be called")
+	}
+
+	// Passing forgets the violation.
+	policy1Results = policyRepoResults{
+		"fake-repo": policydef.Result{Enabled: true, Pass: true},
+	}
+	run()
+	if _, err := stateStore.Get(ctx, key); !errors.Is(err, state.ErrNotFound) {
+		t.Errorf("Expected first seen to be cleared, got: %v", err)
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforce
+
+import (
+	"context"
+
+	"github.com/ossf/allstar/pkg/audit"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/enforceid"
+	"github.com/ossf/allstar/pkg/ghclients"
+	"github.com/ossf/allstar/pkg/policies"
+	"github.com/ossf/allstar/pkg/policydef"
+	"github.com/ossf/allstar/pkg/storage"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+// orgTargetType is the installation target type of organizations, which
+// org-scope policies are run on.
+const orgTargetType = "Organization"
+
+var policiesGetOrgPolicies func() []policydef.OrgPolicy
+var configGetOrgConfigLocation func(context.Context, *github.Client, string) (string, string, error)
+
+func init() {
+	policiesGetOrgPolicies = policies.GetOrgPolicies
+	configGetOrgConfigLocation = config.GetOrgConfigLocation
+}
+
+// runOrgPolicies runs the org-scope policies on owner, and takes their
+// actions. If due is not nil, only the policies in due are run. An error on one
+// policy is logged and the policy skipped, so it does not stop the run. The
+// results are returned in the same form as the results of the repos of the
+// installation, with an empty repo name.
+func runOrgPolicies(ctx context.Context, c *github.Client, owner string, due map[string]bool) (
+	EnforceAllResults, []storage.PolicyResult) {
+	results := make(EnforceAllResults)
+	var policyResults []storage.PolicyResult
+	ctx = enforceid.WithEvaluation(ctx)
+	for _, p := range policiesGetOrgPolicies() {
+		if due != nil && !due[p.Name()] {
+			continue
+		}
+		if g, ok := gateFor(ctx, p.Name()); ok && g.skip {
+			continue
+		}
+		pr, err := runOrgPolicy(ctx, c, owner, p)
+		if err != nil {
+			if ctx.Err() != nil {
+				return results, policyResults
+			}
+			log.Error().
+				Str("org", owner).
+				Str("area", p.Name()).
+				Fields(enforceid.Fields(ctx)).
+				Err(err).
+				Msg("Unexpected error running org policy, skipping.")
+			continue
+		}
+		if pr == nil {
+			continue
+		}
+		policyResults = append(policyResults, *pr)
+		if pr.APICalls > 0 {
+			if results[p.Name()] == nil {
+				results[p.Name()] = make(map[string]int)
+			}
+			results[p.Name()][apiCallsCount] += pr.APICalls
+			results[p.Name()][apiCostCount] += pr.APICost
+		}
+		if !pr.Pass {
+			if results[p.Name()] == nil {
+				results[p.Name()] = make(map[string]int)
+			}
+			results[p.Name()]["totalFailed"] += 1
+			if pr.IssueFallback != "" {
+				results[p.Name()][issueFallbackCount] += 1
+			}
+		}
+	}
+	return results, policyResults
+}
+
+// runOrgPolicy runs an org-scope policy on owner and takes its action. Issues
+// are created in the org config repository, as the settings do not belong to
+// any repository. The result is nil if the policy is not enabled.
+func runOrgPolicy(ctx context.Context, c *github.Client, owner string, p policydef.OrgPolicy) (*storage.PolicyResult, error) {
+	ctx = audit.WithPolicy(ctx, p.Name())
+	ids := enforceid.Fields(ctx)
+	pctx, counter := ghclients.WithAPICounter(ctx)
+	enabled, err := p.IsEnabled(pctx, c, owner)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, nil
+	}
+	r, err := p.Check(pctx, c, owner)
+	if err != nil {
+		return nil, err
+	}
+	r.APICalls = counter.Calls()
+	r.APICost = counter.Cost()
+	log.Info().
+		Str("org", owner).
+		Str("area", p.Name()).
+		Fields(ids).
+		Bool("result", r.Pass).
+		Bool("enabled", r.Enabled).
+		Str("notify", r.NotifyText).
+		Interface("details", r.Details).
+		Int("apiCalls", r.APICalls).
+		Int("apiCost", r.APICost).
+		Msg("Org policy run result.")
+	if !r.Enabled {
+		return nil, nil
+	}
+	pr := &storage.PolicyResult{
+		Owner:         owner,
+		Policy:        p.Name(),
+		Pass:          r.Pass,
+		EnforcementID: enforceid.Evaluation(ctx),
+		APICalls:      r.APICalls,
+		APICost:       r.APICost,
+	}
+	a := gatedAction(ctx, p.Name(), p.GetAction(ctx, c, owner))
+	if !r.Pass {
+		switch a {
+		case "log":
+		case "issue":
+			repo, err := orgIssueRepo(ctx, c, owner)
+			if err != nil {
+				return nil, err
+			}
+			if repo == "" {
+				log.Warn().
+					Str("org", owner).
+					Str("area", p.Name()).
+					Fields(ids).
+					Msg("Action set to issue, but the org has no config repository to create the issue in.")
+				return pr, nil
+			}
+			f, err := issueEnsure(ctx, c, owner, repo, p.Name(), r.NotifyText)
+			if err != nil {
+				return nil, err
+			}
+			if f != nil {
+				log.Warn().
+					Str("org", owner).
+					Str("repo", repo).
+					Str("area", p.Name()).
+					Fields(ids).
+					Str("fallback", f.String()).
+					Msg("Org policy failed, issue reported in a fallback.")
+				pr.IssueFallback = f.String()
+			}
+		case "notify":
+			repo, err := orgIssueRepo(ctx, c, owner)
+			if err != nil {
+				return nil, err
+			}
+			if err := notifySend(ctx, c, owner, repo, p.Name(), r.NotifyText); err != nil {
+				log.Error().
+					Str("org", owner).
+					Str("area", p.Name()).
+					Fields(ids).
+					Err(err).
+					Msg("Unexpected error sending notification.")
+			}
+		case "fix":
+			if err := p.Fix(ctx, c, owner); err != nil {
+				return nil, err
+			}
+		default:
+			log.Warn().
+				Str("org", owner).
+				Str("area", p.Name()).
+				Fields(ids).
+				Str("action", a).
+				Msg("Action is not supported by org policies.")
+		}
+	}
+	if r.Pass && a == "notify" {
+		repo, err := orgIssueRepo(ctx, c, owner)
+		if err != nil {
+			return nil, err
+		}
+		notifyClear(owner, repo, p.Name())
+	}
+	if r.Pass && (a == "issue" || a == "fix") {
+		repo, err := orgIssueRepo(ctx, c, owner)
+		if err != nil {
+			return nil, err
+		}
+		if repo == "" {
+			return pr, nil
+		}
+		if err := issueClose(ctx, c, owner, repo, p.Name()); err != nil {
+			return nil, err
+		}
+	}
+	return pr, nil
+}
+
+// orgIssueRepo returns the org config repository, that org policy issues are
+// created in, or an empty string if the org has none.
+func orgIssueRepo(ctx context.Context, c *github.Client, owner string) (string, error) {
+	repo, _, err := configGetOrgConfigLocation(ctx, c, owner)
+	return repo, err
+}
@@ -0,0 +1,159 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforce
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/issue"
+	"github.com/ossf/allstar/pkg/policydef"
+	"github.com/ossf/allstar/pkg/storage"
+)
+
+type orgPol struct {
+	name    string
+	enabled bool
+	result  policydef.Result
+	err     error
+	action  string
+	fixed   *bool
+}
+
+func (p orgPol) Name() string {
+	return p.name
+}
+
+func (p orgPol) IsEnabled(ctx context.Context, c *github.Client, owner string) (bool, error) {
+	return p.enabled, nil
+}
+
+func (p orgPol) Check(ctx context.Context, c *github.Client, owner string) (*policydef.Result, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	r := p.result
+	return &r, nil
+}
+
+func (p orgPol) Fix(ctx context.Context, c *github.Client, owner string) error {
+	*p.fixed = true
+	return nil
+}
+
+func (p orgPol) GetAction(ctx context.Context, c *github.Client, owner string) string {
+	return p.action
+}
+
+func TestRunOrgPolicies(t *testing.T) {
+	configGetOrgConfigLocation = func(context.Context, *github.Client, string) (string, string, error) {
+		return ".allstar", "", nil
+	}
+	var ensured, closed []string
+	issueEnsure = func(ctx context.Context, c *github.Client, owner, repo, policy, text string) (*issue.Fallback, error) {
+		ensured = append(ensured, repo+"/"+policy)
+		return nil, nil
+	}
+	issueClose = func(ctx context.Context, c *github.Client, owner, repo, policy string) error {
+		closed = append(closed, repo+"/"+policy)
+		return nil
+	}
+	var fixed bool
+	t.Cleanup(func() {
+		policiesGetOrgPolicies = func() []policydef.OrgPolicy {
+			return nil
+		}
+	})
+	policiesGetOrgPolicies = func() []policydef.OrgPolicy {
+		return []policydef.OrgPolicy{
+			orgPol{name: "Failing", enabled: true, action: "issue",
+				result: policydef.Result{Enabled: true, Pass: false, NotifyText: "bad"}},
+			orgPol{name: "Passing", enabled: true, action: "issue",
+				result: policydef.Result{Enabled: true, Pass: true}},
+			orgPol{name: "Fixing", enabled: true, action: "fix", fixed: &fixed,
+				result: policydef.Result{Enabled: true, Pass: false}},
+			orgPol{name: "Disabled", enabled: false, action: "issue"},
+			orgPol{name: "Error", enabled: true, action: "issue", err: errors.New("forbidden")},
+		}
+	}
+
+	results, policyResults := runOrgPolicies(context.Background(), nil, "thisorg", nil)
+
+	expResults := EnforceAllResults{
+		"Failing": {"totalFailed": 1},
+		"Fixing":  {"totalFailed": 1},
+	}
+	if diff := cmp.Diff(expResults, results); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+	expPolicyResults := []storage.PolicyResult{
+		{Owner: "thisorg", Policy: "Failing", Pass: false},
+		{Owner: "thisorg", Policy: "Passing", Pass: true},
+		{Owner: "thisorg", Policy: "Fixing", Pass: false},
+	}
+	if diff := cmp.Diff(expPolicyResults, policyResults,
+		cmpopts.IgnoreFields(storage.PolicyResult{}, "EnforcementID")); diff != "" {
+		t.Errorf("Unexpected policy results. (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{".allstar/Failing"}, ensured); diff != "" {
+		t.Errorf("Unexpected issues ensured. (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{".allstar/Passing"}, closed); diff != "" {
+		t.Errorf("Unexpected issues closed. (-want +got):\n%s", diff)
+	}
+	if !fixed {
+		t.Errorf("Expected fix to be called.")
+	}
+
+	ensured = nil
+	closed = nil
+	_, policyResults = runOrgPolicies(context.Background(), nil, "thisorg", map[string]bool{"Passing": true})
+	if len(policyResults) != 1 || policyResults[0].Policy != "Passing" {
+		t.Errorf("Unexpected policy results for specific policy: %v", policyResults)
+	}
+	_, policyResults = runOrgPolicies(context.Background(), nil, "thisorg", map[string]bool{"Failing": true})
+	if len(policyResults) != 1 || policyResults[0].Policy != "Failing" {
+		t.Errorf("Unexpected policy results for due policies: %v", policyResults)
+	}
+}
+
+func TestRunOrgPoliciesNoConfigRepo(t *testing.T) {
+	configGetOrgConfigLocation = func(context.Context, *github.Client, string) (string, string, error) {
+		return "", "", nil
+	}
+	issueEnsure = func(ctx context.Context, c *github.Client, owner, repo, policy, text string) (*issue.Fallback, error) {
+		t.Errorf("Unexpected issue in %q", repo)
+		return nil, nil
+	}
+	t.Cleanup(func() {
+		policiesGetOrgPolicies = func() []policydef.OrgPolicy {
+			return nil
+		}
+	})
+	policiesGetOrgPolicies = func() []policydef.OrgPolicy {
+		return []policydef.OrgPolicy{
+			orgPol{name: "Failing", enabled: true, action: "issue",
+				result: policydef.Result{Enabled: true, Pass: false, NotifyText: "bad"}},
+		}
+	}
+	results, _ := runOrgPolicies(context.Background(), nil, "thisorg", nil)
+	if results["Failing"]["totalFailed"] != 1 {
+		t.Errorf("Unexpected results: %v", results)
+	}
+}
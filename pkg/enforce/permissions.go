@@ -0,0 +1,112 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforce
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ossf/allstar/pkg/ghclients"
+	"github.com/ossf/allstar/pkg/policies"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+var policiesPermissions func(string) ([]ghclients.Permission, []ghclients.Permission)
+
+func init() {
+	policiesPermissions = policies.Permissions
+}
+
+// gate is how a policy is downgraded on an installation that was not granted
+// all the permissions the policy needs.
+type gate struct {
+	// skip is set when the policy can not be checked, it is not run.
+	skip bool
+	// missing are the permissions the installation was not granted.
+	missing []ghclients.Permission
+}
+
+// String describes the downgrade, for logs and the summary issue.
+func (g gate) String() string {
+	var l []string
+	for _, p := range g.missing {
+		l = append(l, p.String())
+	}
+	what := "fix action downgraded to issue"
+	if g.skip {
+		what = "not run"
+	}
+	return what + ", missing " + strings.Join(l, ", ")
+}
+
+// permissionGates returns the policies of names that are downgraded on
+// installation i, by name, as i was not granted permissions they need. A
+// policy that can be checked, but not fixed, has its fix action downgraded to
+// issue, rather than failing with 403 errors on each repo. Nothing is gated if
+// the granted permissions are not known.
+func permissionGates(i *github.Installation, names []string) map[string]gate {
+	have := ghclients.PermissionsMap(i.Permissions)
+	if have == nil {
+		return nil
+	}
+	gates := make(map[string]gate)
+	for _, name := range names {
+		check, fix := policiesPermissions(name)
+		if missing := ghclients.MissingPermissions(have, check); len(missing) > 0 {
+			gates[name] = gate{skip: true, missing: missing}
+		} else if missing := ghclients.MissingPermissions(have, fix); len(missing) > 0 {
+			gates[name] = gate{missing: missing}
+		}
+	}
+	return gates
+}
+
+// logGates logs the policies downgraded on an installation, once per
+// enforcement of the installation rather than on each repo.
+func logGates(owner string, gates map[string]gate) {
+	for name, g := range gates {
+		log.Warn().
+			Str("org", owner).
+			Str("area", name).
+			Str("downgrade", g.String()).
+			Msg("Installation is missing permissions needed by policy, grant them in the installation settings.")
+	}
+}
+
+type gatesKey struct{}
+
+// withGates returns a copy of ctx that policies are gated with, see
+// permissionGates.
+func withGates(ctx context.Context, gates map[string]gate) context.Context {
+	return context.WithValue(ctx, gatesKey{}, gates)
+}
+
+// gateFor returns how policy name is downgraded in ctx.
+func gateFor(ctx context.Context, name string) (gate, bool) {
+	gates, _ := ctx.Value(gatesKey{}).(map[string]gate)
+	g, ok := gates[name]
+	return g, ok
+}
+
+// gatedAction returns action a of policy name, downgraded from fix to issue
+// if the installation can not fix the policy.
+func gatedAction(ctx context.Context, name, a string) string {
+	if g, ok := gateFor(ctx, name); ok && !g.skip && a == "fix" {
+		return "issue"
+	}
+	return a
+}
@@ -0,0 +1,111 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforce
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/ghclients"
+	"github.com/ossf/allstar/pkg/issue"
+	"github.com/ossf/allstar/pkg/policies"
+	"github.com/ossf/allstar/pkg/policydef"
+)
+
+func stubPermissions(t *testing.T) {
+	t.Helper()
+	policiesPermissions = func(name string) ([]ghclients.Permission, []ghclients.Permission) {
+		switch name {
+		case "Test policy":
+			return []ghclients.Permission{{Name: "administration", Access: "read"}},
+				[]ghclients.Permission{{Name: "administration", Access: "write"}}
+		case "Test policy2":
+			return []ghclients.Permission{{Name: "checks", Access: "read"}}, nil
+		}
+		return nil, nil
+	}
+	t.Cleanup(func() { policiesPermissions = policies.Permissions })
+}
+
+func TestPermissionGates(t *testing.T) {
+	stubPermissions(t)
+	names := []string{"Test policy", "Test policy2"}
+
+	if gates := permissionGates(&github.Installation{}, names); gates != nil {
+		t.Errorf("Expected no gates with unknown permissions, got: %v", gates)
+	}
+
+	gates := permissionGates(&github.Installation{
+		Permissions: &github.InstallationPermissions{
+			Administration: github.String("read"),
+		},
+	}, names)
+	if len(gates) != 2 {
+		t.Fatalf("Unexpected gates: %v", gates)
+	}
+	if g := gates["Test policy"]; g.skip || g.String() != "fix action downgraded to issue, missing administration:write" {
+		t.Errorf("Unexpected gate: %v", g)
+	}
+	if g := gates["Test policy2"]; !g.skip || g.String() != "not run, missing checks:read" {
+		t.Errorf("Unexpected gate: %v", g)
+	}
+
+	gates = permissionGates(&github.Installation{
+		Permissions: &github.InstallationPermissions{
+			Administration: github.String("write"),
+			Checks:         github.String("write"),
+		},
+	}, names)
+	if len(gates) != 0 {
+		t.Errorf("Expected no gates, got: %v", gates)
+	}
+}
+
+func TestRunPoliciesGated(t *testing.T) {
+	stubPermissions(t)
+	policiesGetPolicies = func() []policydef.Policy {
+		return []policydef.Policy{pol{}, pol2{}}
+	}
+	policy1Results = policyRepoResults{"repo": {Enabled: true, Pass: false}}
+	policy2Results = policyRepoResults{"repo": {Enabled: true, Pass: false}}
+	action = "fix"
+	fixCalled = false
+	var issued []string
+	issueEnsure = func(ctx context.Context, c *github.Client, owner, repo, policy, text string) (*issue.Fallback, error) {
+		issued = append(issued, policy)
+		return nil, nil
+	}
+
+	inst := &github.Installation{
+		Permissions: &github.InstallationPermissions{
+			Administration: github.String("read"),
+		},
+	}
+	ctx := withGates(context.Background(), permissionGates(inst, policyNames()))
+	results, err := runPoliciesReal(ctx, nil, "org", "repo", true, false, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := results["Test policy2"]; ok {
+		t.Errorf("Expected policy missing check permissions to be skipped: %v", results)
+	}
+	if fixCalled {
+		t.Errorf("Expected fix to be downgraded")
+	}
+	if len(issued) != 1 || issued[0] != "Test policy" {
+		t.Errorf("Expected fix downgraded to issue, got: %v", issued)
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforce
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ossf/allstar/pkg/config/operator"
+	"github.com/ossf/allstar/pkg/state"
+
+	"github.com/google/go-github/v59/github"
+)
+
+// ResultCacheStats are counters describing result cache usage.
+type ResultCacheStats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+	Size   int    `json:"size"`
+}
+
+// resultCache records the results of repos that passed all policies, with the
+// state of the repo and of the config repos of its org that they were
+// evaluated at. Policies are not run again on a repo until either changes, or
+// the entry expires. Failing repos are not cached, so that their issues are
+// pinged and fixes retried. The results of settings policies, see
+// settingsPolicy, are not cached either, as changes to settings do not update
+// the repo.
+type resultCache struct {
+	ttl   time.Duration
+	store state.Interface
+
+	mu      sync.Mutex
+	entries map[string]cachedResult
+	config  map[string]time.Time
+	stats   ResultCacheStats
+}
+
+// cachedResult is the result of a repo, saved to the state store.
+type cachedResult struct {
+	PushedAt       time.Time          `json:"pushedAt"`
+	UpdatedAt      time.Time          `json:"updatedAt"`
+	ConfigPushedAt time.Time          `json:"configPushedAt"`
+	Evaluated      time.Time          `json:"evaluated"`
+	Results        EnforceRepoResults `json:"results"`
+}
+
+// resultCacheKey is the state key the result cache is saved to.
+var resultCacheKey = state.Key("enforce", "resultcache")
+
+// repoCache is the result cache, nil if disabled.
+var repoCache *resultCache
+
+// EnableResultCache enables reusing the result of repos that passed all
+// policies for up to ttl, while they are unchanged. The cache is loaded from
+// the state store, and saved to it after each enforcement run, call SetState
+// first.
+func EnableResultCache(ctx context.Context, ttl time.Duration) error {
+	c := newResultCache(ttl, stateStore)
+	if err := c.load(ctx); err != nil {
+		return err
+	}
+	repoCache = c
+	return nil
+}
+
+// GetResultCacheStats returns the result cache usage counters, all zero if it
+// is disabled.
+func GetResultCacheStats() ResultCacheStats {
+	return repoCache.getStats()
+}
+
+func newResultCache(ttl time.Duration, s state.Interface) *resultCache {
+	return &resultCache{
+		ttl:     ttl,
+		store:   s,
+		entries: make(map[string]cachedResult),
+		config:  make(map[string]time.Time),
+	}
+}
+
+// setConfig records when the config repos of owner were last pushed to, out
+// of the repos of its installation. Config repos the App is not installed on
+// are not seen, changes to them are only picked up when entries expire.
+func (c *resultCache) setConfig(owner string, repos []*github.Repository) {
+	if c == nil {
+		return
+	}
+	var latest time.Time
+	for _, r := range repos {
+		if n := r.GetName(); n != operator.OrgConfigRepo && n != ".github" {
+			continue
+		}
+		if t := r.GetPushedAt().Time; t.After(latest) {
+			latest = t
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.config[strings.ToLower(owner)] = latest
+}
+
+// get returns the cached results of r, if it is unchanged since they were
+// evaluated and they have not expired at now. Only the results of the due
+// policies are returned.
+func (c *resultCache) get(r *github.Repository, due map[string]bool, now time.Time) (EnforceRepoResults, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[strings.ToLower(r.GetFullName())]
+	if !ok || now.Sub(e.Evaluated) >= c.ttl ||
+		!e.PushedAt.Equal(r.GetPushedAt().Time) ||
+		!e.UpdatedAt.Equal(r.GetUpdatedAt().Time) ||
+		!e.ConfigPushedAt.Equal(c.config[strings.ToLower(r.GetOwner().GetLogin())]) {
+		c.stats.Misses++
+		return nil, false
+	}
+	c.stats.Hits++
+	res := make(EnforceRepoResults)
+	for p, pass := range e.Results {
+		if (due != nil && !due[p]) || settingsPolicy(p) {
+			continue
+		}
+		res[p] = pass
+	}
+	return res, true
+}
+
+// uncached returns the policies of due, or all policies if due is nil, that
+// are run on repos even when their other results are cached, as they are
+// settings policies. Returns nil if the cache is disabled.
+func (c *resultCache) uncached(due map[string]bool) map[string]bool {
+	if c == nil {
+		return nil
+	}
+	u := make(map[string]bool)
+	for _, p := range policiesGetPolicies() {
+		if (due == nil || due[p.Name()]) && settingsPolicy(p.Name()) {
+			u[p.Name()] = true
+		}
+	}
+	return u
+}
+
+// settingsPolicy returns whether the named policy reads repo settings, or
+// other state not updated by pushes to the repo, such as branch protection or
+// collaborators. These are the policies that need permissions other than
+// contents and metadata to be checked.
+func settingsPolicy(name string) bool {
+	check, _ := policiesPermissions(name)
+	for _, p := range check {
+		if p.Name != "contents" && p.Name != "metadata" {
+			return true
+		}
+	}
+	return false
+}
+
+// put records the results of all policies on r, evaluated at now, except
+// settings policies. Any previous entry is removed if a cached policy failed.
+func (c *resultCache) put(r *github.Repository, results EnforceRepoResults, now time.Time) {
+	if c == nil {
+		return
+	}
+	key := strings.ToLower(r.GetFullName())
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached := make(EnforceRepoResults)
+	for p, pass := range results {
+		if settingsPolicy(p) {
+			continue
+		}
+		if !pass {
+			delete(c.entries, key)
+			return
+		}
+		cached[p] = pass
+	}
+	c.entries[key] = cachedResult{
+		PushedAt:       r.GetPushedAt().Time,
+		UpdatedAt:      r.GetUpdatedAt().Time,
+		ConfigPushedAt: c.config[strings.ToLower(r.GetOwner().GetLogin())],
+		Evaluated:      now,
+		Results:        cached,
+	}
+}
+
+// prune removes the entries expired at now.
+func (c *resultCache) prune(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, e := range c.entries {
+		if now.Sub(e.Evaluated) >= c.ttl {
+			delete(c.entries, k)
+		}
+	}
+}
+
+func (c *resultCache) getStats() ResultCacheStats {
+	if c == nil {
+		return ResultCacheStats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.stats
+	s.Size = len(c.entries)
+	return s
+}
+
+// load reads the entries saved to the state store, if any.
+func (c *resultCache) load(ctx context.Context) error {
+	entries := make(map[string]cachedResult)
+	err := state.GetJSON(ctx, c.store, resultCacheKey, &entries)
+	if errors.Is(err, state.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = entries
+	return nil
+}
+
+// save prunes the entries expired at now, and saves the others to the state
+// store.
+func (c *resultCache) save(ctx context.Context, now time.Time) error {
+	if c == nil {
+		return nil
+	}
+	c.prune(now)
+	c.mu.Lock()
+	b, err := json.Marshal(c.entries)
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return c.store.Put(ctx, resultCacheKey, b)
+}
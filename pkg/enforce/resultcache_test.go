@@ -0,0 +1,229 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforce
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/policydef"
+	"github.com/ossf/allstar/pkg/state"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+)
+
+func cacheRepo(name string, pushed time.Time) *github.Repository {
+	return &github.Repository{
+		Name:      github.String(name),
+		FullName:  github.String("acme/" + name),
+		Owner:     &github.User{Login: github.String("acme")},
+		PushedAt:  &github.Timestamp{Time: pushed},
+		UpdatedAt: &github.Timestamp{Time: pushed},
+	}
+}
+
+func TestResultCache(t *testing.T) {
+	stubPermissions(t)
+	now := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	pushed := now.Add(-24 * time.Hour)
+	pass := EnforceRepoResults{"Branch Protection": true, "Security Policy": true}
+	tests := []struct {
+		Name    string
+		Results EnforceRepoResults
+		Repo    *github.Repository
+		Config  *github.Repository
+		Due     map[string]bool
+		At      time.Time
+		Exp     EnforceRepoResults
+		ExpHit  bool
+	}{
+		{
+			Name:    "Hit",
+			Results: pass,
+			Repo:    cacheRepo("repo", pushed),
+			At:      now.Add(time.Hour),
+			Exp:     pass,
+			ExpHit:  true,
+		},
+		{
+			Name:    "HitSpecificPolicy",
+			Results: pass,
+			Repo:    cacheRepo("repo", pushed),
+			Due:     map[string]bool{"Security Policy": true},
+			At:      now.Add(time.Hour),
+			Exp:     EnforceRepoResults{"Security Policy": true},
+			ExpHit:  true,
+		},
+		{
+			Name:    "HitDue",
+			Results: pass,
+			Repo:    cacheRepo("repo", pushed),
+			Due:     map[string]bool{"Branch Protection": true},
+			At:      now.Add(time.Hour),
+			Exp:     EnforceRepoResults{"Branch Protection": true},
+			ExpHit:  true,
+		},
+		{
+			Name:    "Pushed",
+			Results: pass,
+			Repo:    cacheRepo("repo", now.Add(time.Minute)),
+			At:      now.Add(time.Hour),
+		},
+		{
+			Name:    "ConfigPushed",
+			Results: pass,
+			Repo:    cacheRepo("repo", pushed),
+			Config:  cacheRepo(".allstar", now.Add(time.Minute)),
+			At:      now.Add(time.Hour),
+		},
+		{
+			Name:    "Expired",
+			Results: pass,
+			Repo:    cacheRepo("repo", pushed),
+			At:      now.Add(6 * time.Hour),
+		},
+		{
+			Name:    "Failed",
+			Results: EnforceRepoResults{"Branch Protection": true, "Security Policy": false},
+			Repo:    cacheRepo("repo", pushed),
+			At:      now.Add(time.Hour),
+		},
+		{
+			Name:    "SettingsPolicy",
+			Results: EnforceRepoResults{"Branch Protection": true, "Test policy": true},
+			Repo:    cacheRepo("repo", pushed),
+			At:      now.Add(time.Hour),
+			Exp:     EnforceRepoResults{"Branch Protection": true},
+			ExpHit:  true,
+		},
+		{
+			Name:    "SettingsPolicyFailed",
+			Results: EnforceRepoResults{"Branch Protection": true, "Test policy": false},
+			Repo:    cacheRepo("repo", pushed),
+			At:      now.Add(time.Hour),
+			Exp:     EnforceRepoResults{"Branch Protection": true},
+			ExpHit:  true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			c := newResultCache(6*time.Hour, state.NewMemory())
+			c.setConfig("acme", []*github.Repository{cacheRepo(".allstar", pushed)})
+			c.put(cacheRepo("repo", pushed), test.Results, now)
+			if test.Config != nil {
+				c.setConfig("acme", []*github.Repository{test.Config})
+			}
+			got, hit := c.get(test.Repo, test.Due, test.At)
+			if hit != test.ExpHit {
+				t.Errorf("Unexpected hit: %v", hit)
+			}
+			if diff := cmp.Diff(test.Exp, got); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResultCacheSave(t *testing.T) {
+	stubPermissions(t)
+	ctx := context.Background()
+	now := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	pushed := now.Add(-24 * time.Hour)
+	st := state.NewMemory()
+	pass := EnforceRepoResults{"Branch Protection": true}
+
+	c := newResultCache(6*time.Hour, st)
+	if err := c.load(ctx); err != nil {
+		t.Fatalf("Unexpected error loading empty state: %v", err)
+	}
+	c.put(cacheRepo("repo", pushed), pass, now)
+	c.put(cacheRepo("old", pushed), pass, now.Add(-6*time.Hour))
+	if err := c.save(ctx, now); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	l := newResultCache(6*time.Hour, st)
+	if err := l.load(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got, hit := l.get(cacheRepo("repo", pushed), nil, now); !hit || !got["Branch Protection"] {
+		t.Errorf("Expected saved result, got: %v %v", got, hit)
+	}
+	if _, hit := l.get(cacheRepo("old", pushed), nil, now); hit {
+		t.Error("Expected expired result to be pruned")
+	}
+}
+
+func TestRunPoliciesOnInstReposCached(t *testing.T) {
+	stubPermissions(t)
+	policiesGetPolicies = func() []policydef.Policy {
+		return []policydef.Policy{pol{}}
+	}
+	configIsBotEnabled = func(ctx context.Context, c *github.Client, owner, repo string) bool {
+		return true
+	}
+	configGetOrgConfig = func(context.Context, *github.Client, string) *config.OrgConfig {
+		return &config.OrgConfig{}
+	}
+	getRateLimit = func(ctx context.Context, c *github.Client) (*github.Rate, error) {
+		return nil, nil
+	}
+	old := repoCache
+	t.Cleanup(func() { repoCache = old })
+	repoCache = newResultCache(time.Hour, state.NewMemory())
+
+	pushed := time.Now().Add(-24 * time.Hour)
+	repos := []*github.Repository{cacheRepo("pass", pushed), cacheRepo("fail", pushed)}
+	var mu sync.Mutex
+	runs := 0
+	settingsRuns := 0
+	runPolicies = func(ctx context.Context, c *github.Client, owner, repo string, enabled, grace bool, due map[string]bool) (EnforceRepoResults, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		// "Test policy" is a settings policy, it is run on every repo each
+		// run, the others only on repos without cached results.
+		if due != nil {
+			if diff := cmp.Diff(map[string]bool{"Test policy": true}, due); diff != "" {
+				t.Errorf("Unexpected due policies. (-want +got):\n%s", diff)
+			}
+			settingsRuns++
+			return EnforceRepoResults{"Test policy": true}, nil
+		}
+		runs++
+		return EnforceRepoResults{"Content policy": repo == "pass", "Test policy": true}, nil
+	}
+	client := github.NewClient(&http.Client{})
+	for i, exp := range []struct{ runs, settingsRuns int }{{2, 0}, {1, 1}} {
+		runs = 0
+		settingsRuns = 0
+		instResults, _, _, err := runPoliciesOnInstRepos(context.Background(), repos, client, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if runs != exp.runs || settingsRuns != exp.settingsRuns {
+			t.Errorf("Run %v: expected all policies to run on %v repos and settings policies on %v, ran on %v and %v",
+				i, exp.runs, exp.settingsRuns, runs, settingsRuns)
+		}
+		want := EnforceAllResults{"Content policy": {"totalFailed": 1}}
+		if diff := cmp.Diff(want, instResults); diff != "" {
+			t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforce
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/ossf/allstar/pkg/enforceid"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+// maxRepoAttempts is the number of times policies are run on a repo that
+// fails with a transient error, before the repo is skipped.
+const maxRepoAttempts = 3
+
+// repoRetryWait is the wait before the first retry on a repo, doubled on each
+// further retry.
+const repoRetryWait = 5 * time.Second
+
+var repoRetrySleep func(context.Context, time.Duration) error
+
+func init() {
+	repoRetrySleep = sleepCtx
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// runPoliciesRetry runs policies on a repo, retrying with backoff while it
+// fails with a transient error. It returns the number of attempts made.
+// Policies are safe to run again, as their actions only make changes still
+// needed.
+func runPoliciesRetry(ctx context.Context, c *github.Client, owner, repo string, enabled, grace bool, due map[string]bool) (
+	EnforceRepoResults, int, error) {
+	wait := repoRetryWait
+	for attempt := 1; ; attempt++ {
+		results, err := runPolicies(ctx, c, owner, repo, enabled, grace, due)
+		if err == nil || attempt == maxRepoAttempts || ctx.Err() != nil || !isTransient(err) {
+			return results, attempt, err
+		}
+		log.Warn().
+			Str("org", owner).
+			Str("repo", repo).
+			Fields(enforceid.Fields(ctx)).
+			Int("attempt", attempt).
+			Dur("wait", wait).
+			Err(err).
+			Msg("Transient error running policies on repo, retrying.")
+		if err := repoRetrySleep(ctx, wait); err != nil {
+			return nil, attempt, err
+		}
+		wait *= 2
+	}
+}
+
+// isTransient returns whether err is likely to succeed if retried: a GitHub
+// server error, or a network error or timeout. Rate limits are waited on
+// before each repo, and secondary rate limits are retried by the client.
+func isTransient(err error) bool {
+	var e *github.ErrorResponse
+	if errors.As(err, &e) && e.Response != nil {
+		switch e.Response.StatusCode {
+		case http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	var ne net.Error
+	if errors.As(err, &ne) {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, context.DeadlineExceeded)
+}
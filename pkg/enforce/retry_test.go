@@ -0,0 +1,121 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforce
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config/operator"
+	"github.com/ossf/allstar/pkg/storage"
+)
+
+func statusError(code int) error {
+	return &github.ErrorResponse{
+		Response: &http.Response{StatusCode: code},
+		Message:  http.StatusText(code),
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		Err error
+		Exp bool
+	}{
+		{statusError(http.StatusBadGateway), true},
+		{fmt.Errorf("while getting branch: %w", statusError(http.StatusServiceUnavailable)), true},
+		{statusError(http.StatusNotFound), false},
+		{statusError(http.StatusForbidden), false},
+		{io.ErrUnexpectedEOF, true},
+		{context.DeadlineExceeded, true},
+		{errors.New("bad config"), false},
+	}
+	for _, test := range tests {
+		if got := isTransient(test.Err); got != test.Exp {
+			t.Errorf("isTransient(%v) = %v, expected %v", test.Err, got, test.Exp)
+		}
+	}
+}
+
+func TestRunPoliciesOnInstReposRetry(t *testing.T) {
+	configIsBotEnabled = func(ctx context.Context, c *github.Client, owner, repo string) bool {
+		return true
+	}
+	getRateLimit = func(ctx context.Context, c *github.Client) (*github.Rate, error) {
+		return nil, nil
+	}
+	var waits []time.Duration
+	repoRetrySleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	defer func() { repoRetrySleep = sleepCtx }()
+
+	owner := "fake-owner"
+	var repos []*github.Repository
+	for _, n := range []string{"flaky", "down", "broken"} {
+		n := n
+		repos = append(repos, &github.Repository{
+			Name:  &n,
+			Owner: &github.User{Login: &owner},
+		})
+	}
+	calls := make(map[string]int)
+	runPolicies = func(ctx context.Context, c *github.Client, owner, repo string, enabled, grace bool, due map[string]bool) (EnforceRepoResults, error) {
+		calls[repo]++
+		switch {
+		case repo == "flaky" && calls[repo] == 1:
+			return nil, statusError(http.StatusBadGateway)
+		case repo == "down":
+			return nil, statusError(http.StatusServiceUnavailable)
+		case repo == "broken":
+			return nil, errors.New("fail")
+		}
+		return EnforceRepoResults{"Test policy": true}, nil
+	}
+	// Run one repo at a time, for the order of waits.
+	saved := operator.NumRepoWorkers
+	defer func() { operator.NumRepoWorkers = saved }()
+	operator.NumRepoWorkers = 1
+
+	_, counts, _, err := runPoliciesOnInstRepos(context.Background(), repos, github.NewClient(&http.Client{}), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := storage.RunCounts{
+		Skipped: 2,
+		Errors: map[string]int{
+			"fake-owner/down":   maxRepoAttempts,
+			"fake-owner/broken": 1,
+		},
+	}
+	if diff := cmp.Diff(want, counts); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]int{"flaky": 2, "down": maxRepoAttempts, "broken": 1}, calls); diff != "" {
+		t.Errorf("Unexpected attempts. (-want +got):\n%s", diff)
+	}
+	wantWaits := []time.Duration{repoRetryWait, repoRetryWait, 2 * repoRetryWait}
+	if diff := cmp.Diff(wantWaits, waits); diff != "" {
+		t.Errorf("Unexpected waits. (-want +got):\n%s", diff)
+	}
+}
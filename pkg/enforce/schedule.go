@@ -0,0 +1,106 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforce
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/operator"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+// policySchedule tracks when each policy last ran on each org, so that
+// EnforceJob can run expensive policies less often than the job interval.
+type policySchedule struct {
+	mu      sync.Mutex
+	lastRun map[string]time.Time
+}
+
+func newPolicySchedule() *policySchedule {
+	return &policySchedule{
+		lastRun: make(map[string]time.Time),
+	}
+}
+
+// duePolicies returns the set of policy names that are due to run on the org
+// at now. A nil schedule returns nil, which indicates all policies are due.
+func (s *policySchedule) duePolicies(ctx context.Context, c *github.Client, owner string, now time.Time) map[string]bool {
+	if s == nil {
+		return nil
+	}
+	oc := configGetOrgConfig(ctx, c, owner)
+	due := make(map[string]bool)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, name := range policyNames() {
+		last, ok := s.lastRun[scheduleKey(owner, name)]
+		if !ok || !now.Before(last.Add(policyInterval(oc, owner, name))) {
+			due[name] = true
+		}
+	}
+	return due
+}
+
+// policyNames returns the names of all repo and org policies.
+func policyNames() []string {
+	var names []string
+	for _, p := range policiesGetPolicies() {
+		names = append(names, p.Name())
+	}
+	for _, p := range policiesGetOrgPolicies() {
+		names = append(names, p.Name())
+	}
+	return names
+}
+
+// markRun records that the due policies ran on the org at now.
+func (s *policySchedule) markRun(owner string, due map[string]bool, now time.Time) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name := range due {
+		s.lastRun[scheduleKey(owner, name)] = now
+	}
+}
+
+func scheduleKey(owner, policy string) string {
+	return fmt.Sprintf("%s/%s", owner, policy)
+}
+
+// policyInterval gets the minimum duration between runs of the policy. The
+// org-level setting takes precedence over the operator setting.
+func policyInterval(oc *config.OrgConfig, owner, name string) time.Duration {
+	if ds, ok := oc.PolicyIntervals[name]; ok {
+		d, err := time.ParseDuration(ds)
+		if err == nil {
+			return d
+		}
+		log.Warn().
+			Str("org", owner).
+			Str("area", name).
+			Str("interval", ds).
+			Err(err).
+			Msg("Malformed policy interval in org config, using operator default.")
+	}
+	return operator.PolicyIntervals[name]
+}
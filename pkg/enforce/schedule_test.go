@@ -0,0 +1,144 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforce
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/operator"
+	"github.com/ossf/allstar/pkg/policydef"
+)
+
+func TestPolicySchedule(t *testing.T) {
+	policiesGetPolicies = func() []policydef.Policy {
+		return []policydef.Policy{
+			pol{},
+			pol2{},
+		}
+	}
+	saved := operator.PolicyIntervals
+	defer func() { operator.PolicyIntervals = saved }()
+	operator.PolicyIntervals = map[string]time.Duration{
+		"Test policy": time.Hour,
+	}
+	start := time.Now()
+
+	tests := []struct {
+		Name    string
+		Org     config.OrgConfig
+		Elapsed time.Duration
+		ExpDue  map[string]bool
+	}{
+		{
+			Name:    "OperatorIntervalNotDue",
+			Elapsed: 5 * time.Minute,
+			ExpDue: map[string]bool{
+				"Test policy2": true,
+			},
+		},
+		{
+			Name:    "OperatorIntervalDue",
+			Elapsed: time.Hour,
+			ExpDue: map[string]bool{
+				"Test policy":  true,
+				"Test policy2": true,
+			},
+		},
+		{
+			Name: "OrgOverride",
+			Org: config.OrgConfig{
+				PolicyIntervals: map[string]string{
+					"Test policy":  "5m",
+					"Test policy2": "24h",
+				},
+			},
+			Elapsed: 5 * time.Minute,
+			ExpDue: map[string]bool{
+				"Test policy": true,
+			},
+		},
+		{
+			Name: "OrgMalformed",
+			Org: config.OrgConfig{
+				PolicyIntervals: map[string]string{
+					"Test policy": "daily",
+				},
+			},
+			Elapsed: 5 * time.Minute,
+			ExpDue: map[string]bool{
+				"Test policy2": true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configGetOrgConfig = func(ctx context.Context, c *github.Client, owner string) *config.OrgConfig {
+				return &test.Org
+			}
+			s := newPolicySchedule()
+			due := s.duePolicies(context.Background(), nil, "org", start)
+			expAll := map[string]bool{
+				"Test policy":  true,
+				"Test policy2": true,
+			}
+			if diff := cmp.Diff(expAll, due); diff != "" {
+				t.Errorf("Expected all policies due on first run. (-want +got):\n%s", diff)
+			}
+			s.markRun("org", due, start)
+			due = s.duePolicies(context.Background(), nil, "org", start.Add(test.Elapsed))
+			if diff := cmp.Diff(test.ExpDue, due); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+			due = s.duePolicies(context.Background(), nil, "other-org", start.Add(test.Elapsed))
+			if diff := cmp.Diff(expAll, due); diff != "" {
+				t.Errorf("Expected schedule to be per org. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNilPolicySchedule(t *testing.T) {
+	var s *policySchedule
+	due := s.duePolicies(context.Background(), nil, "org", time.Now())
+	if due != nil {
+		t.Errorf("Expected nil schedule to return nil, got %v", due)
+	}
+	s.markRun("org", due, time.Now())
+}
+
+func TestRunPoliciesDue(t *testing.T) {
+	policiesGetPolicies = func() []policydef.Policy {
+		return []policydef.Policy{
+			pol{},
+			pol2{},
+		}
+	}
+	action = "log"
+	policy1Results = policyRepoResults{"repo": policydef.Result{Enabled: true, Pass: false}}
+	policy2Results = policyRepoResults{"repo": policydef.Result{Enabled: true, Pass: true}}
+	res, err := runPoliciesReal(context.Background(), nil, "", "repo", true, false, map[string]bool{"Test policy2": true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(EnforceRepoResults{"Test policy2": true}, res); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforce
+
+import (
+	"sync"
+	"time"
+)
+
+// maxRecentErrors is the number of recent errors kept for the run status.
+const maxRecentErrors = 10
+
+// RunStatus is the status of the enforcement runs of this process, reported
+// by the health and status endpoints. Only runs on all repos are recorded,
+// not enforcements of a specific repo.
+type RunStatus struct {
+	// LastRunID is the run ID of the last completed run.
+	LastRunID string `json:"lastRunId,omitempty"`
+
+	// LastRunStarted is when the last completed run started.
+	LastRunStarted time.Time `json:"lastRunStarted,omitempty"`
+
+	// LastRunFinished is when the last completed run finished.
+	LastRunFinished time.Time `json:"lastRunFinished,omitempty"`
+
+	// LastRunFailed is whether the last completed run returned an error,
+	// such as failing to list the installations of the app.
+	LastRunFailed bool `json:"lastRunFailed,omitempty"`
+
+	// Installations is the number of installations in the last run.
+	Installations int `json:"installations"`
+
+	// Repos is the number of repos enforced in the last run.
+	Repos int `json:"repos"`
+
+	// RepoErrors is the number of repos skipped in the last run, as running
+	// policies on them failed with an error, after any retries.
+	RepoErrors int `json:"repoErrors"`
+
+	// Errors are the most recent errors of any run, newest first.
+	Errors []RunError `json:"errors,omitempty"`
+}
+
+// RunError is an error recorded in the run status.
+type RunError struct {
+	Time  time.Time `json:"time"`
+	RunID string    `json:"runId,omitempty"`
+	Org   string    `json:"org,omitempty"`
+	Repo  string    `json:"repo,omitempty"`
+	Error string    `json:"error"`
+}
+
+var runStatus RunStatus
+var runStatusMu sync.Mutex
+
+// Status returns the status of the enforcement runs of this process.
+func Status() RunStatus {
+	runStatusMu.Lock()
+	defer runStatusMu.Unlock()
+	st := runStatus
+	st.Errors = append([]RunError(nil), runStatus.Errors...)
+	return st
+}
+
+// recordRun records a completed run on all repos in the run status.
+func recordRun(runID string, started, finished time.Time, insts, repos, repoErrors int, err error) {
+	runStatusMu.Lock()
+	defer runStatusMu.Unlock()
+	runStatus.LastRunID = runID
+	runStatus.LastRunStarted = started
+	runStatus.LastRunFinished = finished
+	runStatus.LastRunFailed = err != nil
+	runStatus.Installations = insts
+	runStatus.Repos = repos
+	runStatus.RepoErrors = repoErrors
+}
+
+// recordError records an error in the recent errors of the run status.
+func recordError(runID, org, repo string, err error) {
+	runStatusMu.Lock()
+	defer runStatusMu.Unlock()
+	e := RunError{
+		Time:  time.Now(),
+		RunID: runID,
+		Org:   org,
+		Repo:  repo,
+		Error: err.Error(),
+	}
+	runStatus.Errors = append([]RunError{e}, runStatus.Errors...)
+	if len(runStatus.Errors) > maxRecentErrors {
+		runStatus.Errors = runStatus.Errors[:maxRecentErrors]
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforce
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-github/v59/github"
+)
+
+func TestStatus(t *testing.T) {
+	runStatus = RunStatus{}
+	defer func() { runStatus = RunStatus{} }()
+	getAppInstallations = func(ctx context.Context, ac *github.Client) ([]*github.Installation, error) {
+		return []*github.Installation{
+			{ID: github.Int64(1), Account: &github.User{Login: github.String("org1")}},
+			{ID: github.Int64(2), Account: &github.User{Login: github.String("org2")}},
+		}, nil
+	}
+	getAppInstallationRepos = func(ctx context.Context, ic *github.Client) ([]*github.Repository, *github.Response, error) {
+		return []*github.Repository{
+			{
+				Name:     github.String("repo"),
+				FullName: github.String("org/repo"),
+				Owner:    &github.User{Login: github.String("org")},
+			},
+		}, nil, nil
+	}
+	getRateLimit = func(ctx context.Context, c *github.Client) (*github.Rate, error) {
+		return &github.Rate{Remaining: 5000}, nil
+	}
+	configIsBotEnabled = func(ctx context.Context, c *github.Client, owner, repo string) bool {
+		return true
+	}
+	runPolicies = func(ctx context.Context, c *github.Client, owner, repo string, enabled, grace bool, due map[string]bool) (EnforceRepoResults, error) {
+		return nil, errors.New("boom")
+	}
+
+	if _, err := EnforceAll(context.Background(), &MockGhClients{}, "", ""); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	st := Status()
+	if st.LastRunID == "" || st.LastRunFailed || st.LastRunFinished.IsZero() {
+		t.Errorf("Unexpected last run: %+v", st)
+	}
+	if st.Installations != 2 || st.Repos != 2 || st.RepoErrors != 1 {
+		t.Errorf("Unexpected counts: %v installations, %v repos, %v repo errors", st.Installations, st.Repos, st.RepoErrors)
+	}
+	if len(st.Errors) != 2 || st.Errors[0].Repo != "repo" || st.Errors[0].Error != "boom" {
+		t.Errorf("Unexpected errors: %+v", st.Errors)
+	}
+
+	// A specific repo is not recorded as the last run.
+	last := st.LastRunID
+	if _, err := EnforceAll(context.Background(), &MockGhClients{}, "", "org1/repo"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := Status().LastRunID; got != last {
+		t.Errorf("Unexpected last run ID: %v", got)
+	}
+
+	getAppInstallations = func(ctx context.Context, ac *github.Client) ([]*github.Installation, error) {
+		return nil, errors.New("bad credentials")
+	}
+	if _, err := EnforceAll(context.Background(), &MockGhClients{}, "", ""); err == nil {
+		t.Fatal("Expected error")
+	}
+	st = Status()
+	if !st.LastRunFailed || st.Installations != 0 || st.Errors[0].Error != "bad credentials" {
+		t.Errorf("Unexpected failed run: %+v", st)
+	}
+}
+
+func TestRecordErrorLimit(t *testing.T) {
+	runStatus = RunStatus{}
+	defer func() { runStatus = RunStatus{} }()
+	for i := 0; i < maxRecentErrors+5; i++ {
+		recordError("run", "org", fmt.Sprintf("repo%d", i), errors.New("e"))
+	}
+	st := Status()
+	if len(st.Errors) != maxRecentErrors {
+		t.Fatalf("Unexpected number of errors: %v", len(st.Errors))
+	}
+	if st.Errors[0].Repo != fmt.Sprintf("repo%d", maxRecentErrors+4) {
+		t.Errorf("Expected newest error first, got: %v", st.Errors[0].Repo)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package enforceid generates the unique IDs of enforcement runs and repo
+// evaluations, and carries them in a context. The IDs are included in issues,
+// notifications, logs, and stored results, so that an action Allstar took can
+// be correlated across systems.
+//
+// IDs are ULIDs (https://github.com/ulid/spec), which sort by creation time.
+package enforceid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"time"
+)
+
+// crockford is the Crockford base32 alphabet used by ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+type ctxKey struct{}
+
+type ids struct {
+	run        string
+	evaluation string
+}
+
+var now func() time.Time
+var random func([]byte) (int, error)
+
+func init() {
+	now = time.Now
+	random = rand.Read
+}
+
+// New returns a new ULID.
+func New() string {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(now().UnixMilli())<<16)
+	if _, err := random(b[6:]); err != nil {
+		// crypto/rand does not fail on supported platforms.
+		panic(err)
+	}
+	return encode(b)
+}
+
+// encode encodes the 128 bits of a ULID as 26 base32 characters, most
+// significant first. The first character only holds 3 bits.
+func encode(b [16]byte) string {
+	hi := binary.BigEndian.Uint64(b[:8])
+	lo := binary.BigEndian.Uint64(b[8:])
+	var s [26]byte
+	for i := 25; i >= 0; i-- {
+		s[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(s[:])
+}
+
+// WithRun returns a copy of ctx with a new run ID, for an enforcement run
+// across all installations.
+func WithRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ctxKey{}, ids{run: New()})
+}
+
+// WithEvaluation returns a copy of ctx with a new evaluation ID, for running
+// the policies on a single repo. The run ID of ctx, if any, is kept.
+func WithEvaluation(ctx context.Context) context.Context {
+	v, _ := ctx.Value(ctxKey{}).(ids)
+	v.evaluation = New()
+	return context.WithValue(ctx, ctxKey{}, v)
+}
+
+// Run returns the run ID of ctx, or "" if none.
+func Run(ctx context.Context) string {
+	v, _ := ctx.Value(ctxKey{}).(ids)
+	return v.run
+}
+
+// Evaluation returns the evaluation ID of ctx, or "" if none.
+func Evaluation(ctx context.Context) string {
+	v, _ := ctx.Value(ctxKey{}).(ids)
+	return v.evaluation
+}
+
+// Fields returns the IDs of ctx as log fields, for use with
+// zerolog.Event.Fields.
+func Fields(ctx context.Context) map[string]interface{} {
+	f := make(map[string]interface{})
+	if r := Run(ctx); r != "" {
+		f["runId"] = r
+	}
+	if e := Evaluation(ctx); e != "" {
+		f["enforcementId"] = e
+	}
+	return f
+}
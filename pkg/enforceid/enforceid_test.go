@@ -0,0 +1,80 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforceid
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNew(t *testing.T) {
+	defer func(n func() time.Time, r func([]byte) (int, error)) {
+		now = n
+		random = r
+	}(now, random)
+	now = func() time.Time { return time.UnixMilli(1469918176385) }
+
+	random = func(b []byte) (int, error) {
+		for i := range b {
+			b[i] = 0
+		}
+		return len(b), nil
+	}
+	if got := New(); got != "01ARYZ6S410000000000000000" {
+		t.Errorf("Unexpected ID: %v", got)
+	}
+
+	random = func(b []byte) (int, error) {
+		for i := range b {
+			b[i] = 0xff
+		}
+		return len(b), nil
+	}
+	if got := New(); got != "01ARYZ6S41ZZZZZZZZZZZZZZZZ" {
+		t.Errorf("Unexpected ID: %v", got)
+	}
+}
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	if diff := cmp.Diff(map[string]interface{}{}, Fields(ctx)); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+
+	runCtx := WithRun(ctx)
+	run := Run(runCtx)
+	if len(run) != 26 || Evaluation(runCtx) != "" {
+		t.Errorf("Unexpected IDs: %q %q", run, Evaluation(runCtx))
+	}
+
+	evalCtx := WithEvaluation(runCtx)
+	eval := Evaluation(evalCtx)
+	if Run(evalCtx) != run || len(eval) != 26 || eval == run {
+		t.Errorf("Unexpected IDs: %q %q", Run(evalCtx), eval)
+	}
+	if Evaluation(WithEvaluation(runCtx)) == eval {
+		t.Errorf("Expected a new evaluation ID")
+	}
+	exp := map[string]interface{}{
+		"runId":         run,
+		"enforcementId": eval,
+	}
+	if diff := cmp.Diff(exp, Fields(evalCtx)); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+}
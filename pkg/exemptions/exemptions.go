@@ -0,0 +1,186 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package exemptions implements the org-level exemption registry, a single
+// config file listing the repositories exempted from policies, with the
+// reason, approver, and expiry of each exemption. Policies consult the
+// registry with Apply, so that exemptions are handled the same way by all of
+// them.
+package exemptions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ossf/allstar/pkg/cache"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/policydef"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+// ConfigFile is the name of the exemption registry file, read from the
+// org-level config repository only.
+const ConfigFile = "exemptions.yaml"
+
+// AllPolicies is the policy name of an exemption from all policies.
+const AllPolicies = "*"
+
+const exemptText = "\nThis repository is exempt from this policy until %v.\n"
+
+const expiredText = "\nThe exemption of this repository from this policy expired on %v, and is no longer applied.\n"
+
+// OrgConfig is the org-level exemption registry.
+type OrgConfig struct {
+	// Exemptions is the list of exemptions. Exemptions are only defined at the
+	// org level because they should be made obvious to org security managers.
+	Exemptions []*Exemption `json:"exemptions"`
+}
+
+// Exemption is an exemption of repositories from a policy.
+type Exemption struct {
+	// Repo is a GitHub repo name. Globs are allowed.
+	Repo string `json:"repo"`
+
+	// Policy is the name of the policy, eg: "Branch Protection", or "*" for
+	// all policies. Names are not case sensitive.
+	Policy string `json:"policy"`
+
+	// Reason describes why the exemption was granted.
+	Reason string `json:"reason"`
+
+	// Expires is an optional date, in the form YYYY-MM-DD, after which the
+	// exemption is no longer applied.
+	Expires string `json:"expires"`
+
+	// Approver is who approved the exemption, eg: a GitHub username or team.
+	Approver string `json:"approver"`
+}
+
+var gc = cache.NewGlobCache(cache.DefaultSize)
+
+var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+var timeNow func() time.Time
+
+func init() {
+	configFetchConfig = config.FetchConfig
+	timeNow = time.Now
+}
+
+// Get returns the exemptions of the org. Errors fetching the registry are
+// logged, and no exemptions returned.
+func Get(ctx context.Context, c *github.Client, owner string) []*Exemption {
+	oc := &OrgConfig{}
+	if err := configFetchConfig(ctx, c, owner, "", ConfigFile, config.OrgLevel, oc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("configLevel", "orgLevel").
+			Str("area", "bot").
+			Str("file", ConfigFile).
+			Err(err).
+			Msg("Unexpected config error, not applying exemptions.")
+		return nil
+	}
+	return oc.Exemptions
+}
+
+// Find returns the first exemption in ee of repo from policy, and whether it
+// has expired at now. An unexpired exemption is returned over an expired one.
+func Find(ee []*Exemption, repo, policy string, now time.Time) (*Exemption, bool) {
+	var expired *Exemption
+	for _, e := range ee {
+		if e.Policy != AllPolicies && !strings.EqualFold(e.Policy, policy) {
+			continue
+		}
+		g, err := gc.Compile(e.Repo)
+		if err != nil {
+			log.Warn().
+				Str("repo", repo).
+				Str("glob", e.Repo).
+				Err(err).
+				Msg("Unexpected error compiling the glob.")
+			continue
+		}
+		if !g.Match(repo) {
+			continue
+		}
+		status, err := config.CheckExpiry(e.Expires, now)
+		if err != nil {
+			log.Warn().
+				Str("repo", repo).
+				Str("area", policy).
+				Str("glob", e.Repo).
+				Err(err).
+				Msg("Invalid exemption expiry, ignoring exemption.")
+		}
+		if status == config.ExpiryLapsed {
+			if expired == nil {
+				expired = e
+			}
+			continue
+		}
+		return e, false
+	}
+	if expired != nil {
+		return expired, true
+	}
+	return nil, false
+}
+
+// Apply returns the result r of policy on repo, after applying the org
+// exemption registry. A failing result for an exempt repo is returned as
+// passing, and a note is added to the text of a failing result if its
+// exemption has expired. Passing results are returned as-is, without reading
+// the registry.
+func Apply(ctx context.Context, c *github.Client, owner, repo, policy string,
+	r *policydef.Result) *policydef.Result {
+	if r == nil || r.Pass {
+		return r
+	}
+	e, expired := Find(Get(ctx, c, owner), repo, policy, timeNow())
+	if e == nil {
+		return r
+	}
+	if expired {
+		rv := *r
+		rv.NotifyText = rv.NotifyText + fmt.Sprintf(expiredText, e.Expires)
+		return &rv
+	}
+	log.Info().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", policy).
+		Str("glob", e.Repo).
+		Str("reason", e.Reason).
+		Str("approver", e.Approver).
+		Str("expires", e.Expires).
+		Msg("Policy failure exempted by the exemption registry.")
+	rv := *r
+	rv.Pass = true
+	until := e.Expires
+	if until == "" {
+		until = "further notice"
+	}
+	rv.NotifyText = rv.NotifyText + fmt.Sprintf(exemptText, until)
+	if e.Reason != "" {
+		rv.NotifyText = rv.NotifyText + fmt.Sprintf("Reason: %v\n", e.Reason)
+	}
+	if e.Approver != "" {
+		rv.NotifyText = rv.NotifyText + fmt.Sprintf("Approved by: %v\n", e.Approver)
+	}
+	return &rv
+}
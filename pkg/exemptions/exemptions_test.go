@@ -0,0 +1,195 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exemptions
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/policydef"
+)
+
+func TestFind(t *testing.T) {
+	now := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	branch := &Exemption{Repo: "legacy-*", Policy: "branch protection"}
+	all := &Exemption{Repo: "sandbox", Policy: AllPolicies}
+	lapsed := &Exemption{Repo: "old", Policy: "Outside Collaborators", Expires: "2025-08-01"}
+	renewed := &Exemption{Repo: "old", Policy: "Outside Collaborators", Expires: "2025-12-01"}
+	invalid := &Exemption{Repo: "typo", Policy: "Branch Protection", Expires: "tomorrow"}
+	tests := []struct {
+		Name       string
+		Exemptions []*Exemption
+		Repo       string
+		Policy     string
+		Exp        *Exemption
+		ExpExpired bool
+	}{
+		{
+			Name:       "GlobAndCase",
+			Exemptions: []*Exemption{branch, all},
+			Repo:       "legacy-app",
+			Policy:     "Branch Protection",
+			Exp:        branch,
+		},
+		{
+			Name:       "OtherPolicy",
+			Exemptions: []*Exemption{branch},
+			Repo:       "legacy-app",
+			Policy:     "Outside Collaborators",
+		},
+		{
+			Name:       "AllPolicies",
+			Exemptions: []*Exemption{branch, all},
+			Repo:       "sandbox",
+			Policy:     "Binary Artifacts",
+			Exp:        all,
+		},
+		{
+			Name:       "Expired",
+			Exemptions: []*Exemption{lapsed},
+			Repo:       "old",
+			Policy:     "Outside Collaborators",
+			Exp:        lapsed,
+			ExpExpired: true,
+		},
+		{
+			Name:       "UnexpiredPreferred",
+			Exemptions: []*Exemption{lapsed, renewed},
+			Repo:       "old",
+			Policy:     "Outside Collaborators",
+			Exp:        renewed,
+		},
+		{
+			Name:       "InvalidExpiry",
+			Exemptions: []*Exemption{invalid},
+			Repo:       "typo",
+			Policy:     "Branch Protection",
+			Exp:        invalid,
+			ExpExpired: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			e, expired := Find(test.Exemptions, test.Repo, test.Policy, now)
+			if e != test.Exp {
+				t.Errorf("Unexpected exemption: %+v", e)
+			}
+			if expired != test.ExpExpired {
+				t.Errorf("Unexpected expired: %v", expired)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	timeNow = func() time.Time { return time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC) }
+	defer func() { timeNow = time.Now }()
+	registry := OrgConfig{
+		Exemptions: []*Exemption{
+			{
+				Repo:     "legacy",
+				Policy:   "Branch Protection",
+				Reason:   "Archived soon",
+				Approver: "@org/security",
+			},
+			{
+				Repo:    "old",
+				Policy:  "Branch Protection",
+				Expires: "2025-08-01",
+			},
+		},
+	}
+	fail := &policydef.Result{Enabled: true, Pass: false, NotifyText: "Failed.\n"}
+	tests := []struct {
+		Name     string
+		Repo     string
+		Result   *policydef.Result
+		FetchErr error
+		Exp      *policydef.Result
+	}{
+		{
+			Name:   "Passing",
+			Repo:   "legacy",
+			Result: &policydef.Result{Enabled: true, Pass: true},
+			Exp:    &policydef.Result{Enabled: true, Pass: true},
+		},
+		{
+			Name:   "NotExempt",
+			Repo:   "other",
+			Result: fail,
+			Exp:    fail,
+		},
+		{
+			Name:   "Exempt",
+			Repo:   "legacy",
+			Result: fail,
+			Exp: &policydef.Result{
+				Enabled:    true,
+				Pass:       true,
+				NotifyText: "Failed.\n\nThis repository is exempt from this policy until further notice.\nReason: Archived soon\nApproved by: @org/security\n",
+			},
+		},
+		{
+			Name:   "Expired",
+			Repo:   "old",
+			Result: fail,
+			Exp: &policydef.Result{
+				Enabled:    true,
+				Pass:       false,
+				NotifyText: "Failed.\n\nThe exemption of this repository from this policy expired on 2025-08-01, and is no longer applied.\n",
+			},
+		},
+		{
+			Name:     "FetchError",
+			Repo:     "legacy",
+			Result:   fail,
+			FetchErr: errors.New("fail"),
+			Exp:      fail,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			fetched := false
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				fetched = true
+				if ol != config.OrgLevel || repo != "" || path != ConfigFile {
+					t.Errorf("Unexpected fetch: %v %v %v", ol, repo, path)
+				}
+				if test.FetchErr != nil {
+					return test.FetchErr
+				}
+				oc := out.(*OrgConfig)
+				*oc = registry
+				return nil
+			}
+			got := Apply(context.Background(), nil, "thisorg", test.Repo, "Branch Protection", test.Result)
+			if diff := cmp.Diff(test.Exp, got); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+			if fetched == test.Result.Pass {
+				t.Errorf("Unexpected registry fetch: %v", fetched)
+			}
+			if fail.Pass || fail.NotifyText != "Failed.\n" {
+				t.Errorf("Input result was modified: %+v", fail)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ghclients
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+)
+
+type apiCounterKey struct{}
+
+// APICounter counts the GitHub API requests made with a context, see
+// WithAPICounter.
+type APICounter struct {
+	calls atomic.Int64
+	cost  atomic.Int64
+}
+
+// WithAPICounter returns a copy of ctx with a new APICounter, which counts
+// the requests made by clients from GHClients with the returned context.
+// Responses served from the local cache are not counted. An existing counter
+// of ctx does not count the requests made with the returned context.
+func WithAPICounter(ctx context.Context) (context.Context, *APICounter) {
+	c := &APICounter{}
+	return context.WithValue(ctx, apiCounterKey{}, c), c
+}
+
+// Calls returns the number of requests sent to GitHub, including retries.
+func (c *APICounter) Calls() int {
+	return int(c.calls.Load())
+}
+
+// Cost returns the number of requests counted against the rate limit, that is
+// the requests that were not answered with 304 Not Modified.
+func (c *APICounter) Cost() int {
+	return int(c.cost.Load())
+}
+
+// countingTransport is an http.RoundTripper that counts requests on the
+// APICounter of the request context, if any.
+type countingTransport struct {
+	tr http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.tr.RoundTrip(req)
+	if c, ok := req.Context().Value(apiCounterKey{}).(*APICounter); ok {
+		c.calls.Add(1)
+		if err == nil && resp.StatusCode != http.StatusNotModified {
+			c.cost.Add(1)
+		}
+	}
+	return resp, err
+}
@@ -0,0 +1,59 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ghclients
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v59/github"
+)
+
+type statusTransport struct {
+	status int
+}
+
+func (s *statusTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return chaosResponse(req, s.status, http.Header{}, "{}", docsURL), nil
+}
+
+func TestCountingTransport(t *testing.T) {
+	st := &statusTransport{status: http.StatusOK}
+	c := github.NewClient(&http.Client{Transport: &countingTransport{tr: st}})
+
+	// Not counted without a counter.
+	if _, _, err := c.Repositories.Get(context.Background(), "o", "r"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	ctx, counter := WithAPICounter(context.Background())
+	if _, _, err := c.Repositories.Get(ctx, "o", "r"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	st.status = http.StatusNotModified
+	c.Repositories.Get(ctx, "o", "r")
+
+	// Only the innermost counter counts.
+	nctx, nested := WithAPICounter(ctx)
+	c.Repositories.Get(nctx, "o", "r")
+
+	if counter.Calls() != 2 || counter.Cost() != 1 {
+		t.Errorf("Expected 2 calls costing 1, got %v costing %v", counter.Calls(), counter.Cost())
+	}
+	if nested.Calls() != 1 || nested.Cost() != 0 {
+		t.Errorf("Expected 1 call costing 0, got %v costing %v", nested.Calls(), nested.Cost())
+	}
+}
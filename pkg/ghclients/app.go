@@ -0,0 +1,140 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ghclients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+// Permission is a GitHub App permission needed at startup.
+type Permission struct {
+	// Name is the permission name as in the GitHub API, eg: "administration".
+	Name string
+	// Access is the access level needed, "read" or "write".
+	Access string
+	// For describes what needs the permission, eg: "Branch Protection fix".
+	For string
+}
+
+func (p Permission) String() string {
+	return p.Name + ":" + p.Access
+}
+
+// ParsePermission parses a "name:access" permission, eg:
+// "administration:write".
+func ParsePermission(s, forWhat string) (Permission, error) {
+	name, access, ok := strings.Cut(s, ":")
+	if !ok || name == "" || accessLevels[access] == 0 {
+		return Permission{}, fmt.Errorf("invalid permission %q, expected name:read or name:write", s)
+	}
+	return Permission{Name: name, Access: access, For: forWhat}, nil
+}
+
+// accessLevels orders the access levels, a higher level implies the lower.
+var accessLevels = map[string]int{
+	"read":  1,
+	"write": 2,
+	"admin": 3,
+}
+
+var getApp func(context.Context, *github.Client) (*github.App, error)
+
+func init() {
+	getApp = getAppReal
+}
+
+func getAppReal(ctx context.Context, c *github.Client) (*github.App, error) {
+	app, _, err := c.Apps.Get(ctx, "")
+	return app, err
+}
+
+// VerifyApp gets the GitHub App authenticated as by c, an app-level client, to
+// verify the App ID and private key, and logs the app slug, ID, and
+// permissions. It returns the app and the permissions in need that the app
+// does not have.
+func VerifyApp(ctx context.Context, c *github.Client, need []Permission) (*github.App, []Permission, error) {
+	app, err := getApp(ctx, c)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get the GitHub App, check the App ID and private key: %w", err)
+	}
+	have := PermissionsMap(app.Permissions)
+	log.Info().
+		Str("slug", app.GetSlug()).
+		Int64("id", app.GetID()).
+		Interface("permissions", have).
+		Msg("Verified GitHub App credentials.")
+	return app, MissingPermissions(have, need), nil
+}
+
+// PermissionsMap returns the permissions granted by p, to an app or an
+// installation, keyed by name. It returns nil if p is nil.
+func PermissionsMap(p *github.InstallationPermissions) map[string]string {
+	if p == nil {
+		return nil
+	}
+	have := make(map[string]string)
+	b, err := json.Marshal(p)
+	if err != nil {
+		return have
+	}
+	_ = json.Unmarshal(b, &have)
+	return have
+}
+
+// MissingPermissions returns the permissions in need that are not granted by
+// have, a write permission also grants read.
+func MissingPermissions(have map[string]string, need []Permission) []Permission {
+	var missing []Permission
+	for _, p := range need {
+		if accessLevels[have[p.Name]] < accessLevels[p.Access] {
+			missing = append(missing, p)
+		}
+	}
+	return missing
+}
+
+// MissingPermissionsError returns an error listing the permissions missing
+// from app, with what needs them, and how to grant them.
+func MissingPermissionsError(app *github.App, missing []Permission) error {
+	byPerm := make(map[string][]string)
+	for _, p := range missing {
+		fors := byPerm[p.String()]
+		if p.For != "" && !slices.Contains(fors, p.For) {
+			fors = append(fors, p.For)
+		}
+		byPerm[p.String()] = fors
+	}
+	var l []string
+	for perm, fors := range byPerm {
+		if len(fors) == 0 {
+			l = append(l, perm)
+			continue
+		}
+		l = append(l, fmt.Sprintf("%v (%v)", perm, strings.Join(fors, ", ")))
+	}
+	sort.Strings(l)
+	return fmt.Errorf("GitHub App %q (ID %d) is missing permissions: %v. "+
+		"Grant them under \"Permissions & events\" in the app settings, then "+
+		"accept the new permissions on each installation. See %v",
+		app.GetSlug(), app.GetID(), strings.Join(l, "; "), app.GetHTMLURL())
+}
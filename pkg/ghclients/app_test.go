@@ -0,0 +1,96 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ghclients
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+)
+
+func perm(t *testing.T, s, forWhat string) Permission {
+	t.Helper()
+	p, err := ParsePermission(s, forWhat)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return p
+}
+
+func TestParsePermission(t *testing.T) {
+	for _, s := range []string{"administration", ":read", "administration:none", "administration:"} {
+		if _, err := ParsePermission(s, ""); err == nil {
+			t.Errorf("Expected error parsing %q", s)
+		}
+	}
+	p := perm(t, "administration:write", "Branch Protection fix")
+	if p.Name != "administration" || p.Access != "write" || p.String() != "administration:write" {
+		t.Errorf("Unexpected permission: %+v", p)
+	}
+}
+
+func TestVerifyApp(t *testing.T) {
+	app := &github.App{
+		ID:      github.Int64(123),
+		Slug:    github.String("allstar"),
+		HTMLURL: github.String("https://github.com/apps/allstar"),
+		Permissions: &github.InstallationPermissions{
+			Administration: github.String("read"),
+			Contents:       github.String("write"),
+			Issues:         github.String("write"),
+		},
+	}
+	var getErr error
+	getApp = func(ctx context.Context, c *github.Client) (*github.App, error) {
+		return app, getErr
+	}
+	defer func() { getApp = getAppReal }()
+
+	need := []Permission{
+		perm(t, "contents:read", ""),
+		perm(t, "issues:write", ""),
+		perm(t, "administration:read", "Branch Protection"),
+		perm(t, "administration:write", "Branch Protection fix"),
+		perm(t, "administration:write", "Fork PR Workflows fix"),
+		perm(t, "checks:read", "Status Check Freshness"),
+	}
+	_, missing, err := VerifyApp(context.Background(), nil, need)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(need[3:], missing); diff != "" {
+		t.Errorf("Unexpected missing permissions (-want +got):\n%s", diff)
+	}
+
+	msg := MissingPermissionsError(app, missing).Error()
+	for _, want := range []string{
+		`"allstar" (ID 123)`,
+		"administration:write (Branch Protection fix, Fork PR Workflows fix); checks:read (Status Check Freshness)",
+		"https://github.com/apps/allstar",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("Expected %q in error: %v", want, msg)
+		}
+	}
+
+	getErr = errors.New("401 A JSON web token could not be decoded")
+	if _, _, err := VerifyApp(context.Background(), nil, need); err == nil || !strings.Contains(err.Error(), "App ID and private key") {
+		t.Errorf("Expected actionable credentials error, got: %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ghclients
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ossf/allstar/pkg/audit"
+)
+
+// auditTransport is an http.RoundTripper that records the requests making
+// changes on GitHub to the audit log, see the audit package. Reads, and the
+// installation token requests of the App, are not recorded.
+type auditTransport struct {
+	tr http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (a *auditTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !audit.Enabled() || req.Method == http.MethodGet || req.Method == http.MethodHead ||
+		strings.HasSuffix(req.URL.Path, "/access_tokens") {
+		return a.tr.RoundTrip(req)
+	}
+	var body []byte
+	if req.GetBody != nil {
+		if r, err := req.GetBody(); err == nil {
+			body, _ = io.ReadAll(r)
+			r.Close()
+		}
+	}
+	p := strings.TrimPrefix(req.URL.Path, "/api/v3")
+	if strings.HasSuffix(p, "/graphql") && !strings.Contains(string(body), "mutation") {
+		return a.tr.RoundTrip(req)
+	}
+	e := audit.Event{
+		Method:  req.Method,
+		Path:    p,
+		Request: audit.Summarize(body),
+	}
+	e.Org, e.Repo = auditTarget(p)
+	rsp, err := a.tr.RoundTrip(req)
+	if err != nil {
+		e.Error = err.Error()
+	} else {
+		e.Status = rsp.StatusCode
+	}
+	audit.Record(req.Context(), e)
+	return rsp, err
+}
+
+// auditTarget returns the org and repo of a GitHub API path, eg:
+// "/repos/acme/app/branches/main/protection" or "/orgs/acme/actions/permissions".
+func auditTarget(p string) (org, repo string) {
+	parts := strings.Split(strings.TrimPrefix(p, "/"), "/")
+	switch {
+	case len(parts) >= 3 && parts[0] == "repos":
+		return parts[1], parts[2]
+	case len(parts) >= 2 && parts[0] == "orgs":
+		return parts[1], ""
+	}
+	return "", ""
+}
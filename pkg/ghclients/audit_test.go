@@ -0,0 +1,105 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ghclients
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ossf/allstar/pkg/audit"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+type memSink struct {
+	mu     sync.Mutex
+	events []audit.Event
+}
+
+func (m *memSink) Write(ctx context.Context, events []audit.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, events...)
+	return nil
+}
+
+func (m *memSink) Close() error {
+	return nil
+}
+
+func TestAuditTransport(t *testing.T) {
+	m := &memSink{}
+	audit.SetSink(m)
+	defer audit.SetSink(nil)
+	tr := &auditTransport{tr: &seqTransport{rsps: []func(*http.Request) *http.Response{status(http.StatusOK)}}}
+	ctx := audit.WithPolicy(context.Background(), "Branch Protection")
+	do := func(method, url, body string) {
+		t.Helper()
+		var req *http.Request
+		var err error
+		if body == "" {
+			req, err = http.NewRequestWithContext(ctx, method, url, nil)
+		} else {
+			req, err = http.NewRequestWithContext(ctx, method, url, strings.NewReader(body))
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := tr.RoundTrip(req); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	do("GET", "https://api.github.com/repos/acme/app/branches/main/protection", "")
+	do("PUT", "https://api.github.com/repos/acme/app/branches/main/protection", `{"enforce_admins":true}`)
+	do("POST", "https://ghe.acme.com/api/v3/orgs/acme/actions/runners", "")
+	do("POST", "https://api.github.com/app/installations/1/access_tokens", "")
+	do("POST", "https://api.github.com/graphql", `{"query":"query { viewer { login } }"}`)
+	do("POST", "https://api.github.com/graphql", `{"query":"mutation { addComment }"}`)
+	if err := audit.Flush(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []audit.Event{
+		{
+			Org:     "acme",
+			Repo:    "app",
+			Policy:  "Branch Protection",
+			Method:  "PUT",
+			Path:    "/repos/acme/app/branches/main/protection",
+			Request: map[string]string{"enforce_admins": "true"},
+			Status:  http.StatusOK,
+		},
+		{
+			Org:    "acme",
+			Policy: "Branch Protection",
+			Method: "POST",
+			Path:   "/orgs/acme/actions/runners",
+			Status: http.StatusOK,
+		},
+		{
+			Policy:  "Branch Protection",
+			Method:  "POST",
+			Path:    "/graphql",
+			Request: map[string]string{"query": "mutation { addComment }"},
+			Status:  http.StatusOK,
+		},
+	}
+	if diff := cmp.Diff(want, m.events, cmpopts.IgnoreFields(audit.Event{}, "Time")); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+}
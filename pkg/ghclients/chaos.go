@@ -0,0 +1,141 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ghclients
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+var randFloat64 func() float64
+var randIntn func(int) int
+
+func init() {
+	randFloat64 = rand.Float64
+	randIntn = rand.Intn
+}
+
+// chaosTransport is an http.RoundTripper that fails a fraction of requests
+// with synthetic GitHub API errors instead of sending them, for resilience
+// testing. See operator.ChaosRate.
+type chaosTransport struct {
+	tr       http.RoundTripper
+	rate     float64
+	failures []string
+}
+
+// chaosTimeoutError is returned for injected "timeout" failures. It implements
+// net.Error.
+type chaosTimeoutError struct{}
+
+func (chaosTimeoutError) Error() string   { return "allstar chaos: injected timeout" }
+func (chaosTimeoutError) Timeout() bool   { return true }
+func (chaosTimeoutError) Temporary() bool { return true }
+
+const docsURL = "https://docs.github.com/rest"
+
+// secondaryDocsURL is how GitHub, and go-github, identify secondary rate
+// limit errors.
+const secondaryDocsURL = "https://docs.github.com/rest/overview/rate-limits-for-the-rest-api#about-secondary-rate-limits"
+
+var chaosKinds = map[string]bool{
+	"ratelimit": true,
+	"secondary": true,
+	"403":       true,
+	"404":       true,
+	"timeout":   true,
+}
+
+func newChaosTransport(tr http.RoundTripper, rate float64, failures []string) *chaosTransport {
+	var fs []string
+	for _, f := range failures {
+		f = strings.TrimSpace(f)
+		if !chaosKinds[f] {
+			log.Warn().
+				Str("area", "chaos").
+				Str("failure", f).
+				Msg("Unknown chaos failure kind, ignoring.")
+			continue
+		}
+		fs = append(fs, f)
+	}
+	log.Warn().
+		Str("area", "chaos").
+		Float64("rate", rate).
+		Strs("failures", fs).
+		Msg("Chaos failure injection enabled, do not use in production.")
+	return &chaosTransport{
+		tr:       tr,
+		rate:     rate,
+		failures: fs,
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (c *chaosTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if len(c.failures) == 0 || randFloat64() >= c.rate {
+		return c.tr.RoundTrip(req)
+	}
+	f := c.failures[randIntn(len(c.failures))]
+	log.Debug().
+		Str("area", "chaos").
+		Str("failure", f).
+		Str("method", req.Method).
+		Str("url", req.URL.String()).
+		Msg("Injecting synthetic API failure.")
+	h := http.Header{}
+	switch f {
+	case "ratelimit":
+		h.Set("X-RateLimit-Limit", "5000")
+		h.Set("X-RateLimit-Remaining", "0")
+		h.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10))
+		return chaosResponse(req, http.StatusForbidden, h, "API rate limit exceeded for installation.", docsURL), nil
+	case "secondary":
+		h.Set("Retry-After", "1")
+		return chaosResponse(req, http.StatusForbidden, h, "You have exceeded a secondary rate limit. Please wait a few minutes before you try again.", secondaryDocsURL), nil
+	case "403":
+		return chaosResponse(req, http.StatusForbidden, h, "Resource not accessible by integration", docsURL), nil
+	case "404":
+		return chaosResponse(req, http.StatusNotFound, h, "Not Found", docsURL), nil
+	case "timeout":
+		return nil, chaosTimeoutError{}
+	default:
+		return c.tr.RoundTrip(req)
+	}
+}
+
+func chaosResponse(req *http.Request, code int, h http.Header, msg, doc string) *http.Response {
+	h.Set("Content-Type", "application/json; charset=utf-8")
+	body := fmt.Sprintf(`{"message":%q,"documentation_url":%q}`, msg, doc)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", code, http.StatusText(code)),
+		StatusCode:    code,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          io.NopCloser(bytes.NewBufferString(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
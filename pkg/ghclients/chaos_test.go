@@ -0,0 +1,106 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ghclients
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v59/github"
+)
+
+type okTransport struct {
+	called int
+}
+
+func (o *okTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	o.called++
+	return chaosResponse(req, http.StatusOK, http.Header{}, "ok", docsURL), nil
+}
+
+func TestChaosTransport(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Failure string
+		Rand    float64
+		Check   func(*github.Response, error) bool
+	}{
+		{
+			Name:    "BelowRate",
+			Failure: "404",
+			Rand:    0.5,
+			Check: func(rsp *github.Response, err error) bool {
+				return err == nil
+			},
+		},
+		{
+			Name:    "RateLimit",
+			Failure: "ratelimit",
+			Check: func(rsp *github.Response, err error) bool {
+				var rle *github.RateLimitError
+				return errors.As(err, &rle)
+			},
+		},
+		{
+			Name:    "Secondary",
+			Failure: "secondary",
+			Check: func(rsp *github.Response, err error) bool {
+				var are *github.AbuseRateLimitError
+				return errors.As(err, &are) && are.GetRetryAfter() > 0
+			},
+		},
+		{
+			Name:    "Forbidden",
+			Failure: "403",
+			Check: func(rsp *github.Response, err error) bool {
+				return err != nil && rsp != nil && rsp.StatusCode == http.StatusForbidden
+			},
+		},
+		{
+			Name:    "NotFound",
+			Failure: "404",
+			Check: func(rsp *github.Response, err error) bool {
+				return err != nil && rsp != nil && rsp.StatusCode == http.StatusNotFound
+			},
+		},
+		{
+			Name:    "Timeout",
+			Failure: "timeout",
+			Check: func(rsp *github.Response, err error) bool {
+				var ne net.Error
+				return errors.As(err, &ne) && ne.Timeout()
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			randFloat64 = func() float64 { return test.Rand }
+			randIntn = func(int) int { return 0 }
+			ok := &okTransport{}
+			tr := newChaosTransport(ok, 0.1, []string{test.Failure, "bogus"})
+			c := github.NewClient(&http.Client{Transport: tr})
+			_, rsp, err := c.Repositories.Get(context.Background(), "org", "repo")
+			if !test.Check(rsp, err) {
+				t.Errorf("Unexpected result, rsp: %v err: %v", rsp, err)
+			}
+			if test.Rand >= 0.1 && ok.called != 1 {
+				t.Errorf("Expected request to be passed through")
+			}
+		})
+	}
+}
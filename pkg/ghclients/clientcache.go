@@ -0,0 +1,162 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ghclients
+
+import (
+	"container/list"
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+// refreshInterval is how often stored installation tokens are checked for
+// refresh.
+const refreshInterval = 30 * time.Second
+
+// tokenLifetime is how long GitHub installation tokens are valid for.
+const tokenLifetime = time.Hour
+
+var clientStats struct {
+	hits          atomic.Int64
+	misses        atomic.Int64
+	evictions     atomic.Int64
+	refreshes     atomic.Int64
+	refreshErrors atomic.Int64
+	size          atomic.Int64
+}
+
+// ClientCacheStats are counters of installation client caching since process
+// start.
+type ClientCacheStats struct {
+	// Hits is the number of requested clients that were stored.
+	Hits int64
+	// Misses is the number of requested clients that were created.
+	Misses int64
+	// Evictions is the number of stored clients evicted, as the least
+	// recently used, or as idle when their token expired.
+	Evictions int64
+	// Refreshes is the number of installation tokens refreshed in the
+	// background.
+	Refreshes int64
+	// RefreshErrors is the number of background token refreshes that failed.
+	RefreshErrors int64
+	// Size is the number of stored clients.
+	Size int64
+}
+
+// GetClientCacheStats returns the current installation client cache counters.
+func GetClientCacheStats() ClientCacheStats {
+	return ClientCacheStats{
+		Hits:          clientStats.hits.Load(),
+		Misses:        clientStats.misses.Load(),
+		Evictions:     clientStats.evictions.Load(),
+		Refreshes:     clientStats.refreshes.Load(),
+		RefreshErrors: clientStats.refreshErrors.Load(),
+		Size:          clientStats.size.Load(),
+	}
+}
+
+// tokenSource is the installation token of a client, implemented by
+// ghinstallation.Transport.
+type tokenSource interface {
+	Token(context.Context) (string, error)
+	Expiry() (time.Time, time.Time, error)
+}
+
+// clientEntry is a stored client.
+type clientEntry struct {
+	id     int64
+	client *github.Client
+	cache  *memoryCache
+	// token is nil for the app-level client.
+	token    tokenSource
+	lastUsed time.Time
+}
+
+// add stores ce as the most recently used client, evicting the least recently
+// used beyond the maximum. Callers must hold g.mu.
+func (g *GHClients) add(ce *clientEntry) {
+	g.clients[ce.id] = g.order.PushFront(ce)
+	for g.max > 0 && g.order.Len() > g.max {
+		g.remove(g.order.Back())
+	}
+	clientStats.size.Store(int64(g.order.Len()))
+}
+
+// remove evicts a stored client. Callers must hold g.mu.
+func (g *GHClients) remove(e *list.Element) {
+	g.order.Remove(e)
+	delete(g.clients, e.Value.(*clientEntry).id)
+	clientStats.evictions.Add(1)
+	clientStats.size.Store(int64(g.order.Len()))
+}
+
+// StartTokenRefresh refreshes the tokens of stored installation clients in the
+// background as they near expiry, until ctx is done, so that requests do not
+// wait on a refresh. Clients that were not used since their token was issued
+// are evicted instead, so idle installations do not cause token churn.
+func (g *GHClients) StartTokenRefresh(ctx context.Context) {
+	go func() {
+		t := time.NewTicker(refreshInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				g.refreshTokens(ctx)
+			}
+		}
+	}()
+}
+
+func (g *GHClients) refreshTokens(ctx context.Context) {
+	now := timeNow()
+	var due []*clientEntry
+	g.mu.Lock()
+	for e := g.order.Front(); e != nil; {
+		next := e.Next()
+		ce := e.Value.(*clientEntry)
+		if ce.token != nil {
+			expiresAt, refreshAt, err := ce.token.Expiry()
+			// No token yet if err, it is fetched on first use.
+			if err == nil && !now.Before(refreshAt) {
+				if ce.lastUsed.Before(expiresAt.Add(-tokenLifetime)) {
+					g.remove(e)
+				} else {
+					due = append(due, ce)
+				}
+			}
+		}
+		e = next
+	}
+	g.mu.Unlock()
+
+	for _, ce := range due {
+		if _, err := ce.token.Token(ctx); err != nil {
+			clientStats.refreshErrors.Add(1)
+			log.Warn().
+				Str("area", "bot").
+				Int64("instId", ce.id).
+				Err(err).
+				Msg("Could not refresh installation token.")
+			continue
+		}
+		clientStats.refreshes.Add(1)
+	}
+}
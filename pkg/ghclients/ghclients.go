@@ -17,29 +17,30 @@
 package ghclients
 
 import (
+	"bytes"
+	"container/list"
 	"context"
 	"net/http"
 	"strings"
+	"sync"
 
 	"github.com/bradleyfalzon/ghinstallation/v2"
 	"github.com/google/go-github/v59/github"
 	"github.com/gregjones/httpcache"
 	"github.com/ossf/allstar/pkg/config/operator"
-	"gocloud.dev/runtimevar"
-	_ "gocloud.dev/runtimevar/awssecretsmanager"
-	_ "gocloud.dev/runtimevar/filevar"
-	_ "gocloud.dev/runtimevar/gcpsecretmanager"
 )
 
 var ghinstallationNewAppsTransport func(http.RoundTripper, int64,
 	[]byte) (*ghinstallation.AppsTransport, error)
 var ghinstallationNew func(http.RoundTripper, int64, int64, []byte) (
 	*ghinstallation.Transport, error)
-var getKey func(context.Context) ([]byte, error)
+var getKey func(context.Context) (*Secret, error)
 var getKeyFromSecret func(context.Context, string) ([]byte, error)
 
 var privateKey = operator.PrivateKey
 var keySecret = operator.KeySecret
+var keySecretTTL = operator.KeySecretTTL
+var maxInstallationClients = operator.MaxInstallationClients
 
 func init() {
 	ghinstallationNewAppsTransport = ghinstallation.NewAppsTransport
@@ -54,47 +55,101 @@ type GhClientsInterface interface {
 }
 
 // GHClients stores clients per-installation for re-use throughout a process.
+// At most maxInstallationClients are stored, the least recently used are
+// evicted beyond it.
 type GHClients struct {
-	clients map[int64]*github.Client
-	tr      http.RoundTripper
-	key     []byte
+	mu sync.Mutex
+	// clients holds the elements of order, by installation id.
+	clients map[int64]*list.Element
+	// order holds *clientEntry, most recently used first.
+	order *list.List
+	max   int
+	tr    http.RoundTripper
+	key   *Secret
+	// keyVal is the key the stored clients were created with.
+	keyVal []byte
 }
 
 // NewGHClients returns a new GHClients. The provided RoundTripper will be
-// stored and used when creating new clients.
+// stored and used when creating new clients. It is wrapped to retry on
+// secondary rate limits, to count requests, see WithAPICounter, to record
+// changes to the audit log, see the audit package, and if operator.ChaosRate is
+// set, to inject synthetic failures.
 func NewGHClients(ctx context.Context, t http.RoundTripper) (*GHClients, error) {
 	key, err := getKey(ctx)
 	if err != nil {
 		return nil, err
 	}
+	t = &countingTransport{tr: t}
+	if operator.ChaosRate > 0 {
+		t = newChaosTransport(t, operator.ChaosRate, operator.ChaosFailures)
+	}
+	t = &retryTransport{tr: t}
+	t = &auditTransport{tr: t}
 	return &GHClients{
-		clients: make(map[int64]*github.Client),
+		clients: make(map[int64]*list.Element),
+		order:   list.New(),
+		max:     maxInstallationClients,
 		tr:      t,
 		key:     key,
+		keyVal:  key.Value(ctx),
 	}, nil
 }
 
+// Key returns the App private key, for the caller to reload it, eg: with
+// ReloadOnSIGHUP.
+func (g *GHClients) Key() *Secret {
+	return g.key
+}
+
+// Free releases the cached API responses of installation id i, once it is
+// done being enforced. The client and its token are kept for re-use.
 func (g *GHClients) Free(i int64) {
-	delete(g.clients, i)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if e, ok := g.clients[i]; ok {
+		e.Value.(*clientEntry).cache.clear()
+	}
 }
 
 // Get gets the client for installation id i, If i is 0 it gets the client for
 // the app-level api. If a stored client is not available, it creates a new
-// client with auth and caching built in.
+// client with auth and caching built in. If the App private key was rotated,
+// all stored clients are dropped and created again with the new key.
 func (g *GHClients) Get(i int64) (*github.Client, error) {
-	if c, ok := g.clients[i]; ok {
-		return c, nil
+	key := g.key.Value(context.Background())
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !bytes.Equal(key, g.keyVal) {
+		g.clients = make(map[int64]*list.Element)
+		g.order.Init()
+		g.keyVal = key
+		clientStats.size.Store(0)
 	}
+	if e, ok := g.clients[i]; ok {
+		clientStats.hits.Add(1)
+		g.order.MoveToFront(e)
+		ce := e.Value.(*clientEntry)
+		ce.lastUsed = timeNow()
+		return ce.client, nil
+	}
+	clientStats.misses.Add(1)
 
+	mc := newMemoryCache()
 	ctr := &httpcache.Transport{
 		Transport:           g.tr,
-		Cache:               newMemoryCache(),
+		Cache:               mc,
 		MarkCachedResponses: true,
 	}
+	ce := &clientEntry{
+		id:       i,
+		cache:    mc,
+		lastUsed: timeNow(),
+	}
 
 	var tr http.RoundTripper
 	if i == 0 {
-		appTransport, err := ghinstallationNewAppsTransport(ctr, operator.AppID, g.key)
+		appTransport, err := ghinstallationNewAppsTransport(ctr, operator.AppID, key)
 		if err != nil {
 			return nil, err
 		}
@@ -104,7 +159,7 @@ func (g *GHClients) Get(i int64) (*github.Client, error) {
 		}
 		tr = appTransport
 	} else {
-		ghiTransport, err := ghinstallationNew(ctr, operator.AppID, i, g.key)
+		ghiTransport, err := ghinstallationNew(ctr, operator.AppID, i, key)
 		if err != nil {
 			return nil, err
 		}
@@ -112,7 +167,7 @@ func (g *GHClients) Get(i int64) (*github.Client, error) {
 			ghiTransport.BaseURL = fullEnterpriseApiUrl(operator.GitHubEnterpriseUrl)
 		}
 		tr = ghiTransport
-
+		ce.token = ghiTransport
 	}
 
 	c := github.NewClient(&http.Client{Transport: tr})
@@ -124,8 +179,9 @@ func (g *GHClients) Get(i int64) (*github.Client, error) {
 		c = newC
 	}
 
-	g.clients[i] = c
-	return g.clients[i], nil
+	ce.client = c
+	g.add(ce)
+	return c, nil
 }
 
 // fullEnterpriseApiUrl ensures the base url is in the correct format for GitHub Enterprise usage
@@ -140,22 +196,9 @@ func fullEnterpriseApiUrl(baseUrl string) string {
 	return baseUrl
 }
 
-func getKeyFromSecretReal(ctx context.Context, keySecretVal string) ([]byte, error) {
-	v, err := runtimevar.OpenVariable(ctx, keySecretVal)
-	if err != nil {
-		return nil, err
-	}
-	defer v.Close()
-	s, err := v.Latest(ctx)
-	if err != nil {
-		return nil, err
-	}
-	return s.Value.([]byte), nil
-}
-
-func getKeyReal(ctx context.Context) ([]byte, error) {
+func getKeyReal(ctx context.Context) (*Secret, error) {
 	if keySecret == "direct" {
-		return []byte(privateKey), nil
+		return NewStaticSecret([]byte(privateKey)), nil
 	}
-	return getKeyFromSecret(ctx, keySecret)
+	return NewSecret(ctx, keySecret, keySecretTTL)
 }
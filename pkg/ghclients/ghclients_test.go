@@ -15,11 +15,13 @@
 package ghclients
 
 import (
+	"container/list"
 	"context"
 	"fmt"
 	"net/http"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/bradleyfalzon/ghinstallation/v2"
 	"github.com/google/go-cmp/cmp"
@@ -127,7 +129,7 @@ func TestGetKey(t *testing.T) {
 			if err != nil {
 				t.Fatalf("Unexpected error: %v", err)
 			}
-			if diff := cmp.Diff([]byte(test.ExpKey), ghc.key); diff != "" {
+			if diff := cmp.Diff([]byte(test.ExpKey), ghc.keyVal); diff != "" {
 				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
 			}
 		})
@@ -188,3 +190,145 @@ func TestGitHubEnterpriseClient(t *testing.T) {
 		})
 	}
 }
+
+func TestGetEviction(t *testing.T) {
+	ghinstallationNew = func(r http.RoundTripper, a int64, i int64,
+		f []byte) (*ghinstallation.Transport, error) {
+		return &ghinstallation.Transport{BaseURL: fmt.Sprint(i)}, nil
+	}
+	getKeyFromSecret = func(ctx context.Context, keySecretVal string) ([]byte, error) {
+		return nil, nil
+	}
+	defer func(max int) { maxInstallationClients = max }(maxInstallationClients)
+	maxInstallationClients = 2
+
+	ghc, err := NewGHClients(context.Background(), http.DefaultTransport)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	before := GetClientCacheStats()
+	for _, i := range []int64{1, 2, 1, 3} {
+		if _, err := ghc.Get(i); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if _, ok := ghc.clients[2]; ok {
+		t.Errorf("Least recently used client not evicted")
+	}
+	if _, ok := ghc.clients[1]; !ok {
+		t.Errorf("Recently used client evicted")
+	}
+	after := GetClientCacheStats()
+	if hits := after.Hits - before.Hits; hits != 1 {
+		t.Errorf("Unexpected hits: %v", hits)
+	}
+	if misses := after.Misses - before.Misses; misses != 3 {
+		t.Errorf("Unexpected misses: %v", misses)
+	}
+	if evictions := after.Evictions - before.Evictions; evictions != 1 {
+		t.Errorf("Unexpected evictions: %v", evictions)
+	}
+	if after.Size != 2 {
+		t.Errorf("Unexpected size: %v", after.Size)
+	}
+}
+
+func TestFree(t *testing.T) {
+	ghinstallationNew = func(r http.RoundTripper, a int64, i int64,
+		f []byte) (*ghinstallation.Transport, error) {
+		return &ghinstallation.Transport{BaseURL: fmt.Sprint(i)}, nil
+	}
+	getKeyFromSecret = func(ctx context.Context, keySecretVal string) ([]byte, error) {
+		return nil, nil
+	}
+	ghc, err := NewGHClients(context.Background(), http.DefaultTransport)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	c1, err := ghc.Get(123)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ce := ghc.clients[123].Value.(*clientEntry)
+	ce.cache.Set("key", []byte("response"))
+	ghc.Free(123)
+	if _, ok := ce.cache.Get("key"); ok {
+		t.Errorf("Cached responses not released")
+	}
+	c2, err := ghc.Get(123)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if c1 != c2 {
+		t.Errorf("Client not kept")
+	}
+}
+
+type mockToken struct {
+	expiresAt time.Time
+	refreshed int
+}
+
+func (m *mockToken) Token(ctx context.Context) (string, error) {
+	m.refreshed++
+	return "token", nil
+}
+
+func (m *mockToken) Expiry() (time.Time, time.Time, error) {
+	return m.expiresAt, m.expiresAt.Add(-time.Minute), nil
+}
+
+func TestRefreshTokens(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	tests := []struct {
+		Name         string
+		ExpiresAt    time.Time
+		LastUsed     time.Time
+		ExpRefreshed int
+		ExpStored    bool
+	}{
+		{
+			Name:      "NotDue",
+			ExpiresAt: now.Add(30 * time.Minute),
+			LastUsed:  now.Add(-10 * time.Minute),
+			ExpStored: true,
+		},
+		{
+			Name:         "Due",
+			ExpiresAt:    now.Add(30 * time.Second),
+			LastUsed:     now.Add(-10 * time.Minute),
+			ExpRefreshed: 1,
+			ExpStored:    true,
+		},
+		{
+			Name:      "Idle",
+			ExpiresAt: now.Add(30 * time.Second),
+			LastUsed:  now.Add(-2 * time.Hour),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ghc := &GHClients{
+				clients: make(map[int64]*list.Element),
+				order:   list.New(),
+			}
+			tok := &mockToken{expiresAt: test.ExpiresAt}
+			ghc.add(&clientEntry{id: 123, token: tok, lastUsed: test.LastUsed})
+			ghc.add(&clientEntry{id: 0, lastUsed: test.LastUsed})
+
+			ghc.refreshTokens(context.Background())
+			if tok.refreshed != test.ExpRefreshed {
+				t.Errorf("Unexpected refreshes: %v", tok.refreshed)
+			}
+			if _, ok := ghc.clients[123]; ok != test.ExpStored {
+				t.Errorf("Unexpected stored: %v", ok)
+			}
+			if _, ok := ghc.clients[0]; !ok {
+				t.Errorf("App client evicted")
+			}
+		})
+	}
+}
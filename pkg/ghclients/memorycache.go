@@ -85,6 +85,13 @@ func (c *memoryCache) Delete(key string) {
 		Msg("Cache DELETE request")
 }
 
+// clear removes all items from the cache
+func (c *memoryCache) clear() {
+	c.mu.Lock()
+	c.items = map[string][]byte{}
+	c.mu.Unlock()
+}
+
 func (c *memoryCache) LogCacheSize() {
 	var total int
 	for _, b := range c.items {
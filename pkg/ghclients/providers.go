@@ -0,0 +1,197 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ghclients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"gocloud.dev/runtimevar"
+	_ "gocloud.dev/runtimevar/awssecretsmanager"
+	_ "gocloud.dev/runtimevar/filevar"
+	_ "gocloud.dev/runtimevar/gcpsecretmanager"
+)
+
+const envPrefix = "env:"
+
+// SecretProvider reads secrets from a secret backend, such as a cloud secret
+// manager. The provider is selected by the URL scheme of the secret source,
+// see RegisterSecretProvider.
+type SecretProvider interface {
+	// ReadSecret returns the value of the secret at source.
+	ReadSecret(ctx context.Context, source string) ([]byte, error)
+}
+
+// The built in providers are:
+//   - "env:NAME" reads the environment variable NAME.
+//   - "vault://mount/path?field=name" reads a field of a HashiCorp Vault KV
+//     secret, see vaultProvider.
+//   - Any other source is opened with gocloud.dev/runtimevar, which supports
+//     "gcpsecretmanager://", "awssecretsmanager://" and "file://".
+var secretProviders = map[string]SecretProvider{
+	"env":   envProvider{},
+	"vault": vaultProvider{},
+}
+
+var secretProvidersMu sync.RWMutex
+
+var defaultSecretProvider SecretProvider = runtimevarProvider{}
+
+// vaultHTTPClient is the client used to call the Vault API.
+var vaultHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// RegisterSecretProvider registers p to read secret sources with the URL
+// scheme, replacing any provider already registered for it. It allows
+// operators building their own binary to add secret backends.
+func RegisterSecretProvider(scheme string, p SecretProvider) {
+	secretProvidersMu.Lock()
+	defer secretProvidersMu.Unlock()
+	secretProviders[scheme] = p
+}
+
+func secretProviderFor(source string) SecretProvider {
+	scheme, _, ok := strings.Cut(source, ":")
+	if !ok {
+		return defaultSecretProvider
+	}
+	secretProvidersMu.RLock()
+	defer secretProvidersMu.RUnlock()
+	if p, ok := secretProviders[scheme]; ok {
+		return p
+	}
+	return defaultSecretProvider
+}
+
+type envProvider struct{}
+
+func (envProvider) ReadSecret(ctx context.Context, source string) ([]byte, error) {
+	name := strings.TrimPrefix(strings.TrimPrefix(source, envPrefix), "//")
+	v := osGetenv(name)
+	if v == "" {
+		return nil, fmt.Errorf("environment variable %v is not set", name)
+	}
+	return []byte(v), nil
+}
+
+type runtimevarProvider struct{}
+
+func (runtimevarProvider) ReadSecret(ctx context.Context, source string) ([]byte, error) {
+	return getKeyFromSecret(ctx, source)
+}
+
+func getKeyFromSecretReal(ctx context.Context, keySecretVal string) ([]byte, error) {
+	v, err := runtimevar.OpenVariable(ctx, keySecretVal)
+	if err != nil {
+		return nil, err
+	}
+	defer v.Close()
+	s, err := v.Latest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.Value.([]byte), nil
+}
+
+// vaultProvider reads secrets from the HashiCorp Vault KV secrets engine, with
+// sources like "vault://secret/allstar?field=private_key". The host is the
+// mount path of the engine, and the path is the secret path. The field
+// defaults to "value". Version 2 of the engine is assumed, add "version=1" for
+// version 1. The Vault address and token are read from the VAULT_ADDR and
+// VAULT_TOKEN environment variables, and the namespace from VAULT_NAMESPACE if
+// set.
+type vaultProvider struct{}
+
+func (vaultProvider) ReadSecret(ctx context.Context, source string) ([]byte, error) {
+	u, err := url.Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	addr := strings.TrimSuffix(osGetenv("VAULT_ADDR"), "/")
+	if addr == "" {
+		return nil, fmt.Errorf("VAULT_ADDR is not set")
+	}
+	token := osGetenv("VAULT_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("VAULT_TOKEN is not set")
+	}
+	mount := u.Host
+	path := strings.Trim(u.Path, "/")
+	if mount == "" || path == "" {
+		return nil, fmt.Errorf("invalid vault secret %q, expected vault://mount/path", source)
+	}
+	field := u.Query().Get("field")
+	if field == "" {
+		field = "value"
+	}
+	v2 := u.Query().Get("version") != "1"
+	apiPath := fmt.Sprintf("%v/v1/%v/%v", addr, mount, path)
+	if v2 {
+		apiPath = fmt.Sprintf("%v/v1/%v/data/%v", addr, mount, path)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if ns := osGetenv("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+	rsp, err := vaultHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+	body, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("reading vault secret %v/%v: unexpected status %v", mount, path, rsp.Status)
+	}
+
+	var data map[string]interface{}
+	if v2 {
+		var r struct {
+			Data struct {
+				Data map[string]interface{} `json:"data"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(body, &r); err != nil {
+			return nil, err
+		}
+		data = r.Data.Data
+	} else {
+		var r struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(body, &r); err != nil {
+			return nil, err
+		}
+		data = r.Data
+	}
+	v, ok := data[field].(string)
+	if !ok {
+		return nil, fmt.Errorf("vault secret %v/%v has no string field %q", mount, path, field)
+	}
+	return []byte(v), nil
+}
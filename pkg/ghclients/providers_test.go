@@ -0,0 +1,123 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ghclients
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type mockProvider string
+
+func (m mockProvider) ReadSecret(ctx context.Context, source string) ([]byte, error) {
+	return []byte(string(m) + " " + source), nil
+}
+
+func TestSecretProviderFor(t *testing.T) {
+	RegisterSecretProvider("mock", mockProvider("mocked"))
+	defer func() {
+		secretProvidersMu.Lock()
+		delete(secretProviders, "mock")
+		secretProvidersMu.Unlock()
+	}()
+	tests := map[string]SecretProvider{
+		"env:PRIVATE_KEY":                         envProvider{},
+		"vault://secret/allstar?field=key":        vaultProvider{},
+		"gcpsecretmanager://projects/p/secrets/s": runtimevarProvider{},
+		"file:///etc/allstar/key.pem":             runtimevarProvider{},
+		"mock://key":                              mockProvider("mocked"),
+		"direct":                                  runtimevarProvider{},
+	}
+	for source, exp := range tests {
+		if got := secretProviderFor(source); got != exp {
+			t.Errorf("Unexpected provider for %v: %T", source, got)
+		}
+	}
+	v, err := readSecret(context.Background(), "mock://key")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(v) != "mocked mock://key" {
+		t.Errorf("Unexpected secret: %q", v)
+	}
+}
+
+func TestVaultProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "t0k3n" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.Header.Get("X-Vault-Namespace") != "allstar" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/allstar/app":
+			fmt.Fprint(w, `{"data": {"data": {"value": "v2-key", "private_key": "v2-pk"}, "metadata": {"version": 3}}}`)
+		case "/v1/kv/allstar/app":
+			fmt.Fprint(w, `{"data": {"private_key": "v1-pk"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	env := map[string]string{
+		"VAULT_ADDR":      srv.URL + "/",
+		"VAULT_TOKEN":     "t0k3n",
+		"VAULT_NAMESPACE": "allstar",
+	}
+	osGetenv = func(in string) string {
+		return env[in]
+	}
+
+	tests := []struct {
+		Source string
+		Exp    string
+		ExpErr bool
+	}{
+		{Source: "vault://secret/allstar/app", Exp: "v2-key"},
+		{Source: "vault://secret/allstar/app?field=private_key", Exp: "v2-pk"},
+		{Source: "vault://kv/allstar/app?field=private_key&version=1", Exp: "v1-pk"},
+		{Source: "vault://secret/allstar/app?field=missing", ExpErr: true},
+		{Source: "vault://secret/allstar/other", ExpErr: true},
+		{Source: "vault://secret", ExpErr: true},
+	}
+	for _, test := range tests {
+		v, err := vaultProvider{}.ReadSecret(context.Background(), test.Source)
+		if test.ExpErr {
+			if err == nil {
+				t.Errorf("Expected error for %v, got: %q", test.Source, v)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error for %v: %v", test.Source, err)
+			continue
+		}
+		if string(v) != test.Exp {
+			t.Errorf("Unexpected secret for %v: %q", test.Source, v)
+		}
+	}
+
+	env["VAULT_TOKEN"] = ""
+	if _, err := (vaultProvider{}).ReadSecret(context.Background(), "vault://secret/allstar/app"); err == nil {
+		t.Errorf("Expected error without VAULT_TOKEN")
+	}
+}
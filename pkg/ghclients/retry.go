@@ -0,0 +1,177 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ghclients
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maxRetries is the number of times a request hitting a secondary rate limit
+// is retried before the response is returned to the caller.
+const maxRetries = 3
+
+// defaultRetryWait is used when GitHub does not send Retry-After, as
+// recommended in
+// https://docs.github.com/en/rest/using-the-rest-api/rate-limits-for-the-rest-api#exceeding-the-rate-limit
+const defaultRetryWait = time.Minute
+
+// maxRetryWait is the longest Retry-After that is waited on, longer waits are
+// returned to the caller.
+const maxRetryWait = 5 * time.Minute
+
+var sleep func(context.Context, time.Duration) error
+var randInt63n func(int64) int64
+
+var retryStats struct {
+	secondaryLimits atomic.Int64
+	retries         atomic.Int64
+	gaveUp          atomic.Int64
+}
+
+func init() {
+	sleep = sleepReal
+	randInt63n = rand.Int63n
+}
+
+// RetryStats are counters of secondary rate limit handling since process
+// start.
+type RetryStats struct {
+	// SecondaryLimits is the number of secondary rate limit responses seen.
+	SecondaryLimits int64
+	// Retries is the number of retried requests.
+	Retries int64
+	// GaveUp is the number of secondary rate limit responses returned to the
+	// caller after exhausting retries, or due to a long Retry-After.
+	GaveUp int64
+}
+
+// GetRetryStats returns the current secondary rate limit counters.
+func GetRetryStats() RetryStats {
+	return RetryStats{
+		SecondaryLimits: retryStats.secondaryLimits.Load(),
+		Retries:         retryStats.retries.Load(),
+		GaveUp:          retryStats.gaveUp.Load(),
+	}
+}
+
+// retryTransport is an http.RoundTripper that retries requests hitting a
+// GitHub secondary rate limit, waiting for Retry-After plus jitter.
+type retryTransport struct {
+	tr http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		rsp, err := r.tr.RoundTrip(req)
+		if err != nil {
+			return rsp, err
+		}
+		wait, limited := secondaryLimitWait(rsp, attempt)
+		if !limited {
+			return rsp, nil
+		}
+		retryStats.secondaryLimits.Add(1)
+		if attempt >= maxRetries || wait > maxRetryWait || (req.Body != nil && req.GetBody == nil) {
+			retryStats.gaveUp.Add(1)
+			log.Warn().
+				Str("area", "bot").
+				Str("method", req.Method).
+				Str("url", req.URL.String()).
+				Int("attempt", attempt).
+				Dur("retryAfter", wait).
+				Msg("Secondary rate limit hit, not retrying.")
+			return rsp, nil
+		}
+		rsp.Body.Close()
+		wait += Jitter(wait)
+		log.Warn().
+			Str("area", "bot").
+			Str("method", req.Method).
+			Str("url", req.URL.String()).
+			Int("attempt", attempt).
+			Dur("wait", wait).
+			Msg("Secondary rate limit hit, retrying.")
+		if err := sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+		retryStats.retries.Add(1)
+	}
+}
+
+// secondaryLimitWait determines if the response is a secondary rate limit, and
+// if so how long to wait before retrying. The response body is preserved.
+func secondaryLimitWait(rsp *http.Response, attempt int) (time.Duration, bool) {
+	if rsp.StatusCode != http.StatusForbidden && rsp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	// A primary rate limit resets on the hour, leave it to the caller.
+	if rsp.Header.Get("X-RateLimit-Remaining") == "0" {
+		return 0, false
+	}
+	if ra := rsp.Header.Get("Retry-After"); ra != "" {
+		if s, err := strconv.Atoi(ra); err == nil {
+			return time.Duration(s) * time.Second, true
+		}
+	}
+	if rsp.Body == nil {
+		return 0, false
+	}
+	b, err := io.ReadAll(rsp.Body)
+	rsp.Body.Close()
+	rsp.Body = io.NopCloser(bytes.NewReader(b))
+	if err != nil || !strings.Contains(strings.ToLower(string(b)), "secondary rate limit") {
+		return 0, false
+	}
+	return defaultRetryWait << attempt, true
+}
+
+// Jitter returns a random duration up to a quarter of d, to add to retry
+// waits so that concurrent clients do not retry at once.
+func Jitter(d time.Duration) time.Duration {
+	if d < 4 {
+		return 0
+	}
+	return time.Duration(randInt63n(int64(d / 4)))
+}
+
+func sleepReal(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
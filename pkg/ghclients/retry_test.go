@@ -0,0 +1,142 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ghclients
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+)
+
+type seqTransport struct {
+	rsps   []func(*http.Request) *http.Response
+	bodies []string
+}
+
+func (s *seqTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		b, _ := io.ReadAll(req.Body)
+		s.bodies = append(s.bodies, string(b))
+	}
+	f := s.rsps[0]
+	if len(s.rsps) > 1 {
+		s.rsps = s.rsps[1:]
+	}
+	return f(req), nil
+}
+
+func secondary(retryAfter string) func(*http.Request) *http.Response {
+	return func(req *http.Request) *http.Response {
+		h := http.Header{}
+		if retryAfter != "" {
+			h.Set("Retry-After", retryAfter)
+		}
+		return chaosResponse(req, http.StatusForbidden, h, "You have exceeded a secondary rate limit.", secondaryDocsURL)
+	}
+}
+
+func status(code int) func(*http.Request) *http.Response {
+	return func(req *http.Request) *http.Response {
+		return chaosResponse(req, code, http.Header{}, http.StatusText(code), docsURL)
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Rsps       []func(*http.Request) *http.Response
+		ExpStatus  int
+		ExpWaits   []time.Duration
+		ExpRetries int64
+		ExpGaveUp  int64
+	}{
+		{
+			Name:      "NoLimit",
+			Rsps:      []func(*http.Request) *http.Response{status(http.StatusOK)},
+			ExpStatus: http.StatusOK,
+		},
+		{
+			Name:      "PlainForbidden",
+			Rsps:      []func(*http.Request) *http.Response{status(http.StatusForbidden)},
+			ExpStatus: http.StatusForbidden,
+		},
+		{
+			Name:       "RetryAfter",
+			Rsps:       []func(*http.Request) *http.Response{secondary("2"), status(http.StatusOK)},
+			ExpStatus:  http.StatusOK,
+			ExpWaits:   []time.Duration{2 * time.Second},
+			ExpRetries: 1,
+		},
+		{
+			Name:       "NoRetryAfterBacksOff",
+			Rsps:       []func(*http.Request) *http.Response{secondary(""), secondary(""), status(http.StatusOK)},
+			ExpStatus:  http.StatusOK,
+			ExpWaits:   []time.Duration{time.Minute, 2 * time.Minute},
+			ExpRetries: 2,
+		},
+		{
+			Name:       "GivesUp",
+			Rsps:       []func(*http.Request) *http.Response{secondary("1")},
+			ExpStatus:  http.StatusForbidden,
+			ExpWaits:   []time.Duration{time.Second, time.Second, time.Second},
+			ExpRetries: 3,
+			ExpGaveUp:  1,
+		},
+		{
+			Name:      "LongRetryAfter",
+			Rsps:      []func(*http.Request) *http.Response{secondary("3600")},
+			ExpStatus: http.StatusForbidden,
+			ExpGaveUp: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var waits []time.Duration
+			sleep = func(ctx context.Context, d time.Duration) error {
+				waits = append(waits, d)
+				return nil
+			}
+			randInt63n = func(int64) int64 { return 0 }
+			before := GetRetryStats()
+			st := &seqTransport{rsps: test.Rsps}
+			c := github.NewClient(&http.Client{Transport: &retryTransport{tr: st}})
+			_, rsp, _ := c.Issues.Create(context.Background(), "org", "repo", &github.IssueRequest{Title: github.String("title")})
+			if rsp.StatusCode != test.ExpStatus {
+				t.Errorf("Unexpected status: %v", rsp.StatusCode)
+			}
+			if diff := cmp.Diff(test.ExpWaits, waits); diff != "" {
+				t.Errorf("Unexpected waits. (-want +got):\n%s", diff)
+			}
+			for _, b := range st.bodies {
+				if !strings.Contains(b, "title") {
+					t.Errorf("Request body not resent on retry: %q", b)
+				}
+			}
+			after := GetRetryStats()
+			if after.Retries-before.Retries != test.ExpRetries {
+				t.Errorf("Unexpected retries: %v", after.Retries-before.Retries)
+			}
+			if after.GaveUp-before.GaveUp != test.ExpGaveUp {
+				t.Errorf("Unexpected gave up: %v", after.GaveUp-before.GaveUp)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ghclients
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+var timeNow func() time.Time
+var osGetenv func(string) string
+
+func init() {
+	timeNow = time.Now
+	osGetenv = os.Getenv
+}
+
+// Secret is a secret value, such as the App private key or a webhook secret,
+// that is read again from its source once it is older than its TTL, or when
+// Reload is called, so that a rotated secret is picked up without a restart.
+type Secret struct {
+	source string
+	ttl    time.Duration
+
+	mu       sync.Mutex
+	value    []byte
+	loadedAt time.Time
+}
+
+// NewSecret reads the secret from source and returns it. The source is read by
+// the SecretProvider registered for its URL scheme, eg: "env:NAME",
+// "vault://secret/allstar?field=private_key", or otherwise opened as a
+// gocloud.dev/runtimevar URL, eg: "gcpsecretmanager://...",
+// "awssecretsmanager://..." or "file:///path?decoder=bytes". A ttl of zero
+// disables reading the secret again, other than on Reload.
+func NewSecret(ctx context.Context, source string, ttl time.Duration) (*Secret, error) {
+	s := &Secret{
+		source: source,
+		ttl:    ttl,
+	}
+	if err := s.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewStaticSecret returns a Secret with a fixed value, that is never read
+// again.
+func NewStaticSecret(value []byte) *Secret {
+	return &Secret{
+		value:    value,
+		loadedAt: timeNow(),
+	}
+}
+
+// Value returns the secret, reading it again from its source if older than
+// the TTL. If reading fails, the previous value is returned and the error is
+// logged, so that a temporary failure of the source does not break callers.
+func (s *Secret) Value(ctx context.Context) []byte {
+	s.mu.Lock()
+	expired := s.source != "" && s.ttl > 0 && timeNow().Sub(s.loadedAt) >= s.ttl
+	s.mu.Unlock()
+	if expired {
+		if err := s.Reload(ctx); err != nil {
+			log.Warn().
+				Str("area", "bot").
+				Str("source", s.source).
+				Err(err).
+				Msg("Could not read secret, using previous value.")
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.value
+}
+
+// Reload reads the secret again from its source. On error, the previous value
+// is kept.
+func (s *Secret) Reload(ctx context.Context) error {
+	if s.source == "" {
+		return nil
+	}
+	v, err := readSecret(ctx, s.source)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.value != nil && !bytes.Equal(s.value, v) {
+		log.Info().
+			Str("area", "bot").
+			Str("source", s.source).
+			Msg("Secret rotated.")
+	}
+	s.value = v
+	s.loadedAt = timeNow()
+	return nil
+}
+
+// ReloadOnSIGHUP reloads the secrets each time the process receives SIGHUP,
+// until ctx is done.
+func ReloadOnSIGHUP(ctx context.Context, secrets ...*Secret) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(sigs)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sigs:
+				reloadAll(ctx, secrets)
+			}
+		}
+	}()
+}
+
+func reloadAll(ctx context.Context, secrets []*Secret) {
+	for _, s := range secrets {
+		if err := s.Reload(ctx); err != nil {
+			log.Error().
+				Str("area", "bot").
+				Str("source", s.source).
+				Err(err).
+				Msg("Could not reload secret.")
+		}
+	}
+}
+
+func readSecret(ctx context.Context, source string) ([]byte, error) {
+	return secretProviderFor(source).ReadSecret(ctx, source)
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ghclients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bradleyfalzon/ghinstallation/v2"
+)
+
+func TestSecretTTL(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	val := "one"
+	var readErr error
+	reads := 0
+	getKeyFromSecret = func(ctx context.Context, source string) ([]byte, error) {
+		reads++
+		if readErr != nil {
+			return nil, readErr
+		}
+		return []byte(val), nil
+	}
+	ctx := context.Background()
+	s, err := NewSecret(ctx, "gcpsecretmanager://projects/p/secrets/s", time.Hour)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	val = "two"
+	if got := string(s.Value(ctx)); got != "one" || reads != 1 {
+		t.Errorf("Expected cached secret, got: %v, reads: %v", got, reads)
+	}
+
+	now = now.Add(time.Hour)
+	if got := string(s.Value(ctx)); got != "two" || reads != 2 {
+		t.Errorf("Expected expired secret, got: %v, reads: %v", got, reads)
+	}
+
+	now = now.Add(time.Hour)
+	readErr = errors.New("unavailable")
+	if got := string(s.Value(ctx)); got != "two" {
+		t.Errorf("Expected previous secret on error, got: %v", got)
+	}
+
+	readErr = nil
+	val = "three"
+	reloadAll(ctx, []*Secret{s})
+	if got := string(s.Value(ctx)); got != "three" {
+		t.Errorf("Expected reloaded secret, got: %v", got)
+	}
+}
+
+func TestSecretEnv(t *testing.T) {
+	osGetenv = func(in string) string {
+		if in == "WEBHOOK_SECRET" {
+			return "s3cr3t"
+		}
+		return ""
+	}
+	ctx := context.Background()
+	s, err := NewSecret(ctx, "env:WEBHOOK_SECRET", 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := string(s.Value(ctx)); got != "s3cr3t" {
+		t.Errorf("Unexpected secret: %v", got)
+	}
+	if _, err := NewSecret(ctx, "env:MISSING", 0); err == nil {
+		t.Errorf("Expected error for unset environment variable")
+	}
+}
+
+func TestGetRotatedKey(t *testing.T) {
+	var keys []string
+	ghinstallationNew = func(r http.RoundTripper, a int64, i int64,
+		f []byte) (*ghinstallation.Transport, error) {
+		keys = append(keys, string(f))
+		return &ghinstallation.Transport{BaseURL: fmt.Sprint(i)}, nil
+	}
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	val := "one"
+	getKeyFromSecret = func(ctx context.Context, source string) ([]byte, error) {
+		return []byte(val), nil
+	}
+	keySecret = "gcpsecretmanager://projects/p/secrets/s"
+	keySecretTTL = time.Hour
+	ghc, err := NewGHClients(context.Background(), http.DefaultTransport)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	c1, err := ghc.Get(123)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	val = "two"
+	now = now.Add(time.Hour)
+	c2, err := ghc.Get(123)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if c1 == c2 {
+		t.Errorf("Expected new client after key rotation")
+	}
+	if len(keys) != 2 || keys[0] != "one" || keys[1] != "two" {
+		t.Errorf("Unexpected keys used: %v", keys)
+	}
+}
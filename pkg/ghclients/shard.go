@@ -0,0 +1,72 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ghclients
+
+import (
+	"fmt"
+)
+
+// Shard is the share of installations that an Allstar replica enforces
+// policies on, when installations are sharded across replicas. The zero Shard
+// owns all installations.
+type Shard struct {
+	// Index is the shard of the replica, from 0 to Count-1.
+	Index int
+
+	// Count is the number of shards.
+	Count int
+}
+
+// NewShard returns shard index of count shards.
+func NewShard(index, count int) (Shard, error) {
+	if count < 1 {
+		return Shard{}, fmt.Errorf("shard count must be at least 1, got %d", count)
+	}
+	if index < 0 || index >= count {
+		return Shard{}, fmt.Errorf("shard index must be from 0 to %d, got %d", count-1, index)
+	}
+	return Shard{Index: index, Count: count}, nil
+}
+
+// Owns returns whether the installation with ID instID is in the shard.
+// Installations are assigned to shards with jump consistent hashing of their
+// ID, so that changing the number of shards moves as few installations as
+// possible between them.
+func (s Shard) Owns(instID int64) bool {
+	if s.Count <= 1 {
+		return true
+	}
+	return jumpHash(uint64(instID), s.Count) == s.Index
+}
+
+// String returns the shard as "index/count".
+func (s Shard) String() string {
+	if s.Count <= 1 {
+		return "0/1"
+	}
+	return fmt.Sprintf("%d/%d", s.Index, s.Count)
+}
+
+// jumpHash is the jump consistent hash of key into buckets, from "A Fast,
+// Minimal Memory, Consistent Hash Algorithm" by Lamping and Veach.
+func jumpHash(key uint64, buckets int) int {
+	var b, j int64 = -1, 0
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
@@ -0,0 +1,79 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ghclients
+
+import (
+	"testing"
+)
+
+func TestNewShard(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Index  int
+		Count  int
+		ExpErr bool
+	}{
+		{Name: "Single", Index: 0, Count: 1},
+		{Name: "Last", Index: 2, Count: 3},
+		{Name: "ZeroCount", Index: 0, Count: 0, ExpErr: true},
+		{Name: "IndexTooLarge", Index: 3, Count: 3, ExpErr: true},
+		{Name: "NegativeIndex", Index: -1, Count: 3, ExpErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			_, err := NewShard(test.Index, test.Count)
+			if (err != nil) != test.ExpErr {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestShardOwns(t *testing.T) {
+	if !(Shard{}).Owns(12345) {
+		t.Error("Expected zero shard to own all installations")
+	}
+
+	// Each installation is owned by exactly one shard, and shards are roughly
+	// balanced.
+	const count = 4
+	const insts = 4000
+	owned := make([]int, count)
+	for id := int64(1); id <= insts; id++ {
+		n := 0
+		for i := 0; i < count; i++ {
+			if (Shard{Index: i, Count: count}).Owns(id) {
+				owned[i]++
+				n++
+			}
+		}
+		if n != 1 {
+			t.Fatalf("Installation %d owned by %d shards", id, n)
+		}
+	}
+	for i, n := range owned {
+		if n < insts/count*8/10 || n > insts/count*12/10 {
+			t.Errorf("Unbalanced shard %d: %d installations", i, n)
+		}
+	}
+
+	// Adding a shard only moves installations to the new shard.
+	for id := int64(1); id <= insts; id++ {
+		before := jumpHash(uint64(id), count)
+		if after := jumpHash(uint64(id), count+1); after != before && after != count {
+			t.Fatalf("Installation %d moved from shard %d to %d", id, before, after)
+		}
+	}
+}
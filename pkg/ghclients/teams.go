@@ -0,0 +1,45 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ghclients
+
+import (
+	"context"
+
+	"github.com/google/go-github/v59/github"
+)
+
+// ListTeamMembers returns the logins of the members of the team with the slug
+// in org. A team that is not found returns the *github.ErrorResponse.
+// Docs: https://docs.github.com/en/rest/teams/members#list-team-members
+func ListTeamMembers(ctx context.Context, c *github.Client, org, slug string) ([]string, error) {
+	opt := &github.TeamListTeamMembersOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	var logins []string
+	for {
+		us, resp, err := c.Teams.ListTeamMembersBySlug(ctx, org, slug, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range us {
+			logins = append(logins, u.GetLogin())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return logins, nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package installations lists and removes the installations of the Allstar
+// GitHub App, for operators to manage installations on organizations that are
+// not allowed by operator.AllowedOrganizations.
+package installations
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ossf/allstar/pkg/config/operator"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+// Installation describes an installation of the App.
+type Installation struct {
+	// ID is the installation ID.
+	ID int64 `json:"id"`
+
+	// Account is the login of the organization or user the App is installed
+	// on.
+	Account string `json:"account"`
+
+	// AccountType is "Organization" or "User".
+	AccountType string `json:"accountType"`
+
+	// RepositorySelection is "all" or "selected".
+	RepositorySelection string `json:"repositorySelection"`
+
+	// Allowed is whether the account is allowed by
+	// operator.AllowedOrganizations. Disallowed installations are not
+	// enforced on.
+	Allowed bool `json:"allowed"`
+
+	// CreatedAt is when the App was installed.
+	CreatedAt time.Time `json:"createdAt"`
+
+	// SuspendedAt is when the installation was suspended, if it is.
+	SuspendedAt *time.Time `json:"suspendedAt,omitempty"`
+
+	// SuspendedBy is the login of the user that suspended the installation.
+	SuspendedBy string `json:"suspendedBy,omitempty"`
+}
+
+var listInstallations func(context.Context, *github.Client) ([]*github.Installation, error)
+var deleteInstallation func(context.Context, *github.Client, int64) (*github.Response, error)
+
+func init() {
+	listInstallations = listInstallationsReal
+	deleteInstallation = deleteInstallationReal
+}
+
+// Allowed returns whether Allstar may be installed on the account, according
+// to operator.AllowedOrganizations. An empty list allows all accounts.
+func Allowed(account string) bool {
+	if len(operator.AllowedOrganizations) == 0 ||
+		(len(operator.AllowedOrganizations) == 1 && operator.AllowedOrganizations[0] == "") {
+		return true
+	}
+	for _, ao := range operator.AllowedOrganizations {
+		if ao == account {
+			return true
+		}
+	}
+	return false
+}
+
+// List returns the installations of the App, sorted by account. The client
+// must be authenticated as the App.
+func List(ctx context.Context, ac *github.Client) ([]Installation, error) {
+	is, err := listInstallations(ctx, ac)
+	if err != nil {
+		return nil, err
+	}
+	insts := make([]Installation, 0, len(is))
+	for _, i := range is {
+		inst := Installation{
+			ID:                  i.GetID(),
+			Account:             i.GetAccount().GetLogin(),
+			AccountType:         i.GetTargetType(),
+			RepositorySelection: i.GetRepositorySelection(),
+			Allowed:             Allowed(i.GetAccount().GetLogin()),
+			CreatedAt:           i.GetCreatedAt().Time,
+		}
+		if i.SuspendedAt != nil {
+			t := i.GetSuspendedAt().Time
+			inst.SuspendedAt = &t
+			inst.SuspendedBy = i.GetSuspendedBy().GetLogin()
+		}
+		insts = append(insts, inst)
+	}
+	sort.SliceStable(insts, func(a, b int) bool {
+		return strings.ToLower(insts[a].Account) < strings.ToLower(insts[b].Account)
+	})
+	return insts, nil
+}
+
+// Remove uninstalls the App from the installation. The client must be
+// authenticated as the App. The removal is logged, for auditing.
+func Remove(ctx context.Context, ac *github.Client, inst Installation) error {
+	resp, err := deleteInstallation(ctx, ac, inst.ID)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status removing installation %v: %v", inst.ID, resp.Status)
+	}
+	log.Info().
+		Str("area", "bot").
+		Int64("instId", inst.ID).
+		Str("instTarget", inst.Account).
+		Bool("allowed", inst.Allowed).
+		Msg("Removed installation.")
+	return nil
+}
+
+func listInstallationsReal(ctx context.Context, ac *github.Client) ([]*github.Installation, error) {
+	var insts []*github.Installation
+	opts := &github.ListOptions{
+		PerPage: 100,
+	}
+	for {
+		is, resp, err := ac.Apps.ListInstallations(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		insts = append(insts, is...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return insts, nil
+}
+
+func deleteInstallationReal(ctx context.Context, ac *github.Client, instID int64) (*github.Response, error) {
+	return ac.Apps.DeleteInstallation(ctx, instID)
+}
@@ -0,0 +1,139 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package installations
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config/operator"
+)
+
+func TestAllowed(t *testing.T) {
+	saved := operator.AllowedOrganizations
+	defer func() { operator.AllowedOrganizations = saved }()
+
+	tests := []struct {
+		Name      string
+		Allowlist []string
+		Account   string
+		Exp       bool
+	}{
+		{"Empty", nil, "org-1", true},
+		{"EmptyEnv", []string{""}, "org-1", true},
+		{"Listed", []string{"org-1", "org-2"}, "org-2", true},
+		{"NotListed", []string{"org-1", "org-2"}, "org-3", false},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			operator.AllowedOrganizations = test.Allowlist
+			if got := Allowed(test.Account); got != test.Exp {
+				t.Errorf("Unexpected result: %v", got)
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	saved := operator.AllowedOrganizations
+	defer func() { operator.AllowedOrganizations = saved }()
+	operator.AllowedOrganizations = []string{"org-1", "Org-2"}
+
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	suspended := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	listInstallations = func(ctx context.Context, ac *github.Client) ([]*github.Installation, error) {
+		return []*github.Installation{
+			{
+				ID:                  github.Int64(3),
+				Account:             &github.User{Login: github.String("org-3")},
+				TargetType:          github.String("Organization"),
+				RepositorySelection: github.String("all"),
+				CreatedAt:           &github.Timestamp{Time: created},
+			},
+			{
+				ID:                  github.Int64(2),
+				Account:             &github.User{Login: github.String("Org-2")},
+				TargetType:          github.String("Organization"),
+				RepositorySelection: github.String("selected"),
+				CreatedAt:           &github.Timestamp{Time: created},
+				SuspendedAt:         &github.Timestamp{Time: suspended},
+				SuspendedBy:         &github.User{Login: github.String("admin")},
+			},
+			{
+				ID:         github.Int64(1),
+				Account:    &github.User{Login: github.String("org-1")},
+				TargetType: github.String("Organization"),
+				CreatedAt:  &github.Timestamp{Time: created},
+			},
+		}, nil
+	}
+	exp := []Installation{
+		{
+			ID:          1,
+			Account:     "org-1",
+			AccountType: "Organization",
+			Allowed:     true,
+			CreatedAt:   created,
+		},
+		{
+			ID:                  2,
+			Account:             "Org-2",
+			AccountType:         "Organization",
+			RepositorySelection: "selected",
+			Allowed:             true,
+			CreatedAt:           created,
+			SuspendedAt:         &suspended,
+			SuspendedBy:         "admin",
+		},
+		{
+			ID:                  3,
+			Account:             "org-3",
+			AccountType:         "Organization",
+			RepositorySelection: "all",
+			Allowed:             false,
+			CreatedAt:           created,
+		},
+	}
+	got, err := List(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(exp, got); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	var removed []int64
+	status := http.StatusNoContent
+	deleteInstallation = func(ctx context.Context, ac *github.Client, id int64) (*github.Response, error) {
+		removed = append(removed, id)
+		return &github.Response{Response: &http.Response{StatusCode: status, Status: http.StatusText(status)}}, nil
+	}
+	if err := Remove(context.Background(), nil, Installation{ID: 3, Account: "org-3"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	status = http.StatusInternalServerError
+	if err := Remove(context.Background(), nil, Installation{ID: 4, Account: "org-4"}); err == nil {
+		t.Error("Expected error")
+	}
+	if diff := cmp.Diff([]int64{3, 4}, removed); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+}
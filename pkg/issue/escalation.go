@@ -0,0 +1,94 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issue
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/notify"
+	"github.com/rs/zerolog/log"
+
+	"github.com/google/go-github/v59/github"
+)
+
+// escalationNoteFormat is added to the ping comment that escalates an issue.
+const escalationNoteFormat = "\n\nThis issue is still not resolved after %d pings, and has been escalated."
+
+const escalationTextFormat = "Issue %s is still not resolved after %d pings, and has been escalated."
+
+var notifyEscalate func(context.Context, *github.Client, string, string, string, string) error
+
+func init() {
+	notifyEscalate = notify.Escalate
+}
+
+// shouldEscalate returns whether an issue with state st is escalated by esc.
+func shouldEscalate(esc *config.EscalationConfig, st issueState) bool {
+	return esc != nil && esc.AfterPings > 0 && st.Escalated == nil && st.Pings >= esc.AfterPings
+}
+
+// updatePings records the state st of the issue after a ping in its body, and
+// escalates the issue if escalate is set. Failing to send the escalation
+// notification is logged, as the issue is still escalated.
+func updatePings(ctx context.Context, c *github.Client, issues issues, owner, repo, issueRepo, policy string,
+	issue *github.Issue, st issueState, esc *config.EscalationConfig, escalate bool) error {
+	update := &github.IssueRequest{}
+	if escalate {
+		now := timeNow()
+		st.Escalated = &now
+		if len(esc.Labels) > 0 {
+			var labels []string
+			for _, l := range issue.Labels {
+				labels = append(labels, l.GetName())
+			}
+			labels = appendUnique(labels, esc.Labels...)
+			update.Labels = &labels
+		}
+		if len(esc.Assignees) > 0 {
+			var assignees []string
+			for _, a := range issue.Assignees {
+				assignees = append(assignees, a.GetLogin())
+			}
+			assignees = appendUnique(assignees, expandAssignees(ctx, c, owner, repo, policy, esc.Assignees)...)
+			update.Assignees = &assignees
+		}
+		log.Info().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", policy).
+			Int("issue", issue.GetNumber()).
+			Int("pings", st.Pings).
+			Msg("Issue not resolved after pings, escalating.")
+		if esc.Notify {
+			text := fmt.Sprintf(escalationTextFormat, issue.GetHTMLURL(), st.Pings)
+			if err := notifyEscalate(ctx, c, owner, repo, policy, text); err != nil {
+				log.Error().
+					Str("org", owner).
+					Str("repo", repo).
+					Str("area", policy).
+					Err(err).
+					Msg("Unexpected error sending escalation notification.")
+			}
+		}
+	}
+	body := setIssueState(issue.GetBody(), st)
+	update.Body = &body
+	if _, _, err := issues.Edit(ctx, owner, issueRepo, issue.GetNumber(), update); err != nil {
+		return fmt.Errorf("while updating issue %d: recording pings: %w", issue.GetNumber(), err)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issue
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/operator"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+)
+
+func TestEnsureEscalation(t *testing.T) {
+	setShouldPerform(true)
+	issueTitle := "Security Policy violation thispolicy"
+	open := "open"
+	url := "https://github.com/org/repo/issues/1"
+	stale := github.Timestamp{Time: time.Now().Add(-10 * operator.NoticePingDuration)}
+	configGetAppConfigs = func(context.Context, *github.Client, string, string) (*config.OrgConfig, *config.RepoConfig, *config.RepoConfig) {
+		return &config.OrgConfig{
+			Issues: &config.IssueConfig{
+				Escalation: &config.EscalationConfig{
+					AfterPings: 2,
+					Labels:     []string{"escalated"},
+					Assignees:  []string{"security-lead"},
+					Notify:     true,
+				},
+			},
+		}, &config.RepoConfig{}, &config.RepoConfig{}
+	}
+	defer func() {
+		configGetAppConfigs = func(context.Context, *github.Client, string, string) (*config.OrgConfig, *config.RepoConfig, *config.RepoConfig) {
+			return &config.OrgConfig{}, &config.RepoConfig{}, &config.RepoConfig{}
+		}
+	}()
+	var notified string
+	notifyEscalate = func(ctx context.Context, c *github.Client, owner, repo, policy, text string) error {
+		notified = text
+		return nil
+	}
+
+	tests := []struct {
+		Name         string
+		Body         string
+		ExpEscalate  bool
+		ExpState     issueState
+		ExpLabels    []string
+		ExpAssignees []string
+	}{
+		{
+			Name:     "FirstPing",
+			Body:     "Body",
+			ExpState: issueState{Pings: 1},
+		},
+		{
+			Name:         "Escalate",
+			Body:         setIssueState("Body", issueState{Pings: 1}),
+			ExpEscalate:  true,
+			ExpState:     issueState{Pings: 2, Escalated: &time.Time{}},
+			ExpLabels:    []string{"allstar", "escalated"},
+			ExpAssignees: []string{"owner", "security-lead"},
+		},
+		{
+			Name:     "AlreadyEscalated",
+			Body:     setIssueState("Body", issueState{Pings: 2, Escalated: &time.Time{}}),
+			ExpState: issueState{Pings: 3, Escalated: &time.Time{}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			notified = ""
+			listByRepo = func(ctx context.Context, owner string, repo string,
+				opts *github.IssueListByRepoOptions) ([]*github.Issue, *github.Response, error) {
+				return []*github.Issue{
+					{
+						Title:     &issueTitle,
+						State:     &open,
+						Body:      &test.Body,
+						HTMLURL:   &url,
+						UpdatedAt: &stale,
+						Labels:    []*github.Label{{Name: github.String("allstar")}},
+						Assignees: []*github.User{{Login: github.String("owner")}},
+					},
+				}, &github.Response{NextPage: 0}, nil
+			}
+			var comment string
+			createComment = func(ctx context.Context, owner string, repo string,
+				number int, c *github.IssueComment) (*github.IssueComment, *github.Response, error) {
+				comment = c.GetBody()
+				return nil, nil, nil
+			}
+			var update *github.IssueRequest
+			edit = func(ctx context.Context, owner string, repo string, number int,
+				issue *github.IssueRequest) (*github.Issue, *github.Response, error) {
+				update = issue
+				return nil, nil, nil
+			}
+			create = nil
+			_, err := ensure(context.Background(), nil, mockIssues{}, "", "", "thispolicy", "Status text")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := strings.Contains(comment, "has been escalated"); got != test.ExpEscalate {
+				t.Errorf("Unexpected escalation note in comment: %q", comment)
+			}
+			if update == nil {
+				t.Fatal("Expected pings to be recorded")
+			}
+			st := getIssueState(update.GetBody())
+			if st.Pings != test.ExpState.Pings || (st.Escalated == nil) != (test.ExpState.Escalated == nil) {
+				t.Errorf("Unexpected state: %+v", st)
+			}
+			var labels, assignees []string
+			if update.Labels != nil {
+				labels = *update.Labels
+			}
+			if update.Assignees != nil {
+				assignees = *update.Assignees
+			}
+			if diff := cmp.Diff(test.ExpLabels, labels); diff != "" {
+				t.Errorf("Unexpected labels. (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.ExpAssignees, assignees); diff != "" {
+				t.Errorf("Unexpected assignees. (-want +got):\n%s", diff)
+			}
+			if test.ExpEscalate && !strings.Contains(notified, url) {
+				t.Errorf("Unexpected notification: %q", notified)
+			}
+			if !test.ExpEscalate && notified != "" {
+				t.Errorf("Notification sent unexpectedly: %q", notified)
+			}
+		})
+	}
+}
+
+func TestEnsureReopenResetsState(t *testing.T) {
+	setShouldPerform(true)
+	issueTitle := "Security Policy violation thispolicy"
+	closed := "closed"
+	body := setIssueState("Body", issueState{Pings: 2, Escalated: &time.Time{}})
+	listByRepo = func(ctx context.Context, owner string, repo string,
+		opts *github.IssueListByRepoOptions) ([]*github.Issue, *github.Response, error) {
+		return []*github.Issue{
+			{
+				Title: &issueTitle,
+				State: &closed,
+				Body:  &body,
+			},
+		}, &github.Response{NextPage: 0}, nil
+	}
+	var update *github.IssueRequest
+	edit = func(ctx context.Context, owner string, repo string, number int,
+		issue *github.IssueRequest) (*github.Issue, *github.Response, error) {
+		update = issue
+		return nil, nil, nil
+	}
+	createComment = func(ctx context.Context, owner string, repo string,
+		number int, c *github.IssueComment) (*github.IssueComment, *github.Response, error) {
+		return nil, nil, nil
+	}
+	create = nil
+	if _, err := ensure(context.Background(), nil, mockIssues{}, "", "", "thispolicy", "Status text"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if update.GetState() != "open" {
+		t.Errorf("Expected issue to be reopened")
+	}
+	st := getIssueState(update.GetBody())
+	if st.Pings != 0 || st.Escalated != nil || st.FirstDetected == nil || !st.FirstDetected.Equal(timeNow()) {
+		t.Errorf("Expected state to be reset: %q", update.GetBody())
+	}
+}
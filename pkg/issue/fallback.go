@@ -0,0 +1,125 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issue
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ossf/allstar/pkg/checks"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+// FallbackIssuesDisabled is the Fallback reason when the repository has
+// issues disabled.
+const FallbackIssuesDisabled = "issues disabled"
+
+// Fallback describes a policy violation that could not be reported as an
+// issue in its repository, and where it was reported instead.
+type Fallback struct {
+	// Reason is why no issue was created in the repository, eg:
+	// FallbackIssuesDisabled.
+	Reason string
+
+	// Repo is the org IssueFallbackRepo the issue was created in, if any.
+	Repo string
+
+	// CheckRun is set if the violation was published as a check run on the
+	// repository instead.
+	CheckRun bool
+}
+
+// String returns a human readable description of the fallback, for logs and
+// reports.
+func (f *Fallback) String() string {
+	switch {
+	case f.Repo != "":
+		return fmt.Sprintf("%v, reported in %v", f.Reason, f.Repo)
+	case f.CheckRun:
+		return fmt.Sprintf("%v, reported as a check run", f.Reason)
+	}
+	return fmt.Sprintf("%v, not reported", f.Reason)
+}
+
+var hasIssues func(context.Context, *github.Client, string, string) (bool, error)
+var checksUpdate func(context.Context, *github.Client, string, string, string, bool, string) error
+var checksResolve func(context.Context, *github.Client, string, string, string) error
+
+func init() {
+	hasIssues = hasIssuesReal
+	checksUpdate = checks.Update
+	checksResolve = checks.Resolve
+}
+
+func hasIssuesReal(ctx context.Context, c *github.Client, owner, repo string) (bool, error) {
+	r, _, err := c.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return false, err
+	}
+	return r.GetHasIssues(), nil
+}
+
+// ensureFallback reports a policy violation on a repo with issues disabled,
+// as an issue in the org IssueFallbackRepo if set, otherwise as a failing
+// check run on the repo.
+func ensureFallback(ctx context.Context, c *github.Client, issues issues, owner, repo, policy, text string) (*Fallback, error) {
+	oc, _, _ := configGetAppConfigs(ctx, c, owner, repo)
+	f := &Fallback{Reason: FallbackIssuesDisabled}
+	if oc.IssueFallbackRepo != "" {
+		title := fmt.Sprintf(issueRepoTitle, repo, policy)
+		if err := ensureIn(ctx, c, issues, owner, repo, oc.IssueFallbackRepo, title, policy, text); err != nil {
+			return nil, err
+		}
+		f.Repo = oc.IssueFallbackRepo
+	} else if err := checksUpdate(ctx, c, owner, repo, policy, false, text); err != nil {
+		log.Warn().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", policy).
+			Str("fallback", f.String()).
+			Err(err).
+			Msg("Action set to issue, but issues are disabled, and the check run fallback failed. Set issueFallbackRepo to report the violation.")
+		return f, nil
+	} else {
+		f.CheckRun = true
+	}
+	log.Warn().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", policy).
+		Str("fallback", f.String()).
+		Msg("Action set to issue, but issues are disabled, reported in the fallback.")
+	return f, nil
+}
+
+// closeFallback resolves the violation reported by ensureFallback.
+func closeFallback(ctx context.Context, c *github.Client, issues issues, owner, repo, policy string) error {
+	oc, _, _ := configGetAppConfigs(ctx, c, owner, repo)
+	if oc.IssueFallbackRepo != "" {
+		title := fmt.Sprintf(issueRepoTitle, repo, policy)
+		return closeIn(ctx, c, issues, owner, repo, oc.IssueFallbackRepo, title, policy)
+	}
+	if err := checksResolve(ctx, c, owner, repo, policy); err != nil {
+		log.Warn().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", policy).
+			Err(err).
+			Msg("Issues are disabled, and the check run fallback could not be resolved.")
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issue
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ossf/allstar/pkg/config"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+)
+
+func TestEnsureIssuesDisabled(t *testing.T) {
+	hasIssues = func(context.Context, *github.Client, string, string) (bool, error) {
+		return false, nil
+	}
+	t.Cleanup(func() {
+		hasIssues = func(context.Context, *github.Client, string, string) (bool, error) {
+			return true, nil
+		}
+	})
+	setShouldPerform(true)
+	listByRepo = func(ctx context.Context, owner string, repo string,
+		opts *github.IssueListByRepoOptions) ([]*github.Issue, *github.Response, error) {
+		return make([]*github.Issue, 0), &github.Response{NextPage: 0}, nil
+	}
+	edit = nil
+	createComment = nil
+	tests := []struct {
+		Name         string
+		FallbackRepo string
+		CheckErr     error
+		ExpRepo      string
+		Exp          *Fallback
+	}{
+		{
+			Name:         "FallbackRepo",
+			FallbackRepo: "security",
+			ExpRepo:      "security",
+			Exp:          &Fallback{Reason: FallbackIssuesDisabled, Repo: "security"},
+		},
+		{
+			Name: "CheckRun",
+			Exp:  &Fallback{Reason: FallbackIssuesDisabled, CheckRun: true},
+		},
+		{
+			Name:     "CheckRunFails",
+			CheckErr: errors.New("no permission"),
+			Exp:      &Fallback{Reason: FallbackIssuesDisabled},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configGetAppConfigs = func(context.Context, *github.Client, string, string) (*config.OrgConfig, *config.RepoConfig, *config.RepoConfig) {
+				return &config.OrgConfig{IssueFallbackRepo: test.FallbackRepo}, &config.RepoConfig{}, &config.RepoConfig{}
+			}
+			var created string
+			create = func(ctx context.Context, owner string, repo string,
+				issue *github.IssueRequest) (*github.Issue, *github.Response, error) {
+				created = repo
+				return nil, nil, nil
+			}
+			var checked bool
+			checksUpdate = func(ctx context.Context, c *github.Client, owner, repo, policy string, pass bool, text string) error {
+				checked = true
+				return test.CheckErr
+			}
+			f, err := ensure(context.Background(), nil, mockIssues{}, "thisorg", "thisrepo", "thispolicy", "Status text")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.Exp, f); diff != "" {
+				t.Errorf("Unexpected fallback. (-want +got):\n%s", diff)
+			}
+			if created != test.ExpRepo {
+				t.Errorf("Unexpected issue repo: %q expect: %q", created, test.ExpRepo)
+			}
+			if checked != (test.FallbackRepo == "") {
+				t.Errorf("Unexpected check run update: %v", checked)
+			}
+		})
+	}
+}
@@ -27,6 +27,8 @@ import (
 	"github.com/ossf/allstar/pkg/config"
 	"github.com/ossf/allstar/pkg/config/operator"
 	"github.com/ossf/allstar/pkg/config/schedule"
+	"github.com/ossf/allstar/pkg/enforceid"
+	"github.com/ossf/allstar/pkg/ghclients"
 	"github.com/rs/zerolog/log"
 
 	"github.com/google/go-github/v59/github"
@@ -37,9 +39,34 @@ const sameRepoTitle = "Security Policy violation %v"
 
 const issueSectionHeaderFormat = "<!-- Edit section #%s -->"
 const resultTextHashCommentFormat = "<!-- Current result text hash: %s -->"
-const updateWarningFormat = "\n%s\n:warning: There is an updated version of this policy result! [Click here to see the latest update](%s)\n\n---\n\n"
+
+// issueKeyFormat is a hidden marker in the issue body, with a hash of the repo
+// and policy, used to find the issue exactly instead of by title.
+const issueKeyFormat = "<!-- Allstar issue key: %s -->"
 const updateSectionName = "updates"
 
+// editHistoryHeader marks the list of edits in the updates section of an
+// issue body. Each edit is a "- " line, the oldest are dropped after
+// maxEditHistory edits.
+const editHistoryHeader = "**Edit history**"
+const editHistoryFormat = "- %s: policy result updated%s"
+const maxEditHistory = 10
+
+// maxAssignees is the maximum number of assignees GitHub allows on an issue.
+const maxAssignees = 10
+
+// enforcementIDFormat is appended to issue bodies and comments, so that users
+// can refer to the enforcement that made them.
+const enforcementIDFormat = "\n\n<sub>Allstar enforcement ID: %s</sub>"
+
+// maxCreateRetries is the number of times creating an issue is retried after
+// a server or network error.
+const maxCreateRetries = 3
+
+// createRetryWait is the wait before the first retry of creating an issue,
+// doubled for each further retry.
+const createRetryWait = 2 * time.Second
+
 type issues interface {
 	ListByRepo(context.Context, string, string, *github.IssueListByRepoOptions) (
 		[]*github.Issue, *github.Response, error)
@@ -49,17 +76,31 @@ type issues interface {
 		*github.Issue, *github.Response, error)
 	CreateComment(context.Context, string, string, int, *github.IssueComment) (
 		*github.IssueComment, *github.Response, error)
+	ListMilestones(context.Context, string, string, *github.MilestoneListOptions) (
+		[]*github.Milestone, *github.Response, error)
 }
 
 var configGetAppConfigs func(context.Context, *github.Client, string, string) (*config.OrgConfig, *config.RepoConfig, *config.RepoConfig)
 var scheduleShouldPerform func(*config.ScheduleConfig) bool
+var timeNow func() time.Time
+var listTeamMembers func(context.Context, *github.Client, string, string) ([]string, error)
+var sleep func(context.Context, time.Duration) error
+var jitter func(time.Duration) time.Duration
 
 func init() {
 	configGetAppConfigs = config.GetAppConfigs
 	scheduleShouldPerform = schedule.ShouldPerform
+	timeNow = time.Now
+	listTeamMembers = ghclients.ListTeamMembers
+	sleep = sleepReal
+	jitter = ghclients.Jitter
 }
 
-func getPolicyIssue(ctx context.Context, issues issues, owner, repo, policy, title, label string) (*github.Issue, error) {
+// getPolicyIssue finds the issue with the marker of key, or the state block of
+// the same repo and policy, in its body, including closed issues. Otherwise,
+// the issue is found by title, such as for issues created before markers were
+// added. An empty key only matches by title.
+func getPolicyIssue(ctx context.Context, issues issues, owner, repo, key, title, label string) (*github.Issue, error) {
 	opt := &github.IssueListByRepoOptions{
 		State:  "all",
 		Labels: []string{label},
@@ -67,35 +108,78 @@ func getPolicyIssue(ctx context.Context, issues issues, owner, repo, policy, tit
 			PerPage: 100,
 		},
 	}
+	var marker string
+	if key != "" {
+		marker = fmt.Sprintf(issueKeyFormat, key)
+	}
+	var byTitle *github.Issue
 	for {
 		is, resp, err := issues.ListByRepo(ctx, owner, repo, opt)
 		if err != nil {
 			return nil, err
 		}
 		for _, i := range is {
-			if i.GetTitle() == title {
+			if marker != "" && (strings.Contains(i.GetBody(), marker) || getIssueState(i.GetBody()).key() == key) {
 				return i, nil
 			}
+			if byTitle == nil && i.GetTitle() == title {
+				byTitle = i
+			}
 		}
 		if resp.NextPage == 0 {
 			break
 		}
 		opt.Page = resp.NextPage
 	}
-	return nil, nil
+	return byTitle, nil
+}
+
+// issueKey returns the key of the issue for the repo and policy.
+func issueKey(owner, repo, policy string) string {
+	h := sha256.Sum256([]byte(strings.ToLower(owner+"/"+repo) + "\n" + policy))
+	return hex.EncodeToString(h[:16])
 }
 
 // Ensure ensures an issue exists and is open for the provided repo and
 // policy. If opening, re-opening, or pinging an issue, the provided text will
-// be included.
-func Ensure(ctx context.Context, c *github.Client, owner, repo, policy, text string) error {
+// be included. If the text changed since the issue was opened or last updated,
+// the issue body is updated in place and an entry added to its edit history.
+// Otherwise, no changes are made until the issue is closed or the ping interval
+// passes. No issue is created if the org only uses the summary issue.
+//
+// If the repo has issues disabled, and the org has no IssueRepo, the violation
+// is reported in a fallback instead, which is returned.
+func Ensure(ctx context.Context, c *github.Client, owner, repo, policy, text string) (*Fallback, error) {
 	return ensure(ctx, c, c.Issues, owner, repo, policy, text)
 }
 
-func ensure(ctx context.Context, c *github.Client, issues issues, owner, repo, policy, text string) error {
+func ensure(ctx context.Context, c *github.Client, issues issues, owner, repo, policy, text string) (*Fallback, error) {
+	if oc, _, _ := configGetAppConfigs(ctx, c, owner, repo); oc.SummaryIssue.Enabled && oc.SummaryIssue.DisableRepoIssues {
+		log.Info().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", policy).
+			Msg("Repo issues are disabled, reported in the summary issue only.")
+		return nil, nil
+	}
 	issueRepo, title := getIssueRepoTitle(ctx, c, owner, repo, policy)
+	if issueRepo == repo {
+		ok, err := hasIssues(ctx, c, owner, repo)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return ensureFallback(ctx, c, issues, owner, repo, policy, text)
+		}
+	}
+	return nil, ensureIn(ctx, c, issues, owner, repo, issueRepo, title, policy, text)
+}
+
+// ensureIn ensures the issue for the repo and policy in issueRepo.
+func ensureIn(ctx context.Context, c *github.Client, issues issues, owner, repo, issueRepo, title, policy, text string) error {
 	label := getIssueLabel(ctx, c, owner, repo)
-	issue, err := getPolicyIssue(ctx, issues, owner, issueRepo, policy, title, label)
+	key := issueKey(owner, repo, policy)
+	issue, err := getPolicyIssue(ctx, issues, owner, issueRepo, key, title, label)
 	if err != nil {
 		return err
 	}
@@ -108,23 +192,29 @@ func ensure(ctx context.Context, c *github.Client, issues issues, owner, repo, p
 		return err
 	}
 	hash := hex.EncodeToString(h.Sum(nil))
+	now := timeNow()
 	if issue == nil {
 		if !shouldPing {
 			return nil
 		}
-		var footer string
-		if oc.IssueFooter == "" {
-			footer = operator.GitHubIssueFooter
-		} else {
-			footer = fmt.Sprintf("%v\n\n%v", oc.IssueFooter, operator.GitHubIssueFooter)
-		}
-		body := createIssueBody(owner, repo, text, hash, footer, issueRepo == repo)
+		st := newIssueState(owner, repo, policy, hash, now)
+		body := fitBody(ctx, c, owner, repo, policy, text, func(t string) string {
+			return createIssueBody(owner, repo, key, t, hash, issueFooter(ctx, oc), issueRepo == repo, nil, st)
+		})
+		ic := mergeIssueConfig(oc, orc, rc, policy)
+		labels := appendUnique([]string{label}, ic.Labels...)
 		new := &github.IssueRequest{
 			Title:  &title,
 			Body:   &body,
-			Labels: &[]string{label},
+			Labels: &labels,
 		}
-		_, rsp, err := issues.Create(ctx, owner, issueRepo, new)
+		if assignees := expandAssignees(ctx, c, owner, repo, policy, ic.Assignees); len(assignees) > 0 {
+			new.Assignees = &assignees
+		}
+		if ic.Milestone != "" {
+			new.Milestone = findMilestone(ctx, issues, owner, repo, issueRepo, policy, ic.Milestone)
+		}
+		rsp, err := createIssue(ctx, issues, owner, repo, issueRepo, policy, key, title, label, new)
 		if err != nil && rsp != nil && (rsp.StatusCode == http.StatusGone || rsp.StatusCode == http.StatusForbidden) {
 			log.Warn().
 				Str("org", owner).
@@ -135,50 +225,65 @@ func ensure(ctx context.Context, c *github.Client, issues issues, owner, repo, p
 		}
 		return err
 	}
+	st := getIssueState(issue.GetBody())
+	// Issues created before the state block was added are upgraded on their
+	// next update.
+	st.Repo, st.Policy = fmt.Sprintf("%s/%s", owner, repo), policy
+	changed := !strings.Contains(issue.GetBody(), hash)
+	if st.DetailsHash != "" {
+		changed = st.DetailsHash != hash
+	}
 	// Check if current-version issue is not up to date
-	if !strings.Contains(issue.GetBody(), hash) && hasIssueSection(issue.GetBody(), updateSectionName) {
-		// Comment update and update issue body
-		commentBody := fmt.Sprintf("The policy result has been updated.\n\n---\n\n%s", text)
-		comment, _, err := issues.CreateComment(ctx, owner, issueRepo, issue.GetNumber(), &github.IssueComment{
-			Body: &commentBody,
-		})
-		if err != nil {
-			return fmt.Errorf("while updating issue: creating comment: %w", err)
+	if changed && hasIssueSection(issue.GetBody(), updateSectionName) {
+		// Update the issue body in place, instead of commenting, to not notify
+		// on every change of the result text.
+		history := append(getEditHistory(issue.GetBody()),
+			fmt.Sprintf(editHistoryFormat, now.UTC().Format("2006-01-02 15:04 MST"), enforcementIDSuffix(ctx)))
+		if len(history) > maxEditHistory {
+			history = history[len(history)-maxEditHistory:]
 		}
-		updateWarning := fmt.Sprintf(updateWarningFormat, fmt.Sprintf(resultTextHashCommentFormat, hash), comment.GetHTMLURL())
-		newBody, ok := updateIssueSection(issue.GetBody(), updateSectionName, updateWarning)
-		if !ok {
-			// This shouldn't occur because hasIssueSection was true
-			log.Error().
-				Str("org", owner).
-				Str("repo", repo).
-				Str("area", policy).
-				Int("issueNumber", issue.GetNumber()).
-				Msg("Unexpectedly failed to update issue update section.")
-			return nil
+		// A closed issue is only reopened when the ping schedule allows,
+		// otherwise only its body is updated.
+		reopen := issue.GetState() == "closed" && shouldPing
+		if reopen {
+			st = newIssueState(owner, repo, policy, hash, now)
 		}
-		// Ensure issue is open as well
-		state := "open"
-		_, _, err = issues.Edit(ctx, owner, issueRepo, issue.GetNumber(), &github.IssueRequest{
-			State: &state,
-			Body:  &newBody,
+		st.DetailsHash = hash
+		st.LastChecked = &now
+		newBody := fitBody(ctx, c, owner, repo, policy, text, func(t string) string {
+			return createIssueBody(owner, repo, key, t, hash, issueFooter(ctx, oc), issueRepo == repo, history, st)
 		})
+		update := &github.IssueRequest{
+			Body: &newBody,
+		}
+		if reopen {
+			state := "open"
+			update.State = &state
+		}
+		_, _, err = issues.Edit(ctx, owner, issueRepo, issue.GetNumber(), update)
 		if err != nil {
 			return fmt.Errorf("while updating issue %d: editing body: %w", issue.GetNumber(), err)
 		}
-		return nil
+		if !reopen {
+			return nil
+		}
+		return commentReopened(ctx, c, issues, owner, repo, issueRepo, policy, text, issue.GetNumber())
 	}
 	// If should not ping, don't continue. Below here is:
 	// - Reopen (& ping) if closed & not passing
 	// - Ping after interval
-	// Note that the ping above (on edit) remains.
+	// Note that the update above (on edit) remains.
 	if !shouldPing {
 		return nil
 	}
 	if issue.GetState() == "closed" {
 		state := "open"
+		// A reopened violation is detected, pinged, and escalated, from the
+		// start.
+		reopened := setIssueState(issue.GetBody(), newIssueState(owner, repo, policy, hash, now))
 		update := &github.IssueRequest{
 			State: &state,
+			Body:  &reopened,
 		}
 		if _, rsp, err := issues.Edit(ctx, owner, issueRepo, issue.GetNumber(), update); err != nil {
 			if rsp != nil && (rsp.StatusCode == http.StatusGone || rsp.StatusCode == http.StatusForbidden) {
@@ -191,15 +296,21 @@ func ensure(ctx context.Context, c *github.Client, issues issues, owner, repo, p
 			}
 			return err
 		}
-		body := fmt.Sprintf("Reopening issue. See its status below.\n\n---\n\n%s", text)
-		comment := &github.IssueComment{
-			Body: &body,
-		}
-		_, _, err := issues.CreateComment(ctx, owner, issueRepo, issue.GetNumber(), comment)
-		return err
+		return commentReopened(ctx, c, issues, owner, repo, issueRepo, policy, text, issue.GetNumber())
 	}
-	if issue.GetUpdatedAt().Before(time.Now().Add(-1 * operator.NoticePingDuration)) {
-		body := fmt.Sprintf("Updating issue after ping interval. See its status below.\n\n---\n\n%s", text)
+	if pingDue(issue, st, now) {
+		st.Pings++
+		st.DetailsHash = hash
+		st.LastChecked = &now
+		esc := mergeIssueConfig(oc, orc, rc, policy).Escalation
+		escalate := shouldEscalate(esc, st)
+		var note string
+		if escalate {
+			note = fmt.Sprintf(escalationNoteFormat, st.Pings)
+		}
+		body := fitBody(ctx, c, owner, repo, policy, text, func(t string) string {
+			return fmt.Sprintf("Updating issue after ping interval. See its status below.%s\n\n---\n\n%s%s", note, t, enforcementID(ctx))
+		})
 		comment := &github.IssueComment{
 			Body: &body,
 		}
@@ -212,11 +323,36 @@ func ensure(ctx context.Context, c *github.Client, issues issues, owner, repo, p
 				Msg("Action set to issue, but issues are disabled.")
 			return nil
 		}
-		return err
+		if err != nil {
+			return err
+		}
+		return updatePings(ctx, c, issues, owner, repo, issueRepo, policy, issue, st, esc, escalate)
 	}
 	return nil
 }
 
+// commentReopened leaves the comment announcing that an issue was reopened,
+// with the current text.
+func commentReopened(ctx context.Context, c *github.Client, issues issues, owner, repo, issueRepo, policy, text string, number int) error {
+	body := fitBody(ctx, c, owner, repo, policy, text, func(t string) string {
+		return fmt.Sprintf("Reopening issue. See its status below.\n\n---\n\n%s%s", t, enforcementID(ctx))
+	})
+	comment := &github.IssueComment{
+		Body: &body,
+	}
+	_, _, err := issues.CreateComment(ctx, owner, issueRepo, number, comment)
+	return err
+}
+
+// pingDue returns whether the ping interval passed since Allstar last updated
+// the issue, or since anyone did for issues without a state block.
+func pingDue(issue *github.Issue, st issueState, now time.Time) bool {
+	if st.LastChecked != nil {
+		return st.LastChecked.Before(now.Add(-1 * operator.NoticePingDuration))
+	}
+	return issue.GetUpdatedAt().Before(time.Now().Add(-1 * operator.NoticePingDuration))
+}
+
 // Close ensures that there is not an issue open for the provided repo and
 // policy. If open it closes it with a message.
 func Close(ctx context.Context, c *github.Client, owner, repo, policy string) error {
@@ -225,13 +361,27 @@ func Close(ctx context.Context, c *github.Client, owner, repo, policy string) er
 
 func closeIssue(ctx context.Context, c *github.Client, issues issues, owner, repo, policy string) error {
 	issueRepo, title := getIssueRepoTitle(ctx, c, owner, repo, policy)
+	if issueRepo == repo {
+		ok, err := hasIssues(ctx, c, owner, repo)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return closeFallback(ctx, c, issues, owner, repo, policy)
+		}
+	}
+	return closeIn(ctx, c, issues, owner, repo, issueRepo, title, policy)
+}
+
+// closeIn closes the issue for the repo and policy in issueRepo, if open.
+func closeIn(ctx context.Context, c *github.Client, issues issues, owner, repo, issueRepo, title, policy string) error {
 	label := getIssueLabel(ctx, c, owner, repo)
-	issue, err := getPolicyIssue(ctx, issues, owner, issueRepo, policy, title, label)
+	issue, err := getPolicyIssue(ctx, issues, owner, issueRepo, issueKey(owner, repo, policy), title, label)
 	if err != nil {
 		return err
 	}
 	if issue.GetState() == "open" {
-		body := "Policy is now in compliance. Closing issue."
+		body := "Policy is now in compliance. Closing issue." + enforcementID(ctx)
 		comment := &github.IssueComment{
 			Body: &body,
 		}
@@ -253,10 +403,252 @@ func closeIssue(ctx context.Context, c *github.Client, issues issues, owner, rep
 		if _, _, err := issues.Edit(ctx, owner, issueRepo, issue.GetNumber(), update); err != nil {
 			return err
 		}
+		st := getIssueState(issue.GetBody())
+		l := log.Info().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", policy).
+			Int("issue", issue.GetNumber()).
+			Int("pings", st.Pings).
+			Bool("escalated", st.Escalated != nil)
+		if st.FirstDetected != nil {
+			l = l.Dur("openFor", timeNow().Sub(*st.FirstDetected))
+		}
+		l.Msg("Policy issue resolved, closed.")
+	}
+	return nil
+}
+
+// createIssue creates the new issue. Server and network errors are retried
+// with a jittered backoff. As the failed request may have created the issue,
+// it is searched for again before each retry, so that no duplicate is created.
+func createIssue(ctx context.Context, issues issues, owner, repo, issueRepo, policy, key, title, label string, new *github.IssueRequest) (*github.Response, error) {
+	for attempt := 0; ; attempt++ {
+		_, rsp, err := issues.Create(ctx, owner, issueRepo, new)
+		if err != nil && rsp != nil && rsp.StatusCode == http.StatusUnprocessableEntity &&
+			(new.Assignees != nil || new.Milestone != nil) {
+			// An assignee without access to the repository fails the request,
+			// the issue is more important than its routing.
+			log.Warn().
+				Str("org", owner).
+				Str("repo", repo).
+				Str("area", policy).
+				Err(err).
+				Msg("Unable to create issue with assignees or milestone, creating without.")
+			new.Assignees = nil
+			new.Milestone = nil
+			_, rsp, err = issues.Create(ctx, owner, issueRepo, new)
+		}
+		if err == nil || (rsp != nil && rsp.StatusCode < http.StatusInternalServerError) ||
+			attempt >= maxCreateRetries {
+			return rsp, err
+		}
+		wait := createRetryWait << attempt
+		wait += jitter(wait)
+		log.Warn().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", policy).
+			Int("attempt", attempt).
+			Dur("wait", wait).
+			Err(err).
+			Msg("Unexpected error creating issue, retrying.")
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+		issue, err := getPolicyIssue(ctx, issues, owner, issueRepo, key, title, label)
+		if err != nil {
+			return nil, err
+		}
+		if issue != nil {
+			log.Info().
+				Str("org", owner).
+				Str("repo", repo).
+				Str("area", policy).
+				Int("issue", issue.GetNumber()).
+				Msg("Issue was created despite the error, not retrying.")
+			return nil, nil
+		}
+	}
+}
+
+func sleepReal(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// enforcementID returns the enforcement ID line for the evaluation of ctx, or
+// "" if it has none.
+func enforcementID(ctx context.Context) string {
+	id := enforceid.Evaluation(ctx)
+	if id == "" {
+		return ""
+	}
+	return fmt.Sprintf(enforcementIDFormat, id)
+}
+
+// enforcementIDSuffix returns the enforcement ID of ctx for an edit history
+// entry, or "" if it has none.
+func enforcementIDSuffix(ctx context.Context) string {
+	id := enforceid.Evaluation(ctx)
+	if id == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (enforcement ID %s)", id)
+}
+
+func issueFooter(ctx context.Context, oc *config.OrgConfig) string {
+	var footer string
+	if oc.IssueFooter == "" {
+		footer = operator.GitHubIssueFooter
+	} else {
+		footer = fmt.Sprintf("%v\n\n%v", oc.IssueFooter, operator.GitHubIssueFooter)
+	}
+	return footer + enforcementID(ctx)
+}
+
+// mergeIssueConfig gets the issue config for policy. Each setting is
+// overridden, if present, by the org-level config, then its policy-specific
+// config, then the same in the org-level repo config, then the repo config.
+// Repo-level config in the repository itself is ignored when the org disables
+// repo override, as routing should be controlled by org security managers.
+func mergeIssueConfig(oc *config.OrgConfig, orc, rc *config.RepoConfig, policy string) *config.IssueConfig {
+	ic := &config.IssueConfig{}
+	mergeInIssueConfig(ic, oc.Issues, policy)
+	mergeInIssueConfig(ic, orc.Issues, policy)
+	if !oc.OptConfig.DisableRepoOverride {
+		mergeInIssueConfig(ic, rc.Issues, policy)
+	}
+	return ic
+}
+
+func mergeInIssueConfig(ic, in *config.IssueConfig, policy string) {
+	if in == nil {
+		return
+	}
+	overrideIssueConfig(ic, in)
+	for name, pc := range in.Policies {
+		if pc != nil && strings.EqualFold(name, policy) {
+			overrideIssueConfig(ic, pc)
+		}
+	}
+}
+
+func overrideIssueConfig(ic, in *config.IssueConfig) {
+	if in.Assignees != nil {
+		ic.Assignees = in.Assignees
 	}
+	if in.Labels != nil {
+		ic.Labels = in.Labels
+	}
+	if in.Milestone != "" {
+		ic.Milestone = in.Milestone
+	}
+	if in.Escalation != nil {
+		ic.Escalation = in.Escalation
+	}
+}
+
+// expandAssignees returns the usernames of assignees, with teams, as
+// "org/team-slug", expanded to their members. Teams that can not be listed are
+// logged and skipped.
+func expandAssignees(ctx context.Context, c *github.Client, owner, repo, policy string, assignees []string) []string {
+	var users []string
+	for _, a := range assignees {
+		org, slug, ok := strings.Cut(strings.TrimPrefix(a, "@"), "/")
+		if !ok {
+			users = appendUnique(users, a)
+			continue
+		}
+		members, err := listTeamMembers(ctx, c, org, slug)
+		if err != nil {
+			log.Warn().
+				Str("org", owner).
+				Str("repo", repo).
+				Str("area", policy).
+				Str("team", a).
+				Err(err).
+				Msg("Unable to list team members for issue assignees, skipping team.")
+			continue
+		}
+		users = appendUnique(users, members...)
+	}
+	if len(users) > maxAssignees {
+		log.Warn().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", policy).
+			Int("count", len(users)).
+			Msgf("Too many issue assignees, only assigning the first %v.", maxAssignees)
+		users = users[:maxAssignees]
+	}
+	return users
+}
+
+// findMilestone returns the number of the open milestone titled title in
+// issueRepo, or nil if it is not found.
+func findMilestone(ctx context.Context, issues issues, owner, repo, issueRepo, policy, title string) *int {
+	opt := &github.MilestoneListOptions{
+		State: "open",
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+	for {
+		ms, resp, err := issues.ListMilestones(ctx, owner, issueRepo, opt)
+		if err != nil {
+			log.Warn().
+				Str("org", owner).
+				Str("repo", repo).
+				Str("area", policy).
+				Str("milestone", title).
+				Err(err).
+				Msg("Unable to list milestones, creating issue without milestone.")
+			return nil
+		}
+		for _, m := range ms {
+			if m.GetTitle() == title {
+				n := m.GetNumber()
+				return &n
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	log.Warn().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", policy).
+		Str("milestone", title).
+		Msg("Issue milestone not found, creating issue without milestone.")
 	return nil
 }
 
+// appendUnique appends the values of vs not already in l.
+func appendUnique(l []string, vs ...string) []string {
+	for _, v := range vs {
+		found := false
+		for _, e := range l {
+			if strings.EqualFold(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			l = append(l, v)
+		}
+	}
+	return l
+}
+
 func getIssueLabel(ctx context.Context, c *github.Client, owner, repo string) string {
 	label := operator.GitHubIssueLabel
 	oc, orc, rc := configGetAppConfigs(ctx, c, owner, repo)
@@ -280,16 +672,20 @@ func getIssueRepoTitle(ctx context.Context, c *github.Client, owner, repo, polic
 	return repo, fmt.Sprintf(sameRepoTitle, policy)
 }
 
-func createIssueBody(owner, repo, text, hash, footer string, isIssueRepo bool) string {
+func createIssueBody(owner, repo, key, text, hash, footer string, isIssueRepo bool, history []string, st issueState) string {
 	var refersTo string
 	if !isIssueRepo {
 		ownerRepo := fmt.Sprintf("%s/%s", owner, repo)
 		refersTo = fmt.Sprintf(" and refers to [%s](https://github.com/%s)", ownerRepo, ownerRepo)
 	}
 	editHeader := issueSectionHeader(updateSectionName)
+	updates := fmt.Sprintf(resultTextHashCommentFormat, hash)
+	if len(history) > 0 {
+		updates += fmt.Sprintf("\n\n%s\n%s\n", editHistoryHeader, strings.Join(history, "\n"))
+	}
 	return fmt.Sprintf("_This issue was automatically created by [Allstar](https://github.com/ossf/allstar/)%s._\n\n**Security Policy Violation**\n"+
-		"%v\n\n---\n\n%s%s%s\n%v",
-		refersTo, text, editHeader, fmt.Sprintf(resultTextHashCommentFormat, hash), editHeader, footer)
+		"%v\n\n---\n\n"+issueKeyFormat+"%s%s%s%s\n%v",
+		refersTo, text, key, st, editHeader, updates, editHeader, footer)
 }
 
 func issueSectionHeader(sectionName string) string {
@@ -300,11 +696,26 @@ func hasIssueSection(body, sectionName string) bool {
 	return strings.Count(body, issueSectionHeader(sectionName)) == 2
 }
 
-func updateIssueSection(body, sectionName, editText string) (string, bool) {
-	header := issueSectionHeader(sectionName)
-	s := strings.Split(body, header)
+func getIssueSection(body, sectionName string) (string, bool) {
+	s := strings.Split(body, issueSectionHeader(sectionName))
 	if len(s) != 3 {
-		return body, false
+		return "", false
+	}
+	return s[1], true
+}
+
+// getEditHistory returns the edit history entries of an issue body.
+func getEditHistory(body string) []string {
+	section, _ := getIssueSection(body, updateSectionName)
+	_, list, ok := strings.Cut(section, editHistoryHeader)
+	if !ok {
+		return nil
+	}
+	var history []string
+	for _, l := range strings.Split(list, "\n") {
+		if strings.HasPrefix(l, "- ") {
+			history = append(history, l)
+		}
 	}
-	return strings.Join([]string{s[0], header, editText, header, s[2]}, ""), true
+	return history
 }
@@ -16,14 +16,18 @@ package issue
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/ossf/allstar/pkg/config"
 	"github.com/ossf/allstar/pkg/config/operator"
+	"github.com/ossf/allstar/pkg/enforceid"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-github/v59/github"
 )
 
@@ -35,6 +39,8 @@ var edit func(context.Context, string, string, int, *github.IssueRequest) (
 	*github.Issue, *github.Response, error)
 var createComment func(context.Context, string, string, int,
 	*github.IssueComment) (*github.IssueComment, *github.Response, error)
+var listMilestones func(context.Context, string, string,
+	*github.MilestoneListOptions) ([]*github.Milestone, *github.Response, error)
 
 type mockIssues struct{}
 
@@ -58,6 +64,20 @@ func (m mockIssues) CreateComment(ctx context.Context, owner string, repo string
 	return createComment(ctx, owner, repo, number, comment)
 }
 
+func (m mockIssues) ListMilestones(ctx context.Context, owner string, repo string,
+	opts *github.MilestoneListOptions) ([]*github.Milestone, *github.Response, error) {
+	return listMilestones(ctx, owner, repo, opts)
+}
+
+func init() {
+	timeNow = func() time.Time {
+		return time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	}
+	hasIssues = func(context.Context, *github.Client, string, string) (bool, error) {
+		return true, nil
+	}
+}
+
 func setShouldPerform(b bool) {
 	scheduleShouldPerform = func(*config.ScheduleConfig) bool {
 		return b
@@ -70,8 +90,9 @@ func TestEnsure(t *testing.T) {
 	issueTitleOtherRepo := "Security Policy violation for repository \"\" thispolicy"
 	closed := "closed"
 	open := "open"
-	body := "_This issue was automatically created by [Allstar](https://github.com/ossf/allstar/)._\n\n**Security Policy Violation**\nStatus text\n\n---\n\n<!-- Edit section #updates --><!-- Current result text hash: 1ab61918ea1b7d10e20db2b40287c1a265a1617b998d87b28579a4462b2efac2 --><!-- Edit section #updates -->\nThis issue will auto resolve when the policy is in compliance.\n\nIssue created by Allstar. See https://github.com/ossf/allstar/ for more information. For questions specific to the repository, please contact the owner or maintainer."
-	bodyOtherRepo := "_This issue was automatically created by [Allstar](https://github.com/ossf/allstar/) and refers to [/](https://github.com//)._\n\n**Security Policy Violation**\nStatus text\n\n---\n\n<!-- Edit section #updates --><!-- Current result text hash: 1ab61918ea1b7d10e20db2b40287c1a265a1617b998d87b28579a4462b2efac2 --><!-- Edit section #updates -->\nThis issue will auto resolve when the policy is in compliance.\n\nIssue created by Allstar. See https://github.com/ossf/allstar/ for more information. For questions specific to the repository, please contact the owner or maintainer."
+	stateBlock := newIssueState("", "", "thispolicy", "1ab61918ea1b7d10e20db2b40287c1a265a1617b998d87b28579a4462b2efac2", timeNow()).String()
+	body := "_This issue was automatically created by [Allstar](https://github.com/ossf/allstar/)._\n\n**Security Policy Violation**\nStatus text\n\n---\n\n<!-- Allstar issue key: 27b2d8810f55fe22d61196dfadb820f4 -->" + stateBlock + "<!-- Edit section #updates --><!-- Current result text hash: 1ab61918ea1b7d10e20db2b40287c1a265a1617b998d87b28579a4462b2efac2 --><!-- Edit section #updates -->\nThis issue will auto resolve when the policy is in compliance.\n\nIssue created by Allstar. See https://github.com/ossf/allstar/ for more information. For questions specific to the repository, please contact the owner or maintainer."
+	bodyOtherRepo := "_This issue was automatically created by [Allstar](https://github.com/ossf/allstar/) and refers to [/](https://github.com//)._\n\n**Security Policy Violation**\nStatus text\n\n---\n\n<!-- Allstar issue key: 27b2d8810f55fe22d61196dfadb820f4 -->" + stateBlock + "<!-- Edit section #updates --><!-- Current result text hash: 1ab61918ea1b7d10e20db2b40287c1a265a1617b998d87b28579a4462b2efac2 --><!-- Edit section #updates -->\nThis issue will auto resolve when the policy is in compliance.\n\nIssue created by Allstar. See https://github.com/ossf/allstar/ for more information. For questions specific to the repository, please contact the owner or maintainer."
 	configGetAppConfigs = func(context.Context, *github.Client, string, string) (*config.OrgConfig, *config.RepoConfig, *config.RepoConfig) {
 		return &config.OrgConfig{}, &config.RepoConfig{}, &config.RepoConfig{}
 	}
@@ -97,7 +118,7 @@ func TestEnsure(t *testing.T) {
 		}
 		edit = nil
 		createComment = nil
-		err := ensure(context.Background(), nil, mockIssues{}, "", "", "thispolicy", "Status text")
+		_, err := ensure(context.Background(), nil, mockIssues{}, "", "", "thispolicy", "Status text")
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
@@ -105,6 +126,27 @@ func TestEnsure(t *testing.T) {
 			t.Error("Expected issue to be created")
 		}
 	})
+	t.Run("NoIssueEnforcementID", func(t *testing.T) {
+		listByRepo = func(ctx context.Context, owner string, repo string,
+			opts *github.IssueListByRepoOptions) ([]*github.Issue, *github.Response, error) {
+			return make([]*github.Issue, 0), &github.Response{NextPage: 0}, nil
+		}
+		ctx := enforceid.WithEvaluation(context.Background())
+		expBody := body + "\n\n<sub>Allstar enforcement ID: " + enforceid.Evaluation(ctx) + "</sub>"
+		create = func(ctx context.Context, owner string, repo string,
+			issue *github.IssueRequest) (*github.Issue, *github.Response, error) {
+			if *issue.Body != expBody {
+				t.Errorf("Unexpected body: %q expect: %q", issue.GetBody(), expBody)
+			}
+			return nil, nil, nil
+		}
+		edit = nil
+		createComment = nil
+		_, err := ensure(ctx, nil, mockIssues{}, "", "", "thispolicy", "Status text")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
 	t.Run("NoIssueInAnotherRepo", func(t *testing.T) {
 		configGetAppConfigs = func(context.Context, *github.Client, string, string) (*config.OrgConfig, *config.RepoConfig, *config.RepoConfig) {
 			return &config.OrgConfig{IssueRepo: "issuerepo"}, &config.RepoConfig{}, &config.RepoConfig{}
@@ -130,7 +172,7 @@ func TestEnsure(t *testing.T) {
 		}
 		edit = nil
 		createComment = nil
-		err := ensure(context.Background(), nil, mockIssues{}, "", "", "thispolicy", "Status text")
+		_, err := ensure(context.Background(), nil, mockIssues{}, "", "", "thispolicy", "Status text")
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
@@ -142,7 +184,7 @@ func TestEnsure(t *testing.T) {
 		configGetAppConfigs = func(context.Context, *github.Client, string, string) (*config.OrgConfig, *config.RepoConfig, *config.RepoConfig) {
 			return &config.OrgConfig{IssueFooter: "CustomFooter"}, &config.RepoConfig{}, &config.RepoConfig{}
 		}
-		bodyWithFooter := "_This issue was automatically created by [Allstar](https://github.com/ossf/allstar/)._\n\n**Security Policy Violation**\nStatus text\n\n---\n\n<!-- Edit section #updates --><!-- Current result text hash: 1ab61918ea1b7d10e20db2b40287c1a265a1617b998d87b28579a4462b2efac2 --><!-- Edit section #updates -->\nCustomFooter\n\nThis issue will auto resolve when the policy is in compliance.\n\nIssue created by Allstar. See https://github.com/ossf/allstar/ for more information. For questions specific to the repository, please contact the owner or maintainer."
+		bodyWithFooter := "_This issue was automatically created by [Allstar](https://github.com/ossf/allstar/)._\n\n**Security Policy Violation**\nStatus text\n\n---\n\n<!-- Allstar issue key: 27b2d8810f55fe22d61196dfadb820f4 -->" + stateBlock + "<!-- Edit section #updates --><!-- Current result text hash: 1ab61918ea1b7d10e20db2b40287c1a265a1617b998d87b28579a4462b2efac2 --><!-- Edit section #updates -->\nCustomFooter\n\nThis issue will auto resolve when the policy is in compliance.\n\nIssue created by Allstar. See https://github.com/ossf/allstar/ for more information. For questions specific to the repository, please contact the owner or maintainer."
 		listByRepo = func(ctx context.Context, owner string, repo string,
 			opts *github.IssueListByRepoOptions) ([]*github.Issue, *github.Response, error) {
 			return make([]*github.Issue, 0), &github.Response{NextPage: 0}, nil
@@ -164,7 +206,7 @@ func TestEnsure(t *testing.T) {
 		}
 		edit = nil
 		createComment = nil
-		err := ensure(context.Background(), nil, mockIssues{}, "", "", "thispolicy", "Status text")
+		_, err := ensure(context.Background(), nil, mockIssues{}, "", "", "thispolicy", "Status text")
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
@@ -201,7 +243,7 @@ func TestEnsure(t *testing.T) {
 			commentCalled = true
 			return nil, nil, nil
 		}
-		err := ensure(context.Background(), nil, mockIssues{}, "", "", "thispolicy", "Status text")
+		_, err := ensure(context.Background(), nil, mockIssues{}, "", "", "thispolicy", "Status text")
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
@@ -233,8 +275,11 @@ func TestEnsure(t *testing.T) {
 			if !strings.Contains(issue.GetBody(), "<!-- Current result text hash: 3079c555ed4ca1a5d828505dd39ed455313c07451755e380c36ee48a359038e9 -->") {
 				t.Errorf("Unexpected issue edit body (missing hash): %v", issue.GetBody())
 			}
-			if !strings.Contains(issue.GetBody(), "Click here to see the latest update") {
-				t.Errorf("Unexpected issue edit body (missing link to update): %v", issue.GetBody())
+			if !strings.Contains(issue.GetBody(), "**Security Policy Violation**\nNew status text\n") {
+				t.Errorf("Unexpected issue edit body (missing new text): %v", issue.GetBody())
+			}
+			if !strings.Contains(issue.GetBody(), "**Edit history**\n- 2025-09-01 12:00 UTC: policy result updated\n") {
+				t.Errorf("Unexpected issue edit body (missing edit history): %v", issue.GetBody())
 			}
 			editCalled = true
 			return nil, nil, nil
@@ -242,13 +287,13 @@ func TestEnsure(t *testing.T) {
 		commentCalled := false
 		createComment = func(ctx context.Context, owner string, repo string,
 			number int, comment *github.IssueComment) (*github.IssueComment, *github.Response, error) {
-			if !strings.HasPrefix(comment.GetBody(), "The policy result has been updated") {
+			if !strings.HasPrefix(comment.GetBody(), "Reopening issue") {
 				t.Errorf("Unexpected comment: %v", comment.GetBody())
 			}
 			commentCalled = true
 			return nil, nil, nil
 		}
-		err := ensure(context.Background(), nil, mockIssues{}, "", "", "thispolicy", "New status text")
+		_, err := ensure(context.Background(), nil, mockIssues{}, "", "", "thispolicy", "New status text")
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
@@ -259,6 +304,104 @@ func TestEnsure(t *testing.T) {
 			t.Error("Expected comment to be left")
 		}
 	})
+	t.Run("ClosedIssueUpdatedTextScheduleBlocksReopen", func(t *testing.T) {
+		setShouldPerform(false)
+		defer setShouldPerform(true)
+		listByRepo = func(ctx context.Context, owner string, repo string,
+			opts *github.IssueListByRepoOptions) ([]*github.Issue, *github.Response, error) {
+			return []*github.Issue{
+				&github.Issue{
+					Title: &issueTitle,
+					State: &closed,
+					Body:  &body,
+				},
+			}, &github.Response{NextPage: 0}, nil
+		}
+		create = nil
+		editCalled := false
+		edit = func(ctx context.Context, owner string, repo string, number int,
+			issue *github.IssueRequest) (*github.Issue, *github.Response, error) {
+			if issue.State != nil {
+				t.Errorf("Unexpected state: %v", issue.GetState())
+			}
+			if !strings.Contains(issue.GetBody(), "**Security Policy Violation**\nNew status text\n") {
+				t.Errorf("Unexpected issue edit body (missing new text): %v", issue.GetBody())
+			}
+			editCalled = true
+			return nil, nil, nil
+		}
+		// Expect to not call nil functions
+		createComment = nil
+		_, err := ensure(context.Background(), nil, mockIssues{}, "", "", "thispolicy", "New status text")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if editCalled != true {
+			t.Error("Expected issue body to be updated")
+		}
+	})
+	t.Run("OpenIssueUpdatedText", func(t *testing.T) {
+		var history []string
+		for i := 0; i < maxEditHistory; i++ {
+			history = append(history, fmt.Sprintf("- 2025-08-%02d 12:00 UTC: policy result updated", i+1))
+		}
+		oldBody := createIssueBody("", "", issueKey("", "", "thispolicy"), "Status text", "oldhash", operator.GitHubIssueFooter, true, history, issueState{})
+		listByRepo = func(ctx context.Context, owner string, repo string,
+			opts *github.IssueListByRepoOptions) ([]*github.Issue, *github.Response, error) {
+			return []*github.Issue{
+				&github.Issue{
+					Title: &issueTitle,
+					State: &open,
+					Body:  &oldBody,
+				},
+			}, &github.Response{NextPage: 0}, nil
+		}
+		ctx := enforceid.WithEvaluation(context.Background())
+		expHistory := append(history[1:], "- 2025-09-01 12:00 UTC: policy result updated (enforcement ID "+enforceid.Evaluation(ctx)+")")
+		create = nil
+		editCalled := false
+		edit = func(ctx context.Context, owner string, repo string, number int,
+			issue *github.IssueRequest) (*github.Issue, *github.Response, error) {
+			if issue.State != nil {
+				t.Errorf("Unexpected state: %v", issue.GetState())
+			}
+			if diff := cmp.Diff(expHistory, getEditHistory(issue.GetBody())); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+			editCalled = true
+			return nil, nil, nil
+		}
+		createComment = nil
+		_, err := ensure(ctx, nil, mockIssues{}, "", "", "thispolicy", "New status text")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if editCalled != true {
+			t.Error("Expected issue to be updated")
+		}
+	})
+	t.Run("OpenIssueSameText", func(t *testing.T) {
+		now := github.Timestamp{Time: time.Now()}
+		listByRepo = func(ctx context.Context, owner string, repo string,
+			opts *github.IssueListByRepoOptions) ([]*github.Issue, *github.Response, error) {
+			return []*github.Issue{
+				&github.Issue{
+					Title:     &issueTitle,
+					State:     &open,
+					Body:      &body,
+					UpdatedAt: &now,
+				},
+			}, &github.Response{NextPage: 0}, nil
+		}
+		// Expect to not call nil functions
+		create = nil
+		edit = nil
+		createComment = nil
+		_, err := ensure(context.Background(), nil, mockIssues{}, "", "", "thispolicy", "Status text")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
 	t.Run("OpenFreshIssue", func(t *testing.T) {
 		now := github.Timestamp{Time: time.Now()}
 
@@ -276,7 +419,7 @@ func TestEnsure(t *testing.T) {
 		create = nil
 		edit = nil
 		createComment = nil
-		err := ensure(context.Background(), nil, mockIssues{}, "", "", "thispolicy", "Status text")
+		_, err := ensure(context.Background(), nil, mockIssues{}, "", "", "thispolicy", "Status text")
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
@@ -302,16 +445,24 @@ func TestEnsure(t *testing.T) {
 			commentCalled = true
 			return nil, nil, nil
 		}
+		var st issueState
+		edit = func(ctx context.Context, owner string, repo string, number int,
+			issue *github.IssueRequest) (*github.Issue, *github.Response, error) {
+			st = getIssueState(issue.GetBody())
+			return nil, nil, nil
+		}
 		// Expect to not call nil functions
 		create = nil
-		edit = nil
-		err := ensure(context.Background(), nil, mockIssues{}, "", "", "thispolicy", "Status text")
+		_, err := ensure(context.Background(), nil, mockIssues{}, "", "", "thispolicy", "Status text")
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
 		if commentCalled != true {
 			t.Error("Expected comment to be left")
 		}
+		if st.Pings != 1 || st.LastChecked == nil || st.Policy != "thispolicy" {
+			t.Errorf("Unexpected state after ping: %+v", st)
+		}
 	})
 	t.Run("NoIssueScheduleBlocksCreate", func(t *testing.T) {
 		setShouldPerform(false)
@@ -336,7 +487,7 @@ func TestEnsure(t *testing.T) {
 		}
 		edit = nil
 		createComment = nil
-		err := ensure(context.Background(), nil, mockIssues{}, "", "", "thispolicy", "Status text")
+		_, err := ensure(context.Background(), nil, mockIssues{}, "", "", "thispolicy", "Status text")
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
@@ -370,7 +521,7 @@ func TestEnsure(t *testing.T) {
 		}
 		edit = nil
 		createComment = nil
-		err := ensure(context.Background(), nil, mockIssues{}, "", "", "thispolicy", "Status text")
+		_, err := ensure(context.Background(), nil, mockIssues{}, "", "", "thispolicy", "Status text")
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
@@ -400,16 +551,24 @@ func TestEnsure(t *testing.T) {
 			commentCalled = true
 			return nil, nil, nil
 		}
+		var st issueState
+		edit = func(ctx context.Context, owner string, repo string, number int,
+			issue *github.IssueRequest) (*github.Issue, *github.Response, error) {
+			st = getIssueState(issue.GetBody())
+			return nil, nil, nil
+		}
 		// Expect to not call nil functions
 		create = nil
-		edit = nil
-		err := ensure(context.Background(), nil, mockIssues{}, "", "", "thispolicy", "Status text")
+		_, err := ensure(context.Background(), nil, mockIssues{}, "", "", "thispolicy", "Status text")
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
 		if commentCalled != true {
 			t.Error("Expected comment to be left")
 		}
+		if st.Pings != 1 || st.LastChecked == nil || st.Policy != "thispolicy" {
+			t.Errorf("Unexpected state after ping: %+v", st)
+		}
 	})
 	t.Run("OpenStaleIssueScheduleBlocksPing", func(t *testing.T) {
 		setShouldPerform(false)
@@ -436,7 +595,7 @@ func TestEnsure(t *testing.T) {
 		// Expect to not call nil functions
 		create = nil
 		edit = nil
-		err := ensure(context.Background(), nil, mockIssues{}, "", "", "thispolicy", "Status text")
+		_, err := ensure(context.Background(), nil, mockIssues{}, "", "", "thispolicy", "Status text")
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
@@ -623,3 +782,365 @@ func TestRepoTitle(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeIssueConfig(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Org     config.OrgConfig
+		OrgRepo config.RepoConfig
+		Repo    config.RepoConfig
+		Exp     config.IssueConfig
+	}{
+		{
+			Name: "None",
+			Exp:  config.IssueConfig{},
+		},
+		{
+			Name: "OrgOnly",
+			Org: config.OrgConfig{
+				Issues: &config.IssueConfig{
+					Assignees: []string{"alice"},
+					Labels:    []string{"security"},
+					Milestone: "Backlog",
+				},
+			},
+			Exp: config.IssueConfig{
+				Assignees: []string{"alice"},
+				Labels:    []string{"security"},
+				Milestone: "Backlog",
+			},
+		},
+		{
+			Name: "OrgPolicy",
+			Org: config.OrgConfig{
+				Issues: &config.IssueConfig{
+					Assignees: []string{"alice"},
+					Labels:    []string{"security"},
+					Policies: map[string]*config.IssueConfig{
+						"ThisPolicy": {
+							Assignees: []string{"thisorg/actions-team"},
+						},
+						"otherpolicy": {
+							Milestone: "Other",
+						},
+					},
+				},
+			},
+			Exp: config.IssueConfig{
+				Assignees: []string{"thisorg/actions-team"},
+				Labels:    []string{"security"},
+			},
+		},
+		{
+			Name: "RepoOverOrg",
+			Org: config.OrgConfig{
+				Issues: &config.IssueConfig{
+					Assignees: []string{"alice"},
+					Labels:    []string{"security"},
+					Milestone: "Backlog",
+				},
+			},
+			OrgRepo: config.RepoConfig{
+				Issues: &config.IssueConfig{
+					Milestone: "Q4",
+				},
+			},
+			Repo: config.RepoConfig{
+				Issues: &config.IssueConfig{
+					Policies: map[string]*config.IssueConfig{
+						"thispolicy": {
+							Assignees: []string{"bob"},
+							Labels:    []string{},
+						},
+					},
+				},
+			},
+			Exp: config.IssueConfig{
+				Assignees: []string{"bob"},
+				Labels:    []string{},
+				Milestone: "Q4",
+			},
+		},
+		{
+			Name: "RepoDisallowed",
+			Org: config.OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					DisableRepoOverride: true,
+				},
+				Issues: &config.IssueConfig{
+					Assignees: []string{"alice"},
+				},
+			},
+			OrgRepo: config.RepoConfig{
+				Issues: &config.IssueConfig{
+					Milestone: "Q4",
+				},
+			},
+			Repo: config.RepoConfig{
+				Issues: &config.IssueConfig{
+					Assignees: []string{"bob"},
+				},
+			},
+			Exp: config.IssueConfig{
+				Assignees: []string{"alice"},
+				Milestone: "Q4",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got := mergeIssueConfig(&test.Org, &test.OrgRepo, &test.Repo, "thispolicy")
+			if diff := cmp.Diff(&test.Exp, got); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEnsureRouting(t *testing.T) {
+	configGetAppConfigs = func(context.Context, *github.Client, string, string) (*config.OrgConfig, *config.RepoConfig, *config.RepoConfig) {
+		return &config.OrgConfig{
+			Issues: &config.IssueConfig{
+				Assignees: []string{"alice", "thisorg/security", "thisorg/missing"},
+				Labels:    []string{"security", operator.GitHubIssueLabel},
+				Milestone: "Q4",
+			},
+		}, &config.RepoConfig{}, &config.RepoConfig{}
+	}
+	listTeamMembers = func(ctx context.Context, c *github.Client, org, slug string) ([]string, error) {
+		if slug == "security" {
+			return []string{"bob", "Alice"}, nil
+		}
+		return nil, errors.New("not found")
+	}
+	listByRepo = func(ctx context.Context, owner string, repo string,
+		opts *github.IssueListByRepoOptions) ([]*github.Issue, *github.Response, error) {
+		return make([]*github.Issue, 0), &github.Response{NextPage: 0}, nil
+	}
+	edit = nil
+	createComment = nil
+	setShouldPerform(true)
+
+	t.Run("Routed", func(t *testing.T) {
+		listMilestones = func(ctx context.Context, owner string, repo string,
+			opts *github.MilestoneListOptions) ([]*github.Milestone, *github.Response, error) {
+			return []*github.Milestone{
+				{Title: github.String("Q3"), Number: github.Int(3)},
+				{Title: github.String("Q4"), Number: github.Int(4)},
+			}, &github.Response{NextPage: 0}, nil
+		}
+		var got *github.IssueRequest
+		create = func(ctx context.Context, owner string, repo string,
+			issue *github.IssueRequest) (*github.Issue, *github.Response, error) {
+			got = issue
+			return nil, nil, nil
+		}
+		_, err := ensure(context.Background(), nil, mockIssues{}, "thisorg", "thisrepo", "thispolicy", "Status text")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if got == nil {
+			t.Fatal("Expected issue to be created")
+		}
+		if diff := cmp.Diff([]string{operator.GitHubIssueLabel, "security"}, got.GetLabels()); diff != "" {
+			t.Errorf("Unexpected labels. (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff([]string{"alice", "bob"}, got.GetAssignees()); diff != "" {
+			t.Errorf("Unexpected assignees. (-want +got):\n%s", diff)
+		}
+		if got.GetMilestone() != 4 {
+			t.Errorf("Unexpected milestone: %v", got.GetMilestone())
+		}
+	})
+	t.Run("InvalidAssignee", func(t *testing.T) {
+		listMilestones = func(ctx context.Context, owner string, repo string,
+			opts *github.MilestoneListOptions) ([]*github.Milestone, *github.Response, error) {
+			return nil, &github.Response{NextPage: 0}, nil
+		}
+		var calls []*github.IssueRequest
+		create = func(ctx context.Context, owner string, repo string,
+			issue *github.IssueRequest) (*github.Issue, *github.Response, error) {
+			r := *issue
+			calls = append(calls, &r)
+			if issue.Assignees != nil {
+				rsp := &github.Response{Response: &http.Response{StatusCode: http.StatusUnprocessableEntity}}
+				return nil, rsp, errors.New("invalid assignee")
+			}
+			return nil, nil, nil
+		}
+		_, err := ensure(context.Background(), nil, mockIssues{}, "thisorg", "thisrepo", "thispolicy", "Status text")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(calls) != 2 {
+			t.Fatalf("Expected create to be retried, got %v calls", len(calls))
+		}
+		if calls[0].Milestone != nil {
+			t.Errorf("Unexpected milestone: %v", calls[0].GetMilestone())
+		}
+		if calls[1].Assignees != nil {
+			t.Errorf("Unexpected assignees on retry: %v", calls[1].GetAssignees())
+		}
+		if diff := cmp.Diff([]string{operator.GitHubIssueLabel, "security"}, calls[1].GetLabels()); diff != "" {
+			t.Errorf("Unexpected labels. (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestGetPolicyIssue(t *testing.T) {
+	marker := fmt.Sprintf(issueKeyFormat, "thiskey")
+	tests := []struct {
+		Name   string
+		Issues []*github.Issue
+		Key    string
+		Exp    int
+	}{
+		{
+			Name: "Marker",
+			Issues: []*github.Issue{
+				{Number: github.Int(1), Title: github.String("thistitle"), Body: github.String("Old issue")},
+				{Number: github.Int(2), Title: github.String("Renamed"), Body: github.String("Body " + marker), State: github.String("closed")},
+			},
+			Key: "thiskey",
+			Exp: 2,
+		},
+		{
+			Name: "TitleFallback",
+			Issues: []*github.Issue{
+				{Number: github.Int(1), Title: github.String("othertitle"), Body: github.String("Other")},
+				{Number: github.Int(2), Title: github.String("thistitle"), Body: github.String("Old issue")},
+			},
+			Key: "thiskey",
+			Exp: 2,
+		},
+		{
+			Name: "NoKey",
+			Issues: []*github.Issue{
+				{Number: github.Int(1), Title: github.String("thistitle"), Body: github.String("Body " + marker)},
+			},
+			Exp: 1,
+		},
+		{
+			Name: "NotFound",
+			Issues: []*github.Issue{
+				{Number: github.Int(1), Title: github.String("othertitle"), Body: github.String("Other")},
+			},
+			Key: "thiskey",
+			Exp: 0,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			listByRepo = func(ctx context.Context, owner string, repo string,
+				opts *github.IssueListByRepoOptions) ([]*github.Issue, *github.Response, error) {
+				if opts.State != "all" {
+					t.Errorf("Unexpected state: %v", opts.State)
+				}
+				return test.Issues, &github.Response{NextPage: 0}, nil
+			}
+			issue, err := getPolicyIssue(context.Background(), mockIssues{}, "thisorg", "thisrepo", test.Key, "thistitle", "thislabel")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if issue.GetNumber() != test.Exp {
+				t.Errorf("Unexpected issue: %v, expected %v", issue.GetNumber(), test.Exp)
+			}
+		})
+	}
+}
+
+func TestEnsureCreateRetry(t *testing.T) {
+	configGetAppConfigs = func(context.Context, *github.Client, string, string) (*config.OrgConfig, *config.RepoConfig, *config.RepoConfig) {
+		return &config.OrgConfig{}, &config.RepoConfig{}, &config.RepoConfig{}
+	}
+	setShouldPerform(true)
+	jitter = func(time.Duration) time.Duration { return 0 }
+	edit = nil
+	createComment = nil
+	serverError := &github.Response{Response: &http.Response{StatusCode: http.StatusBadGateway}}
+	forbidden := &github.Response{Response: &http.Response{StatusCode: http.StatusForbidden}}
+	created := &github.Issue{
+		Number: github.Int(1),
+		Title:  github.String("Security Policy violation thispolicy"),
+		Body:   github.String(fmt.Sprintf(issueKeyFormat, issueKey("thisorg", "thisrepo", "thispolicy"))),
+	}
+	tests := []struct {
+		Name       string
+		Responses  []*github.Response
+		FoundAfter int
+		ExpCreates int
+		ExpWaits   []time.Duration
+		ExpErr     bool
+	}{
+		{
+			Name:       "RetrySucceeds",
+			Responses:  []*github.Response{serverError, nil},
+			ExpCreates: 2,
+			ExpWaits:   []time.Duration{2 * time.Second},
+		},
+		{
+			Name:       "CreatedDespiteError",
+			Responses:  []*github.Response{serverError},
+			FoundAfter: 1,
+			ExpCreates: 1,
+			ExpWaits:   []time.Duration{2 * time.Second},
+		},
+		{
+			Name:       "NetworkError",
+			Responses:  []*github.Response{nil, nil},
+			ExpCreates: 2,
+			ExpWaits:   []time.Duration{2 * time.Second},
+		},
+		{
+			Name:       "GiveUp",
+			Responses:  []*github.Response{serverError, serverError, serverError, serverError},
+			ExpCreates: 4,
+			ExpWaits:   []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second},
+			ExpErr:     true,
+		},
+		{
+			Name:       "ClientErrorNotRetried",
+			Responses:  []*github.Response{forbidden},
+			ExpCreates: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			creates := 0
+			listByRepo = func(ctx context.Context, owner string, repo string,
+				opts *github.IssueListByRepoOptions) ([]*github.Issue, *github.Response, error) {
+				if test.FoundAfter > 0 && creates >= test.FoundAfter {
+					return []*github.Issue{created}, &github.Response{NextPage: 0}, nil
+				}
+				return nil, &github.Response{NextPage: 0}, nil
+			}
+			create = func(ctx context.Context, owner string, repo string,
+				issue *github.IssueRequest) (*github.Issue, *github.Response, error) {
+				rsp := test.Responses[creates]
+				creates++
+				if rsp == serverError || rsp == forbidden {
+					return nil, rsp, errors.New("create failed")
+				}
+				if rsp == nil && creates < len(test.Responses) {
+					return nil, nil, errors.New("connection reset")
+				}
+				return created, nil, nil
+			}
+			var waits []time.Duration
+			sleep = func(ctx context.Context, d time.Duration) error {
+				waits = append(waits, d)
+				return nil
+			}
+			_, err := ensure(context.Background(), nil, mockIssues{}, "thisorg", "thisrepo", "thispolicy", "Status text")
+			if (err != nil) != test.ExpErr {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if creates != test.ExpCreates {
+				t.Errorf("Unexpected number of creates: %v", creates)
+			}
+			if diff := cmp.Diff(test.ExpWaits, waits); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
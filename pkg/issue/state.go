@@ -0,0 +1,115 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issue
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// issueStateFormat is a hidden block in the issue body with the
+// machine-readable state of the issue, as JSON.
+const issueStateFormat = "<!-- Allstar state: %s -->"
+
+var issueStateRe = regexp.MustCompile(`<!-- Allstar state: (.*?) -->`)
+
+// issueState is the machine-readable state of an issue, kept in its body. It
+// is used to find the issue of a repo and policy, to decide when to ping it,
+// and for metrics, instead of the title and update time of the issue, which
+// users may change.
+type issueState struct {
+	// Repo is the repository of the violation, as "owner/repo".
+	Repo string `json:"repo,omitempty"`
+
+	// Policy is the name of the violated policy.
+	Policy string `json:"policy,omitempty"`
+
+	// FirstDetected is when the violation was first detected, since the
+	// issue was last opened.
+	FirstDetected *time.Time `json:"firstDetected,omitempty"`
+
+	// LastChecked is when Allstar last checked the violation and updated the
+	// issue, by creating, editing, reopening, or pinging it.
+	LastChecked *time.Time `json:"lastChecked,omitempty"`
+
+	// DetailsHash is the hash of the policy result text in the issue.
+	DetailsHash string `json:"detailsHash,omitempty"`
+
+	// Pings is the number of pings made since the issue was opened.
+	Pings int `json:"pings,omitempty"`
+
+	// Escalated is when the issue was escalated, if it was.
+	Escalated *time.Time `json:"escalated,omitempty"`
+}
+
+// newIssueState returns the state of a newly opened issue for the repo and
+// policy.
+func newIssueState(owner, repo, policy, hash string, now time.Time) issueState {
+	return issueState{
+		Repo:          fmt.Sprintf("%s/%s", owner, repo),
+		Policy:        policy,
+		FirstDetected: &now,
+		LastChecked:   &now,
+		DetailsHash:   hash,
+	}
+}
+
+// key returns the issue key of the repo and policy of st, or "" if unknown.
+func (st issueState) key() string {
+	owner, repo, ok := strings.Cut(st.Repo, "/")
+	if !ok || st.Policy == "" {
+		return ""
+	}
+	return issueKey(owner, repo, st.Policy)
+}
+
+// String returns the state block of st.
+func (st issueState) String() string {
+	b, _ := json.Marshal(st)
+	return fmt.Sprintf(issueStateFormat, b)
+}
+
+// getIssueState returns the state in an issue body, or the zero state if it
+// has none or it is malformed.
+func getIssueState(body string) issueState {
+	var st issueState
+	m := issueStateRe.FindStringSubmatch(body)
+	if m == nil {
+		return st
+	}
+	if err := json.Unmarshal([]byte(m[1]), &st); err != nil {
+		return issueState{}
+	}
+	return st
+}
+
+// setIssueState returns body with its state block replaced with st, or added
+// at the end if it has none. The zero state is removed.
+func setIssueState(body string, st issueState) string {
+	var block string
+	if st != (issueState{}) {
+		block = st.String()
+	}
+	if issueStateRe.MatchString(body) {
+		return issueStateRe.ReplaceAllLiteralString(body, block)
+	}
+	if block == "" {
+		return body
+	}
+	return body + "\n" + block
+}
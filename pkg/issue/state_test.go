@@ -0,0 +1,96 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issue
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ossf/allstar/pkg/config/operator"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+)
+
+func TestIssueState(t *testing.T) {
+	at := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	st := issueState{Pings: 3, Escalated: &at}
+
+	body := setIssueState("Body", st)
+	if body != `Body
+<!-- Allstar state: {"pings":3,"escalated":"2025-09-01T12:00:00Z"} -->` {
+		t.Errorf("Unexpected body: %q", body)
+	}
+	if diff := cmp.Diff(st, getIssueState(body)); diff != "" {
+		t.Errorf("Unexpected state. (-want +got):\n%s", diff)
+	}
+	body = setIssueState(body, issueState{Pings: 4})
+	if got := getIssueState(body); got.Pings != 4 || got.Escalated != nil {
+		t.Errorf("Unexpected state: %+v", got)
+	}
+	if strings.Count(body, "Allstar state") != 1 {
+		t.Errorf("Expected state block to be replaced: %q", body)
+	}
+	if body = setIssueState(body, issueState{}); body != "Body\n" {
+		t.Errorf("Expected zero state to be removed: %q", body)
+	}
+	if got := getIssueState("<!-- Allstar state: {bad -->"); got != (issueState{}) {
+		t.Errorf("Unexpected state of malformed block: %+v", got)
+	}
+}
+
+func TestGetPolicyIssueByState(t *testing.T) {
+	title := "Renamed by a user"
+	other := setIssueState("Body", issueState{Repo: "org/other", Policy: "thispolicy"})
+	body := setIssueState("Body", issueState{Repo: "Org/Repo", Policy: "thispolicy"})
+	listByRepo = func(ctx context.Context, owner string, repo string,
+		opts *github.IssueListByRepoOptions) ([]*github.Issue, *github.Response, error) {
+		return []*github.Issue{
+			{Number: github.Int(1), Title: &title, Body: &other},
+			{Number: github.Int(2), Title: &title, Body: &body},
+		}, &github.Response{NextPage: 0}, nil
+	}
+	i, err := getPolicyIssue(context.Background(), mockIssues{}, "org", "repo", issueKey("org", "repo", "thispolicy"), "Security Policy violation thispolicy", "allstar")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if i.GetNumber() != 2 {
+		t.Errorf("Unexpected issue: %v", i.GetNumber())
+	}
+}
+
+func TestPingDue(t *testing.T) {
+	now := timeNow()
+	recent := now.Add(-time.Hour)
+	old := now.Add(-2 * operator.NoticePingDuration)
+	// Comments by users update the issue, but don't delay pings.
+	updated := &github.Issue{UpdatedAt: &github.Timestamp{Time: time.Now()}}
+	if !pingDue(updated, issueState{LastChecked: &old}, now) {
+		t.Error("Expected ping to be due")
+	}
+	if pingDue(updated, issueState{LastChecked: &recent}, now) {
+		t.Error("Expected ping to not be due")
+	}
+	// Issues without state fall back to the update time.
+	stale := &github.Issue{UpdatedAt: &github.Timestamp{Time: time.Now().Add(-2 * operator.NoticePingDuration)}}
+	if !pingDue(stale, issueState{}, now) {
+		t.Error("Expected ping to be due without state")
+	}
+	if pingDue(updated, issueState{}, now) {
+		t.Error("Expected ping to not be due without state")
+	}
+}
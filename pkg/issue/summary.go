@@ -0,0 +1,272 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/operator"
+	"github.com/ossf/allstar/pkg/enforceid"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+const summaryTitle = "Security Policy summary"
+
+// The failing repos of each policy are kept in the summary issue body, so
+// that runs of only some policies or repos can update it.
+const summarySectionName = "summary"
+const summaryDataPrefix = "<!-- Failing repos: "
+const summaryDataSuffix = " -->"
+
+const downgradedHeader = "\n### Missing Permissions\n\n"
+
+// SummaryRun is the results of an enforcement run on the repos of an org.
+type SummaryRun struct {
+	// Policies are the policies that were run, nil if all were run.
+	Policies map[string]bool
+
+	// Repo is the comma separated repo name patterns the run was limited to,
+	// empty if all repos were run on.
+	Repo string
+
+	// Results are the results of each policy on each repo.
+	Results []SummaryResult
+
+	// Downgraded describes the policies downgraded, by name, as the
+	// installation is missing permissions they need, eg: "not run, missing
+	// administration:read".
+	Downgraded map[string]string
+}
+
+// SummaryResult is the result of a policy on a repo.
+type SummaryResult struct {
+	Repo   string
+	Policy string
+	Pass   bool
+}
+
+var configGetOrgConfig func(context.Context, *github.Client, string) *config.OrgConfig
+
+func init() {
+	configGetOrgConfig = config.GetOrgConfig
+}
+
+// EnsureSummary updates the org-level summary issue with the results of an
+// enforcement run, if enabled in the org config. The issue is closed when no
+// repos are failing, and reopened when some are again.
+func EnsureSummary(ctx context.Context, c *github.Client, owner string, run *SummaryRun) error {
+	return ensureSummary(ctx, c, c.Issues, owner, run)
+}
+
+func ensureSummary(ctx context.Context, c *github.Client, issues issues, owner string, run *SummaryRun) error {
+	oc := configGetOrgConfig(ctx, c, owner)
+	if !oc.SummaryIssue.Enabled {
+		return nil
+	}
+	if oc.IssueRepo == "" {
+		log.Warn().
+			Str("org", owner).
+			Str("area", "bot").
+			Msg("Summary issue is enabled, but issueRepo is not set.")
+		return nil
+	}
+	label := operator.GitHubIssueLabel
+	if oc.IssueLabel != "" {
+		label = oc.IssueLabel
+	}
+	issue, err := getPolicyIssue(ctx, issues, owner, oc.IssueRepo, "", summaryTitle, label)
+	if err != nil {
+		return err
+	}
+	var prev map[string][]string
+	if issue != nil {
+		prev = getSummaryData(issue.GetBody())
+	}
+	failing := updateSummary(prev, run)
+	downgraded := downgradedSection(run.Downgraded)
+	body := createSummaryBody(ctx, owner, failing, downgraded, issueFooter(ctx, oc))
+	open := len(failing) > 0 || downgraded != ""
+
+	if issue == nil {
+		if !open {
+			return nil
+		}
+		title := summaryTitle
+		new := &github.IssueRequest{
+			Title:  &title,
+			Body:   &body,
+			Labels: &[]string{label},
+		}
+		_, rsp, err := issues.Create(ctx, owner, oc.IssueRepo, new)
+		if err != nil && rsp != nil && (rsp.StatusCode == http.StatusGone || rsp.StatusCode == http.StatusForbidden) {
+			log.Warn().
+				Str("org", owner).
+				Str("repo", oc.IssueRepo).
+				Str("area", "bot").
+				Msg("Summary issue is enabled, but issues are disabled.")
+			return nil
+		}
+		return err
+	}
+
+	wasOpen := issue.GetState() == "open"
+	sameDowngraded := strings.Contains(issue.GetBody(), downgraded)
+	if downgraded == "" {
+		sameDowngraded = !strings.Contains(issue.GetBody(), downgradedHeader)
+	}
+	if reflect.DeepEqual(prev, failing) && sameDowngraded && open == wasOpen {
+		return nil
+	}
+	update := &github.IssueRequest{
+		Body: &body,
+	}
+	if open != wasOpen {
+		state := "closed"
+		if open {
+			state = "open"
+		}
+		update.State = &state
+	}
+	if _, _, err := issues.Edit(ctx, owner, oc.IssueRepo, issue.GetNumber(), update); err != nil {
+		return fmt.Errorf("while updating summary issue %d: %w", issue.GetNumber(), err)
+	}
+	return nil
+}
+
+// updateSummary returns the failing repos of each policy, from the previous
+// failing repos updated with the results of run. Policies run on all repos
+// are replaced, otherwise only the repos run on are updated.
+func updateSummary(prev map[string][]string, run *SummaryRun) map[string][]string {
+	failing := make(map[string]map[string]bool)
+	for p, repos := range prev {
+		if run.Repo == "" && (run.Policies == nil || run.Policies[p]) {
+			continue
+		}
+		failing[p] = make(map[string]bool)
+		for _, r := range repos {
+			failing[p][r] = true
+		}
+	}
+	for _, r := range run.Results {
+		if failing[r.Policy] == nil {
+			failing[r.Policy] = make(map[string]bool)
+		}
+		if r.Pass {
+			delete(failing[r.Policy], r.Repo)
+		} else {
+			failing[r.Policy][r.Repo] = true
+		}
+	}
+	rv := make(map[string][]string)
+	for p, repos := range failing {
+		if len(repos) == 0 {
+			continue
+		}
+		l := make([]string, 0, len(repos))
+		for r := range repos {
+			l = append(l, r)
+		}
+		sort.Strings(l)
+		rv[p] = l
+	}
+	return rv
+}
+
+// downgradedSection returns the summary issue section listing the downgraded
+// policies, or an empty string if there are none.
+func downgradedSection(downgraded map[string]string) string {
+	if len(downgraded) == 0 {
+		return ""
+	}
+	policies := make([]string, 0, len(downgraded))
+	for p := range downgraded {
+		policies = append(policies, p)
+	}
+	sort.Strings(policies)
+	var b strings.Builder
+	b.WriteString(downgradedHeader)
+	b.WriteString("The Allstar installation is missing permissions needed by these policies. Grant them in the installation settings of the organization.\n\n")
+	for _, p := range policies {
+		fmt.Fprintf(&b, "- **%s**: %s\n", p, downgraded[p])
+	}
+	return b.String()
+}
+
+func createSummaryBody(ctx context.Context, owner string, failing map[string][]string, downgraded, footer string) string {
+	var b strings.Builder
+	b.WriteString("_This issue is automatically maintained by [Allstar](https://github.com/ossf/allstar/)._\n\n**Security Policy Summary**\n")
+	if len(failing) == 0 {
+		b.WriteString("All repositories are in compliance.\n")
+	}
+	policies := make([]string, 0, len(failing))
+	for p := range failing {
+		policies = append(policies, p)
+	}
+	sort.Strings(policies)
+	for _, p := range policies {
+		repos := failing[p]
+		fmt.Fprintf(&b, "\n### %s\n\n", p)
+		for i, r := range repos {
+			// Limit the repos listed, to keep the body under GitHub's size
+			// limit.
+			if i == operator.MaxSummaryRepos {
+				fmt.Fprintf(&b, "- and %d more\n", len(repos)-operator.MaxSummaryRepos)
+				break
+			}
+			ownerRepo := fmt.Sprintf("%s/%s", owner, r)
+			fmt.Fprintf(&b, "- [%s](https://github.com/%s)\n", ownerRepo, ownerRepo)
+		}
+	}
+	b.WriteString(downgraded)
+	data, _ := json.Marshal(failing)
+	header := issueSectionHeader(summarySectionName)
+	fmt.Fprintf(&b, "\n---\n\n%s%s%s%s%s\n", header, summaryDataPrefix, data, summaryDataSuffix, header)
+	if id := enforceid.Run(ctx); id != "" {
+		fmt.Fprintf(&b, "Updated by enforcement run %s.\n\n", id)
+	}
+	b.WriteString(footer)
+	return b.String()
+}
+
+// getSummaryData returns the failing repos of each policy stored in the body
+// of a summary issue.
+func getSummaryData(body string) map[string][]string {
+	section, ok := getIssueSection(body, summarySectionName)
+	if !ok {
+		return nil
+	}
+	data, ok := strings.CutPrefix(section, summaryDataPrefix)
+	if !ok {
+		return nil
+	}
+	data, ok = strings.CutSuffix(data, summaryDataSuffix)
+	if !ok {
+		return nil
+	}
+	var failing map[string][]string
+	if err := json.Unmarshal([]byte(data), &failing); err != nil {
+		return nil
+	}
+	return failing
+}
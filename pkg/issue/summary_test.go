@@ -0,0 +1,363 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issue
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/enforceid"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+)
+
+func TestUpdateSummary(t *testing.T) {
+	prev := map[string][]string{
+		"Branch Protection": {"repo1", "repo2"},
+		"SECURITY.md":       {"repo3"},
+	}
+	tests := []struct {
+		Name string
+		Run  SummaryRun
+		Exp  map[string][]string
+	}{
+		{
+			Name: "AllPolicies",
+			Run: SummaryRun{
+				Results: []SummaryResult{
+					{Repo: "repo2", Policy: "Branch Protection", Pass: false},
+					{Repo: "repo4", Policy: "Branch Protection", Pass: false},
+					{Repo: "repo3", Policy: "SECURITY.md", Pass: true},
+				},
+			},
+			Exp: map[string][]string{
+				"Branch Protection": {"repo2", "repo4"},
+			},
+		},
+		{
+			Name: "SomePolicies",
+			Run: SummaryRun{
+				Policies: map[string]bool{"Branch Protection": true},
+				Results: []SummaryResult{
+					{Repo: "repo4", Policy: "Branch Protection", Pass: false},
+				},
+			},
+			Exp: map[string][]string{
+				"Branch Protection": {"repo4"},
+				"SECURITY.md":       {"repo3"},
+			},
+		},
+		{
+			Name: "OneRepo",
+			Run: SummaryRun{
+				Repo: "repo1",
+				Results: []SummaryResult{
+					{Repo: "repo1", Policy: "Branch Protection", Pass: true},
+					{Repo: "repo1", Policy: "SECURITY.md", Pass: false},
+				},
+			},
+			Exp: map[string][]string{
+				"Branch Protection": {"repo2"},
+				"SECURITY.md":       {"repo1", "repo3"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got := updateSummary(prev, &test.Run)
+			if diff := cmp.Diff(test.Exp, got); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSummaryBody(t *testing.T) {
+	failing := map[string][]string{
+		"SECURITY.md":       {"repo3"},
+		"Branch Protection": {"repo1", "repo2"},
+	}
+	body := createSummaryBody(context.Background(), "thisorg", failing, "", "Footer")
+	exp := "_This issue is automatically maintained by [Allstar](https://github.com/ossf/allstar/)._\n\n**Security Policy Summary**\n" +
+		"\n### Branch Protection\n\n- [thisorg/repo1](https://github.com/thisorg/repo1)\n- [thisorg/repo2](https://github.com/thisorg/repo2)\n" +
+		"\n### SECURITY.md\n\n- [thisorg/repo3](https://github.com/thisorg/repo3)\n" +
+		"\n---\n\n<!-- Edit section #summary --><!-- Failing repos: {\"Branch Protection\":[\"repo1\",\"repo2\"],\"SECURITY.md\":[\"repo3\"]} --><!-- Edit section #summary -->\nFooter"
+	if body != exp {
+		t.Errorf("Unexpected body: %q expect: %q", body, exp)
+	}
+	if diff := cmp.Diff(failing, getSummaryData(body)); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+}
+
+func TestDowngradedSection(t *testing.T) {
+	if s := downgradedSection(nil); s != "" {
+		t.Errorf("Expected no section, got: %q", s)
+	}
+	s := downgradedSection(map[string]string{
+		"SECURITY.md":       "fix action downgraded to issue, missing contents:write",
+		"Branch Protection": "not run, missing administration:read",
+	})
+	exp := "\n### Missing Permissions\n\n" +
+		"The Allstar installation is missing permissions needed by these policies. Grant them in the installation settings of the organization.\n\n" +
+		"- **Branch Protection**: not run, missing administration:read\n" +
+		"- **SECURITY.md**: fix action downgraded to issue, missing contents:write\n"
+	if s != exp {
+		t.Errorf("Unexpected section: %q expect: %q", s, exp)
+	}
+}
+
+func TestEnsureSummary(t *testing.T) {
+	failingRun := &SummaryRun{
+		Results: []SummaryResult{
+			{Repo: "repo1", Policy: "thispolicy", Pass: false},
+		},
+	}
+	passingRun := &SummaryRun{
+		Results: []SummaryResult{
+			{Repo: "repo1", Policy: "thispolicy", Pass: true},
+		},
+	}
+	failingBody := createSummaryBody(context.Background(), "thisorg",
+		map[string][]string{"thispolicy": {"repo1"}}, "", "")
+	downgraded := map[string]string{"otherpolicy": "not run, missing administration:read"}
+	downgradedRun := &SummaryRun{
+		Results:    failingRun.Results,
+		Downgraded: downgraded,
+	}
+	downgradedBody := createSummaryBody(context.Background(), "thisorg",
+		map[string][]string{"thispolicy": {"repo1"}}, downgradedSection(downgraded), "")
+	tests := []struct {
+		Name      string
+		Org       config.OrgConfig
+		Issue     *github.Issue
+		Run       *SummaryRun
+		ExpCreate bool
+		ExpEdit   *github.IssueRequest
+	}{
+		{
+			Name: "Disabled",
+			Org: config.OrgConfig{
+				IssueRepo: "issuerepo",
+			},
+			Run: failingRun,
+		},
+		{
+			Name: "NoIssueRepo",
+			Org: config.OrgConfig{
+				SummaryIssue: config.SummaryIssueConfig{Enabled: true},
+			},
+			Run: failingRun,
+		},
+		{
+			Name: "Create",
+			Org: config.OrgConfig{
+				IssueRepo:    "issuerepo",
+				SummaryIssue: config.SummaryIssueConfig{Enabled: true},
+			},
+			Run:       failingRun,
+			ExpCreate: true,
+		},
+		{
+			Name: "NoCreatePassing",
+			Org: config.OrgConfig{
+				IssueRepo:    "issuerepo",
+				SummaryIssue: config.SummaryIssueConfig{Enabled: true},
+			},
+			Run: passingRun,
+		},
+		{
+			Name: "Unchanged",
+			Org: config.OrgConfig{
+				IssueRepo:    "issuerepo",
+				SummaryIssue: config.SummaryIssueConfig{Enabled: true},
+			},
+			Issue: &github.Issue{
+				Number: github.Int(1),
+				Title:  github.String(summaryTitle),
+				State:  github.String("open"),
+				Body:   &failingBody,
+			},
+			Run: failingRun,
+		},
+		{
+			Name: "CreateDowngraded",
+			Org: config.OrgConfig{
+				IssueRepo:    "issuerepo",
+				SummaryIssue: config.SummaryIssueConfig{Enabled: true},
+			},
+			Run: &SummaryRun{
+				Results:    passingRun.Results,
+				Downgraded: downgraded,
+			},
+			ExpCreate: true,
+		},
+		{
+			Name: "AddDowngraded",
+			Org: config.OrgConfig{
+				IssueRepo:    "issuerepo",
+				SummaryIssue: config.SummaryIssueConfig{Enabled: true},
+			},
+			Issue: &github.Issue{
+				Number: github.Int(1),
+				Title:  github.String(summaryTitle),
+				State:  github.String("open"),
+				Body:   &failingBody,
+			},
+			Run:     downgradedRun,
+			ExpEdit: &github.IssueRequest{},
+		},
+		{
+			Name: "UnchangedDowngraded",
+			Org: config.OrgConfig{
+				IssueRepo:    "issuerepo",
+				SummaryIssue: config.SummaryIssueConfig{Enabled: true},
+			},
+			Issue: &github.Issue{
+				Number: github.Int(1),
+				Title:  github.String(summaryTitle),
+				State:  github.String("open"),
+				Body:   &downgradedBody,
+			},
+			Run: downgradedRun,
+		},
+		{
+			Name: "RemoveDowngraded",
+			Org: config.OrgConfig{
+				IssueRepo:    "issuerepo",
+				SummaryIssue: config.SummaryIssueConfig{Enabled: true},
+			},
+			Issue: &github.Issue{
+				Number: github.Int(1),
+				Title:  github.String(summaryTitle),
+				State:  github.String("open"),
+				Body:   &downgradedBody,
+			},
+			Run:     failingRun,
+			ExpEdit: &github.IssueRequest{},
+		},
+		{
+			Name: "Close",
+			Org: config.OrgConfig{
+				IssueRepo:    "issuerepo",
+				SummaryIssue: config.SummaryIssueConfig{Enabled: true},
+			},
+			Issue: &github.Issue{
+				Number: github.Int(1),
+				Title:  github.String(summaryTitle),
+				State:  github.String("open"),
+				Body:   &failingBody,
+			},
+			Run: passingRun,
+			ExpEdit: &github.IssueRequest{
+				State: github.String("closed"),
+			},
+		},
+		{
+			Name: "Reopen",
+			Org: config.OrgConfig{
+				IssueRepo:    "issuerepo",
+				SummaryIssue: config.SummaryIssueConfig{Enabled: true},
+			},
+			Issue: &github.Issue{
+				Number: github.Int(1),
+				Title:  github.String(summaryTitle),
+				State:  github.String("closed"),
+				Body:   &failingBody,
+			},
+			Run: failingRun,
+			ExpEdit: &github.IssueRequest{
+				State: github.String("open"),
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configGetOrgConfig = func(context.Context, *github.Client, string) *config.OrgConfig {
+				return &test.Org
+			}
+			listByRepo = func(ctx context.Context, owner string, repo string,
+				opts *github.IssueListByRepoOptions) ([]*github.Issue, *github.Response, error) {
+				if repo != "issuerepo" {
+					t.Errorf("Unexpected issue repo: %v", repo)
+				}
+				if test.Issue == nil {
+					return nil, &github.Response{NextPage: 0}, nil
+				}
+				return []*github.Issue{test.Issue}, &github.Response{NextPage: 0}, nil
+			}
+			created := false
+			create = func(ctx context.Context, owner string, repo string,
+				issue *github.IssueRequest) (*github.Issue, *github.Response, error) {
+				if issue.GetTitle() != summaryTitle {
+					t.Errorf("Unexpected title: %v", issue.GetTitle())
+				}
+				if !strings.Contains(issue.GetBody(), "Updated by enforcement run ") {
+					t.Errorf("Expected run ID in body: %v", issue.GetBody())
+				}
+				created = true
+				return nil, nil, nil
+			}
+			var edited *github.IssueRequest
+			edit = func(ctx context.Context, owner string, repo string, number int,
+				issue *github.IssueRequest) (*github.Issue, *github.Response, error) {
+				edited = issue
+				return nil, nil, nil
+			}
+
+			ctx := enforceid.WithRun(context.Background())
+			if err := ensureSummary(ctx, nil, mockIssues{}, "thisorg", test.Run); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if created != test.ExpCreate {
+				t.Errorf("Unexpected create: %v", created)
+			}
+			if test.ExpEdit == nil {
+				if edited != nil {
+					t.Errorf("Unexpected edit: %v", edited)
+				}
+				return
+			}
+			if edited == nil {
+				t.Fatal("Expected issue to be edited")
+			}
+			if edited.GetState() != test.ExpEdit.GetState() {
+				t.Errorf("Unexpected state: %v", edited.GetState())
+			}
+		})
+	}
+}
+
+func TestEnsureRepoIssuesDisabled(t *testing.T) {
+	configGetAppConfigs = func(context.Context, *github.Client, string, string) (*config.OrgConfig, *config.RepoConfig, *config.RepoConfig) {
+		return &config.OrgConfig{
+			IssueRepo: "issuerepo",
+			SummaryIssue: config.SummaryIssueConfig{
+				Enabled:           true,
+				DisableRepoIssues: true,
+			},
+		}, &config.RepoConfig{}, &config.RepoConfig{}
+	}
+	listByRepo = nil
+	create = nil
+	edit = nil
+	createComment = nil
+	if _, err := ensure(context.Background(), nil, mockIssues{}, "thisorg", "thisrepo", "thispolicy", "Status text"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
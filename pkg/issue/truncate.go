@@ -0,0 +1,145 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issue
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ossf/allstar/pkg/config/operator"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+// truncationNote is appended to policy result text that was cut to fit the
+// issue size limit.
+const truncationNote = "\n\n_…and %d more lines, truncated to fit the issue size limit._"
+
+// fullDetailsNote is appended to truncationNote when the full text was
+// published elsewhere.
+const fullDetailsNote = " See the [full details](%s)."
+
+// detailsCheckRunName is the name of the check run the full text is published
+// to, with the policy name.
+const detailsCheckRunName = "Allstar: %s"
+
+var createDetailsCheckRun func(context.Context, *github.Client, string, string, string, string) (string, error)
+
+func init() {
+	createDetailsCheckRun = createDetailsCheckRunReal
+}
+
+// fitBody returns the issue body or comment built from the policy result
+// text. If it is larger than operator.MaxIssueBodySize, the text is truncated
+// to fit. If operator.IssueOverflowCheckRun is set, the full text is published
+// as a check run on the repo, and linked from the truncated text.
+func fitBody(ctx context.Context, c *github.Client, owner, repo, policy, text string, build func(string) string) string {
+	body := build(text)
+	if len(body) <= operator.MaxIssueBodySize {
+		return body
+	}
+	var url string
+	if operator.IssueOverflowCheckRun {
+		u, err := createDetailsCheckRun(ctx, c, owner, repo, policy, text)
+		if err != nil {
+			log.Warn().
+				Str("org", owner).
+				Str("repo", repo).
+				Str("area", policy).
+				Err(err).
+				Msg("Could not publish full policy result text as a check run.")
+		}
+		url = u
+	}
+	room := operator.MaxIssueBodySize - (len(body) - len(text))
+	return build(truncateText(text, room, url))
+}
+
+// truncateText cuts text at a line boundary, so that with the truncation note
+// it is at most room bytes. Text that fits is returned unchanged.
+func truncateText(text string, room int, url string) string {
+	if len(text) <= room {
+		return text
+	}
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	note := fmt.Sprintf(truncationNote, len(lines))
+	if url != "" {
+		note += fmt.Sprintf(fullDetailsNote, url)
+	}
+	// Reserve room for the note, and for closing a code block.
+	budget := room - len(note) - len("\n```")
+
+	var b strings.Builder
+	kept := 0
+	for _, l := range lines {
+		if b.Len()+len(l)+1 > budget {
+			break
+		}
+		b.WriteString(l)
+		b.WriteString("\n")
+		kept++
+	}
+	if kept == 0 && budget > 0 {
+		// The first line alone is too long, cut it without splitting a rune.
+		cut := budget
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		b.WriteString(text[:cut])
+		kept = 1
+	}
+	out := strings.TrimRight(b.String(), "\n")
+	if strings.Count(out, "```")%2 == 1 {
+		out += "\n```"
+	}
+	out += fmt.Sprintf(truncationNote, len(lines)-kept)
+	if url != "" {
+		out += fmt.Sprintf(fullDetailsNote, url)
+	}
+	return out
+}
+
+// createDetailsCheckRunReal publishes text as a completed check run on the
+// head of the default branch of the repo, and returns its URL.
+func createDetailsCheckRunReal(ctx context.Context, c *github.Client, owner, repo, policy, text string) (string, error) {
+	r, _, err := c.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return "", err
+	}
+	b, _, err := c.Repositories.GetBranch(ctx, owner, repo, r.GetDefaultBranch(), 1)
+	if err != nil {
+		return "", err
+	}
+	title := fmt.Sprintf("%s policy result", policy)
+	// The check run summary has the same size limit as issues.
+	summary := truncateText(text, operator.GitHubMaxIssueBodySize-1, "")
+	cr, _, err := c.Checks.CreateCheckRun(ctx, owner, repo, github.CreateCheckRunOptions{
+		Name:       fmt.Sprintf(detailsCheckRunName, policy),
+		HeadSHA:    b.GetCommit().GetSHA(),
+		Status:     github.String("completed"),
+		Conclusion: github.String("neutral"),
+		Output: &github.CheckRunOutput{
+			Title:   &title,
+			Summary: &summary,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	return cr.GetHTMLURL(), nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config/operator"
+)
+
+func TestTruncateText(t *testing.T) {
+	var lines []string
+	for i := 0; i < 20; i++ {
+		lines = append(lines, fmt.Sprintf("- branch-%02d", i))
+	}
+	branches := strings.Join(lines, "\n")
+	tests := []struct {
+		Name string
+		Text string
+		Room int
+		URL  string
+		Exp  string
+	}{
+		{
+			Name: "Fits",
+			Text: "line 1\nline 2\n",
+			Room: 100,
+			Exp:  "line 1\nline 2\n",
+		},
+		{
+			Name: "Lines",
+			Text: branches,
+			Room: 100,
+			Exp:  "- branch-00\n- branch-01\n\n_…and 18 more lines, truncated to fit the issue size limit._",
+		},
+		{
+			Name: "URL",
+			Text: branches,
+			Room: 150,
+			URL:  "https://example.com/run",
+			Exp: "- branch-00\n- branch-01\n\n_…and 18 more lines, truncated to fit the issue size limit._" +
+				" See the [full details](https://example.com/run).",
+		},
+		{
+			Name: "CodeBlock",
+			Text: "Found:\n```\n" + branches + "\n```\n",
+			Room: 100,
+			Exp:  "Found:\n```\n- branch-00\n```\n\n_…and 20 more lines, truncated to fit the issue size limit._",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got := truncateText(test.Text, test.Room, test.URL)
+			if got != test.Exp {
+				t.Errorf("Unexpected text: %q expect: %q", got, test.Exp)
+			}
+			if len(got) > test.Room {
+				t.Errorf("Text of %v bytes exceeds room of %v", len(got), test.Room)
+			}
+		})
+	}
+}
+
+func TestTruncateTextLongLine(t *testing.T) {
+	text := strings.Repeat("é", 100)
+	got := truncateText(text, 101, "")
+	if len(got) > 101 {
+		t.Errorf("Text of %v bytes exceeds room", len(got))
+	}
+	if !utf8.ValidString(got) {
+		t.Errorf("Rune split: %q", got)
+	}
+	if !strings.HasSuffix(got, fmt.Sprintf(truncationNote, 0)) {
+		t.Errorf("Missing truncation note: %q", got)
+	}
+}
+
+func TestFitBody(t *testing.T) {
+	defer func(size int, checkRun bool) {
+		operator.MaxIssueBodySize = size
+		operator.IssueOverflowCheckRun = checkRun
+	}(operator.MaxIssueBodySize, operator.IssueOverflowCheckRun)
+	operator.MaxIssueBodySize = 300
+
+	var lines []string
+	for i := 0; i < 100; i++ {
+		lines = append(lines, fmt.Sprintf("- branch-%d", i))
+	}
+	text := strings.Join(lines, "\n")
+	build := func(t string) string {
+		return "Header\n\n" + t + "\n\nFooter"
+	}
+
+	published := ""
+	createDetailsCheckRun = func(ctx context.Context, c *github.Client, owner, repo, policy, text string) (string, error) {
+		published = text
+		return "https://github.com/thisorg/thisrepo/runs/1", nil
+	}
+
+	operator.IssueOverflowCheckRun = false
+	body := fitBody(context.Background(), nil, "thisorg", "thisrepo", "thispolicy", text, build)
+	if len(body) > operator.MaxIssueBodySize {
+		t.Errorf("Body of %v bytes exceeds limit", len(body))
+	}
+	if !strings.HasPrefix(body, "Header\n\n- branch-0\n") || !strings.HasSuffix(body, "limit._\n\nFooter") {
+		t.Errorf("Unexpected body: %q", body)
+	}
+	if published != "" {
+		t.Errorf("Unexpected check run")
+	}
+
+	operator.IssueOverflowCheckRun = true
+	body = fitBody(context.Background(), nil, "thisorg", "thisrepo", "thispolicy", text, build)
+	if len(body) > operator.MaxIssueBodySize {
+		t.Errorf("Body of %v bytes exceeds limit", len(body))
+	}
+	if !strings.Contains(body, "See the [full details](https://github.com/thisorg/thisrepo/runs/1).") {
+		t.Errorf("Missing link to full details: %q", body)
+	}
+	if published != text {
+		t.Errorf("Unexpected published text: %q", published)
+	}
+
+	createDetailsCheckRun = func(ctx context.Context, c *github.Client, owner, repo, policy, text string) (string, error) {
+		return "", errors.New("forbidden")
+	}
+	body = fitBody(context.Background(), nil, "thisorg", "thisrepo", "thispolicy", text, build)
+	if len(body) > operator.MaxIssueBodySize || strings.Contains(body, "full details") {
+		t.Errorf("Unexpected body: %q", body)
+	}
+
+	short := build("Status text")
+	if got := fitBody(context.Background(), nil, "thisorg", "thisrepo", "thispolicy", "Status text", build); got != short {
+		t.Errorf("Unexpected body: %q expect: %q", got, short)
+	}
+}
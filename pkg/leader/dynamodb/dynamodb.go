@@ -0,0 +1,139 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dynamodb is a leader lock backend holding the lock in an Amazon
+// DynamoDB item, updated with conditional writes. Importing it registers the
+// "dynamodb" scheme, eg: "dynamodb://my-table/allstar?region=us-east-1". The
+// table must have a string partition key named "id". The default AWS
+// credentials are used.
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ossf/allstar/pkg/leader"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const scheme = "dynamodb"
+
+func init() {
+	leader.Register(scheme, func(ctx context.Context, u string) (leader.Lock, error) {
+		pu, err := url.Parse(u)
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimPrefix(pu.Path, "/")
+		if pu.Host == "" || name == "" {
+			return nil, fmt.Errorf("dynamodb: invalid URL %q, expected dynamodb://table/name", u)
+		}
+		return Open(ctx, pu.Host, name, pu.Query().Get("region"))
+	})
+}
+
+// api is the subset of the DynamoDB client used, replaced in tests.
+type api interface {
+	PutItem(ctx context.Context, in *dynamodb.PutItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	DeleteItem(ctx context.Context, in *dynamodb.DeleteItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+}
+
+// Lock is a leader.Lock held in a DynamoDB item.
+type Lock struct {
+	c     api
+	table string
+	name  string
+	now   func() time.Time
+}
+
+// Open opens the lock held in the item with id name in table. If region is
+// empty, the region from the default AWS configuration is used.
+func Open(ctx context.Context, table, name, region string) (*Lock, error) {
+	var opts []func(*config.LoadOptions) error
+	if region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Lock{
+		c:     dynamodb.NewFromConfig(cfg),
+		table: table,
+		name:  name,
+		now:   time.Now,
+	}, nil
+}
+
+// Acquire implements leader.Lock.
+func (l *Lock) Acquire(ctx context.Context, holder string, ttl time.Duration) (bool, error) {
+	now := l.now()
+	_, err := l.c.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(l.table),
+		Item: map[string]types.AttributeValue{
+			"id":      &types.AttributeValueMemberS{Value: l.name},
+			"holder":  &types.AttributeValueMemberS{Value: holder},
+			"expires": number(now.Add(ttl)),
+		},
+		ConditionExpression: aws.String("attribute_not_exists(id) OR holder = :holder OR expires < :now"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":holder": &types.AttributeValueMemberS{Value: holder},
+			":now":    number(now),
+		},
+	})
+	var cerr *types.ConditionalCheckFailedException
+	if errors.As(err, &cerr) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Release implements leader.Lock.
+func (l *Lock) Release(ctx context.Context, holder string) error {
+	_, err := l.c.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(l.table),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: l.name},
+		},
+		ConditionExpression: aws.String("holder = :holder"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":holder": &types.AttributeValueMemberS{Value: holder},
+		},
+	})
+	// Another holder took the lock already.
+	var cerr *types.ConditionalCheckFailedException
+	if errors.As(err, &cerr) {
+		return nil
+	}
+	return err
+}
+
+// Close implements leader.Lock.
+func (l *Lock) Close() error {
+	return nil
+}
+
+// number returns t as a DynamoDB number of Unix milliseconds.
+func number(t time.Time) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(t.UnixMilli(), 10)}
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dynamodb
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ossf/allstar/pkg/leader"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// fakeTable evaluates the condition expressions used by Lock against a single
+// item.
+type fakeTable struct {
+	item map[string]types.AttributeValue
+}
+
+func str(av types.AttributeValue) string {
+	if s, ok := av.(*types.AttributeValueMemberS); ok {
+		return s.Value
+	}
+	return ""
+}
+
+func num(av types.AttributeValue) int64 {
+	n, _ := strconv.ParseInt(av.(*types.AttributeValueMemberN).Value, 10, 64)
+	return n
+}
+
+func (f *fakeTable) PutItem(ctx context.Context, in *dynamodb.PutItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	if aws.ToString(in.ConditionExpression) != "attribute_not_exists(id) OR holder = :holder OR expires < :now" {
+		panic("unexpected condition")
+	}
+	v := in.ExpressionAttributeValues
+	if f.item != nil && str(f.item["holder"]) != str(v[":holder"]) && num(f.item["expires"]) >= num(v[":now"]) {
+		return nil, &types.ConditionalCheckFailedException{}
+	}
+	f.item = in.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeTable) DeleteItem(ctx context.Context, in *dynamodb.DeleteItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	if f.item == nil || str(f.item["holder"]) != str(in.ExpressionAttributeValues[":holder"]) {
+		return nil, &types.ConditionalCheckFailedException{}
+	}
+	f.item = nil
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func TestLock(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	tbl := &fakeTable{}
+	l := &Lock{c: tbl, table: "t", name: "allstar", now: func() time.Time { return now }}
+
+	if ok, err := l.Acquire(ctx, "a", time.Minute); !ok || err != nil {
+		t.Fatalf("Expected a to acquire free lock: %v", err)
+	}
+	if str(tbl.item["id"]) != "allstar" {
+		t.Errorf("Unexpected item id: %v", str(tbl.item["id"]))
+	}
+	if ok, err := l.Acquire(ctx, "b", time.Minute); ok || err != nil {
+		t.Fatalf("Expected b to not acquire held lock: %v", err)
+	}
+	if ok, _ := l.Acquire(ctx, "a", time.Minute); !ok {
+		t.Fatal("Expected a to renew its lock")
+	}
+	now = now.Add(2 * time.Minute)
+	if ok, _ := l.Acquire(ctx, "b", time.Minute); !ok {
+		t.Fatal("Expected b to acquire expired lock")
+	}
+	if err := l.Release(ctx, "a"); err != nil || tbl.item == nil {
+		t.Fatalf("Expected release by another holder to be ignored: %v", err)
+	}
+	if err := l.Release(ctx, "b"); err != nil || tbl.item != nil {
+		t.Fatalf("Expected lock to be released: %v", err)
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	if _, err := leader.Open(context.Background(), "dynamodb://table-only"); err == nil {
+		t.Error("Expected error for URL without lock name")
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leader
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// releaseTimeout is how long releasing the lock may take on shutdown.
+const releaseTimeout = 10 * time.Second
+
+// Elector campaigns for a Lock, running a function only while it holds it.
+type Elector struct {
+	lock    Lock
+	holder  string
+	ttl     time.Duration
+	retry   time.Duration
+	leading atomic.Bool
+}
+
+// NewElector returns an Elector campaigning for l as holder. The lock is held
+// for ttl, and renewed, or retried, every third of ttl.
+func NewElector(l Lock, holder string, ttl time.Duration) *Elector {
+	return &Elector{
+		lock:   l,
+		holder: holder,
+		ttl:    ttl,
+		retry:  ttl / 3,
+	}
+}
+
+// Holder returns an identity of this process for holding a lock, its host
+// name with a random suffix, so that restarts on the same host are different
+// holders.
+func Holder() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "allstar"
+	}
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return host + "-" + hex.EncodeToString(b)
+}
+
+// IsLeader returns whether the elector currently holds the lock.
+func (e *Elector) IsLeader() bool {
+	return e.leading.Load()
+}
+
+// Run campaigns for the lock until ctx is done. While the lock is held, lead
+// runs with a context that is cancelled when the lock is lost, or could not be
+// renewed before it may expire. Run waits for lead to return before
+// campaigning again. When ctx is done, or lead returns on its own, the lock is
+// released and Run returns the error of ctx or lead.
+func (e *Elector) Run(ctx context.Context, lead func(context.Context) error) error {
+	var cancel context.CancelFunc
+	var done chan error
+	var renewed time.Time
+	stop := func() {
+		if cancel == nil {
+			return
+		}
+		cancel()
+		<-done
+		cancel = nil
+		done = nil
+		e.leading.Store(false)
+	}
+	release := func() {
+		rctx, cf := context.WithTimeout(context.Background(), releaseTimeout)
+		defer cf()
+		if err := e.lock.Release(rctx, e.holder); err != nil {
+			log.Error().
+				Str("area", "leader").
+				Str("holder", e.holder).
+				Err(err).
+				Msg("Unexpected error releasing leader lock.")
+		}
+	}
+	for {
+		ok, err := e.lock.Acquire(ctx, e.holder, e.ttl)
+		now := time.Now()
+		switch {
+		case err != nil && ctx.Err() == nil:
+			log.Error().
+				Str("area", "leader").
+				Str("holder", e.holder).
+				Err(err).
+				Msg("Unexpected error acquiring leader lock.")
+			// Step down before the lock may expire, so that two replicas
+			// never lead at once.
+			if cancel != nil && !now.Before(renewed.Add(e.ttl-e.retry)) {
+				log.Warn().
+					Str("area", "leader").
+					Str("holder", e.holder).
+					Msg("Could not renew leader lock, no longer leading.")
+				stop()
+			}
+		case err != nil:
+		case ok:
+			renewed = now
+			if cancel == nil {
+				log.Info().
+					Str("area", "leader").
+					Str("holder", e.holder).
+					Msg("Acquired leader lock, leading.")
+				lctx, lcancel := context.WithCancel(ctx)
+				cancel = lcancel
+				done = make(chan error, 1)
+				e.leading.Store(true)
+				go func() {
+					done <- lead(lctx)
+				}()
+			}
+		case cancel != nil:
+			log.Warn().
+				Str("area", "leader").
+				Str("holder", e.holder).
+				Msg("Leader lock taken by another holder, no longer leading.")
+			stop()
+		}
+		select {
+		case <-ctx.Done():
+			stop()
+			release()
+			return ctx.Err()
+		case err := <-done:
+			cancel()
+			cancel = nil
+			done = nil
+			e.leading.Store(false)
+			release()
+			return err
+		case <-time.After(e.retry):
+		}
+	}
+}
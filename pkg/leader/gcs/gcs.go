@@ -0,0 +1,155 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gcs is a leader lock backend holding the lock in a Google Cloud
+// Storage object, updated with generation preconditions. Importing it
+// registers the "gs" scheme, eg: "gs://my-bucket/allstar/leader". The default
+// application credentials are used.
+package gcs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ossf/allstar/pkg/leader"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/googleapi"
+)
+
+const scheme = "gs"
+
+func init() {
+	leader.Register(scheme, func(ctx context.Context, url string) (leader.Lock, error) {
+		bucket, object, ok := strings.Cut(strings.TrimPrefix(url, scheme+"://"), "/")
+		if !ok || bucket == "" || object == "" {
+			return nil, fmt.Errorf("gcs: invalid URL %q, expected gs://bucket/object", url)
+		}
+		return Open(ctx, bucket, object)
+	})
+}
+
+// record is the content of the lock object.
+type record struct {
+	Holder  string    `json:"holder"`
+	Expires time.Time `json:"expires"`
+}
+
+// object reads and conditionally writes the lock object.
+type object interface {
+	// read returns the record and generation of the object, generation 0 if
+	// it does not exist.
+	read(ctx context.Context) (record, int64, error)
+
+	// write replaces the object if its generation is still gen, or creates
+	// it if gen is 0. Returns false if the precondition failed.
+	write(ctx context.Context, r record, gen int64) (bool, error)
+}
+
+// Lock is a leader.Lock held in a Cloud Storage object.
+type Lock struct {
+	obj    object
+	client *storage.Client
+	now    func() time.Time
+}
+
+// Open opens the lock held in object of bucket.
+func Open(ctx context.Context, bucket, object string) (*Lock, error) {
+	c, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Lock{
+		obj:    &gcsObject{h: c.Bucket(bucket).Object(object)},
+		client: c,
+		now:    time.Now,
+	}, nil
+}
+
+// Acquire implements leader.Lock.
+func (l *Lock) Acquire(ctx context.Context, holder string, ttl time.Duration) (bool, error) {
+	r, gen, err := l.obj.read(ctx)
+	if err != nil {
+		return false, err
+	}
+	now := l.now()
+	if gen != 0 && r.Holder != "" && r.Holder != holder && now.Before(r.Expires) {
+		return false, nil
+	}
+	return l.obj.write(ctx, record{Holder: holder, Expires: now.Add(ttl)}, gen)
+}
+
+// Release implements leader.Lock.
+func (l *Lock) Release(ctx context.Context, holder string) error {
+	r, gen, err := l.obj.read(ctx)
+	if err != nil || gen == 0 || r.Holder != holder {
+		return err
+	}
+	// If the precondition fails, another holder took the lock already.
+	_, err = l.obj.write(ctx, record{}, gen)
+	return err
+}
+
+// Close implements leader.Lock.
+func (l *Lock) Close() error {
+	if l.client == nil {
+		return nil
+	}
+	return l.client.Close()
+}
+
+type gcsObject struct {
+	h *storage.ObjectHandle
+}
+
+func (o *gcsObject) read(ctx context.Context) (record, int64, error) {
+	var r record
+	rd, err := o.h.NewReader(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return r, 0, nil
+	}
+	if err != nil {
+		return r, 0, err
+	}
+	defer rd.Close()
+	if err := json.NewDecoder(rd).Decode(&r); err != nil {
+		// A malformed object is treated as free, and replaced.
+		return record{}, rd.Attrs.Generation, nil
+	}
+	return r, rd.Attrs.Generation, nil
+}
+
+func (o *gcsObject) write(ctx context.Context, r record, gen int64) (bool, error) {
+	cond := storage.Conditions{GenerationMatch: gen}
+	if gen == 0 {
+		cond = storage.Conditions{DoesNotExist: true}
+	}
+	w := o.h.If(cond).NewWriter(ctx)
+	w.ContentType = "application/json"
+	if err := json.NewEncoder(w).Encode(r); err != nil {
+		w.Close()
+		return false, err
+	}
+	err := w.Close()
+	var gerr *googleapi.Error
+	if errors.As(err, &gerr) && gerr.Code == http.StatusPreconditionFailed {
+		return false, nil
+	}
+	return err == nil, err
+}
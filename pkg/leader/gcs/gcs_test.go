@@ -0,0 +1,97 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ossf/allstar/pkg/leader"
+)
+
+// fakeObject is an object with generation preconditions, like Cloud Storage.
+type fakeObject struct {
+	r   record
+	gen int64
+}
+
+func (f *fakeObject) read(ctx context.Context) (record, int64, error) {
+	return f.r, f.gen, nil
+}
+
+func (f *fakeObject) write(ctx context.Context, r record, gen int64) (bool, error) {
+	if gen != f.gen {
+		return false, nil
+	}
+	f.r = r
+	f.gen++
+	return true, nil
+}
+
+func TestLock(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	obj := &fakeObject{}
+	l := &Lock{obj: obj, now: func() time.Time { return now }}
+
+	if ok, err := l.Acquire(ctx, "a", time.Minute); !ok || err != nil {
+		t.Fatalf("Expected a to acquire free lock: %v", err)
+	}
+	if ok, _ := l.Acquire(ctx, "b", time.Minute); ok {
+		t.Fatal("Expected b to not acquire held lock")
+	}
+	if ok, _ := l.Acquire(ctx, "a", time.Minute); !ok {
+		t.Fatal("Expected a to renew its lock")
+	}
+	now = now.Add(2 * time.Minute)
+	if ok, _ := l.Acquire(ctx, "b", time.Minute); !ok {
+		t.Fatal("Expected b to acquire expired lock")
+	}
+	if err := l.Release(ctx, "a"); err != nil || obj.r.Holder != "b" {
+		t.Fatalf("Expected release by another holder to be ignored: %v", err)
+	}
+	if err := l.Release(ctx, "b"); err != nil || obj.r.Holder != "" {
+		t.Fatalf("Expected lock to be released: %v", err)
+	}
+	if ok, _ := l.Acquire(ctx, "a", time.Minute); !ok {
+		t.Fatal("Expected a to acquire released lock")
+	}
+
+	// A concurrent write between read and write loses the race.
+	stale := &racingObject{fakeObject: obj}
+	l.obj = stale
+	now = now.Add(2 * time.Minute)
+	if ok, _ := l.Acquire(ctx, "b", time.Minute); ok {
+		t.Fatal("Expected b to lose the race")
+	}
+}
+
+// racingObject is written by another holder after each read.
+type racingObject struct {
+	*fakeObject
+}
+
+func (r *racingObject) read(ctx context.Context) (record, int64, error) {
+	rec, gen, err := r.fakeObject.read(ctx)
+	r.fakeObject.gen++
+	return rec, gen, err
+}
+
+func TestOpenInvalid(t *testing.T) {
+	if _, err := leader.Open(context.Background(), "gs://bucket-only"); err == nil {
+		t.Error("Expected error for URL without object")
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package kubernetes is a leader lock backend holding the lock in a
+// Kubernetes coordination.k8s.io/v1 Lease, updated with resourceVersion
+// preconditions. Importing it registers the "kubernetes" scheme, eg:
+// "kubernetes://allstar/allstar-leader" for the Lease "allstar-leader" in
+// namespace "allstar". The in-cluster service account is used, it needs the
+// get, create, and update verbs on leases in the namespace.
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ossf/allstar/pkg/leader"
+)
+
+const scheme = "kubernetes"
+
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// microTime is the Kubernetes metav1.MicroTime serialization format.
+const microTime = "2006-01-02T15:04:05.000000Z07:00"
+
+func init() {
+	leader.Register(scheme, func(ctx context.Context, url string) (leader.Lock, error) {
+		ns, name, ok := strings.Cut(strings.TrimPrefix(url, scheme+"://"), "/")
+		if !ok || ns == "" || name == "" {
+			return nil, fmt.Errorf("kubernetes: invalid URL %q, expected kubernetes://namespace/name", url)
+		}
+		return Open(ns, name)
+	})
+}
+
+type metadata struct {
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+type leaseSpec struct {
+	HolderIdentity       *string `json:"holderIdentity,omitempty"`
+	LeaseDurationSeconds *int32  `json:"leaseDurationSeconds,omitempty"`
+	AcquireTime          *string `json:"acquireTime,omitempty"`
+	RenewTime            *string `json:"renewTime,omitempty"`
+}
+
+type lease struct {
+	APIVersion string    `json:"apiVersion"`
+	Kind       string    `json:"kind"`
+	Metadata   metadata  `json:"metadata"`
+	Spec       leaseSpec `json:"spec"`
+}
+
+// Lock is a leader.Lock held in a Kubernetes Lease.
+type Lock struct {
+	client    *http.Client
+	host      string
+	token     string
+	namespace string
+	name      string
+	now       func() time.Time
+}
+
+// Open opens the lock held in Lease name of namespace, with the in-cluster
+// service account.
+func Open(namespace, name string) (*Lock, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, errors.New("kubernetes: not running in a cluster, KUBERNETES_SERVICE_HOST or KUBERNETES_SERVICE_PORT not set")
+	}
+	token, err := os.ReadFile(filepath.Join(serviceAccountDir, "token"))
+	if err != nil {
+		return nil, err
+	}
+	ca, err := os.ReadFile(filepath.Join(serviceAccountDir, "ca.crt"))
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("kubernetes: no certificates in service account ca.crt")
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = &tls.Config{RootCAs: pool}
+	return &Lock{
+		client:    &http.Client{Transport: t, Timeout: 30 * time.Second},
+		host:      "https://" + net.JoinHostPort(host, port),
+		token:     strings.TrimSpace(string(token)),
+		namespace: namespace,
+		name:      name,
+		now:       time.Now,
+	}, nil
+}
+
+// Acquire implements leader.Lock.
+func (l *Lock) Acquire(ctx context.Context, holder string, ttl time.Duration) (bool, error) {
+	now := l.now()
+	ls, err := l.get(ctx)
+	if err != nil {
+		return false, err
+	}
+	seconds := int32(math.Ceil(ttl.Seconds()))
+	renew := now.UTC().Format(microTime)
+	if ls == nil {
+		return l.write(ctx, http.MethodPost, l.path(""), &lease{
+			APIVersion: "coordination.k8s.io/v1",
+			Kind:       "Lease",
+			Metadata:   metadata{Name: l.name, Namespace: l.namespace},
+			Spec: leaseSpec{
+				HolderIdentity:       &holder,
+				LeaseDurationSeconds: &seconds,
+				AcquireTime:          &renew,
+				RenewTime:            &renew,
+			},
+		})
+	}
+	cur := deref(ls.Spec.HolderIdentity)
+	if cur != "" && cur != holder && now.Before(expires(ls)) {
+		return false, nil
+	}
+	if cur != holder {
+		ls.Spec.AcquireTime = &renew
+	}
+	ls.Spec.HolderIdentity = &holder
+	ls.Spec.LeaseDurationSeconds = &seconds
+	ls.Spec.RenewTime = &renew
+	return l.write(ctx, http.MethodPut, l.path(l.name), ls)
+}
+
+// Release implements leader.Lock.
+func (l *Lock) Release(ctx context.Context, holder string) error {
+	ls, err := l.get(ctx)
+	if err != nil || ls == nil || deref(ls.Spec.HolderIdentity) != holder {
+		return err
+	}
+	// Clear the holder, as client-go does, rather than deleting the Lease,
+	// which may have been created with RBAC scoped to its name.
+	empty := ""
+	one := int32(1)
+	ls.Spec.HolderIdentity = &empty
+	ls.Spec.LeaseDurationSeconds = &one
+	// If the precondition fails, another holder took the lock already.
+	_, err = l.write(ctx, http.MethodPut, l.path(l.name), ls)
+	return err
+}
+
+// Close implements leader.Lock.
+func (l *Lock) Close() error {
+	l.client.CloseIdleConnections()
+	return nil
+}
+
+func (l *Lock) path(name string) string {
+	p := fmt.Sprintf("%v/apis/coordination.k8s.io/v1/namespaces/%v/leases", l.host, l.namespace)
+	if name != "" {
+		p += "/" + name
+	}
+	return p
+}
+
+func (l *Lock) do(ctx context.Context, method, url string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+l.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return l.client.Do(req)
+}
+
+// get returns the Lease, or nil if it does not exist.
+func (l *Lock) get(ctx context.Context) (*lease, error) {
+	resp, err := l.do(ctx, http.MethodGet, l.path(l.name), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
+	}
+	var ls lease
+	if err := json.NewDecoder(resp.Body).Decode(&ls); err != nil {
+		return nil, err
+	}
+	return &ls, nil
+}
+
+// write creates or updates the Lease. Returns false if another holder
+// created or updated it first.
+func (l *Lock) write(ctx context.Context, method, url string, ls *lease) (bool, error) {
+	b, err := json.Marshal(ls)
+	if err != nil {
+		return false, err
+	}
+	resp, err := l.do(ctx, method, url, bytes.NewReader(b))
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		return true, nil
+	case http.StatusConflict:
+		return false, nil
+	}
+	return false, statusError(resp)
+}
+
+func statusError(resp *http.Response) error {
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("kubernetes: %v %v: %v: %s", resp.Request.Method, resp.Request.URL.Path, resp.Status, b)
+}
+
+// expires returns when the Lease expires, or the zero time if it was never
+// renewed.
+func expires(ls *lease) time.Time {
+	if ls.Spec.RenewTime == nil || ls.Spec.LeaseDurationSeconds == nil {
+		return time.Time{}
+	}
+	t, err := time.Parse(microTime, *ls.Spec.RenewTime)
+	if err != nil {
+		return time.Time{}
+	}
+	return t.Add(time.Duration(*ls.Spec.LeaseDurationSeconds) * time.Second)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,149 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ossf/allstar/pkg/leader"
+)
+
+// fakeAPIServer serves a single Lease with resourceVersion preconditions.
+type fakeAPIServer struct {
+	mu      sync.Mutex
+	lease   *lease
+	version int
+}
+
+func (f *fakeAPIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	const base = "/apis/coordination.k8s.io/v1/namespaces/allstar/leases"
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == base+"/leader":
+		if f.lease == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(f.lease)
+	case r.Method == http.MethodPost && r.URL.Path == base:
+		if f.lease != nil {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		f.store(w, r, http.StatusCreated)
+	case r.Method == http.MethodPut && r.URL.Path == base+"/leader":
+		var ls lease
+		json.NewDecoder(r.Body).Decode(&ls)
+		if f.lease == nil || ls.Metadata.ResourceVersion != f.lease.Metadata.ResourceVersion {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		f.lease = &ls
+		f.version++
+		f.lease.Metadata.ResourceVersion = strconv.Itoa(f.version)
+		json.NewEncoder(w).Encode(f.lease)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeAPIServer) store(w http.ResponseWriter, r *http.Request, status int) {
+	var ls lease
+	json.NewDecoder(r.Body).Decode(&ls)
+	f.lease = &ls
+	f.version++
+	f.lease.Metadata.ResourceVersion = strconv.Itoa(f.version)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(f.lease)
+}
+
+func (f *fakeAPIServer) holder() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.lease == nil {
+		return ""
+	}
+	return deref(f.lease.Spec.HolderIdentity)
+}
+
+func TestLock(t *testing.T) {
+	ctx := context.Background()
+	api := &fakeAPIServer{}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+	now := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	l := &Lock{
+		client:    srv.Client(),
+		host:      srv.URL,
+		token:     "token",
+		namespace: "allstar",
+		name:      "leader",
+		now:       func() time.Time { return now },
+	}
+
+	if ok, err := l.Acquire(ctx, "a", time.Minute); !ok || err != nil {
+		t.Fatalf("Expected a to create and acquire lease: %v", err)
+	}
+	if got := *api.lease.Spec.LeaseDurationSeconds; got != 60 {
+		t.Errorf("Unexpected lease duration: %v", got)
+	}
+	if ok, err := l.Acquire(ctx, "b", time.Minute); ok || err != nil {
+		t.Fatalf("Expected b to not acquire held lease: %v", err)
+	}
+	now = now.Add(30 * time.Second)
+	if ok, _ := l.Acquire(ctx, "a", time.Minute); !ok {
+		t.Fatal("Expected a to renew its lease")
+	}
+	now = now.Add(45 * time.Second)
+	if ok, _ := l.Acquire(ctx, "b", time.Minute); ok {
+		t.Fatal("Expected b to not acquire renewed lease")
+	}
+	now = now.Add(time.Minute)
+	if ok, _ := l.Acquire(ctx, "b", time.Minute); !ok {
+		t.Fatal("Expected b to acquire expired lease")
+	}
+	if err := l.Release(ctx, "a"); err != nil || api.holder() != "b" {
+		t.Fatalf("Expected release by another holder to be ignored: %v", err)
+	}
+	if err := l.Release(ctx, "b"); err != nil || api.holder() != "" {
+		t.Fatalf("Expected lease to be released: %v", err)
+	}
+	if ok, _ := l.Acquire(ctx, "a", time.Minute); !ok {
+		t.Fatal("Expected a to acquire released lease")
+	}
+
+	l.token = "wrong"
+	if _, err := l.Acquire(ctx, "a", time.Minute); err == nil {
+		t.Error("Expected error for unauthorized request")
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	if _, err := leader.Open(context.Background(), "kubernetes://namespace-only"); err == nil {
+		t.Error("Expected error for URL without lease name")
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package leader elects a single Allstar replica, out of several deployed for
+// availability, to run the enforcement loop, with a lock held for a limited
+// time and renewed while the replica runs. Other replicas stay on hot standby,
+// serving the operator API, and take over when the lock expires.
+//
+// Lock backends implement Lock and register an opener for their URL scheme
+// with Register, as with the state package. The in-memory backend, "mem://",
+// is always registered, for tests. See the gcs, dynamodb, and kubernetes
+// subpackages for distributed backends.
+package leader
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Lock is implemented by lock backends. Implementations must be safe for
+// concurrent use.
+type Lock interface {
+	// Acquire takes the lock for holder until ttl from now, or renews it if
+	// holder already holds it. Returns false, without error, if another
+	// holder holds the lock and it has not expired.
+	Acquire(ctx context.Context, holder string, ttl time.Duration) (bool, error)
+
+	// Release releases the lock, if held by holder, so that another holder
+	// can take it without waiting for it to expire.
+	Release(ctx context.Context, holder string) error
+
+	// Close releases any resources held by the backend.
+	Close() error
+}
+
+// Opener opens a backend from a URL, eg: "gs://my-bucket/allstar/leader".
+type Opener func(ctx context.Context, url string) (Lock, error)
+
+var openers = make(map[string]Opener)
+var openersMu sync.Mutex
+
+func init() {
+	Register("mem", func(ctx context.Context, url string) (Lock, error) {
+		return NewMemory(), nil
+	})
+}
+
+// Register makes a backend available to Open for URLs with the provided
+// scheme. It panics if the scheme is registered twice.
+func Register(scheme string, o Opener) {
+	openersMu.Lock()
+	defer openersMu.Unlock()
+	if _, ok := openers[scheme]; ok {
+		panic(fmt.Sprintf("leader: Register called twice for scheme %v", scheme))
+	}
+	openers[scheme] = o
+}
+
+// Open opens the backend registered for the scheme of url.
+func Open(ctx context.Context, url string) (Lock, error) {
+	scheme, _, ok := strings.Cut(url, "://")
+	if !ok {
+		return nil, fmt.Errorf("leader: invalid URL %q, expected scheme://", url)
+	}
+	openersMu.Lock()
+	o, ok := openers[scheme]
+	openersMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("leader: unknown scheme %q, registered: %v", scheme, schemes())
+	}
+	return o(ctx, url)
+}
+
+func schemes() []string {
+	openersMu.Lock()
+	defer openersMu.Unlock()
+	var s []string
+	for k := range openers {
+		s = append(s, k)
+	}
+	sort.Strings(s)
+	return s
+}
+
+// Memory is an in-memory Lock, shared by the holders in one process, for
+// tests.
+type Memory struct {
+	mu      sync.Mutex
+	holder  string
+	expires time.Time
+	now     func() time.Time
+}
+
+// NewMemory returns an unheld in-memory lock.
+func NewMemory() *Memory {
+	return &Memory{now: time.Now}
+}
+
+// Acquire implements Lock.
+func (m *Memory) Acquire(ctx context.Context, holder string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	if m.holder != "" && m.holder != holder && now.Before(m.expires) {
+		return false, nil
+	}
+	m.holder = holder
+	m.expires = now.Add(ttl)
+	return true, nil
+}
+
+// Release implements Lock.
+func (m *Memory) Release(ctx context.Context, holder string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.holder == holder {
+		m.holder = ""
+	}
+	return nil
+}
+
+// Close implements Lock.
+func (m *Memory) Close() error {
+	return nil
+}
@@ -0,0 +1,174 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leader
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+
+	if ok, _ := m.Acquire(ctx, "a", time.Minute); !ok {
+		t.Fatal("Expected a to acquire free lock")
+	}
+	if ok, _ := m.Acquire(ctx, "b", time.Minute); ok {
+		t.Fatal("Expected b to not acquire held lock")
+	}
+	if ok, _ := m.Acquire(ctx, "a", time.Minute); !ok {
+		t.Fatal("Expected a to renew its lock")
+	}
+	now = now.Add(2 * time.Minute)
+	if ok, _ := m.Acquire(ctx, "b", time.Minute); !ok {
+		t.Fatal("Expected b to acquire expired lock")
+	}
+	if err := m.Release(ctx, "a"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ok, _ := m.Acquire(ctx, "a", time.Minute); ok {
+		t.Fatal("Expected release by another holder to be ignored")
+	}
+	if err := m.Release(ctx, "b"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ok, _ := m.Acquire(ctx, "a", time.Minute); !ok {
+		t.Fatal("Expected a to acquire released lock")
+	}
+}
+
+func TestOpen(t *testing.T) {
+	if l, err := Open(context.Background(), "mem://"); err != nil || l == nil {
+		t.Errorf("Unexpected error opening memory backend: %v", err)
+	}
+	if _, err := Open(context.Background(), "other://somewhere"); err == nil {
+		t.Errorf("Expected error for unknown scheme")
+	}
+	if _, err := Open(context.Background(), "somewhere"); err == nil {
+		t.Errorf("Expected error for missing scheme")
+	}
+}
+
+// waitFor polls cond until it is true, or fails the test after a second.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %v", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestElectorFailover(t *testing.T) {
+	l := NewMemory()
+	ttl := 60 * time.Millisecond
+	e1 := NewElector(l, "one", ttl)
+	e2 := NewElector(l, "two", ttl)
+	lead := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	ctx1, cf1 := context.WithCancel(context.Background())
+	done1 := make(chan error, 1)
+	go func() { done1 <- e1.Run(ctx1, lead) }()
+	waitFor(t, "one to lead", e1.IsLeader)
+
+	ctx2, cf2 := context.WithCancel(context.Background())
+	defer cf2()
+	done2 := make(chan error, 1)
+	go func() { done2 <- e2.Run(ctx2, lead) }()
+	time.Sleep(2 * ttl)
+	if e2.IsLeader() {
+		t.Fatal("Expected only one leader")
+	}
+
+	// Shutting down releases the lock to the standby.
+	cf1()
+	if err := <-done1; !errors.Is(err, context.Canceled) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if e1.IsLeader() {
+		t.Error("Expected one to no longer lead")
+	}
+	waitFor(t, "two to lead", e2.IsLeader)
+
+	// Losing the lock stops leading.
+	l.mu.Lock()
+	l.holder = "three"
+	l.expires = time.Now().Add(time.Hour)
+	l.mu.Unlock()
+	waitFor(t, "two to step down", func() bool { return !e2.IsLeader() })
+	cf2()
+	<-done2
+}
+
+type failingLock struct {
+	Memory
+	fail bool
+}
+
+func (f *failingLock) Acquire(ctx context.Context, holder string, ttl time.Duration) (bool, error) {
+	f.mu.Lock()
+	fail := f.fail
+	f.mu.Unlock()
+	if fail {
+		return false, errors.New("unavailable")
+	}
+	return f.Memory.Acquire(ctx, holder, ttl)
+}
+
+func TestElectorRenewFailure(t *testing.T) {
+	l := &failingLock{Memory: Memory{now: time.Now}}
+	e := NewElector(l, "one", 60*time.Millisecond)
+	stopped := make(chan struct{})
+	ctx, cf := context.WithCancel(context.Background())
+	defer cf()
+	go func() {
+		_ = e.Run(ctx, func(ctx context.Context) error {
+			<-ctx.Done()
+			close(stopped)
+			return nil
+		})
+	}()
+	waitFor(t, "one to lead", e.IsLeader)
+	l.mu.Lock()
+	l.fail = true
+	l.mu.Unlock()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Expected leading to stop when the lock can not be renewed")
+	}
+}
+
+func TestElectorLeadReturns(t *testing.T) {
+	l := NewMemory()
+	e := NewElector(l, "one", time.Minute)
+	want := errors.New("done")
+	if err := e.Run(context.Background(), func(ctx context.Context) error { return want }); !errors.Is(err, want) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if ok, _ := l.Acquire(context.Background(), "two", time.Minute); !ok {
+		t.Error("Expected lock to be released")
+	}
+}
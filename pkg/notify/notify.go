@@ -0,0 +1,255 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package notify implements the "notify" action, which POSTs policy
+// violations to a Slack incoming webhook or a generic HTTP endpoint.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/operator"
+	"github.com/ossf/allstar/pkg/config/schedule"
+	"github.com/ossf/allstar/pkg/enforceid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/google/go-github/v59/github"
+)
+
+const typeSlack = "slack"
+const typeWebhook = "webhook"
+
+const defaultTemplate = `Allstar policy violation for repository {{.Owner}}/{{.Repo}}: {{.Policy}}
+
+{{.Text}}`
+
+// operatorTemplate is used for alerts to the operator, which are about an
+// installation rather than a repository.
+const operatorTemplate = `Allstar operator alert for {{.Owner}}: {{.Policy}}
+
+{{.Text}}`
+
+// Payload is the JSON body POSTed to generic webhook endpoints.
+type Payload struct {
+	Owner   string `json:"owner"`
+	Repo    string `json:"repo"`
+	Policy  string `json:"policy"`
+	Text    string `json:"text"`
+	Message string `json:"message"`
+
+	// RunID and EnforcementID identify the enforcement run and repo
+	// evaluation that sent the notification, if any.
+	RunID         string `json:"runId,omitempty"`
+	EnforcementID string `json:"enforcementId,omitempty"`
+}
+
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+type sentRecord struct {
+	hash string
+	at   time.Time
+}
+
+var configGetAppConfigs func(context.Context, *github.Client, string, string) (*config.OrgConfig, *config.RepoConfig, *config.RepoConfig)
+var scheduleShouldPerform func(*config.ScheduleConfig) bool
+var timeNow func() time.Time
+var httpClient *http.Client
+
+// sent records the last notification per repo and policy, so that the same
+// violation is only re-sent after operator.NoticePingDuration, similar to
+// issue pings.
+var sent map[string]sentRecord
+var sentMu sync.Mutex
+
+func init() {
+	configGetAppConfigs = config.GetAppConfigs
+	scheduleShouldPerform = schedule.ShouldPerform
+	timeNow = time.Now
+	httpClient = &http.Client{Timeout: 30 * time.Second}
+	sent = make(map[string]sentRecord)
+}
+
+// Send sends a notification of the policy violation for the provided repo to
+// the configured endpoint. Repeated calls with the same text are only sent
+// once per ping interval.
+func Send(ctx context.Context, c *github.Client, owner, repo, policy, text string) error {
+	oc, orc, rc := configGetAppConfigs(ctx, c, owner, repo)
+	nc := mergeNotifyConfig(oc, orc, rc)
+	if nc == nil || nc.URL == "" {
+		log.Warn().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", policy).
+			Msg("Action set to notify, but no notify endpoint is configured.")
+		return nil
+	}
+	osc := schedule.MergeSchedules(oc.Schedule, orc.Schedule, rc.Schedule)
+	if !scheduleShouldPerform(osc) {
+		return nil
+	}
+	key := fmt.Sprintf("%s/%s/%s", owner, repo, policy)
+	hash := hashText(text)
+	sentMu.Lock()
+	last, ok := sent[key]
+	sentMu.Unlock()
+	if ok && last.hash == hash && last.at.After(timeNow().Add(-1*operator.NoticePingDuration)) {
+		return nil
+	}
+	if err := send(ctx, nc, owner, repo, policy, text); err != nil {
+		return err
+	}
+	sentMu.Lock()
+	sent[key] = sentRecord{hash: hash, at: timeNow()}
+	sentMu.Unlock()
+	return nil
+}
+
+// Escalate sends a notification that the issue for the provided repo and
+// policy was escalated to the configured endpoint. Unlike Send, it is sent
+// immediately, as an issue is only escalated once.
+func Escalate(ctx context.Context, c *github.Client, owner, repo, policy, text string) error {
+	oc, orc, rc := configGetAppConfigs(ctx, c, owner, repo)
+	nc := mergeNotifyConfig(oc, orc, rc)
+	if nc == nil || nc.URL == "" {
+		log.Warn().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", policy).
+			Msg("Escalation set to notify, but no notify endpoint is configured.")
+		return nil
+	}
+	return send(ctx, nc, owner, repo, policy, text)
+}
+
+// Clear forgets any previous notification for the provided repo and policy,
+// so that a future violation is sent immediately.
+func Clear(owner, repo, policy string) {
+	sentMu.Lock()
+	delete(sent, fmt.Sprintf("%s/%s/%s", owner, repo, policy))
+	sentMu.Unlock()
+}
+
+// SendOperator alerts the operator of an event for the provided owner, such as
+// an installation being suspended, at operator.OperatorNotifyURL. Nothing is
+// sent if no operator endpoint is configured.
+func SendOperator(ctx context.Context, owner, event, text string) error {
+	if operator.OperatorNotifyURL == "" {
+		return nil
+	}
+	nc := &config.NotifyConfig{
+		Type:     operator.OperatorNotifyType,
+		URL:      operator.OperatorNotifyURL,
+		Template: operatorTemplate,
+	}
+	return send(ctx, nc, owner, "", event, text)
+}
+
+func send(ctx context.Context, nc *config.NotifyConfig, owner, repo, policy, text string) error {
+	tmpl := nc.Template
+	if tmpl == "" {
+		tmpl = defaultTemplate
+	}
+	p := Payload{
+		Owner:         owner,
+		Repo:          repo,
+		Policy:        policy,
+		Text:          text,
+		RunID:         enforceid.Run(ctx),
+		EnforcementID: enforceid.Evaluation(ctx),
+	}
+	msg, err := render(tmpl, p)
+	if err != nil {
+		return fmt.Errorf("rendering notify template: %w", err)
+	}
+	p.Message = msg
+
+	var body []byte
+	switch strings.ToLower(nc.Type) {
+	case typeSlack:
+		body, err = json.Marshal(slackPayload{Text: msg})
+	case typeWebhook, "":
+		body, err = json.Marshal(p)
+	default:
+		return fmt.Errorf("unknown notify type %q", nc.Type)
+	}
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, nc.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	rsp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return fmt.Errorf("notify endpoint returned %v", rsp.Status)
+	}
+	log.Info().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", policy).
+		Str("type", nc.Type).
+		Fields(enforceid.Fields(ctx)).
+		Msg("Sent policy violation notification.")
+	return nil
+}
+
+func render(tmpl string, p Payload) (string, error) {
+	t, err := template.New("notify").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, p); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func hashText(text string) string {
+	h := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(h[:])
+}
+
+// mergeNotifyConfig gets the preferred NotifyConfig. Repo-level config in the
+// repository itself is ignored when the org disables repo override, as the
+// endpoint should be controlled by org security managers.
+func mergeNotifyConfig(oc *config.OrgConfig, orc, rc *config.RepoConfig) *config.NotifyConfig {
+	nc := oc.Notify
+	if orc.Notify != nil {
+		nc = orc.Notify
+	}
+	if rc.Notify != nil && !oc.OptConfig.DisableRepoOverride {
+		nc = rc.Notify
+	}
+	return nc
+}
@@ -0,0 +1,244 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/operator"
+	"github.com/ossf/allstar/pkg/enforceid"
+)
+
+func TestSend(t *testing.T) {
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+	}))
+	defer srv.Close()
+
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	scheduleShouldPerform = func(*config.ScheduleConfig) bool { return true }
+	evalCtx := enforceid.WithEvaluation(enforceid.WithRun(context.Background()))
+
+	tests := []struct {
+		Name    string
+		Org     config.OrgConfig
+		Repo    config.RepoConfig
+		Ctx     context.Context
+		Exp     interface{}
+		ExpSent bool
+	}{
+		{
+			Name:    "NotConfigured",
+			ExpSent: false,
+		},
+		{
+			Name: "Webhook",
+			Org: config.OrgConfig{
+				Notify: &config.NotifyConfig{URL: srv.URL},
+			},
+			ExpSent: true,
+			Exp: &Payload{
+				Owner:   "thisorg",
+				Repo:    "thisrepo",
+				Policy:  "thispolicy",
+				Text:    "Status text",
+				Message: "Allstar policy violation for repository thisorg/thisrepo: thispolicy\n\nStatus text",
+			},
+		},
+		{
+			Name: "EnforcementID",
+			Org: config.OrgConfig{
+				Notify: &config.NotifyConfig{URL: srv.URL},
+			},
+			Ctx:     evalCtx,
+			ExpSent: true,
+			Exp: &Payload{
+				Owner:         "thisorg",
+				Repo:          "thisrepo",
+				Policy:        "thispolicy",
+				Text:          "Status text",
+				Message:       "Allstar policy violation for repository thisorg/thisrepo: thispolicy\n\nStatus text",
+				RunID:         enforceid.Run(evalCtx),
+				EnforcementID: enforceid.Evaluation(evalCtx),
+			},
+		},
+		{
+			Name: "SlackTemplate",
+			Org: config.OrgConfig{
+				Notify: &config.NotifyConfig{
+					Type:     "slack",
+					URL:      srv.URL,
+					Template: "{{.Repo}} failed {{.Policy}}",
+				},
+			},
+			ExpSent: true,
+			Exp:     &slackPayload{Text: "thisrepo failed thispolicy"},
+		},
+		{
+			Name: "RepoOverrideDisallowed",
+			Org: config.OrgConfig{
+				OptConfig: config.OrgOptConfig{DisableRepoOverride: true},
+			},
+			Repo: config.RepoConfig{
+				Notify: &config.NotifyConfig{URL: srv.URL},
+			},
+			ExpSent: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			bodies = nil
+			Clear("thisorg", "thisrepo", "thispolicy")
+			configGetAppConfigs = func(context.Context, *github.Client, string, string) (*config.OrgConfig, *config.RepoConfig, *config.RepoConfig) {
+				return &test.Org, &config.RepoConfig{}, &test.Repo
+			}
+			ctx := test.Ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			if err := Send(ctx, nil, "thisorg", "thisrepo", "thispolicy", "Status text"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !test.ExpSent {
+				if len(bodies) != 0 {
+					t.Fatalf("Unexpected notification: %v", bodies)
+				}
+				return
+			}
+			if len(bodies) != 1 {
+				t.Fatalf("Expected one notification, got %v", len(bodies))
+			}
+			var got interface{}
+			switch test.Exp.(type) {
+			case *Payload:
+				got = &Payload{}
+			case *slackPayload:
+				got = &slackPayload{}
+			}
+			if err := json.Unmarshal([]byte(bodies[0]), got); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.Exp, got); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSendDedupe(t *testing.T) {
+	count := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+	}))
+	defer srv.Close()
+
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	scheduleShouldPerform = func(*config.ScheduleConfig) bool { return true }
+	configGetAppConfigs = func(context.Context, *github.Client, string, string) (*config.OrgConfig, *config.RepoConfig, *config.RepoConfig) {
+		return &config.OrgConfig{Notify: &config.NotifyConfig{URL: srv.URL}}, &config.RepoConfig{}, &config.RepoConfig{}
+	}
+	Clear("o", "r", "p")
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := Send(ctx, nil, "o", "r", "p", "same"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if count != 1 {
+		t.Errorf("Expected identical text to be sent once, sent %v times", count)
+	}
+	if err := Send(ctx, nil, "o", "r", "p", "changed"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected changed text to be sent, sent %v times", count)
+	}
+	now = now.Add(48 * time.Hour)
+	if err := Send(ctx, nil, "o", "r", "p", "changed"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected re-send after ping interval, sent %v times", count)
+	}
+}
+
+func TestSendError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	scheduleShouldPerform = func(*config.ScheduleConfig) bool { return true }
+	configGetAppConfigs = func(context.Context, *github.Client, string, string) (*config.OrgConfig, *config.RepoConfig, *config.RepoConfig) {
+		return &config.OrgConfig{Notify: &config.NotifyConfig{URL: srv.URL}}, &config.RepoConfig{}, &config.RepoConfig{}
+	}
+	Clear("o", "r", "p")
+	if err := Send(context.Background(), nil, "o", "r", "p", "text"); err == nil {
+		t.Error("Expected error on non-2xx response")
+	}
+}
+
+func TestSendOperator(t *testing.T) {
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+	}))
+	defer srv.Close()
+	defer func(url string) { operator.OperatorNotifyURL = url }(operator.OperatorNotifyURL)
+
+	operator.OperatorNotifyURL = ""
+	if err := SendOperator(context.Background(), "thisorg", "Installation suspended", "text"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(bodies) != 0 {
+		t.Fatalf("Unexpected notification: %v", bodies)
+	}
+
+	operator.OperatorNotifyURL = srv.URL
+	if err := SendOperator(context.Background(), "thisorg", "Installation suspended", "text"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(bodies) != 1 {
+		t.Fatalf("Expected one notification, got %v", len(bodies))
+	}
+	var got Payload
+	if err := json.Unmarshal([]byte(bodies[0]), &got); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	exp := Payload{
+		Owner:   "thisorg",
+		Policy:  "Installation suspended",
+		Text:    "text",
+		Message: "Allstar operator alert for thisorg: Installation suspended\n\ntext",
+	}
+	if diff := cmp.Diff(exp, got); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,310 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ocsf translates the results of an enforcement run into Open
+// Cybersecurity Schema Framework (OCSF) Compliance Finding events, for
+// ingestion by a SIEM. See https://schema.ocsf.io/1.1.0/classes/compliance_finding
+package ocsf
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ossf/allstar/pkg/storage"
+)
+
+// SchemaVersion is the version of the OCSF schema the events conform to.
+const SchemaVersion = "1.1.0"
+
+// Class, category, and activity of the events, as defined by the schema.
+const (
+	classUID     = 2003
+	className    = "Compliance Finding"
+	categoryUID  = 2
+	categoryName = "Findings"
+	activityID   = 1
+	activityName = "Create"
+)
+
+// Compliance status IDs, as defined by the schema.
+const (
+	complianceStatusPass    = 1
+	complianceStatusWarning = 2
+	complianceStatusFail    = 3
+)
+
+// Finding status IDs, as defined by the schema.
+const (
+	statusNew      = 1
+	statusResolved = 4
+)
+
+// Severity IDs, as defined by the schema.
+const (
+	severityInformational = 1
+	severityLow           = 2
+	severityMedium        = 3
+	severityHigh          = 4
+)
+
+var severityNames = map[int]string{
+	severityInformational: "Informational",
+	severityLow:           "Low",
+	severityMedium:        "Medium",
+	severityHigh:          "High",
+}
+
+// Finding is an OCSF Compliance Finding event, for the result of one policy
+// on one repository. Only the attributes Allstar has values for are included.
+type Finding struct {
+	ActivityID   int    `json:"activity_id"`
+	ActivityName string `json:"activity_name"`
+	CategoryUID  int    `json:"category_uid"`
+	CategoryName string `json:"category_name"`
+	ClassUID     int    `json:"class_uid"`
+	ClassName    string `json:"class_name"`
+	TypeUID      int    `json:"type_uid"`
+	TypeName     string `json:"type_name"`
+
+	// Time is the time of the finding, in milliseconds since the epoch.
+	Time int64 `json:"time"`
+
+	SeverityID int    `json:"severity_id"`
+	Severity   string `json:"severity"`
+	StatusID   int    `json:"status_id"`
+	Status     string `json:"status"`
+	Message    string `json:"message"`
+
+	Metadata    Metadata    `json:"metadata"`
+	FindingInfo FindingInfo `json:"finding_info"`
+	Compliance  Compliance  `json:"compliance"`
+	Resources   []Resource  `json:"resources"`
+}
+
+// Metadata is the OCSF metadata object.
+type Metadata struct {
+	Version string  `json:"version"`
+	Product Product `json:"product"`
+
+	// UID is the ID of the evaluation of the repository, as included in
+	// issues, notifications, and logs.
+	UID string `json:"uid,omitempty"`
+
+	// CorrelationUID is the ID of the enforcement run.
+	CorrelationUID string `json:"correlation_uid,omitempty"`
+}
+
+// Product is the OCSF product object.
+type Product struct {
+	Name       string `json:"name"`
+	VendorName string `json:"vendor_name"`
+	URL        string `json:"url_string"`
+}
+
+// FindingInfo is the OCSF finding information object.
+type FindingInfo struct {
+	// UID is stable for a policy and repository across runs.
+	UID   string   `json:"uid"`
+	Title string   `json:"title"`
+	Types []string `json:"types"`
+}
+
+// Compliance is the OCSF compliance object.
+type Compliance struct {
+	Control   string   `json:"control"`
+	Standards []string `json:"standards"`
+	StatusID  int      `json:"status_id"`
+	Status    string   `json:"status"`
+}
+
+// Resource is the OCSF resource details object, for a repository.
+type Resource struct {
+	Type  string `json:"type"`
+	UID   string `json:"uid"`
+	Name  string `json:"name"`
+	Group Group  `json:"group"`
+}
+
+// Group is the OCSF group object, for the owner of a repository.
+type Group struct {
+	Name string `json:"name"`
+}
+
+// control is how the findings of a policy are classified.
+type control struct {
+	// ID is the compliance control, stable even if the policy is renamed.
+	ID string
+
+	// Type is the finding type, grouping related policies.
+	Type string
+
+	// Severity is the severity ID of a failure.
+	Severity int
+}
+
+// controls maps policy names to their classification. New policies should be
+// added here, otherwise their findings are classified from their name.
+var controls = map[string]control{
+	"Branch Protection":                {"allstar.branch_protection", "Source Code Protection", severityHigh},
+	"Binary Artifacts":                 {"allstar.binary_artifacts", "Supply Chain", severityMedium},
+	"CODEOWNERS":                       {"allstar.codeowners", "Source Code Protection", severityLow},
+	"Outside Collaborators":            {"allstar.outside_collaborators", "Access Control", severityHigh},
+	"OpenSSF Scorecard":                {"allstar.scorecard", "Security Posture", severityMedium},
+	"SECURITY.md":                      {"allstar.security_policy", "Vulnerability Disclosure", severityLow},
+	"Dangerous Workflow":               {"allstar.dangerous_workflow", "CI/CD Security", severityHigh},
+	"GitHub Actions":                   {"allstar.github_actions", "CI/CD Security", severityMedium},
+	"Repository Administrators":        {"allstar.repository_administrators", "Access Control", severityMedium},
+	"Allowed Actions":                  {"allstar.allowed_actions", "CI/CD Security", severityMedium},
+	"Security Triage Board":            {"allstar.security_triage_board", "Vulnerability Management", severityLow},
+	"Fork PR Workflows":                {"allstar.fork_pr_workflows", "CI/CD Security", severityMedium},
+	"Secret Scanning":                  {"allstar.secret_scanning", "Secrets Management", severityHigh},
+	"Vulnerability Alerts":             {"allstar.vulnerability_alerts", "Vulnerability Management", severityMedium},
+	"Organization Moderation":          {"allstar.organization_moderation", "Access Control", severityLow},
+	"Code Scanning":                    {"allstar.code_scanning", "Vulnerability Management", severityMedium},
+	"OpenSSF Best Practices":           {"allstar.best_practices", "Security Posture", severityLow},
+	"Cache Poisoning":                  {"allstar.cache_poisoning", "CI/CD Security", severityHigh},
+	"Dependency Update Latency":        {"allstar.dependency_update_latency", "Vulnerability Management", severityMedium},
+	"Fork PR Deployments":              {"allstar.fork_pr_deployments", "CI/CD Security", severityHigh},
+	"Published Actions":                {"allstar.published_actions", "Supply Chain", severityMedium},
+	"Required Integrations":            {"allstar.required_integrations", "Security Posture", severityMedium},
+	"Repository Lifecycle":             {"allstar.repository_lifecycle", "Asset Management", severityMedium},
+	"Status Check Freshness":           {"allstar.status_check_freshness", "Source Code Protection", severityMedium},
+	"External Access":                  {"allstar.external_access", "Access Control", severityMedium},
+	"Workflow Deprecations":            {"allstar.workflow_deprecations", "CI/CD Security", severityLow},
+	"Merge Commit Messages":            {"allstar.merge_commit_messages", "Source Code Protection", severityLow},
+	"Config Protection":                {"allstar.config_protection", "Access Control", severityHigh},
+	"Release Signing Keys":             {"allstar.release_signing_keys", "Supply Chain", severityMedium},
+	"Required Workflows":               {"allstar.required_workflows", "CI/CD Security", severityMedium},
+	"Config Health":                    {"allstar.config_health", "Configuration", severityLow},
+	"Organization Actions Settings":    {"allstar.organization_actions_settings", "CI/CD Security", severityHigh},
+	"Two-Factor Authentication":        {"allstar.two_factor_authentication", "Access Control", severityHigh},
+	"Organization Repository Settings": {"allstar.organization_repository_settings", "Access Control", severityMedium},
+}
+
+// controlFor returns the classification of policy.
+func controlFor(policy string) control {
+	if c, ok := controls[policy]; ok {
+		return c
+	}
+	id := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		}
+		return '_'
+	}, policy)
+	return control{
+		ID:       "allstar." + id,
+		Type:     "Other",
+		Severity: severityMedium,
+	}
+}
+
+// Findings returns a Compliance Finding event for each result of run. Passing
+// results are included, resolved, so that a SIEM can track when a finding is
+// addressed. Failures in a repository's grace period are reported as
+// warnings.
+func Findings(run *storage.RunResult) []Finding {
+	if run == nil {
+		return nil
+	}
+	fs := make([]Finding, 0, len(run.Results))
+	for _, r := range run.Results {
+		fs = append(fs, finding(run, r))
+	}
+	return fs
+}
+
+func finding(run *storage.RunResult, r storage.PolicyResult) Finding {
+	c := controlFor(r.Policy)
+	fullName := fmt.Sprintf("%s/%s", r.Owner, r.Repo)
+	resource := Resource{
+		Type:  "GitHub Repository",
+		UID:   fullName,
+		Name:  r.Repo,
+		Group: Group{Name: r.Owner},
+	}
+	// Results of org-scope policies have no repo.
+	if r.Repo == "" {
+		fullName = r.Owner
+		resource = Resource{
+			Type: "GitHub Organization",
+			UID:  r.Owner,
+			Name: r.Owner,
+		}
+	}
+
+	f := Finding{
+		ActivityID:   activityID,
+		ActivityName: activityName,
+		CategoryUID:  categoryUID,
+		CategoryName: categoryName,
+		ClassUID:     classUID,
+		ClassName:    className,
+		TypeUID:      classUID*100 + activityID,
+		TypeName:     fmt.Sprintf("%s: %s", className, activityName),
+		Time:         run.Finished.UnixMilli(),
+		Metadata: Metadata{
+			Version: SchemaVersion,
+			Product: Product{
+				Name:       "Allstar",
+				VendorName: "OpenSSF",
+				URL:        "https://github.com/ossf/allstar",
+			},
+			UID:            r.EnforcementID,
+			CorrelationUID: run.RunID,
+		},
+		FindingInfo: FindingInfo{
+			UID:   fmt.Sprintf("%s/%s", c.ID, fullName),
+			Title: fmt.Sprintf("%s policy on %s", r.Policy, fullName),
+			Types: []string{c.Type},
+		},
+		Compliance: Compliance{
+			Control:   c.ID,
+			Standards: []string{"Allstar"},
+		},
+		Resources: []Resource{resource},
+	}
+
+	switch {
+	case r.Pass:
+		f.Compliance.StatusID = complianceStatusPass
+		f.Compliance.Status = "Pass"
+		f.StatusID = statusResolved
+		f.Status = "Resolved"
+		f.SeverityID = severityInformational
+		f.Message = fmt.Sprintf("%s passed the %s policy.", fullName, r.Policy)
+	case r.GracePeriod:
+		f.Compliance.StatusID = complianceStatusWarning
+		f.Compliance.Status = "Warning"
+		f.StatusID = statusNew
+		f.Status = "New"
+		f.SeverityID = severityInformational
+		f.Message = fmt.Sprintf("%s failed the %s policy, in its grace period.", fullName, r.Policy)
+	default:
+		f.Compliance.StatusID = complianceStatusFail
+		f.Compliance.Status = "Fail"
+		f.StatusID = statusNew
+		f.Status = "New"
+		f.SeverityID = c.Severity
+		f.Message = fmt.Sprintf("%s failed the %s policy.", fullName, r.Policy)
+	}
+	if !r.Pass && r.IssueFallback != "" {
+		f.Message += fmt.Sprintf(" Issue not created: %s.", r.IssueFallback)
+	}
+	f.Severity = severityNames[f.SeverityID]
+	return f
+}
@@ -0,0 +1,138 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ocsf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ossf/allstar/pkg/policies"
+	"github.com/ossf/allstar/pkg/storage"
+)
+
+func TestFindings(t *testing.T) {
+	finished := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	run := &storage.RunResult{
+		RunID:    "run1",
+		Finished: finished,
+		Results: []storage.PolicyResult{
+			{Owner: "thisorg", Repo: "repo1", Policy: "Branch Protection", Pass: false, EnforcementID: "eval1"},
+			{Owner: "thisorg", Repo: "repo2", Policy: "Branch Protection", Pass: true, EnforcementID: "eval2"},
+			{Owner: "thisorg", Repo: "repo3", Policy: "Branch Protection", Pass: false, GracePeriod: true},
+		},
+	}
+	base := func(repo string) Finding {
+		return Finding{
+			ActivityID:   1,
+			ActivityName: "Create",
+			CategoryUID:  2,
+			CategoryName: "Findings",
+			ClassUID:     2003,
+			ClassName:    "Compliance Finding",
+			TypeUID:      200301,
+			TypeName:     "Compliance Finding: Create",
+			Time:         finished.UnixMilli(),
+			Metadata: Metadata{
+				Version: "1.1.0",
+				Product: Product{
+					Name:       "Allstar",
+					VendorName: "OpenSSF",
+					URL:        "https://github.com/ossf/allstar",
+				},
+				CorrelationUID: "run1",
+			},
+			FindingInfo: FindingInfo{
+				UID:   "allstar.branch_protection/thisorg/" + repo,
+				Title: "Branch Protection policy on thisorg/" + repo,
+				Types: []string{"Source Code Protection"},
+			},
+			Compliance: Compliance{
+				Control:   "allstar.branch_protection",
+				Standards: []string{"Allstar"},
+			},
+			Resources: []Resource{
+				{
+					Type:  "GitHub Repository",
+					UID:   "thisorg/" + repo,
+					Name:  repo,
+					Group: Group{Name: "thisorg"},
+				},
+			},
+		}
+	}
+	fail := base("repo1")
+	fail.Metadata.UID = "eval1"
+	fail.SeverityID = 4
+	fail.Severity = "High"
+	fail.StatusID = 1
+	fail.Status = "New"
+	fail.Message = "thisorg/repo1 failed the Branch Protection policy."
+	fail.Compliance.StatusID = 3
+	fail.Compliance.Status = "Fail"
+
+	pass := base("repo2")
+	pass.Metadata.UID = "eval2"
+	pass.SeverityID = 1
+	pass.Severity = "Informational"
+	pass.StatusID = 4
+	pass.Status = "Resolved"
+	pass.Message = "thisorg/repo2 passed the Branch Protection policy."
+	pass.Compliance.StatusID = 1
+	pass.Compliance.Status = "Pass"
+
+	grace := base("repo3")
+	grace.SeverityID = 1
+	grace.Severity = "Informational"
+	grace.StatusID = 1
+	grace.Status = "New"
+	grace.Message = "thisorg/repo3 failed the Branch Protection policy, in its grace period."
+	grace.Compliance.StatusID = 2
+	grace.Compliance.Status = "Warning"
+
+	exp := []Finding{fail, pass, grace}
+	if diff := cmp.Diff(exp, Findings(run)); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+	if fs := Findings(nil); fs != nil {
+		t.Errorf("Unexpected findings for nil run: %v", fs)
+	}
+}
+
+func TestControls(t *testing.T) {
+	ids := make(map[string]string)
+	for _, p := range policies.GetPolicies() {
+		c, ok := controls[p.Name()]
+		if !ok {
+			t.Errorf("Policy %q has no control mapping", p.Name())
+			continue
+		}
+		if other, ok := ids[c.ID]; ok {
+			t.Errorf("Policies %q and %q have the same control %q", p.Name(), other, c.ID)
+		}
+		ids[c.ID] = p.Name()
+	}
+}
+
+func TestControlForUnknown(t *testing.T) {
+	exp := control{
+		ID:       "allstar.new_policy_v2",
+		Type:     "Other",
+		Severity: severityMedium,
+	}
+	if diff := cmp.Diff(exp, controlFor("New Policy v2")); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+}
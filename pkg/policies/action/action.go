@@ -22,8 +22,12 @@ import (
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
+	"github.com/ossf/allstar/pkg/cache"
 	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/exemptions"
 	"github.com/ossf/allstar/pkg/policydef"
+	"github.com/ossf/allstar/pkg/pullrequest"
+	"github.com/ossf/allstar/pkg/selector"
 	"github.com/rhysd/actionlint"
 
 	"github.com/google/go-github/v59/github"
@@ -36,7 +40,9 @@ const polName = "GitHub Actions"
 const failText = "This policy, specified at the organization level, sets requirements for Action use by repos within the organization. This repo is failing to fully comply with organization policies, as explained below.\n\n```\n%s```\n\nSee the org-level %s policy configuration for details."
 
 const maxWorkflows = 50
-const repoSelectorExcludeDepthLimit = 3
+
+// localActionsRuleName is the name of the rule added by AllowLocalActions.
+const localActionsRuleName = "Allow local Actions"
 
 var priorities = map[string]int{
 	"critical": 0,
@@ -53,6 +59,9 @@ type OrgConfig struct {
 	// Groups is the set of RuleGroups to employ during Check.
 	// They are evaluated in order.
 	Groups []*RuleGroup `json:"groups"`
+
+	// Workflows configures workflow-level checks, applied to all repos.
+	Workflows WorkflowConfig `json:"workflows"`
 }
 
 // RuleGroup is used to apply rules to repos matched by RepoSelectors.
@@ -69,6 +78,11 @@ type RuleGroup struct {
 	// Rules are applied in order of priority, with allow/require rules
 	// evaluated before deny rules at each priority tier.
 	Rules []*Rule `json:"rules"`
+
+	// AllowLocalActions allows the Actions hosted in the organization, as a
+	// medium priority allow rule. Local Actions of the repo, eg:
+	// "./.github/actions/setup", are always allowed.
+	AllowLocalActions bool `json:"allowLocalActions"`
 }
 
 // Rule is an Action Use rule
@@ -76,7 +90,8 @@ type Rule struct {
 	// Name is the name used to identify the rule
 	Name string `json:"name"`
 
-	// Method is the type of rule. One of "require", "allow", and "deny".
+	// Method is the type of rule. One of "require", "allow", "deny", and
+	// "requirePinned".
 	Method string `json:"method"`
 
 	// Priority is the priority tier identifier applied to the rule.
@@ -92,26 +107,27 @@ type Rule struct {
 	// [For use with "require" method]
 	MustPass bool `json:"mustPass"`
 
+	// RunEvents are the events of the workflow runs checked for MustPass,
+	// any of "push", "pull_request", and "schedule". The workflow must run on
+	// all of them. Default "push", with the workflow required to run on push
+	// and pull_request.
+	// [For use with "require" method]
+	RunEvents []string `json:"runEvents"`
+
 	// RequireAll specifies that all Actions listed should be required,
 	// rather than just one.
 	// [For use with "require" method]
 	RequireAll bool `json:"requireAll"`
-}
-
-// RepoSelector specifies a selection of repos
-type RepoSelector struct {
-	// Name is the repo name in glob format
-	Name string `json:"name"`
 
-	// Language is a set of programming languages.
-	// See the section about language detection below
-	Language []string `json:"language"`
-
-	// Exclude is a set of RepoSelectors targeting repos that should
-	// not be matched by this selector.
-	Exclude []*RepoSelector `json:"exclude"`
+	// TrustedActions is a set of Action names in glob format, eg:
+	// "actions/*", that are not required to be pinned to a full commit SHA.
+	// [For use with "requirePinned" method]
+	TrustedActions []string `json:"trustedActions"`
 }
 
+// RepoSelector specifies a selection of repos, see pkg/selector.
+type RepoSelector = selector.RepoSelector
+
 // ActionSelector specifies a selection of Actions
 type ActionSelector struct {
 	// Name is the Action name in glob format
@@ -124,11 +140,18 @@ type ActionSelector struct {
 
 type details struct {
 	FailedRules []*Rule
+
+	// Workflows are the workflow-level check failures, per workflow.
+	Workflows []*WorkflowFindings
 }
 
 type workflowMetadata struct {
 	filename string
+	// path is the path of the workflow file in the repo.
+	path     string
 	workflow *actionlint.Workflow
+	// content is the raw workflow file, used by Fix to edit it.
+	content []byte
 }
 
 type actionMetadata struct {
@@ -137,6 +160,13 @@ type actionMetadata struct {
 	workflowFilename string
 	workflowName     string
 	workflowOn       []actionlint.Event
+	// workflow and step locate the use of the Action, for Fix.
+	workflow *workflowMetadata
+	step     *actionlint.Step
+	// via is the chain of uses leading to an Action used by a reusable
+	// workflow or composite Action, starting with the workflow path. Empty if
+	// used directly by the workflow.
+	via []string
 }
 
 // internalRuleGroup is a RuleGroup using internalRule
@@ -169,15 +199,24 @@ type internalOrgConfig struct {
 	// Groups is the set of RuleGroups to employ during Check.
 	// They are evaluated in order.
 	Groups []*internalRuleGroup `json:"groups"`
+
+	// Workflows configures workflow-level checks, applied to all repos.
+	Workflows WorkflowConfig `json:"workflows"`
 }
 
+var gc = cache.NewGlobCache(cache.DefaultSize)
+var sc = cache.NewSemverCache(cache.DefaultSize)
+
 var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
 
 var listWorkflows func(ctx context.Context, c *github.Client, owner, repo string) ([]*workflowMetadata, error)
 var listLanguages func(ctx context.Context, c *github.Client, owner, repo string) (map[string]int, error)
-var listWorkflowRunsByFilename func(ctx context.Context, c *github.Client, owner, repo string, workflowFilename string) ([]*github.WorkflowRun, error)
+var listWorkflowRunsByFilename func(ctx context.Context, c *github.Client, owner, repo string, workflowFilename, event string) ([]*github.WorkflowRun, error)
 var getLatestCommitHash func(ctx context.Context, c *github.Client, owner, repo string) (string, error)
 var listTags func(ctx context.Context, c *github.Client, owner, repo string) ([]*github.RepositoryTag, error)
+var pullrequestEnsure func(context.Context, *github.Client, string, string, string, *pullrequest.Request) (*github.PullRequest, error)
+
+var exemptionsApply func(context.Context, *github.Client, string, string, string, *policydef.Result) *policydef.Result
 
 func init() {
 	configFetchConfig = config.FetchConfig
@@ -186,6 +225,9 @@ func init() {
 	listWorkflowRunsByFilename = listWorkflowRunsByFilenameReal
 	getLatestCommitHash = getLatestCommitHashReal
 	listTags = listTagsReal
+	getFileContent = getFileContentReal
+	pullrequestEnsure = pullrequest.Ensure
+	exemptionsApply = exemptions.Apply
 }
 
 // sortableRules is a sortable list of *Rule
@@ -208,16 +250,25 @@ func (a Action) Name() string {
 // Check whether this policy is enabled or not
 func (a Action) IsEnabled(ctx context.Context, c *github.Client, owner, repo string) (bool, error) {
 	oc := getConfig(ctx, c, owner, repo)
-	enabled := oc.Groups != nil
+	enabled := oc.Groups != nil || oc.Workflows.enabled()
 	return enabled, nil
 }
 
 // Check performs the policy check for Action Use policy based on the
 // configuration stored in the org, implementing policydef.Policy.Check()
 func (a Action) Check(ctx context.Context, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	r, err := check(ctx, c, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	return exemptionsApply(ctx, c, owner, repo, polName, r), nil
+}
+
+func check(ctx context.Context, c *github.Client, owner,
 	repo string) (*policydef.Result, error) {
 	oc := getConfig(ctx, c, owner, repo)
-	enabled := oc.Groups != nil
+	enabled := oc.Groups != nil || oc.Workflows.enabled()
 	log.Info().
 		Str("org", owner).
 		Str("repo", repo).
@@ -225,7 +276,7 @@ func (a Action) Check(ctx context.Context, c *github.Client, owner,
 		Bool("enabled", enabled).
 		Msg("Check repo enabled")
 	if !enabled {
-		// Don't run this policy if no rules exist.
+		// Don't run this policy if no rules or checks exist.
 		return &policydef.Result{
 			Enabled:    enabled,
 			Pass:       true,
@@ -233,14 +284,66 @@ func (a Action) Check(ctx context.Context, c *github.Client, owner,
 			Details:    details{},
 		}, nil
 	}
-	// Get workflows.
-	// Workflows should have push and pull_request listed as trigger events
-	// in order to qualify.
 	wfs, err := listWorkflows(ctx, c, owner, repo)
 	if err != nil {
 		return nil, err
 	}
+	_, results, err := evaluate(ctx, c, owner, repo, oc, wfs)
+	if err != nil {
+		return nil, err
+	}
+
+	d := details{}
+
+	passing := true
+	combinedExplain := ""
+
+	// Use this map to dedupe Rules
+	failedRules := map[*internalRule]struct{}{}
+
+	for _, result := range results {
+		if !result.passed() {
+			passing = false
+			if combinedExplain != "" {
+				combinedExplain += "\n"
+			}
+			combinedExplain += result.explain()
+			failedRules[result.relevantRule()] = struct{}{}
+		}
+	}
+
+	for r := range failedRules {
+		d.FailedRules = append(d.FailedRules, r.Rule)
+	}
+
+	d.Workflows = checkWorkflows(wfs, oc.Workflows)
+	if len(d.Workflows) > 0 {
+		passing = false
+		if combinedExplain != "" {
+			combinedExplain += "\n"
+		}
+		combinedExplain += explainWorkflows(d.Workflows)
+	}
 
+	notifyText := fmt.Sprintf(failText, combinedExplain, polName)
+
+	if passing {
+		notifyText = "OK"
+	}
+
+	return &policydef.Result{
+		Enabled:    enabled,
+		Pass:       passing,
+		NotifyText: notifyText,
+		Details:    d,
+	}, nil
+}
+
+// evaluate evaluates the rules applicable to the repo against the Actions
+// used in its workflows. Returns the applicable rules, in evaluation order, and
+// the results.
+func evaluate(ctx context.Context, c *github.Client, owner, repo string,
+	oc *internalOrgConfig, wfs []*workflowMetadata) (sortableRules, []ruleEvaluationResult, error) {
 	// Create index of which workflows run which Actions
 	var actions []*actionMetadata
 
@@ -267,6 +370,11 @@ func (a Action) Check(ctx context.Context, c *github.Client, owner,
 					// Missing uses in step
 					continue
 				}
+				if strings.HasPrefix(actionStep.Uses.Value, "./") {
+					// Local Actions are part of the repo, and always
+					// allowed.
+					continue
+				}
 				sm := strings.SplitN(actionStep.Uses.Value, "@", 2)
 				if len(sm) != 2 {
 					// Ignore invalid Action
@@ -286,26 +394,24 @@ func (a Action) Check(ctx context.Context, c *github.Client, owner,
 					workflowFilename: wf.filename,
 					workflowName:     wf.workflow.Name.Value,
 					workflowOn:       wf.workflow.On,
+					workflow:         wf,
+					step:             s,
 				})
 			}
 		}
 	}
 
-	// Init caches
-
-	gc := newGlobCache()
-	sc := newSemverCache()
-
 	// Determine applicable rules
 
 	var applicableRules sortableRules
 
+	sr := selector.NewRepo(selectorRepos{c}, owner, repo)
 	for _, g := range oc.Groups {
 		// Check if group match
 		groupMatch := false
 		for _, rs := range g.Repos {
 			// Ignore error while checking match. Match will be false on error.
-			match, err := rs.match(ctx, c, owner, repo, repoSelectorExcludeDepthLimit, gc, sc)
+			match, err := rs.Match(ctx, sr)
 
 			if err != nil {
 				log.Warn().
@@ -340,9 +446,18 @@ func (a Action) Check(ctx context.Context, c *github.Client, owner,
 	var results []ruleEvaluationResult
 
 	// => First, evaluate deny rules
-	// Note: deny rules are evaluated Action-wise
+	// Note: deny rules are evaluated Action-wise, including the Actions used
+	// by reusable workflows and composite Actions.
 
-	for _, a := range actions {
+	denied := actions
+	for _, r := range applicableRules {
+		if r.Method == "deny" {
+			denied = append(append([]*actionMetadata{}, actions...), transitiveActions(ctx, c, owner, repo, wfs)...)
+			break
+		}
+	}
+
+	for _, a := range denied {
 		denyResult, errors := evaluateActionDenied(ctx, c, applicableRules, a, gc, sc)
 		// errors are often parse errors (user-created) and are reflected in
 		// denyResult steps
@@ -396,51 +511,33 @@ func (a Action) Check(ctx context.Context, c *github.Client, owner,
 		}
 	}
 
-	d := details{}
-
-	passing := true
-	combinedExplain := ""
-
-	// Use this map to dedupe Rules
-	failedRules := map[*internalRule]struct{}{}
+	// => Last, evaluate requirePinned rules
 
-	for _, result := range results {
-		if !result.passed() {
-			passing = false
-			if combinedExplain != "" {
-				combinedExplain += "\n"
-			}
-			combinedExplain += result.explain()
-			failedRules[result.relevantRule()] = struct{}{}
+	for _, r := range applicableRules {
+		if r.Method != "requirePinned" {
+			continue
 		}
+		result, err := evaluateRequirePinnedRule(r, actions, gc)
+		if err != nil {
+			log.Warn().
+				Str("org", owner).
+				Str("repo", repo).
+				Str("area", polName).
+				Err(err).
+				Msg("Error evaluating requirePinned rule")
+			continue
+		}
+		results = append(results, result)
 	}
 
-	for r := range failedRules {
-		d.FailedRules = append(d.FailedRules, r.Rule)
-	}
-
-	notifyText := fmt.Sprintf(failText, combinedExplain, polName)
-
-	if passing {
-		notifyText = "OK"
-	}
-
-	return &policydef.Result{
-		Enabled:    enabled,
-		Pass:       passing,
-		NotifyText: notifyText,
-		Details:    d,
-	}, nil
+	return applicableRules, results, nil
 }
 
-// Fix implementing policydef.Policy.Fix(). Not supported.
+// Fix implementing policydef.Policy.Fix(). Opens a pull request updating
+// Actions to versions that satisfy the rules, pinning them to commits, and
+// commenting out steps using denied Actions.
 func (a Action) Fix(ctx context.Context, c *github.Client, owner, repo string) error {
-	log.Warn().
-		Str("org", owner).
-		Str("repo", repo).
-		Str("area", polName).
-		Msg("Action fix is configured, but not implemented.")
-	return nil
+	return fix(ctx, c, owner, repo)
 }
 
 // GetAction returns the configured action from Action Use policy's
@@ -472,6 +569,17 @@ func getConfig(ctx context.Context, c *github.Client, owner, repo string) *inter
 			RuleGroup: g,
 			Rules:     nil,
 		}
+		if g.AllowLocalActions {
+			ig.Rules = append(ig.Rules, &internalRule{
+				Rule: &Rule{
+					Name:    localActionsRuleName,
+					Method:  "allow",
+					Actions: []*ActionSelector{{Name: owner + "/*"}},
+				},
+				group:       g,
+				priorityInt: priorities["medium"],
+			})
+		}
 		for _, r := range g.Rules {
 			ir := &internalRule{Rule: r}
 			// Set each rule's group to its *RuleGroup
@@ -490,15 +598,16 @@ func getConfig(ctx context.Context, c *github.Client, owner, repo string) *inter
 		gs = append(gs, ig)
 	}
 	return &internalOrgConfig{
-		Action: oc.Action,
-		Groups: gs,
+		Action:    oc.Action,
+		Groups:    gs,
+		Workflows: oc.Workflows,
 	}
 }
 
 // resolveVersion gets a *semver.Version given an actionMetadata.
 // It will use tags of the Action repo if necessary.
-func resolveVersion(ctx context.Context, c *github.Client, m *actionMetadata, gc globCache, sc semverCache) (*semver.Version, error) {
-	version, err := sc.compileVersion(m.version)
+func resolveVersion(ctx context.Context, c *github.Client, m *actionMetadata, gc *cache.GlobCache, sc *cache.SemverCache) (*semver.Version, error) {
+	version, err := sc.Version(m.version)
 	if err == nil {
 		return version, nil
 	}
@@ -514,7 +623,7 @@ func resolveVersion(ctx context.Context, c *github.Client, m *actionMetadata, gc
 	}
 	for _, tag := range tags {
 		if tag.GetCommit().GetSHA() == m.version {
-			version, err := sc.compileVersion(tag.GetName())
+			version, err := sc.Version(tag.GetName())
 			return version, err
 		}
 	}
@@ -522,9 +631,9 @@ func resolveVersion(ctx context.Context, c *github.Client, m *actionMetadata, gc
 }
 
 // match checks if an ActionSelector matches an actionMetadata.
-func (as *ActionSelector) match(ctx context.Context, c *github.Client, m *actionMetadata, gc globCache, sc semverCache) (match, matchName, matchVersion bool, err error) {
+func (as *ActionSelector) match(ctx context.Context, c *github.Client, m *actionMetadata, gc *cache.GlobCache, sc *cache.SemverCache) (match, matchName, matchVersion bool, err error) {
 	if as.Name != "" {
-		nameGlob, err := gc.compileGlob(as.Name)
+		nameGlob, err := gc.Compile(as.Name)
 		if err != nil {
 			return false, false, false, err
 		}
@@ -539,7 +648,7 @@ func (as *ActionSelector) match(ctx context.Context, c *github.Client, m *action
 		return true, true, true, nil
 	}
 	if as.Version != "" {
-		constraint, err := sc.compileConstraints(as.Version)
+		constraint, err := sc.Constraints(as.Version)
 		if err != nil {
 			// on error, assume this is a ref
 			// (we know it doesn't match because not equal above)
@@ -556,80 +665,6 @@ func (as *ActionSelector) match(ctx context.Context, c *github.Client, m *action
 	return true, true, true, nil
 }
 
-// match checks if a repo matches a RepoSelector.
-// Set excludeDepth to > 0 for exclusion depth limit, or < 0 for no depth limit.
-func (rs *RepoSelector) match(ctx context.Context, c *github.Client, owner, repo string, excludeDepth int, gc globCache, sc semverCache) (bool, error) {
-	if rs == nil {
-		return true, nil
-	}
-	if rs.Name != "" {
-		ng, err := gc.compileGlob(rs.Name)
-		if err != nil {
-			return false, err
-		}
-		if !ng.Match(repo) {
-			return false, nil
-		}
-	}
-	if rs.Language != nil {
-		langs, err := listLanguages(ctx, c, owner, repo)
-		if err != nil {
-			return false, err
-		}
-		if !languageSatisfied(langs, rs.Language) {
-			return false, nil
-		}
-	}
-	// Check if covered by exclusion case
-	if excludeDepth != 0 {
-		for _, exc := range rs.Exclude {
-			match, err := exc.match(ctx, c, owner, repo, excludeDepth-1, gc, sc)
-			if err != nil {
-				// API error? Ignore exclusion
-				continue
-			}
-			if match {
-				return false, nil
-			}
-		}
-	}
-	return true, nil
-}
-
-// languageSatisfied determines from a map of languages to bytes whether the
-// queried languages are significantly present.
-func languageSatisfied(langs map[string]int, want []string) bool {
-	totalBytes := 0
-
-	topLangBytes := 0
-	topLang := ""
-
-	var significantLanguages []string
-
-	for l, b := range langs {
-		totalBytes += b
-		if topLang == "" || topLangBytes < b {
-			topLang = l
-			topLangBytes = b
-		}
-		if b > 3000 {
-			significantLanguages = append(significantLanguages, l)
-		}
-	}
-
-	significantLanguages = append(significantLanguages, topLang)
-
-	for _, w := range want {
-		for _, s := range significantLanguages {
-			if strings.EqualFold(s, w) {
-				return true
-			}
-		}
-	}
-
-	return false
-}
-
 // Len returns number of rules in s
 func (s sortableRules) Len() int {
 	return len(s)
@@ -669,16 +704,30 @@ func getLatestCommitHashReal(ctx context.Context, c *github.Client, owner, repo
 }
 
 // listWorkflowRunsByFilenameReal returns workflow runs for a repo by
-// workflow filename.
+// workflow filename, triggered by event.
 // Docs:
 // https://docs.github.com/en/rest/actions/workflow-runs#list-workflow-runs
-func listWorkflowRunsByFilenameReal(ctx context.Context, c *github.Client, owner, repo string, workflowFilename string) ([]*github.WorkflowRun, error) {
+func listWorkflowRunsByFilenameReal(ctx context.Context, c *github.Client, owner, repo string, workflowFilename, event string) ([]*github.WorkflowRun, error) {
 	runs, _, err := c.Actions.ListWorkflowRunsByFileName(ctx, owner, repo, workflowFilename, &github.ListWorkflowRunsOptions{
-		Event: "push",
+		Event: event,
 	})
 	return runs.WorkflowRuns, err
 }
 
+// selectorRepos looks up repo details for RepoSelectors, with listLanguages.
+type selectorRepos struct {
+	c *github.Client
+}
+
+func (s selectorRepos) Get(ctx context.Context, owner, repo string) (*github.Repository, *github.Response, error) {
+	return s.c.Repositories.Get(ctx, owner, repo)
+}
+
+func (s selectorRepos) ListLanguages(ctx context.Context, owner, repo string) (map[string]int, *github.Response, error) {
+	l, err := listLanguages(ctx, s.c, owner, repo)
+	return l, nil, err
+}
+
 // listLanguagesReal uses the GitHub API to list languages.
 // Docs: https://docs.github.com/en/rest/repos/repos#list-repository-languages
 func listLanguagesReal(ctx context.Context, c *github.Client, owner, repo string) (map[string]int, error) {
@@ -766,7 +815,9 @@ func listWorkflowsReal(ctx context.Context, c *github.Client, owner, repo string
 		}
 		workflows = append(workflows, &workflowMetadata{
 			filename: wfc.GetName(),
+			path:     wfc.GetPath(),
 			workflow: wf,
+			content:  bc,
 		})
 	}
 	return workflows, nil
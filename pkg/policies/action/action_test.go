@@ -19,14 +19,27 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/gobwas/glob"
+	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-github/v59/github"
 	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/policydef"
+	"github.com/ossf/allstar/pkg/pullrequest"
 	"github.com/rhysd/actionlint"
 )
 
+func noExemptions(ctx context.Context, c *github.Client, owner, repo, policy string,
+	r *policydef.Result) *policydef.Result {
+	return r
+}
+
+func noFiles(ctx context.Context, c *github.Client, owner, repo, path, ref string) ([]byte, error) {
+	return nil, nil
+}
+
 func TestCheck(t *testing.T) {
 	createWorkflowRun := func(sha string, complete bool, passing *bool) *github.WorkflowRun {
 		status := "completed"
@@ -59,7 +72,11 @@ func TestCheck(t *testing.T) {
 		// Will be loaded from test_workflows/ directory.
 		File string
 
+		// Runs are the push runs of the workflow.
 		Runs []*github.WorkflowRun
+
+		// EventRuns are the runs of other events, by event.
+		EventRuns map[string][]*github.WorkflowRun
 	}
 
 	denyAll := &Rule{
@@ -171,6 +188,56 @@ func TestCheck(t *testing.T) {
 			},
 			ExpectPass: false,
 		},
+		{
+			Name: "Allow local Actions",
+			Org: OrgConfig{
+				Action: "issue",
+				Groups: []*RuleGroup{
+					{
+						Rules: []*Rule{
+							{
+								Name:    "Allow GitHub Actions",
+								Method:  "allow",
+								Actions: []*ActionSelector{{Name: "actions/*"}},
+							},
+							denyAll,
+						},
+						AllowLocalActions: true,
+					},
+				},
+			},
+			Workflows: []testingWorkflowMetadata{
+				{
+					File: "local.yaml",
+				},
+			},
+			ExpectPass: true,
+		},
+		{
+			Name: "Local Actions not allowed",
+			Org: OrgConfig{
+				Action: "issue",
+				Groups: []*RuleGroup{
+					{
+						Rules: []*Rule{
+							{
+								Name:    "Allow GitHub Actions",
+								Method:  "allow",
+								Actions: []*ActionSelector{{Name: "actions/*"}},
+							},
+							denyAll,
+						},
+					},
+				},
+			},
+			Workflows: []testingWorkflowMetadata{
+				{
+					File: "local.yaml",
+				},
+			},
+			ExpectPass:    false,
+			ExpectMessage: []string{"Action \"thisorg/shared-action\" version v1 hit deny rule \"Deny default\""},
+		},
 		{
 			Name: "Deny some, repo match",
 			Org: OrgConfig{
@@ -612,6 +679,125 @@ func TestCheck(t *testing.T) {
 				"Fix non-passing * \"gradle/wrapper*\"",
 			},
 		},
+		{
+			Name: "Require passing on pull_request, passing on pull request",
+			Org: OrgConfig{
+				Action: "issue",
+				Groups: []*RuleGroup{
+					{
+						Rules: []*Rule{
+							{
+								Name:      "Require Gradle Wrapper validation",
+								Method:    "require",
+								MustPass:  true,
+								RunEvents: []string{"pull_request"},
+								Actions: []*ActionSelector{
+									{
+										Name:    "gradle/wrapper-validation-action",
+										Version: ">= 1.0.4",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Workflows: []testingWorkflowMetadata{
+				{
+					File: "gradle-wrapper-validate-pr.yaml",
+					EventRuns: map[string][]*github.WorkflowRun{
+						"pull_request": {
+							createWorkflowRun("sha-pr", true, boolptr(true)),
+							createWorkflowRun("sha-pr-old", true, boolptr(false)),
+						},
+					},
+				},
+			},
+			LatestCommitHash: "sha-latest",
+			ExpectPass:       true,
+		},
+		{
+			Name: "Require passing on push and pull_request, failing on pull request",
+			Org: OrgConfig{
+				Action: "issue",
+				Groups: []*RuleGroup{
+					{
+						Rules: []*Rule{
+							{
+								Name:      "Require Gradle Wrapper validation",
+								Method:    "require",
+								MustPass:  true,
+								RunEvents: []string{"push", "pull_request"},
+								Actions: []*ActionSelector{
+									{
+										Name:    "gradle/wrapper-validation-action",
+										Version: ">= 1.0.4",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Workflows: []testingWorkflowMetadata{
+				{
+					File: "gradle-wrapper-validate.yaml",
+					Runs: []*github.WorkflowRun{
+						createWorkflowRun("sha-latest", true, boolptr(true)),
+					},
+					EventRuns: map[string][]*github.WorkflowRun{
+						"pull_request": {
+							createWorkflowRun("sha-pr", true, boolptr(false)),
+						},
+					},
+				},
+			},
+			LatestCommitHash: "sha-latest",
+			ExpectPass:       false,
+			ExpectMessage: []string{
+				`0 / 1 requisites met`,
+				`Fix non-passing * "gradle/wrapper*"`,
+			},
+		},
+		{
+			Name: "Require passing on schedule, not run on schedule",
+			Org: OrgConfig{
+				Action: "issue",
+				Groups: []*RuleGroup{
+					{
+						Rules: []*Rule{
+							{
+								Name:      "Require Gradle Wrapper validation",
+								Method:    "require",
+								MustPass:  true,
+								RunEvents: []string{"schedule"},
+								Actions: []*ActionSelector{
+									{
+										Name:    "gradle/wrapper-validation-action",
+										Version: ">= 1.0.4",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Workflows: []testingWorkflowMetadata{
+				{
+					File: "gradle-wrapper-validate-pr.yaml",
+					EventRuns: map[string][]*github.WorkflowRun{
+						"pull_request": {
+							createWorkflowRun("sha-latest", true, boolptr(true)),
+						},
+					},
+				},
+			},
+			LatestCommitHash: "sha-latest",
+			ExpectPass:       false,
+			ExpectMessage: []string{
+				`Enable workflow "GW Validate PR Workflow" * to run on schedule.`,
+			},
+		},
 		{
 			Name: "Require, not present",
 			Org: OrgConfig{
@@ -1042,8 +1228,67 @@ func TestCheck(t *testing.T) {
 				`Enable workflow "Test Workflow 2" containing Action "oss* to run on pull_request and push.`,
 			},
 		},
+		{
+			Name: "Require pinned, trusted owner",
+			Org: OrgConfig{
+				Groups: []*RuleGroup{
+					{
+						Rules: []*Rule{
+							{
+								Name:           "Pin third-party",
+								Method:         "requirePinned",
+								TrustedActions: []string{"actions/*"},
+							},
+						},
+					},
+				},
+			},
+			Workflows: []testingWorkflowMetadata{
+				{
+					File: "version-pinned.yaml",
+				},
+			},
+			ExpectPass: false,
+			ExpectMessage: []string{
+				`RequirePinned rule "Pin third-party" (member of nameless rule group) not satisfied`,
+				`Action "ossf/required-action" version v3 in workflow "Test Workflow" is not pinned to a full commit SHA`,
+			},
+		},
+		{
+			Name: "Require pinned, selected Actions pinned",
+			Org: OrgConfig{
+				Groups: []*RuleGroup{
+					{
+						Rules: []*Rule{
+							{
+								Name:   "Pin test-action",
+								Method: "requirePinned",
+								Actions: []*ActionSelector{
+									{
+										Name: "ossf/test-*",
+									},
+								},
+							},
+							{
+								Name:   "Allow all",
+								Method: "allow",
+							},
+						},
+					},
+				},
+			},
+			Workflows: []testingWorkflowMetadata{
+				{
+					File: "version-pinned.yaml",
+				},
+			},
+			ExpectPass: true,
+		},
 	}
 
+	exemptionsApply = noExemptions
+	getFileContent = noFiles
+
 	a := NewAction()
 
 	for _, test := range tests {
@@ -1090,10 +1335,13 @@ func TestCheck(t *testing.T) {
 			}
 
 			listWorkflowRunsByFilename = func(ctx context.Context, c *github.Client, owner, repo,
-				workflowFilename string) ([]*github.WorkflowRun, error) {
+				workflowFilename, event string) ([]*github.WorkflowRun, error) {
 				for _, wf := range test.Workflows {
 					if wf.File == workflowFilename {
-						return wf.Runs, nil
+						if event == "push" {
+							return wf.Runs, nil
+						}
+						return wf.EventRuns[event], nil
 					}
 				}
 				return nil, fmt.Errorf("could not find testWorkflowMetadata for filename %s", workflowFilename)
@@ -1128,8 +1376,8 @@ func TestCheck(t *testing.T) {
 
 			if !res.Pass {
 				d := res.Details.(details)
-				if d.FailedRules == nil {
-					t.Errorf("FailedRules nil")
+				if d.FailedRules == nil && d.Workflows == nil {
+					t.Errorf("FailedRules and Workflows nil")
 				}
 				for i, r := range d.FailedRules {
 					if r == nil {
@@ -1155,3 +1403,501 @@ func TestCheck(t *testing.T) {
 		})
 	}
 }
+
+func TestFix(t *testing.T) {
+	tag := func(name string) *github.RepositoryTag {
+		return &github.RepositoryTag{Name: &name}
+	}
+	commitTag := func(name, sha string) *github.RepositoryTag {
+		return &github.RepositoryTag{Name: &name, Commit: &github.Commit{SHA: &sha}}
+	}
+
+	allowCheckout := &Rule{
+		Name:     "Allow checkout",
+		Method:   "allow",
+		Priority: "high",
+		Actions: []*ActionSelector{
+			{
+				Name:    "actions/checkout",
+				Version: ">= 3.0.0",
+			},
+			{
+				Name: "actions/setup-go",
+			},
+		},
+	}
+
+	denyAll := &Rule{
+		Name:   "Deny default",
+		Method: "deny",
+	}
+
+	requireSetupGo := &Rule{
+		Name:   "Require setup-go",
+		Method: "require",
+		Actions: []*ActionSelector{
+			{
+				Name:    "actions/setup-go",
+				Version: ">= 4.0.0",
+			},
+		},
+	}
+
+	tests := []struct {
+		Name       string
+		Rules      []*Rule
+		ExpPR      bool
+		ExpContent string
+		ExpBody    []string
+	}{
+		{
+			Name:  "UpdateAndCommentOut",
+			Rules: []*Rule{allowCheckout, denyAll, requireSetupGo},
+			ExpPR: true,
+			ExpContent: `name: "Fix Workflow"
+on: [push, pull_request]
+
+jobs:
+  build:
+    name: "Build"
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4.0.0
+      # Allstar: Action "evil/action" version v1 is denied by deny rule "Deny default" (member of rule group "Main").
+      # - name: Denied
+      #   uses: evil/action@v1
+      #   with:
+      #     token: x
+
+      - uses: actions/setup-go@v4.1.0
+      - run: go build
+`,
+			ExpBody: []string{
+				"Update `actions/checkout` from `v2` to `v4.0.0`",
+				"Update `actions/setup-go` from `v3` to `v4.1.0`",
+				"Comment out `evil/action@v1`",
+			},
+		},
+		{
+			Name: "PinAfterUpdate",
+			Rules: []*Rule{
+				allowCheckout,
+				{
+					Name:   "Allow evil",
+					Method: "allow",
+					Actions: []*ActionSelector{
+						{
+							Name: "evil/action",
+						},
+					},
+				},
+				denyAll,
+				{
+					Name:   "Pin all",
+					Method: "requirePinned",
+				},
+			},
+			ExpPR: true,
+			ExpContent: `name: "Fix Workflow"
+on: [push, pull_request]
+
+jobs:
+  build:
+    name: "Build"
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@4444444444444444444444444444444444444444 # v4.0.0
+      - name: Denied
+        uses: evil/action@1111111111111111111111111111111111111111 # v1
+        with:
+          token: x
+
+      - uses: actions/setup-go@v3
+      - run: go build
+`,
+			ExpBody: []string{
+				"Pin `actions/checkout@v2` to `4444444444444444444444444444444444444444` (v4.0.0)",
+				"Pin `evil/action@v1` to `1111111111111111111111111111111111111111` (v1)",
+			},
+		},
+		{
+			Name: "MissingRequiredNotFixable",
+			Rules: []*Rule{
+				{
+					Name:   "Require other",
+					Method: "require",
+					Actions: []*ActionSelector{
+						{
+							Name: "ossf/other-action",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name: "NoVersionSatisfies",
+			Rules: []*Rule{
+				{
+					Name:   "Require setup-go 5",
+					Method: "require",
+					Actions: []*ActionSelector{
+						{
+							Name:    "actions/setup-go",
+							Version: ">= 5.0.0",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	content, err := os.ReadFile(filepath.Join("test_workflows", "fix.yaml"))
+	if err != nil {
+		t.Fatalf("failed to open test workflow file: %v", err)
+	}
+
+	listTags = func(ctx context.Context, c *github.Client, owner, repo string) ([]*github.RepositoryTag, error) {
+		switch owner + "/" + repo {
+		case "actions/checkout":
+			return []*github.RepositoryTag{tag("v2.0.0"), commitTag("v4.0.0", strings.Repeat("4", 40)), tag("v3.1.0"), tag("latest")}, nil
+		case "actions/setup-go":
+			return []*github.RepositoryTag{tag("v3.0.0"), tag("v4.1.0")}, nil
+		case "evil/action":
+			return []*github.RepositoryTag{commitTag("v1", strings.Repeat("1", 40))}, nil
+		}
+		return nil, nil
+	}
+
+	getFileContent = noFiles
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client, owner, repo, path string,
+				ol config.ConfigLevel, out interface{}) error {
+				if ol == config.OrgLevel {
+					oc := out.(*OrgConfig)
+					*oc = OrgConfig{
+						Action: "fix",
+						Groups: []*RuleGroup{
+							{
+								Name:  "Main",
+								Rules: test.Rules,
+							},
+						},
+					}
+				}
+				return nil
+			}
+			listWorkflows = func(ctx context.Context, c *github.Client, owner, repo string) (
+				[]*workflowMetadata, error) {
+				workflow, errs := actionlint.Parse(content)
+				if len(errs) > 0 {
+					t.Fatalf("parse errs: %v", errs)
+				}
+				return []*workflowMetadata{
+					{
+						filename: "fix.yaml",
+						path:     ".github/workflows/fix.yaml",
+						workflow: workflow,
+						content:  content,
+					},
+				}, nil
+			}
+			var got *pullrequest.Request
+			pullrequestEnsure = func(ctx context.Context, c *github.Client, owner, repo, policy string,
+				pr *pullrequest.Request) (*github.PullRequest, error) {
+				got = pr
+				return &github.PullRequest{}, nil
+			}
+
+			if err := NewAction().Fix(context.Background(), nil, "thisorg", "thisrepo"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if (got != nil) != test.ExpPR {
+				t.Fatalf("Unexpected pull request, want %v got %v", test.ExpPR, got)
+			}
+			if got == nil {
+				return
+			}
+			if got.Branch != fixBranch || len(got.Files) != 1 || got.Files[0].Path != ".github/workflows/fix.yaml" {
+				t.Fatalf("Unexpected pull request: %v", got)
+			}
+			if diff := cmp.Diff(test.ExpContent, string(got.Files[0].Content)); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+			for _, b := range test.ExpBody {
+				if !strings.Contains(got.Body, b) {
+					t.Errorf("%q does not contain %q", got.Body, b)
+				}
+			}
+		})
+	}
+}
+
+const noPermissionsWorkflow = `name: Build
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+`
+
+const writeAllWorkflow = `name: Release
+on: push
+permissions: write-all
+jobs:
+  release:
+    runs-on: ubuntu-latest
+    permissions: write-all
+    steps:
+      - uses: actions/checkout@v4
+`
+
+const prTargetWorkflow = `name: Label
+on: pull_request_target
+permissions:
+  contents: read
+jobs:
+  label:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+        with:
+          ref: ${{ github.event.pull_request.head.sha }}
+  script:
+    runs-on: ubuntu-latest
+    steps:
+      - run: gh pr checkout ${{ github.event.number }}
+  safe:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+`
+
+func TestCheckWorkflows(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Workflows  WorkflowConfig
+		ExpPass    bool
+		ExpDetails []*WorkflowFindings
+	}{
+		{
+			Name:    "Disabled",
+			ExpPass: true,
+		},
+		{
+			Name: "RequirePermissions",
+			Workflows: WorkflowConfig{
+				RequirePermissions: true,
+			},
+			ExpPass: false,
+			ExpDetails: []*WorkflowFindings{
+				{
+					Workflow: ".github/workflows/build.yaml",
+					Findings: []string{"No top-level permissions block, the GITHUB_TOKEN has the default permissions."},
+				},
+			},
+		},
+		{
+			Name: "DenyWriteAll",
+			Workflows: WorkflowConfig{
+				DenyWriteAll: true,
+			},
+			ExpPass: false,
+			ExpDetails: []*WorkflowFindings{
+				{
+					Workflow: ".github/workflows/release.yaml",
+					Findings: []string{
+						"GITHUB_TOKEN is granted write-all permissions.",
+						"Job \"release\" grants the GITHUB_TOKEN write-all permissions.",
+					},
+				},
+			},
+		},
+		{
+			Name: "DenyPullRequestTargetCheckout",
+			Workflows: WorkflowConfig{
+				DenyPullRequestTargetCheckout: true,
+			},
+			ExpPass: false,
+			ExpDetails: []*WorkflowFindings{
+				{
+					Workflow: ".github/workflows/label.yaml",
+					Findings: []string{
+						"Job \"label\" checks out the pull request head on pull_request_target.",
+						"Job \"script\" checks out the pull request head on pull_request_target.",
+					},
+				},
+			},
+		},
+	}
+
+	workflows := map[string]string{
+		"build.yaml":   noPermissionsWorkflow,
+		"release.yaml": writeAllWorkflow,
+		"label.yaml":   prTargetWorkflow,
+	}
+
+	exemptionsApply = noExemptions
+
+	getFileContent = noFiles
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client, owner, repo, path string,
+				ol config.ConfigLevel, out interface{}) error {
+				if ol == config.OrgLevel {
+					oc := out.(*OrgConfig)
+					*oc = OrgConfig{
+						Action:    "issue",
+						Workflows: test.Workflows,
+					}
+				}
+				return nil
+			}
+			listWorkflows = func(ctx context.Context, c *github.Client, owner, repo string) (
+				[]*workflowMetadata, error) {
+				var wfs []*workflowMetadata
+				for name, content := range workflows {
+					wf, errs := actionlint.Parse([]byte(content))
+					if len(errs) > 0 {
+						t.Fatalf("Unexpected parse errors in %v: %v", name, errs)
+					}
+					wfs = append(wfs, &workflowMetadata{
+						filename: name,
+						path:     ".github/workflows/" + name,
+						workflow: wf,
+					})
+				}
+				return wfs, nil
+			}
+
+			res, err := NewAction().Check(context.Background(), nil, "thisorg", "thisrepo")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Pass != test.ExpPass {
+				t.Errorf("Expect pass = %t, got pass = %t", test.ExpPass, res.Pass)
+				t.Logf("NotifyText:\n%s", res.NotifyText)
+			}
+			if diff := cmp.Diff(test.ExpDetails, res.Details.(details).Workflows); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCheckTransitive(t *testing.T) {
+	// files are keyed by "owner/repo/path@ref".
+	files := map[string]string{
+		"thisorg/thisrepo/.github/actions/setup/action.yml@": `
+name: Setup
+runs:
+  using: composite
+  steps:
+    - uses: actions/setup-go@v5
+    - uses: risky/action@v1
+    - run: make
+`,
+		"acme/workflows/.github/workflows/scan.yml@v1": `
+on: workflow_call
+jobs:
+  scan:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: risky/scanner@v2
+`,
+		"actions/checkout/action.yml@v4": `
+name: Checkout
+runs:
+  using: node20
+  main: dist/index.js
+`,
+	}
+	var fetched []string
+	getFileContent = func(ctx context.Context, c *github.Client, owner, repo, path, ref string) ([]byte, error) {
+		k := fmt.Sprintf("%s/%s/%s@%s", owner, repo, path, ref)
+		fetched = append(fetched, k)
+		if s, ok := files[k]; ok {
+			return []byte(s), nil
+		}
+		return nil, nil
+	}
+	configFetchConfig = func(ctx context.Context, c *github.Client, owner, repo, path string,
+		ol config.ConfigLevel, out interface{}) error {
+		if ol == config.OrgLevel {
+			oc := out.(*OrgConfig)
+			*oc = OrgConfig{
+				Action: "fix",
+				Groups: []*RuleGroup{
+					{
+						Name: "Main",
+						Rules: []*Rule{
+							{
+								Name:    "Deny risky",
+								Method:  "deny",
+								Actions: []*ActionSelector{{Name: "risky/*"}},
+							},
+						},
+					},
+				},
+			}
+		}
+		return nil
+	}
+	listWorkflows = func(ctx context.Context, c *github.Client, owner, repo string) (
+		[]*workflowMetadata, error) {
+		d, err := os.ReadFile(filepath.Join("test_workflows", "reusable.yaml"))
+		if err != nil {
+			return nil, err
+		}
+		workflow, _ := actionlint.Parse(d)
+		return []*workflowMetadata{
+			{
+				filename: "reusable.yaml",
+				path:     ".github/workflows/reusable.yaml",
+				workflow: workflow,
+				content:  d,
+			},
+		}, nil
+	}
+	exemptionsApply = noExemptions
+
+	res, err := NewAction().Check(context.Background(), nil, "thisorg", "thisrepo")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if res.Pass {
+		t.Errorf("Expected fail, got pass")
+	}
+	for _, m := range []string{
+		`Action "risky/action" version v1 used via .github/workflows/reusable.yaml -> ./.github/actions/setup hit deny rule "Deny risky"`,
+		`Action "risky/scanner" version v2 used via .github/workflows/reusable.yaml -> acme/workflows/.github/workflows/scan.yml@v1 hit deny rule "Deny risky"`,
+	} {
+		if !strings.Contains(res.NotifyText, m) {
+			t.Errorf("%q does not contain %q", res.NotifyText, m)
+		}
+	}
+	if strings.Contains(res.NotifyText, "setup-go") {
+		t.Errorf("Unexpected Action followed two levels deep: %q", res.NotifyText)
+	}
+	wantFetched := []string{
+		"actions/checkout/action.yml@v4",
+		"thisorg/thisrepo/.github/actions/setup/action.yml@",
+		"acme/workflows/.github/workflows/scan.yml@v1",
+	}
+	if diff := cmp.Diff(wantFetched, fetched); diff != "" {
+		t.Errorf("Unexpected files fetched (-want +got):\n%s", diff)
+	}
+
+	// Actions used via other files are not fixed in the workflow.
+	pullrequestEnsure = func(ctx context.Context, c *github.Client, owner, repo, policy string,
+		pr *pullrequest.Request) (*github.PullRequest, error) {
+		t.Errorf("Unexpected pull request: %v", pr.Files)
+		return nil, nil
+	}
+	if err := fix(context.Background(), nil, "thisorg", "thisrepo"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
@@ -17,12 +17,22 @@ package action
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/cache"
 )
 
 var requireWorkflowOnForRequire = []string{"pull_request", "push"}
 
+// defaultRunEvents are the events of the workflow runs checked for MustPass,
+// unless set in the rule.
+var defaultRunEvents = []string{"push"}
+
+// commitSHARe matches a full length commit SHA.
+var commitSHARe = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
 // runInProgressStatuses is the set of workflow run statuses that are
 // acceptable when mustPass is true on a require rule and the conclusion is
 // not success.
@@ -30,7 +40,7 @@ var runInProgressStatuses = []string{"in_progress", "queued", "waiting", "reques
 
 // evaluateActionDenied evaluates an Action against a set of Rules
 func evaluateActionDenied(ctx context.Context, c *github.Client, rules []*internalRule, action *actionMetadata,
-	gc globCache, sc semverCache) (*denyRuleEvaluationResult, []error) {
+	gc *cache.GlobCache, sc *cache.SemverCache) (*denyRuleEvaluationResult, []error) {
 	result := &denyRuleEvaluationResult{
 		denied:         false,
 		actionMetadata: action,
@@ -39,6 +49,10 @@ func evaluateActionDenied(ctx context.Context, c *github.Client, rules []*intern
 	var errs []error
 
 	for _, r := range rules {
+		if r.Method == "requirePinned" {
+			// Evaluated separately, does not allow or deny Actions.
+			continue
+		}
 		// Check if the Action is matched by the rule's ActionSelectors
 		ruleMatch := false
 		errored := false
@@ -121,7 +135,7 @@ func evaluateActionDenied(ctx context.Context, c *github.Client, rules []*intern
 
 // evaluateRequireRule evaluates a require rule against a set of Actions
 func evaluateRequireRule(ctx context.Context, c *github.Client, owner, repo string, rule *internalRule,
-	actions []*actionMetadata, headSHA string, gc globCache, sc semverCache) (*requireRuleEvaluationResult, error) {
+	actions []*actionMetadata, headSHA string, gc *cache.GlobCache, sc *cache.SemverCache) (*requireRuleEvaluationResult, error) {
 	if rule.Method != "require" {
 		return nil, fmt.Errorf("rule is not a require rule")
 	}
@@ -147,7 +161,7 @@ func evaluateRequireRule(ctx context.Context, c *github.Client, owner, repo stri
 
 		// Find Action matching selector ra
 		for _, a := range actions {
-			match, fixMethod, err := requireActionDetermineFix(ctx, c, owner, repo, ra, a, rule.MustPass, headSHA, gc, sc)
+			match, fixMethod, err := requireActionDetermineFix(ctx, c, owner, repo, ra, a, rule.Rule, headSHA, gc, sc)
 
 			if err != nil {
 				return nil, err
@@ -167,6 +181,8 @@ func evaluateRequireRule(ctx context.Context, c *github.Client, owner, repo stri
 					workflowName:            a.workflowName,
 					actionName:              ra.Name,
 					actionVersionConstraint: ra.Version,
+					actionMetadata:          a,
+					events:                  rule.requiredOn(),
 				}
 				break
 			}
@@ -202,7 +218,7 @@ func evaluateRequireRule(ctx context.Context, c *github.Client, owner, repo stri
 //   - on error, the match bool is false AND fix method will not be usable.
 //   - on match true, the fix method is not to be used.
 func requireActionDetermineFix(ctx context.Context, c *github.Client, owner, repo string, ra *ActionSelector, a *actionMetadata,
-	mustPass bool, headSHA string, gc globCache, sc semverCache) (match bool, fix requireRuleEvaluationFixMethod, err error) {
+	rule *Rule, headSHA string, gc *cache.GlobCache, sc *cache.SemverCache) (match bool, fix requireRuleEvaluationFixMethod, err error) {
 	match, matchName, _, err := ra.match(ctx, c, a, gc, sc)
 	if err != nil {
 		return false, 0, err
@@ -221,7 +237,7 @@ func requireActionDetermineFix(ctx context.Context, c *github.Client, owner, rep
 		on[o.EventName()] = struct{}{}
 	}
 	hasRequired := true
-	for _, requireOn := range requireWorkflowOnForRequire {
+	for _, requireOn := range rule.requiredOn() {
 		if _, ok := on[requireOn]; !ok {
 			hasRequired = false
 		}
@@ -231,38 +247,122 @@ func requireActionDetermineFix(ctx context.Context, c *github.Client, owner, rep
 		return false, requireRuleEvaluationFixMethodEnable, nil
 	}
 
-	if !mustPass {
+	if !rule.MustPass {
 		// This action matches and is not required to pass
 		return true, 0, nil
 	}
 
-	// Check if passing (if the Action is required to be)
-	runs, err := listWorkflowRunsByFilename(ctx, c, owner, repo, a.workflowFilename)
-	if err != nil {
-		return false, 0, err
+	// Check if passing (if the Action is required to be). The latest relevant
+	// run of each event is checked, any failing fails the rule.
+	passing := false
+	for _, event := range rule.runEvents() {
+		runs, err := listWorkflowRunsByFilename(ctx, c, owner, repo, a.workflowFilename, event)
+		if err != nil {
+			return false, 0, err
+		}
+		for _, run := range runs {
+			if event != "pull_request" && run.GetHeadSHA() != headSHA {
+				// Irrelevant run. Pull request runs are on the commits of
+				// the pull request, so the latest is relevant.
+				continue
+			}
+			inProgress := false
+			for _, s := range runInProgressStatuses {
+				if run.GetStatus() == s {
+					inProgress = true
+				}
+			}
+			if !inProgress && run.GetConclusion() != "success" {
+				// Not passing and this was the relevant run. Suggest fix.
+				return false, requireRuleEvaluationFixMethodFix, nil
+			}
+			// The run is passing, or isn't complete, so OK for now
+			passing = true
+			break
+		}
+	}
+	if passing {
+		return true, 0, nil
+	}
+	// No relevant run. Suggest fix.
+	return false, requireRuleEvaluationFixMethodFix, nil
+}
+
+// runEvents returns the events of the workflow runs checked for MustPass.
+func (r *Rule) runEvents() []string {
+	if len(r.RunEvents) > 0 {
+		return r.RunEvents
+	}
+	return defaultRunEvents
+}
+
+// requiredOn returns the events a workflow using a required Action must run
+// on.
+func (r *Rule) requiredOn() []string {
+	if len(r.RunEvents) > 0 {
+		return r.RunEvents
 	}
-	for _, run := range runs {
-		if run.GetHeadSHA() != headSHA {
-			// Irrelevant run
+	return requireWorkflowOnForRequire
+}
+
+// evaluateRequirePinnedRule evaluates a requirePinned rule against a set of
+// Actions. Only the names of the rule's ActionSelectors are used, the version
+// is ignored.
+func evaluateRequirePinnedRule(rule *internalRule, actions []*actionMetadata,
+	gc *cache.GlobCache) (*pinRuleEvaluationResult, error) {
+	if rule.Method != "requirePinned" {
+		return nil, fmt.Errorf("rule is not a requirePinned rule")
+	}
+	result := &pinRuleEvaluationResult{
+		rule: rule,
+	}
+	for _, a := range actions {
+		if strings.HasPrefix(a.name, "docker://") {
+			// Docker images are pinned by digest, not commit.
 			continue
 		}
-		inProgress := false
-		for _, s := range runInProgressStatuses {
-			if run.GetStatus() == s {
-				inProgress = true
+		selected := rule.Actions == nil
+		for _, as := range rule.Actions {
+			match, err := matchName(gc, as.Name, a.name)
+			if err != nil {
+				return nil, err
+			}
+			if match {
+				selected = true
+				break
 			}
 		}
-		if inProgress {
-			// The check run isn't complete, so OK for now
-			return true, 0, nil
+		if !selected {
+			continue
+		}
+		trusted := false
+		for _, t := range rule.TrustedActions {
+			match, err := matchName(gc, t, a.name)
+			if err != nil {
+				return nil, err
+			}
+			if match {
+				trusted = true
+				break
+			}
 		}
-		if run.GetConclusion() == "success" {
-			// The run is completed and passing!
-			return true, 0, nil
+		if trusted || commitSHARe.MatchString(a.version) {
+			continue
 		}
-		// Not passing and this was the matching commit
-		break
+		result.unpinned = append(result.unpinned, a)
 	}
-	// Not passing. Suggest fix.
-	return false, requireRuleEvaluationFixMethodFix, nil
+	return result, nil
+}
+
+// matchName checks if an Action name matches a glob, an empty glob matches
+// all names.
+func matchName(gc *cache.GlobCache, pattern, name string) (bool, error) {
+	if pattern == "" {
+		return true, nil
+	}
+	g, err := gc.Compile(pattern)
+	if err != nil {
+		return false, err
+	}
+	return g.Match(name), nil
 }
@@ -0,0 +1,400 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/pullrequest"
+	"github.com/rhysd/actionlint"
+	"github.com/rs/zerolog/log"
+)
+
+// fixBranch is the branch the Fix action proposes workflow changes from.
+const fixBranch = "allstar/actions"
+
+const fixTitle = "Update GitHub Actions to follow organization policy"
+
+const fixBody = "This updates the GitHub Actions used in workflows to follow the organization's %s policy.\n\n%s\nSteps using denied Actions are commented out rather than deleted. If that leaves a job without steps, the job must be updated or removed before merging."
+
+// workflowEdit is a change to the use of an Action in a workflow.
+type workflowEdit struct {
+	action *actionMetadata
+
+	// version is the version to update the Action to. Empty to comment out
+	// the step.
+	version string
+
+	// reason explains why the step is commented out.
+	reason string
+
+	// comment is added to the end of the uses line, the tag an Action is
+	// pinned to.
+	comment string
+}
+
+func fix(ctx context.Context, c *github.Client, owner, repo string) error {
+	oc := getConfig(ctx, c, owner, repo)
+	if oc.Groups == nil {
+		return nil
+	}
+	wfs, err := listWorkflows(ctx, c, owner, repo)
+	if err != nil {
+		return err
+	}
+	rules, results, err := evaluate(ctx, c, owner, repo, oc, wfs)
+	if err != nil {
+		return err
+	}
+
+	var edits []*workflowEdit
+	edited := map[*actionMetadata]*workflowEdit{}
+
+	// Denied Actions are updated to a version allowed by a higher priority
+	// rule if possible, otherwise the step is commented out.
+	for _, r := range results {
+		dr, ok := r.(*denyRuleEvaluationResult)
+		if !ok || dr.passed() {
+			continue
+		}
+		a := dr.actionMetadata
+		if len(a.via) > 0 {
+			// Used by a reusable workflow or composite Action, which must
+			// be changed where it is defined.
+			continue
+		}
+		e := &workflowEdit{
+			action: a,
+			reason: fmt.Sprintf("Action \"%s\" version %s is denied by %s.", a.name, a.version, dr.denyingRule.string(false)),
+		}
+		for _, s := range dr.steps {
+			if s.status != denyRuleStepStatusActionVersionMismatch || s.rule.Method == "deny" {
+				continue
+			}
+			v, err := allowedVersion(ctx, c, rules, a, s.ruleVersionConstraint)
+			if err != nil {
+				log.Warn().
+					Str("org", owner).
+					Str("repo", repo).
+					Str("area", polName).
+					Str("action", a.name).
+					Err(err).
+					Msg("Error finding allowed Action version, will comment out.")
+				break
+			}
+			if v != "" {
+				e.version = v
+				break
+			}
+		}
+		edits = append(edits, e)
+		edited[a] = e
+	}
+
+	// Required Actions in use at the wrong version are updated.
+	for _, r := range results {
+		rr, ok := r.(*requireRuleEvaluationResult)
+		if !ok || rr.passed() {
+			continue
+		}
+		for _, f := range rr.fixes {
+			a := f.actionMetadata
+			if f.fixMethod != requireRuleEvaluationFixMethodUpdate || a == nil {
+				continue
+			}
+			if _, ok := edited[a]; ok {
+				continue
+			}
+			v, err := allowedVersion(ctx, c, rules, a, f.actionVersionConstraint)
+			if err != nil {
+				log.Warn().
+					Str("org", owner).
+					Str("repo", repo).
+					Str("area", polName).
+					Str("action", a.name).
+					Err(err).
+					Msg("Error finding required Action version, skipping.")
+				continue
+			}
+			if v == "" {
+				continue
+			}
+			e := &workflowEdit{action: a, version: v}
+			edits = append(edits, e)
+			edited[a] = e
+		}
+	}
+
+	// Unpinned Actions are pinned to the commit of their tag, after any
+	// update above.
+	for _, r := range results {
+		pr, ok := r.(*pinRuleEvaluationResult)
+		if !ok || pr.passed() {
+			continue
+		}
+		for _, a := range pr.unpinned {
+			e, ok := edited[a]
+			if ok && e.version == "" {
+				// Commented out
+				continue
+			}
+			version := a.version
+			if ok {
+				version = e.version
+			}
+			sha, err := tagCommit(ctx, c, a, version)
+			if err != nil {
+				log.Warn().
+					Str("org", owner).
+					Str("repo", repo).
+					Str("area", polName).
+					Str("action", a.name).
+					Err(err).
+					Msg("Error finding Action commit to pin, skipping.")
+				continue
+			}
+			if sha == "" {
+				// Not a tag, eg: a branch, which can not be pinned safely.
+				continue
+			}
+			if !ok {
+				e = &workflowEdit{action: a}
+				edits = append(edits, e)
+				edited[a] = e
+			}
+			e.version = sha
+			e.comment = version
+		}
+	}
+
+	files, summary := applyEdits(edits)
+	if len(files) == 0 {
+		log.Info().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", polName).
+			Msg("No workflow changes can be proposed with Fix action.")
+		return nil
+	}
+	_, err = pullrequestEnsure(ctx, c, owner, repo, polName, &pullrequest.Request{
+		Branch: fixBranch,
+		Title:  fixTitle,
+		Body:   fmt.Sprintf(fixBody, polName, summary),
+		Files:  files,
+	})
+	return err
+}
+
+// allowedVersion returns the highest version of the Action that satisfies
+// constraint and is not denied by the rules, or empty if there is none. A
+// constraint that is not a semver constraint is a ref, which is returned
+// as-is.
+func allowedVersion(ctx context.Context, c *github.Client, rules sortableRules,
+	a *actionMetadata, constraint string) (string, error) {
+	var candidates []string
+	if cs, err := sc.Constraints(constraint); err != nil {
+		candidates = []string{constraint}
+	} else {
+		// Action names may include a path within the Action repo, eg:
+		// "github/codeql-action/init".
+		ownerRepo := strings.SplitN(a.name, "/", 3)
+		if len(ownerRepo) < 2 {
+			return "", fmt.Errorf("invalid name \"%s\"", a.name)
+		}
+		tags, err := listTags(ctx, c, ownerRepo[0], ownerRepo[1])
+		if err != nil {
+			return "", err
+		}
+		type tagVersion struct {
+			name    string
+			version *semver.Version
+		}
+		var tvs []tagVersion
+		for _, t := range tags {
+			v, err := sc.Version(t.GetName())
+			if err != nil || !cs.Check(v) {
+				continue
+			}
+			tvs = append(tvs, tagVersion{t.GetName(), v})
+		}
+		sort.SliceStable(tvs, func(i, j int) bool {
+			return tvs[i].version.GreaterThan(tvs[j].version)
+		})
+		for _, tv := range tvs {
+			candidates = append(candidates, tv.name)
+		}
+	}
+	for _, v := range candidates {
+		updated := *a
+		updated.version = v
+		dr, errs := evaluateActionDenied(ctx, c, rules, &updated, gc, sc)
+		if len(errs) > 0 {
+			return "", errs[0]
+		}
+		if !dr.denied {
+			return v, nil
+		}
+	}
+	return "", nil
+}
+
+// tagCommit returns the commit SHA of the Action's tag named version, or empty
+// if there is no such tag.
+func tagCommit(ctx context.Context, c *github.Client, a *actionMetadata, version string) (string, error) {
+	ownerRepo := strings.SplitN(a.name, "/", 3)
+	if len(ownerRepo) < 2 {
+		return "", fmt.Errorf("invalid name \"%s\"", a.name)
+	}
+	tags, err := listTags(ctx, c, ownerRepo[0], ownerRepo[1])
+	if err != nil {
+		return "", err
+	}
+	for _, t := range tags {
+		if t.GetName() == version {
+			return t.GetCommit().GetSHA(), nil
+		}
+	}
+	return "", nil
+}
+
+// applyEdits applies the edits to the workflow files. Returns the changed files
+// and a markdown list of the changes made.
+func applyEdits(edits []*workflowEdit) ([]pullrequest.File, string) {
+	byWorkflow := map[*workflowMetadata][]*workflowEdit{}
+	var workflows []*workflowMetadata
+	for _, e := range edits {
+		wf := e.action.workflow
+		if wf == nil || wf.content == nil || e.action.step == nil || e.action.step.Pos == nil {
+			continue
+		}
+		if _, ok := byWorkflow[wf]; !ok {
+			workflows = append(workflows, wf)
+		}
+		byWorkflow[wf] = append(byWorkflow[wf], e)
+	}
+
+	var files []pullrequest.File
+	var summary strings.Builder
+	for _, wf := range workflows {
+		es := byWorkflow[wf]
+		lines := strings.Split(string(wf.content), "\n")
+		var changes []string
+		// Update versions first, then comment out steps from the bottom up,
+		// so that line numbers remain valid.
+		for _, e := range es {
+			if e.version == "" {
+				continue
+			}
+			if !updateVersion(lines, e) {
+				continue
+			}
+			if e.comment != "" {
+				changes = append(changes, fmt.Sprintf("Pin `%s@%s` to `%s` (%s)", e.action.name, e.action.version, e.version, e.comment))
+			} else {
+				changes = append(changes, fmt.Sprintf("Update `%s` from `%s` to `%s`", e.action.name, e.action.version, e.version))
+			}
+		}
+		sort.SliceStable(es, func(i, j int) bool {
+			return es[i].action.step.Pos.Line > es[j].action.step.Pos.Line
+		})
+		for _, e := range es {
+			if e.version != "" {
+				continue
+			}
+			var ok bool
+			if lines, ok = commentOutStep(lines, e.action, e.reason); ok {
+				changes = append(changes, fmt.Sprintf("Comment out `%s@%s`: %s", e.action.name, e.action.version, e.reason))
+			}
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		files = append(files, pullrequest.File{
+			Path:    wf.path,
+			Content: []byte(strings.Join(lines, "\n")),
+		})
+		fmt.Fprintf(&summary, "`%s`:\n", wf.path)
+		for _, ch := range changes {
+			fmt.Fprintf(&summary, "- %s\n", ch)
+		}
+	}
+	return files, summary.String()
+}
+
+// updateVersion changes the version in the uses line of the Action's step, and
+// adds the comment if the line has none.
+func updateVersion(lines []string, e *workflowEdit) bool {
+	a := e.action
+	exec, ok := a.step.Exec.(*actionlint.ExecAction)
+	if !ok || exec.Uses == nil || exec.Uses.Pos == nil {
+		return false
+	}
+	i := exec.Uses.Pos.Line - 1
+	if i < 0 || i >= len(lines) {
+		return false
+	}
+	old := a.name + "@" + a.version
+	if !strings.Contains(lines[i], old) {
+		return false
+	}
+	lines[i] = strings.Replace(lines[i], old, a.name+"@"+e.version, 1)
+	if e.comment != "" && !strings.Contains(lines[i], "#") {
+		lines[i] += " # " + e.comment
+	}
+	return true
+}
+
+// commentOutStep comments out the Action's step, with a comment explaining
+// why, and returns the updated lines. Only block style steps, starting with
+// "- " on their own line, are supported. Lines are inserted, so steps must be
+// commented out from the bottom of the file up.
+func commentOutStep(lines []string, a *actionMetadata, reason string) ([]string, bool) {
+	pos := a.step.Pos
+	first := pos.Line - 1
+	col := pos.Col - 1
+	if first < 0 || first >= len(lines) || col > len(lines[first]) {
+		return lines, false
+	}
+	prefix := strings.TrimRight(lines[first][:col], " ")
+	if strings.TrimSpace(prefix) != "-" {
+		return lines, false
+	}
+	dash := len(prefix) - 1
+	last := first
+	for i := first + 1; i < len(lines); i++ {
+		l := lines[i]
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		if len(l)-len(strings.TrimLeft(l, " ")) < col {
+			break
+		}
+		last = i
+	}
+	for i := first; i <= last; i++ {
+		if strings.TrimSpace(lines[i]) == "" {
+			continue
+		}
+		lines[i] = lines[i][:dash] + "# " + lines[i][dash:]
+	}
+	note := strings.Repeat(" ", dash) + "# Allstar: " + reason
+	lines = append(lines[:first], append([]string{note}, lines[first:]...)...)
+	return lines, true
+}
@@ -84,11 +84,16 @@ func (de *denyRuleEvaluationResult) explain() string {
 	if de.denyingRule == nil {
 		de.denyingRule = &internalRule{Rule: &Rule{Name: "Name unknown"}}
 	}
+	a := de.actionMetadata
+	via := ""
+	if len(a.via) > 0 {
+		via = fmt.Sprintf(" used via %s", strings.Join(a.via, " -> "))
+	}
 	s := ""
 	if de.denied {
-		s = fmt.Sprintf("Action \"%s\" version %s hit %s:\n", de.actionMetadata.name, de.actionMetadata.version, de.denyingRule.string(false))
+		s = fmt.Sprintf("Action \"%s\" version %s%s hit %s:\n", a.name, a.version, via, de.denyingRule.string(false))
 	} else {
-		s = fmt.Sprintf("Action \"%s\" version %s did not hit a deny rule.\n", de.actionMetadata.name, de.actionMetadata.version)
+		s = fmt.Sprintf("Action \"%s\" version %s%s did not hit a deny rule.\n", a.name, a.version, via)
 	}
 	// Add step results
 	for _, stepResult := range de.steps {
@@ -148,6 +153,14 @@ type requireRuleEvaluationFix struct {
 	actionName string
 
 	actionVersionConstraint string
+
+	// actionMetadata is the closest matching Action in use, if any. Set for
+	// requireRuleEvaluationFixMethodUpdate.
+	actionMetadata *actionMetadata
+
+	// events are the events the workflow must run on. Set for
+	// requireRuleEvaluationFixMethodEnable.
+	events []string
 }
 
 func (re *requireRuleEvaluationResult) passed() bool {
@@ -189,12 +202,40 @@ func (rf *requireRuleEvaluationFix) string() string {
 	case requireRuleEvaluationFixMethodUpdate:
 		return fmt.Sprintf("Update Action \"%s\" to version satisfying \"%s\"", rf.actionName, rf.actionVersionConstraint)
 	case requireRuleEvaluationFixMethodEnable:
-		return fmt.Sprintf("Enable workflow \"%s\" containing Action \"%s\" to run on %v.", rf.workflowName, rf.actionName, strings.Join(requireWorkflowOnForRequire, " and "))
+		return fmt.Sprintf("Enable workflow \"%s\" containing Action \"%s\" to run on %v.", rf.workflowName, rf.actionName, strings.Join(rf.events, " and "))
 	default:
 		return "unknown require rule eval fix"
 	}
 }
 
+// pinRuleEvaluationResult represents the result of a requirePinned rule
+// evaluation.
+type pinRuleEvaluationResult struct {
+	rule *internalRule
+
+	// unpinned are the selected Actions not pinned to a full commit SHA.
+	unpinned []*actionMetadata
+}
+
+func (pe *pinRuleEvaluationResult) passed() bool {
+	return len(pe.unpinned) == 0
+}
+
+func (pe *pinRuleEvaluationResult) explain() string {
+	if pe.passed() {
+		return fmt.Sprintf("%s satisfied.\n", pe.rule.string(true))
+	}
+	s := fmt.Sprintf("%s not satisfied:\n", pe.rule.string(true))
+	for _, a := range pe.unpinned {
+		s += fmt.Sprintf("-> Action \"%s\" version %s in workflow \"%s\" is not pinned to a full commit SHA\n", a.name, a.version, a.workflowName)
+	}
+	return s
+}
+
+func (pe *pinRuleEvaluationResult) relevantRule() *internalRule {
+	return pe.rule
+}
+
 func (r *internalRule) string(capitalize bool) string {
 	groupName := "Unknown"
 	if r.group != nil {
@@ -207,7 +248,7 @@ func (r *internalRule) string(capitalize bool) string {
 	displayMethod := r.Method
 	if capitalize {
 		if len(r.Method) > 2 {
-			displayMethod = strings.ToUpper(r.Method[:1]) + strings.ToLower(r.Method[1:])
+			displayMethod = strings.ToUpper(r.Method[:1]) + r.Method[1:]
 		}
 	}
 	ruleDesc := fmt.Sprintf("%s rule \"%s\"", displayMethod, r.Name)
@@ -0,0 +1,215 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rhysd/actionlint"
+	"github.com/rs/zerolog/log"
+	"sigs.k8s.io/yaml"
+)
+
+// maxTransitive limits the number of reusable workflows and composite Actions
+// followed per repo.
+const maxTransitive = 50
+
+// compositeAction is the part of an Action's metadata file needed to find the
+// Actions used by a composite Action.
+type compositeAction struct {
+	Runs struct {
+		Using string `json:"using"`
+		Steps []struct {
+			Uses string `json:"uses"`
+		} `json:"steps"`
+	} `json:"runs"`
+}
+
+// getFileContent returns the content of a file in a repo at ref, or the
+// default branch if empty. Returns nil if the file is not found.
+var getFileContent func(ctx context.Context, c *github.Client, owner, repo, path, ref string) ([]byte, error)
+
+// transitiveActions returns the Actions used by the reusable workflows and
+// composite Actions that the workflows use, one level deep, with the chain of
+// uses that leads to them. Local reusable workflows in wfs are evaluated as
+// workflows of the repo, and are not followed. Each reference is followed
+// once, from the first workflow using it.
+func transitiveActions(ctx context.Context, c *github.Client, owner, repo string,
+	wfs []*workflowMetadata) []*actionMetadata {
+	listed := make(map[string]bool)
+	for _, wf := range wfs {
+		listed[wf.path] = true
+	}
+	followed := make(map[string]bool)
+	var actions []*actionMetadata
+	for _, wf := range wfs {
+		for _, j := range sortedJobs(wf.workflow) {
+			var uses []string
+			if j.WorkflowCall != nil && j.WorkflowCall.Uses != nil {
+				uses = append(uses, j.WorkflowCall.Uses.Value)
+			}
+			for _, s := range j.Steps {
+				if s == nil || s.Exec == nil {
+					continue
+				}
+				if e, ok := s.Exec.(*actionlint.ExecAction); ok && e.Uses != nil {
+					uses = append(uses, e.Uses.Value)
+				}
+			}
+			for _, u := range uses {
+				if followed[u] || strings.HasPrefix(u, "docker://") {
+					continue
+				}
+				if strings.HasPrefix(u, "./") && listed[strings.TrimPrefix(u, "./")] {
+					continue
+				}
+				if len(followed) >= maxTransitive {
+					log.Warn().
+						Str("org", owner).
+						Str("repo", repo).
+						Str("area", polName).
+						Int("max", maxTransitive).
+						Msg("Too many reusable workflows and composite Actions, not following the rest.")
+					return actions
+				}
+				followed[u] = true
+				used, err := usedBy(ctx, c, owner, repo, u, j.WorkflowCall != nil)
+				if err != nil {
+					log.Warn().
+						Str("org", owner).
+						Str("repo", repo).
+						Str("area", polName).
+						Str("uses", u).
+						Err(err).
+						Msg("Error following uses, skipping.")
+					continue
+				}
+				for _, a := range used {
+					name, version, ok := strings.Cut(a, "@")
+					if !ok {
+						// Local to the caller, or invalid, not followed
+						// further.
+						continue
+					}
+					actions = append(actions, &actionMetadata{
+						name:             name,
+						version:          version,
+						workflowFilename: wf.filename,
+						workflowName:     wf.workflow.Name.Value,
+						workflowOn:       wf.workflow.On,
+						via:              []string{workflowPath(wf), u},
+					})
+				}
+			}
+		}
+	}
+	return actions
+}
+
+// usedBy returns the uses of the reusable workflow or composite Action
+// referenced by uses, or nothing if it is neither.
+func usedBy(ctx context.Context, c *github.Client, owner, repo, uses string, reusable bool) ([]string, error) {
+	o, r, p, ref := owner, repo, strings.TrimPrefix(uses, "./"), ""
+	if !strings.HasPrefix(uses, "./") {
+		name, version, ok := strings.Cut(uses, "@")
+		if !ok {
+			return nil, nil
+		}
+		parts := strings.SplitN(name, "/", 3)
+		if len(parts) < 2 {
+			return nil, nil
+		}
+		o, r, ref = parts[0], parts[1], version
+		p = ""
+		if len(parts) == 3 {
+			p = parts[2]
+		}
+	}
+	if reusable {
+		b, err := getFileContent(ctx, c, o, r, p, ref)
+		if err != nil || b == nil {
+			return nil, err
+		}
+		wf, _ := actionlint.Parse(b)
+		if wf == nil {
+			return nil, nil
+		}
+		var rv []string
+		for _, j := range sortedJobs(wf) {
+			for _, s := range j.Steps {
+				if s == nil || s.Exec == nil {
+					continue
+				}
+				if e, ok := s.Exec.(*actionlint.ExecAction); ok && e.Uses != nil {
+					rv = append(rv, e.Uses.Value)
+				}
+			}
+		}
+		return rv, nil
+	}
+	var b []byte
+	for _, f := range []string{"action.yml", "action.yaml"} {
+		var err error
+		b, err = getFileContent(ctx, c, o, r, path.Join(p, f), ref)
+		if err != nil {
+			return nil, err
+		}
+		if b != nil {
+			break
+		}
+	}
+	if b == nil {
+		return nil, nil
+	}
+	var ca compositeAction
+	if err := yaml.Unmarshal(b, &ca); err != nil {
+		return nil, err
+	}
+	if ca.Runs.Using != "composite" {
+		return nil, nil
+	}
+	var rv []string
+	for _, s := range ca.Runs.Steps {
+		if s.Uses != "" {
+			rv = append(rv, s.Uses)
+		}
+	}
+	return rv, nil
+}
+
+// getFileContentReal uses the GitHub API to get the content of a file.
+// Docs: https://docs.github.com/en/rest/repos/contents#get-repository-content
+func getFileContentReal(ctx context.Context, c *github.Client, owner, repo, path, ref string) ([]byte, error) {
+	fc, _, rsp, err := c.Repositories.GetContents(ctx, owner, repo, path, &github.RepositoryContentGetOptions{Ref: ref})
+	if err != nil {
+		if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if fc == nil {
+		// A directory
+		return nil, nil
+	}
+	s, err := fc.GetContent()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(s), nil
+}
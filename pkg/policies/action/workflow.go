@@ -0,0 +1,178 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package action
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rhysd/actionlint"
+)
+
+// WorkflowConfig configures workflow-level checks, applied to the workflows
+// of every repo.
+type WorkflowConfig struct {
+	// RequirePermissions fails workflows without a top-level permissions
+	// block, as the GITHUB_TOKEN then has the default permissions of the repo
+	// or org.
+	RequirePermissions bool `json:"requirePermissions"`
+
+	// DenyWriteAll fails workflows that grant the GITHUB_TOKEN write-all
+	// permissions, at the workflow or job level.
+	DenyWriteAll bool `json:"denyWriteAll"`
+
+	// DenyPullRequestTargetCheckout fails workflows triggered by
+	// pull_request_target that check out the head of the pull request, which
+	// runs untrusted code with access to secrets.
+	DenyPullRequestTargetCheckout bool `json:"denyPullRequestTargetCheckout"`
+}
+
+// enabled returns whether any workflow-level check is configured.
+func (wc WorkflowConfig) enabled() bool {
+	return wc.RequirePermissions || wc.DenyWriteAll || wc.DenyPullRequestTargetCheckout
+}
+
+// WorkflowFindings are the workflow-level check failures of a workflow.
+type WorkflowFindings struct {
+	// Workflow is the path of the workflow file.
+	Workflow string
+
+	// Findings explain each failure.
+	Findings []string
+}
+
+// prHeadRe matches expressions and refs that refer to the head of a pull
+// request.
+var prHeadRe = regexp.MustCompile(`github\.event\.pull_request\.head\.|github\.head_ref|refs/pull/`)
+
+// checkWorkflows runs the workflow-level checks configured in wc. Returns the
+// findings of failing workflows, sorted by path.
+func checkWorkflows(wfs []*workflowMetadata, wc WorkflowConfig) []*WorkflowFindings {
+	var rv []*WorkflowFindings
+	for _, wf := range wfs {
+		var f []string
+		if wc.RequirePermissions && wf.workflow.Permissions == nil {
+			f = append(f, "No top-level permissions block, the GITHUB_TOKEN has the default permissions.")
+		}
+		if wc.DenyWriteAll {
+			if writeAll(wf.workflow.Permissions) {
+				f = append(f, "GITHUB_TOKEN is granted write-all permissions.")
+			}
+			for _, j := range sortedJobs(wf.workflow) {
+				if writeAll(j.Permissions) {
+					f = append(f, fmt.Sprintf("Job %q grants the GITHUB_TOKEN write-all permissions.", j.ID.Value))
+				}
+			}
+		}
+		if wc.DenyPullRequestTargetCheckout && onPullRequestTarget(wf.workflow) {
+			for _, j := range sortedJobs(wf.workflow) {
+				if checksOutPRHead(j) {
+					f = append(f, fmt.Sprintf("Job %q checks out the pull request head on pull_request_target.", j.ID.Value))
+				}
+			}
+		}
+		if len(f) > 0 {
+			rv = append(rv, &WorkflowFindings{
+				Workflow: workflowPath(wf),
+				Findings: f,
+			})
+		}
+	}
+	sort.Slice(rv, func(i, j int) bool {
+		return rv[i].Workflow < rv[j].Workflow
+	})
+	return rv
+}
+
+// explainWorkflows formats findings for the notification text.
+func explainWorkflows(wfs []*WorkflowFindings) string {
+	var b strings.Builder
+	for _, wf := range wfs {
+		fmt.Fprintf(&b, "Workflow %s:\n", wf.Workflow)
+		for _, f := range wf.Findings {
+			fmt.Fprintf(&b, "  - %s\n", f)
+		}
+	}
+	return b.String()
+}
+
+func writeAll(p *actionlint.Permissions) bool {
+	return p != nil && p.All != nil && p.All.Value == "write-all"
+}
+
+func onPullRequestTarget(wf *actionlint.Workflow) bool {
+	for _, e := range wf.On {
+		if e.EventName() == "pull_request_target" {
+			return true
+		}
+	}
+	return false
+}
+
+// checksOutPRHead returns whether a job checks out the head of a pull request,
+// either with actions/checkout or a git or gh command.
+func checksOutPRHead(j *actionlint.Job) bool {
+	for _, s := range j.Steps {
+		if s == nil || s.Exec == nil {
+			continue
+		}
+		switch e := s.Exec.(type) {
+		case *actionlint.ExecAction:
+			if e.Uses == nil || !strings.HasPrefix(e.Uses.Value, "actions/checkout@") {
+				continue
+			}
+			if i, ok := e.Inputs["ref"]; ok && i != nil && i.Value != nil && prHeadRe.MatchString(i.Value.Value) {
+				return true
+			}
+		case *actionlint.ExecRun:
+			if e.Run == nil {
+				continue
+			}
+			r := e.Run.Value
+			if strings.Contains(r, "gh pr checkout") {
+				return true
+			}
+			if (strings.Contains(r, "git checkout") || strings.Contains(r, "git fetch")) && prHeadRe.MatchString(r) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// workflowPath returns the path of the workflow file, or its name if unknown.
+func workflowPath(wf *workflowMetadata) string {
+	if wf.path != "" {
+		return wf.path
+	}
+	return wf.filename
+}
+
+// sortedJobs returns the jobs of a workflow sorted by ID, for stable output.
+func sortedJobs(wf *actionlint.Workflow) []*actionlint.Job {
+	var js []*actionlint.Job
+	for _, j := range wf.Jobs {
+		if j == nil || j.ID == nil {
+			continue
+		}
+		js = append(js, j)
+	}
+	sort.Slice(js, func(i, k int) bool {
+		return js[i].ID.Value < js[k].ID.Value
+	})
+	return js
+}
@@ -17,10 +17,19 @@ package admin
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
 
-	"github.com/gobwas/glob"
 	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/exemptions"
+	"github.com/ossf/allstar/pkg/ghclients"
+	"github.com/ossf/allstar/pkg/issue"
 	"github.com/ossf/allstar/pkg/policydef"
+	"github.com/ossf/allstar/pkg/revert"
+	"github.com/ossf/allstar/pkg/selector"
 
 	"github.com/google/go-github/v59/github"
 	"github.com/rs/zerolog/log"
@@ -58,6 +67,13 @@ To add a user as administrator From the main page of the repository, go to Setti
 const maxNumberAdminTeamsText = `The number of teams with admin permission on this repository is greater than the allowed maximum value.
 `
 
+const fixedText = `The number of administrators of this repository was greater than the allowed maximum value, so Allstar downgraded these administrators:
+`
+
+const expiredText = "The exemption for repositories matching %q expired on %v, and is no longer applied.\n"
+
+const expiringText = "The exemption for repositories matching %q expires on %v, after which this policy may fail.\n"
+
 // OrgConfig is the org-level config definition for Repository Administrators
 // security policy.
 type OrgConfig struct {
@@ -72,6 +88,16 @@ type OrgConfig struct {
 	// administrators, default false.
 	OwnerlessAllowed bool `json:"ownerlessAllowed"`
 
+	// ImplicitOrgOwners defines if the organization owners, who administer
+	// all repositories, count as owners of repositories without any
+	// administrators, default false.
+	ImplicitOrgOwners bool `json:"implicitOrgOwners"`
+
+	// ImplicitOwnerTeams is a list of team slugs, eg: security-managers,
+	// whose members count as owners of repositories without any
+	// administrators.
+	ImplicitOwnerTeams []string `json:"implicitOwnerTeams"`
+
 	// Whether to allow users to be admins on a repo. If false then only teams can be admins. Default true.
 	UserAdminsAllowed bool `json:"userAdminsAllowed"`
 
@@ -86,6 +112,21 @@ type OrgConfig struct {
 	// It only takes effect if a value > 0 is specified. If you wish to disallow admin teams in general, please use the teamAdminsAllowed bool instead.
 	MaxNumberAdminTeams int `json:"maxNumberAdminTeams"`
 
+	// FixPermission is the permission the fix action downgrades users and
+	// teams over MaxNumberUserAdmins and MaxNumberAdminTeams to, one of
+	// "maintain" or "write", default "maintain".
+	FixPermission string `json:"fixPermission"`
+
+	// KeepUserAdmins is an ordered list of user logins to keep as
+	// administrators when the fix action downgrades users over
+	// MaxNumberUserAdmins. Users not listed are downgraded first.
+	KeepUserAdmins []string `json:"keepUserAdmins"`
+
+	// KeepAdminTeams is an ordered list of team slugs to keep as
+	// administrators when the fix action downgrades teams over
+	// MaxNumberAdminTeams. Teams not listed are downgraded first.
+	KeepAdminTeams []string `json:"keepAdminTeams"`
+
 	// Exemptions is a list of repo-bool pairings to exempt.
 	// Exemptions are only defined at the org level because they should be made
 	// obvious to org security managers.
@@ -104,6 +145,14 @@ type RepoConfig struct {
 	// OwnerlessAllowed overrides the same setting in org-level, only if present.
 	OwnerlessAllowed *bool `json:"ownerlessAllowed"`
 
+	// ImplicitOrgOwners overrides the same setting in org-level, only if
+	// present.
+	ImplicitOrgOwners *bool `json:"implicitOrgOwners"`
+
+	// ImplicitOwnerTeams overrides the same setting in org-level, only if
+	// present.
+	ImplicitOwnerTeams []string `json:"implicitOwnerTeams"`
+
 	// UserAdminsAllowed overrides the same setting in org-level, only if present.
 	UserAdminsAllowed *bool `json:"userAdminsAllowed"`
 
@@ -115,26 +164,42 @@ type RepoConfig struct {
 
 	// MaxNumberAdminTeams overrides the same setting in org-level, only if present.
 	MaxNumberAdminTeams *int `json:"maxNumberAdminTeams"`
+
+	// FixPermission overrides the same setting in org-level, only if present.
+	FixPermission *string `json:"fixPermission"`
+
+	// KeepUserAdmins overrides the same setting in org-level, only if present.
+	KeepUserAdmins []string `json:"keepUserAdmins"`
+
+	// KeepAdminTeams overrides the same setting in org-level, only if present.
+	KeepAdminTeams []string `json:"keepAdminTeams"`
 }
 
 type mergedConfig struct {
 	Action              string
 	OwnerlessAllowed    bool
+	ImplicitOrgOwners   bool
+	ImplicitOwnerTeams  []string
 	UserAdminsAllowed   bool
 	TeamAdminsAllowed   bool
 	MaxNumberAdminTeams int
 	MaxNumberUserAdmins int
+	FixPermission       string
+	KeepUserAdmins      []string
+	KeepAdminTeams      []string
 	Exemptions          []*AdministratorExemption
 }
 
-type globCache map[string]glob.Glob
-
 // AdministratorExemption is an exemption entry for the Repository Administrators policy.
 type AdministratorExemption struct {
 
 	// Repo is a GitHub repo name. Globs are allowed.
 	Repo string `json:"repo"`
 
+	// Repos is a set of RepoSelectors selecting more repos, by name,
+	// language, or topic, for the exemption to apply to.
+	Repos []*selector.RepoSelector `json:"repos"`
+
 	// OwnerlessAllowed defines if repositories are allowed to have no
 	// administrators, default false.
 	OwnerlessAllowed bool `json:"ownerlessAllowed"`
@@ -158,20 +223,53 @@ type AdministratorExemption struct {
 	// The maximum number of teams with admin permissions on this repo that are allowed. It overrides the int value MaxNumberAdminTeams.
 	// It only takes effect if a value > 0 is specified. If you wish to disallow admin teams in general, please use the teamAdminsAllowed bool instead.
 	MaxNumberAdminTeams int `json:"maxNumberAdminTeams"`
+
+	// Expires is an optional date, in the form YYYY-MM-DD, after which the
+	// exemption is no longer applied. The policy warns about the exemption
+	// in the two weeks before it expires.
+	Expires string `json:"expires"`
 }
 
 type details struct {
 	Admins     []string
 	TeamAdmins []string
+	// OrgOwners are the organization owners, counted as owners of a
+	// repository without administrators when ImplicitOrgOwners is set.
+	OrgOwners []string
+	// OwnerTeams are the ImplicitOwnerTeams with members, counted as owners
+	// of a repository without administrators.
+	OwnerTeams []string
+	// ExpiredExemptions are the repo globs of exemptions for this repo that
+	// have expired.
+	ExpiredExemptions []string
+	// ExpiringExemptions are the repo globs of exemptions for this repo that
+	// expire soon.
+	ExpiringExemptions []string
 }
 
 var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
 
 var configIsEnabled func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig, c *github.Client, owner, repo string) (bool, error)
 
+var timeNow func() time.Time
+
+var exemptionsApply func(context.Context, *github.Client, string, string, string, *policydef.Result) *policydef.Result
+
+var listOrgOwners func(context.Context, *github.Client, string) ([]string, error)
+
+var listTeamMembers func(context.Context, *github.Client, string, string) ([]string, error)
+
+var issueEnsure func(context.Context, *github.Client, string, string, string, string) (*issue.Fallback, error)
+
 func init() {
 	configFetchConfig = config.FetchConfig
 	configIsEnabled = config.IsEnabled
+	timeNow = time.Now
+	exemptionsApply = exemptions.Apply
+	listOrgOwners = listOrgOwnersReal
+	listTeamMembers = listTeamMembersReal
+	issueEnsure = issue.Ensure
+	revert.Register(polName, revertFix)
 }
 
 // Admin is the Repository Administrator policy object, implements policydef.Policy.
@@ -189,6 +287,7 @@ func (a Admin) Name() string {
 }
 
 type repositories interface {
+	selector.Repositories
 	ListCollaborators(context.Context, string, string,
 		*github.ListCollaboratorsOptions) ([]*github.User, *github.Response, error)
 	ListTeams(context.Context, string, string, *github.ListOptions) (
@@ -199,7 +298,11 @@ type repositories interface {
 // configuration stored in the org/repo, implementing policydef.Policy.Check()
 func (a Admin) Check(ctx context.Context, c *github.Client, owner,
 	repo string) (*policydef.Result, error) {
-	return check(ctx, c.Repositories, c, owner, repo)
+	r, err := check(ctx, c.Repositories, c, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	return exemptionsApply(ctx, c, owner, repo, polName, r), nil
 }
 
 // Check whether this policy is enabled or not
@@ -218,37 +321,22 @@ func check(ctx context.Context, rep repositories, c *github.Client, owner,
 
 	mc := mergeConfig(oc, orc, rc, repo)
 
-	gc := globCache{}
-
 	var d details
-	Admins, err := getAdminUsers(ctx, rep, owner, repo, mc.Exemptions, gc)
+	var expiryText string
+	mc.Exemptions = exemptionsFor(ctx, selector.NewRepo(rep, owner, repo), mc.Exemptions)
+	mc.Exemptions, expiryText = filterExpired(owner, repo, mc.Exemptions, timeNow(), &d)
+	d.Admins, d.TeamAdmins, err = getAdmins(ctx, rep, owner, repo)
 	if err != nil {
 		return nil, err
 	}
-	d.Admins = Admins
 
-	opt := &github.ListOptions{
-		PerPage: 100,
-	}
-	var teams []*github.Team
-	for {
-		ts, resp, err := rep.ListTeams(ctx, owner, repo, opt)
-		if err != nil {
+	ownerless := (len(d.Admins)+len(d.TeamAdmins)) < 1 && !(mc.OwnerlessAllowed || isOwnerlessExempt(mc.Exemptions))
+	if ownerless {
+		if err := implicitOwners(ctx, c, owner, mc, &d); err != nil {
 			return nil, err
 		}
-		teams = append(teams, ts...)
-		if resp.NextPage == 0 {
-			break
-		}
-		opt.Page = resp.NextPage
+		ownerless = len(d.OrgOwners)+len(d.OwnerTeams) < 1
 	}
-	var teamAdmins []string
-	for _, t := range teams {
-		if t.GetPermissions()["admin"] {
-			teamAdmins = append(teamAdmins, t.GetSlug())
-		}
-	}
-	d.TeamAdmins = teamAdmins
 
 	rv := &policydef.Result{
 		Enabled: enabled,
@@ -257,52 +345,211 @@ func check(ctx context.Context, rep repositories, c *github.Client, owner,
 	}
 
 	// Test OwnerlessAllowed
-	if (len(d.Admins)+len(d.TeamAdmins)) < 1 && !(mc.OwnerlessAllowed || isOwnerlessExempt(repo, mc.Exemptions, gc)) {
+	if ownerless {
 		rv.Pass = false
 		rv.NotifyText = rv.NotifyText + ownerlessText
 	}
 
 	// Test UserAdminsAllowed
-	if len(d.Admins) > 0 && !(mc.UserAdminsAllowed || isUserAdminsExempt(repo, d.Admins, mc.Exemptions, gc)) {
+	if len(d.Admins) > 0 && !(mc.UserAdminsAllowed || isUserAdminsExempt(d.Admins, mc.Exemptions)) {
 		rv.Pass = false
 		rv.NotifyText = rv.NotifyText + userAdminsText
 	}
 
 	// Test MaxNumberUserAdmins exemption if it's defined
-	if !isMaxNumberUserAdminsExempt(repo, len(d.Admins), mc.Exemptions, gc, true) {
+	if !isMaxNumberUserAdminsExempt(len(d.Admins), mc.Exemptions, true) {
 		rv.Pass = false
 		rv.NotifyText = rv.NotifyText + maxNumberUserAdminsText
 	}
 
 	// Test MaxNumberUserAdmins
-	if mc.MaxNumberUserAdmins > 0 && len(d.Admins) > mc.MaxNumberUserAdmins && !isMaxNumberUserAdminsExempt(repo, len(d.Admins), mc.Exemptions, gc, false) {
+	if mc.MaxNumberUserAdmins > 0 && len(d.Admins) > mc.MaxNumberUserAdmins && !isMaxNumberUserAdminsExempt(len(d.Admins), mc.Exemptions, false) {
 		rv.Pass = false
 		rv.NotifyText = rv.NotifyText + maxNumberUserAdminsText
 	}
 
 	// Test TeamAdminsAllowed
-	if len(d.TeamAdmins) > 0 && !(mc.TeamAdminsAllowed || isTeamAdminsExempt(repo, d.TeamAdmins, mc.Exemptions, gc)) {
+	if len(d.TeamAdmins) > 0 && !(mc.TeamAdminsAllowed || isTeamAdminsExempt(d.TeamAdmins, mc.Exemptions)) {
 		rv.Pass = false
 		rv.NotifyText = rv.NotifyText + teamAdminsText
 	}
 
 	// Test MaxNumberAdminTeams exemption if it's defined
-	if !isMaxNumberAdminTeamsExempt(repo, len(d.TeamAdmins), mc.Exemptions, gc, true) {
+	if !isMaxNumberAdminTeamsExempt(len(d.TeamAdmins), mc.Exemptions, true) {
 		rv.Pass = false
 		rv.NotifyText = rv.NotifyText + maxNumberAdminTeamsText
 	}
 
 	// Test MaxNumberAdminTeams
-	if mc.MaxNumberAdminTeams > 0 && len(d.TeamAdmins) > mc.MaxNumberAdminTeams && !isMaxNumberAdminTeamsExempt(repo, len(d.TeamAdmins), mc.Exemptions, gc, false) {
+	if mc.MaxNumberAdminTeams > 0 && len(d.TeamAdmins) > mc.MaxNumberAdminTeams && !isMaxNumberAdminTeamsExempt(len(d.TeamAdmins), mc.Exemptions, false) {
 		rv.Pass = false
 		rv.NotifyText = rv.NotifyText + maxNumberAdminTeamsText
 	}
 
+	if expiryText != "" {
+		if rv.NotifyText != "" {
+			rv.NotifyText = rv.NotifyText + "\n"
+		}
+		rv.NotifyText = rv.NotifyText + expiryText
+	}
+
+	return rv, nil
+}
+
+// exemptionsFor returns the exemptions that apply to repo, those with a Repo
+// glob matching the repo name, or Repos selecting the repo.
+func exemptionsFor(ctx context.Context, repo *selector.Repo, ee []*AdministratorExemption) []*AdministratorExemption {
+	var rv []*AdministratorExemption
+	for _, e := range ee {
+		if e.Repo != "" {
+			match, err := selector.MatchName(e.Repo, repo.Name)
+			if err != nil {
+				log.Warn().
+					Str("repo", repo.Name).
+					Str("glob", e.Repo).
+					Err(err).
+					Msg("Unexpected error compiling the glob.")
+			} else if match {
+				rv = append(rv, e)
+				continue
+			}
+		}
+		match, err := selector.MatchAny(ctx, e.Repos, repo)
+		if err != nil {
+			log.Warn().
+				Str("org", repo.Owner).
+				Str("repo", repo.Name).
+				Str("area", polName).
+				Err(err).
+				Msg("Unexpected error matching exemption repo selectors.")
+		}
+		if match {
+			rv = append(rv, e)
+		}
+	}
+	return rv
+}
+
+// filterExpired returns the exemptions for repo that have not expired, and
+// text describing the expired and soon expiring exemptions, which are also
+// added to d.
+func filterExpired(owner, repo string, ee []*AdministratorExemption, now time.Time,
+	d *details) ([]*AdministratorExemption, string) {
+	var rv []*AdministratorExemption
+	var text string
+	for _, e := range ee {
+		status, err := config.CheckExpiry(e.Expires, now)
+		if err != nil {
+			log.Warn().
+				Str("org", owner).
+				Str("repo", repo).
+				Str("area", polName).
+				Str("glob", e.Repo).
+				Err(err).
+				Msg("Invalid exemption expiry, ignoring exemption.")
+		}
+		if status != config.ExpiryLapsed {
+			rv = append(rv, e)
+		}
+		if status != config.ExpiryLapsed && status != config.ExpirySoon {
+			continue
+		}
+		// Exemptions applied with Repos selectors are named by the repo.
+		name := e.Repo
+		if name == "" {
+			name = repo
+		}
+		if status == config.ExpiryLapsed {
+			d.ExpiredExemptions = append(d.ExpiredExemptions, name)
+			text = text + fmt.Sprintf(expiredText, name, e.Expires)
+		} else {
+			d.ExpiringExemptions = append(d.ExpiringExemptions, name)
+			text = text + fmt.Sprintf(expiringText, name, e.Expires)
+		}
+	}
+	return rv, text
+}
+
+// implicitOwners adds the organization owners and the owner teams with
+// members to d, as configured in mc.
+func implicitOwners(ctx context.Context, c *github.Client, owner string, mc *mergedConfig, d *details) error {
+	if mc.ImplicitOrgOwners {
+		owners, err := listOrgOwners(ctx, c, owner)
+		if err != nil {
+			return err
+		}
+		d.OrgOwners = owners
+	}
+	for _, t := range mc.ImplicitOwnerTeams {
+		members, err := listTeamMembers(ctx, c, owner, t)
+		if err != nil {
+			return err
+		}
+		if len(members) > 0 {
+			d.OwnerTeams = append(d.OwnerTeams, t)
+		}
+	}
+	return nil
+}
+
+// listOrgOwnersReal returns the logins of the organization owners.
+// Docs: https://docs.github.com/en/rest/orgs/members#list-organization-members
+func listOrgOwnersReal(ctx context.Context, c *github.Client, owner string) ([]string, error) {
+	opt := &github.ListMembersOptions{
+		Role:        "admin",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	var rv []string
+	for {
+		ms, resp, err := c.Organizations.ListMembers(ctx, owner, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range ms {
+			rv = append(rv, m.GetLogin())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return rv, nil
+}
+
+// listTeamMembersReal returns the logins of the members of the team. A team
+// that is not found is logged, and has no members.
+func listTeamMembersReal(ctx context.Context, c *github.Client, owner, slug string) ([]string, error) {
+	rv, err := ghclients.ListTeamMembers(ctx, c, owner, slug)
+	var e *github.ErrorResponse
+	if errors.As(err, &e) && e.Response != nil && e.Response.StatusCode == http.StatusNotFound {
+		log.Warn().
+			Str("org", owner).
+			Str("area", polName).
+			Str("team", slug).
+			Msg("Implicit owner team not found.")
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
 	return rv, nil
 }
 
-func getAdminUsers(ctx context.Context, r repositories, owner, repo string,
-	exemptions []*AdministratorExemption, gc globCache) ([]string, error) {
+// getAdmins returns the user and team administrators of the repo, the set
+// that is checked, and downgraded by the fix.
+func getAdmins(ctx context.Context, r repositories, owner, repo string) ([]string, []string, error) {
+	users, err := getAdminUsers(ctx, r, owner, repo)
+	if err != nil {
+		return nil, nil, err
+	}
+	teams, err := getAdminTeams(ctx, r, owner, repo)
+	if err != nil {
+		return nil, nil, err
+	}
+	return users, teams, nil
+}
+
+func getAdminUsers(ctx context.Context, r repositories, owner, repo string) ([]string, error) {
 	opt := &github.ListCollaboratorsOptions{
 		ListOptions: github.ListOptions{
 			PerPage: 100,
@@ -331,48 +578,56 @@ func getAdminUsers(ctx context.Context, r repositories, owner, repo string,
 	return rv, nil
 }
 
-func isOwnerlessExempt(repo string, ee []*AdministratorExemption, gc globCache) bool {
-	for _, e := range ee {
-		g, err := gc.compileGlob(e.Repo)
+func getAdminTeams(ctx context.Context, r repositories, owner, repo string) ([]string, error) {
+	opt := &github.ListOptions{
+		PerPage: 100,
+	}
+	var teams []*github.Team
+	for {
+		ts, resp, err := r.ListTeams(ctx, owner, repo, opt)
 		if err != nil {
-			log.Warn().
-				Str("repo", repo).
-				Str("glob", e.Repo).
-				Err(err).
-				Msg("Unexpected error compiling the glob.")
-		} else if g.Match(repo) && e.OwnerlessAllowed {
+			return nil, err
+		}
+		teams = append(teams, ts...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	var rv []string
+	for _, t := range teams {
+		if t.GetPermissions()["admin"] {
+			rv = append(rv, t.GetSlug())
+		}
+	}
+	return rv, nil
+}
+
+// The is*Exempt functions below are passed the exemptions that apply to the
+// repo, see exemptionsFor.
+
+func isOwnerlessExempt(ee []*AdministratorExemption) bool {
+	for _, e := range ee {
+		if e.OwnerlessAllowed {
 			return true
 		}
 	}
 	return false
 }
 
-func isUserAdminsExempt(repo string, userAdmins []string, ee []*AdministratorExemption, gc globCache) bool {
+func isUserAdminsExempt(userAdmins []string, ee []*AdministratorExemption) bool {
 	for _, e := range ee {
-		g, err := gc.compileGlob(e.Repo)
-		if err != nil {
-			log.Warn().
-				Str("repo", repo).
-				Str("glob", e.Repo).
-				Err(err).
-				Msg("Unexpected error compiling the glob.")
-		} else if g.Match(repo) && (e.UserAdminsAllowed || in(userAdmins, e.UserAdmins)) {
+		if e.UserAdminsAllowed || in(userAdmins, e.UserAdmins) {
 			return true
 		}
 	}
 	return false
 }
 
-func isTeamAdminsExempt(repo string, teamAdmins []string, ee []*AdministratorExemption, gc globCache) bool {
+func isTeamAdminsExempt(teamAdmins []string, ee []*AdministratorExemption) bool {
 	for _, e := range ee {
-		g, err := gc.compileGlob(e.Repo)
-		if err != nil {
-			log.Warn().
-				Str("repo", repo).
-				Str("glob", e.Repo).
-				Err(err).
-				Msg("Unexpected error compiling the glob.")
-		} else if g.Match(repo) && (e.TeamAdminsAllowed || in(teamAdmins, e.TeamAdmins)) {
+		if e.TeamAdminsAllowed || in(teamAdmins, e.TeamAdmins) {
 			return true
 		}
 	}
@@ -395,47 +650,208 @@ func in(admins []string, list []string) bool {
 	return true
 }
 
-func isMaxNumberUserAdminsExempt(repo string, adminsCount int, ee []*AdministratorExemption, gc globCache, def bool) bool {
+func isMaxNumberUserAdminsExempt(adminsCount int, ee []*AdministratorExemption, def bool) bool {
 	for _, e := range ee {
-		g, err := gc.compileGlob(e.Repo)
-		if err != nil {
-			log.Warn().
-				Str("repo", repo).
-				Str("glob", e.Repo).
-				Err(err).
-				Msg("Unexpected error compiling the glob.")
-		} else if g.Match(repo) && e.MaxNumberUserAdmins > 0 {
+		if e.MaxNumberUserAdmins > 0 {
 			return e.MaxNumberUserAdmins >= adminsCount
 		}
 	}
 	return def
 }
 
-func isMaxNumberAdminTeamsExempt(repo string, teamAdminsCount int, ee []*AdministratorExemption, gc globCache, def bool) bool {
+func isMaxNumberAdminTeamsExempt(teamAdminsCount int, ee []*AdministratorExemption, def bool) bool {
 	for _, e := range ee {
-		g, err := gc.compileGlob(e.Repo)
-		if err != nil {
-			log.Warn().
-				Str("repo", repo).
-				Str("glob", e.Repo).
-				Err(err).
-				Msg("Unexpected error compiling the glob.")
-		} else if g.Match(repo) && e.MaxNumberAdminTeams > 0 {
+		if e.MaxNumberAdminTeams > 0 {
 			return e.MaxNumberAdminTeams >= teamAdminsCount
 		}
 	}
 	return def
 }
 
-// Fix implementing policydef.Policy.Fix(). Currently not supported. Plan
-// to support this TODO.
+// admins is the part of the GitHub API the fix action uses to change the
+// permission of administrators.
+type admins interface {
+	AddCollaborator(context.Context, string, string, string,
+		*github.RepositoryAddCollaboratorOptions) (*github.CollaboratorInvitation, *github.Response, error)
+	AddTeamRepoBySlug(context.Context, string, string, string, string,
+		*github.TeamAddTeamRepoOptions) (*github.Response, error)
+}
+
+// adminsClient implements admins with a GitHub client.
+type adminsClient struct {
+	c *github.Client
+}
+
+func (a adminsClient) AddCollaborator(ctx context.Context, owner, repo, user string,
+	opts *github.RepositoryAddCollaboratorOptions) (*github.CollaboratorInvitation, *github.Response, error) {
+	return a.c.Repositories.AddCollaborator(ctx, owner, repo, user, opts)
+}
+
+func (a adminsClient) AddTeamRepoBySlug(ctx context.Context, org, slug, owner, repo string,
+	opts *github.TeamAddTeamRepoOptions) (*github.Response, error) {
+	return a.c.Teams.AddTeamRepoBySlug(ctx, org, slug, owner, repo, opts)
+}
+
+// fixPermissions maps the FixPermission setting to the permission named by the
+// collaborators and teams APIs.
+var fixPermissions = map[string]string{
+	"maintain": "maintain",
+	"write":    "push",
+}
+
+// Fix implementing policydef.Policy.Fix(). Users and teams over
+// MaxNumberUserAdmins and MaxNumberAdminTeams are downgraded to
+// FixPermission, except organization owners, and the changes are reported in
+// the policy issue. Other failures are not fixed.
 func (a Admin) Fix(ctx context.Context, c *github.Client, owner, repo string) error {
-	log.Warn().
-		Str("org", owner).
-		Str("repo", repo).
-		Str("area", polName).
-		Msg("Action fix is configured, but not implemented.")
-	return nil
+	return fix(ctx, c.Repositories, adminsClient{c}, c, owner, repo)
+}
+
+func fix(ctx context.Context, rep repositories, adm admins, c *github.Client, owner, repo string) error {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return nil
+	}
+	mc := mergeConfig(oc, orc, rc, repo)
+	var d details
+	mc.Exemptions = exemptionsFor(ctx, selector.NewRepo(rep, owner, repo), mc.Exemptions)
+	mc.Exemptions, _ = filterExpired(owner, repo, mc.Exemptions, timeNow(), &d)
+	perm, ok := fixPermissions[mc.FixPermission]
+	if !ok {
+		log.Warn().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", polName).
+			Str("fixPermission", mc.FixPermission).
+			Msg("Invalid fixPermission, administrators not downgraded.")
+		return nil
+	}
+	users, teams, err := getAdmins(ctx, rep, owner, repo)
+	if err != nil {
+		return err
+	}
+	// Organization owners administer every repository, whatever their
+	// collaborator permission, so they can not be downgraded. They are kept
+	// first, and still count towards the maximum, as in check.
+	var orgOwners []string
+	if len(excess(users, nil, maxUserAdmins(mc))) > 0 {
+		orgOwners, err = listOrgOwners(ctx, c, owner)
+		if err != nil {
+			return err
+		}
+	}
+
+	var changes []string
+	for _, u := range excess(users, append(orgOwners, mc.KeepUserAdmins...), maxUserAdmins(mc)) {
+		if in([]string{u}, orgOwners) {
+			log.Info().
+				Str("org", owner).
+				Str("repo", repo).
+				Str("area", polName).
+				Str("user", u).
+				Msg("User administrator is an organization owner, not downgraded.")
+			continue
+		}
+		recordPrior(ctx, owner, repo, u, false)
+		if _, _, err := adm.AddCollaborator(ctx, owner, repo, u,
+			&github.RepositoryAddCollaboratorOptions{Permission: perm}); err != nil {
+			return err
+		}
+		log.Info().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", polName).
+			Str("user", u).
+			Str("to", perm).
+			Msg("Downgraded user administrator.")
+		changes = append(changes, fmt.Sprintf("- User `%v`, to %v", u, mc.FixPermission))
+	}
+	for _, t := range excess(teams, mc.KeepAdminTeams, maxAdminTeams(mc)) {
+		recordPrior(ctx, owner, repo, t, true)
+		if _, err := adm.AddTeamRepoBySlug(ctx, owner, t, owner, repo,
+			&github.TeamAddTeamRepoOptions{Permission: perm}); err != nil {
+			return err
+		}
+		log.Info().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", polName).
+			Str("team", t).
+			Str("to", perm).
+			Msg("Downgraded team administrator.")
+		changes = append(changes, fmt.Sprintf("- Team `%v`, to %v", t, mc.FixPermission))
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	_, err = issueEnsure(ctx, c, owner, repo, polName, fixedText+strings.Join(changes, "\n")+"\n")
+	return err
+}
+
+// recordPrior records the admin permission of the user or team before it is
+// downgraded, see the revert package.
+func recordPrior(ctx context.Context, owner, repo, name string, team bool) {
+	if err := revert.Record(ctx, owner, repo, polName, name,
+		priorAdmin{Team: team, Permission: "admin"}); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", polName).
+			Str("admin", name).
+			Err(err).
+			Msg("Unexpected error recording permission before fix, it can not be reverted.")
+	}
+}
+
+// excess returns the admins to downgrade so that no more than max remain,
+// keeping those in keep, in its order, then the rest in the order listed. A
+// max of 0 or less is no limit.
+func excess(admins, keep []string, max int) []string {
+	if max <= 0 || len(admins) <= max {
+		return nil
+	}
+	var ordered []string
+	kept := make(map[string]bool)
+	for _, k := range keep {
+		for _, a := range admins {
+			if a == k && !kept[a] {
+				ordered = append(ordered, a)
+				kept[a] = true
+			}
+		}
+	}
+	for _, a := range admins {
+		if !kept[a] {
+			ordered = append(ordered, a)
+		}
+	}
+	return ordered[max:]
+}
+
+// maxUserAdmins returns the maximum number of user admins, from an exemption
+// if one sets it, as in check.
+func maxUserAdmins(mc *mergedConfig) int {
+	for _, e := range mc.Exemptions {
+		if e.MaxNumberUserAdmins > 0 {
+			return e.MaxNumberUserAdmins
+		}
+	}
+	return mc.MaxNumberUserAdmins
+}
+
+// maxAdminTeams returns the maximum number of admin teams, from an exemption
+// if one sets it, as in check.
+func maxAdminTeams(mc *mergedConfig) int {
+	for _, e := range mc.Exemptions {
+		if e.MaxNumberAdminTeams > 0 {
+			return e.MaxNumberAdminTeams
+		}
+	}
+	return mc.MaxNumberAdminTeams
 }
 
 // GetAction returns the configured action from this policy's
@@ -455,6 +871,7 @@ func getConfig(ctx context.Context, c *github.Client, owner, repo string) (*OrgC
 		MaxNumberUserAdmins: 0,
 		TeamAdminsAllowed:   true,
 		MaxNumberAdminTeams: 0,
+		FixPermission:       "maintain",
 	}
 	if err := configFetchConfig(ctx, c, owner, "", configFile, config.OrgLevel, oc); err != nil {
 		log.Error().
@@ -495,10 +912,15 @@ func mergeConfig(oc *OrgConfig, orc *RepoConfig, rc *RepoConfig, repo string) *m
 	mc := &mergedConfig{
 		Action:              oc.Action,
 		OwnerlessAllowed:    oc.OwnerlessAllowed,
+		ImplicitOrgOwners:   oc.ImplicitOrgOwners,
+		ImplicitOwnerTeams:  oc.ImplicitOwnerTeams,
 		UserAdminsAllowed:   oc.UserAdminsAllowed,
 		MaxNumberUserAdmins: oc.MaxNumberUserAdmins,
 		TeamAdminsAllowed:   oc.TeamAdminsAllowed,
 		MaxNumberAdminTeams: oc.MaxNumberAdminTeams,
+		FixPermission:       oc.FixPermission,
+		KeepUserAdmins:      oc.KeepUserAdmins,
+		KeepAdminTeams:      oc.KeepAdminTeams,
 		Exemptions:          oc.Exemptions,
 	}
 	mc = mergeInRepoConfig(mc, orc, repo)
@@ -516,6 +938,12 @@ func mergeInRepoConfig(mc *mergedConfig, rc *RepoConfig, repo string) *mergedCon
 	if rc.OwnerlessAllowed != nil {
 		mc.OwnerlessAllowed = *rc.OwnerlessAllowed
 	}
+	if rc.ImplicitOrgOwners != nil {
+		mc.ImplicitOrgOwners = *rc.ImplicitOrgOwners
+	}
+	if rc.ImplicitOwnerTeams != nil {
+		mc.ImplicitOwnerTeams = rc.ImplicitOwnerTeams
+	}
 	if rc.UserAdminsAllowed != nil {
 		mc.UserAdminsAllowed = *rc.UserAdminsAllowed
 	}
@@ -528,18 +956,14 @@ func mergeInRepoConfig(mc *mergedConfig, rc *RepoConfig, repo string) *mergedCon
 	if rc.MaxNumberAdminTeams != nil {
 		mc.MaxNumberAdminTeams = *rc.MaxNumberAdminTeams
 	}
-	return mc
-}
-
-// compileGlob returns cached glob if present, otherwise attempts glob.Compile.
-func (g globCache) compileGlob(s string) (glob.Glob, error) {
-	if glob, ok := g[s]; ok {
-		return glob, nil
+	if rc.FixPermission != nil {
+		mc.FixPermission = *rc.FixPermission
 	}
-	c, err := glob.Compile(s)
-	if err != nil {
-		return nil, err
+	if rc.KeepUserAdmins != nil {
+		mc.KeepUserAdmins = rc.KeepUserAdmins
+	}
+	if rc.KeepAdminTeams != nil {
+		mc.KeepAdminTeams = rc.KeepAdminTeams
 	}
-	g[s] = c
-	return c, nil
+	return mc
 }
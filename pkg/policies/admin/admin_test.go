@@ -16,12 +16,18 @@ package admin
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-github/v59/github"
 	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/configtest"
+	"github.com/ossf/allstar/pkg/issue"
 	"github.com/ossf/allstar/pkg/policydef"
+	"github.com/ossf/allstar/pkg/revert"
+	"github.com/ossf/allstar/pkg/selector"
 )
 
 var listCollaborators func(context.Context, string, string,
@@ -36,10 +42,38 @@ func (m mockRepos) ListCollaborators(ctx context.Context, o, r string,
 	return listCollaborators(ctx, o, r, op)
 }
 
+func (m mockRepos) Get(ctx context.Context, owner, repo string) (*github.Repository,
+	*github.Response, error) {
+	return &github.Repository{}, nil, nil
+}
+
+func (m mockRepos) ListLanguages(ctx context.Context, owner, repo string) (map[string]int,
+	*github.Response, error) {
+	return map[string]int{"Go": 10000}, nil, nil
+}
+
 func (m mockRepos) ListTeams(ctx context.Context, owner string, repo string, opts *github.ListOptions) ([]*github.Team, *github.Response, error) {
 	return listTeams(ctx, owner, repo, opts)
 }
 
+// changes are the permission changes made, "user:permission" or
+// "team/slug:permission".
+var changes []string
+
+type mockAdmins struct{}
+
+func (m mockAdmins) AddCollaborator(ctx context.Context, owner, repo, user string,
+	opts *github.RepositoryAddCollaboratorOptions) (*github.CollaboratorInvitation, *github.Response, error) {
+	changes = append(changes, user+":"+opts.Permission)
+	return nil, nil, nil
+}
+
+func (m mockAdmins) AddTeamRepoBySlug(ctx context.Context, org, slug, owner, repo string,
+	opts *github.TeamAddTeamRepoOptions) (*github.Response, error) {
+	changes = append(changes, "team/"+slug+":"+opts.Permission)
+	return nil, nil
+}
+
 func TestConfigPrecedence(t *testing.T) {
 	tests := []struct {
 		Name      string
@@ -211,6 +245,8 @@ func TestCheck(t *testing.T) {
 		cofigEnabled bool
 		Exp          policydef.Result
 		Teams        []*github.Team
+		OrgOwners    []string
+		TeamMembers  map[string][]string
 	}{
 		{
 			Name: "NotEnabled",
@@ -656,6 +692,49 @@ func TestCheck(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "Ownerless not allowed but allowed by a selector exemption and pass",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					OptOutStrategy: true,
+				},
+				OwnerlessAllowed:  false,
+				UserAdminsAllowed: true,
+				TeamAdminsAllowed: true,
+				Exemptions: []*AdministratorExemption{
+					{
+						Repos: []*selector.RepoSelector{
+							{Language: []string{"python"}},
+						},
+						OwnerlessAllowed: false,
+					},
+					{
+						Repos: []*selector.RepoSelector{
+							{Name: "this*", Language: []string{"go"}},
+						},
+						OwnerlessAllowed: true,
+					},
+				},
+			},
+			Repo: RepoConfig{},
+			Users: []*github.User{
+				&github.User{
+					Login: &alice,
+					Permissions: map[string]bool{
+						"push": true,
+					},
+				},
+			},
+			cofigEnabled: true,
+			Exp: policydef.Result{
+				Enabled:    true,
+				Pass:       true,
+				NotifyText: "",
+				Details: details{
+					Admins: nil,
+				},
+			},
+		},
 		{
 			Name: "Ownerless not allowed by an exemption and fail",
 			Org: OrgConfig{
@@ -1854,8 +1933,173 @@ func TestCheck(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "Ownerless allowed by an exemption expiring soon and pass",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					OptOutStrategy: true,
+				},
+				Exemptions: []*AdministratorExemption{
+					{
+						Repo:             "this*",
+						OwnerlessAllowed: true,
+						Expires:          "2025-09-10",
+					},
+				},
+			},
+			Repo:         RepoConfig{},
+			cofigEnabled: true,
+			Exp: policydef.Result{
+				Enabled:    true,
+				Pass:       true,
+				NotifyText: "The exemption for repositories matching \"this*\" expires on 2025-09-10, after which this policy may fail.\n",
+				Details: details{
+					ExpiringExemptions: []string{"this*"},
+				},
+			},
+		},
+		{
+			Name: "Ownerless not allowed by an expired exemption and fail",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					OptOutStrategy: true,
+				},
+				Exemptions: []*AdministratorExemption{
+					{
+						Repo:             "this*",
+						OwnerlessAllowed: true,
+						Expires:          "2025-08-31",
+					},
+				},
+			},
+			Repo:         RepoConfig{},
+			cofigEnabled: true,
+			Exp: policydef.Result{
+				Enabled:    true,
+				Pass:       false,
+				NotifyText: "Did not find any owners of this repository\nThis policy requires all repositories to have an organization member or team assigned as an administrator",
+				Details: details{
+					ExpiredExemptions: []string{"this*"},
+				},
+			},
+		},
+		{
+			Name: "Ownerless administered by org owners and pass",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					OptOutStrategy: true,
+				},
+				ImplicitOrgOwners: true,
+			},
+			Repo:         RepoConfig{},
+			OrgOwners:    []string{"alice"},
+			cofigEnabled: true,
+			Exp: policydef.Result{
+				Enabled:    true,
+				Pass:       true,
+				NotifyText: "",
+				Details: details{
+					OrgOwners: []string{"alice"},
+				},
+			},
+		},
+		{
+			Name: "Ownerless with org owners not implicit and fail",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					OptOutStrategy: true,
+				},
+				ImplicitOrgOwners: true,
+			},
+			Repo: RepoConfig{
+				ImplicitOrgOwners: github.Bool(false),
+			},
+			OrgOwners:    []string{"alice"},
+			cofigEnabled: true,
+			Exp: policydef.Result{
+				Enabled:    true,
+				Pass:       false,
+				NotifyText: "Did not find any owners of this repository\nThis policy requires all repositories to have an organization member or team assigned as an administrator",
+				Details:    details{},
+			},
+		},
+		{
+			Name: "Ownerless administered by an owner team and pass",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					OptOutStrategy: true,
+				},
+				ImplicitOwnerTeams: []string{"security-managers"},
+			},
+			Repo: RepoConfig{},
+			TeamMembers: map[string][]string{
+				"security-managers": {"dave"},
+			},
+			cofigEnabled: true,
+			Exp: policydef.Result{
+				Enabled:    true,
+				Pass:       true,
+				NotifyText: "",
+				Details: details{
+					OwnerTeams: []string{"security-managers"},
+				},
+			},
+		},
+		{
+			Name: "Ownerless with an empty owner team and fail",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					OptOutStrategy: true,
+				},
+				ImplicitOwnerTeams: []string{"security-managers"},
+			},
+			Repo:         RepoConfig{},
+			cofigEnabled: true,
+			Exp: policydef.Result{
+				Enabled:    true,
+				Pass:       false,
+				NotifyText: "Did not find any owners of this repository\nThis policy requires all repositories to have an organization member or team assigned as an administrator",
+				Details:    details{},
+			},
+		},
+		{
+			Name: "Owner teams not queried with an admin and pass",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					OptOutStrategy: true,
+				},
+				UserAdminsAllowed:  true,
+				ImplicitOrgOwners:  true,
+				ImplicitOwnerTeams: []string{"security-managers"},
+			},
+			Repo: RepoConfig{},
+			Users: []*github.User{
+				&github.User{
+					Login: &bob,
+					Permissions: map[string]bool{
+						"admin": true,
+					},
+				},
+			},
+			OrgOwners: []string{"alice"},
+			TeamMembers: map[string][]string{
+				"security-managers": {"dave"},
+			},
+			cofigEnabled: true,
+			Exp: policydef.Result{
+				Enabled:    true,
+				Pass:       true,
+				NotifyText: "",
+				Details: details{
+					Admins: []string{"bob"},
+				},
+			},
+		},
 	}
 
+	timeNow = func() time.Time { return time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC) }
+	defer func() { timeNow = time.Now }()
+
 	for _, test := range tests {
 		t.Run(test.Name, func(t *testing.T) {
 			configFetchConfig = func(ctx context.Context, c *github.Client,
@@ -1880,6 +2124,12 @@ func TestCheck(t *testing.T) {
 			listTeams = func(ctx context.Context, owner string, repo string, opts *github.ListOptions) ([]*github.Team, *github.Response, error) {
 				return test.Teams, &github.Response{NextPage: 0}, nil
 			}
+			listOrgOwners = func(ctx context.Context, c *github.Client, owner string) ([]string, error) {
+				return test.OrgOwners, nil
+			}
+			listTeamMembers = func(ctx context.Context, c *github.Client, owner, slug string) ([]string, error) {
+				return test.TeamMembers[slug], nil
+			}
 			res, err := check(context.Background(), mockRepos{}, nil, "", "thisrepo")
 			if err != nil {
 				t.Fatalf("Unexpected error: %v", err)
@@ -1898,3 +2148,199 @@ func trunc(s string, n int) string {
 	}
 	return s[:n]
 }
+
+func TestGeneratedPrecedence(t *testing.T) {
+	configtest.TestPrecedence(t, configtest.Precedence{
+		Org:   OrgConfig{},
+		Repo:  RepoConfig{},
+		Fetch: &configFetchConfig,
+		Merge: func() interface{} {
+			ctx := context.Background()
+			oc, orc, rc := getConfig(ctx, nil, "", configtest.Repo)
+			return mergeConfig(oc, orc, rc, configtest.Repo)
+		},
+	})
+}
+
+func TestFix(t *testing.T) {
+	user := func(login string) *github.User {
+		return &github.User{
+			Login:       github.String(login),
+			Permissions: map[string]bool{"push": true, "admin": true},
+		}
+	}
+	team := func(slug string) *github.Team {
+		return &github.Team{
+			Slug:        github.String(slug),
+			Permissions: map[string]bool{"push": true, "admin": true},
+		}
+	}
+	tests := []struct {
+		Name       string
+		Org        OrgConfig
+		Users      []*github.User
+		Teams      []*github.Team
+		OrgOwners  []string
+		ExpChanges []string
+		ExpIssue   bool
+	}{
+		{
+			Name: "No maximum",
+			Org: OrgConfig{
+				FixPermission: "maintain",
+			},
+			Users: []*github.User{user("alice"), user("bob")},
+			Teams: []*github.Team{team("core"), team("infra")},
+		},
+		{
+			Name: "Within maximum",
+			Org: OrgConfig{
+				FixPermission:       "maintain",
+				MaxNumberUserAdmins: 2,
+				MaxNumberAdminTeams: 2,
+			},
+			Users: []*github.User{user("alice"), user("bob")},
+			Teams: []*github.Team{team("core"), team("infra")},
+		},
+		{
+			Name: "Downgrade in listed order",
+			Org: OrgConfig{
+				FixPermission:       "maintain",
+				MaxNumberUserAdmins: 1,
+				MaxNumberAdminTeams: 1,
+			},
+			Users:      []*github.User{user("alice"), user("bob"), user("carol")},
+			Teams:      []*github.Team{team("core"), team("infra")},
+			ExpChanges: []string{"bob:maintain", "carol:maintain", "team/infra:maintain"},
+			ExpIssue:   true,
+		},
+		{
+			Name: "Keep list",
+			Org: OrgConfig{
+				FixPermission:       "write",
+				MaxNumberUserAdmins: 2,
+				MaxNumberAdminTeams: 1,
+				KeepUserAdmins:      []string{"dave", "carol", "bob"},
+				KeepAdminTeams:      []string{"infra"},
+			},
+			Users:      []*github.User{user("alice"), user("bob"), user("carol")},
+			Teams:      []*github.Team{team("core"), team("infra")},
+			ExpChanges: []string{"alice:push", "team/core:push"},
+			ExpIssue:   true,
+		},
+		{
+			Name: "Exemption maximum",
+			Org: OrgConfig{
+				FixPermission:       "maintain",
+				MaxNumberUserAdmins: 1,
+				Exemptions: []*AdministratorExemption{
+					{
+						Repo:                "thisrepo",
+						MaxNumberUserAdmins: 2,
+					},
+				},
+			},
+			Users:      []*github.User{user("alice"), user("bob"), user("carol")},
+			ExpChanges: []string{"carol:maintain"},
+			ExpIssue:   true,
+		},
+		{
+			Name: "Org owners kept",
+			Org: OrgConfig{
+				FixPermission:       "maintain",
+				MaxNumberUserAdmins: 1,
+				KeepUserAdmins:      []string{"alice"},
+			},
+			Users:      []*github.User{user("alice"), user("bob"), user("carol")},
+			OrgOwners:  []string{"carol"},
+			ExpChanges: []string{"alice:maintain", "bob:maintain"},
+			ExpIssue:   true,
+		},
+		{
+			Name: "Only org owners over maximum",
+			Org: OrgConfig{
+				FixPermission:       "maintain",
+				MaxNumberUserAdmins: 1,
+			},
+			Users:     []*github.User{user("alice"), user("bob")},
+			OrgOwners: []string{"alice", "bob"},
+		},
+		{
+			Name: "Invalid permission",
+			Org: OrgConfig{
+				FixPermission:       "admin",
+				MaxNumberUserAdmins: 1,
+			},
+			Users: []*github.User{user("alice"), user("bob")},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.OrgLevel {
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+			listCollaborators = func(c context.Context, o, r string,
+				op *github.ListCollaboratorsOptions) ([]*github.User, *github.Response, error) {
+				return test.Users, &github.Response{NextPage: 0}, nil
+			}
+			listTeams = func(ctx context.Context, owner string, repo string, opts *github.ListOptions) ([]*github.Team, *github.Response, error) {
+				return test.Teams, &github.Response{NextPage: 0}, nil
+			}
+			configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+				c *github.Client, owner, repo string) (bool, error) {
+				return true, nil
+			}
+			var issueText string
+			issueEnsure = func(ctx context.Context, c *github.Client, owner, repo, policy, text string) (*issue.Fallback, error) {
+				issueText = text
+				return nil, nil
+			}
+			listOrgOwners = func(ctx context.Context, c *github.Client, owner string) ([]string, error) {
+				return test.OrgOwners, nil
+			}
+			changes = nil
+
+			if err := fix(context.Background(), mockRepos{}, mockAdmins{}, nil, "", "thisrepo"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.ExpChanges, changes); diff != "" {
+				t.Errorf("Unexpected changes (-want +got):\n%s", diff)
+			}
+			if (issueText != "") != test.ExpIssue {
+				t.Errorf("Unexpected issue, want %v got %q", test.ExpIssue, issueText)
+			}
+		})
+	}
+}
+
+func TestRevertAdmin(t *testing.T) {
+	changes = nil
+	s := revert.Snapshot{
+		Org:    "org",
+		Repo:   "thisrepo",
+		Policy: polName,
+		Target: "alice",
+		Prior:  json.RawMessage(`{"permission":"admin"}`),
+	}
+	if err := revertAdmin(context.Background(), mockAdmins{}, s); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s.Target = "core"
+	s.Prior = json.RawMessage(`{"team":true,"permission":"admin"}`)
+	if err := revertAdmin(context.Background(), mockAdmins{}, s); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"alice:admin", "team/core:admin"}, changes); diff != "" {
+		t.Errorf("Unexpected changes (-want +got):\n%s", diff)
+	}
+	s.Prior = json.RawMessage(`{}`)
+	if err := revertAdmin(context.Background(), mockAdmins{}, s); err == nil {
+		t.Errorf("Expected error without prior permission")
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/ossf/allstar/pkg/revert"
+
+	"github.com/google/go-github/v59/github"
+)
+
+// priorAdmin is the permission of a user or team administrator before a Fix
+// action downgraded it, recorded so that the change can be reverted, see the
+// revert package. The snapshot target is the user login or team slug.
+type priorAdmin struct {
+	// Team is set if the target is a team.
+	Team bool `json:"team,omitempty"`
+
+	// Permission is the permission of the user or team, as named by the
+	// collaborators and teams APIs.
+	Permission string `json:"permission"`
+}
+
+// revertFix restores the permission of a downgraded administrator,
+// implementing revert.Reverter.
+func revertFix(ctx context.Context, c *github.Client, s revert.Snapshot) error {
+	return revertAdmin(ctx, adminsClient{c}, s)
+}
+
+func revertAdmin(ctx context.Context, adm admins, s revert.Snapshot) error {
+	var prior priorAdmin
+	if err := json.Unmarshal(s.Prior, &prior); err != nil {
+		return err
+	}
+	if prior.Permission == "" {
+		return errors.New("no prior permission recorded")
+	}
+	if prior.Team {
+		_, err := adm.AddTeamRepoBySlug(ctx, s.Org, s.Target, s.Org, s.Repo,
+			&github.TeamAddTeamRepoOptions{Permission: prior.Permission})
+		return err
+	}
+	_, _, err := adm.AddCollaborator(ctx, s.Org, s.Repo, s.Target,
+		&github.RepositoryAddCollaboratorOptions{Permission: prior.Permission})
+	return err
+}
@@ -0,0 +1,442 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package allowedactions implements the Allowed Actions security policy. It
+// checks the repository-level GitHub Actions permissions setting, as opposed to
+// the workflow contents checked by the GitHub Actions policy.
+package allowedactions
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/ossf/allstar/pkg/cache"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/policydef"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+const configFile = "allowed_actions.yaml"
+const polName = "Allowed Actions"
+
+const allowedSelected = "selected"
+
+const notifyText = `This policy requires that GitHub Actions in this repository are restricted to a selected set of Actions: those created by GitHub, Marketplace verified creators, and patterns approved by the organization.
+
+To fix this, from the main page of the repository go to Settings -> Actions -> General, and under "Actions permissions" choose "Allow %v, and select non-%v, actions and reusable workflows", then adjust the options to match the organization policy.
+(For more information, see https://docs.github.com/en/repositories/managing-your-repositorys-settings-and-features/enabling-features-for-your-repository/managing-github-actions-settings-for-a-repository#allowing-select-actions-and-reusable-workflows-to-run)`
+
+// OrgConfig is the org-level config definition for Allowed Actions.
+type OrgConfig struct {
+	// OptConfig is the standard org-level opt in/out config, RepoOverride
+	// applies to all config.
+	OptConfig config.OrgOptConfig `json:"optConfig"`
+
+	// Action defines which action to take, default log, other: issue...
+	Action string `json:"action"`
+
+	// GitHubOwnedAllowed : set to true to allow Actions created by GitHub,
+	// default true. When false, the repo setting must also be false.
+	GitHubOwnedAllowed bool `json:"githubOwnedAllowed"`
+
+	// VerifiedAllowed : set to true to allow Actions by Marketplace verified
+	// creators, default true. When false, the repo setting must also be false.
+	VerifiedAllowed bool `json:"verifiedAllowed"`
+
+	// AllowedPatterns is the org allowlist of Action patterns (e.g.
+	// "myorg/*" or "octo/action@v1"). Every pattern configured on a repo must
+	// match an entry of this list. Globs are allowed.
+	AllowedPatterns []string `json:"allowedPatterns"`
+}
+
+// RepoConfig is the repo-level config for Allowed Actions.
+type RepoConfig struct {
+	// OptConfig is the standard repo-level opt in/out config.
+	OptConfig config.RepoOptConfig `json:"optConfig"`
+
+	// Action overrides the same setting in org-level, only if present.
+	Action *string `json:"action"`
+
+	// GitHubOwnedAllowed overrides the same setting in org-level, only if
+	// present.
+	GitHubOwnedAllowed *bool `json:"githubOwnedAllowed"`
+
+	// VerifiedAllowed overrides the same setting in org-level, only if present.
+	VerifiedAllowed *bool `json:"verifiedAllowed"`
+
+	// AllowedPatterns adds more patterns to the org-level list. Does not
+	// override.
+	AllowedPatterns []string `json:"allowedPatterns"`
+}
+
+type mergedConfig struct {
+	Action             string
+	GitHubOwnedAllowed bool
+	VerifiedAllowed    bool
+	AllowedPatterns    []string
+}
+
+type details struct {
+	ActionsEnabled       bool
+	AllowedActions       string
+	GitHubOwnedAllowed   bool
+	VerifiedAllowed      bool
+	PatternsAllowed      []string
+	DisallowedPatterns   []string
+	SetAtOrgOrEnterprise bool
+}
+
+var gc = cache.NewGlobCache(cache.DefaultSize)
+
+var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+
+var configIsEnabled func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig, c *github.Client, owner, repo string) (bool, error)
+
+func init() {
+	configFetchConfig = config.FetchConfig
+	configIsEnabled = config.IsEnabled
+}
+
+type repositories interface {
+	GetActionsPermissions(context.Context, string, string) (
+		*github.ActionsPermissionsRepository, *github.Response, error)
+	EditActionsPermissions(context.Context, string, string,
+		github.ActionsPermissionsRepository) (
+		*github.ActionsPermissionsRepository, *github.Response, error)
+	GetActionsAllowed(context.Context, string, string) (
+		*github.ActionsAllowed, *github.Response, error)
+	EditActionsAllowed(context.Context, string, string, github.ActionsAllowed) (
+		*github.ActionsAllowed, *github.Response, error)
+}
+
+// AllowedActions is the Allowed Actions policy object, implements
+// policydef.Policy.
+type AllowedActions bool
+
+// NewAllowedActions returns a new Allowed Actions policy.
+func NewAllowedActions() policydef.Policy {
+	var a AllowedActions
+	return a
+}
+
+// Name returns the name of this policy, implementing policydef.Policy.Name()
+func (a AllowedActions) Name() string {
+	return polName
+}
+
+// Check whether this policy is enabled or not
+func (a AllowedActions) IsEnabled(ctx context.Context, c *github.Client, owner, repo string) (bool, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	return configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+}
+
+// Check performs the policy check for Allowed Actions based on the
+// configuration stored in the org/repo, implementing policydef.Policy.Check()
+func (a AllowedActions) Check(ctx context.Context, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	return check(ctx, c.Repositories, c, owner, repo)
+}
+
+func check(ctx context.Context, rep repositories, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Bool("enabled", enabled).
+		Msg("Check repo enabled")
+
+	mc := mergeConfig(oc, orc, rc, repo)
+
+	perm, _, err := rep.GetActionsPermissions(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	var d details
+	d.ActionsEnabled = perm.GetEnabled()
+	d.AllowedActions = perm.GetAllowedActions()
+	if !d.ActionsEnabled {
+		// No Actions can run at all, which is stricter than this policy.
+		return &policydef.Result{
+			Enabled:    enabled,
+			Pass:       true,
+			NotifyText: "",
+			Details:    d,
+		}, nil
+	}
+	if d.AllowedActions != allowedSelected {
+		return &policydef.Result{
+			Enabled: enabled,
+			Pass:    false,
+			NotifyText: fmt.Sprintf("Actions permissions are set to allow %q Actions, rather than %q.\n\n",
+				d.AllowedActions, allowedSelected) + fmt.Sprintf(notifyText, owner, owner),
+			Details: d,
+		}, nil
+	}
+
+	aa, rsp, err := rep.GetActionsAllowed(ctx, owner, repo)
+	if err != nil {
+		if rsp != nil && rsp.StatusCode == http.StatusConflict {
+			// The allowed Actions are managed by the org or enterprise
+			// setting, so the repo has no settings of its own to check.
+			d.SetAtOrgOrEnterprise = true
+			return &policydef.Result{
+				Enabled:    enabled,
+				Pass:       true,
+				NotifyText: "",
+				Details:    d,
+			}, nil
+		}
+		return nil, err
+	}
+	d.GitHubOwnedAllowed = aa.GetGithubOwnedAllowed()
+	d.VerifiedAllowed = aa.GetVerifiedAllowed()
+	d.PatternsAllowed = aa.PatternsAllowed
+
+	pass := true
+	text := ""
+	if d.GitHubOwnedAllowed && !mc.GitHubOwnedAllowed {
+		pass = false
+		text = text + "Actions created by GitHub are allowed, but not by organization policy.\n"
+	}
+	if d.VerifiedAllowed && !mc.VerifiedAllowed {
+		pass = false
+		text = text + "Actions by Marketplace verified creators are allowed, but not by organization policy.\n"
+	}
+	for _, p := range d.PatternsAllowed {
+		if !matches(mc.AllowedPatterns, p, gc) {
+			d.DisallowedPatterns = append(d.DisallowedPatterns, p)
+		}
+	}
+	if len(d.DisallowedPatterns) > 0 {
+		pass = false
+		text = text + "Allowed Action patterns not in the organization allowlist:\n"
+		for _, p := range d.DisallowedPatterns {
+			text = text + fmt.Sprintf("- %v\n", p)
+		}
+	}
+	if !pass {
+		text = text + "\n" + fmt.Sprintf(notifyText, owner, owner)
+	}
+
+	return &policydef.Result{
+		Enabled:    enabled,
+		Pass:       pass,
+		NotifyText: text,
+		Details:    d,
+	}, nil
+}
+
+// Fix implementing policydef.Policy.Fix(). Sets the repo Actions permissions
+// to "selected" and removes any allowed Actions not permitted by the policy.
+func (a AllowedActions) Fix(ctx context.Context, c *github.Client, owner, repo string) error {
+	return fix(ctx, c.Repositories, c, owner, repo)
+}
+
+func fix(ctx context.Context, rep repositories, c *github.Client, owner,
+	repo string) error {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return nil
+	}
+	mc := mergeConfig(oc, orc, rc, repo)
+
+	perm, _, err := rep.GetActionsPermissions(ctx, owner, repo)
+	if err != nil {
+		return err
+	}
+	if !perm.GetEnabled() {
+		return nil
+	}
+	if perm.GetAllowedActions() != allowedSelected {
+		_, rsp, err := rep.EditActionsPermissions(ctx, owner, repo, github.ActionsPermissionsRepository{
+			Enabled:        github.Bool(true),
+			AllowedActions: github.String(allowedSelected),
+		})
+		if err != nil {
+			if rsp != nil && (rsp.StatusCode == http.StatusForbidden || rsp.StatusCode == http.StatusConflict) {
+				log.Warn().
+					Str("org", owner).
+					Str("repo", repo).
+					Str("area", polName).
+					Msg("Action set to fix, but Actions permissions could not be updated.")
+				return nil
+			}
+			return err
+		}
+		log.Info().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", polName).
+			Msg("Updated Actions permissions to selected with Fix action.")
+	}
+
+	aa, rsp, err := rep.GetActionsAllowed(ctx, owner, repo)
+	if err != nil {
+		if rsp != nil && rsp.StatusCode == http.StatusConflict {
+			return nil
+		}
+		return err
+	}
+	update := false
+	na := github.ActionsAllowed{
+		GithubOwnedAllowed: github.Bool(aa.GetGithubOwnedAllowed()),
+		VerifiedAllowed:    github.Bool(aa.GetVerifiedAllowed()),
+		PatternsAllowed:    make([]string, 0),
+	}
+	if aa.GetGithubOwnedAllowed() && !mc.GitHubOwnedAllowed {
+		na.GithubOwnedAllowed = github.Bool(false)
+		update = true
+	}
+	if aa.GetVerifiedAllowed() && !mc.VerifiedAllowed {
+		na.VerifiedAllowed = github.Bool(false)
+		update = true
+	}
+	for _, p := range aa.PatternsAllowed {
+		if matches(mc.AllowedPatterns, p, gc) {
+			na.PatternsAllowed = append(na.PatternsAllowed, p)
+		} else {
+			update = true
+		}
+	}
+	if !update {
+		return nil
+	}
+	if _, rsp, err := rep.EditActionsAllowed(ctx, owner, repo, na); err != nil {
+		if rsp != nil && (rsp.StatusCode == http.StatusForbidden || rsp.StatusCode == http.StatusConflict) {
+			log.Warn().
+				Str("org", owner).
+				Str("repo", repo).
+				Str("area", polName).
+				Msg("Action set to fix, but allowed Actions could not be updated.")
+			return nil
+		}
+		return err
+	}
+	log.Info().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Msg("Updated allowed Actions with Fix action.")
+	return nil
+}
+
+// GetAction returns the configured action from Allowed Actions'
+// configuration stored in the org-level repo, default log. Implementing
+// policydef.Policy.GetAction()
+func (a AllowedActions) GetAction(ctx context.Context, c *github.Client, owner, repo string) string {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	mc := mergeConfig(oc, orc, rc, repo)
+	return mc.Action
+}
+
+func getConfig(ctx context.Context, c *github.Client, owner, repo string) (*OrgConfig, *RepoConfig, *RepoConfig) {
+	oc := &OrgConfig{ // Fill out non-zero defaults
+		Action:             "log",
+		GitHubOwnedAllowed: true,
+		VerifiedAllowed:    true,
+	}
+	if err := configFetchConfig(ctx, c, owner, "", configFile, config.OrgLevel, oc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	orc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.OrgRepoLevel, orc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgRepoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	rc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.RepoLevel, rc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "repoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	return oc, orc, rc
+}
+
+func mergeConfig(oc *OrgConfig, orc, rc *RepoConfig, repo string) *mergedConfig {
+	mc := &mergedConfig{
+		Action:             oc.Action,
+		GitHubOwnedAllowed: oc.GitHubOwnedAllowed,
+		VerifiedAllowed:    oc.VerifiedAllowed,
+		AllowedPatterns:    oc.AllowedPatterns,
+	}
+	mc.AllowedPatterns = append(mc.AllowedPatterns, orc.AllowedPatterns...)
+	mc = mergeInRepoConfig(mc, orc, repo)
+
+	if !oc.OptConfig.DisableRepoOverride {
+		mc.AllowedPatterns = append(mc.AllowedPatterns, rc.AllowedPatterns...)
+		mc = mergeInRepoConfig(mc, rc, repo)
+	}
+	return mc
+}
+
+func mergeInRepoConfig(mc *mergedConfig, rc *RepoConfig, repo string) *mergedConfig {
+	if rc.Action != nil {
+		mc.Action = *rc.Action
+	}
+	if rc.GitHubOwnedAllowed != nil {
+		mc.GitHubOwnedAllowed = *rc.GitHubOwnedAllowed
+	}
+	if rc.VerifiedAllowed != nil {
+		mc.VerifiedAllowed = *rc.VerifiedAllowed
+	}
+	return mc
+}
+
+func matches(s []string, e string, gc *cache.GlobCache) bool {
+	for _, v := range s {
+		g, err := gc.Compile(v)
+		if err != nil {
+			log.Warn().
+				Str("area", polName).
+				Str("pattern", e).
+				Str("glob", v).
+				Err(err).
+				Msg("Unexpected error compiling the glob.")
+		} else if g.Match(e) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,478 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package allowedactions
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/configtest"
+)
+
+var getActionsPermissions func(context.Context, string, string) (
+	*github.ActionsPermissionsRepository, *github.Response, error)
+var editActionsPermissions func(context.Context, string, string,
+	github.ActionsPermissionsRepository) (*github.ActionsPermissionsRepository,
+	*github.Response, error)
+var getActionsAllowed func(context.Context, string, string) (
+	*github.ActionsAllowed, *github.Response, error)
+var editActionsAllowed func(context.Context, string, string,
+	github.ActionsAllowed) (*github.ActionsAllowed, *github.Response, error)
+
+type mockRepos struct{}
+
+func (m mockRepos) GetActionsPermissions(ctx context.Context, o, r string) (
+	*github.ActionsPermissionsRepository, *github.Response, error) {
+	return getActionsPermissions(ctx, o, r)
+}
+
+func (m mockRepos) EditActionsPermissions(ctx context.Context, o, r string,
+	p github.ActionsPermissionsRepository) (*github.ActionsPermissionsRepository,
+	*github.Response, error) {
+	return editActionsPermissions(ctx, o, r, p)
+}
+
+func (m mockRepos) GetActionsAllowed(ctx context.Context, o, r string) (
+	*github.ActionsAllowed, *github.Response, error) {
+	return getActionsAllowed(ctx, o, r)
+}
+
+func (m mockRepos) EditActionsAllowed(ctx context.Context, o, r string,
+	a github.ActionsAllowed) (*github.ActionsAllowed, *github.Response, error) {
+	return editActionsAllowed(ctx, o, r, a)
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Org       OrgConfig
+		OrgRepo   RepoConfig
+		Repo      RepoConfig
+		ExpAction string
+		Exp       mergedConfig
+	}{
+		{
+			Name: "OrgOnly",
+			Org: OrgConfig{
+				Action:          "issue",
+				VerifiedAllowed: true,
+				AllowedPatterns: []string{"myorg/*"},
+			},
+			OrgRepo:   RepoConfig{},
+			Repo:      RepoConfig{},
+			ExpAction: "issue",
+			Exp: mergedConfig{
+				Action:          "issue",
+				VerifiedAllowed: true,
+				AllowedPatterns: []string{"myorg/*"},
+			},
+		},
+		{
+			Name: "OrgRepoOverOrg",
+			Org: OrgConfig{
+				Action:          "issue",
+				VerifiedAllowed: true,
+				AllowedPatterns: []string{"myorg/*"},
+			},
+			OrgRepo: RepoConfig{
+				Action:          github.String("log"),
+				VerifiedAllowed: github.Bool(false),
+				AllowedPatterns: []string{"other/action@v1"},
+			},
+			Repo:      RepoConfig{},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action:          "log",
+				VerifiedAllowed: false,
+				AllowedPatterns: []string{"myorg/*", "other/action@v1"},
+			},
+		},
+		{
+			Name: "RepoOverAllOrg",
+			Org: OrgConfig{
+				Action:          "issue",
+				AllowedPatterns: []string{"myorg/*"},
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action:             github.String("email"),
+				GitHubOwnedAllowed: github.Bool(true),
+				AllowedPatterns:    []string{"mine/*"},
+			},
+			ExpAction: "email",
+			Exp: mergedConfig{
+				Action:             "email",
+				GitHubOwnedAllowed: true,
+				AllowedPatterns:    []string{"myorg/*", "mine/*"},
+			},
+		},
+		{
+			Name: "RepoDisallowed",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					DisableRepoOverride: true,
+				},
+				Action:          "issue",
+				AllowedPatterns: []string{"myorg/*"},
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action:             github.String("email"),
+				GitHubOwnedAllowed: github.Bool(true),
+				AllowedPatterns:    []string{"mine/*"},
+			},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action:          "log",
+				AllowedPatterns: []string{"myorg/*"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				switch ol {
+				case config.RepoLevel:
+					rc := out.(*RepoConfig)
+					*rc = test.Repo
+				case config.OrgRepoLevel:
+					orc := out.(*RepoConfig)
+					*orc = test.OrgRepo
+				case config.OrgLevel:
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+
+			a := AllowedActions(true)
+			ctx := context.Background()
+
+			action := a.GetAction(ctx, nil, "", "thisrepo")
+			if action != test.ExpAction {
+				t.Errorf("Unexpected results. want %s, got %s", test.ExpAction, action)
+			}
+
+			oc, orc, rc := getConfig(ctx, nil, "", "thisrepo")
+			mc := mergeConfig(oc, orc, rc, "thisrepo")
+			if diff := cmp.Diff(&test.Exp, mc); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Org         OrgConfig
+		Perm        github.ActionsPermissionsRepository
+		Allowed     github.ActionsAllowed
+		AllowedCode int
+		ExpPass     bool
+		ExpDetails  details
+	}{
+		{
+			Name: "ActionsDisabled",
+			Org:  OrgConfig{},
+			Perm: github.ActionsPermissionsRepository{
+				Enabled: github.Bool(false),
+			},
+			ExpPass: true,
+			ExpDetails: details{
+				ActionsEnabled: false,
+			},
+		},
+		{
+			Name: "AllActionsAllowed",
+			Org:  OrgConfig{},
+			Perm: github.ActionsPermissionsRepository{
+				Enabled:        github.Bool(true),
+				AllowedActions: github.String("all"),
+			},
+			ExpPass: false,
+			ExpDetails: details{
+				ActionsEnabled: true,
+				AllowedActions: "all",
+			},
+		},
+		{
+			Name: "SelectedPass",
+			Org: OrgConfig{
+				GitHubOwnedAllowed: true,
+				VerifiedAllowed:    true,
+				AllowedPatterns:    []string{"myorg/*"},
+			},
+			Perm: github.ActionsPermissionsRepository{
+				Enabled:        github.Bool(true),
+				AllowedActions: github.String("selected"),
+			},
+			Allowed: github.ActionsAllowed{
+				GithubOwnedAllowed: github.Bool(true),
+				VerifiedAllowed:    github.Bool(true),
+				PatternsAllowed:    []string{"myorg/thing@v1"},
+			},
+			ExpPass: true,
+			ExpDetails: details{
+				ActionsEnabled:     true,
+				AllowedActions:     "selected",
+				GitHubOwnedAllowed: true,
+				VerifiedAllowed:    true,
+				PatternsAllowed:    []string{"myorg/thing@v1"},
+			},
+		},
+		{
+			Name: "VerifiedNotAllowed",
+			Org: OrgConfig{
+				GitHubOwnedAllowed: true,
+			},
+			Perm: github.ActionsPermissionsRepository{
+				Enabled:        github.Bool(true),
+				AllowedActions: github.String("selected"),
+			},
+			Allowed: github.ActionsAllowed{
+				GithubOwnedAllowed: github.Bool(true),
+				VerifiedAllowed:    github.Bool(true),
+			},
+			ExpPass: false,
+			ExpDetails: details{
+				ActionsEnabled:     true,
+				AllowedActions:     "selected",
+				GitHubOwnedAllowed: true,
+				VerifiedAllowed:    true,
+			},
+		},
+		{
+			Name: "PatternNotInAllowlist",
+			Org: OrgConfig{
+				GitHubOwnedAllowed: true,
+				VerifiedAllowed:    true,
+				AllowedPatterns:    []string{"myorg/*"},
+			},
+			Perm: github.ActionsPermissionsRepository{
+				Enabled:        github.Bool(true),
+				AllowedActions: github.String("selected"),
+			},
+			Allowed: github.ActionsAllowed{
+				PatternsAllowed: []string{"myorg/a@v1", "evil/*"},
+			},
+			ExpPass: false,
+			ExpDetails: details{
+				ActionsEnabled:     true,
+				AllowedActions:     "selected",
+				PatternsAllowed:    []string{"myorg/a@v1", "evil/*"},
+				DisallowedPatterns: []string{"evil/*"},
+			},
+		},
+		{
+			Name: "SetAtOrg",
+			Org:  OrgConfig{},
+			Perm: github.ActionsPermissionsRepository{
+				Enabled:        github.Bool(true),
+				AllowedActions: github.String("selected"),
+			},
+			AllowedCode: http.StatusConflict,
+			ExpPass:     true,
+			ExpDetails: details{
+				ActionsEnabled:       true,
+				AllowedActions:       "selected",
+				SetAtOrgOrEnterprise: true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.OrgLevel {
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+			configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+				c *github.Client, owner, repo string) (bool, error) {
+				return true, nil
+			}
+			getActionsPermissions = func(context.Context, string, string) (
+				*github.ActionsPermissionsRepository, *github.Response, error) {
+				return &test.Perm, nil, nil
+			}
+			getActionsAllowed = func(context.Context, string, string) (
+				*github.ActionsAllowed, *github.Response, error) {
+				if test.AllowedCode != 0 {
+					return nil, &github.Response{Response: &http.Response{StatusCode: test.AllowedCode}},
+						errors.New("error")
+				}
+				return &test.Allowed, nil, nil
+			}
+			res, err := check(context.Background(), mockRepos{}, nil, "thisorg", "thisrepo")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Pass != test.ExpPass {
+				t.Errorf("Unexpected pass. want %v, got %v", test.ExpPass, res.Pass)
+			}
+			if !res.Pass && res.NotifyText == "" {
+				t.Errorf("Expected notify text on failure.")
+			}
+			if diff := cmp.Diff(test.ExpDetails, res.Details); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFix(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Org        OrgConfig
+		Perm       github.ActionsPermissionsRepository
+		Allowed    github.ActionsAllowed
+		ExpPerm    *github.ActionsPermissionsRepository
+		ExpAllowed *github.ActionsAllowed
+	}{
+		{
+			Name: "NoChange",
+			Org: OrgConfig{
+				GitHubOwnedAllowed: true,
+				VerifiedAllowed:    true,
+				AllowedPatterns:    []string{"myorg/*"},
+			},
+			Perm: github.ActionsPermissionsRepository{
+				Enabled:        github.Bool(true),
+				AllowedActions: github.String("selected"),
+			},
+			Allowed: github.ActionsAllowed{
+				GithubOwnedAllowed: github.Bool(true),
+				VerifiedAllowed:    github.Bool(true),
+				PatternsAllowed:    []string{"myorg/a@v1"},
+			},
+		},
+		{
+			Name: "SetSelected",
+			Org: OrgConfig{
+				GitHubOwnedAllowed: true,
+				VerifiedAllowed:    true,
+			},
+			Perm: github.ActionsPermissionsRepository{
+				Enabled:        github.Bool(true),
+				AllowedActions: github.String("all"),
+			},
+			Allowed: github.ActionsAllowed{
+				GithubOwnedAllowed: github.Bool(true),
+				VerifiedAllowed:    github.Bool(true),
+			},
+			ExpPerm: &github.ActionsPermissionsRepository{
+				Enabled:        github.Bool(true),
+				AllowedActions: github.String("selected"),
+			},
+		},
+		{
+			Name: "RemoveDisallowed",
+			Org: OrgConfig{
+				GitHubOwnedAllowed: true,
+				AllowedPatterns:    []string{"myorg/*"},
+			},
+			Perm: github.ActionsPermissionsRepository{
+				Enabled:        github.Bool(true),
+				AllowedActions: github.String("selected"),
+			},
+			Allowed: github.ActionsAllowed{
+				GithubOwnedAllowed: github.Bool(true),
+				VerifiedAllowed:    github.Bool(true),
+				PatternsAllowed:    []string{"myorg/a@v1", "evil/*"},
+			},
+			ExpAllowed: &github.ActionsAllowed{
+				GithubOwnedAllowed: github.Bool(true),
+				VerifiedAllowed:    github.Bool(false),
+				PatternsAllowed:    []string{"myorg/a@v1"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.OrgLevel {
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+			configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+				c *github.Client, owner, repo string) (bool, error) {
+				return true, nil
+			}
+			getActionsPermissions = func(context.Context, string, string) (
+				*github.ActionsPermissionsRepository, *github.Response, error) {
+				return &test.Perm, nil, nil
+			}
+			getActionsAllowed = func(context.Context, string, string) (
+				*github.ActionsAllowed, *github.Response, error) {
+				return &test.Allowed, nil, nil
+			}
+			var gotPerm *github.ActionsPermissionsRepository
+			editActionsPermissions = func(ctx context.Context, o, r string,
+				p github.ActionsPermissionsRepository) (*github.ActionsPermissionsRepository,
+				*github.Response, error) {
+				gotPerm = &p
+				return &p, nil, nil
+			}
+			var gotAllowed *github.ActionsAllowed
+			editActionsAllowed = func(ctx context.Context, o, r string,
+				a github.ActionsAllowed) (*github.ActionsAllowed, *github.Response, error) {
+				gotAllowed = &a
+				return &a, nil, nil
+			}
+			if err := fix(context.Background(), mockRepos{}, nil, "thisorg", "thisrepo"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.ExpPerm, gotPerm); diff != "" {
+				t.Errorf("Unexpected permissions update. (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.ExpAllowed, gotAllowed); diff != "" {
+				t.Errorf("Unexpected allowed update. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGeneratedPrecedence(t *testing.T) {
+	configtest.TestPrecedence(t, configtest.Precedence{
+		Org:   OrgConfig{},
+		Repo:  RepoConfig{},
+		Fetch: &configFetchConfig,
+		Merge: func() interface{} {
+			ctx := context.Background()
+			oc, orc, rc := getConfig(ctx, nil, "", configtest.Repo)
+			return mergeConfig(oc, orc, rc, configtest.Repo)
+		},
+		Append: []string{"AllowedPatterns"},
+	})
+}
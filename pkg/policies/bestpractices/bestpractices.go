@@ -0,0 +1,434 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bestpractices implements the OpenSSF Best Practices policy. It
+// checks that the repository declares an OpenSSF Best Practices badge, and
+// that the badge is at a minimum level.
+package bestpractices
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/ossf/allstar/pkg/cache"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/policydef"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+const configFile = "best_practices.yaml"
+const polName = "OpenSSF Best Practices"
+
+const (
+	levelInProgress = "in_progress"
+	levelPassing    = "passing"
+	levelSilver     = "silver"
+	levelGold       = "gold"
+)
+
+// levelRank orders the badge levels.
+var levelRank = map[string]int{
+	levelInProgress: 0,
+	levelPassing:    1,
+	levelSilver:     2,
+	levelGold:       3,
+}
+
+const bestPracticesURL = "https://www.bestpractices.dev/projects/%d.json"
+
+// badgeRegexp matches a badge or project link of both the current and the
+// former site, with an optional locale, ex:
+// https://www.bestpractices.dev/en/projects/1234
+var badgeRegexp = regexp.MustCompile(`(?:bestpractices\.dev|bestpractices\.coreinfrastructure\.org)(?:/[a-z]{2}(?:-[A-Za-z]{2})?)?/projects/(\d+)`)
+
+const notifyText = `This policy requires that the project has an OpenSSF Best Practices badge at or above the configured level, declared by linking the badge in the README or a configured metadata file.
+
+To fix this, register the project at https://www.bestpractices.dev, complete the criteria for the required level, and add the badge to the README.
+(For more information, see https://www.bestpractices.dev/en/criteria)`
+
+// OrgConfig is the org-level config definition for OpenSSF Best Practices.
+type OrgConfig struct {
+	// OptConfig is the standard org-level opt in/out config, RepoOverride
+	// applies to all config.
+	OptConfig config.OrgOptConfig `json:"optConfig"`
+
+	// Action defines which action to take, default log, other: issue...
+	Action string `json:"action"`
+
+	// MinLevel is the minimum badge level required, one of: "in_progress",
+	// "passing", "silver", or "gold", default "passing".
+	MinLevel string `json:"minLevel"`
+
+	// RepoLevels overrides MinLevel for the repos selected. The first
+	// selector matching a repo applies.
+	RepoLevels []LevelSelector `json:"repoLevels"`
+
+	// MetadataFiles is a list of files, in addition to the README, searched
+	// for the badge, default ["SECURITY-INSIGHTS.yml"].
+	MetadataFiles []string `json:"metadataFiles"`
+}
+
+// LevelSelector sets the minimum badge level of a set of repos.
+type LevelSelector struct {
+	// Repos is a list of repo names. Globs are allowed.
+	Repos []string `json:"repos"`
+
+	// MinLevel is the minimum badge level required for the selected repos.
+	MinLevel string `json:"minLevel"`
+}
+
+// RepoConfig is the repo-level config for OpenSSF Best Practices.
+type RepoConfig struct {
+	// OptConfig is the standard repo-level opt in/out config.
+	OptConfig config.RepoOptConfig `json:"optConfig"`
+
+	// Action overrides the same setting in org-level, only if present.
+	Action *string `json:"action"`
+
+	// MinLevel overrides the same setting in org-level, only if present.
+	MinLevel *string `json:"minLevel"`
+
+	// MetadataFiles overrides the same setting in org-level, only if present.
+	MetadataFiles []string `json:"metadataFiles"`
+}
+
+type mergedConfig struct {
+	Action        string
+	MinLevel      string
+	MetadataFiles []string
+}
+
+type details struct {
+	ProjectID  int
+	Source     string
+	BadgeLevel string
+	MinLevel   string
+}
+
+var gc = cache.NewGlobCache(cache.DefaultSize)
+
+var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+
+var configIsEnabled func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig, c *github.Client, owner, repo string) (bool, error)
+
+var getBadgeLevel func(context.Context, int) (string, error)
+
+var httpClient *http.Client
+
+func init() {
+	configFetchConfig = config.FetchConfig
+	configIsEnabled = config.IsEnabled
+	getBadgeLevel = getBadgeLevelReal
+	httpClient = &http.Client{Timeout: 30 * time.Second}
+}
+
+type repositories interface {
+	GetReadme(context.Context, string, string,
+		*github.RepositoryContentGetOptions) (*github.RepositoryContent,
+		*github.Response, error)
+	GetContents(context.Context, string, string, string,
+		*github.RepositoryContentGetOptions) (*github.RepositoryContent,
+		[]*github.RepositoryContent, *github.Response, error)
+}
+
+// BestPractices is the OpenSSF Best Practices policy object, implements
+// policydef.Policy.
+type BestPractices bool
+
+// NewBestPractices returns a new OpenSSF Best Practices policy.
+func NewBestPractices() policydef.Policy {
+	var b BestPractices
+	return b
+}
+
+// Name returns the name of this policy, implementing policydef.Policy.Name()
+func (b BestPractices) Name() string {
+	return polName
+}
+
+// Check whether this policy is enabled or not
+func (b BestPractices) IsEnabled(ctx context.Context, c *github.Client, owner, repo string) (bool, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	return configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+}
+
+// Check performs the policy check for OpenSSF Best Practices based on the
+// configuration stored in the org/repo, implementing policydef.Policy.Check()
+func (b BestPractices) Check(ctx context.Context, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	return check(ctx, c.Repositories, c, owner, repo)
+}
+
+func check(ctx context.Context, rep repositories, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Bool("enabled", enabled).
+		Msg("Check repo enabled")
+
+	mc := mergeConfig(oc, orc, rc, repo)
+	minLevel := mc.MinLevel
+	if _, ok := levelRank[minLevel]; !ok {
+		log.Warn().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", polName).
+			Str("minLevel", minLevel).
+			Msg("Unknown minimum badge level, using passing.")
+		minLevel = levelPassing
+	}
+	d := details{MinLevel: minLevel}
+
+	id, source, err := findProjectID(ctx, rep, owner, repo, mc.MetadataFiles)
+	if err != nil {
+		return nil, err
+	}
+	if id == 0 {
+		return &policydef.Result{
+			Enabled:    enabled,
+			Pass:       false,
+			NotifyText: "No OpenSSF Best Practices badge found.\n\n" + notifyText,
+			Details:    d,
+		}, nil
+	}
+	d.ProjectID = id
+	d.Source = source
+
+	level, err := getBadgeLevel(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	d.BadgeLevel = level
+	if level == "" {
+		return &policydef.Result{
+			Enabled: enabled,
+			Pass:    false,
+			NotifyText: fmt.Sprintf("OpenSSF Best Practices project %v, linked in %v, was not found.\n\n",
+				id, source) + notifyText,
+			Details: d,
+		}, nil
+	}
+	if rank, ok := levelRank[level]; !ok || rank < levelRank[minLevel] {
+		return &policydef.Result{
+			Enabled: enabled,
+			Pass:    false,
+			NotifyText: fmt.Sprintf("OpenSSF Best Practices badge level is %q, at least %q is required.\n\n",
+				level, minLevel) + notifyText,
+			Details: d,
+		}, nil
+	}
+	return &policydef.Result{
+		Enabled:    enabled,
+		Pass:       true,
+		NotifyText: "",
+		Details:    d,
+	}, nil
+}
+
+// findProjectID searches the README, then the metadata files, for a badge.
+// Returns the project ID and the file it was found in, or 0 if none.
+func findProjectID(ctx context.Context, rep repositories, owner, repo string,
+	files []string) (int, string, error) {
+	rc, rsp, err := rep.GetReadme(ctx, owner, repo, nil)
+	if err != nil && (rsp == nil || rsp.StatusCode != http.StatusNotFound) {
+		return 0, "", err
+	}
+	if err == nil {
+		if id := matchProjectID(rc); id != 0 {
+			return id, rc.GetPath(), nil
+		}
+	}
+	for _, f := range files {
+		fc, _, rsp, err := rep.GetContents(ctx, owner, repo, f, nil)
+		if err != nil {
+			if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+				continue
+			}
+			return 0, "", err
+		}
+		if id := matchProjectID(fc); id != 0 {
+			return id, f, nil
+		}
+	}
+	return 0, "", nil
+}
+
+func matchProjectID(rc *github.RepositoryContent) int {
+	if rc == nil {
+		return 0
+	}
+	content, err := rc.GetContent()
+	if err != nil {
+		return 0
+	}
+	m := badgeRegexp.FindStringSubmatch(content)
+	if m == nil {
+		return 0
+	}
+	id, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// getBadgeLevelReal returns the badge level of the project from the
+// bestpractices.dev API, or "" if the project is not found.
+func getBadgeLevelReal(ctx context.Context, id int) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(bestPracticesURL, id), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/json")
+	rsp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return "", fmt.Errorf("bestpractices.dev returned %v for project %v", rsp.Status, id)
+	}
+	var p struct {
+		BadgeLevel string `json:"badge_level"`
+	}
+	if err := json.NewDecoder(rsp.Body).Decode(&p); err != nil {
+		return "", err
+	}
+	return p.BadgeLevel, nil
+}
+
+// Fix implementing policydef.Policy.Fix(). Not supported, badge criteria need
+// to be completed by the project.
+func (b BestPractices) Fix(ctx context.Context, c *github.Client, owner, repo string) error {
+	log.Warn().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Msg("Action fix is configured, but not implemented.")
+	return nil
+}
+
+// GetAction returns the configured action from OpenSSF Best Practices'
+// configuration stored in the org-level repo, default log. Implementing
+// policydef.Policy.GetAction()
+func (b BestPractices) GetAction(ctx context.Context, c *github.Client, owner, repo string) string {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	mc := mergeConfig(oc, orc, rc, repo)
+	return mc.Action
+}
+
+func getConfig(ctx context.Context, c *github.Client, owner, repo string) (*OrgConfig, *RepoConfig, *RepoConfig) {
+	oc := &OrgConfig{ // Fill out non-zero defaults
+		Action:        "log",
+		MinLevel:      levelPassing,
+		MetadataFiles: []string{"SECURITY-INSIGHTS.yml"},
+	}
+	if err := configFetchConfig(ctx, c, owner, "", configFile, config.OrgLevel, oc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	orc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.OrgRepoLevel, orc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgRepoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	rc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.RepoLevel, rc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "repoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	return oc, orc, rc
+}
+
+func mergeConfig(oc *OrgConfig, orc, rc *RepoConfig, repo string) *mergedConfig {
+	mc := &mergedConfig{
+		Action:        oc.Action,
+		MinLevel:      selectLevel(repo, oc.MinLevel, oc.RepoLevels, gc),
+		MetadataFiles: oc.MetadataFiles,
+	}
+	mc = mergeInRepoConfig(mc, orc, repo)
+
+	if !oc.OptConfig.DisableRepoOverride {
+		mc = mergeInRepoConfig(mc, rc, repo)
+	}
+	return mc
+}
+
+func mergeInRepoConfig(mc *mergedConfig, rc *RepoConfig, repo string) *mergedConfig {
+	if rc.Action != nil {
+		mc.Action = *rc.Action
+	}
+	if rc.MinLevel != nil {
+		mc.MinLevel = *rc.MinLevel
+	}
+	if rc.MetadataFiles != nil {
+		mc.MetadataFiles = rc.MetadataFiles
+	}
+	return mc
+}
+
+// selectLevel returns the level of the first selector matching repo, or def.
+func selectLevel(repo, def string, ss []LevelSelector, gc *cache.GlobCache) string {
+	for _, s := range ss {
+		for _, r := range s.Repos {
+			g, err := gc.Compile(r)
+			if err != nil {
+				log.Warn().
+					Str("repo", repo).
+					Str("area", polName).
+					Str("glob", r).
+					Err(err).
+					Msg("Unexpected error compiling the glob.")
+			} else if g.Match(repo) {
+				return s.MinLevel
+			}
+		}
+	}
+	return def
+}
@@ -0,0 +1,367 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bestpractices
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/configtest"
+)
+
+var getReadme func(context.Context, string, string,
+	*github.RepositoryContentGetOptions) (*github.RepositoryContent,
+	*github.Response, error)
+var getContents func(context.Context, string, string, string,
+	*github.RepositoryContentGetOptions) (*github.RepositoryContent,
+	[]*github.RepositoryContent, *github.Response, error)
+
+type mockRepos struct{}
+
+func (m mockRepos) GetReadme(ctx context.Context, o, r string,
+	opt *github.RepositoryContentGetOptions) (*github.RepositoryContent,
+	*github.Response, error) {
+	return getReadme(ctx, o, r, opt)
+}
+
+func (m mockRepos) GetContents(ctx context.Context, o, r, p string,
+	opt *github.RepositoryContentGetOptions) (*github.RepositoryContent,
+	[]*github.RepositoryContent, *github.Response, error) {
+	return getContents(ctx, o, r, p, opt)
+}
+
+var notFound = &github.Response{
+	Response: &http.Response{StatusCode: http.StatusNotFound},
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Org       OrgConfig
+		OrgRepo   RepoConfig
+		Repo      RepoConfig
+		ExpAction string
+		Exp       mergedConfig
+	}{
+		{
+			Name: "OrgOnly",
+			Org: OrgConfig{
+				Action:        "issue",
+				MinLevel:      "passing",
+				MetadataFiles: []string{"SECURITY-INSIGHTS.yml"},
+			},
+			OrgRepo:   RepoConfig{},
+			Repo:      RepoConfig{},
+			ExpAction: "issue",
+			Exp: mergedConfig{
+				Action:        "issue",
+				MinLevel:      "passing",
+				MetadataFiles: []string{"SECURITY-INSIGHTS.yml"},
+			},
+		},
+		{
+			Name: "OrgSelector",
+			Org: OrgConfig{
+				Action:   "issue",
+				MinLevel: "passing",
+				RepoLevels: []LevelSelector{
+					{Repos: []string{"other"}, MinLevel: "gold"},
+					{Repos: []string{"this*"}, MinLevel: "silver"},
+					{Repos: []string{"thisrepo"}, MinLevel: "gold"},
+				},
+			},
+			OrgRepo:   RepoConfig{},
+			Repo:      RepoConfig{},
+			ExpAction: "issue",
+			Exp: mergedConfig{
+				Action:   "issue",
+				MinLevel: "silver",
+			},
+		},
+		{
+			Name: "OrgRepoOverOrg",
+			Org: OrgConfig{
+				Action:   "issue",
+				MinLevel: "passing",
+			},
+			OrgRepo: RepoConfig{
+				Action:        github.String("log"),
+				MinLevel:      github.String("gold"),
+				MetadataFiles: []string{"META.md"},
+			},
+			Repo:      RepoConfig{},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action:        "log",
+				MinLevel:      "gold",
+				MetadataFiles: []string{"META.md"},
+			},
+		},
+		{
+			Name: "RepoOverAllOrg",
+			Org: OrgConfig{
+				Action:   "issue",
+				MinLevel: "passing",
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action:   github.String("email"),
+				MinLevel: github.String("in_progress"),
+			},
+			ExpAction: "email",
+			Exp: mergedConfig{
+				Action:   "email",
+				MinLevel: "in_progress",
+			},
+		},
+		{
+			Name: "RepoDisallowed",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					DisableRepoOverride: true,
+				},
+				Action:   "issue",
+				MinLevel: "silver",
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action:   github.String("email"),
+				MinLevel: github.String("in_progress"),
+			},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action:   "log",
+				MinLevel: "silver",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				switch ol {
+				case config.RepoLevel:
+					rc := out.(*RepoConfig)
+					*rc = test.Repo
+				case config.OrgRepoLevel:
+					orc := out.(*RepoConfig)
+					*orc = test.OrgRepo
+				case config.OrgLevel:
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+
+			b := BestPractices(true)
+			ctx := context.Background()
+
+			action := b.GetAction(ctx, nil, "", "thisrepo")
+			if action != test.ExpAction {
+				t.Errorf("Unexpected results. want %s, got %s", test.ExpAction, action)
+			}
+
+			oc, orc, rc := getConfig(ctx, nil, "", "thisrepo")
+			mc := mergeConfig(oc, orc, rc, "thisrepo")
+			if diff := cmp.Diff(&test.Exp, mc); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Org        OrgConfig
+		Readme     string
+		Files      map[string]string
+		Levels     map[int]string
+		ExpPass    bool
+		ExpDetails details
+	}{
+		{
+			Name:    "ReadmeBadgePassing",
+			Org:     OrgConfig{MinLevel: "passing"},
+			Readme:  "[![OpenSSF Best Practices](https://www.bestpractices.dev/projects/1234/badge)](https://www.bestpractices.dev/projects/1234)",
+			Levels:  map[int]string{1234: "passing"},
+			ExpPass: true,
+			ExpDetails: details{
+				ProjectID:  1234,
+				Source:     "README.md",
+				BadgeLevel: "passing",
+				MinLevel:   "passing",
+			},
+		},
+		{
+			Name:    "LegacyLinkBelowLevel",
+			Org:     OrgConfig{MinLevel: "silver"},
+			Readme:  "See https://bestpractices.coreinfrastructure.org/en/projects/42 for details.",
+			Levels:  map[int]string{42: "passing"},
+			ExpPass: false,
+			ExpDetails: details{
+				ProjectID:  42,
+				Source:     "README.md",
+				BadgeLevel: "passing",
+				MinLevel:   "silver",
+			},
+		},
+		{
+			Name: "MetadataFile",
+			Org: OrgConfig{
+				MinLevel:      "passing",
+				MetadataFiles: []string{"missing.yml", "SECURITY-INSIGHTS.yml"},
+			},
+			Readme: "# No badge",
+			Files: map[string]string{
+				"SECURITY-INSIGHTS.yml": "best-practices-badge: https://www.bestpractices.dev/projects/7\n",
+			},
+			Levels:  map[int]string{7: "gold"},
+			ExpPass: true,
+			ExpDetails: details{
+				ProjectID:  7,
+				Source:     "SECURITY-INSIGHTS.yml",
+				BadgeLevel: "gold",
+				MinLevel:   "passing",
+			},
+		},
+		{
+			Name:    "NoBadge",
+			Org:     OrgConfig{MinLevel: "passing"},
+			ExpPass: false,
+			ExpDetails: details{
+				MinLevel: "passing",
+			},
+		},
+		{
+			Name:    "ProjectNotFound",
+			Org:     OrgConfig{MinLevel: "passing"},
+			Readme:  "https://www.bestpractices.dev/projects/99",
+			Levels:  map[int]string{},
+			ExpPass: false,
+			ExpDetails: details{
+				ProjectID: 99,
+				Source:    "README.md",
+				MinLevel:  "passing",
+			},
+		},
+		{
+			Name:    "UnknownLevelUsesPassing",
+			Org:     OrgConfig{MinLevel: "platinum"},
+			Readme:  "https://www.bestpractices.dev/projects/5",
+			Levels:  map[int]string{5: "passing"},
+			ExpPass: true,
+			ExpDetails: details{
+				ProjectID:  5,
+				Source:     "README.md",
+				BadgeLevel: "passing",
+				MinLevel:   "passing",
+			},
+		},
+		{
+			Name: "SelectorRequiresGold",
+			Org: OrgConfig{
+				MinLevel: "passing",
+				RepoLevels: []LevelSelector{
+					{Repos: []string{"thisrepo"}, MinLevel: "gold"},
+				},
+			},
+			Readme:  "https://www.bestpractices.dev/projects/5",
+			Levels:  map[int]string{5: "silver"},
+			ExpPass: false,
+			ExpDetails: details{
+				ProjectID:  5,
+				Source:     "README.md",
+				BadgeLevel: "silver",
+				MinLevel:   "gold",
+			},
+		},
+	}
+
+	configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+		c *github.Client, owner, repo string) (bool, error) {
+		return true, nil
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.OrgLevel {
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+			getReadme = func(context.Context, string, string,
+				*github.RepositoryContentGetOptions) (*github.RepositoryContent,
+				*github.Response, error) {
+				if test.Readme == "" {
+					return nil, notFound, errors.New("404")
+				}
+				return &github.RepositoryContent{
+					Path:    github.String("README.md"),
+					Content: &test.Readme,
+				}, nil, nil
+			}
+			getContents = func(ctx context.Context, o, r, p string,
+				opt *github.RepositoryContentGetOptions) (*github.RepositoryContent,
+				[]*github.RepositoryContent, *github.Response, error) {
+				c, ok := test.Files[p]
+				if !ok {
+					return nil, nil, notFound, errors.New("404")
+				}
+				return &github.RepositoryContent{Content: &c}, nil, nil, nil
+			}
+			getBadgeLevel = func(ctx context.Context, id int) (string, error) {
+				return test.Levels[id], nil
+			}
+
+			res, err := check(context.Background(), mockRepos{}, nil, "", "thisrepo")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Pass != test.ExpPass {
+				t.Errorf("Unexpected pass: %v, notify text: %v", res.Pass, res.NotifyText)
+			}
+			if diff := cmp.Diff(test.ExpDetails, res.Details); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGeneratedPrecedence(t *testing.T) {
+	configtest.TestPrecedence(t, configtest.Precedence{
+		Org:   OrgConfig{},
+		Repo:  RepoConfig{},
+		Fetch: &configFetchConfig,
+		Merge: func() interface{} {
+			ctx := context.Background()
+			oc, orc, rc := getConfig(ctx, nil, "", configtest.Repo)
+			return mergeConfig(oc, orc, rc, configtest.Repo)
+		},
+	})
+}
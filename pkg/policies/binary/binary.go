@@ -19,22 +19,38 @@ package binary
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"path"
 	"path/filepath"
+	"strings"
 
+	"github.com/gobwas/glob"
 	"github.com/google/go-github/v59/github"
 	"github.com/ossf/scorecard/v5/checker"
 	"github.com/ossf/scorecard/v5/checks"
 	sc "github.com/ossf/scorecard/v5/pkg/scorecard"
 	"github.com/rs/zerolog/log"
+	"sigs.k8s.io/yaml"
 
 	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/operator"
+	"github.com/ossf/allstar/pkg/exemptions"
 	"github.com/ossf/allstar/pkg/policydef"
+	"github.com/ossf/allstar/pkg/pullrequest"
 	"github.com/ossf/allstar/pkg/scorecard"
 )
 
 const configFile = "binary_artifacts.yaml"
 const polName = "Binary Artifacts"
 
+// fixBranch is the branch the Fix action proposes changes from.
+const fixBranch = "allstar/binary-artifacts"
+
+const (
+	fixMethodRemove = "remove"
+	fixMethodExempt = "exempt"
+)
+
 // OrgConfig is the org-level config definition for this policy.
 type OrgConfig struct {
 	// OptConfig is the standard org-level opt in/out config, RepoOverride applies to all
@@ -48,6 +64,19 @@ type OrgConfig struct {
 	// with these names are allowed, and the policy may still pass. These are
 	// just the file name, not a full path. Globs are not allowed.
 	IgnoreFiles []string `json:"ignoreFiles"`
+
+	// IgnorePathGlobs is a list of path globs to ignore, eg: "testdata/**".
+	// "*" matches within a directory, and "**" matches across directories.
+	IgnorePathGlobs []string `json:"ignorePathGlobs"`
+
+	// AllowExtensions is a list of file extensions to allow, eg: ".jar".
+	AllowExtensions []string `json:"allowExtensions"`
+
+	// FixMethod selects what the fix action proposes in a pull request:
+	// "remove" (default) deletes the Binary Artifacts, "exempt" adds them to
+	// IgnorePaths in the repo-level config file. The repo-level config file is
+	// only used if RepoOverride is allowed.
+	FixMethod string `json:"fixMethod"`
 }
 
 // RepoConfig is the repo-level config for this policy.
@@ -63,12 +92,22 @@ type RepoConfig struct {
 	// must be full paths with directories. Globs are not allowed. These are
 	// allowed even if RepoOverride is false.
 	IgnorePaths []string `json:"ignorePaths"`
+
+	// IgnorePathGlobs is a list of path globs to ignore, in addition to the
+	// org-level setting.
+	IgnorePathGlobs []string `json:"ignorePathGlobs"`
+
+	// FixMethod overrides the same setting in org-level, only if present.
+	FixMethod *string `json:"fixMethod"`
 }
 
 type mergedConfig struct {
-	Action      string
-	IgnoreFiles []string
-	IgnorePaths []string
+	Action          string
+	IgnoreFiles     []string
+	IgnorePaths     []string
+	IgnorePathGlobs []string
+	AllowExtensions []string
+	FixMethod       string
 }
 
 type details struct {
@@ -76,9 +115,16 @@ type details struct {
 }
 
 var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+var getContents func(context.Context, *github.Client, string, string, string) (*github.RepositoryContent, *github.Response, error)
+var pullrequestEnsure func(context.Context, *github.Client, string, string, string, *pullrequest.Request) (*github.PullRequest, error)
+
+var exemptionsApply func(context.Context, *github.Client, string, string, string, *policydef.Result) *policydef.Result
 
 func init() {
 	configFetchConfig = config.FetchConfig
+	getContents = getContentsReal
+	pullrequestEnsure = pullrequest.Ensure
+	exemptionsApply = exemptions.Apply
 }
 
 // Binary is the Binary Artifacts policy object, implements policydef.Policy.
@@ -104,6 +150,15 @@ func (b Binary) IsEnabled(ctx context.Context, c *github.Client, owner, repo str
 // Check performs the policy check for this policy based on the
 // configuration stored in the org/repo, implementing policydef.Policy.Check()
 func (b Binary) Check(ctx context.Context, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	r, err := check(ctx, c, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	return exemptionsApply(ctx, c, owner, repo, polName, r), nil
+}
+
+func check(ctx context.Context, c *github.Client, owner,
 	repo string) (*policydef.Result, error) {
 	oc, orc, rc := getConfig(ctx, c, owner, repo)
 	mc := mergeConfig(oc, orc, rc, repo)
@@ -225,6 +280,7 @@ func listJoin(list []string) string {
 }
 
 func convertAndFilterLogs(logs []checker.CheckDetail, mc *mergedConfig) []string {
+	globs := compileGlobs(mc.IgnorePathGlobs)
 	var s []string
 	for _, l := range logs {
 		if in(l.Msg.Path, mc.IgnorePaths) {
@@ -233,19 +289,168 @@ func convertAndFilterLogs(logs []checker.CheckDetail, mc *mergedConfig) []string
 		if in(filepath.Base(l.Msg.Path), mc.IgnoreFiles) {
 			continue
 		}
+		if matchAny(l.Msg.Path, globs) {
+			continue
+		}
+		if allowedExtension(l.Msg.Path, mc.AllowExtensions) {
+			continue
+		}
 		s = append(s, l.Msg.Path)
 	}
 	return s
 }
 
-// Fix implementing policydef.Policy.Fix(). Scorecard checks will not have a Fix option.
+// compileGlobs compiles path globs, skipping invalid ones.
+func compileGlobs(gs []string) []glob.Glob {
+	var rv []glob.Glob
+	for _, g := range gs {
+		cg, err := glob.Compile(g, '/')
+		if err != nil {
+			log.Warn().
+				Str("area", polName).
+				Str("glob", g).
+				Err(err).
+				Msg("Ignoring invalid path glob.")
+			continue
+		}
+		rv = append(rv, cg)
+	}
+	return rv
+}
+
+func matchAny(p string, gs []glob.Glob) bool {
+	for _, g := range gs {
+		if g.Match(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedExtension checks the extension of p against exts, which may be
+// listed with or without the leading dot.
+func allowedExtension(p string, exts []string) bool {
+	ext := path.Ext(p)
+	if ext == "" {
+		return false
+	}
+	for _, e := range exts {
+		if strings.EqualFold(ext, "."+strings.TrimPrefix(e, ".")) {
+			return true
+		}
+	}
+	return false
+}
+
+// Fix implementing policydef.Policy.Fix(). Opens a pull request either
+// removing the Binary Artifacts found, or exempting them in the repo-level
+// config file, according to FixMethod.
 func (b Binary) Fix(ctx context.Context, c *github.Client, owner, repo string) error {
-	log.Warn().
-		Str("org", owner).
-		Str("repo", repo).
-		Str("area", polName).
-		Msg("Action fix is configured, but not implemented.")
-	return nil
+	res, err := b.Check(ctx, c, owner, repo)
+	if err != nil {
+		return err
+	}
+	if res.Pass {
+		return nil
+	}
+	return fix(ctx, c, owner, repo, res.Details.(details).Artifacts)
+}
+
+func fix(ctx context.Context, c *github.Client, owner, repo string, artifacts []string) error {
+	if len(artifacts) == 0 {
+		return nil
+	}
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	mc := mergeConfig(oc, orc, rc, repo)
+
+	var pr *pullrequest.Request
+	switch mc.FixMethod {
+	case fixMethodExempt:
+		if oc.OptConfig.DisableRepoOverride {
+			log.Warn().
+				Str("org", owner).
+				Str("repo", repo).
+				Str("area", polName).
+				Msg("Fix method exempt is configured, but repo override is disabled. Not fixing.")
+			return nil
+		}
+		f, err := exemptFile(ctx, c, owner, repo, artifacts)
+		if err != nil {
+			return err
+		}
+		pr = &pullrequest.Request{
+			Branch: fixBranch,
+			Title:  "Exempt Binary Artifacts",
+			Body: "This adds the Binary Artifacts found to the ignored paths of the " +
+				"Allstar Binary Artifacts policy. Please review that each is expected " +
+				"before merging:\n\n" + listJoin(artifacts),
+			Files: []pullrequest.File{*f},
+		}
+	case fixMethodRemove, "":
+		var files []pullrequest.File
+		for _, a := range artifacts {
+			files = append(files, pullrequest.File{Path: a, Delete: true})
+		}
+		pr = &pullrequest.Request{
+			Branch: fixBranch,
+			Title:  "Remove Binary Artifacts",
+			Body: "This removes the Binary Artifacts found, which cannot be reviewed. " +
+				"Please check that nothing depends on them before merging:\n\n" +
+				listJoin(artifacts),
+			Files: files,
+		}
+	default:
+		log.Warn().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", polName).
+			Str("fixMethod", mc.FixMethod).
+			Msg("Unknown fix method. Not fixing.")
+		return nil
+	}
+	_, err := pullrequestEnsure(ctx, c, owner, repo, polName, pr)
+	return err
+}
+
+// exemptFile returns the repo-level config file with artifacts added to
+// ignorePaths. Other settings in an existing file are kept.
+func exemptFile(ctx context.Context, c *github.Client, owner, repo string, artifacts []string) (*pullrequest.File, error) {
+	p := path.Join(operator.RepoConfigDir, configFile)
+	cfg := map[string]interface{}{}
+	fc, rsp, err := getContents(ctx, c, owner, repo, p)
+	if err != nil && (rsp == nil || rsp.StatusCode != http.StatusNotFound) {
+		return nil, err
+	}
+	if err == nil {
+		content, err := fc.GetContent()
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal([]byte(content), &cfg); err != nil {
+			return nil, fmt.Errorf("parsing %v: %w", p, err)
+		}
+		if cfg == nil {
+			cfg = map[string]interface{}{}
+		}
+	}
+	var ignore []interface{}
+	if ip, ok := cfg["ignorePaths"].([]interface{}); ok {
+		ignore = ip
+	}
+	for _, a := range artifacts {
+		ignore = append(ignore, a)
+	}
+	cfg["ignorePaths"] = ignore
+	b, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &pullrequest.File{Path: p, Content: b}, nil
+}
+
+func getContentsReal(ctx context.Context, c *github.Client, owner, repo, p string) (*github.RepositoryContent, *github.Response, error) {
+	fc, _, rsp, err := c.Repositories.GetContents(ctx, owner, repo, p, nil)
+	return fc, rsp, err
 }
 
 // GetAction returns the configured action from this policy's configuration
@@ -298,8 +503,11 @@ func getConfig(ctx context.Context, c *github.Client, owner, repo string) (*OrgC
 
 func mergeConfig(oc *OrgConfig, orc, rc *RepoConfig, repo string) *mergedConfig {
 	mc := &mergedConfig{
-		Action:      oc.Action,
-		IgnoreFiles: oc.IgnoreFiles,
+		Action:          oc.Action,
+		IgnoreFiles:     oc.IgnoreFiles,
+		IgnorePathGlobs: oc.IgnorePathGlobs,
+		AllowExtensions: oc.AllowExtensions,
+		FixMethod:       oc.FixMethod,
 	}
 	mc = mergeInRepoConfig(mc, orc, repo)
 
@@ -316,6 +524,12 @@ func mergeInRepoConfig(mc *mergedConfig, rc *RepoConfig, repo string) *mergedCon
 	if rc.IgnorePaths != nil {
 		mc.IgnorePaths = rc.IgnorePaths
 	}
+	if rc.IgnorePathGlobs != nil {
+		mc.IgnorePathGlobs = append(append([]string{}, mc.IgnorePathGlobs...), rc.IgnorePathGlobs...)
+	}
+	if rc.FixMethod != nil {
+		mc.FixMethod = *rc.FixMethod
+	}
 	return mc
 }
 
@@ -16,11 +16,17 @@ package binary
 
 import (
 	"context"
+	"errors"
+	"net/http"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/google/go-github/v59/github"
 	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/configtest"
+	"github.com/ossf/allstar/pkg/pullrequest"
+	"github.com/ossf/scorecard/v5/checker"
 )
 
 func TestConfigPrecedence(t *testing.T) {
@@ -74,6 +80,26 @@ func TestConfigPrecedence(t *testing.T) {
 				Action: "email",
 			},
 		},
+		{
+			Name: "RepoGlobsAndFixMethod",
+			Org: OrgConfig{
+				Action:          "issue",
+				IgnorePathGlobs: []string{"testdata/**"},
+				AllowExtensions: []string{".jar"},
+			},
+			OrgRepo: RepoConfig{},
+			Repo: RepoConfig{
+				IgnorePathGlobs: []string{"vendor/**"},
+				FixMethod:       github.String("exempt"),
+			},
+			ExpAction: "issue",
+			Exp: mergedConfig{
+				Action:          "issue",
+				IgnorePathGlobs: []string{"testdata/**", "vendor/**"},
+				AllowExtensions: []string{".jar"},
+				FixMethod:       "exempt",
+			},
+		},
 		{
 			Name: "RepoDisallowed",
 			Org: OrgConfig{
@@ -129,3 +155,145 @@ func TestConfigPrecedence(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertAndFilterLogs(t *testing.T) {
+	var logs []checker.CheckDetail
+	for _, p := range []string{
+		"bin/tool",
+		"testdata/a/b.exe",
+		"lib/dep.jar",
+		"lib/DEP.JAR",
+		"gradle/wrapper/gradle-wrapper.jar",
+		"docs/ignored.exe",
+		"other.exe",
+	} {
+		logs = append(logs, checker.CheckDetail{Msg: checker.LogMessage{Path: p}})
+	}
+	mc := &mergedConfig{
+		IgnoreFiles:     []string{"other.exe"},
+		IgnorePaths:     []string{"docs/ignored.exe"},
+		IgnorePathGlobs: []string{"testdata/**", "[invalid"},
+		AllowExtensions: []string{"jar"},
+	}
+	got := convertAndFilterLogs(logs, mc)
+	if diff := cmp.Diff([]string{"bin/tool"}, got); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+}
+
+func TestFix(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Org      OrgConfig
+		Repo     RepoConfig
+		Existing string
+		ExpPR    *pullrequest.Request
+	}{
+		{
+			Name: "Remove",
+			ExpPR: &pullrequest.Request{
+				Branch: fixBranch,
+				Title:  "Remove Binary Artifacts",
+				Files: []pullrequest.File{
+					{Path: "bin/tool", Delete: true},
+					{Path: "lib/dep.so", Delete: true},
+				},
+			},
+		},
+		{
+			Name: "ExemptNewFile",
+			Org: OrgConfig{
+				FixMethod: "exempt",
+			},
+			ExpPR: &pullrequest.Request{
+				Branch: fixBranch,
+				Title:  "Exempt Binary Artifacts",
+				Files: []pullrequest.File{
+					{
+						Path:    ".allstar/binary_artifacts.yaml",
+						Content: []byte("ignorePaths:\n- bin/tool\n- lib/dep.so\n"),
+					},
+				},
+			},
+		},
+		{
+			Name: "ExemptExistingFile",
+			Repo: RepoConfig{
+				FixMethod: github.String("exempt"),
+			},
+			Existing: "optConfig:\n  optOut: false\nignorePaths:\n- old.bin\n",
+			ExpPR: &pullrequest.Request{
+				Branch: fixBranch,
+				Title:  "Exempt Binary Artifacts",
+				Files: []pullrequest.File{
+					{
+						Path:    ".allstar/binary_artifacts.yaml",
+						Content: []byte("ignorePaths:\n- old.bin\n- bin/tool\n- lib/dep.so\noptConfig:\n  optOut: false\n"),
+					},
+				},
+			},
+		},
+		{
+			Name: "ExemptRepoOverrideDisabled",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					DisableRepoOverride: true,
+				},
+				FixMethod: "exempt",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				switch ol {
+				case config.RepoLevel:
+					rc := out.(*RepoConfig)
+					*rc = test.Repo
+				case config.OrgLevel:
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+			getContents = func(ctx context.Context, c *github.Client, owner, repo, p string) (*github.RepositoryContent, *github.Response, error) {
+				if test.Existing == "" {
+					return nil, &github.Response{
+						Response: &http.Response{StatusCode: http.StatusNotFound},
+					}, errors.New("404")
+				}
+				return &github.RepositoryContent{Content: &test.Existing}, nil, nil
+			}
+			var got *pullrequest.Request
+			pullrequestEnsure = func(ctx context.Context, c *github.Client, owner, repo, policy string,
+				pr *pullrequest.Request) (*github.PullRequest, error) {
+				got = pr
+				return nil, nil
+			}
+
+			err := fix(context.Background(), nil, "thisorg", "thisrepo", []string{"bin/tool", "lib/dep.so"})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.ExpPR, got, cmpopts.IgnoreFields(pullrequest.Request{}, "Body")); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGeneratedPrecedence(t *testing.T) {
+	configtest.TestPrecedence(t, configtest.Precedence{
+		Org:   OrgConfig{},
+		Repo:  RepoConfig{},
+		Fetch: &configFetchConfig,
+		Merge: func() interface{} {
+			ctx := context.Background()
+			oc, orc, rc := getConfig(ctx, nil, "", configtest.Repo)
+			return mergeConfig(oc, orc, rc, configtest.Repo)
+		},
+		Append: []string{"IgnorePathGlobs"},
+	})
+}
@@ -17,19 +17,39 @@ package branch
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/exemptions"
 	"github.com/ossf/allstar/pkg/policydef"
+	"github.com/ossf/allstar/pkg/revert"
+	"github.com/ossf/allstar/pkg/reviewbot"
+	"github.com/ossf/allstar/pkg/selector"
 
 	"github.com/google/go-github/v59/github"
 	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/errgroup"
 )
 
 const configFile = "branch_protection.yaml"
 const polName = "Branch Protection"
 
+// maxBranchUpdates is the number of branches of a repo updated concurrently by
+// the Fix action.
+const maxBranchUpdates = 4
+
+// branchUpdateInterval is the minimum time between the Fix action's writes to
+// a single repo, to stay clear of secondary rate limits.
+var branchUpdateInterval = 250 * time.Millisecond
+
 // OrgConfig is the org-level config definition for Branch Protection.
 type OrgConfig struct {
 	// OptConfig is the standard org-level opt in/out config, RepoOverride
@@ -45,9 +65,15 @@ type OrgConfig struct {
 
 	// EnforceBranches is a map of repos and branches. These are other
 	// non-default branches to enforce policy on, such as branches which releases
-	// are made from.
+	// are made from. Branches may be glob patterns, such as "release/*", which
+	// are matched against the existing branches of the repo.
 	EnforceBranches map[string][]string `json:"enforceBranches"`
 
+	// EnforceSelectedBranches adds more branches to enforce policy on, in the
+	// repos selected by each entry, by name, language, or topic. Branches may
+	// be glob patterns, as in EnforceBranches.
+	EnforceSelectedBranches []*SelectedBranches `json:"enforceSelectedBranches"`
+
 	// RequireApproval : set to true to enforce approval on PRs, default true.
 	// When this config is false, ApprovalCount will always be set to 0.
 	RequireApproval bool `json:"requireApproval"`
@@ -76,12 +102,82 @@ type OrgConfig struct {
 	// the context, and optionally an appID.
 	RequireStatusChecks []StatusCheck `json:"requireStatusChecks"`
 
+	// RequireReviewBot : set to true to add the status set by the Allstar
+	// Review Bot, "allstar/reviewbot", to RequireStatusChecks, default false.
+	RequireReviewBot bool `json:"requireReviewBot"`
+
 	// EnforceOnAdmins : set to true to apply the branch protection rules on
 	// administrators as well.
 	EnforceOnAdmins bool `json:"enforceOnAdmins"`
 
 	// RequireSignedCommits : set to true to require signed commits on protected branches, default false
 	RequireSignedCommits bool `json:"requireSignedCommits"`
+
+	// RestrictDismissals : set to true to require that only DismissalActors
+	// may dismiss PR reviews, default false. Only applies to branches
+	// requiring PR reviews.
+	RestrictDismissals bool `json:"restrictDismissals"`
+
+	// DismissalActors are the users, teams, and apps allowed to dismiss PR
+	// reviews when RestrictDismissals is set. If empty, only admins may
+	// dismiss reviews.
+	DismissalActors Actors `json:"dismissalActors"`
+
+	// RestrictBypass : set to true to require that only BypassActors may
+	// bypass the PR requirements, default false.
+	RestrictBypass bool `json:"restrictBypass"`
+
+	// BypassActors are the users, teams, and apps allowed to bypass the PR
+	// requirements when RestrictBypass is set. If empty, no one may bypass.
+	BypassActors Actors `json:"bypassActors"`
+
+	// RequireLinearHistory : set to true to prevent merge commits from being
+	// pushed to protected branches, default false.
+	RequireLinearHistory bool `json:"requireLinearHistory"`
+
+	// RequireConversationResolution : set to true to require all
+	// conversations on a PR to be resolved before merging, default false.
+	RequireConversationResolution bool `json:"requireConversationResolution"`
+
+	// BlockDeletions : set to true to block deletion of protected branches by
+	// users with push access, default false.
+	BlockDeletions bool `json:"blockDeletions"`
+
+	// PreventDowngrade : set to true to audit settings that are stricter than
+	// the policy, such as more required approvals, default false. They are
+	// reported in the details of the check, and the fix action never loosens
+	// them, nor grants review dismissal to actors that can't already dismiss.
+	PreventDowngrade bool `json:"preventDowngrade"`
+
+	// ManagedContext is a required status check context that marks the
+	// protection of a branch as managed by another tool. The fix action does
+	// not modify the protection of branches requiring it. Default empty, all
+	// branches are fixed.
+	ManagedContext string `json:"managedContext"`
+}
+
+// Actors is a list of users, teams, and apps, used for PR review dismissal
+// and bypass allowances.
+type Actors struct {
+	// Users is a list of user logins.
+	Users []string `json:"users"`
+
+	// Teams is a list of team slugs.
+	Teams []string `json:"teams"`
+
+	// Apps is a list of app slugs.
+	Apps []string `json:"apps"`
+}
+
+// SelectedBranches is a set of branches to enforce policy on in the repos
+// selected by Repos.
+type SelectedBranches struct {
+	// Repos is the set of RepoSelectors selecting the repos. A repo is
+	// selected if it matches any selector.
+	Repos []*selector.RepoSelector `json:"repos"`
+
+	// Branches is the list of branches, or glob patterns.
+	Branches []string `json:"branches"`
 }
 
 // RepoConfig is the repo-level config for Branch Protection
@@ -95,8 +191,9 @@ type RepoConfig struct {
 	// EnforceDefault overrides the same setting in org-level, only if present.
 	EnforceDefault *bool `json:"enforceDefault"`
 
-	// EnforceBranches adds more branches to the org-level list. Does not
-	// override. Always allowed irrespective of DisableRepoOverride setting.
+	// EnforceBranches adds more branches, or glob patterns, to the org-level
+	// list. Does not override. Always allowed irrespective of
+	// DisableRepoOverride setting.
 	EnforceBranches []string `json:"enforceBranches"`
 
 	// RequireApproval overrides the same setting in org-level, only if present.
@@ -127,9 +224,45 @@ type RepoConfig struct {
 	// setting to be empty.
 	RequireStatusChecks []StatusCheck `json:"requireStatusChecks"`
 
+	// RequireReviewBot overrides the same setting in org-level, only if
+	// present.
+	RequireReviewBot *bool `json:"requireReviewBot"`
+
 	// RequireSignedCommits overrides the same setting in org-level, only if
 	// present.
 	RequireSignedCommits *bool `json:"requireSignedCommits"`
+
+	// RestrictDismissals overrides the same setting in org-level, only if
+	// present.
+	RestrictDismissals *bool `json:"restrictDismissals"`
+
+	// DismissalActors overrides the same setting in org-level, only if
+	// present.
+	DismissalActors *Actors `json:"dismissalActors"`
+
+	// RestrictBypass overrides the same setting in org-level, only if present.
+	RestrictBypass *bool `json:"restrictBypass"`
+
+	// BypassActors overrides the same setting in org-level, only if present.
+	BypassActors *Actors `json:"bypassActors"`
+
+	// RequireLinearHistory overrides the same setting in org-level, only if
+	// present.
+	RequireLinearHistory *bool `json:"requireLinearHistory"`
+
+	// RequireConversationResolution overrides the same setting in org-level,
+	// only if present.
+	RequireConversationResolution *bool `json:"requireConversationResolution"`
+
+	// BlockDeletions overrides the same setting in org-level, only if present.
+	BlockDeletions *bool `json:"blockDeletions"`
+
+	// PreventDowngrade overrides the same setting in org-level, only if
+	// present.
+	PreventDowngrade *bool `json:"preventDowngrade"`
+
+	// ManagedContext overrides the same setting in org-level, only if present.
+	ManagedContext *string `json:"managedContext"`
 }
 
 // StatusCheck is the config description for specifying a single required
@@ -150,39 +283,63 @@ type statusCheckHash struct {
 }
 
 type mergedConfig struct {
-	Action                  string
-	EnforceDefault          bool
-	EnforceBranches         []string
-	RequireApproval         bool
-	RequireCodeOwnerReviews bool
-	ApprovalCount           int
-	DismissStale            bool
-	BlockForce              bool
-	EnforceOnAdmins         bool
-	RequireUpToDateBranch   bool
-	RequireStatusChecks     []StatusCheck
-	RequireSignedCommits    bool
+	Action                        string
+	EnforceDefault                bool
+	EnforceBranches               []string
+	RequireApproval               bool
+	RequireCodeOwnerReviews       bool
+	ApprovalCount                 int
+	DismissStale                  bool
+	BlockForce                    bool
+	EnforceOnAdmins               bool
+	RequireUpToDateBranch         bool
+	RequireStatusChecks           []StatusCheck
+	RequireReviewBot              bool
+	RequireSignedCommits          bool
+	RestrictDismissals            bool
+	DismissalActors               Actors
+	RestrictBypass                bool
+	BypassActors                  Actors
+	RequireLinearHistory          bool
+	RequireConversationResolution bool
+	BlockDeletions                bool
+	PreventDowngrade              bool
+	ManagedContext                string
 }
 
 type details struct {
-	PRReviews               bool
-	NumReviews              int
-	DismissStale            bool
-	BlockForce              bool
-	EnforceOnAdmins         bool
-	RequireUpToDateBranch   bool
-	RequireStatusChecks     []StatusCheck
-	RequireSignedCommits    bool
-	RequireCodeOwnerReviews bool
+	PRReviews                     bool
+	NumReviews                    int
+	DismissStale                  bool
+	BlockForce                    bool
+	EnforceOnAdmins               bool
+	RequireUpToDateBranch         bool
+	RequireStatusChecks           []StatusCheck
+	RequireSignedCommits          bool
+	RequireCodeOwnerReviews       bool
+	DismissalRestricted           bool
+	DismissalActors               Actors
+	BypassActors                  Actors
+	PushRestricted                bool
+	PushActors                    Actors
+	RequireLinearHistory          bool
+	RequireConversationResolution bool
+	AllowDeletions                bool
+	Managed                       bool
+	StricterThanPolicy            []string
 }
 
 var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
 var configIsEnabled func(ctx context.Context, o config.OrgOptConfig,
 	orc, r config.RepoOptConfig, c *github.Client, owner, repo string) (bool, error)
 
+var exemptionsApply func(context.Context, *github.Client, string, string, string, *policydef.Result) *policydef.Result
+
 func init() {
 	configFetchConfig = config.FetchConfig
 	configIsEnabled = config.IsEnabled
+	exemptionsApply = exemptions.Apply
+	revert.Register(polName, revertFix)
 }
 
 // Branch is the Branch Protection policy object, implements policydef.Policy.
@@ -200,8 +357,7 @@ func (b Branch) Name() string {
 }
 
 type repositories interface {
-	Get(context.Context, string, string) (*github.Repository,
-		*github.Response, error)
+	selector.Repositories
 	ListBranches(context.Context, string, string, *github.BranchListOptions) (
 		[]*github.Branch, *github.Response, error)
 	GetBranchProtection(context.Context, string, string, string) (
@@ -224,7 +380,11 @@ func (b Branch) IsEnabled(ctx context.Context, c *github.Client, owner, repo str
 // configuration stored in the org/repo, implementing policydef.Policy.Check()
 func (b Branch) Check(ctx context.Context, c *github.Client, owner,
 	repo string) (*policydef.Result, error) {
-	return check(ctx, c.Repositories, c, owner, repo)
+	r, err := check(ctx, c.Repositories, c, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	return exemptionsApply(ctx, c, owner, repo, polName, r), nil
 }
 
 func check(ctx context.Context, rep repositories, c *github.Client, owner,
@@ -247,25 +407,13 @@ func check(ctx context.Context, rep repositories, c *github.Client, owner,
 	if err != nil {
 		return nil, err
 	}
+	mc.EnforceBranches = addSelectedBranches(ctx, selector.NewRepo(rep, owner, repo).WithRepository(r),
+		oc.EnforceSelectedBranches, mc.EnforceBranches)
 
-	opt := &github.BranchListOptions{
-		ListOptions: github.ListOptions{
-			PerPage: 100,
-		},
-	}
-	var branches []*github.Branch
-	for {
-		bs, resp, err := rep.ListBranches(ctx, owner, repo, opt)
-		if err != nil {
-			return nil, err
-		}
-		branches = append(branches, bs...)
-		if resp.NextPage == 0 {
-			break
-		}
-		opt.Page = resp.NextPage
+	branches, err := listAllBranches(ctx, rep, owner, repo)
+	if err != nil {
+		return nil, err
 	}
-	// Don't really need pagination here, only checking if no branches exist.
 	if len(branches) == 0 {
 		return &policydef.Result{
 			Enabled:    enabled,
@@ -275,9 +423,9 @@ func check(ctx context.Context, rep repositories, c *github.Client, owner,
 		}, nil
 	}
 
-	allBranches := mc.EnforceBranches
+	allBranches := expandBranches(mc.EnforceBranches, branches)
 	if mc.EnforceDefault {
-		allBranches = append(mc.EnforceBranches, r.GetDefaultBranch())
+		allBranches = append(allBranches, r.GetDefaultBranch())
 	}
 	if len(allBranches) == 0 {
 		return &policydef.Result{
@@ -313,6 +461,7 @@ func check(ctx context.Context, rep repositories, c *github.Client, owner,
 		}
 
 		var d details
+		d.Managed = managed(p, mc.ManagedContext)
 		rev := p.GetRequiredPullRequestReviews()
 		if rev != nil {
 			d.PRReviews = true
@@ -335,6 +484,31 @@ func check(ctx context.Context, rep repositories, c *github.Client, owner,
 					fmt.Sprintf("Require Code Owner Reviews not configured for branch %v\n", b)
 				pass = false
 			}
+			if dr := rev.DismissalRestrictions; dr != nil {
+				d.DismissalRestricted = true
+				d.DismissalActors = actorsOf(dr.Users, dr.Teams, dr.Apps)
+			}
+			if mc.RestrictDismissals {
+				if !d.DismissalRestricted {
+					text = text +
+						fmt.Sprintf("Review dismissal restrictions not configured for branch %v\n", b)
+					pass = false
+				} else if na := d.DismissalActors.notIn(mc.DismissalActors); !na.empty() {
+					text = text +
+						fmt.Sprintf("Review dismissal allowed for %v, not allowed by policy, for branch %v\n", na, b)
+					pass = false
+				}
+			}
+			if ba := rev.BypassPullRequestAllowances; ba != nil {
+				d.BypassActors = actorsOf(ba.Users, ba.Teams, ba.Apps)
+			}
+			if mc.RestrictBypass {
+				if na := d.BypassActors.notIn(mc.BypassActors); !na.empty() {
+					text = text +
+						fmt.Sprintf("PR requirements bypass allowed for %v, not allowed by policy, for branch %v\n", na, b)
+					pass = false
+				}
+			}
 		} else {
 			if mc.RequireApproval || mc.RequireCodeOwnerReviews {
 				pass = false
@@ -342,6 +516,10 @@ func check(ctx context.Context, rep repositories, c *github.Client, owner,
 					fmt.Sprintf("PR Approvals not configured for branch %v\n", b)
 			}
 		}
+		if r := p.GetRestrictions(); r != nil {
+			d.PushRestricted = true
+			d.PushActors = actorsOf(r.Users, r.Teams, r.Apps)
+		}
 		afp := p.GetAllowForcePushes()
 		d.BlockForce = true
 		if afp != nil {
@@ -352,6 +530,27 @@ func check(ctx context.Context, rep repositories, c *github.Client, owner,
 				d.BlockForce = false
 			}
 		}
+		lh := p.GetRequireLinearHistory()
+		d.RequireLinearHistory = lh != nil && lh.Enabled
+		if mc.RequireLinearHistory && !d.RequireLinearHistory {
+			text = text +
+				fmt.Sprintf("Require linear history not configured for branch %v\n", b)
+			pass = false
+		}
+		cr := p.GetRequiredConversationResolution()
+		d.RequireConversationResolution = cr != nil && cr.Enabled
+		if mc.RequireConversationResolution && !d.RequireConversationResolution {
+			text = text +
+				fmt.Sprintf("Require conversation resolution not configured for branch %v\n", b)
+			pass = false
+		}
+		ad := p.GetAllowDeletions()
+		d.AllowDeletions = ad != nil && ad.Enabled
+		if mc.BlockDeletions && d.AllowDeletions {
+			text = text +
+				fmt.Sprintf("Block deletions not configured for branch %v\n", b)
+			pass = false
+		}
 		ea := p.GetEnforceAdmins()
 		d.EnforceOnAdmins = (ea != nil && ea.Enabled)
 		if mc.EnforceOnAdmins && (ea == nil || !ea.Enabled) {
@@ -405,6 +604,9 @@ func check(ctx context.Context, rep repositories, c *github.Client, owner,
 			pass = false
 			text = text + fmt.Sprintf("Signed commits required, but not enabled for branch: %v\n", b)
 		}
+		if mc.PreventDowngrade {
+			d.StricterThanPolicy = stricterThanPolicy(mc, d)
+		}
 
 		ds[b] = d
 	}
@@ -438,11 +640,29 @@ func fix(ctx context.Context, rep repositories, c *github.Client,
 	if err != nil {
 		return err
 	}
+	mc.EnforceBranches = addSelectedBranches(ctx, selector.NewRepo(rep, owner, repo).WithRepository(r),
+		oc.EnforceSelectedBranches, mc.EnforceBranches)
 	allBranches := mc.EnforceBranches
+	if hasPattern(allBranches) {
+		branches, err := listAllBranches(ctx, rep, owner, repo)
+		if err != nil {
+			return err
+		}
+		allBranches = expandBranches(allBranches, branches)
+	}
 	if mc.EnforceDefault {
-		allBranches = append(mc.EnforceBranches, r.GetDefaultBranch())
+		allBranches = append(allBranches, r.GetDefaultBranch())
 	}
+	// Plan all updates first, so that branches already matching the config are
+	// skipped and the remaining updates can be applied together.
+	var plan []*branchFix
+	var unchanged []string
+	seen := make(map[string]bool)
 	for _, b := range allBranches {
+		if seen[b] {
+			continue
+		}
+		seen[b] = true
 		p, rsp, err := rep.GetBranchProtection(ctx, owner, repo, b)
 		if err != nil {
 			if rsp != nil && rsp.StatusCode == http.StatusNotFound {
@@ -454,12 +674,24 @@ func fix(ctx context.Context, rep repositories, c *github.Client,
 				if mc.EnforceOnAdmins {
 					pr.EnforceAdmins = true
 				}
+				if mc.RequireLinearHistory {
+					pr.RequireLinearHistory = github.Bool(true)
+				}
+				if mc.RequireConversationResolution {
+					pr.RequiredConversationResolution = github.Bool(true)
+				}
+				if mc.BlockDeletions {
+					pr.AllowDeletions = github.Bool(false)
+				}
 				if mc.RequireApproval || mc.RequireCodeOwnerReviews {
 					rq := &github.PullRequestReviewsEnforcementRequest{
 						DismissStaleReviews:          mc.DismissStale,
 						RequiredApprovingReviewCount: mc.ApprovalCount,
 						RequireCodeOwnerReviews:      mc.RequireCodeOwnerReviews,
 					}
+					if mc.RestrictDismissals {
+						rq.DismissalRestrictionsRequest = mc.DismissalActors.dismissalRequest()
+					}
 					pr.RequiredPullRequestReviews = rq
 				}
 				if len(mc.RequireStatusChecks) > 0 {
@@ -476,19 +708,7 @@ func fix(ctx context.Context, rep repositories, c *github.Client,
 					}
 					pr.RequiredStatusChecks = rsc
 				}
-				_, rsp, err := rep.UpdateBranchProtection(ctx, owner, repo, b, pr)
-				if err != nil {
-					if rsp != nil && rsp.StatusCode == http.StatusForbidden {
-						log.Warn().
-							Str("org", owner).
-							Str("repo", repo).
-							Str("area", polName).
-							Msg("Action set to fix, but did not accept admin:write permissions update.")
-						// no sense to continue, just return
-						return nil
-					}
-					return err
-				}
+				plan = append(plan, &branchFix{branch: b, pr: pr, create: true})
 				continue
 			}
 			if rsp != nil && rsp.StatusCode == http.StatusForbidden {
@@ -502,57 +722,36 @@ func fix(ctx context.Context, rep repositories, c *github.Client,
 			}
 			return err
 		}
-		// Got existing protection, modify from existing
-		update := false
-		pr := &github.ProtectionRequest{
-			RequiredStatusChecks: p.RequiredStatusChecks,
-			EnforceAdmins:        p.EnforceAdmins.Enabled,
-			AllowForcePushes:     &p.AllowForcePushes.Enabled,
+		if managed(p, mc.ManagedContext) {
+			log.Info().
+				Str("org", owner).
+				Str("repo", repo).
+				Str("area", polName).
+				Str("branch", b).
+				Str("context", mc.ManagedContext).
+				Msg("Branch protection is managed by another tool, not fixing.")
+			continue
 		}
+		// Got existing protection, modify from existing
+		pr := protectionRequest(p)
+		// Required status checks without checks are removed, as updates with
+		// them fail.
+		update := p.RequiredStatusChecks != nil && pr.RequiredStatusChecks == nil
 		if mc.EnforceOnAdmins && !pr.EnforceAdmins {
 			pr.EnforceAdmins = true
 			update = true
 		}
-		if pr.RequiredStatusChecks != nil {
-			// Clear out Contexts, since API populates both, but updates require only one.
-			pr.RequiredStatusChecks.Contexts = nil
-			// If there are no actual checks or contexts, then unset RequiredStatusChecks entirely,
-			// otherwise update fails
-			if len(pr.RequiredStatusChecks.Checks) == 0 && len(pr.RequiredStatusChecks.Contexts) == 0 {
-				update = true
-				pr.RequiredStatusChecks = nil
-			}
+		if mc.RequireLinearHistory && !pr.GetRequireLinearHistory() {
+			pr.RequireLinearHistory = github.Bool(true)
+			update = true
 		}
-		if p.RequiredPullRequestReviews != nil {
-			prr := &github.PullRequestReviewsEnforcementRequest{
-				DismissStaleReviews:          p.RequiredPullRequestReviews.DismissStaleReviews,
-				RequireCodeOwnerReviews:      p.RequiredPullRequestReviews.RequireCodeOwnerReviews,
-				RequiredApprovingReviewCount: p.RequiredPullRequestReviews.RequiredApprovingReviewCount,
-			}
-			pr.RequiredPullRequestReviews = prr
+		if mc.RequireConversationResolution && !pr.GetRequiredConversationResolution() {
+			pr.RequiredConversationResolution = github.Bool(true)
+			update = true
 		}
-		if p.Restrictions != nil {
-			rr := &github.BranchRestrictionsRequest{
-				Users: make([]string, 0),
-				Teams: make([]string, 0),
-			}
-			if p.Restrictions.Users != nil {
-				for _, u := range p.Restrictions.Users {
-					rr.Users = append(rr.Users, *u.Login)
-				}
-			}
-			if p.Restrictions.Teams != nil {
-				for _, t := range p.Restrictions.Teams {
-					rr.Teams = append(rr.Teams, *t.Slug)
-				}
-			}
-			if p.Restrictions.Apps != nil {
-				rr.Apps = make([]string, 0)
-				for _, a := range p.Restrictions.Apps {
-					rr.Apps = append(rr.Apps, *a.Slug)
-				}
-			}
-			pr.Restrictions = rr
+		if mc.BlockDeletions && pr.GetAllowDeletions() {
+			pr.AllowDeletions = github.Bool(false)
+			update = true
 		}
 		if *pr.AllowForcePushes && mc.BlockForce {
 			f := false
@@ -582,6 +781,32 @@ func fix(ctx context.Context, rep repositories, c *github.Client,
 				update = true
 			}
 		}
+		if prr := pr.RequiredPullRequestReviews; prr != nil {
+			if mc.RestrictDismissals {
+				existing := p.GetRequiredPullRequestReviews().GetDismissalRestrictions()
+				if existing == nil {
+					prr.DismissalRestrictionsRequest = mc.DismissalActors.dismissalRequest()
+					update = true
+				} else if cur := actorsOf(existing.Users, existing.Teams, existing.Apps); !cur.notIn(mc.DismissalActors).empty() {
+					if mc.PreventDowngrade {
+						// Only remove disallowed actors, never grant dismissal.
+						prr.DismissalRestrictionsRequest = cur.in(mc.DismissalActors).dismissalRequest()
+					} else {
+						prr.DismissalRestrictionsRequest = mc.DismissalActors.dismissalRequest()
+					}
+					update = true
+				}
+			}
+			if mc.RestrictBypass && prr.BypassPullRequestAllowancesRequest != nil {
+				// Only remove disallowed actors, never grant bypass.
+				ba := prr.BypassPullRequestAllowancesRequest
+				cur := Actors{Users: ba.Users, Teams: ba.Teams, Apps: ba.Apps}
+				if !cur.notIn(mc.BypassActors).empty() {
+					prr.BypassPullRequestAllowancesRequest = cur.in(mc.BypassActors).bypassRequest()
+					update = true
+				}
+			}
+		}
 		if len(mc.RequireStatusChecks) > 0 {
 			if pr.RequiredStatusChecks == nil {
 				checks := make([]*github.RequiredStatusCheck, len(mc.RequireStatusChecks))
@@ -618,54 +843,336 @@ func fix(ctx context.Context, rep repositories, c *github.Client,
 				pr.RequiredStatusChecks.Checks = ac
 			}
 		}
+		bf := &branchFix{branch: b, prior: protectionRequest(p)}
 		if update {
-			_, rsp, err := rep.UpdateBranchProtection(ctx, owner, repo, b, pr)
-			if err != nil {
-				if rsp != nil && rsp.StatusCode == http.StatusForbidden {
-					log.Warn().
-						Str("org", owner).
-						Str("repo", repo).
-						Str("area", polName).
-						Msg("Action set to fix, but did not accept admin:write permissions update.")
-					return nil
-				}
-				return err
-			}
-			log.Info().
-				Str("org", owner).
-				Str("repo", repo).
-				Str("area", polName).
-				Msg("Updated with Fix action.")
+			bf.pr = pr
 		}
 
 		signatureProtectionEnabled, err := getSignatureProtectionEnabled(ctx, rep, owner, repo, b)
 		if err != nil {
 			return err
 		}
-		if mc.RequireSignedCommits && !signatureProtectionEnabled {
-			_, rsp, err = rep.RequireSignaturesOnProtectedBranch(ctx, owner, repo, b)
-
-			if err != nil {
-				if rsp != nil && rsp.StatusCode == http.StatusForbidden {
-					log.Warn().
-						Str("org", owner).
-						Str("repo", repo).
-						Str("area", polName).
-						Str("branch", b).
-						Msg("Action set to fix, but did not accept admin:write update to make signed commits required.")
-					return nil
-				}
-				return err
+		bf.requireSignatures = mc.RequireSignedCommits && !signatureProtectionEnabled
+		if bf.pr == nil && !bf.requireSignatures {
+			unchanged = append(unchanged, b)
+			continue
+		}
+		plan = append(plan, bf)
+	}
+	return applyBranchFixes(ctx, rep, owner, repo, plan, unchanged)
+}
+
+// protectionRequest returns the request that sets the existing protection p
+// unchanged, for the policy to modify, and to revert fixes to.
+func protectionRequest(p *github.Protection) *github.ProtectionRequest {
+	pr := &github.ProtectionRequest{
+		EnforceAdmins:    p.EnforceAdmins.Enabled,
+		AllowForcePushes: github.Bool(p.AllowForcePushes.Enabled),
+	}
+	keepUnmodeled(pr, p)
+	if p.RequiredStatusChecks != nil {
+		// Copy, as the policy modifies the checks of the request.
+		rsc := *p.RequiredStatusChecks
+		rsc.Checks = append([]*github.RequiredStatusCheck{}, rsc.Checks...)
+		// Clear out Contexts, since API populates both, but updates require only one.
+		rsc.Contexts = nil
+		// If there are no actual checks or contexts, then unset RequiredStatusChecks entirely,
+		// otherwise update fails
+		if len(rsc.Checks) > 0 {
+			pr.RequiredStatusChecks = &rsc
+		}
+	}
+	if p.RequiredPullRequestReviews != nil {
+		prr := &github.PullRequestReviewsEnforcementRequest{
+			DismissStaleReviews:          p.RequiredPullRequestReviews.DismissStaleReviews,
+			RequireCodeOwnerReviews:      p.RequiredPullRequestReviews.RequireCodeOwnerReviews,
+			RequiredApprovingReviewCount: p.RequiredPullRequestReviews.RequiredApprovingReviewCount,
+		}
+		if p.RequiredPullRequestReviews.RequireLastPushApproval {
+			prr.RequireLastPushApproval = github.Bool(true)
+		}
+		// Keep existing dismissal restrictions and bypass allowances, which
+		// are otherwise removed by the update.
+		if dr := p.RequiredPullRequestReviews.DismissalRestrictions; dr != nil {
+			prr.DismissalRestrictionsRequest = actorsOf(dr.Users, dr.Teams, dr.Apps).dismissalRequest()
+		}
+		if ba := p.RequiredPullRequestReviews.BypassPullRequestAllowances; ba != nil {
+			prr.BypassPullRequestAllowancesRequest = actorsOf(ba.Users, ba.Teams, ba.Apps).bypassRequest()
+		}
+		pr.RequiredPullRequestReviews = prr
+	}
+	if p.Restrictions != nil {
+		rr := &github.BranchRestrictionsRequest{
+			Users: make([]string, 0),
+			Teams: make([]string, 0),
+		}
+		if p.Restrictions.Users != nil {
+			for _, u := range p.Restrictions.Users {
+				rr.Users = append(rr.Users, *u.Login)
+			}
+		}
+		if p.Restrictions.Teams != nil {
+			for _, t := range p.Restrictions.Teams {
+				rr.Teams = append(rr.Teams, *t.Slug)
 			}
-			log.Info().
-				Str("org", owner).
-				Str("repo", repo).
-				Str("area", polName).
-				Str("branch", b).
-				Msg("Updated to make signed commits required with Fix action.")
 		}
+		if p.Restrictions.Apps != nil {
+			rr.Apps = make([]string, 0)
+			for _, a := range p.Restrictions.Apps {
+				rr.Apps = append(rr.Apps, *a.Slug)
+			}
+		}
+		pr.Restrictions = rr
+	}
+	return pr
+}
+
+// managed returns whether the protection p requires the status check
+// context marking it as managed by another tool.
+func managed(p *github.Protection, marker string) bool {
+	if marker == "" {
+		return false
+	}
+	rsc := p.GetRequiredStatusChecks()
+	if rsc == nil {
+		return false
+	}
+	for _, c := range rsc.Checks {
+		if c.Context == marker {
+			return true
+		}
+	}
+	for _, c := range rsc.Contexts {
+		if c == marker {
+			return true
+		}
+	}
+	return false
+}
+
+// stricterThanPolicy returns the settings of the protection d that are
+// stricter than the policy requires.
+func stricterThanPolicy(mc *mergedConfig, d details) []string {
+	var s []string
+	if d.PRReviews && !mc.RequireApproval && !mc.RequireCodeOwnerReviews {
+		s = append(s, "PR reviews required")
+	}
+	if d.NumReviews > mc.ApprovalCount {
+		s = append(s, fmt.Sprintf("PR approvals %v, policy requires %v", d.NumReviews, mc.ApprovalCount))
+	}
+	if d.DismissStale && !mc.DismissStale {
+		s = append(s, "Stale reviews dismissed")
+	}
+	if d.RequireCodeOwnerReviews && !mc.RequireCodeOwnerReviews {
+		s = append(s, "Code owner reviews required")
+	}
+	if d.EnforceOnAdmins && !mc.EnforceOnAdmins {
+		s = append(s, "Enforced on admins")
+	}
+	if d.RequireUpToDateBranch && !mc.RequireUpToDateBranch {
+		s = append(s, "Up to date branch required")
+	}
+	if d.RequireLinearHistory && !mc.RequireLinearHistory {
+		s = append(s, "Linear history required")
+	}
+	if d.RequireConversationResolution && !mc.RequireConversationResolution {
+		s = append(s, "Conversation resolution required")
+	}
+	if d.RequireSignedCommits && !mc.RequireSignedCommits {
+		s = append(s, "Signed commits required")
+	}
+	return s
+}
+
+// keepUnmodeled copies the settings of the existing protection p that the
+// policy does not model, or only tightens when configured, into pr. Updating
+// protection replaces all of it, so these would otherwise be reset. Required
+// deployments are not in the branch protection API, so can not be kept, and
+// may be reset by the update.
+func keepUnmodeled(pr *github.ProtectionRequest, p *github.Protection) {
+	if p.RequireLinearHistory != nil {
+		pr.RequireLinearHistory = github.Bool(p.RequireLinearHistory.Enabled)
+	}
+	if p.AllowDeletions != nil {
+		pr.AllowDeletions = github.Bool(p.AllowDeletions.Enabled)
+	}
+	if p.RequiredConversationResolution != nil {
+		pr.RequiredConversationResolution = github.Bool(p.RequiredConversationResolution.Enabled)
+	}
+	if p.BlockCreations != nil {
+		pr.BlockCreations = p.BlockCreations.Enabled
+	}
+	if p.LockBranch != nil {
+		pr.LockBranch = p.LockBranch.Enabled
+	}
+	if p.AllowForkSyncing != nil {
+		pr.AllowForkSyncing = p.AllowForkSyncing.Enabled
 	}
-	return nil
+}
+
+// branchFix is a planned Fix action change to a single branch.
+type branchFix struct {
+	branch string
+	// pr is the protection to set, nil if unchanged.
+	pr *github.ProtectionRequest
+	// create is set if the branch had no protection.
+	create bool
+	// prior is the protection before the change, nil if the branch had none.
+	prior *github.ProtectionRequest
+	// requireSignatures is set to enable required signatures.
+	requireSignatures bool
+}
+
+// fixForbiddenError is returned when an update is not permitted, after which
+// there is no sense to continue.
+type fixForbiddenError struct {
+	branch string
+	msg    string
+	err    error
+}
+
+func (e *fixForbiddenError) Error() string { return e.err.Error() }
+func (e *fixForbiddenError) Unwrap() error { return e.err }
+
+// applyBranchFixes applies the planned changes, up to maxBranchUpdates
+// branches at a time, with the writes of the repo spaced by
+// branchUpdateInterval. All changed branches are logged in a single summary.
+func applyBranchFixes(ctx context.Context, rep repositories, owner, repo string,
+	plan []*branchFix, unchanged []string) error {
+	var mu sync.Mutex
+	var created, updated, signed, notFound []string
+	record := func(l *[]string, b string) {
+		mu.Lock()
+		*l = append(*l, b)
+		mu.Unlock()
+	}
+	lim := &updateLimiter{}
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(maxBranchUpdates)
+	for _, bf := range plan {
+		bf := bf
+		g.Go(func() error {
+			recordPrior(gctx, owner, repo, bf)
+			if bf.pr != nil {
+				if err := lim.wait(gctx); err != nil {
+					return err
+				}
+				_, rsp, err := rep.UpdateBranchProtection(gctx, owner, repo, bf.branch, bf.pr)
+				if err != nil {
+					if rsp != nil && rsp.StatusCode == http.StatusForbidden {
+						return &fixForbiddenError{
+							branch: bf.branch,
+							msg:    "Action set to fix, but did not accept admin:write permissions update.",
+							err:    err,
+						}
+					}
+					if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+						logBranchNotFound(owner, repo, bf.branch)
+						record(&notFound, bf.branch)
+						return nil
+					}
+					return err
+				}
+				if bf.create {
+					record(&created, bf.branch)
+				} else {
+					record(&updated, bf.branch)
+				}
+			}
+			if bf.requireSignatures {
+				if err := lim.wait(gctx); err != nil {
+					return err
+				}
+				_, rsp, err := rep.RequireSignaturesOnProtectedBranch(gctx, owner, repo, bf.branch)
+				if err != nil {
+					if rsp != nil && rsp.StatusCode == http.StatusForbidden {
+						return &fixForbiddenError{
+							branch: bf.branch,
+							msg:    "Action set to fix, but did not accept admin:write update to make signed commits required.",
+							err:    err,
+						}
+					}
+					if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+						logBranchNotFound(owner, repo, bf.branch)
+						record(&notFound, bf.branch)
+						return nil
+					}
+					return err
+				}
+				record(&signed, bf.branch)
+			}
+			return nil
+		})
+	}
+	err := g.Wait()
+	sort.Strings(created)
+	sort.Strings(updated)
+	sort.Strings(signed)
+	sort.Strings(notFound)
+	if len(created)+len(updated)+len(signed)+len(notFound) > 0 {
+		log.Info().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", polName).
+			Strs("created", created).
+			Strs("updated", updated).
+			Strs("signaturesRequired", signed).
+			Strs("notFound", notFound).
+			Strs("unchanged", unchanged).
+			Msg("Updated with Fix action.")
+	}
+	var fe *fixForbiddenError
+	if errors.As(err, &fe) {
+		log.Warn().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", polName).
+			Str("branch", fe.branch).
+			Msg(fe.msg)
+		return nil
+	}
+	return err
+}
+
+// updateLimiter spaces out the branch protection writes to a repo by
+// branchUpdateInterval.
+type updateLimiter struct {
+	mu   sync.Mutex
+	next time.Time
+}
+
+func (l *updateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(branchUpdateInterval)
+	l.mu.Unlock()
+	d := time.Until(at)
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// logBranchNotFound logs a branch that disappeared while being fixed, such as
+// when it is deleted, or the repo renamed or transferred, mid-run.
+func logBranchNotFound(owner, repo, branch string) {
+	log.Warn().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Str("branch", branch).
+		Msg("Branch not found while updating protection, skipping.")
 }
 
 func getSignatureProtectionEnabled(ctx context.Context, rep repositories, owner string, repo string, branch string) (
@@ -739,18 +1246,28 @@ func getConfig(ctx context.Context, c *github.Client, owner, repo string) (*OrgC
 
 func mergeConfig(oc *OrgConfig, orc, rc *RepoConfig, repo string) *mergedConfig {
 	mc := &mergedConfig{
-		Action:                  oc.Action,
-		EnforceDefault:          oc.EnforceDefault,
-		EnforceBranches:         oc.EnforceBranches[repo],
-		RequireApproval:         oc.RequireApproval,
-		RequireCodeOwnerReviews: oc.RequireCodeOwnerReviews,
-		ApprovalCount:           oc.ApprovalCount,
-		DismissStale:            oc.DismissStale,
-		BlockForce:              oc.BlockForce,
-		EnforceOnAdmins:         oc.EnforceOnAdmins,
-		RequireUpToDateBranch:   oc.RequireUpToDateBranch,
-		RequireStatusChecks:     oc.RequireStatusChecks,
-		RequireSignedCommits:    oc.RequireSignedCommits,
+		Action:                        oc.Action,
+		EnforceDefault:                oc.EnforceDefault,
+		EnforceBranches:               oc.EnforceBranches[repo],
+		RequireApproval:               oc.RequireApproval,
+		RequireCodeOwnerReviews:       oc.RequireCodeOwnerReviews,
+		ApprovalCount:                 oc.ApprovalCount,
+		DismissStale:                  oc.DismissStale,
+		BlockForce:                    oc.BlockForce,
+		EnforceOnAdmins:               oc.EnforceOnAdmins,
+		RequireUpToDateBranch:         oc.RequireUpToDateBranch,
+		RequireStatusChecks:           oc.RequireStatusChecks,
+		RequireReviewBot:              oc.RequireReviewBot,
+		RequireSignedCommits:          oc.RequireSignedCommits,
+		RestrictDismissals:            oc.RestrictDismissals,
+		DismissalActors:               oc.DismissalActors,
+		RestrictBypass:                oc.RestrictBypass,
+		BypassActors:                  oc.BypassActors,
+		RequireLinearHistory:          oc.RequireLinearHistory,
+		RequireConversationResolution: oc.RequireConversationResolution,
+		BlockDeletions:                oc.BlockDeletions,
+		PreventDowngrade:              oc.PreventDowngrade,
+		ManagedContext:                oc.ManagedContext,
 	}
 	mc.EnforceBranches = append(mc.EnforceBranches, orc.EnforceBranches...)
 	mc = mergeInRepoConfig(mc, orc, repo)
@@ -764,9 +1281,27 @@ func mergeConfig(oc *OrgConfig, orc, rc *RepoConfig, repo string) *mergedConfig
 		mc.ApprovalCount = 0
 	}
 
+	if mc.RequireReviewBot {
+		mc.RequireStatusChecks = withReviewBot(mc.RequireStatusChecks)
+	}
+
 	return mc
 }
 
+// withReviewBot returns the status checks with the Review Bot's status added,
+// if not already listed.
+func withReviewBot(scs []StatusCheck) []StatusCheck {
+	for _, sc := range scs {
+		if sc.Context == reviewbot.StatusContext {
+			return scs
+		}
+	}
+	// Copy, to not modify the org-level config's list.
+	out := make([]StatusCheck, 0, len(scs)+1)
+	out = append(out, scs...)
+	return append(out, StatusCheck{Context: reviewbot.StatusContext})
+}
+
 func mergeInRepoConfig(mc *mergedConfig, rc *RepoConfig, repo string) *mergedConfig {
 	if rc.Action != nil {
 		mc.Action = *rc.Action
@@ -798,12 +1333,219 @@ func mergeInRepoConfig(mc *mergedConfig, rc *RepoConfig, repo string) *mergedCon
 	if rc.RequireStatusChecks != nil {
 		mc.RequireStatusChecks = rc.RequireStatusChecks
 	}
+	if rc.RequireReviewBot != nil {
+		mc.RequireReviewBot = *rc.RequireReviewBot
+	}
 	if rc.RequireSignedCommits != nil {
 		mc.RequireSignedCommits = *rc.RequireSignedCommits
 	}
+	if rc.RestrictDismissals != nil {
+		mc.RestrictDismissals = *rc.RestrictDismissals
+	}
+	if rc.DismissalActors != nil {
+		mc.DismissalActors = *rc.DismissalActors
+	}
+	if rc.RestrictBypass != nil {
+		mc.RestrictBypass = *rc.RestrictBypass
+	}
+	if rc.BypassActors != nil {
+		mc.BypassActors = *rc.BypassActors
+	}
+	if rc.RequireLinearHistory != nil {
+		mc.RequireLinearHistory = *rc.RequireLinearHistory
+	}
+	if rc.RequireConversationResolution != nil {
+		mc.RequireConversationResolution = *rc.RequireConversationResolution
+	}
+	if rc.BlockDeletions != nil {
+		mc.BlockDeletions = *rc.BlockDeletions
+	}
+	if rc.PreventDowngrade != nil {
+		mc.PreventDowngrade = *rc.PreventDowngrade
+	}
+	if rc.ManagedContext != nil {
+		mc.ManagedContext = *rc.ManagedContext
+	}
 	return mc
 }
 
+// listAllBranches lists all branches of the repo.
+func listAllBranches(ctx context.Context, rep repositories, owner, repo string) ([]*github.Branch, error) {
+	opt := &github.BranchListOptions{
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+	var branches []*github.Branch
+	for {
+		bs, resp, err := rep.ListBranches(ctx, owner, repo, opt)
+		if err != nil {
+			return nil, err
+		}
+		branches = append(branches, bs...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return branches, nil
+}
+
+// isPattern returns whether an EnforceBranches entry is a glob pattern,
+// rather than a branch name.
+func isPattern(b string) bool {
+	return strings.ContainsAny(b, "*?[")
+}
+
+func hasPattern(bs []string) bool {
+	for _, b := range bs {
+		if isPattern(b) {
+			return true
+		}
+	}
+	return false
+}
+
+// addSelectedBranches adds the branches of the EnforceSelectedBranches entries
+// that select repo to enforce, skipping those already in enforce.
+func addSelectedBranches(ctx context.Context, repo *selector.Repo, sbs []*SelectedBranches, enforce []string) []string {
+	for _, sb := range sbs {
+		match, err := selector.MatchAny(ctx, sb.Repos, repo)
+		if err != nil {
+			log.Warn().
+				Str("org", repo.Owner).
+				Str("repo", repo.Name).
+				Str("area", polName).
+				Err(err).
+				Msg("Unexpected error matching enforceSelectedBranches repo selectors.")
+		}
+		if !match {
+			continue
+		}
+		for _, b := range sb.Branches {
+			if !slices.Contains(enforce, b) {
+				enforce = append(enforce, b)
+			}
+		}
+	}
+	return enforce
+}
+
+// expandBranches returns the EnforceBranches entries, with glob patterns
+// replaced by the names of the existing branches they match, as matched by
+// path.Match. Branch names are kept as is, even if the branch does not exist,
+// so that it is reported. Duplicates are removed.
+func expandBranches(enforce []string, branches []*github.Branch) []string {
+	var out []string
+	seen := make(map[string]bool)
+	add := func(b string) {
+		if !seen[b] {
+			seen[b] = true
+			out = append(out, b)
+		}
+	}
+	for _, e := range enforce {
+		if !isPattern(e) {
+			add(e)
+			continue
+		}
+		for _, br := range branches {
+			if ok, _ := path.Match(e, br.GetName()); ok {
+				add(br.GetName())
+			}
+		}
+	}
+	return out
+}
+
+func actorsOf(users []*github.User, teams []*github.Team, apps []*github.App) Actors {
+	var a Actors
+	for _, u := range users {
+		a.Users = append(a.Users, u.GetLogin())
+	}
+	for _, t := range teams {
+		a.Teams = append(a.Teams, t.GetSlug())
+	}
+	for _, ap := range apps {
+		a.Apps = append(a.Apps, ap.GetSlug())
+	}
+	return a
+}
+
+func (a Actors) empty() bool {
+	return len(a.Users) == 0 && len(a.Teams) == 0 && len(a.Apps) == 0
+}
+
+// notIn returns the actors of a that are not in allowed.
+func (a Actors) notIn(allowed Actors) Actors {
+	return Actors{
+		Users: filter(a.Users, allowed.Users, false),
+		Teams: filter(a.Teams, allowed.Teams, false),
+		Apps:  filter(a.Apps, allowed.Apps, false),
+	}
+}
+
+// in returns the actors of a that are in allowed.
+func (a Actors) in(allowed Actors) Actors {
+	return Actors{
+		Users: filter(a.Users, allowed.Users, true),
+		Teams: filter(a.Teams, allowed.Teams, true),
+		Apps:  filter(a.Apps, allowed.Apps, true),
+	}
+}
+
+func (a Actors) String() string {
+	var ss []string
+	for _, u := range a.Users {
+		ss = append(ss, "user "+u)
+	}
+	for _, t := range a.Teams {
+		ss = append(ss, "team "+t)
+	}
+	for _, ap := range a.Apps {
+		ss = append(ss, "app "+ap)
+	}
+	return strings.Join(ss, ", ")
+}
+
+func (a Actors) dismissalRequest() *github.DismissalRestrictionsRequest {
+	users := append([]string{}, a.Users...)
+	teams := append([]string{}, a.Teams...)
+	apps := append([]string{}, a.Apps...)
+	return &github.DismissalRestrictionsRequest{
+		Users: &users,
+		Teams: &teams,
+		Apps:  &apps,
+	}
+}
+
+func (a Actors) bypassRequest() *github.BypassPullRequestAllowancesRequest {
+	return &github.BypassPullRequestAllowancesRequest{
+		Users: append([]string{}, a.Users...),
+		Teams: append([]string{}, a.Teams...),
+		Apps:  append([]string{}, a.Apps...),
+	}
+}
+
+// filter returns the names that are, or are not, in list. Names are compared
+// ignoring case, as GitHub logins and slugs are case insensitive.
+func filter(names, list []string, in bool) []string {
+	var out []string
+	for _, n := range names {
+		found := false
+		for _, l := range list {
+			if strings.EqualFold(n, l) {
+				found = true
+				break
+			}
+		}
+		if found == in {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
 func makeSCLookupTable(prrsc []*github.RequiredStatusCheck) map[statusCheckHash]struct{} {
 	lt := make(map[statusCheckHash]struct{}, len(prrsc))
 	for _, c := range prrsc {
@@ -19,12 +19,16 @@ import (
 	"errors"
 	"net/http"
 	"sort"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-github/v59/github"
 	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/configtest"
 	"github.com/ossf/allstar/pkg/policydef"
+	"github.com/ossf/allstar/pkg/selector"
 )
 
 var get func(context.Context, string, string) (*github.Repository,
@@ -47,6 +51,11 @@ func (m mockRepos) Get(ctx context.Context, o string, r string) (
 	return get(ctx, o, r)
 }
 
+func (m mockRepos) ListLanguages(ctx context.Context, o string, r string) (
+	map[string]int, *github.Response, error) {
+	return nil, nil, nil
+}
+
 func (m mockRepos) ListBranches(ctx context.Context, o string, r string,
 	op *github.BranchListOptions) ([]*github.Branch, *github.Response, error) {
 	return listBranches(ctx, o, r, op)
@@ -200,6 +209,51 @@ func TestConfigPrecedence(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "RequireReviewBot",
+			Org: OrgConfig{
+				Action:           "issue",
+				RequireReviewBot: true,
+				RequireStatusChecks: []StatusCheck{
+					{"mycheck", nil},
+				},
+			},
+			OrgRepo: RepoConfig{},
+			Repo: RepoConfig{
+				RequireStatusChecks: []StatusCheck{
+					{"allstar/reviewbot", nil}, {"bestcheck", nil},
+				},
+			},
+			ExpAction: "issue",
+			Exp: mergedConfig{
+				Action:           "issue",
+				RequireReviewBot: true,
+				RequireStatusChecks: []StatusCheck{
+					{"allstar/reviewbot", nil}, {"bestcheck", nil},
+				},
+			},
+		},
+		{
+			Name: "RequireReviewBotAdded",
+			Org: OrgConfig{
+				Action: "issue",
+				RequireStatusChecks: []StatusCheck{
+					{"mycheck", nil},
+				},
+			},
+			OrgRepo: RepoConfig{
+				RequireReviewBot: github.Bool(true),
+			},
+			Repo:      RepoConfig{},
+			ExpAction: "issue",
+			Exp: mergedConfig{
+				Action:           "issue",
+				RequireReviewBot: true,
+				RequireStatusChecks: []StatusCheck{
+					{"mycheck", nil}, {"allstar/reviewbot", nil},
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -296,6 +350,57 @@ func TestGetSignatureProtectionEnabled(t *testing.T) {
 	}
 }
 
+func TestExpandBranches(t *testing.T) {
+	branches := []*github.Branch{
+		{Name: github.String("main")},
+		{Name: github.String("release/1.0")},
+		{Name: github.String("release/2.0")},
+		{Name: github.String("release/2.0/hotfix")},
+		{Name: github.String("v1-maintenance")},
+		{Name: github.String("v2-maintenance")},
+	}
+	tests := []struct {
+		Name    string
+		Enforce []string
+		Exp     []string
+	}{
+		{
+			Name:    "Names",
+			Enforce: []string{"main", "missing"},
+			Exp:     []string{"main", "missing"},
+		},
+		{
+			Name:    "Slash",
+			Enforce: []string{"release/*"},
+			Exp:     []string{"release/1.0", "release/2.0"},
+		},
+		{
+			Name:    "Suffix",
+			Enforce: []string{"v*-maintenance"},
+			Exp:     []string{"v1-maintenance", "v2-maintenance"},
+		},
+		{
+			Name:    "NoMatch",
+			Enforce: []string{"stable-*"},
+			Exp:     nil,
+		},
+		{
+			Name:    "Duplicates",
+			Enforce: []string{"release/1.0", "release/*", "release/?.0"},
+			Exp:     []string{"release/1.0", "release/2.0"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got := expandBranches(test.Enforce, branches)
+			if diff := cmp.Diff(test.Exp, got); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestCheck(t *testing.T) {
 	tests := []struct {
 		Name          string
@@ -1254,6 +1359,243 @@ func TestCheck(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "CatchDismissalAndBypass",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					OptOutStrategy: true,
+				},
+				EnforceDefault:     true,
+				RequireApproval:    true,
+				ApprovalCount:      1,
+				RestrictDismissals: true,
+				DismissalActors:    Actors{Teams: []string{"maintainers"}},
+				RestrictBypass:     true,
+				BypassActors:       Actors{Apps: []string{"release-bot"}},
+			},
+			Repo: RepoConfig{},
+			Prot: map[string]github.Protection{
+				"main": github.Protection{
+					RequiredPullRequestReviews: &github.PullRequestReviewsEnforcement{
+						RequiredApprovingReviewCount: 1,
+						DismissalRestrictions: &github.DismissalRestrictions{
+							Users: []*github.User{{Login: github.String("alice")}},
+							Teams: []*github.Team{{Slug: github.String("Maintainers")}},
+						},
+						BypassPullRequestAllowances: &github.BypassPullRequestAllowances{
+							Users: []*github.User{{Login: github.String("bob")}},
+							Apps:  []*github.App{{Slug: github.String("release-bot")}},
+						},
+					},
+				},
+			},
+			SigProtection: map[string]github.SignaturesProtectedBranch{
+				"main": github.SignaturesProtectedBranch{
+					Enabled: github.Bool(false),
+				},
+			},
+			cofigEnabled: true,
+			Exp: policydef.Result{
+				Enabled: true,
+				Pass:    false,
+				NotifyText: "Review dismissal allowed for user alice, not allowed by policy, for branch main\n" +
+					"PR requirements bypass allowed for user bob, not allowed by policy, for branch main\n",
+				Details: map[string]details{
+					"main": details{
+						PRReviews:           true,
+						NumReviews:          1,
+						BlockForce:          true,
+						DismissalRestricted: true,
+						DismissalActors: Actors{
+							Users: []string{"alice"},
+							Teams: []string{"Maintainers"},
+						},
+						BypassActors: Actors{
+							Users: []string{"bob"},
+							Apps:  []string{"release-bot"},
+						},
+					},
+				},
+			},
+		},
+		{
+			Name: "CatchDismissalNotRestricted",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					OptOutStrategy: true,
+				},
+				EnforceDefault:     true,
+				RequireApproval:    true,
+				ApprovalCount:      1,
+				RestrictDismissals: true,
+				RestrictBypass:     true,
+			},
+			Repo: RepoConfig{},
+			Prot: map[string]github.Protection{
+				"main": github.Protection{
+					RequiredPullRequestReviews: &github.PullRequestReviewsEnforcement{
+						RequiredApprovingReviewCount: 1,
+					},
+				},
+			},
+			SigProtection: map[string]github.SignaturesProtectedBranch{
+				"main": github.SignaturesProtectedBranch{
+					Enabled: github.Bool(false),
+				},
+			},
+			cofigEnabled: true,
+			Exp: policydef.Result{
+				Enabled:    true,
+				Pass:       false,
+				NotifyText: "Review dismissal restrictions not configured for branch main\n",
+				Details: map[string]details{
+					"main": details{
+						PRReviews:  true,
+						NumReviews: 1,
+						BlockForce: true,
+					},
+				},
+			},
+		},
+		{
+			Name: "CatchLinearHistoryConversationDeletions",
+			Org: OrgConfig{
+				EnforceDefault:                true,
+				RequireLinearHistory:          true,
+				RequireConversationResolution: true,
+				BlockDeletions:                true,
+			},
+			Repo: RepoConfig{},
+			Prot: map[string]github.Protection{
+				"main": github.Protection{
+					RequireLinearHistory: &github.RequireLinearHistory{
+						Enabled: true,
+					},
+					AllowDeletions: &github.AllowDeletions{
+						Enabled: true,
+					},
+				},
+			},
+			SigProtection: map[string]github.SignaturesProtectedBranch{
+				"main": github.SignaturesProtectedBranch{
+					Enabled: github.Bool(false),
+				},
+			},
+			cofigEnabled: true,
+			Exp: policydef.Result{
+				Enabled: true,
+				Pass:    false,
+				NotifyText: "Require conversation resolution not configured for branch main\n" +
+					"Block deletions not configured for branch main\n",
+				Details: map[string]details{
+					"main": details{
+						BlockForce:           true,
+						RequireLinearHistory: true,
+						AllowDeletions:       true,
+					},
+				},
+			},
+		},
+		{
+			Name: "ReportBypassAndPushActors",
+			Org: OrgConfig{
+				EnforceDefault:  true,
+				RequireApproval: true,
+				ApprovalCount:   1,
+			},
+			Repo: RepoConfig{},
+			Prot: map[string]github.Protection{
+				"main": github.Protection{
+					RequiredPullRequestReviews: &github.PullRequestReviewsEnforcement{
+						RequiredApprovingReviewCount: 1,
+						BypassPullRequestAllowances: &github.BypassPullRequestAllowances{
+							Users: []*github.User{{Login: github.String("alice")}},
+						},
+					},
+					Restrictions: &github.BranchRestrictions{
+						Teams: []*github.Team{{Slug: github.String("maintainers")}},
+						Apps:  []*github.App{{Slug: github.String("release-bot")}},
+					},
+				},
+			},
+			SigProtection: map[string]github.SignaturesProtectedBranch{
+				"main": github.SignaturesProtectedBranch{
+					Enabled: github.Bool(false),
+				},
+			},
+			cofigEnabled: true,
+			Exp: policydef.Result{
+				Enabled:    true,
+				Pass:       true,
+				NotifyText: "",
+				Details: map[string]details{
+					"main": details{
+						PRReviews:      true,
+						NumReviews:     1,
+						BlockForce:     true,
+						BypassActors:   Actors{Users: []string{"alice"}},
+						PushRestricted: true,
+						PushActors: Actors{
+							Teams: []string{"maintainers"},
+							Apps:  []string{"release-bot"},
+						},
+					},
+				},
+			},
+		},
+		{
+			Name: "PreventDowngradeReportsStricter",
+			Org: OrgConfig{
+				EnforceDefault:   true,
+				RequireApproval:  true,
+				ApprovalCount:    2,
+				PreventDowngrade: true,
+				ManagedContext:   "terraform",
+			},
+			Repo: RepoConfig{},
+			Prot: map[string]github.Protection{
+				"main": github.Protection{
+					RequiredPullRequestReviews: &github.PullRequestReviewsEnforcement{
+						RequiredApprovingReviewCount: 3,
+						DismissStaleReviews:          true,
+					},
+					EnforceAdmins: &github.AdminEnforcement{
+						Enabled: true,
+					},
+					RequiredStatusChecks: &github.RequiredStatusChecks{
+						Checks: []*github.RequiredStatusCheck{
+							{Context: "terraform"},
+						},
+					},
+				},
+			},
+			SigProtection: map[string]github.SignaturesProtectedBranch{
+				"main": github.SignaturesProtectedBranch{
+					Enabled: github.Bool(false),
+				},
+			},
+			cofigEnabled: true,
+			Exp: policydef.Result{
+				Enabled:    true,
+				Pass:       true,
+				NotifyText: "",
+				Details: map[string]details{
+					"main": details{
+						PRReviews:       true,
+						NumReviews:      3,
+						DismissStale:    true,
+						BlockForce:      true,
+						EnforceOnAdmins: true,
+						Managed:         true,
+						StricterThanPolicy: []string{
+							"PR approvals 3, policy requires 2",
+							"Stale reviews dismissed",
+							"Enforced on admins",
+						},
+					},
+				},
+			},
+		},
 	}
 
 	get = func(context.Context, string, string) (*github.Repository,
@@ -1386,6 +1728,66 @@ func TestFix(t *testing.T) {
 			},
 			ExpSignatureRequests: map[string]bool{},
 		},
+		{
+			Name: "KeepUnmodeled",
+			Org: OrgConfig{
+				EnforceDefault:  true,
+				RequireApproval: true,
+				ApprovalCount:   2,
+				BlockForce:      true,
+			},
+			Repo: RepoConfig{},
+			Prot: map[string]github.Protection{
+				"main": github.Protection{
+					AllowForcePushes: &github.AllowForcePushes{
+						Enabled: false,
+					},
+					EnforceAdmins: &github.AdminEnforcement{
+						Enabled: false,
+					},
+					RequiredPullRequestReviews: &github.PullRequestReviewsEnforcement{
+						RequiredApprovingReviewCount: 1,
+						RequireLastPushApproval:      true,
+					},
+					RequireLinearHistory: &github.RequireLinearHistory{
+						Enabled: true,
+					},
+					AllowDeletions: &github.AllowDeletions{
+						Enabled: false,
+					},
+					RequiredConversationResolution: &github.RequiredConversationResolution{
+						Enabled: true,
+					},
+					LockBranch: &github.LockBranch{
+						Enabled: github.Bool(true),
+					},
+					AllowForkSyncing: &github.AllowForkSyncing{
+						Enabled: github.Bool(true),
+					},
+				},
+			},
+			cofigEnabled: true,
+			Exp: map[string]github.ProtectionRequest{
+				"main": github.ProtectionRequest{
+					AllowForcePushes: github.Bool(false),
+					RequiredPullRequestReviews: &github.PullRequestReviewsEnforcementRequest{
+						RequiredApprovingReviewCount: 2,
+						RequireLastPushApproval:      github.Bool(true),
+					},
+					RequireLinearHistory:           github.Bool(true),
+					AllowDeletions:                 github.Bool(false),
+					RequiredConversationResolution: github.Bool(true),
+					LockBranch:                     github.Bool(true),
+					AllowForkSyncing:               github.Bool(true),
+				},
+			},
+			SignatureProt: map[string]github.SignaturesProtectedBranch{
+				"main": github.SignaturesProtectedBranch{
+					Enabled: github.Bool(false),
+				},
+			},
+			ExpSignatureRequests: map[string]bool{},
+		},
 		{
 			Name: "AddProtection",
 			Org: OrgConfig{
@@ -2017,6 +2419,271 @@ func TestFix(t *testing.T) {
 				"main": true,
 			},
 		},
+		{
+			Name: "KeepAllowances",
+			Org: OrgConfig{
+				EnforceDefault:  true,
+				RequireApproval: true,
+				ApprovalCount:   1,
+				BlockForce:      true,
+			},
+			Repo: RepoConfig{},
+			Prot: map[string]github.Protection{
+				"main": github.Protection{
+					AllowForcePushes: &github.AllowForcePushes{
+						Enabled: true,
+					},
+					EnforceAdmins: &github.AdminEnforcement{
+						Enabled: false,
+					},
+					RequiredPullRequestReviews: &github.PullRequestReviewsEnforcement{
+						RequiredApprovingReviewCount: 1,
+						DismissalRestrictions: &github.DismissalRestrictions{
+							Teams: []*github.Team{{Slug: github.String("maintainers")}},
+						},
+						BypassPullRequestAllowances: &github.BypassPullRequestAllowances{
+							Users: []*github.User{{Login: github.String("bob")}},
+						},
+					},
+				},
+			},
+			cofigEnabled: true,
+			Exp: map[string]github.ProtectionRequest{
+				"main": github.ProtectionRequest{
+					AllowForcePushes: github.Bool(false),
+					RequiredPullRequestReviews: &github.PullRequestReviewsEnforcementRequest{
+						RequiredApprovingReviewCount: 1,
+						DismissalRestrictionsRequest: &github.DismissalRestrictionsRequest{
+							Users: &[]string{},
+							Teams: &[]string{"maintainers"},
+							Apps:  &[]string{},
+						},
+						BypassPullRequestAllowancesRequest: &github.BypassPullRequestAllowancesRequest{
+							Users: []string{"bob"},
+							Teams: []string{},
+							Apps:  []string{},
+						},
+					},
+				},
+			},
+			SignatureProt:        map[string]github.SignaturesProtectedBranch{},
+			ExpSignatureRequests: map[string]bool{},
+		},
+		{
+			Name: "RestrictDismissalAndBypass",
+			Org: OrgConfig{
+				EnforceDefault:     true,
+				RequireApproval:    true,
+				ApprovalCount:      1,
+				RestrictDismissals: true,
+				DismissalActors:    Actors{Teams: []string{"maintainers"}},
+				RestrictBypass:     true,
+				BypassActors:       Actors{Apps: []string{"release-bot"}},
+			},
+			Repo: RepoConfig{},
+			Prot: map[string]github.Protection{
+				"main": github.Protection{
+					AllowForcePushes: &github.AllowForcePushes{
+						Enabled: false,
+					},
+					EnforceAdmins: &github.AdminEnforcement{
+						Enabled: false,
+					},
+					RequiredPullRequestReviews: &github.PullRequestReviewsEnforcement{
+						RequiredApprovingReviewCount: 1,
+						BypassPullRequestAllowances: &github.BypassPullRequestAllowances{
+							Users: []*github.User{{Login: github.String("bob")}},
+							Apps:  []*github.App{{Slug: github.String("release-bot")}},
+						},
+					},
+				},
+			},
+			cofigEnabled: true,
+			Exp: map[string]github.ProtectionRequest{
+				"main": github.ProtectionRequest{
+					AllowForcePushes: github.Bool(false),
+					RequiredPullRequestReviews: &github.PullRequestReviewsEnforcementRequest{
+						RequiredApprovingReviewCount: 1,
+						DismissalRestrictionsRequest: &github.DismissalRestrictionsRequest{
+							Users: &[]string{},
+							Teams: &[]string{"maintainers"},
+							Apps:  &[]string{},
+						},
+						BypassPullRequestAllowancesRequest: &github.BypassPullRequestAllowancesRequest{
+							Users: []string{},
+							Teams: []string{},
+							Apps:  []string{"release-bot"},
+						},
+					},
+				},
+			},
+			SignatureProt:        map[string]github.SignaturesProtectedBranch{},
+			ExpSignatureRequests: map[string]bool{},
+		},
+		{
+			Name: "AllowedDismissalNoChange",
+			Org: OrgConfig{
+				EnforceDefault:     true,
+				RequireApproval:    true,
+				ApprovalCount:      1,
+				RestrictDismissals: true,
+				DismissalActors:    Actors{Teams: []string{"maintainers"}, Users: []string{"alice"}},
+			},
+			Repo: RepoConfig{},
+			Prot: map[string]github.Protection{
+				"main": github.Protection{
+					AllowForcePushes: &github.AllowForcePushes{
+						Enabled: false,
+					},
+					EnforceAdmins: &github.AdminEnforcement{
+						Enabled: false,
+					},
+					RequiredPullRequestReviews: &github.PullRequestReviewsEnforcement{
+						RequiredApprovingReviewCount: 1,
+						DismissalRestrictions: &github.DismissalRestrictions{
+							Teams: []*github.Team{{Slug: github.String("maintainers")}},
+						},
+					},
+				},
+			},
+			cofigEnabled:         true,
+			Exp:                  map[string]github.ProtectionRequest{},
+			SignatureProt:        map[string]github.SignaturesProtectedBranch{},
+			ExpSignatureRequests: map[string]bool{},
+		},
+		{
+			Name: "CreateLinearHistoryConversationDeletions",
+			Org: OrgConfig{
+				EnforceDefault:                true,
+				BlockForce:                    true,
+				RequireLinearHistory:          true,
+				RequireConversationResolution: true,
+				BlockDeletions:                true,
+			},
+			Repo:                 RepoConfig{},
+			Prot:                 map[string]github.Protection{},
+			cofigEnabled:         true,
+			SignatureProt:        map[string]github.SignaturesProtectedBranch{},
+			ExpSignatureRequests: map[string]bool{},
+			Exp: map[string]github.ProtectionRequest{
+				"main": github.ProtectionRequest{
+					AllowForcePushes:               github.Bool(false),
+					RequireLinearHistory:           github.Bool(true),
+					RequiredConversationResolution: github.Bool(true),
+					AllowDeletions:                 github.Bool(false),
+				},
+			},
+		},
+		{
+			Name: "UpdateLinearHistoryConversationDeletions",
+			Org: OrgConfig{
+				EnforceDefault:                true,
+				RequireLinearHistory:          true,
+				RequireConversationResolution: true,
+				BlockDeletions:                true,
+			},
+			Repo: RepoConfig{},
+			Prot: map[string]github.Protection{
+				"main": github.Protection{
+					AllowForcePushes: &github.AllowForcePushes{
+						Enabled: false,
+					},
+					EnforceAdmins: &github.AdminEnforcement{
+						Enabled: false,
+					},
+					RequireLinearHistory: &github.RequireLinearHistory{
+						Enabled: false,
+					},
+					AllowDeletions: &github.AllowDeletions{
+						Enabled: true,
+					},
+				},
+			},
+			cofigEnabled:         true,
+			SignatureProt:        map[string]github.SignaturesProtectedBranch{},
+			ExpSignatureRequests: map[string]bool{},
+			Exp: map[string]github.ProtectionRequest{
+				"main": github.ProtectionRequest{
+					AllowForcePushes:               github.Bool(false),
+					RequireLinearHistory:           github.Bool(true),
+					RequiredConversationResolution: github.Bool(true),
+					AllowDeletions:                 github.Bool(false),
+				},
+			},
+		},
+		{
+			Name: "SkipManaged",
+			Org: OrgConfig{
+				EnforceDefault:  true,
+				RequireApproval: true,
+				ApprovalCount:   2,
+				BlockForce:      true,
+				ManagedContext:  "terraform",
+			},
+			Repo: RepoConfig{},
+			Prot: map[string]github.Protection{
+				"main": github.Protection{
+					AllowForcePushes: &github.AllowForcePushes{
+						Enabled: true,
+					},
+					EnforceAdmins: &github.AdminEnforcement{
+						Enabled: false,
+					},
+					RequiredStatusChecks: &github.RequiredStatusChecks{
+						Contexts: []string{"terraform"},
+					},
+				},
+			},
+			cofigEnabled:         true,
+			Exp:                  map[string]github.ProtectionRequest{},
+			SignatureProt:        map[string]github.SignaturesProtectedBranch{},
+			ExpSignatureRequests: map[string]bool{},
+		},
+		{
+			Name: "PreventDowngradeDismissal",
+			Org: OrgConfig{
+				EnforceDefault:     true,
+				RequireApproval:    true,
+				ApprovalCount:      1,
+				RestrictDismissals: true,
+				DismissalActors:    Actors{Teams: []string{"maintainers"}, Users: []string{"alice"}},
+				PreventDowngrade:   true,
+			},
+			Repo: RepoConfig{},
+			Prot: map[string]github.Protection{
+				"main": github.Protection{
+					AllowForcePushes: &github.AllowForcePushes{
+						Enabled: false,
+					},
+					EnforceAdmins: &github.AdminEnforcement{
+						Enabled: false,
+					},
+					RequiredPullRequestReviews: &github.PullRequestReviewsEnforcement{
+						RequiredApprovingReviewCount: 3,
+						DismissalRestrictions: &github.DismissalRestrictions{
+							Users: []*github.User{{Login: github.String("bob")}},
+							Teams: []*github.Team{{Slug: github.String("maintainers")}},
+						},
+					},
+				},
+			},
+			cofigEnabled: true,
+			Exp: map[string]github.ProtectionRequest{
+				"main": github.ProtectionRequest{
+					AllowForcePushes: github.Bool(false),
+					RequiredPullRequestReviews: &github.PullRequestReviewsEnforcementRequest{
+						RequiredApprovingReviewCount: 3,
+						DismissalRestrictionsRequest: &github.DismissalRestrictionsRequest{
+							Users: &[]string{},
+							Teams: &[]string{"maintainers"},
+							Apps:  &[]string{},
+						},
+					},
+				},
+			},
+			SignatureProt:        map[string]github.SignaturesProtectedBranch{},
+			ExpSignatureRequests: map[string]bool{},
+		},
 	}
 	get = func(context.Context, string, string) (*github.Repository,
 		*github.Response, error) {
@@ -2032,14 +2699,19 @@ func TestFix(t *testing.T) {
 		}, &github.Response{NextPage: 0}, nil
 	}
 
+	branchUpdateInterval = 0
+
 	for _, test := range tests {
 		t.Run(test.Name, func(t *testing.T) {
+			var mu sync.Mutex
 			got := make(map[string]github.ProtectionRequest)
 			requireSignatureRequests := make(map[string]bool)
 
 			updateBranchProtection = func(ctx context.Context, owner, repo,
 				branch string, preq *github.ProtectionRequest) (*github.Protection,
 				*github.Response, error) {
+				mu.Lock()
+				defer mu.Unlock()
 				got[branch] = *preq
 				return nil, nil, nil
 			}
@@ -2082,6 +2754,8 @@ func TestFix(t *testing.T) {
 			}
 			requireSignaturesProtectedBranch = func(ctx context.Context, owner, repo, branch string) (
 				*github.SignaturesProtectedBranch, *github.Response, error) {
+				mu.Lock()
+				defer mu.Unlock()
 				requireSignatureRequests[branch] = true
 				return nil, nil, nil
 			}
@@ -2124,3 +2798,241 @@ func TestFix(t *testing.T) {
 	}
 
 }
+
+func TestFixBranchNotFound(t *testing.T) {
+	notFound := &github.Response{
+		Response: &http.Response{
+			StatusCode: http.StatusNotFound,
+		},
+	}
+	get = func(context.Context, string, string) (*github.Repository,
+		*github.Response, error) {
+		b := "main"
+		return &github.Repository{
+			DefaultBranch: &b,
+		}, nil, nil
+	}
+	configFetchConfig = func(ctx context.Context, c *github.Client,
+		owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+		if ol == config.OrgLevel {
+			oc := out.(*OrgConfig)
+			*oc = OrgConfig{
+				EnforceDefault:       true,
+				EnforceBranches:      map[string][]string{"thisrepo": {"gone", "deleted"}},
+				BlockForce:           true,
+				RequireSignedCommits: true,
+			}
+		}
+		return nil
+	}
+	configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+		c *github.Client, owner, repo string) (bool, error) {
+		return true, nil
+	}
+	getBranchProtection = func(ctx context.Context, o string, r string,
+		b string) (*github.Protection, *github.Response, error) {
+		if b == "deleted" || b == "main" {
+			return &github.Protection{
+				AllowForcePushes: &github.AllowForcePushes{Enabled: true},
+				EnforceAdmins:    &github.AdminEnforcement{Enabled: false},
+			}, nil, nil
+		}
+		return nil, notFound, errors.New("404")
+	}
+	getSignaturesProtectedBranch = func(ctx context.Context, o string, r string,
+		b string) (*github.SignaturesProtectedBranch, *github.Response, error) {
+		return nil, notFound, errors.New("404")
+	}
+	branchUpdateInterval = 0
+	var mu sync.Mutex
+	var updated []string
+	updateBranchProtection = func(ctx context.Context, owner, repo,
+		branch string, preq *github.ProtectionRequest) (*github.Protection,
+		*github.Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		updated = append(updated, branch)
+		if branch == "main" {
+			return nil, nil, nil
+		}
+		return nil, notFound, errors.New("404")
+	}
+	var signed []string
+	requireSignaturesProtectedBranch = func(ctx context.Context, owner, repo, branch string) (
+		*github.SignaturesProtectedBranch, *github.Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		signed = append(signed, branch)
+		return nil, nil, nil
+	}
+
+	if err := fix(context.Background(), mockRepos{}, nil, "", "thisrepo"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sort.Strings(updated)
+	if diff := cmp.Diff([]string{"deleted", "gone", "main"}, updated); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"main"}, signed); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+}
+
+func TestFixSelectedBranches(t *testing.T) {
+	get = func(context.Context, string, string) (*github.Repository,
+		*github.Response, error) {
+		b := "main"
+		return &github.Repository{
+			DefaultBranch: &b,
+			Topics:        []string{"released"},
+		}, nil, nil
+	}
+	configFetchConfig = func(ctx context.Context, c *github.Client,
+		owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+		if ol == config.OrgLevel {
+			oc := out.(*OrgConfig)
+			*oc = OrgConfig{
+				EnforceDefault:  true,
+				EnforceBranches: map[string][]string{"thisrepo": {"stable"}},
+				EnforceSelectedBranches: []*SelectedBranches{
+					{
+						Repos:    []*selector.RepoSelector{{Topics: []string{"released"}}},
+						Branches: []string{"stable", "release"},
+					},
+					{
+						Repos:    []*selector.RepoSelector{{Name: "other*"}},
+						Branches: []string{"other"},
+					},
+				},
+				BlockForce: true,
+			}
+		}
+		return nil
+	}
+	configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+		c *github.Client, owner, repo string) (bool, error) {
+		return true, nil
+	}
+	getBranchProtection = func(ctx context.Context, o string, r string,
+		b string) (*github.Protection, *github.Response, error) {
+		return &github.Protection{
+			AllowForcePushes: &github.AllowForcePushes{Enabled: true},
+			EnforceAdmins:    &github.AdminEnforcement{Enabled: false},
+		}, nil, nil
+	}
+	branchUpdateInterval = 0
+	var mu sync.Mutex
+	var updated []string
+	updateBranchProtection = func(ctx context.Context, owner, repo,
+		branch string, preq *github.ProtectionRequest) (*github.Protection,
+		*github.Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		updated = append(updated, branch)
+		return nil, nil, nil
+	}
+
+	if err := fix(context.Background(), mockRepos{}, nil, "", "thisrepo"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sort.Strings(updated)
+	if diff := cmp.Diff([]string{"main", "release", "stable"}, updated); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+}
+
+func TestFixBatched(t *testing.T) {
+	branches := []string{"a", "b", "c", "d", "e", "f", "ok", "main"}
+	get = func(context.Context, string, string) (*github.Repository,
+		*github.Response, error) {
+		b := "main"
+		return &github.Repository{
+			DefaultBranch: &b,
+		}, nil, nil
+	}
+	configFetchConfig = func(ctx context.Context, c *github.Client,
+		owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+		if ol == config.OrgLevel {
+			oc := out.(*OrgConfig)
+			*oc = OrgConfig{
+				EnforceDefault: true,
+				// "main" is also the default branch, and only updated once.
+				EnforceBranches: map[string][]string{"thisrepo": branches},
+				BlockForce:      true,
+			}
+		}
+		return nil
+	}
+	configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+		c *github.Client, owner, repo string) (bool, error) {
+		return true, nil
+	}
+	getBranchProtection = func(ctx context.Context, o string, r string,
+		b string) (*github.Protection, *github.Response, error) {
+		return &github.Protection{
+			AllowForcePushes: &github.AllowForcePushes{Enabled: b != "ok"},
+			EnforceAdmins:    &github.AdminEnforcement{Enabled: false},
+		}, nil, nil
+	}
+	getSignaturesProtectedBranch = func(ctx context.Context, o string, r string,
+		b string) (*github.SignaturesProtectedBranch, *github.Response, error) {
+		return &github.SignaturesProtectedBranch{Enabled: github.Bool(false)}, nil, nil
+	}
+	branchUpdateInterval = 5 * time.Millisecond
+	var mu sync.Mutex
+	var updated []string
+	var times []time.Time
+	running, maxRunning := 0, 0
+	updateBranchProtection = func(ctx context.Context, owner, repo,
+		branch string, preq *github.ProtectionRequest) (*github.Protection,
+		*github.Response, error) {
+		mu.Lock()
+		updated = append(updated, branch)
+		times = append(times, time.Now())
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil, nil, nil
+	}
+
+	if err := fix(context.Background(), mockRepos{}, nil, "", "thisrepo"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sort.Strings(updated)
+	if diff := cmp.Diff([]string{"a", "b", "c", "d", "e", "f", "main"}, updated); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+	if maxRunning > maxBranchUpdates {
+		t.Errorf("Unexpected concurrent updates, want at most %v got %v", maxBranchUpdates, maxRunning)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	for i := 1; i < len(times); i++ {
+		// Allow for timer granularity.
+		if d := times[i].Sub(times[i-1]); d < branchUpdateInterval/2 {
+			t.Errorf("Unexpected update interval %v", d)
+		}
+	}
+}
+
+func TestGeneratedPrecedence(t *testing.T) {
+	configtest.TestPrecedence(t, configtest.Precedence{
+		Org:   OrgConfig{},
+		Repo:  RepoConfig{},
+		Fetch: &configFetchConfig,
+		Merge: func() interface{} {
+			ctx := context.Background()
+			oc, orc, rc := getConfig(ctx, nil, "", configtest.Repo)
+			return mergeConfig(oc, orc, rc, configtest.Repo)
+		},
+		// EnforceBranches is keyed by repo at the org level, and ApprovalCount
+		// only applies with RequireApproval, both are covered by
+		// TestConfigPrecedence.
+		Ignore: []string{"EnforceBranches", "ApprovalCount"},
+	})
+}
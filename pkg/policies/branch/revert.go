@@ -0,0 +1,98 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package branch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/ossf/allstar/pkg/revert"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+// priorProtection is the protection of a branch before a Fix action changed
+// it, recorded so that the change can be reverted, see the revert package.
+type priorProtection struct {
+	// Protection is the protection of the branch, nil if it had none.
+	Protection *github.ProtectionRequest `json:"protection,omitempty"`
+	// Updated is set if the protection was updated, or created.
+	Updated bool `json:"updated,omitempty"`
+	// SignaturesRequired is set if required signatures were enabled.
+	SignaturesRequired bool `json:"signaturesRequired,omitempty"`
+}
+
+// recordPrior records the protection of the branch before bf is applied.
+// Errors are logged, and the fix still applied.
+func recordPrior(ctx context.Context, owner, repo string, bf *branchFix) {
+	prior := priorProtection{
+		Protection:         bf.prior,
+		Updated:            bf.pr != nil,
+		SignaturesRequired: bf.requireSignatures,
+	}
+	if err := revert.Record(ctx, owner, repo, polName, bf.branch, prior); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", polName).
+			Str("branch", bf.branch).
+			Err(err).
+			Msg("Unexpected error recording protection before fix, it can not be reverted.")
+	}
+}
+
+type protectionReverter interface {
+	UpdateBranchProtection(context.Context, string, string, string,
+		*github.ProtectionRequest) (*github.Protection, *github.Response, error)
+	RemoveBranchProtection(ctx context.Context, owner, repo, branch string) (
+		*github.Response, error)
+	OptionalSignaturesOnProtectedBranch(ctx context.Context, owner, repo, branch string) (
+		*github.Response, error)
+}
+
+// revertFix restores the protection of a branch recorded by recordPrior,
+// implementing revert.Reverter.
+func revertFix(ctx context.Context, c *github.Client, s revert.Snapshot) error {
+	return revertProtection(ctx, c.Repositories, s)
+}
+
+func revertProtection(ctx context.Context, rep protectionReverter, s revert.Snapshot) error {
+	var prior priorProtection
+	if err := json.Unmarshal(s.Prior, &prior); err != nil {
+		return err
+	}
+	if prior.Updated && prior.Protection == nil {
+		// Protection was created, removing it also removes required
+		// signatures.
+		rsp, err := rep.RemoveBranchProtection(ctx, s.Org, s.Repo, s.Target)
+		if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return err
+	}
+	if prior.Updated {
+		if _, _, err := rep.UpdateBranchProtection(ctx, s.Org, s.Repo, s.Target, prior.Protection); err != nil {
+			return err
+		}
+	}
+	if prior.SignaturesRequired {
+		if _, err := rep.OptionalSignaturesOnProtectedBranch(ctx, s.Org, s.Repo, s.Target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package branch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/revert"
+	"github.com/ossf/allstar/pkg/state"
+)
+
+func TestFixRecordsPrior(t *testing.T) {
+	revert.SetState(state.NewMemory())
+	defer revert.SetState(nil)
+	notFound := &github.Response{
+		Response: &http.Response{
+			StatusCode: http.StatusNotFound,
+		},
+	}
+	get = func(context.Context, string, string) (*github.Repository,
+		*github.Response, error) {
+		return &github.Repository{
+			DefaultBranch: github.String("main"),
+		}, nil, nil
+	}
+	configFetchConfig = func(ctx context.Context, c *github.Client,
+		owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+		if ol == config.OrgLevel {
+			oc := out.(*OrgConfig)
+			*oc = OrgConfig{
+				EnforceDefault:       true,
+				EnforceBranches:      map[string][]string{"thisrepo": {"dev"}},
+				BlockForce:           true,
+				RequireSignedCommits: true,
+			}
+		}
+		return nil
+	}
+	configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+		c *github.Client, owner, repo string) (bool, error) {
+		return true, nil
+	}
+	getBranchProtection = func(ctx context.Context, o, r, b string) (
+		*github.Protection, *github.Response, error) {
+		if b == "main" {
+			return &github.Protection{
+				AllowForcePushes: &github.AllowForcePushes{Enabled: true},
+				EnforceAdmins:    &github.AdminEnforcement{Enabled: true},
+				RequiredStatusChecks: &github.RequiredStatusChecks{
+					Strict:   true,
+					Contexts: []string{"build"},
+					Checks:   []*github.RequiredStatusCheck{{Context: "build"}},
+				},
+			}, nil, nil
+		}
+		return nil, notFound, errors.New("404")
+	}
+	getSignaturesProtectedBranch = func(ctx context.Context, o, r, b string) (
+		*github.SignaturesProtectedBranch, *github.Response, error) {
+		return nil, notFound, errors.New("404")
+	}
+	updateBranchProtection = func(ctx context.Context, owner, repo,
+		branch string, preq *github.ProtectionRequest) (*github.Protection,
+		*github.Response, error) {
+		return nil, nil, nil
+	}
+	requireSignaturesProtectedBranch = func(ctx context.Context, owner, repo, branch string) (
+		*github.SignaturesProtectedBranch, *github.Response, error) {
+		return nil, nil, nil
+	}
+	if err := fix(context.Background(), mockRepos{}, nil, "acme", "thisrepo"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	snaps, err := revert.List(context.Background(), "acme", "thisrepo", polName, time.Time{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got := make(map[string]priorProtection)
+	for _, s := range snaps {
+		var p priorProtection
+		if err := json.Unmarshal(s.Prior, &p); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		got[s.Target] = p
+	}
+	want := map[string]priorProtection{
+		"main": {
+			Protection: &github.ProtectionRequest{
+				EnforceAdmins:    true,
+				AllowForcePushes: github.Bool(true),
+				RequiredStatusChecks: &github.RequiredStatusChecks{
+					Strict: true,
+					Checks: []*github.RequiredStatusCheck{{Context: "build"}},
+				},
+			},
+			Updated:            true,
+			SignaturesRequired: true,
+		},
+		"dev": {
+			Updated: true,
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+}
+
+type mockReverter struct {
+	calls    []string
+	updated  *github.ProtectionRequest
+	notFound bool
+}
+
+func (m *mockReverter) UpdateBranchProtection(ctx context.Context, owner, repo, branch string,
+	preq *github.ProtectionRequest) (*github.Protection, *github.Response, error) {
+	m.calls = append(m.calls, "update "+branch)
+	m.updated = preq
+	return nil, nil, nil
+}
+
+func (m *mockReverter) RemoveBranchProtection(ctx context.Context, owner, repo, branch string) (
+	*github.Response, error) {
+	m.calls = append(m.calls, "remove "+branch)
+	if m.notFound {
+		return &github.Response{Response: &http.Response{StatusCode: http.StatusNotFound}}, errors.New("404")
+	}
+	return nil, nil
+}
+
+func (m *mockReverter) OptionalSignaturesOnProtectedBranch(ctx context.Context, owner, repo, branch string) (
+	*github.Response, error) {
+	m.calls = append(m.calls, "optional signatures "+branch)
+	return nil, nil
+}
+
+func TestRevertProtection(t *testing.T) {
+	prior := &github.ProtectionRequest{
+		EnforceAdmins:    true,
+		AllowForcePushes: github.Bool(true),
+	}
+	tests := []struct {
+		Name       string
+		Prior      priorProtection
+		NotFound   bool
+		ExpCalls   []string
+		ExpUpdated *github.ProtectionRequest
+	}{
+		{
+			Name:     "Created",
+			Prior:    priorProtection{Updated: true, SignaturesRequired: true},
+			ExpCalls: []string{"remove main"},
+		},
+		{
+			Name:     "CreatedAlreadyRemoved",
+			Prior:    priorProtection{Updated: true},
+			NotFound: true,
+			ExpCalls: []string{"remove main"},
+		},
+		{
+			Name:       "Updated",
+			Prior:      priorProtection{Protection: prior, Updated: true},
+			ExpCalls:   []string{"update main"},
+			ExpUpdated: prior,
+		},
+		{
+			Name:       "UpdatedAndSigned",
+			Prior:      priorProtection{Protection: prior, Updated: true, SignaturesRequired: true},
+			ExpCalls:   []string{"update main", "optional signatures main"},
+			ExpUpdated: prior,
+		},
+		{
+			Name:     "Signed",
+			Prior:    priorProtection{Protection: prior, SignaturesRequired: true},
+			ExpCalls: []string{"optional signatures main"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			b, err := json.Marshal(test.Prior)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			m := &mockReverter{notFound: test.NotFound}
+			s := revert.Snapshot{Org: "acme", Repo: "thisrepo", Policy: polName, Target: "main", Prior: b}
+			if err := revertProtection(context.Background(), m, s); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.ExpCalls, m.calls); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.ExpUpdated, m.updated); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,544 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cachepoisoning implements the GitHub Actions Cache Poisoning
+// policy, which flags privileged workflows restoring caches that untrusted
+// workflows can write.
+package cachepoisoning
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/policies/workflowfiles"
+	"github.com/ossf/allstar/pkg/policydef"
+	"github.com/rhysd/actionlint"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+const configFile = "cache_poisoning.yaml"
+const polName = "Cache Poisoning"
+
+const notifyText = `Workflows in this repository restore GitHub Actions caches in privileged contexts using cache keys that are also written by workflows run for pull requests. Code from a pull request can save a poisoned cache entry under a shared key, which a privileged workflow, with access to secrets or a write token, then restores and runs.
+
+%v
+To fix this, use distinct cache keys in privileged workflows, for example by including the triggering event or ref in the key, or don't restore caches in privileged workflows such as release builds.
+
+For more information, see https://docs.github.com/en/actions/using-workflows/caching-dependencies-to-speed-up-workflows#restrictions-for-accessing-a-cache`
+
+// defaultPrivilegedTriggers are events that run workflows with access to
+// secrets or a write token.
+var defaultPrivilegedTriggers = []string{
+	"push",
+	"release",
+	"schedule",
+	"workflow_dispatch",
+	"workflow_run",
+	"pull_request_target",
+	"deployment",
+}
+
+// defaultUntrustedTriggers are events that run workflows with code from pull
+// requests.
+var defaultUntrustedTriggers = []string{
+	"pull_request",
+	"pull_request_target",
+}
+
+// scopedExpressions are expression contexts that differ between triggers or
+// refs, a cache key including them is not shared.
+var scopedExpressions = []string{
+	"github.event_name",
+	"github.ref",
+	"github.head_ref",
+	"github.base_ref",
+	"github.sha",
+	"github.run_id",
+}
+
+// setupActions are Actions with a "cache" input that caches dependencies
+// under a key computed by the Action.
+var setupActions = []string{
+	"actions/setup-go",
+	"actions/setup-java",
+	"actions/setup-node",
+	"actions/setup-python",
+}
+
+var expressionRe = regexp.MustCompile(`\$\{\{\s*(.*?)\s*\}\}`)
+
+// OrgConfig is the org-level config definition for Cache Poisoning.
+type OrgConfig struct {
+	// OptConfig is the standard org-level opt in/out config, RepoOverride
+	// applies to all config.
+	OptConfig config.OrgOptConfig `json:"optConfig"`
+
+	// Action defines which action to take, default log, other: issue...
+	Action string `json:"action"`
+
+	// SharedKeys : set to true to flag privileged workflows that restore a
+	// cache using a key pattern that untrusted workflows also save, default
+	// true.
+	SharedKeys bool `json:"sharedKeys"`
+
+	// RestoreKeys : set to true to also match the restore-keys prefixes of
+	// actions/cache against saved keys, default true.
+	RestoreKeys bool `json:"restoreKeys"`
+
+	// SetupActions : set to true to also consider caches enabled with the
+	// "cache" input of actions/setup-* Actions, default true.
+	SetupActions bool `json:"setupActions"`
+
+	// PrivilegedTriggers are the workflow trigger events considered
+	// privileged, default: push, release, schedule, workflow_dispatch,
+	// workflow_run, pull_request_target, and deployment.
+	PrivilegedTriggers []string `json:"privilegedTriggers"`
+
+	// UntrustedTriggers are the workflow trigger events considered to run
+	// untrusted code, default: pull_request and pull_request_target.
+	UntrustedTriggers []string `json:"untrustedTriggers"`
+}
+
+// RepoConfig is the repo-level config for Cache Poisoning.
+type RepoConfig struct {
+	// OptConfig is the standard repo-level opt in/out config.
+	OptConfig config.RepoOptConfig `json:"optConfig"`
+
+	// Action overrides the same setting in org-level, only if present.
+	Action *string `json:"action"`
+
+	// SharedKeys overrides the same setting in org-level, only if present.
+	SharedKeys *bool `json:"sharedKeys"`
+
+	// RestoreKeys overrides the same setting in org-level, only if present.
+	RestoreKeys *bool `json:"restoreKeys"`
+
+	// SetupActions overrides the same setting in org-level, only if present.
+	SetupActions *bool `json:"setupActions"`
+}
+
+type mergedConfig struct {
+	Action             string
+	SharedKeys         bool
+	RestoreKeys        bool
+	SetupActions       bool
+	PrivilegedTriggers []string
+	UntrustedTriggers  []string
+}
+
+// finding is a cache restored by a privileged workflow from a key an
+// untrusted workflow saves.
+type finding struct {
+	// Workflow is the path of the privileged workflow.
+	Workflow string
+	// Job is the ID of the job restoring the cache.
+	Job string
+	// Step describes the step restoring the cache.
+	Step string
+	// Key is the restored key pattern.
+	Key string
+	// Writer is the path of the untrusted workflow saving the key.
+	Writer string
+}
+
+type details struct {
+	Findings []finding
+}
+
+// cacheStep is a step that restores or saves a cache.
+type cacheStep struct {
+	workflow string
+	job      string
+	step     string
+	// keys are the key patterns of the cache, the primary key first.
+	keys []string
+	// prefixes are the restore-keys patterns, matched as prefixes.
+	prefixes []string
+	restore  bool
+	save     bool
+}
+
+var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+var configIsEnabled func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig, c *github.Client, owner, repo string) (bool, error)
+var listWorkflows func(context.Context, *github.Client, string, string, string) ([]*workflowfiles.Workflow, error)
+
+func init() {
+	configFetchConfig = config.FetchConfig
+	configIsEnabled = config.IsEnabled
+	listWorkflows = workflowfiles.ListParsed
+}
+
+// CachePoisoning is the Cache Poisoning policy object, implements
+// policydef.Policy.
+type CachePoisoning bool
+
+// NewCachePoisoning returns a new Cache Poisoning policy.
+func NewCachePoisoning() policydef.Policy {
+	var c CachePoisoning
+	return c
+}
+
+// Name returns the name of this policy, implementing policydef.Policy.Name()
+func (c CachePoisoning) Name() string {
+	return polName
+}
+
+// Check whether this policy is enabled or not
+func (c CachePoisoning) IsEnabled(ctx context.Context, cl *github.Client, owner, repo string) (bool, error) {
+	oc, orc, rc := getConfig(ctx, cl, owner, repo)
+	return configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, cl, owner, repo)
+}
+
+// Check performs the policy check for Cache Poisoning policy based on the
+// configuration stored in the org/repo, implementing policydef.Policy.Check()
+func (c CachePoisoning) Check(ctx context.Context, cl *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	oc, orc, rc := getConfig(ctx, cl, owner, repo)
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, cl, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Bool("enabled", enabled).
+		Msg("Check repo enabled")
+	mc := mergeConfig(oc, orc, rc, repo)
+
+	if !mc.SharedKeys {
+		return &policydef.Result{
+			Enabled:    enabled,
+			Pass:       true,
+			NotifyText: "",
+			Details:    details{},
+		}, nil
+	}
+
+	wfs, err := listWorkflows(ctx, cl, owner, repo, polName)
+	if err != nil {
+		return nil, err
+	}
+	fs := findSharedKeys(wfs, mc)
+	if len(fs) == 0 {
+		return &policydef.Result{
+			Enabled:    enabled,
+			Pass:       true,
+			NotifyText: "",
+			Details:    details{},
+		}, nil
+	}
+
+	var text string
+	last := ""
+	for _, f := range fs {
+		if f.Workflow != last {
+			text += fmt.Sprintf("`%v`:\n", f.Workflow)
+			last = f.Workflow
+		}
+		text += fmt.Sprintf("- job `%v`, %v restores key `%v`, also saved by `%v`\n", f.Job, f.Step, f.Key, f.Writer)
+	}
+	return &policydef.Result{
+		Enabled:    enabled,
+		Pass:       false,
+		NotifyText: fmt.Sprintf(notifyText, text),
+		Details: details{
+			Findings: fs,
+		},
+	}, nil
+}
+
+// findSharedKeys returns the caches restored by privileged workflows from keys
+// saved by untrusted workflows, sorted by workflow and job.
+func findSharedKeys(wfs []*workflowfiles.Workflow, mc *mergedConfig) []finding {
+	var restorers, writers []*cacheStep
+	for _, wf := range wfs {
+		privileged := hasTrigger(wf.Workflow, mc.PrivilegedTriggers)
+		untrusted := hasTrigger(wf.Workflow, mc.UntrustedTriggers)
+		if !privileged && !untrusted {
+			continue
+		}
+		for _, cs := range cacheSteps(wf, mc.SetupActions) {
+			if privileged && cs.restore {
+				restorers = append(restorers, cs)
+			}
+			if untrusted && cs.save {
+				writers = append(writers, cs)
+			}
+		}
+	}
+
+	var fs []finding
+	seen := map[finding]struct{}{}
+	for _, r := range restorers {
+		for _, w := range writers {
+			key, ok := sharedKey(r, w, mc.RestoreKeys)
+			if !ok {
+				continue
+			}
+			f := finding{
+				Workflow: r.workflow,
+				Job:      r.job,
+				Step:     r.step,
+				Key:      key,
+				Writer:   w.workflow,
+			}
+			if _, ok := seen[f]; ok {
+				continue
+			}
+			seen[f] = struct{}{}
+			fs = append(fs, f)
+		}
+	}
+	sort.SliceStable(fs, func(i, j int) bool {
+		if fs[i].Workflow != fs[j].Workflow {
+			return fs[i].Workflow < fs[j].Workflow
+		}
+		return fs[i].Job < fs[j].Job
+	})
+	return fs
+}
+
+// sharedKey returns the pattern of the key restored by r that w may have
+// saved.
+func sharedKey(r, w *cacheStep, restoreKeys bool) (string, bool) {
+	for _, wk := range w.keys {
+		for _, rk := range r.keys {
+			if rk == wk && !isScoped(rk) {
+				return rk, true
+			}
+		}
+		if !restoreKeys {
+			continue
+		}
+		for _, p := range r.prefixes {
+			if strings.HasPrefix(wk, p) && !isScoped(p) {
+				return p, true
+			}
+		}
+	}
+	return "", false
+}
+
+// isScoped returns whether the key pattern includes an expression that
+// differs between triggers or refs.
+func isScoped(key string) bool {
+	for _, m := range expressionRe.FindAllStringSubmatch(key, -1) {
+		for _, s := range scopedExpressions {
+			if strings.Contains(m[1], s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// normalizeKey removes insignificant whitespace from expressions in key.
+func normalizeKey(key string) string {
+	return expressionRe.ReplaceAllString(strings.TrimSpace(key), "$${{ $1 }}")
+}
+
+func hasTrigger(wf *actionlint.Workflow, triggers []string) bool {
+	for _, e := range wf.On {
+		for _, t := range triggers {
+			if e.EventName() == t {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cacheSteps returns the steps in wf using actions/cache, and optionally
+// setup Actions with caching enabled.
+func cacheSteps(wf *workflowfiles.Workflow, setup bool) []*cacheStep {
+	var ids []string
+	for id := range wf.Workflow.Jobs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var css []*cacheStep
+	for _, id := range ids {
+		j := wf.Workflow.Jobs[id]
+		if j == nil {
+			continue
+		}
+		for i, s := range j.Steps {
+			if s == nil || s.Exec == nil {
+				continue
+			}
+			e, ok := s.Exec.(*actionlint.ExecAction)
+			if !ok || e.Uses == nil {
+				continue
+			}
+			name := strings.ToLower(strings.SplitN(e.Uses.Value, "@", 2)[0])
+			desc := fmt.Sprintf("step %v (`%v`)", i+1, e.Uses.Value)
+			if s.Name != nil && s.Name.Value != "" {
+				desc = fmt.Sprintf("step %q (`%v`)", s.Name.Value, e.Uses.Value)
+			}
+			cs := &cacheStep{
+				workflow: wf.Path,
+				job:      id,
+				step:     desc,
+			}
+			switch name {
+			case "actions/cache":
+				cs.restore = true
+				cs.save = true
+			case "actions/cache/restore":
+				cs.restore = true
+			case "actions/cache/save":
+				cs.save = true
+			default:
+				if !setup || !slices.Contains(setupActions, name) {
+					continue
+				}
+				v := input(e, "cache")
+				if v == "" || v == "false" {
+					continue
+				}
+				// The key is computed by the Action, from the runner and
+				// dependency files, so it is the same in every workflow.
+				cs.restore = true
+				cs.save = true
+				cs.keys = []string{fmt.Sprintf("%v (%v)", name, v)}
+				css = append(css, cs)
+				continue
+			}
+			k := input(e, "key")
+			if k == "" {
+				continue
+			}
+			cs.keys = []string{normalizeKey(k)}
+			for _, p := range strings.Split(input(e, "restore-keys"), "\n") {
+				if p = normalizeKey(p); p != "" {
+					cs.prefixes = append(cs.prefixes, p)
+				}
+			}
+			css = append(css, cs)
+		}
+	}
+	return css
+}
+
+func input(e *actionlint.ExecAction, name string) string {
+	i, ok := e.Inputs[name]
+	if !ok || i == nil || i.Value == nil {
+		return ""
+	}
+	return i.Value.Value
+}
+
+// Fix implementing policydef.Policy.Fix(). Not supported.
+func (c CachePoisoning) Fix(ctx context.Context, cl *github.Client, owner, repo string) error {
+	log.Warn().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Msg("Action fix is configured, but not implemented.")
+	return nil
+}
+
+// GetAction returns the configured action from Cache Poisoning policy's
+// configuration stored in the org-level repo, default log. Implementing
+// policydef.Policy.GetAction()
+func (c CachePoisoning) GetAction(ctx context.Context, cl *github.Client, owner, repo string) string {
+	oc, orc, rc := getConfig(ctx, cl, owner, repo)
+	mc := mergeConfig(oc, orc, rc, repo)
+	return mc.Action
+}
+
+func getConfig(ctx context.Context, c *github.Client, owner, repo string) (*OrgConfig, *RepoConfig, *RepoConfig) {
+	oc := &OrgConfig{ // Fill out non-zero defaults
+		Action:             "log",
+		SharedKeys:         true,
+		RestoreKeys:        true,
+		SetupActions:       true,
+		PrivilegedTriggers: defaultPrivilegedTriggers,
+		UntrustedTriggers:  defaultUntrustedTriggers,
+	}
+	if err := configFetchConfig(ctx, c, owner, "", configFile, config.OrgLevel, oc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	orc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.OrgRepoLevel, orc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgRepoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	rc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.RepoLevel, rc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "repoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	return oc, orc, rc
+}
+
+func mergeConfig(oc *OrgConfig, orc, rc *RepoConfig, repo string) *mergedConfig {
+	mc := &mergedConfig{
+		Action:             oc.Action,
+		SharedKeys:         oc.SharedKeys,
+		RestoreKeys:        oc.RestoreKeys,
+		SetupActions:       oc.SetupActions,
+		PrivilegedTriggers: oc.PrivilegedTriggers,
+		UntrustedTriggers:  oc.UntrustedTriggers,
+	}
+	mc = mergeInRepoConfig(mc, orc, repo)
+
+	if !oc.OptConfig.DisableRepoOverride {
+		mc = mergeInRepoConfig(mc, rc, repo)
+	}
+	return mc
+}
+
+func mergeInRepoConfig(mc *mergedConfig, rc *RepoConfig, repo string) *mergedConfig {
+	if rc.Action != nil {
+		mc.Action = *rc.Action
+	}
+	if rc.SharedKeys != nil {
+		mc.SharedKeys = *rc.SharedKeys
+	}
+	if rc.RestoreKeys != nil {
+		mc.RestoreKeys = *rc.RestoreKeys
+	}
+	if rc.SetupActions != nil {
+		mc.SetupActions = *rc.SetupActions
+	}
+	return mc
+}
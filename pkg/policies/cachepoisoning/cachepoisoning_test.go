@@ -0,0 +1,402 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cachepoisoning
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/configtest"
+	"github.com/ossf/allstar/pkg/policies/workflowfiles"
+	"github.com/rhysd/actionlint"
+)
+
+func TestConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Org       OrgConfig
+		OrgRepo   RepoConfig
+		Repo      RepoConfig
+		ExpAction string
+		Exp       mergedConfig
+	}{
+		{
+			Name: "OrgOnly",
+			Org: OrgConfig{
+				Action:            "issue",
+				SharedKeys:        true,
+				UntrustedTriggers: []string{"pull_request"},
+			},
+			OrgRepo:   RepoConfig{},
+			Repo:      RepoConfig{},
+			ExpAction: "issue",
+			Exp: mergedConfig{
+				Action:            "issue",
+				SharedKeys:        true,
+				UntrustedTriggers: []string{"pull_request"},
+			},
+		},
+		{
+			Name: "OrgRepoOverOrg",
+			Org: OrgConfig{
+				Action:     "issue",
+				SharedKeys: true,
+			},
+			OrgRepo: RepoConfig{
+				Action:       github.String("log"),
+				RestoreKeys:  github.Bool(true),
+				SetupActions: github.Bool(true),
+			},
+			Repo:      RepoConfig{},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action:       "log",
+				SharedKeys:   true,
+				RestoreKeys:  true,
+				SetupActions: true,
+			},
+		},
+		{
+			Name: "RepoOverAllOrg",
+			Org: OrgConfig{
+				Action:     "issue",
+				SharedKeys: true,
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action:     github.String("email"),
+				SharedKeys: github.Bool(false),
+			},
+			ExpAction: "email",
+			Exp: mergedConfig{
+				Action:     "email",
+				SharedKeys: false,
+			},
+		},
+		{
+			Name: "RepoDisallowed",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					DisableRepoOverride: true,
+				},
+				Action:     "issue",
+				SharedKeys: true,
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action:     github.String("email"),
+				SharedKeys: github.Bool(false),
+			},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action:     "log",
+				SharedKeys: true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				switch ol {
+				case config.RepoLevel:
+					rc := out.(*RepoConfig)
+					*rc = test.Repo
+				case config.OrgRepoLevel:
+					orc := out.(*RepoConfig)
+					*orc = test.OrgRepo
+				case config.OrgLevel:
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+
+			c := CachePoisoning(true)
+			ctx := context.Background()
+
+			action := c.GetAction(ctx, nil, "", "thisrepo")
+			if action != test.ExpAction {
+				t.Errorf("Unexpected results. want %s, got %s", test.ExpAction, action)
+			}
+
+			oc, orc, rc := getConfig(ctx, nil, "", "thisrepo")
+			mc := mergeConfig(oc, orc, rc, "thisrepo")
+			if diff := cmp.Diff(&test.Exp, mc); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+const prWorkflow = `name: PR
+on: pull_request
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/cache@v4
+        with:
+          path: ~/.cache
+          key: ${{runner.os}}-build-${{ hashFiles('**/go.sum') }}
+      - uses: actions/setup-node@v4
+        with:
+          cache: npm
+`
+
+const releaseWorkflow = `name: Release
+on:
+  push:
+    tags: ["v*"]
+jobs:
+  release:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - name: Restore build cache
+        uses: actions/cache/restore@v4
+        with:
+          path: ~/.cache
+          key: ${{ runner.os }}-build-${{ hashFiles('**/go.sum') }}
+  publish:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/setup-node@v4
+        with:
+          cache: npm
+`
+
+const prefixWorkflow = `name: Nightly
+on:
+  schedule:
+    - cron: "0 0 * * *"
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/cache@v4
+        with:
+          path: ~/.cache
+          key: nightly-${{ github.run_id }}
+          restore-keys: |
+            ${{ runner.os }}-build-
+`
+
+const scopedWorkflow = `name: Main
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/cache@v4
+        with:
+          path: ~/.cache
+          key: ${{ github.ref }}-${{ runner.os }}-build-${{ hashFiles('**/go.sum') }}
+`
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Workflows  map[string]string
+		Repo       RepoConfig
+		ExpPass    bool
+		ExpNotify  string
+		ExpDetails details
+	}{
+		{
+			Name:       "NoWorkflows",
+			ExpPass:    true,
+			ExpDetails: details{},
+		},
+		{
+			Name: "NoPrivileged",
+			Workflows: map[string]string{
+				"pr.yaml": prWorkflow,
+			},
+			ExpPass:    true,
+			ExpDetails: details{},
+		},
+		{
+			Name: "SharedKeys",
+			Workflows: map[string]string{
+				"pr.yaml":      prWorkflow,
+				"release.yaml": releaseWorkflow,
+			},
+			ExpPass:   false,
+			ExpNotify: "`.github/workflows/release.yaml`:\n- job `publish`, step 1 (`actions/setup-node@v4`) restores key `actions/setup-node (npm)`, also saved by `.github/workflows/pr.yaml`\n",
+			ExpDetails: details{
+				Findings: []finding{
+					{
+						Workflow: ".github/workflows/release.yaml",
+						Job:      "publish",
+						Step:     "step 1 (`actions/setup-node@v4`)",
+						Key:      "actions/setup-node (npm)",
+						Writer:   ".github/workflows/pr.yaml",
+					},
+					{
+						Workflow: ".github/workflows/release.yaml",
+						Job:      "release",
+						Step:     "step \"Restore build cache\" (`actions/cache/restore@v4`)",
+						Key:      "${{ runner.os }}-build-${{ hashFiles('**/go.sum') }}",
+						Writer:   ".github/workflows/pr.yaml",
+					},
+				},
+			},
+		},
+		{
+			Name: "SetupActionsDisabled",
+			Workflows: map[string]string{
+				"pr.yaml":      prWorkflow,
+				"release.yaml": releaseWorkflow,
+			},
+			Repo: RepoConfig{
+				SetupActions: github.Bool(false),
+			},
+			ExpPass: false,
+			ExpDetails: details{
+				Findings: []finding{
+					{
+						Workflow: ".github/workflows/release.yaml",
+						Job:      "release",
+						Step:     "step \"Restore build cache\" (`actions/cache/restore@v4`)",
+						Key:      "${{ runner.os }}-build-${{ hashFiles('**/go.sum') }}",
+						Writer:   ".github/workflows/pr.yaml",
+					},
+				},
+			},
+		},
+		{
+			Name: "RestoreKeys",
+			Workflows: map[string]string{
+				"pr.yaml":      prWorkflow,
+				"nightly.yaml": prefixWorkflow,
+			},
+			ExpPass: false,
+			ExpDetails: details{
+				Findings: []finding{
+					{
+						Workflow: ".github/workflows/nightly.yaml",
+						Job:      "build",
+						Step:     "step 1 (`actions/cache@v4`)",
+						Key:      "${{ runner.os }}-build-",
+						Writer:   ".github/workflows/pr.yaml",
+					},
+				},
+			},
+		},
+		{
+			Name: "RestoreKeysDisabled",
+			Workflows: map[string]string{
+				"pr.yaml":      prWorkflow,
+				"nightly.yaml": prefixWorkflow,
+			},
+			Repo: RepoConfig{
+				RestoreKeys: github.Bool(false),
+			},
+			ExpPass:    true,
+			ExpDetails: details{},
+		},
+		{
+			Name: "ScopedKey",
+			Workflows: map[string]string{
+				"pr.yaml":   prWorkflow,
+				"main.yaml": scopedWorkflow,
+			},
+			ExpPass:    true,
+			ExpDetails: details{},
+		},
+		{
+			Name: "SharedKeysDisabled",
+			Workflows: map[string]string{
+				"pr.yaml":      prWorkflow,
+				"release.yaml": releaseWorkflow,
+			},
+			Repo: RepoConfig{
+				SharedKeys: github.Bool(false),
+			},
+			ExpPass:    true,
+			ExpDetails: details{},
+		},
+	}
+
+	configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+		c *github.Client, owner, repo string) (bool, error) {
+		return true, nil
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.RepoLevel {
+					rc := out.(*RepoConfig)
+					*rc = test.Repo
+				}
+				return nil
+			}
+			listWorkflows = func(ctx context.Context, c *github.Client, owner, repo, area string) ([]*workflowfiles.Workflow, error) {
+				var wfs []*workflowfiles.Workflow
+				for name, content := range test.Workflows {
+					wf, errs := actionlint.Parse([]byte(content))
+					if len(errs) > 0 {
+						t.Fatalf("Unexpected parse errors in %v: %v", name, errs)
+					}
+					wfs = append(wfs, &workflowfiles.Workflow{
+						Path:     ".github/workflows/" + name,
+						Workflow: wf,
+					})
+				}
+				return wfs, nil
+			}
+
+			res, err := CachePoisoning(true).Check(context.Background(), nil, "thisorg", "thisrepo")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Pass != test.ExpPass {
+				t.Errorf("Unexpected pass: %v", res.Pass)
+			}
+			if test.ExpNotify != "" && !strings.Contains(res.NotifyText, test.ExpNotify) {
+				t.Errorf("Expected notify text to contain:\n%v\ngot:\n%v", test.ExpNotify, res.NotifyText)
+			}
+			if diff := cmp.Diff(test.ExpDetails, res.Details, cmp.AllowUnexported(details{})); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGeneratedPrecedence(t *testing.T) {
+	configtest.TestPrecedence(t, configtest.Precedence{
+		Org:   OrgConfig{},
+		Repo:  RepoConfig{},
+		Fetch: &configFetchConfig,
+		Merge: func() interface{} {
+			ctx := context.Background()
+			oc, orc, rc := getConfig(ctx, nil, "", configtest.Repo)
+			return mergeConfig(oc, orc, rc, configtest.Repo)
+		},
+	})
+}
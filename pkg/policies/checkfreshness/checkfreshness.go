@@ -0,0 +1,448 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package checkfreshness implements the Status Check Freshness policy. It
+// checks that the status checks required by the default branch's protection
+// have reported on recent commits, so that a renamed or retired CI job does
+// not leave a requirement that is never met, or silently bypassed by admins.
+package checkfreshness
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/policydef"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+const configFile = "status_check_freshness.yaml"
+const polName = "Status Check Freshness"
+
+const day = 24 * time.Hour
+
+const notifyText = `This policy requires that each status check required by the default branch's protection has reported, as a check run or a commit status, on a recent commit of the default branch or a recently merged pull request. A required check that never reports is usually a CI job that was renamed or removed, which blocks merging, or is bypassed by administrators, and no longer protects the branch.
+
+To fix this, update the required status checks in the branch protection settings to match the names of the current CI jobs, or remove the checks that are no longer run.
+(For more information, see https://docs.github.com/en/repositories/configuring-branches-and-merges-in-your-repository/managing-protected-branches/about-protected-branches#require-status-checks-before-merging)`
+
+// OrgConfig is the org-level config definition for Status Check Freshness.
+type OrgConfig struct {
+	// OptConfig is the standard org-level opt in/out config, RepoOverride
+	// applies to all config.
+	OptConfig config.OrgOptConfig `json:"optConfig"`
+
+	// Action defines which action to take, default log, other: issue...
+	Action string `json:"action"`
+
+	// MaxAgeDays is the number of days within which each required check must
+	// have reported, default 30.
+	MaxAgeDays int `json:"maxAgeDays"`
+
+	// MaxCommits is the maximum number of recent default branch commits, and
+	// of recently merged pull requests, inspected, default 10.
+	MaxCommits int `json:"maxCommits"`
+}
+
+// RepoConfig is the repo-level config for Status Check Freshness.
+type RepoConfig struct {
+	// OptConfig is the standard repo-level opt in/out config.
+	OptConfig config.RepoOptConfig `json:"optConfig"`
+
+	// Action overrides the same setting in org-level, only if present.
+	Action *string `json:"action"`
+
+	// MaxAgeDays overrides the same setting in org-level, only if present.
+	MaxAgeDays *int `json:"maxAgeDays"`
+}
+
+type mergedConfig struct {
+	Action     string
+	MaxAgeDays int
+	MaxCommits int
+}
+
+type details struct {
+	// Required lists the status checks required on the default branch.
+	Required []string
+
+	// Stale lists the required status checks that have not reported.
+	Stale []string
+
+	// Commits is the number of commits inspected.
+	Commits int
+}
+
+var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+
+var configIsEnabled func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig, c *github.Client, owner, repo string) (bool, error)
+
+var getDefaultBranch func(context.Context, *github.Client, string, string) (string, error)
+
+var getRequiredChecks func(context.Context, *github.Client, string, string, string) ([]string, error)
+
+var listRecentSHAs func(context.Context, *github.Client, string, string, string, time.Time, int) ([]string, error)
+
+var listReported func(context.Context, *github.Client, string, string, string) ([]string, error)
+
+var now func() time.Time
+
+func init() {
+	configFetchConfig = config.FetchConfig
+	configIsEnabled = config.IsEnabled
+	getDefaultBranch = getDefaultBranchReal
+	getRequiredChecks = getRequiredChecksReal
+	listRecentSHAs = listRecentSHAsReal
+	listReported = listReportedReal
+	now = time.Now
+}
+
+// CheckFreshness is the Status Check Freshness policy object, implements
+// policydef.Policy.
+type CheckFreshness bool
+
+// NewCheckFreshness returns a new Status Check Freshness policy.
+func NewCheckFreshness() policydef.Policy {
+	var f CheckFreshness
+	return f
+}
+
+// Name returns the name of this policy, implementing policydef.Policy.Name()
+func (f CheckFreshness) Name() string {
+	return polName
+}
+
+// Check whether this policy is enabled or not
+func (f CheckFreshness) IsEnabled(ctx context.Context, c *github.Client, owner, repo string) (bool, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	return configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+}
+
+// Check performs the policy check for Status Check Freshness based on the
+// configuration stored in the org/repo, implementing policydef.Policy.Check()
+func (f CheckFreshness) Check(ctx context.Context, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Bool("enabled", enabled).
+		Msg("Check repo enabled")
+
+	mc := mergeConfig(oc, orc, rc, repo)
+
+	var d details
+	branch, err := getDefaultBranch(ctx, c, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	required, err := getRequiredChecks(ctx, c, owner, repo, branch)
+	if err != nil {
+		return nil, err
+	}
+	d.Required = required
+	if len(required) == 0 {
+		return &policydef.Result{
+			Enabled:    enabled,
+			Pass:       true,
+			NotifyText: "",
+			Details:    d,
+		}, nil
+	}
+
+	shas, err := listRecentSHAs(ctx, c, owner, repo, branch,
+		now().Add(-time.Duration(mc.MaxAgeDays)*day), mc.MaxCommits)
+	if err != nil {
+		return nil, err
+	}
+	d.Commits = len(shas)
+	if len(shas) == 0 {
+		// Empty repo
+		return &policydef.Result{
+			Enabled:    enabled,
+			Pass:       true,
+			NotifyText: "",
+			Details:    d,
+		}, nil
+	}
+
+	stale := make(map[string]bool)
+	for _, r := range required {
+		stale[r] = true
+	}
+	for _, sha := range shas {
+		names, err := listReported(ctx, c, owner, repo, sha)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range names {
+			delete(stale, n)
+		}
+		if len(stale) == 0 {
+			break
+		}
+	}
+	for s := range stale {
+		d.Stale = append(d.Stale, s)
+	}
+	// Sort for stable issue text.
+	sort.Strings(d.Stale)
+
+	if len(d.Stale) == 0 {
+		return &policydef.Result{
+			Enabled:    enabled,
+			Pass:       true,
+			NotifyText: "",
+			Details:    d,
+		}, nil
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "Required status checks on branch %v have not reported in the last %v days (%v commits inspected):\n",
+		branch, mc.MaxAgeDays, d.Commits)
+	for _, s := range d.Stale {
+		fmt.Fprintf(&text, "- %v\n", s)
+	}
+	return &policydef.Result{
+		Enabled:    enabled,
+		Pass:       false,
+		NotifyText: text.String() + "\n" + notifyText,
+		Details:    d,
+	}, nil
+}
+
+func getDefaultBranchReal(ctx context.Context, c *github.Client, owner, repo string) (string, error) {
+	r, _, err := c.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return "", err
+	}
+	return r.GetDefaultBranch(), nil
+}
+
+// getRequiredChecksReal returns the names of the status checks required by
+// the branch protection, or nil if the branch is not protected or protection
+// is not available.
+func getRequiredChecksReal(ctx context.Context, c *github.Client, owner, repo, branch string) ([]string, error) {
+	rsc, rsp, err := c.Repositories.GetRequiredStatusChecks(ctx, owner, repo, branch)
+	if err != nil {
+		if rsp != nil && (rsp.StatusCode == http.StatusNotFound || rsp.StatusCode == http.StatusForbidden) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var names []string
+	for _, ch := range rsc.Checks {
+		if !seen[ch.Context] {
+			seen[ch.Context] = true
+			names = append(names, ch.Context)
+		}
+	}
+	// Contexts is deprecated, but still returned for older configurations.
+	for _, n := range rsc.Contexts {
+		if !seen[n] {
+			seen[n] = true
+			names = append(names, n)
+		}
+	}
+	return names, nil
+}
+
+// listRecentSHAsReal returns the commits of the branch since the provided
+// time, and the head commits of pull requests merged into it since then, up to
+// limit of each. If there are none, the head of the branch is returned, so that
+// inactive repos are still checked.
+func listRecentSHAsReal(ctx context.Context, c *github.Client, owner, repo, branch string, since time.Time, limit int) ([]string, error) {
+	cs, _, err := c.Repositories.ListCommits(ctx, owner, repo, &github.CommitsListOptions{
+		SHA:   branch,
+		Since: since,
+		ListOptions: github.ListOptions{
+			PerPage: limit,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var shas []string
+	for _, cm := range cs {
+		shas = append(shas, cm.GetSHA())
+	}
+	// Checks often only run on pull requests, and squash merges create a new
+	// commit, so the merged pull request heads are checked as well.
+	prs, _, err := c.PullRequests.List(ctx, owner, repo, &github.PullRequestListOptions{
+		State:     "closed",
+		Base:      branch,
+		Sort:      "updated",
+		Direction: "desc",
+		ListOptions: github.ListOptions{
+			PerPage: limit,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, pr := range prs {
+		if pr.MergedAt != nil && pr.GetMergedAt().After(since) {
+			shas = append(shas, pr.GetHead().GetSHA())
+		}
+	}
+	if len(shas) > 0 {
+		return shas, nil
+	}
+	b, rsp, err := c.Repositories.GetBranch(ctx, owner, repo, branch, 1)
+	if err != nil {
+		if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return []string{b.GetCommit().GetSHA()}, nil
+}
+
+// listReportedReal returns the names of the check runs and commit statuses
+// reported on the commit.
+func listReportedReal(ctx context.Context, c *github.Client, owner, repo, sha string) ([]string, error) {
+	var names []string
+	opt := &github.ListCheckRunsOptions{
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+	for {
+		rs, resp, err := c.Checks.ListCheckRunsForRef(ctx, owner, repo, sha, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rs.CheckRuns {
+			names = append(names, r.GetName())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	sopt := &github.ListOptions{
+		PerPage: 100,
+	}
+	for {
+		cs, resp, err := c.Repositories.GetCombinedStatus(ctx, owner, repo, sha, sopt)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range cs.Statuses {
+			names = append(names, s.GetContext())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		sopt.Page = resp.NextPage
+	}
+	return names, nil
+}
+
+// Fix implementing policydef.Policy.Fix(). Not supported, the required checks
+// to keep can't be known.
+func (f CheckFreshness) Fix(ctx context.Context, c *github.Client, owner, repo string) error {
+	log.Warn().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Msg("Action fix is configured, but not implemented.")
+	return nil
+}
+
+// GetAction returns the configured action from Status Check Freshness'
+// configuration stored in the org-level repo, default log. Implementing
+// policydef.Policy.GetAction()
+func (f CheckFreshness) GetAction(ctx context.Context, c *github.Client, owner, repo string) string {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	mc := mergeConfig(oc, orc, rc, repo)
+	return mc.Action
+}
+
+func getConfig(ctx context.Context, c *github.Client, owner, repo string) (*OrgConfig, *RepoConfig, *RepoConfig) {
+	oc := &OrgConfig{ // Fill out non-zero defaults
+		Action:     "log",
+		MaxAgeDays: 30,
+		MaxCommits: 10,
+	}
+	if err := configFetchConfig(ctx, c, owner, "", configFile, config.OrgLevel, oc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	orc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.OrgRepoLevel, orc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgRepoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	rc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.RepoLevel, rc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "repoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	return oc, orc, rc
+}
+
+func mergeConfig(oc *OrgConfig, orc, rc *RepoConfig, repo string) *mergedConfig {
+	mc := &mergedConfig{
+		Action:     oc.Action,
+		MaxAgeDays: oc.MaxAgeDays,
+		MaxCommits: oc.MaxCommits,
+	}
+	mc = mergeInRepoConfig(mc, orc, repo)
+
+	if !oc.OptConfig.DisableRepoOverride {
+		mc = mergeInRepoConfig(mc, rc, repo)
+	}
+	return mc
+}
+
+func mergeInRepoConfig(mc *mergedConfig, rc *RepoConfig, repo string) *mergedConfig {
+	if rc.Action != nil {
+		mc.Action = *rc.Action
+	}
+	if rc.MaxAgeDays != nil {
+		mc.MaxAgeDays = *rc.MaxAgeDays
+	}
+	return mc
+}
@@ -0,0 +1,266 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checkfreshness
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/configtest"
+)
+
+func TestConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Org       OrgConfig
+		OrgRepo   RepoConfig
+		Repo      RepoConfig
+		ExpAction string
+		Exp       mergedConfig
+	}{
+		{
+			Name: "OrgOnly",
+			Org: OrgConfig{
+				Action:     "issue",
+				MaxAgeDays: 14,
+				MaxCommits: 5,
+			},
+			OrgRepo:   RepoConfig{},
+			Repo:      RepoConfig{},
+			ExpAction: "issue",
+			Exp: mergedConfig{
+				Action:     "issue",
+				MaxAgeDays: 14,
+				MaxCommits: 5,
+			},
+		},
+		{
+			Name: "OrgRepoOverOrg",
+			Org: OrgConfig{
+				Action:     "issue",
+				MaxAgeDays: 14,
+			},
+			OrgRepo: RepoConfig{
+				Action:     github.String("log"),
+				MaxAgeDays: github.Int(60),
+			},
+			Repo:      RepoConfig{},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action:     "log",
+				MaxAgeDays: 60,
+			},
+		},
+		{
+			Name: "RepoOverAllOrg",
+			Org: OrgConfig{
+				Action: "issue",
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action:     github.String("email"),
+				MaxAgeDays: github.Int(7),
+			},
+			ExpAction: "email",
+			Exp: mergedConfig{
+				Action:     "email",
+				MaxAgeDays: 7,
+			},
+		},
+		{
+			Name: "RepoDisallowed",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					DisableRepoOverride: true,
+				},
+				Action:     "issue",
+				MaxAgeDays: 14,
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action:     github.String("email"),
+				MaxAgeDays: github.Int(7),
+			},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action:     "log",
+				MaxAgeDays: 14,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				switch ol {
+				case config.RepoLevel:
+					rc := out.(*RepoConfig)
+					*rc = test.Repo
+				case config.OrgRepoLevel:
+					orc := out.(*RepoConfig)
+					*orc = test.OrgRepo
+				case config.OrgLevel:
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+
+			f := CheckFreshness(true)
+			ctx := context.Background()
+
+			action := f.GetAction(ctx, nil, "", "thisrepo")
+			if action != test.ExpAction {
+				t.Errorf("Unexpected results. want %s, got %s", test.ExpAction, action)
+			}
+
+			oc, orc, rc := getConfig(ctx, nil, "", "thisrepo")
+			mc := mergeConfig(oc, orc, rc, "thisrepo")
+			if diff := cmp.Diff(&test.Exp, mc); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		Name       string
+		Required   []string
+		SHAs       []string
+		Reported   map[string][]string
+		ExpPass    bool
+		ExpNotify  string
+		ExpDetails details
+	}{
+		{
+			Name:       "NoneRequired",
+			ExpPass:    true,
+			ExpDetails: details{},
+		},
+		{
+			Name:     "EmptyRepo",
+			Required: []string{"build"},
+			ExpPass:  true,
+			ExpDetails: details{
+				Required: []string{"build"},
+			},
+		},
+		{
+			Name:     "Fresh",
+			Required: []string{"build", "ci/lint"},
+			SHAs:     []string{"a", "b"},
+			Reported: map[string][]string{
+				"a": {"build"},
+				"b": {"ci/lint", "other"},
+			},
+			ExpPass: true,
+			ExpDetails: details{
+				Required: []string{"build", "ci/lint"},
+				Commits:  2,
+			},
+		},
+		{
+			Name:     "Stale",
+			Required: []string{"test (1.20)", "build", "lint"},
+			SHAs:     []string{"a", "b"},
+			Reported: map[string][]string{
+				"a": {"build"},
+				"b": {"test (1.22)"},
+			},
+			ExpPass:   false,
+			ExpNotify: "Required status checks on branch main have not reported in the last 30 days (2 commits inspected):\n- lint\n- test (1.20)\n",
+			ExpDetails: details{
+				Required: []string{"test (1.20)", "build", "lint"},
+				Stale:    []string{"lint", "test (1.20)"},
+				Commits:  2,
+			},
+		},
+	}
+
+	configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+		c *github.Client, owner, repo string) (bool, error) {
+		return true, nil
+	}
+	configFetchConfig = func(ctx context.Context, c *github.Client,
+		owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+		return nil
+	}
+	now = func() time.Time { return start }
+	getDefaultBranch = func(ctx context.Context, c *github.Client, owner, repo string) (string, error) {
+		return "main", nil
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			getRequiredChecks = func(ctx context.Context, c *github.Client, owner, repo, branch string) ([]string, error) {
+				if branch != "main" {
+					t.Errorf("Unexpected branch: %v", branch)
+				}
+				return test.Required, nil
+			}
+			listRecentSHAs = func(ctx context.Context, c *github.Client, owner, repo, branch string, since time.Time, limit int) ([]string, error) {
+				if !since.Equal(start.Add(-30 * day)) {
+					t.Errorf("Unexpected since: %v", since)
+				}
+				if limit != 10 {
+					t.Errorf("Unexpected limit: %v", limit)
+				}
+				return test.SHAs, nil
+			}
+			listReported = func(ctx context.Context, c *github.Client, owner, repo, sha string) ([]string, error) {
+				return test.Reported[sha], nil
+			}
+
+			res, err := CheckFreshness(true).Check(context.Background(), nil, "thisorg", "thisrepo")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Pass != test.ExpPass {
+				t.Errorf("Unexpected pass: %v", res.Pass)
+			}
+			if test.ExpNotify != "" && !strings.Contains(res.NotifyText, test.ExpNotify) {
+				t.Errorf("Expected notify text to contain:\n%v\ngot:\n%v", test.ExpNotify, res.NotifyText)
+			}
+			if diff := cmp.Diff(test.ExpDetails, res.Details); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGeneratedPrecedence(t *testing.T) {
+	configtest.TestPrecedence(t, configtest.Precedence{
+		Org:   OrgConfig{},
+		Repo:  RepoConfig{},
+		Fetch: &configFetchConfig,
+		Merge: func() interface{} {
+			ctx := context.Background()
+			oc, orc, rc := getConfig(ctx, nil, "", configtest.Repo)
+			return mergeConfig(oc, orc, rc, configtest.Repo)
+		},
+	})
+}
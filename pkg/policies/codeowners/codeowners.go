@@ -22,6 +22,7 @@ import (
 
 	"github.com/ossf/allstar/pkg/config"
 	"github.com/ossf/allstar/pkg/policydef"
+	"github.com/ossf/allstar/pkg/pullrequest"
 
 	"github.com/google/go-github/v59/github"
 	"github.com/rs/zerolog/log"
@@ -30,6 +31,12 @@ import (
 const configFile = "codeowners.yaml"
 const polName = "CODEOWNERS"
 
+// fixBranch is the branch the Fix action proposes a CODEOWNERS from.
+const fixBranch = "allstar/codeowners"
+
+// fixPath is the path of the CODEOWNERS proposed by the Fix action.
+const fixPath = ".github/CODEOWNERS"
+
 const notifyText = `A CODEOWNERS file can give users information about who is responsible for the maintenance of the repository, or specific folders/files. This is different the access control/permissions on a repository.
 
 To fix this, add a CODEOWNERS file to your repository, following the official Github documentation and maybe your company's policy.
@@ -47,6 +54,13 @@ type OrgConfig struct {
 	// RequireCODEOWNERS : set to true to require presence of a CODEOWNERS on the repositories (creates an issue if not present)
 	// default false (only checks if existing CODEOWNERS is valid, creates issues if not valid).
 	RequireCODEOWNERS bool `json:"requireCODEOWNERS"`
+
+	// FixTemplate is the CODEOWNERS content proposed in a pull request by the
+	// fix action, when the repository has none. It is a Go text/template with
+	// the .Owner and .Repo fields, ex: "* @{{.Owner}}/{{.Repo}}-maintainers".
+	// Default empty, no pull request is opened, as there are no sensible
+	// default owners.
+	FixTemplate string `json:"fixTemplate"`
 }
 
 // RepoConfig is the repo-level config for CODEOWNERS
@@ -69,6 +83,7 @@ type repositories interface {
 type mergedConfig struct {
 	Action            string
 	RequireCODEOWNERS bool
+	FixTemplate       string
 }
 
 type details struct {
@@ -81,9 +96,12 @@ var configFetchConfig func(context.Context, *github.Client, string, string, stri
 
 var configIsEnabled func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig, c *github.Client, owner, repo string) (bool, error)
 
+var pullrequestEnsure func(context.Context, *github.Client, string, string, string, *pullrequest.Request) (*github.PullRequest, error)
+
 func init() {
 	configFetchConfig = config.FetchConfig
 	configIsEnabled = config.IsEnabled
+	pullrequestEnsure = pullrequest.Ensure
 }
 
 // Codeowners is the CODEOWNERS policy object, implements policydef.Policy.
@@ -185,15 +203,51 @@ func check(ctx context.Context, rep repositories, c *github.Client, owner,
 	return nil, err
 }
 
-// Fix implementing policydef.Policy.Fix(). Currently not supported. Plan
-// to support this TODO.
+// Fix implementing policydef.Policy.Fix(). Opens a pull request adding a
+// CODEOWNERS from the configured template, if the repository has none. An
+// existing CODEOWNERS with errors is left to the maintainers.
 func (s Codeowners) Fix(ctx context.Context, c *github.Client, owner, repo string) error {
-	log.Warn().
-		Str("org", owner).
-		Str("repo", repo).
-		Str("area", polName).
-		Msg("Action fix is configured, but not implemented.")
-	return nil
+	return fix(ctx, c.Repositories, c, owner, repo)
+}
+
+func fix(ctx context.Context, rep repositories, c *github.Client, owner, repo string) error {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return nil
+	}
+	mc := mergeConfig(oc, orc, rc, repo)
+
+	_, resp, err := rep.GetCodeownersErrors(ctx, owner, repo, nil)
+	if err == nil {
+		return nil
+	}
+	if resp == nil || resp.StatusCode != http.StatusNotFound {
+		return err
+	}
+	if mc.FixTemplate == "" {
+		log.Warn().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", polName).
+			Msg("Action fix is configured, but no fixTemplate is set.")
+		return nil
+	}
+	f, err := pullrequest.Render(fixPath, mc.FixTemplate, pullrequest.TemplateData{Owner: owner, Repo: repo})
+	if err != nil {
+		return err
+	}
+	_, err = pullrequestEnsure(ctx, c, owner, repo, polName, &pullrequest.Request{
+		Branch: fixBranch,
+		Title:  "Add CODEOWNERS",
+		Body: "This adds a CODEOWNERS file listing who is responsible for the maintenance of this repository. " +
+			"Please review the owners before merging.",
+		Files: []pullrequest.File{f},
+	})
+	return err
 }
 
 // GetAction returns the configured action from CODEOWNERS policy's
@@ -249,6 +303,7 @@ func mergeConfig(oc *OrgConfig, orc *RepoConfig, rc *RepoConfig, repo string) *m
 	mc := &mergedConfig{
 		Action:            oc.Action,
 		RequireCODEOWNERS: oc.RequireCODEOWNERS,
+		FixTemplate:       oc.FixTemplate,
 	}
 	mc = mergeInRepoConfig(mc, orc, repo)
 
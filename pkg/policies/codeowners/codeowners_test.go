@@ -23,7 +23,9 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-github/v59/github"
 	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/configtest"
 	"github.com/ossf/allstar/pkg/policydef"
+	"github.com/ossf/allstar/pkg/pullrequest"
 )
 
 var GetCodeownersErrors func(ctx context.Context, owner, repo string, op *github.GetCodeownersErrorsOptions) (*github.CodeownersErrors, *github.Response, error)
@@ -261,9 +263,94 @@ func TestCheck(t *testing.T) {
 	}
 }
 
+func TestFix(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Org        OrgConfig
+		Present    bool
+		ExpContent string
+		ExpPR      bool
+	}{
+		{
+			Name:       "OpensPR",
+			Org:        OrgConfig{FixTemplate: "* @{{.Owner}}/{{.Repo}}-maintainers\n"},
+			ExpContent: "* @org/thisrepo-maintainers\n",
+			ExpPR:      true,
+		},
+		{
+			Name: "NoTemplate",
+			Org:  OrgConfig{},
+		},
+		{
+			Name:    "Existing",
+			Org:     OrgConfig{FixTemplate: "* @org/admins\n"},
+			Present: true,
+		},
+	}
+
+	configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+		c *github.Client, owner, repo string) (bool, error) {
+		return true, nil
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.OrgLevel {
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+			GetCodeownersErrors = func(ctx context.Context, owner, repo string, op *github.GetCodeownersErrorsOptions) (*github.CodeownersErrors, *github.Response, error) {
+				if test.Present {
+					return &github.CodeownersErrors{}, nil, nil
+				}
+				return nil, &github.Response{Response: &http.Response{StatusCode: http.StatusNotFound}}, errors.New("Fake error")
+			}
+			var got *pullrequest.Request
+			pullrequestEnsure = func(ctx context.Context, c *github.Client, owner, repo, policy string,
+				pr *pullrequest.Request) (*github.PullRequest, error) {
+				got = pr
+				return &github.PullRequest{}, nil
+			}
+
+			if err := fix(context.Background(), mockRepos{}, nil, "org", "thisrepo"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if (got != nil) != test.ExpPR {
+				t.Fatalf("Unexpected pull request, want %v got %v", test.ExpPR, got)
+			}
+			if got == nil {
+				return
+			}
+			if got.Branch != fixBranch || len(got.Files) != 1 || got.Files[0].Path != ".github/CODEOWNERS" {
+				t.Errorf("Unexpected pull request: %v", got)
+			}
+			if content := string(got.Files[0].Content); content != test.ExpContent {
+				t.Errorf("Unexpected content, want %q got %q", test.ExpContent, content)
+			}
+		})
+	}
+}
+
 func trunc(s string, n int) string {
 	if n >= len(s) {
 		return s
 	}
 	return s[:n]
 }
+
+func TestGeneratedPrecedence(t *testing.T) {
+	configtest.TestPrecedence(t, configtest.Precedence{
+		Org:   OrgConfig{},
+		Repo:  RepoConfig{},
+		Fetch: &configFetchConfig,
+		Merge: func() interface{} {
+			ctx := context.Background()
+			oc, orc, rc := getConfig(ctx, nil, "", configtest.Repo)
+			return mergeConfig(oc, orc, rc, configtest.Repo)
+		},
+	})
+}
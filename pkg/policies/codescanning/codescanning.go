@@ -0,0 +1,378 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package codescanning implements the Code Scanning security policy. It
+// requires a recent successful code scanning analysis, or a workflow running
+// CodeQL, for each configured language.
+package codescanning
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/policies/workflowfiles"
+	"github.com/ossf/allstar/pkg/policydef"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+const configFile = "code_scanning.yaml"
+const polName = "Code Scanning"
+
+// anyLanguage is used in details when no languages are configured.
+const anyLanguage = "any"
+
+var codeQLUses = regexp.MustCompile(`uses:\s*['"]?github/codeql-action/`)
+
+const notifyText = `This policy requires code scanning, such as CodeQL, to regularly analyze this repository for vulnerabilities.
+
+To fix this, from the main page of the repository go to Settings -> Code security, and under "Code scanning" set up CodeQL analysis. Either the default setup, or an advanced setup workflow using github/codeql-action, can be used.
+(For more information, see https://docs.github.com/en/code-security/code-scanning/enabling-code-scanning/configuring-default-setup-for-code-scanning)`
+
+// OrgConfig is the org-level config definition for Code Scanning.
+type OrgConfig struct {
+	// OptConfig is the standard org-level opt in/out config, RepoOverride
+	// applies to all config.
+	OptConfig config.OrgOptConfig `json:"optConfig"`
+
+	// Action defines which action to take, default log, other: issue...
+	Action string `json:"action"`
+
+	// Languages is the list of CodeQL languages that must be analyzed, ex:
+	// "go", "python", "javascript-typescript". If empty, any analysis
+	// satisfies the policy.
+	Languages []string `json:"languages"`
+
+	// MaxAnalysisAgeDays is the maximum age in days of the most recent
+	// successful analysis of each language, default 30.
+	MaxAnalysisAgeDays int `json:"maxAnalysisAgeDays"`
+
+	// AllowWorkflow : set to true to accept a workflow using
+	// github/codeql-action, that mentions the language, in place of a recent
+	// analysis, default true.
+	AllowWorkflow bool `json:"allowWorkflow"`
+}
+
+// RepoConfig is the repo-level config for Code Scanning.
+type RepoConfig struct {
+	// OptConfig is the standard repo-level opt in/out config.
+	OptConfig config.RepoOptConfig `json:"optConfig"`
+
+	// Action overrides the same setting in org-level, only if present.
+	Action *string `json:"action"`
+
+	// Languages overrides the same setting in org-level, only if present.
+	Languages []string `json:"languages"`
+
+	// MaxAnalysisAgeDays overrides the same setting in org-level, only if
+	// present.
+	MaxAnalysisAgeDays *int `json:"maxAnalysisAgeDays"`
+
+	// AllowWorkflow overrides the same setting in org-level, only if present.
+	AllowWorkflow *bool `json:"allowWorkflow"`
+}
+
+type mergedConfig struct {
+	Action             string
+	Languages          []string
+	MaxAnalysisAgeDays int
+	AllowWorkflow      bool
+}
+
+type details struct {
+	// LastAnalysis is the time of the most recent successful analysis of each
+	// language.
+	LastAnalysis map[string]time.Time
+	// Workflows are the workflow files using github/codeql-action.
+	Workflows        []string
+	MissingLanguages []string
+}
+
+var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+
+var configIsEnabled func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig, c *github.Client, owner, repo string) (bool, error)
+
+var listWorkflows func(context.Context, *github.Client, string, string) ([]workflowfiles.File, error)
+
+var timeNow func() time.Time
+
+func init() {
+	configFetchConfig = config.FetchConfig
+	configIsEnabled = config.IsEnabled
+	listWorkflows = workflowfiles.List
+	timeNow = time.Now
+}
+
+type codeScanning interface {
+	ListAnalysesForRepo(context.Context, string, string,
+		*github.AnalysesListOptions) ([]*github.ScanningAnalysis,
+		*github.Response, error)
+}
+
+// CodeScanning is the Code Scanning policy object, implements
+// policydef.Policy.
+type CodeScanning bool
+
+// NewCodeScanning returns a new Code Scanning policy.
+func NewCodeScanning() policydef.Policy {
+	var cs CodeScanning
+	return cs
+}
+
+// Name returns the name of this policy, implementing policydef.Policy.Name()
+func (cs CodeScanning) Name() string {
+	return polName
+}
+
+// Check whether this policy is enabled or not
+func (cs CodeScanning) IsEnabled(ctx context.Context, c *github.Client, owner, repo string) (bool, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	return configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+}
+
+// Check performs the policy check for Code Scanning based on the
+// configuration stored in the org/repo, implementing policydef.Policy.Check()
+func (cs CodeScanning) Check(ctx context.Context, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	return check(ctx, c.CodeScanning, c, owner, repo)
+}
+
+func check(ctx context.Context, csc codeScanning, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Bool("enabled", enabled).
+		Msg("Check repo enabled")
+
+	mc := mergeConfig(oc, orc, rc, repo)
+
+	d := details{
+		LastAnalysis: make(map[string]time.Time),
+	}
+	as, rsp, err := csc.ListAnalysesForRepo(ctx, owner, repo, &github.AnalysesListOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	})
+	if err != nil {
+		// Not found when there are no analyses, forbidden when code scanning
+		// is not available.
+		if rsp == nil || (rsp.StatusCode != http.StatusNotFound && rsp.StatusCode != http.StatusForbidden) {
+			return nil, err
+		}
+	}
+	for _, a := range as {
+		if a.GetError() != "" {
+			continue
+		}
+		lang := analysisLanguage(a)
+		t := a.GetCreatedAt().Time
+		if t.After(d.LastAnalysis[lang]) {
+			d.LastAnalysis[lang] = t
+		}
+	}
+
+	var workflows []workflowfiles.File
+	if mc.AllowWorkflow {
+		wfs, err := listWorkflows(ctx, c, owner, repo)
+		if err != nil {
+			return nil, err
+		}
+		for _, wf := range wfs {
+			if codeQLUses.MatchString(wf.Content) {
+				workflows = append(workflows, wf)
+				d.Workflows = append(d.Workflows, path.Base(wf.Path))
+			}
+		}
+	}
+
+	cutoff := timeNow().Add(-time.Duration(mc.MaxAnalysisAgeDays) * 24 * time.Hour)
+	langs := mc.Languages
+	if len(langs) == 0 {
+		langs = []string{anyLanguage}
+	}
+	for _, l := range langs {
+		if !satisfied(l, d.LastAnalysis, workflows, cutoff) {
+			d.MissingLanguages = append(d.MissingLanguages, l)
+		}
+	}
+
+	if len(d.MissingLanguages) == 0 {
+		return &policydef.Result{
+			Enabled:    enabled,
+			Pass:       true,
+			NotifyText: "",
+			Details:    d,
+		}, nil
+	}
+	var text string
+	if len(mc.Languages) == 0 {
+		text = fmt.Sprintf("No successful code scanning analysis in the last %v days was found.\n", mc.MaxAnalysisAgeDays)
+	} else {
+		text = fmt.Sprintf("No successful code scanning analysis in the last %v days was found for these languages:\n", mc.MaxAnalysisAgeDays)
+		for _, l := range d.MissingLanguages {
+			text = text + fmt.Sprintf("- %v\n", l)
+		}
+	}
+	return &policydef.Result{
+		Enabled:    enabled,
+		Pass:       false,
+		NotifyText: text + "\n" + notifyText,
+		Details:    d,
+	}, nil
+}
+
+// satisfied returns if language l has an analysis after cutoff, or is
+// mentioned in a CodeQL workflow.
+func satisfied(l string, last map[string]time.Time, workflows []workflowfiles.File, cutoff time.Time) bool {
+	if l == anyLanguage {
+		for _, t := range last {
+			if t.After(cutoff) {
+				return true
+			}
+		}
+		return len(workflows) > 0
+	}
+	if last[strings.ToLower(l)].After(cutoff) {
+		return true
+	}
+	re := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(l) + `\b`)
+	for _, wf := range workflows {
+		if re.MatchString(wf.Content) {
+			return true
+		}
+	}
+	return false
+}
+
+// analysisLanguage returns the language of a CodeQL analysis, from the
+// category "/language:go", or the environment {"language":"go"}. Otherwise the
+// tool name is used.
+func analysisLanguage(a *github.ScanningAnalysis) string {
+	if i := strings.Index(a.GetCategory(), "language:"); i >= 0 {
+		return strings.ToLower(a.GetCategory()[i+len("language:"):])
+	}
+	var env struct {
+		Language string `json:"language"`
+	}
+	if err := json.Unmarshal([]byte(a.GetEnvironment()), &env); err == nil && env.Language != "" {
+		return strings.ToLower(env.Language)
+	}
+	return strings.ToLower(a.GetTool().GetName())
+}
+
+// Fix implementing policydef.Policy.Fix(). Currently not supported, which
+// languages to analyze and how needs a person to decide.
+func (cs CodeScanning) Fix(ctx context.Context, c *github.Client, owner, repo string) error {
+	log.Warn().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Msg("Action fix is configured, but not implemented.")
+	return nil
+}
+
+// GetAction returns the configured action from Code Scanning's configuration
+// stored in the org-level repo, default log. Implementing
+// policydef.Policy.GetAction()
+func (cs CodeScanning) GetAction(ctx context.Context, c *github.Client, owner, repo string) string {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	mc := mergeConfig(oc, orc, rc, repo)
+	return mc.Action
+}
+
+func getConfig(ctx context.Context, c *github.Client, owner, repo string) (*OrgConfig, *RepoConfig, *RepoConfig) {
+	oc := &OrgConfig{ // Fill out non-zero defaults
+		Action:             "log",
+		MaxAnalysisAgeDays: 30,
+		AllowWorkflow:      true,
+	}
+	if err := configFetchConfig(ctx, c, owner, "", configFile, config.OrgLevel, oc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	orc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.OrgRepoLevel, orc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgRepoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	rc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.RepoLevel, rc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "repoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	return oc, orc, rc
+}
+
+func mergeConfig(oc *OrgConfig, orc, rc *RepoConfig, repo string) *mergedConfig {
+	mc := &mergedConfig{
+		Action:             oc.Action,
+		Languages:          oc.Languages,
+		MaxAnalysisAgeDays: oc.MaxAnalysisAgeDays,
+		AllowWorkflow:      oc.AllowWorkflow,
+	}
+	mc = mergeInRepoConfig(mc, orc, repo)
+
+	if !oc.OptConfig.DisableRepoOverride {
+		mc = mergeInRepoConfig(mc, rc, repo)
+	}
+	return mc
+}
+
+func mergeInRepoConfig(mc *mergedConfig, rc *RepoConfig, repo string) *mergedConfig {
+	if rc.Action != nil {
+		mc.Action = *rc.Action
+	}
+	if rc.Languages != nil {
+		mc.Languages = rc.Languages
+	}
+	if rc.MaxAnalysisAgeDays != nil {
+		mc.MaxAnalysisAgeDays = *rc.MaxAnalysisAgeDays
+	}
+	if rc.AllowWorkflow != nil {
+		mc.AllowWorkflow = *rc.AllowWorkflow
+	}
+	return mc
+}
@@ -0,0 +1,378 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codescanning
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/configtest"
+	"github.com/ossf/allstar/pkg/policies/workflowfiles"
+)
+
+var listAnalysesForRepo func(context.Context, string, string,
+	*github.AnalysesListOptions) ([]*github.ScanningAnalysis, *github.Response, error)
+
+type mockCodeScanning struct{}
+
+func (m mockCodeScanning) ListAnalysesForRepo(ctx context.Context, o, r string,
+	opts *github.AnalysesListOptions) ([]*github.ScanningAnalysis, *github.Response, error) {
+	return listAnalysesForRepo(ctx, o, r, opts)
+}
+
+const codeQLWorkflow = `name: CodeQL
+on: [push]
+jobs:
+  analyze:
+    strategy:
+      matrix:
+        language: [ 'go', 'python' ]
+    steps:
+    - uses: github/codeql-action/init@v3
+      with:
+        languages: ${{ matrix.language }}
+    - uses: github/codeql-action/analyze@v3
+`
+
+func TestConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Org       OrgConfig
+		OrgRepo   RepoConfig
+		Repo      RepoConfig
+		ExpAction string
+		Exp       mergedConfig
+	}{
+		{
+			Name: "OrgOnly",
+			Org: OrgConfig{
+				Action:             "issue",
+				Languages:          []string{"go"},
+				MaxAnalysisAgeDays: 30,
+				AllowWorkflow:      true,
+			},
+			OrgRepo:   RepoConfig{},
+			Repo:      RepoConfig{},
+			ExpAction: "issue",
+			Exp: mergedConfig{
+				Action:             "issue",
+				Languages:          []string{"go"},
+				MaxAnalysisAgeDays: 30,
+				AllowWorkflow:      true,
+			},
+		},
+		{
+			Name: "OrgRepoOverOrg",
+			Org: OrgConfig{
+				Action:             "issue",
+				Languages:          []string{"go"},
+				MaxAnalysisAgeDays: 30,
+			},
+			OrgRepo: RepoConfig{
+				Action:             github.String("log"),
+				Languages:          []string{"python"},
+				MaxAnalysisAgeDays: github.Int(7),
+			},
+			Repo:      RepoConfig{},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action:             "log",
+				Languages:          []string{"python"},
+				MaxAnalysisAgeDays: 7,
+			},
+		},
+		{
+			Name: "RepoOverAllOrg",
+			Org: OrgConfig{
+				Action:    "issue",
+				Languages: []string{"go"},
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action:        github.String("email"),
+				Languages:     []string{"java-kotlin"},
+				AllowWorkflow: github.Bool(true),
+			},
+			ExpAction: "email",
+			Exp: mergedConfig{
+				Action:        "email",
+				Languages:     []string{"java-kotlin"},
+				AllowWorkflow: true,
+			},
+		},
+		{
+			Name: "RepoDisallowed",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					DisableRepoOverride: true,
+				},
+				Action:    "issue",
+				Languages: []string{"go"},
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action:    github.String("email"),
+				Languages: []string{"java-kotlin"},
+			},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action:    "log",
+				Languages: []string{"go"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				switch ol {
+				case config.RepoLevel:
+					rc := out.(*RepoConfig)
+					*rc = test.Repo
+				case config.OrgRepoLevel:
+					orc := out.(*RepoConfig)
+					*orc = test.OrgRepo
+				case config.OrgLevel:
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+
+			cs := CodeScanning(true)
+			ctx := context.Background()
+
+			action := cs.GetAction(ctx, nil, "", "thisrepo")
+			if action != test.ExpAction {
+				t.Errorf("Unexpected results. want %s, got %s", test.ExpAction, action)
+			}
+
+			oc, orc, rc := getConfig(ctx, nil, "", "thisrepo")
+			mc := mergeConfig(oc, orc, rc, "thisrepo")
+			if diff := cmp.Diff(&test.Exp, mc); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	recent := now.Add(-24 * time.Hour)
+	old := now.Add(-60 * 24 * time.Hour)
+	analysis := func(category, env, e string, at time.Time) *github.ScanningAnalysis {
+		return &github.ScanningAnalysis{
+			Category:    &category,
+			Environment: &env,
+			Error:       &e,
+			CreatedAt:   &github.Timestamp{Time: at},
+			Tool:        &github.Tool{Name: github.String("CodeQL")},
+		}
+	}
+
+	tests := []struct {
+		Name         string
+		Org          OrgConfig
+		Analyses     []*github.ScanningAnalysis
+		AnalysesCode int
+		Workflows    []workflowfiles.File
+		ExpPass      bool
+		ExpDetails   details
+	}{
+		{
+			Name: "AnyRecentAnalysis",
+			Org:  OrgConfig{MaxAnalysisAgeDays: 30},
+			Analyses: []*github.ScanningAnalysis{
+				analysis("/language:go", "", "", recent),
+			},
+			ExpPass: true,
+			ExpDetails: details{
+				LastAnalysis: map[string]time.Time{"go": recent},
+			},
+		},
+		{
+			Name: "AnalysisTooOld",
+			Org:  OrgConfig{MaxAnalysisAgeDays: 30},
+			Analyses: []*github.ScanningAnalysis{
+				analysis("/language:go", "", "", old),
+			},
+			ExpPass: false,
+			ExpDetails: details{
+				LastAnalysis:     map[string]time.Time{"go": old},
+				MissingLanguages: []string{"any"},
+			},
+		},
+		{
+			Name:         "NoAnalysesNotFound",
+			Org:          OrgConfig{MaxAnalysisAgeDays: 30},
+			AnalysesCode: http.StatusNotFound,
+			ExpPass:      false,
+			ExpDetails: details{
+				LastAnalysis:     map[string]time.Time{},
+				MissingLanguages: []string{"any"},
+			},
+		},
+		{
+			Name: "FailedAnalysisIgnored",
+			Org:  OrgConfig{MaxAnalysisAgeDays: 30},
+			Analyses: []*github.ScanningAnalysis{
+				analysis("/language:go", "", "failed", recent),
+			},
+			ExpPass: false,
+			ExpDetails: details{
+				LastAnalysis:     map[string]time.Time{},
+				MissingLanguages: []string{"any"},
+			},
+		},
+		{
+			Name: "PerLanguage",
+			Org: OrgConfig{
+				Languages:          []string{"go", "Python", "ruby"},
+				MaxAnalysisAgeDays: 30,
+			},
+			Analyses: []*github.ScanningAnalysis{
+				analysis("", `{"language":"go"}`, "", recent),
+				analysis("/language:python", "", "", old),
+				analysis("/language:ruby", "", "", recent),
+			},
+			ExpPass: false,
+			ExpDetails: details{
+				LastAnalysis: map[string]time.Time{
+					"go":     recent,
+					"python": old,
+					"ruby":   recent,
+				},
+				MissingLanguages: []string{"Python"},
+			},
+		},
+		{
+			Name: "WorkflowCoversLanguages",
+			Org: OrgConfig{
+				Languages:          []string{"go", "python"},
+				MaxAnalysisAgeDays: 30,
+				AllowWorkflow:      true,
+			},
+			AnalysesCode: http.StatusForbidden,
+			Workflows: []workflowfiles.File{
+				{Path: ".github/workflows/build.yml", Content: "name: build\n"},
+				{Path: ".github/workflows/codeql.yml", Content: codeQLWorkflow},
+			},
+			ExpPass: true,
+			ExpDetails: details{
+				LastAnalysis: map[string]time.Time{},
+				Workflows:    []string{"codeql.yml"},
+			},
+		},
+		{
+			Name: "WorkflowMissingLanguage",
+			Org: OrgConfig{
+				Languages:          []string{"go", "java-kotlin"},
+				MaxAnalysisAgeDays: 30,
+				AllowWorkflow:      true,
+			},
+			AnalysesCode: http.StatusNotFound,
+			Workflows: []workflowfiles.File{
+				{Path: ".github/workflows/codeql.yml", Content: codeQLWorkflow},
+			},
+			ExpPass: false,
+			ExpDetails: details{
+				LastAnalysis:     map[string]time.Time{},
+				Workflows:        []string{"codeql.yml"},
+				MissingLanguages: []string{"java-kotlin"},
+			},
+		},
+		{
+			Name: "WorkflowNotAllowed",
+			Org: OrgConfig{
+				MaxAnalysisAgeDays: 30,
+			},
+			AnalysesCode: http.StatusNotFound,
+			Workflows: []workflowfiles.File{
+				{Path: ".github/workflows/codeql.yml", Content: codeQLWorkflow},
+			},
+			ExpPass: false,
+			ExpDetails: details{
+				LastAnalysis:     map[string]time.Time{},
+				MissingLanguages: []string{"any"},
+			},
+		},
+	}
+
+	configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+		c *github.Client, owner, repo string) (bool, error) {
+		return true, nil
+	}
+	timeNow = func() time.Time { return now }
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.OrgLevel {
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+			listAnalysesForRepo = func(context.Context, string, string,
+				*github.AnalysesListOptions) ([]*github.ScanningAnalysis, *github.Response, error) {
+				if test.AnalysesCode != 0 {
+					return nil, &github.Response{
+						Response: &http.Response{StatusCode: test.AnalysesCode},
+					}, errors.New("error")
+				}
+				return test.Analyses, nil, nil
+			}
+			listWorkflows = func(context.Context, *github.Client, string, string) ([]workflowfiles.File, error) {
+				return test.Workflows, nil
+			}
+
+			res, err := check(context.Background(), mockCodeScanning{}, nil, "", "thisrepo")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Pass != test.ExpPass {
+				t.Errorf("Unexpected pass: %v, notify text: %v", res.Pass, res.NotifyText)
+			}
+			if diff := cmp.Diff(test.ExpDetails, res.Details); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGeneratedPrecedence(t *testing.T) {
+	configtest.TestPrecedence(t, configtest.Precedence{
+		Org:   OrgConfig{},
+		Repo:  RepoConfig{},
+		Fetch: &configFetchConfig,
+		Merge: func() interface{} {
+			ctx := context.Background()
+			oc, orc, rc := getConfig(ctx, nil, "", configtest.Repo)
+			return mergeConfig(oc, orc, rc, configtest.Repo)
+		},
+	})
+}
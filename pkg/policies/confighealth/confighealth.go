@@ -0,0 +1,222 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package confighealth implements the Config Health policy, which reports
+// unknown fields and parse errors in the Allstar config files of a repository.
+package confighealth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/operator"
+	"github.com/ossf/allstar/pkg/policydef"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+const configFile = "config_health.yaml"
+const polName = "Config Health"
+
+const notifyText = `Allstar config files in this repository have problems. Unknown fields are
+ignored, and files that can not be parsed are ignored in favor of defaults, so
+these settings are not being applied:
+
+%v
+Correct the field names, see the config definitions for each policy in the
+Allstar documentation: https://github.com/ossf/allstar#policies`
+
+// OrgConfig is the org-level config definition for Config Health.
+type OrgConfig struct {
+	// OptConfig is the standard org-level opt in/out config, RepoOverride
+	// applies to all config.
+	OptConfig config.OrgOptConfig `json:"optConfig"`
+
+	// Action defines which action to take, default log, other: issue...
+	Action string `json:"action"`
+}
+
+// RepoConfig is the repo-level config for Config Health.
+type RepoConfig struct {
+	// OptConfig is the standard repo-level opt in/out config.
+	OptConfig config.RepoOptConfig `json:"optConfig"`
+
+	// Action overrides the same setting in org-level, only if present.
+	Action *string `json:"action"`
+}
+
+type mergedConfig struct {
+	Action string
+}
+
+type details struct {
+	Problems []string
+}
+
+var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+var configIsEnabled func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig, c *github.Client, owner, repo string) (bool, error)
+var configGetProblems func(string, string) []config.Problem
+
+func init() {
+	configFetchConfig = config.FetchConfig
+	configIsEnabled = config.IsEnabled
+	configGetProblems = config.GetProblems
+}
+
+// ConfigHealth is the Config Health policy object, implements
+// policydef.Policy.
+type ConfigHealth bool
+
+// NewConfigHealth returns a new Config Health policy.
+func NewConfigHealth() policydef.Policy {
+	var c ConfigHealth
+	return c
+}
+
+// Name returns the name of this policy, implementing policydef.Policy.Name()
+func (c ConfigHealth) Name() string {
+	return polName
+}
+
+// Check whether this policy is enabled or not
+func (c ConfigHealth) IsEnabled(ctx context.Context, cl *github.Client, owner, repo string) (bool, error) {
+	oc, orc, rc := getConfig(ctx, cl, owner, repo)
+	return configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, cl, owner, repo)
+}
+
+// Check performs the policy check for Config Health policy based on the
+// configuration stored in the org/repo, implementing policydef.Policy.Check()
+//
+// Problems are recorded by the config package as config files are fetched,
+// only when operator.StrictConfig is set. This policy is run last, so the
+// config of all other policies for the repo has been fetched. Problems in
+// org-level config files are reported on the org config repository.
+func (c ConfigHealth) Check(ctx context.Context, cl *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	oc, orc, rc := getConfig(ctx, cl, owner, repo)
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, cl, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Bool("enabled", enabled).
+		Bool("strictConfig", operator.StrictConfig).
+		Msg("Check repo enabled")
+
+	var ps []string
+	var list string
+	for _, p := range configGetProblems(owner, repo) {
+		ps = append(ps, p.String())
+		list += fmt.Sprintf("- %v\n", p)
+	}
+	if len(ps) == 0 {
+		return &policydef.Result{
+			Enabled:    enabled,
+			Pass:       true,
+			NotifyText: "",
+			Details:    details{},
+		}, nil
+	}
+	return &policydef.Result{
+		Enabled:    enabled,
+		Pass:       false,
+		NotifyText: fmt.Sprintf(notifyText, list),
+		Details: details{
+			Problems: ps,
+		},
+	}, nil
+}
+
+// Fix implementing policydef.Policy.Fix(). Not supported, the config files
+// must be corrected by hand.
+func (c ConfigHealth) Fix(ctx context.Context, cl *github.Client, owner, repo string) error {
+	log.Warn().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Msg("Action fix is configured, but not implemented.")
+	return nil
+}
+
+// GetAction returns the configured action from Config Health policy's
+// configuration stored in the org-level repo, default log. Implementing
+// policydef.Policy.GetAction()
+func (c ConfigHealth) GetAction(ctx context.Context, cl *github.Client, owner, repo string) string {
+	oc, orc, rc := getConfig(ctx, cl, owner, repo)
+	mc := mergeConfig(oc, orc, rc, repo)
+	return mc.Action
+}
+
+func getConfig(ctx context.Context, c *github.Client, owner, repo string) (*OrgConfig, *RepoConfig, *RepoConfig) {
+	oc := &OrgConfig{ // Fill out non-zero defaults
+		Action: "log",
+	}
+	if err := configFetchConfig(ctx, c, owner, "", configFile, config.OrgLevel, oc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Bool("orgLevel", true).
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	orc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.OrgRepoLevel, orc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgRepoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	rc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.RepoLevel, rc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Bool("orgLevel", false).
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	return oc, orc, rc
+}
+
+func mergeConfig(oc *OrgConfig, orc, rc *RepoConfig, repo string) *mergedConfig {
+	mc := &mergedConfig{
+		Action: oc.Action,
+	}
+	mc = mergeInRepoConfig(mc, orc, repo)
+
+	if !oc.OptConfig.DisableRepoOverride {
+		mc = mergeInRepoConfig(mc, rc, repo)
+	}
+	return mc
+}
+
+func mergeInRepoConfig(mc *mergedConfig, rc *RepoConfig, repo string) *mergedConfig {
+	if rc.Action != nil {
+		mc.Action = *rc.Action
+	}
+	return mc
+}
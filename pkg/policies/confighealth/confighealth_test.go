@@ -0,0 +1,196 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package confighealth
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/configtest"
+)
+
+func TestConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Org       OrgConfig
+		OrgRepo   RepoConfig
+		Repo      RepoConfig
+		ExpAction string
+		Exp       mergedConfig
+	}{
+		{
+			Name: "OrgOnly",
+			Org: OrgConfig{
+				Action: "issue",
+			},
+			OrgRepo:   RepoConfig{},
+			Repo:      RepoConfig{},
+			ExpAction: "issue",
+			Exp: mergedConfig{
+				Action: "issue",
+			},
+		},
+		{
+			Name: "OrgRepoOverOrg",
+			Org: OrgConfig{
+				Action: "issue",
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo:      RepoConfig{},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action: "log",
+			},
+		},
+		{
+			Name: "RepoDisallowed",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					DisableRepoOverride: true,
+				},
+				Action: "issue",
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action: github.String("email"),
+			},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action: "log",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				switch ol {
+				case config.RepoLevel:
+					rc := out.(*RepoConfig)
+					*rc = test.Repo
+				case config.OrgRepoLevel:
+					orc := out.(*RepoConfig)
+					*orc = test.OrgRepo
+				case config.OrgLevel:
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+
+			ch := ConfigHealth(true)
+			ctx := context.Background()
+
+			action := ch.GetAction(ctx, nil, "", "thisrepo")
+			if action != test.ExpAction {
+				t.Errorf("Unexpected results. want %s, got %s", test.ExpAction, action)
+			}
+
+			oc, orc, rc := getConfig(ctx, nil, "", "thisrepo")
+			mc := mergeConfig(oc, orc, rc, "thisrepo")
+			if diff := cmp.Diff(&test.Exp, mc); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Problems   []config.Problem
+		ExpPass    bool
+		ExpNotify  string
+		ExpDetails details
+	}{
+		{
+			Name:       "NoProblems",
+			ExpPass:    true,
+			ExpDetails: details{},
+		},
+		{
+			Name: "Problems",
+			Problems: []config.Problem{
+				{Repo: "thisrepo", Path: ".allstar/branch_protection.yaml", Field: "aprovalCount", Suggestion: "approvalCount"},
+				{Repo: "thisrepo", Path: ".allstar/security.yaml", Err: "yaml: line 2: mapping values are not allowed in this context"},
+			},
+			ExpPass: false,
+			ExpNotify: "- `.allstar/branch_protection.yaml`: unknown field `aprovalCount`, did you mean `approvalCount`?\n" +
+				"- `.allstar/security.yaml`: could not be parsed, defaults are used: yaml: line 2: mapping values are not allowed in this context\n",
+			ExpDetails: details{
+				Problems: []string{
+					"`.allstar/branch_protection.yaml`: unknown field `aprovalCount`, did you mean `approvalCount`?",
+					"`.allstar/security.yaml`: could not be parsed, defaults are used: yaml: line 2: mapping values are not allowed in this context",
+				},
+			},
+		},
+	}
+
+	configFetchConfig = func(ctx context.Context, c *github.Client,
+		owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+		return nil
+	}
+	configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+		c *github.Client, owner, repo string) (bool, error) {
+		return true, nil
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configGetProblems = func(owner, repo string) []config.Problem {
+				if owner != "thisorg" || repo != "thisrepo" {
+					t.Errorf("Unexpected repo: %v/%v", owner, repo)
+				}
+				return test.Problems
+			}
+
+			res, err := ConfigHealth(true).Check(context.Background(), nil, "thisorg", "thisrepo")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Pass != test.ExpPass {
+				t.Errorf("Unexpected pass: %v", res.Pass)
+			}
+			if test.ExpNotify != "" && !strings.Contains(res.NotifyText, test.ExpNotify) {
+				t.Errorf("Expected notify text to contain:\n%v\ngot:\n%v", test.ExpNotify, res.NotifyText)
+			}
+			if diff := cmp.Diff(test.ExpDetails, res.Details); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGeneratedPrecedence(t *testing.T) {
+	configtest.TestPrecedence(t, configtest.Precedence{
+		Org:   OrgConfig{},
+		Repo:  RepoConfig{},
+		Fetch: &configFetchConfig,
+		Merge: func() interface{} {
+			ctx := context.Background()
+			oc, orc, rc := getConfig(ctx, nil, "", configtest.Repo)
+			return mergeConfig(oc, orc, rc, configtest.Repo)
+		},
+	})
+}
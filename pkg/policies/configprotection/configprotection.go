@@ -0,0 +1,452 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package configprotection implements the Config Protection policy. It checks
+// that changes to the org-level Allstar config repository are reviewed, as
+// anyone who can push to it controls enforcement across the organization.
+package configprotection
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/ossf/allstar/pkg/codeowners"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/policydef"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+const configFile = "config_protection.yaml"
+const polName = "Config Protection"
+
+const notifyText = `This policy requires changes to the Allstar config repository of the organization to be reviewed. Anyone who can push to it can disable or change the enforcement of every policy.
+
+To fix this, protect the default branch of the config repository, requiring pull request reviews, and add a CODEOWNERS file assigning owners to the Allstar config files. See https://docs.github.com/en/repositories/configuring-branches-and-merges-in-your-repository/managing-protected-branches/managing-a-branch-protection-rule and https://docs.github.com/en/repositories/managing-your-repositorys-settings-and-features/customizing-your-repository/about-code-owners`
+
+// OrgConfig is the org-level config definition for Config Protection.
+type OrgConfig struct {
+	// OptConfig is the standard org-level opt in/out config, RepoOverride
+	// applies to all config. The config repository is checked without being
+	// listed in OptInRepos, it can only be opted out with the opt out
+	// strategy.
+	OptConfig config.OrgOptConfig `json:"optConfig"`
+
+	// Action defines which action to take, default log, other: issue...
+	Action string `json:"action"`
+
+	// MinReviews is the minimum number of approving reviews required on the
+	// default branch of the config repository, default 1.
+	MinReviews int `json:"minReviews"`
+
+	// RequireCodeOwnerReviews : set to true to require reviews from code
+	// owners on the default branch of the config repository, default true.
+	RequireCodeOwnerReviews bool `json:"requireCodeOwnerReviews"`
+
+	// RequireCODEOWNERS : set to true to require CODEOWNERS in the config
+	// repository to assign owners to every Allstar config file, default true.
+	RequireCODEOWNERS bool `json:"requireCODEOWNERS"`
+}
+
+// RepoConfig is the repo-level config for Config Protection.
+type RepoConfig struct {
+	// OptConfig is the standard repo-level opt in/out config.
+	OptConfig config.RepoOptConfig `json:"optConfig"`
+
+	// Action overrides the same setting in org-level, only if present.
+	Action *string `json:"action"`
+}
+
+type mergedConfig struct {
+	Action                  string
+	MinReviews              int
+	RequireCodeOwnerReviews bool
+	RequireCODEOWNERS       bool
+}
+
+type details struct {
+	ConfigRepo       string
+	Branch           string
+	Protected        bool
+	RequiredReviews  int
+	CodeOwnerReviews bool
+	CodeownersPath   string
+	// Unowned are the config files not assigned owners by CODEOWNERS.
+	Unowned []string
+}
+
+var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+
+var configIsEnabled func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig, c *github.Client, owner, repo string) (bool, error)
+
+var configGetOrgConfigLocation func(context.Context, *github.Client, string) (string, string, error)
+
+func init() {
+	configFetchConfig = config.FetchConfig
+	configIsEnabled = config.IsEnabled
+	configGetOrgConfigLocation = config.GetOrgConfigLocation
+}
+
+type repositories interface {
+	Get(context.Context, string, string) (*github.Repository,
+		*github.Response, error)
+	GetBranchProtection(context.Context, string, string, string) (
+		*github.Protection, *github.Response, error)
+	GetContents(context.Context, string, string, string,
+		*github.RepositoryContentGetOptions) (*github.RepositoryContent,
+		[]*github.RepositoryContent, *github.Response, error)
+}
+
+// ConfigProtection is the Config Protection policy object, implements
+// policydef.Policy.
+type ConfigProtection bool
+
+// NewConfigProtection returns a new Config Protection policy.
+func NewConfigProtection() policydef.Policy {
+	var p ConfigProtection
+	return p
+}
+
+// Name returns the name of this policy, implementing policydef.Policy.Name()
+func (p ConfigProtection) Name() string {
+	return polName
+}
+
+// Check whether this policy is enabled or not
+func (p ConfigProtection) IsEnabled(ctx context.Context, c *github.Client, owner, repo string) (bool, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	enabled, _, err := isEnabled(ctx, oc, orc, rc, c, owner, repo)
+	return enabled, err
+}
+
+// isEnabled returns whether the policy is enabled on repo, and the directory
+// of the config files within it. Only the org config repository is checked,
+// even if it is not opted in, unless it is opted out with the opt out
+// strategy.
+func isEnabled(ctx context.Context, oc *OrgConfig, orc, rc *RepoConfig, c *github.Client,
+	owner, repo string) (bool, string, error) {
+	cr, dir, err := configGetOrgConfigLocation(ctx, c, owner)
+	if err != nil {
+		return false, "", err
+	}
+	if cr == "" || !strings.EqualFold(cr, repo) {
+		return false, "", nil
+	}
+	if !oc.OptConfig.OptOutStrategy {
+		return true, dir, nil
+	}
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+	return enabled, dir, err
+}
+
+// Check performs the policy check for Config Protection based on the
+// configuration stored in the org/repo, implementing policydef.Policy.Check()
+func (p ConfigProtection) Check(ctx context.Context, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	return check(ctx, c.Repositories, c, owner, repo)
+}
+
+func check(ctx context.Context, rep repositories, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	enabled, dir, err := isEnabled(ctx, oc, orc, rc, c, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Bool("enabled", enabled).
+		Msg("Check repo enabled")
+	if !enabled {
+		return &policydef.Result{
+			Enabled:    false,
+			Pass:       true,
+			NotifyText: "",
+			Details:    details{},
+		}, nil
+	}
+
+	mc := mergeConfig(oc, orc, rc, repo)
+
+	r, _, err := rep.Get(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	d := details{
+		ConfigRepo: repo,
+		Branch:     r.GetDefaultBranch(),
+	}
+	var problems []string
+
+	bp, rsp, err := rep.GetBranchProtection(ctx, owner, repo, d.Branch)
+	if err != nil && !(rsp != nil && rsp.StatusCode == http.StatusNotFound) {
+		return nil, err
+	}
+	if err == nil {
+		d.Protected = true
+		if prr := bp.GetRequiredPullRequestReviews(); prr != nil {
+			d.RequiredReviews = prr.RequiredApprovingReviewCount
+			d.CodeOwnerReviews = prr.RequireCodeOwnerReviews
+		}
+	}
+	if !d.Protected {
+		problems = append(problems, fmt.Sprintf("The default branch `%v` is not protected.", d.Branch))
+	} else {
+		if d.RequiredReviews < mc.MinReviews {
+			problems = append(problems, fmt.Sprintf("The default branch `%v` requires %v approving reviews, at least %v are required.",
+				d.Branch, d.RequiredReviews, mc.MinReviews))
+		}
+		if mc.RequireCodeOwnerReviews && !d.CodeOwnerReviews {
+			problems = append(problems, fmt.Sprintf("The default branch `%v` does not require reviews from code owners.", d.Branch))
+		}
+	}
+
+	if mc.RequireCODEOWNERS {
+		co, rules, err := getCodeowners(ctx, rep, owner, repo)
+		if err != nil {
+			return nil, err
+		}
+		d.CodeownersPath = co
+		if co == "" {
+			problems = append(problems, "There is no CODEOWNERS file.")
+		} else {
+			files, err := getConfigFiles(ctx, rep, owner, repo, dir)
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range files {
+				if !owned(rules, f) {
+					d.Unowned = append(d.Unowned, f)
+				}
+			}
+			if len(d.Unowned) > 0 {
+				problems = append(problems, fmt.Sprintf("`%v` does not assign owners to: `%v`.",
+					co, strings.Join(d.Unowned, "`, `")))
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return &policydef.Result{
+			Enabled:    enabled,
+			Pass:       true,
+			NotifyText: "",
+			Details:    d,
+		}, nil
+	}
+	text := "Changes to the Allstar config repository are not protected:\n"
+	for _, p := range problems {
+		text = text + fmt.Sprintf("- %v\n", p)
+	}
+	return &policydef.Result{
+		Enabled:    enabled,
+		Pass:       false,
+		NotifyText: text + "\n" + notifyText,
+		Details:    d,
+	}, nil
+}
+
+// getCodeowners returns the path and rules of the CODEOWNERS file that GitHub
+// uses for repo, or an empty path if there is none.
+func getCodeowners(ctx context.Context, rep repositories, owner, repo string) (string, []codeowners.Rule, error) {
+	for _, p := range codeowners.Paths {
+		fc, _, rsp, err := rep.GetContents(ctx, owner, repo, p, nil)
+		if err != nil {
+			if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+				continue
+			}
+			return "", nil, err
+		}
+		if fc == nil {
+			continue
+		}
+		content, err := fc.GetContent()
+		if err != nil {
+			return "", nil, err
+		}
+		return p, codeowners.Parse(content), nil
+	}
+	return "", nil, nil
+}
+
+// owned returns whether the last rule matching file assigns owners, as only
+// the last match applies.
+func owned(rules []codeowners.Rule, file string) bool {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if matchPattern(rules[i].Pattern, file) {
+			return len(rules[i].Owners) > 0
+		}
+	}
+	return false
+}
+
+// matchPattern matches a CODEOWNERS pattern, which follows gitignore rules,
+// to a file path. A pattern matching a directory matches all files in it.
+func matchPattern(pattern, file string) bool {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	p := strings.Trim(pattern, "/")
+	if p == "" {
+		return false
+	}
+	// Patterns with a slash, other than a trailing one, are relative to the
+	// root, others match at any depth.
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	ps := strings.Split(p, "/")
+	fs := strings.Split(file, "/")
+	for start := 0; start < len(fs); start++ {
+		if matchSegments(ps, fs[start:], dirOnly) {
+			return true
+		}
+		if anchored {
+			break
+		}
+	}
+	return false
+}
+
+// matchSegments matches pattern segments to a prefix of path segments. A
+// match of the whole path is only accepted if !dirOnly.
+func matchSegments(ps, fs []string, dirOnly bool) bool {
+	if len(ps) == 0 {
+		return len(fs) > 0 || !dirOnly
+	}
+	if ps[0] == "**" {
+		for i := 0; i <= len(fs); i++ {
+			if matchSegments(ps[1:], fs[i:], dirOnly) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(fs) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(ps[0], fs[0]); !ok {
+		return false
+	}
+	return matchSegments(ps[1:], fs[1:], dirOnly)
+}
+
+// getConfigFiles lists the Allstar config files in dir of repo. If there are
+// none, the Allstar config file it would hold is returned, so that the
+// location is still checked.
+func getConfigFiles(ctx context.Context, rep repositories, owner, repo, dir string) ([]string, error) {
+	var files []string
+	_, dc, rsp, err := rep.GetContents(ctx, owner, repo, dir, nil)
+	if err != nil && !(rsp != nil && rsp.StatusCode == http.StatusNotFound) {
+		return nil, err
+	}
+	for _, c := range dc {
+		if c.GetType() != "file" {
+			continue
+		}
+		if ext := path.Ext(c.GetName()); ext == ".yaml" || ext == ".yml" {
+			files = append(files, path.Join(dir, c.GetName()))
+		}
+	}
+	if len(files) == 0 {
+		files = append(files, path.Join(dir, "allstar.yaml"))
+	}
+	return files, nil
+}
+
+// Fix implementing policydef.Policy.Fix(). Not supported, protection of the
+// config repository should be changed deliberately by its administrators.
+func (p ConfigProtection) Fix(ctx context.Context, c *github.Client, owner, repo string) error {
+	log.Warn().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Msg("Action fix is configured, but not implemented.")
+	return nil
+}
+
+// GetAction returns the configured action from Config Protection's
+// configuration stored in the org-level repo, default log. Implementing
+// policydef.Policy.GetAction()
+func (p ConfigProtection) GetAction(ctx context.Context, c *github.Client, owner, repo string) string {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	mc := mergeConfig(oc, orc, rc, repo)
+	return mc.Action
+}
+
+func getConfig(ctx context.Context, c *github.Client, owner, repo string) (*OrgConfig, *RepoConfig, *RepoConfig) {
+	oc := &OrgConfig{ // Fill out non-zero defaults
+		Action:                  "log",
+		MinReviews:              1,
+		RequireCodeOwnerReviews: true,
+		RequireCODEOWNERS:       true,
+	}
+	if err := configFetchConfig(ctx, c, owner, "", configFile, config.OrgLevel, oc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Bool("orgLevel", true).
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	orc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.OrgRepoLevel, orc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgRepoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	rc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.RepoLevel, rc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Bool("orgLevel", false).
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	return oc, orc, rc
+}
+
+func mergeConfig(oc *OrgConfig, orc, rc *RepoConfig, repo string) *mergedConfig {
+	mc := &mergedConfig{
+		Action:                  oc.Action,
+		MinReviews:              oc.MinReviews,
+		RequireCodeOwnerReviews: oc.RequireCodeOwnerReviews,
+		RequireCODEOWNERS:       oc.RequireCODEOWNERS,
+	}
+	mc = mergeInRepoConfig(mc, orc, repo)
+
+	if !oc.OptConfig.DisableRepoOverride {
+		mc = mergeInRepoConfig(mc, rc, repo)
+	}
+	return mc
+}
+
+func mergeInRepoConfig(mc *mergedConfig, rc *RepoConfig, repo string) *mergedConfig {
+	if rc.Action != nil {
+		mc.Action = *rc.Action
+	}
+	return mc
+}
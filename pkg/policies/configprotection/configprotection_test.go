@@ -0,0 +1,429 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configprotection
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/configtest"
+)
+
+var getBranchProtection func(context.Context, string, string, string) (
+	*github.Protection, *github.Response, error)
+var getContents func(context.Context, string, string, string,
+	*github.RepositoryContentGetOptions) (*github.RepositoryContent,
+	[]*github.RepositoryContent, *github.Response, error)
+
+type mockRepos struct{}
+
+func (m mockRepos) Get(ctx context.Context, o, r string) (*github.Repository,
+	*github.Response, error) {
+	return &github.Repository{DefaultBranch: github.String("main")}, nil, nil
+}
+
+func (m mockRepos) GetBranchProtection(ctx context.Context, o, r, b string) (
+	*github.Protection, *github.Response, error) {
+	return getBranchProtection(ctx, o, r, b)
+}
+
+func (m mockRepos) GetContents(ctx context.Context, o, r, p string,
+	opts *github.RepositoryContentGetOptions) (*github.RepositoryContent,
+	[]*github.RepositoryContent, *github.Response, error) {
+	return getContents(ctx, o, r, p, opts)
+}
+
+var notFound = &github.Response{Response: &http.Response{StatusCode: http.StatusNotFound}}
+
+func TestConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Org       OrgConfig
+		OrgRepo   RepoConfig
+		Repo      RepoConfig
+		ExpAction string
+		Exp       mergedConfig
+	}{
+		{
+			Name: "OrgOnly",
+			Org: OrgConfig{
+				Action:     "issue",
+				MinReviews: 2,
+			},
+			OrgRepo:   RepoConfig{},
+			Repo:      RepoConfig{},
+			ExpAction: "issue",
+			Exp: mergedConfig{
+				Action:     "issue",
+				MinReviews: 2,
+			},
+		},
+		{
+			Name: "OrgRepoOverOrg",
+			Org: OrgConfig{
+				Action: "issue",
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo:      RepoConfig{},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action: "log",
+			},
+		},
+		{
+			Name: "RepoOverAllOrg",
+			Org: OrgConfig{
+				Action:            "issue",
+				RequireCODEOWNERS: true,
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action: github.String("email"),
+			},
+			ExpAction: "email",
+			Exp: mergedConfig{
+				Action:            "email",
+				RequireCODEOWNERS: true,
+			},
+		},
+		{
+			Name: "RepoDisallowed",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					DisableRepoOverride: true,
+				},
+				Action: "issue",
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action: github.String("email"),
+			},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action: "log",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				switch ol {
+				case config.RepoLevel:
+					rc := out.(*RepoConfig)
+					*rc = test.Repo
+				case config.OrgRepoLevel:
+					orc := out.(*RepoConfig)
+					*orc = test.OrgRepo
+				case config.OrgLevel:
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+
+			p := ConfigProtection(true)
+			ctx := context.Background()
+
+			action := p.GetAction(ctx, nil, "", "thisrepo")
+			if action != test.ExpAction {
+				t.Errorf("Unexpected results. want %s, got %s", test.ExpAction, action)
+			}
+
+			oc, orc, rc := getConfig(ctx, nil, "", "thisrepo")
+			mc := mergeConfig(oc, orc, rc, "thisrepo")
+			if diff := cmp.Diff(&test.Exp, mc); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestIsEnabled(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Repo       string
+		ConfigRepo string
+		OptOut     bool
+		RepoOptOut bool
+		Exp        bool
+	}{
+		{
+			Name:       "ConfigRepoNotListed",
+			Repo:       ".allstar",
+			ConfigRepo: ".allstar",
+			Exp:        true,
+		},
+		{
+			Name:       "OtherRepo",
+			Repo:       "thisrepo",
+			ConfigRepo: ".allstar",
+			Exp:        false,
+		},
+		{
+			Name: "NoConfigRepo",
+			Repo: ".github",
+			Exp:  false,
+		},
+		{
+			Name:       "OptOutStrategy",
+			Repo:       ".github",
+			ConfigRepo: ".github",
+			OptOut:     true,
+			Exp:        true,
+		},
+		{
+			Name:       "OptedOut",
+			Repo:       ".github",
+			ConfigRepo: ".github",
+			OptOut:     true,
+			RepoOptOut: true,
+			Exp:        false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.OrgLevel {
+					oc := out.(*OrgConfig)
+					oc.OptConfig.OptOutStrategy = test.OptOut
+				}
+				return nil
+			}
+			configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+				c *github.Client, owner, repo string) (bool, error) {
+				return !test.RepoOptOut, nil
+			}
+			configGetOrgConfigLocation = func(ctx context.Context, c *github.Client, owner string) (string, string, error) {
+				return test.ConfigRepo, "", nil
+			}
+			enabled, err := ConfigProtection(true).IsEnabled(context.Background(), nil, "", test.Repo)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if enabled != test.Exp {
+				t.Errorf("Expected enabled %v, got %v", test.Exp, enabled)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	protected := &github.Protection{
+		RequiredPullRequestReviews: &github.PullRequestReviewsEnforcement{
+			RequiredApprovingReviewCount: 1,
+			RequireCodeOwnerReviews:      true,
+		},
+	}
+	configDir := []*github.RepositoryContent{
+		{Type: github.String("file"), Name: github.String("allstar.yaml")},
+		{Type: github.String("file"), Name: github.String("branch_protection.yaml")},
+		{Type: github.String("file"), Name: github.String("README.md")},
+		{Type: github.String("dir"), Name: github.String("docs")},
+	}
+	tests := []struct {
+		Name       string
+		Dir        string
+		Protection *github.Protection
+		Codeowners string
+		Files      []*github.RepositoryContent
+		ExpPass    bool
+		ExpDetails details
+	}{
+		{
+			Name:       "Protected",
+			Protection: protected,
+			Codeowners: "* @acme/admins\n",
+			Files:      configDir,
+			ExpPass:    true,
+			ExpDetails: details{
+				ConfigRepo:       ".allstar",
+				Branch:           "main",
+				Protected:        true,
+				RequiredReviews:  1,
+				CodeOwnerReviews: true,
+				CodeownersPath:   ".github/CODEOWNERS",
+			},
+		},
+		{
+			Name:       "NotProtected",
+			Codeowners: "* @acme/admins\n",
+			Files:      configDir,
+			ExpPass:    false,
+			ExpDetails: details{
+				ConfigRepo:     ".allstar",
+				Branch:         "main",
+				CodeownersPath: ".github/CODEOWNERS",
+			},
+		},
+		{
+			Name: "NoCodeOwnerReviews",
+			Protection: &github.Protection{
+				RequiredPullRequestReviews: &github.PullRequestReviewsEnforcement{
+					RequiredApprovingReviewCount: 2,
+				},
+			},
+			Codeowners: "* @acme/admins\n",
+			Files:      configDir,
+			ExpPass:    false,
+			ExpDetails: details{
+				ConfigRepo:      ".allstar",
+				Branch:          "main",
+				Protected:       true,
+				RequiredReviews: 2,
+				CodeownersPath:  ".github/CODEOWNERS",
+			},
+		},
+		{
+			Name:       "NoCodeowners",
+			Protection: protected,
+			Files:      configDir,
+			ExpPass:    false,
+			ExpDetails: details{
+				ConfigRepo:       ".allstar",
+				Branch:           "main",
+				Protected:        true,
+				RequiredReviews:  1,
+				CodeOwnerReviews: true,
+			},
+		},
+		{
+			Name:       "Unowned",
+			Protection: protected,
+			Codeowners: "* @acme/admins\n/branch_protection.yaml\n",
+			Files:      configDir,
+			ExpPass:    false,
+			ExpDetails: details{
+				ConfigRepo:       ".allstar",
+				Branch:           "main",
+				Protected:        true,
+				RequiredReviews:  1,
+				CodeOwnerReviews: true,
+				CodeownersPath:   ".github/CODEOWNERS",
+				Unowned:          []string{"branch_protection.yaml"},
+			},
+		},
+		{
+			Name:       "GitHubRepoDir",
+			Dir:        "allstar",
+			Protection: protected,
+			Codeowners: "/workflow-templates/ @acme/devs\n/allstar/ @acme/admins\n",
+			ExpPass:    true,
+			ExpDetails: details{
+				ConfigRepo:       ".allstar",
+				Branch:           "main",
+				Protected:        true,
+				RequiredReviews:  1,
+				CodeOwnerReviews: true,
+				CodeownersPath:   ".github/CODEOWNERS",
+			},
+		},
+	}
+
+	configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+		c *github.Client, owner, repo string) (bool, error) {
+		return true, nil
+	}
+	configFetchConfig = func(ctx context.Context, c *github.Client,
+		owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+		return nil
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configGetOrgConfigLocation = func(ctx context.Context, c *github.Client, owner string) (string, string, error) {
+				return ".allstar", test.Dir, nil
+			}
+			getBranchProtection = func(ctx context.Context, o, r, b string) (
+				*github.Protection, *github.Response, error) {
+				if test.Protection == nil {
+					return nil, notFound, &github.ErrorResponse{Message: "Branch not protected"}
+				}
+				return test.Protection, nil, nil
+			}
+			getContents = func(ctx context.Context, o, r, p string,
+				opts *github.RepositoryContentGetOptions) (*github.RepositoryContent,
+				[]*github.RepositoryContent, *github.Response, error) {
+				if p == test.Dir {
+					return nil, test.Files, nil, nil
+				}
+				if p == ".github/CODEOWNERS" && test.Codeowners != "" {
+					return &github.RepositoryContent{Content: github.String(test.Codeowners)}, nil, nil, nil
+				}
+				return nil, nil, notFound, &github.ErrorResponse{Message: "Not Found"}
+			}
+			res, err := check(context.Background(), mockRepos{}, nil, "", ".allstar")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Pass != test.ExpPass {
+				t.Errorf("Expected pass %v, got %v: %v", test.ExpPass, res.Pass, res.NotifyText)
+			}
+			if diff := cmp.Diff(test.ExpDetails, res.Details); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		Pattern string
+		File    string
+		Exp     bool
+	}{
+		{"*", "allstar/allstar.yaml", true},
+		{"*.yaml", "allstar/allstar.yaml", true},
+		{"/allstar/", "allstar/allstar.yaml", true},
+		{"allstar/", "allstar/allstar.yaml", true},
+		{"allstar/", "allstar", false},
+		{"/allstar", "allstar/allstar.yaml", true},
+		{"/docs/", "allstar/allstar.yaml", false},
+		{"docs/*.yaml", "allstar/docs/a.yaml", false},
+		{"**/allstar.yaml", "allstar/allstar.yaml", true},
+		{"/allstar.yaml", "allstar/allstar.yaml", false},
+		{"allstar.yaml", "allstar/allstar.yaml", true},
+	}
+	for _, test := range tests {
+		if got := matchPattern(test.Pattern, test.File); got != test.Exp {
+			t.Errorf("matchPattern(%q, %q) = %v, want %v", test.Pattern, test.File, got, test.Exp)
+		}
+	}
+}
+
+func TestGeneratedPrecedence(t *testing.T) {
+	configtest.TestPrecedence(t, configtest.Precedence{
+		Org:   OrgConfig{},
+		Repo:  RepoConfig{},
+		Fetch: &configFetchConfig,
+		Merge: func() interface{} {
+			ctx := context.Background()
+			oc, orc, rc := getConfig(ctx, nil, "", configtest.Repo)
+			return mergeConfig(oc, orc, rc, configtest.Repo)
+		},
+	})
+}
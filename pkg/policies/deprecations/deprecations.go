@@ -0,0 +1,462 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package deprecations implements the Workflow Deprecations policy. It flags
+// workflows that use Actions running on deprecated Node.js runtimes, or run
+// deprecated workflow commands, which indicate unmaintained automation.
+package deprecations
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/policies/workflowfiles"
+	"github.com/ossf/allstar/pkg/policydef"
+	"github.com/rhysd/actionlint"
+	"sigs.k8s.io/yaml"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+const configFile = "workflow_deprecations.yaml"
+const polName = "Workflow Deprecations"
+
+const notifyText = `This policy flags workflows that depend on deprecated GitHub Actions features. GitHub warns about, and eventually removes, these features, which breaks the workflows. Their use usually indicates automation, or Actions it depends on, that are no longer maintained, and may not receive security fixes either.
+
+To fix this, update the Actions listed above to a version that runs on a supported Node.js runtime, or replace them with maintained alternatives. Replace the set-output and save-state commands by writing to the files in the GITHUB_OUTPUT and GITHUB_STATE environment variables, eg: echo "name=value" >> "$GITHUB_OUTPUT".
+(For more information, see https://github.blog/changelog/2023-09-22-github-actions-transitioning-from-node-16-to-node-20/ and https://github.blog/changelog/2022-10-11-github-actions-deprecating-save-state-and-set-output-commands/)`
+
+// metadataPaths are the names of the metadata file of an Action.
+var metadataPaths = []string{"action.yml", "action.yaml"}
+
+// commandRe matches a workflow command in a run script, eg:
+// "::set-output name=foo::bar".
+var commandRe = regexp.MustCompile(`::([a-z-]+)[ :]`)
+
+// OrgConfig is the org-level config definition for Workflow Deprecations.
+type OrgConfig struct {
+	// OptConfig is the standard org-level opt in/out config, RepoOverride
+	// applies to all config.
+	OptConfig config.OrgOptConfig `json:"optConfig"`
+
+	// Action defines which action to take, default log, other: issue...
+	Action string `json:"action"`
+
+	// DeprecatedRuntimes lists the deprecated runtimes, the runs.using value
+	// of Action metadata, default: node12, node16.
+	DeprecatedRuntimes []string `json:"deprecatedRuntimes"`
+
+	// DeprecatedCommands lists the deprecated workflow commands, default:
+	// set-output, save-state.
+	DeprecatedCommands []string `json:"deprecatedCommands"`
+
+	// CheckRuntimes enables fetching the metadata of the Actions used by
+	// workflows, to check their runtime, default true.
+	CheckRuntimes bool `json:"checkRuntimes"`
+}
+
+// RepoConfig is the repo-level config for Workflow Deprecations.
+type RepoConfig struct {
+	// OptConfig is the standard repo-level opt in/out config.
+	OptConfig config.RepoOptConfig `json:"optConfig"`
+
+	// Action overrides the same setting in org-level, only if present.
+	Action *string `json:"action"`
+
+	// CheckRuntimes overrides the same setting in org-level, only if present.
+	CheckRuntimes *bool `json:"checkRuntimes"`
+}
+
+type mergedConfig struct {
+	Action             string
+	DeprecatedRuntimes []string
+	DeprecatedCommands []string
+	CheckRuntimes      bool
+}
+
+type details struct {
+	// Runtimes are the Actions used that run on a deprecated runtime, as
+	// "path: uses (runtime)".
+	Runtimes []string
+
+	// Commands are the deprecated workflow commands run, as
+	// "path: job: command".
+	Commands []string
+}
+
+// actionMetadata is the part of an Action metadata file read by this policy.
+type actionMetadata struct {
+	Runs struct {
+		Using string `json:"using"`
+	} `json:"runs"`
+}
+
+var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+var configIsEnabled func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig, c *github.Client, owner, repo string) (bool, error)
+var listWorkflows func(context.Context, *github.Client, string, string, string) ([]*workflowfiles.Workflow, error)
+var getActionRuntime func(context.Context, *github.Client, string, string, string, string) (string, error)
+
+func init() {
+	configFetchConfig = config.FetchConfig
+	configIsEnabled = config.IsEnabled
+	listWorkflows = workflowfiles.ListParsed
+	getActionRuntime = getActionRuntimeReal
+}
+
+// runtimes caches the runtime of each Action version, as the same Actions are
+// used across many repos.
+var runtimes = make(map[string]string)
+var runtimesMu sync.Mutex
+
+// Deprecations is the Workflow Deprecations policy object, implements
+// policydef.Policy.
+type Deprecations bool
+
+// NewDeprecations returns a new Workflow Deprecations policy.
+func NewDeprecations() policydef.Policy {
+	var d Deprecations
+	return d
+}
+
+// Name returns the name of this policy, implementing policydef.Policy.Name()
+func (d Deprecations) Name() string {
+	return polName
+}
+
+// Check whether this policy is enabled or not
+func (d Deprecations) IsEnabled(ctx context.Context, c *github.Client, owner, repo string) (bool, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	return configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+}
+
+// Check performs the policy check for Workflow Deprecations based on the
+// configuration stored in the org/repo, implementing policydef.Policy.Check()
+func (d Deprecations) Check(ctx context.Context, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Bool("enabled", enabled).
+		Msg("Check repo enabled")
+	mc := mergeConfig(oc, orc, rc, repo)
+
+	wfs, err := listWorkflows(ctx, c, owner, repo, polName)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(wfs, func(i, j int) bool {
+		return wfs[i].Path < wfs[j].Path
+	})
+
+	var dt details
+	dt.Commands = deprecatedCommands(wfs, mc.DeprecatedCommands)
+	if mc.CheckRuntimes {
+		dt.Runtimes, err = deprecatedRuntimes(ctx, c, owner, repo, wfs, mc.DeprecatedRuntimes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(dt.Runtimes) == 0 && len(dt.Commands) == 0 {
+		return &policydef.Result{
+			Enabled:    enabled,
+			Pass:       true,
+			NotifyText: "",
+			Details:    dt,
+		}, nil
+	}
+	var text strings.Builder
+	if len(dt.Runtimes) > 0 {
+		text.WriteString("Actions running on a deprecated runtime:\n")
+		for _, r := range dt.Runtimes {
+			fmt.Fprintf(&text, "- `%v`\n", r)
+		}
+	}
+	if len(dt.Commands) > 0 {
+		text.WriteString("Deprecated workflow commands:\n")
+		for _, r := range dt.Commands {
+			fmt.Fprintf(&text, "- `%v`\n", r)
+		}
+	}
+	return &policydef.Result{
+		Enabled:    enabled,
+		Pass:       false,
+		NotifyText: text.String() + "\n" + notifyText,
+		Details:    dt,
+	}, nil
+}
+
+// deprecatedCommands returns the deprecated workflow commands run by the steps
+// of wfs.
+func deprecatedCommands(wfs []*workflowfiles.Workflow, deprecated []string) []string {
+	var rv []string
+	for _, wf := range wfs {
+		for _, id := range jobIDs(wf) {
+			seen := make(map[string]bool)
+			for _, s := range wf.Workflow.Jobs[id].Steps {
+				if s == nil || s.Exec == nil {
+					continue
+				}
+				e, ok := s.Exec.(*actionlint.ExecRun)
+				if !ok || e.Run == nil {
+					continue
+				}
+				for _, m := range commandRe.FindAllStringSubmatch(e.Run.Value, -1) {
+					if in(m[1], deprecated) && !seen[m[1]] {
+						seen[m[1]] = true
+						rv = append(rv, fmt.Sprintf("%v: %v: ::%v", wf.Path, id, m[1]))
+					}
+				}
+			}
+		}
+	}
+	return rv
+}
+
+// deprecatedRuntimes returns the Actions used by the steps of wfs that run on
+// a deprecated runtime.
+func deprecatedRuntimes(ctx context.Context, c *github.Client, owner, repo string,
+	wfs []*workflowfiles.Workflow, deprecated []string) ([]string, error) {
+	var rv []string
+	for _, wf := range wfs {
+		seen := make(map[string]bool)
+		for _, id := range jobIDs(wf) {
+			for _, s := range wf.Workflow.Jobs[id].Steps {
+				if s == nil || s.Exec == nil {
+					continue
+				}
+				e, ok := s.Exec.(*actionlint.ExecAction)
+				if !ok || e.Uses == nil || seen[e.Uses.Value] {
+					continue
+				}
+				uses := e.Uses.Value
+				seen[uses] = true
+				runtime, err := actionRuntime(ctx, c, owner, repo, uses)
+				if err != nil {
+					return nil, err
+				}
+				if in(runtime, deprecated) {
+					rv = append(rv, fmt.Sprintf("%v: %v (%v)", wf.Path, uses, runtime))
+				}
+			}
+		}
+	}
+	return rv, nil
+}
+
+// actionRuntime returns the runtime of the Action referred to by uses, or ""
+// if it is not a JavaScript Action, or its metadata is not found.
+func actionRuntime(ctx context.Context, c *github.Client, owner, repo, uses string) (string, error) {
+	if strings.HasPrefix(uses, "docker://") {
+		return "", nil
+	}
+	var aOwner, aRepo, dir, ref string
+	if local, ok := strings.CutPrefix(uses, "./"); ok {
+		// Local Actions are read from the default branch.
+		aOwner, aRepo, dir = owner, repo, local
+	} else {
+		name, r, ok := strings.Cut(uses, "@")
+		if !ok {
+			return "", nil
+		}
+		parts := strings.SplitN(name, "/", 3)
+		if len(parts) < 2 {
+			return "", nil
+		}
+		aOwner, aRepo, ref = parts[0], parts[1], r
+		if len(parts) == 3 {
+			dir = parts[2]
+		}
+		if strings.HasSuffix(dir, ".yml") || strings.HasSuffix(dir, ".yaml") {
+			// A reusable workflow, not an Action.
+			return "", nil
+		}
+	}
+	key := strings.ToLower(fmt.Sprintf("%v/%v/%v@%v", aOwner, aRepo, dir, ref))
+	if ref != "" {
+		runtimesMu.Lock()
+		rt, ok := runtimes[key]
+		runtimesMu.Unlock()
+		if ok {
+			return rt, nil
+		}
+	}
+	rt, err := getActionRuntime(ctx, c, aOwner, aRepo, dir, ref)
+	if err != nil {
+		return "", err
+	}
+	if ref != "" {
+		runtimesMu.Lock()
+		runtimes[key] = rt
+		runtimesMu.Unlock()
+	}
+	return rt, nil
+}
+
+func jobIDs(wf *workflowfiles.Workflow) []string {
+	ids := make([]string, 0, len(wf.Workflow.Jobs))
+	for id, j := range wf.Workflow.Jobs {
+		if j != nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func in(s string, list []string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// getActionRuntimeReal returns the runs.using value of the metadata of the
+// Action in dir of a repo at ref, or "" if the metadata is not found.
+func getActionRuntimeReal(ctx context.Context, c *github.Client, owner, repo, dir, ref string) (string, error) {
+	var opts *github.RepositoryContentGetOptions
+	if ref != "" {
+		opts = &github.RepositoryContentGetOptions{Ref: ref}
+	}
+	for _, mp := range metadataPaths {
+		fc, _, rsp, err := c.Repositories.GetContents(ctx, owner, repo, path.Join(dir, mp), opts)
+		if err != nil {
+			if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+				continue
+			}
+			return "", err
+		}
+		if fc == nil {
+			continue
+		}
+		content, err := fc.GetContent()
+		if err != nil {
+			return "", err
+		}
+		var am actionMetadata
+		if err := yaml.Unmarshal([]byte(content), &am); err != nil {
+			log.Warn().
+				Str("area", polName).
+				Str("action", fmt.Sprintf("%v/%v/%v@%v", owner, repo, dir, ref)).
+				Err(err).
+				Msg("Unable to parse Action metadata file, skipping.")
+			return "", nil
+		}
+		return am.Runs.Using, nil
+	}
+	return "", nil
+}
+
+// Fix implementing policydef.Policy.Fix(). Not supported, updating Actions
+// may change their behavior.
+func (d Deprecations) Fix(ctx context.Context, c *github.Client, owner, repo string) error {
+	log.Warn().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Msg("Action fix is configured, but not implemented.")
+	return nil
+}
+
+// GetAction returns the configured action from Workflow Deprecations'
+// configuration stored in the org-level repo, default log. Implementing
+// policydef.Policy.GetAction()
+func (d Deprecations) GetAction(ctx context.Context, c *github.Client, owner, repo string) string {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	mc := mergeConfig(oc, orc, rc, repo)
+	return mc.Action
+}
+
+func getConfig(ctx context.Context, c *github.Client, owner, repo string) (*OrgConfig, *RepoConfig, *RepoConfig) {
+	oc := &OrgConfig{ // Fill out non-zero defaults
+		Action:             "log",
+		DeprecatedRuntimes: []string{"node12", "node16"},
+		DeprecatedCommands: []string{"set-output", "save-state"},
+		CheckRuntimes:      true,
+	}
+	if err := configFetchConfig(ctx, c, owner, "", configFile, config.OrgLevel, oc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	orc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.OrgRepoLevel, orc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgRepoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	rc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.RepoLevel, rc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "repoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	return oc, orc, rc
+}
+
+func mergeConfig(oc *OrgConfig, orc, rc *RepoConfig, repo string) *mergedConfig {
+	mc := &mergedConfig{
+		Action:             oc.Action,
+		DeprecatedRuntimes: oc.DeprecatedRuntimes,
+		DeprecatedCommands: oc.DeprecatedCommands,
+		CheckRuntimes:      oc.CheckRuntimes,
+	}
+	mc = mergeInRepoConfig(mc, orc, repo)
+
+	if !oc.OptConfig.DisableRepoOverride {
+		mc = mergeInRepoConfig(mc, rc, repo)
+	}
+	return mc
+}
+
+func mergeInRepoConfig(mc *mergedConfig, rc *RepoConfig, repo string) *mergedConfig {
+	if rc.Action != nil {
+		mc.Action = *rc.Action
+	}
+	if rc.CheckRuntimes != nil {
+		mc.CheckRuntimes = *rc.CheckRuntimes
+	}
+	return mc
+}
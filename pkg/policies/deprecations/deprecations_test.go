@@ -0,0 +1,318 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deprecations
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/configtest"
+	"github.com/ossf/allstar/pkg/policies/workflowfiles"
+	"github.com/rhysd/actionlint"
+)
+
+func TestConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Org       OrgConfig
+		OrgRepo   RepoConfig
+		Repo      RepoConfig
+		ExpAction string
+		Exp       mergedConfig
+	}{
+		{
+			Name: "OrgOnly",
+			Org: OrgConfig{
+				Action:             "issue",
+				DeprecatedRuntimes: []string{"node16"},
+				CheckRuntimes:      true,
+			},
+			OrgRepo:   RepoConfig{},
+			Repo:      RepoConfig{},
+			ExpAction: "issue",
+			Exp: mergedConfig{
+				Action:             "issue",
+				DeprecatedRuntimes: []string{"node16"},
+				CheckRuntimes:      true,
+			},
+		},
+		{
+			Name: "OrgRepoOverOrg",
+			Org: OrgConfig{
+				Action:        "issue",
+				CheckRuntimes: true,
+			},
+			OrgRepo: RepoConfig{
+				Action:        github.String("log"),
+				CheckRuntimes: github.Bool(false),
+			},
+			Repo:      RepoConfig{},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action: "log",
+			},
+		},
+		{
+			Name: "RepoOverAllOrg",
+			Org: OrgConfig{
+				Action: "issue",
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action:        github.String("email"),
+				CheckRuntimes: github.Bool(true),
+			},
+			ExpAction: "email",
+			Exp: mergedConfig{
+				Action:        "email",
+				CheckRuntimes: true,
+			},
+		},
+		{
+			Name: "RepoDisallowed",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					DisableRepoOverride: true,
+				},
+				Action:        "issue",
+				CheckRuntimes: true,
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action:        github.String("email"),
+				CheckRuntimes: github.Bool(false),
+			},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action:        "log",
+				CheckRuntimes: true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				switch ol {
+				case config.RepoLevel:
+					rc := out.(*RepoConfig)
+					*rc = test.Repo
+				case config.OrgRepoLevel:
+					orc := out.(*RepoConfig)
+					*orc = test.OrgRepo
+				case config.OrgLevel:
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+
+			d := Deprecations(true)
+			ctx := context.Background()
+
+			action := d.GetAction(ctx, nil, "", "thisrepo")
+			if action != test.ExpAction {
+				t.Errorf("Unexpected results. want %s, got %s", test.ExpAction, action)
+			}
+
+			oc, orc, rc := getConfig(ctx, nil, "", "thisrepo")
+			mc := mergeConfig(oc, orc, rc, "thisrepo")
+			if diff := cmp.Diff(&test.Exp, mc); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+const currentWorkflow = `name: Test
+on: pull_request
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: docker://alpine:3
+      - id: version
+        run: echo "version=1.0" >> "$GITHUB_OUTPUT"
+  release:
+    uses: my/workflows/.github/workflows/release.yml@main
+`
+
+const deprecatedWorkflow = `name: Build
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v2
+      - uses: actions/checkout@v2
+      - uses: actions/setup-node@v3
+      - uses: ./.github/actions/build
+      - id: version
+        run: |
+          echo "::set-output name=version::1.0"
+          echo "::set-output name=sha::abc"
+          echo "::save-state name=started::1"
+          echo "::warning ::Not deprecated"
+`
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Workflows  map[string]string
+		Org        OrgConfig
+		ExpPass    bool
+		ExpNotify  string
+		ExpDetails details
+	}{
+		{
+			Name:       "NoWorkflows",
+			Org:        OrgConfig{CheckRuntimes: true},
+			ExpPass:    true,
+			ExpDetails: details{},
+		},
+		{
+			Name:       "Current",
+			Workflows:  map[string]string{"test.yaml": currentWorkflow},
+			Org:        OrgConfig{CheckRuntimes: true},
+			ExpPass:    true,
+			ExpDetails: details{},
+		},
+		{
+			Name: "Deprecated",
+			Workflows: map[string]string{
+				"test.yaml":  currentWorkflow,
+				"build.yaml": deprecatedWorkflow,
+			},
+			Org:       OrgConfig{CheckRuntimes: true},
+			ExpPass:   false,
+			ExpNotify: "Actions running on a deprecated runtime:\n- `.github/workflows/build.yaml: actions/checkout@v2 (node12)`\n",
+			ExpDetails: details{
+				Runtimes: []string{
+					".github/workflows/build.yaml: actions/checkout@v2 (node12)",
+					".github/workflows/build.yaml: actions/setup-node@v3 (node16)",
+					".github/workflows/build.yaml: ./.github/actions/build (node16)",
+				},
+				Commands: []string{
+					".github/workflows/build.yaml: build: ::set-output",
+					".github/workflows/build.yaml: build: ::save-state",
+				},
+			},
+		},
+		{
+			Name:      "RuntimesNotChecked",
+			Workflows: map[string]string{"build.yaml": deprecatedWorkflow},
+			ExpPass:   false,
+			ExpNotify: "Deprecated workflow commands:\n- `.github/workflows/build.yaml: build: ::set-output`\n",
+			ExpDetails: details{
+				Commands: []string{
+					".github/workflows/build.yaml: build: ::set-output",
+					".github/workflows/build.yaml: build: ::save-state",
+				},
+			},
+		},
+	}
+
+	configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+		c *github.Client, owner, repo string) (bool, error) {
+		return true, nil
+	}
+	actionRuntimes := map[string]string{
+		"actions/checkout@v2":                     "node12",
+		"actions/checkout@v4":                     "node20",
+		"actions/setup-node@v3":                   "node16",
+		"thisorg/thisrepo/.github/actions/build@": "node16",
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			runtimes = make(map[string]string)
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.OrgLevel {
+					oc := out.(*OrgConfig)
+					oc.Action = "issue"
+					oc.CheckRuntimes = test.Org.CheckRuntimes
+				}
+				return nil
+			}
+			fetched := make(map[string]int)
+			getActionRuntime = func(ctx context.Context, c *github.Client, owner, repo, dir, ref string) (string, error) {
+				name := owner + "/" + repo
+				if dir != "" {
+					name += "/" + dir
+				}
+				name += "@" + ref
+				fetched[name]++
+				return actionRuntimes[name], nil
+			}
+			listWorkflows = func(ctx context.Context, c *github.Client, owner, repo, area string) ([]*workflowfiles.Workflow, error) {
+				var wfs []*workflowfiles.Workflow
+				for name, content := range test.Workflows {
+					wf, errs := actionlint.Parse([]byte(content))
+					if len(errs) > 0 {
+						t.Fatalf("Unexpected parse errors in %v: %v", name, errs)
+					}
+					wfs = append(wfs, &workflowfiles.Workflow{
+						Path:     ".github/workflows/" + name,
+						Workflow: wf,
+					})
+				}
+				return wfs, nil
+			}
+
+			res, err := Deprecations(true).Check(context.Background(), nil, "thisorg", "thisrepo")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Pass != test.ExpPass {
+				t.Errorf("Unexpected pass: %v", res.Pass)
+			}
+			if test.ExpNotify != "" && !strings.Contains(res.NotifyText, test.ExpNotify) {
+				t.Errorf("Expected notify text to contain:\n%v\ngot:\n%v", test.ExpNotify, res.NotifyText)
+			}
+			if diff := cmp.Diff(test.ExpDetails, res.Details); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+			for name, n := range fetched {
+				if n > 1 {
+					t.Errorf("Metadata of %v fetched %v times", name, n)
+				}
+			}
+		})
+	}
+}
+
+func TestGeneratedPrecedence(t *testing.T) {
+	configtest.TestPrecedence(t, configtest.Precedence{
+		Org:   OrgConfig{},
+		Repo:  RepoConfig{},
+		Fetch: &configFetchConfig,
+		Merge: func() interface{} {
+			ctx := context.Background()
+			oc, orc, rc := getConfig(ctx, nil, "", configtest.Repo)
+			return mergeConfig(oc, orc, rc, configtest.Repo)
+		},
+	})
+}
@@ -0,0 +1,463 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package externalaccess implements the External Access policy. It summarizes
+// the users that are not members of the organization, outside collaborators
+// and users with pending invitations, that have access to a repository, and
+// flags repositories with more of them than allowed, for periodic access
+// certification.
+package externalaccess
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/policydef"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+const configFile = "external_access.yaml"
+const polName = "External Access"
+
+const day = 24 * time.Hour
+
+const notifyText = `This policy limits the number of users that are not members of the organization with access to this repository, either as outside collaborators or through pending invitations, and how long invitations may stay pending. Access granted outside of organization membership is easy to lose track of, and is not removed when someone leaves a team or the organization.
+
+To fix this, review the users listed above. From the main page of the repository, go to Settings -> Collaborators and teams, and remove the users that no longer need access, or cancel their invitations. Users that need long term access should be added to the organization and given access through a team.
+(For more information, see https://docs.github.com/en/organizations/managing-user-access-to-your-organizations-repositories/managing-outside-collaborators/removing-an-outside-collaborator-from-an-organization-repository)`
+
+// OrgConfig is the org-level config definition for External Access.
+type OrgConfig struct {
+	// OptConfig is the standard org-level opt in/out config, RepoOverride
+	// applies to all config.
+	OptConfig config.OrgOptConfig `json:"optConfig"`
+
+	// Action defines which action to take, default log, other: issue...
+	// The fix action cancels invitations pending longer than
+	// MaxInvitationAgeDays.
+	Action string `json:"action"`
+
+	// MaxOutsideCollaborators is the number of outside collaborators allowed
+	// on a repository, default 10.
+	MaxOutsideCollaborators int `json:"maxOutsideCollaborators"`
+
+	// MaxPendingInvitations is the number of pending invitations of users
+	// that are not members of the organization allowed on a repository,
+	// default 10.
+	MaxPendingInvitations int `json:"maxPendingInvitations"`
+
+	// MaxInvitationAgeDays is the number of days an invitation may be
+	// pending, default 30. Set to 0 to allow invitations of any age.
+	MaxInvitationAgeDays int `json:"maxInvitationAgeDays"`
+}
+
+// RepoConfig is the repo-level config for External Access.
+type RepoConfig struct {
+	// OptConfig is the standard repo-level opt in/out config.
+	OptConfig config.RepoOptConfig `json:"optConfig"`
+
+	// Action overrides the same setting in org-level, only if present.
+	Action *string `json:"action"`
+
+	// MaxOutsideCollaborators overrides the same setting in org-level, only
+	// if present.
+	MaxOutsideCollaborators *int `json:"maxOutsideCollaborators"`
+
+	// MaxPendingInvitations overrides the same setting in org-level, only if
+	// present.
+	MaxPendingInvitations *int `json:"maxPendingInvitations"`
+}
+
+type mergedConfig struct {
+	Action                  string
+	MaxOutsideCollaborators int
+	MaxPendingInvitations   int
+	MaxInvitationAgeDays    int
+}
+
+// ExternalAccess describes the access of a user that is not a member of the
+// organization.
+type ExternalAccess struct {
+	// Login is the user's login.
+	Login string
+
+	// Permission is the user's permission on the repository, eg: read,
+	// write, admin.
+	Permission string
+
+	// InvitedAt is when the user was invited, for pending invitations.
+	InvitedAt *time.Time
+}
+
+type details struct {
+	// OutsideCollaborators lists the outside collaborators of the repository.
+	OutsideCollaborators []ExternalAccess
+
+	// PendingInvitations lists the pending invitations of users that are not
+	// members of the organization.
+	PendingInvitations []ExternalAccess
+
+	// StaleInvitations lists the users whose invitations have been pending
+	// longer than allowed.
+	StaleInvitations []string
+}
+
+type invitation struct {
+	ID     int64
+	Access ExternalAccess
+}
+
+var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+
+var configIsEnabled func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig, c *github.Client, owner, repo string) (bool, error)
+
+var listOutsideCollaborators func(context.Context, *github.Client, string, string) ([]ExternalAccess, error)
+
+var listInvitations func(context.Context, *github.Client, string, string) ([]invitation, error)
+
+var isOrgMember func(context.Context, *github.Client, string, string) (bool, error)
+
+var deleteInvitation func(context.Context, *github.Client, string, string, int64) error
+
+var now func() time.Time
+
+func init() {
+	configFetchConfig = config.FetchConfig
+	configIsEnabled = config.IsEnabled
+	listOutsideCollaborators = listOutsideCollaboratorsReal
+	listInvitations = listInvitationsReal
+	isOrgMember = isOrgMemberReal
+	deleteInvitation = deleteInvitationReal
+	now = time.Now
+}
+
+// ExternalAccessPolicy is the External Access policy object, implements
+// policydef.Policy.
+type ExternalAccessPolicy bool
+
+// NewExternalAccess returns a new External Access policy.
+func NewExternalAccess() policydef.Policy {
+	var e ExternalAccessPolicy
+	return e
+}
+
+// Name returns the name of this policy, implementing policydef.Policy.Name()
+func (e ExternalAccessPolicy) Name() string {
+	return polName
+}
+
+// Check whether this policy is enabled or not
+func (e ExternalAccessPolicy) IsEnabled(ctx context.Context, c *github.Client, owner, repo string) (bool, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	return configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+}
+
+// Check performs the policy check for External Access based on the
+// configuration stored in the org/repo, implementing policydef.Policy.Check()
+func (e ExternalAccessPolicy) Check(ctx context.Context, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Bool("enabled", enabled).
+		Msg("Check repo enabled")
+
+	mc := mergeConfig(oc, orc, rc, repo)
+
+	var d details
+	outside, err := listOutsideCollaborators(ctx, c, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	d.OutsideCollaborators = outside
+
+	pending, stale, err := pendingInvitations(ctx, c, owner, repo, mc)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range pending {
+		d.PendingInvitations = append(d.PendingInvitations, i.Access)
+	}
+	for _, i := range stale {
+		d.StaleInvitations = append(d.StaleInvitations, i.Access.Login)
+	}
+
+	var text strings.Builder
+	if len(d.OutsideCollaborators) > mc.MaxOutsideCollaborators {
+		fmt.Fprintf(&text, "Found %v outside collaborators, more than the %v allowed:\n",
+			len(d.OutsideCollaborators), mc.MaxOutsideCollaborators)
+		for _, a := range d.OutsideCollaborators {
+			fmt.Fprintf(&text, "- %v (%v)\n", a.Login, a.Permission)
+		}
+	}
+	if len(d.PendingInvitations) > mc.MaxPendingInvitations {
+		fmt.Fprintf(&text, "Found %v pending invitations of users that are not organization members, more than the %v allowed:\n",
+			len(d.PendingInvitations), mc.MaxPendingInvitations)
+		for _, a := range d.PendingInvitations {
+			fmt.Fprintf(&text, "- %v (%v)\n", a.Login, a.Permission)
+		}
+	}
+	if len(stale) > 0 {
+		fmt.Fprintf(&text, "Found %v invitations pending for more than %v days:\n",
+			len(stale), mc.MaxInvitationAgeDays)
+		for _, i := range stale {
+			fmt.Fprintf(&text, "- %v (%v), invited %v\n", i.Access.Login, i.Access.Permission,
+				i.Access.InvitedAt.Format("2006-01-02"))
+		}
+	}
+
+	if text.Len() == 0 {
+		return &policydef.Result{
+			Enabled:    enabled,
+			Pass:       true,
+			NotifyText: "",
+			Details:    d,
+		}, nil
+	}
+	return &policydef.Result{
+		Enabled:    enabled,
+		Pass:       false,
+		NotifyText: text.String() + "\n" + notifyText,
+		Details:    d,
+	}, nil
+}
+
+// pendingInvitations returns the pending invitations of users that are not
+// members of the organization, and those of them that have been pending
+// longer than allowed.
+func pendingInvitations(ctx context.Context, c *github.Client, owner, repo string,
+	mc *mergedConfig) ([]invitation, []invitation, error) {
+	is, err := listInvitations(ctx, c, owner, repo)
+	if err != nil {
+		return nil, nil, err
+	}
+	var pending, stale []invitation
+	for _, i := range is {
+		member, err := isOrgMember(ctx, c, owner, i.Access.Login)
+		if err != nil {
+			return nil, nil, err
+		}
+		if member {
+			continue
+		}
+		pending = append(pending, i)
+		if mc.MaxInvitationAgeDays > 0 && i.Access.InvitedAt != nil &&
+			now().Sub(*i.Access.InvitedAt) > time.Duration(mc.MaxInvitationAgeDays)*day {
+			stale = append(stale, i)
+		}
+	}
+	return pending, stale, nil
+}
+
+func listOutsideCollaboratorsReal(ctx context.Context, c *github.Client, owner, repo string) ([]ExternalAccess, error) {
+	opt := &github.ListCollaboratorsOptions{
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+		Affiliation: "outside",
+	}
+	var rv []ExternalAccess
+	for {
+		us, resp, err := c.Repositories.ListCollaborators(ctx, owner, repo, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range us {
+			rv = append(rv, ExternalAccess{
+				Login:      u.GetLogin(),
+				Permission: u.GetRoleName(),
+			})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	// Sort for stable issue text.
+	sort.Slice(rv, func(a, b int) bool { return rv[a].Login < rv[b].Login })
+	return rv, nil
+}
+
+func listInvitationsReal(ctx context.Context, c *github.Client, owner, repo string) ([]invitation, error) {
+	opt := &github.ListOptions{
+		PerPage: 100,
+	}
+	var rv []invitation
+	for {
+		is, resp, err := c.Repositories.ListInvitations(ctx, owner, repo, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range is {
+			if i.Invitee == nil {
+				continue
+			}
+			var at *time.Time
+			if i.CreatedAt != nil {
+				t := i.GetCreatedAt().Time
+				at = &t
+			}
+			rv = append(rv, invitation{
+				ID: i.GetID(),
+				Access: ExternalAccess{
+					Login:      i.GetInvitee().GetLogin(),
+					Permission: i.GetPermissions(),
+					InvitedAt:  at,
+				},
+			})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	// Sort for stable issue text.
+	sort.Slice(rv, func(a, b int) bool { return rv[a].Access.Login < rv[b].Access.Login })
+	return rv, nil
+}
+
+// isOrgMemberReal returns whether the user is a member of the organization.
+// For repositories owned by a user, nobody is.
+func isOrgMemberReal(ctx context.Context, c *github.Client, owner, user string) (bool, error) {
+	member, rsp, err := c.Organizations.IsMember(ctx, owner, user)
+	if err != nil {
+		if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return member, nil
+}
+
+func deleteInvitationReal(ctx context.Context, c *github.Client, owner, repo string, id int64) error {
+	_, err := c.Repositories.DeleteInvitation(ctx, owner, repo, id)
+	return err
+}
+
+// Fix implementing policydef.Policy.Fix(). Cancels the invitations of users
+// that are not members of the organization that have been pending longer than
+// MaxInvitationAgeDays. Outside collaborators are not removed, which ones
+// still need access can't be known.
+func (e ExternalAccessPolicy) Fix(ctx context.Context, c *github.Client, owner, repo string) error {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	mc := mergeConfig(oc, orc, rc, repo)
+	_, stale, err := pendingInvitations(ctx, c, owner, repo, mc)
+	if err != nil {
+		return err
+	}
+	for _, i := range stale {
+		if err := deleteInvitation(ctx, c, owner, repo, i.ID); err != nil {
+			return err
+		}
+		log.Info().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", polName).
+			Str("user", i.Access.Login).
+			Msg("Cancelled stale invitation.")
+	}
+	return nil
+}
+
+// GetAction returns the configured action from External Access'
+// configuration stored in the org-level repo, default log. Implementing
+// policydef.Policy.GetAction()
+func (e ExternalAccessPolicy) GetAction(ctx context.Context, c *github.Client, owner, repo string) string {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	mc := mergeConfig(oc, orc, rc, repo)
+	return mc.Action
+}
+
+func getConfig(ctx context.Context, c *github.Client, owner, repo string) (*OrgConfig, *RepoConfig, *RepoConfig) {
+	oc := &OrgConfig{ // Fill out non-zero defaults
+		Action:                  "log",
+		MaxOutsideCollaborators: 10,
+		MaxPendingInvitations:   10,
+		MaxInvitationAgeDays:    30,
+	}
+	if err := configFetchConfig(ctx, c, owner, "", configFile, config.OrgLevel, oc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	orc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.OrgRepoLevel, orc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgRepoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	rc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.RepoLevel, rc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "repoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	return oc, orc, rc
+}
+
+func mergeConfig(oc *OrgConfig, orc, rc *RepoConfig, repo string) *mergedConfig {
+	mc := &mergedConfig{
+		Action:                  oc.Action,
+		MaxOutsideCollaborators: oc.MaxOutsideCollaborators,
+		MaxPendingInvitations:   oc.MaxPendingInvitations,
+		MaxInvitationAgeDays:    oc.MaxInvitationAgeDays,
+	}
+	mc = mergeInRepoConfig(mc, orc, repo)
+
+	if !oc.OptConfig.DisableRepoOverride {
+		mc = mergeInRepoConfig(mc, rc, repo)
+	}
+	return mc
+}
+
+func mergeInRepoConfig(mc *mergedConfig, rc *RepoConfig, repo string) *mergedConfig {
+	if rc.Action != nil {
+		mc.Action = *rc.Action
+	}
+	if rc.MaxOutsideCollaborators != nil {
+		mc.MaxOutsideCollaborators = *rc.MaxOutsideCollaborators
+	}
+	if rc.MaxPendingInvitations != nil {
+		mc.MaxPendingInvitations = *rc.MaxPendingInvitations
+	}
+	return mc
+}
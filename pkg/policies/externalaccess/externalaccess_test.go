@@ -0,0 +1,346 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package externalaccess
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/configtest"
+)
+
+func TestConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Org       OrgConfig
+		OrgRepo   RepoConfig
+		Repo      RepoConfig
+		ExpAction string
+		Exp       mergedConfig
+	}{
+		{
+			Name: "OrgOnly",
+			Org: OrgConfig{
+				Action:                  "issue",
+				MaxOutsideCollaborators: 2,
+				MaxPendingInvitations:   3,
+				MaxInvitationAgeDays:    14,
+			},
+			OrgRepo:   RepoConfig{},
+			Repo:      RepoConfig{},
+			ExpAction: "issue",
+			Exp: mergedConfig{
+				Action:                  "issue",
+				MaxOutsideCollaborators: 2,
+				MaxPendingInvitations:   3,
+				MaxInvitationAgeDays:    14,
+			},
+		},
+		{
+			Name: "OrgRepoOverOrg",
+			Org: OrgConfig{
+				Action:                  "issue",
+				MaxOutsideCollaborators: 2,
+			},
+			OrgRepo: RepoConfig{
+				Action:                  github.String("log"),
+				MaxOutsideCollaborators: github.Int(20),
+				MaxPendingInvitations:   github.Int(5),
+			},
+			Repo:      RepoConfig{},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action:                  "log",
+				MaxOutsideCollaborators: 20,
+				MaxPendingInvitations:   5,
+			},
+		},
+		{
+			Name: "RepoOverAllOrg",
+			Org: OrgConfig{
+				Action: "issue",
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action:                  github.String("email"),
+				MaxOutsideCollaborators: github.Int(1),
+			},
+			ExpAction: "email",
+			Exp: mergedConfig{
+				Action:                  "email",
+				MaxOutsideCollaborators: 1,
+			},
+		},
+		{
+			Name: "RepoDisallowed",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					DisableRepoOverride: true,
+				},
+				Action:                  "issue",
+				MaxOutsideCollaborators: 2,
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action:                  github.String("email"),
+				MaxOutsideCollaborators: github.Int(20),
+			},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action:                  "log",
+				MaxOutsideCollaborators: 2,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				switch ol {
+				case config.RepoLevel:
+					rc := out.(*RepoConfig)
+					*rc = test.Repo
+				case config.OrgRepoLevel:
+					orc := out.(*RepoConfig)
+					*orc = test.OrgRepo
+				case config.OrgLevel:
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+
+			e := ExternalAccessPolicy(true)
+			ctx := context.Background()
+
+			action := e.GetAction(ctx, nil, "", "thisrepo")
+			if action != test.ExpAction {
+				t.Errorf("Unexpected results. want %s, got %s", test.ExpAction, action)
+			}
+
+			oc, orc, rc := getConfig(ctx, nil, "", "thisrepo")
+			mc := mergeConfig(oc, orc, rc, "thisrepo")
+			if diff := cmp.Diff(&test.Exp, mc); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	recent := start.Add(-2 * day)
+	old := start.Add(-45 * day)
+	tests := []struct {
+		Name        string
+		Org         OrgConfig
+		Outside     []ExternalAccess
+		Invitations []invitation
+		Members     []string
+		ExpPass     bool
+		ExpNotify   []string
+		ExpDetails  details
+	}{
+		{
+			Name:       "None",
+			Org:        OrgConfig{MaxInvitationAgeDays: 30},
+			ExpPass:    true,
+			ExpDetails: details{},
+		},
+		{
+			Name: "WithinThresholds",
+			Org: OrgConfig{
+				MaxOutsideCollaborators: 1,
+				MaxPendingInvitations:   1,
+				MaxInvitationAgeDays:    30,
+			},
+			Outside: []ExternalAccess{{Login: "alice", Permission: "write"}},
+			Invitations: []invitation{
+				{ID: 1, Access: ExternalAccess{Login: "bob", Permission: "read", InvitedAt: &recent}},
+				{ID: 2, Access: ExternalAccess{Login: "member", Permission: "admin", InvitedAt: &old}},
+			},
+			Members: []string{"member"},
+			ExpPass: true,
+			ExpDetails: details{
+				OutsideCollaborators: []ExternalAccess{{Login: "alice", Permission: "write"}},
+				PendingInvitations:   []ExternalAccess{{Login: "bob", Permission: "read", InvitedAt: &recent}},
+			},
+		},
+		{
+			Name: "TooManyOutside",
+			Org: OrgConfig{
+				MaxOutsideCollaborators: 1,
+				MaxPendingInvitations:   1,
+			},
+			Outside: []ExternalAccess{
+				{Login: "alice", Permission: "write"},
+				{Login: "carol", Permission: "admin"},
+			},
+			ExpPass: false,
+			ExpNotify: []string{
+				"Found 2 outside collaborators, more than the 1 allowed:\n- alice (write)\n- carol (admin)\n",
+			},
+			ExpDetails: details{
+				OutsideCollaborators: []ExternalAccess{
+					{Login: "alice", Permission: "write"},
+					{Login: "carol", Permission: "admin"},
+				},
+			},
+		},
+		{
+			Name: "TooManyAndStaleInvitations",
+			Org: OrgConfig{
+				MaxOutsideCollaborators: 1,
+				MaxPendingInvitations:   1,
+				MaxInvitationAgeDays:    30,
+			},
+			Invitations: []invitation{
+				{ID: 1, Access: ExternalAccess{Login: "bob", Permission: "read", InvitedAt: &recent}},
+				{ID: 2, Access: ExternalAccess{Login: "dave", Permission: "write", InvitedAt: &old}},
+			},
+			ExpPass: false,
+			ExpNotify: []string{
+				"Found 2 pending invitations of users that are not organization members, more than the 1 allowed:\n- bob (read)\n- dave (write)\n",
+				"Found 1 invitations pending for more than 30 days:\n- dave (write), invited 2025-01-15\n",
+			},
+			ExpDetails: details{
+				PendingInvitations: []ExternalAccess{
+					{Login: "bob", Permission: "read", InvitedAt: &recent},
+					{Login: "dave", Permission: "write", InvitedAt: &old},
+				},
+				StaleInvitations: []string{"dave"},
+			},
+		},
+		{
+			Name: "AgeDisabled",
+			Org: OrgConfig{
+				MaxPendingInvitations: 1,
+			},
+			Invitations: []invitation{
+				{ID: 2, Access: ExternalAccess{Login: "dave", Permission: "write", InvitedAt: &old}},
+			},
+			ExpPass: true,
+			ExpDetails: details{
+				PendingInvitations: []ExternalAccess{
+					{Login: "dave", Permission: "write", InvitedAt: &old},
+				},
+			},
+		},
+	}
+
+	configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+		c *github.Client, owner, repo string) (bool, error) {
+		return true, nil
+	}
+	now = func() time.Time { return start }
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if oc, ok := out.(*OrgConfig); ok {
+					*oc = test.Org
+				}
+				return nil
+			}
+			listOutsideCollaborators = func(ctx context.Context, c *github.Client, owner, repo string) ([]ExternalAccess, error) {
+				return test.Outside, nil
+			}
+			listInvitations = func(ctx context.Context, c *github.Client, owner, repo string) ([]invitation, error) {
+				return test.Invitations, nil
+			}
+			isOrgMember = func(ctx context.Context, c *github.Client, owner, user string) (bool, error) {
+				for _, m := range test.Members {
+					if m == user {
+						return true, nil
+					}
+				}
+				return false, nil
+			}
+
+			res, err := ExternalAccessPolicy(true).Check(context.Background(), nil, "thisorg", "thisrepo")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Pass != test.ExpPass {
+				t.Errorf("Unexpected pass: %v", res.Pass)
+			}
+			for _, n := range test.ExpNotify {
+				if !strings.Contains(res.NotifyText, n) {
+					t.Errorf("Expected notify text to contain:\n%v\ngot:\n%v", n, res.NotifyText)
+				}
+			}
+			if diff := cmp.Diff(test.ExpDetails, res.Details); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFix(t *testing.T) {
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	recent := start.Add(-2 * day)
+	old := start.Add(-45 * day)
+	now = func() time.Time { return start }
+	configFetchConfig = func(ctx context.Context, c *github.Client,
+		owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+		return nil
+	}
+	listInvitations = func(ctx context.Context, c *github.Client, owner, repo string) ([]invitation, error) {
+		return []invitation{
+			{ID: 1, Access: ExternalAccess{Login: "bob", InvitedAt: &recent}},
+			{ID: 2, Access: ExternalAccess{Login: "dave", InvitedAt: &old}},
+			{ID: 3, Access: ExternalAccess{Login: "member", InvitedAt: &old}},
+		}, nil
+	}
+	isOrgMember = func(ctx context.Context, c *github.Client, owner, user string) (bool, error) {
+		return user == "member", nil
+	}
+	var deleted []int64
+	deleteInvitation = func(ctx context.Context, c *github.Client, owner, repo string, id int64) error {
+		deleted = append(deleted, id)
+		return nil
+	}
+
+	if err := ExternalAccessPolicy(true).Fix(context.Background(), nil, "thisorg", "thisrepo"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]int64{2}, deleted); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+}
+
+func TestGeneratedPrecedence(t *testing.T) {
+	configtest.TestPrecedence(t, configtest.Precedence{
+		Org:   OrgConfig{},
+		Repo:  RepoConfig{},
+		Fetch: &configFetchConfig,
+		Merge: func() interface{} {
+			ctx := context.Background()
+			oc, orc, rc := getConfig(ctx, nil, "", configtest.Repo)
+			return mergeConfig(oc, orc, rc, configtest.Repo)
+		},
+	})
+}
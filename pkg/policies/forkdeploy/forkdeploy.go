@@ -0,0 +1,431 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package forkdeploy implements the Fork PR Deployments policy, which flags
+// workflows that deploy when triggered by pull requests, which may come from
+// forks, without a protected environment.
+package forkdeploy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/policies/workflowfiles"
+	"github.com/ossf/allstar/pkg/policydef"
+	"github.com/rhysd/actionlint"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+const configFile = "fork_pr_deployments.yaml"
+const polName = "Fork PR Deployments"
+
+const notifyText = `Workflows in this repository deploy when triggered by pull requests, which may come from forks, without requiring approval through a protected environment. Anyone able to open a pull request can then run a deployment, with access to the deployment's secrets.
+
+%v
+To fix this, deploy only from trusted triggers such as push or release, or run deployment jobs in an environment with required reviewers. For workflows triggered by pull_request, an environment limited to selected deployment branches also prevents deployments from pull requests.
+
+For more information, see https://docs.github.com/en/actions/deployment/targeting-different-environments/using-environments-for-deployment`
+
+// defaultTriggers are events that run workflows for pull requests from forks.
+var defaultTriggers = []string{
+	"pull_request",
+	"pull_request_target",
+}
+
+// defaultDeployActions are Actions that deploy, a job using one is considered
+// a deployment even without an environment.
+var defaultDeployActions = []string{
+	"actions/deploy-pages",
+	"peaceiris/actions-gh-pages",
+	"jamesives/github-pages-deploy-action",
+	"aws-actions/amazon-ecs-deploy-task-definition",
+	"azure/webapps-deploy",
+	"google-github-actions/deploy-appengine",
+	"google-github-actions/deploy-cloudrun",
+	"cloudflare/wrangler-action",
+	"amondnet/vercel-action",
+}
+
+// OrgConfig is the org-level config definition for Fork PR Deployments.
+type OrgConfig struct {
+	// OptConfig is the standard org-level opt in/out config, RepoOverride
+	// applies to all config.
+	OptConfig config.OrgOptConfig `json:"optConfig"`
+
+	// Action defines which action to take, default log, other: issue...
+	Action string `json:"action"`
+
+	// Triggers are the workflow trigger events that run for pull requests
+	// from forks, default: pull_request and pull_request_target.
+	Triggers []string `json:"triggers"`
+
+	// DeployActions are Actions, without a version, that deploy. A job using
+	// one of these, or run in an environment, is a deployment. Default is a
+	// list of common deployment Actions, such as actions/deploy-pages.
+	DeployActions []string `json:"deployActions"`
+}
+
+// RepoConfig is the repo-level config for Fork PR Deployments.
+type RepoConfig struct {
+	// OptConfig is the standard repo-level opt in/out config.
+	OptConfig config.RepoOptConfig `json:"optConfig"`
+
+	// Action overrides the same setting in org-level, only if present.
+	Action *string `json:"action"`
+
+	// DeployActions overrides the same setting in org-level, only if present.
+	DeployActions *[]string `json:"deployActions"`
+}
+
+type mergedConfig struct {
+	Action        string
+	Triggers      []string
+	DeployActions []string
+}
+
+// finding is a deployment job reachable from pull requests without a
+// protected environment.
+type finding struct {
+	// Workflow is the path of the workflow.
+	Workflow string
+	// Job is the ID of the deployment job.
+	Job string
+	// Triggers are the pull request events that run the workflow.
+	Triggers []string
+	// Environment is the environment of the job, if any.
+	Environment string
+	// Reason describes why the deployment is not protected.
+	Reason string
+}
+
+type details struct {
+	Findings []finding
+}
+
+var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+var configIsEnabled func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig, c *github.Client, owner, repo string) (bool, error)
+var listWorkflows func(context.Context, *github.Client, string, string, string) ([]*workflowfiles.Workflow, error)
+var listEnvironments func(context.Context, *github.Client, string, string) (map[string]*github.Environment, error)
+
+func init() {
+	configFetchConfig = config.FetchConfig
+	configIsEnabled = config.IsEnabled
+	listWorkflows = workflowfiles.ListParsed
+	listEnvironments = listEnvironmentsReal
+}
+
+// ForkDeploy is the Fork PR Deployments policy object, implements
+// policydef.Policy.
+type ForkDeploy bool
+
+// NewForkDeploy returns a new Fork PR Deployments policy.
+func NewForkDeploy() policydef.Policy {
+	var f ForkDeploy
+	return f
+}
+
+// Name returns the name of this policy, implementing policydef.Policy.Name()
+func (f ForkDeploy) Name() string {
+	return polName
+}
+
+// Check whether this policy is enabled or not
+func (f ForkDeploy) IsEnabled(ctx context.Context, c *github.Client, owner, repo string) (bool, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	return configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+}
+
+// Check performs the policy check for Fork PR Deployments policy based on the
+// configuration stored in the org/repo, implementing policydef.Policy.Check()
+func (f ForkDeploy) Check(ctx context.Context, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Bool("enabled", enabled).
+		Msg("Check repo enabled")
+	mc := mergeConfig(oc, orc, rc, repo)
+
+	wfs, err := listWorkflows(ctx, c, owner, repo, polName)
+	if err != nil {
+		return nil, err
+	}
+	jobs := deployJobs(wfs, mc)
+	if len(jobs) == 0 {
+		return &policydef.Result{
+			Enabled:    enabled,
+			Pass:       true,
+			NotifyText: "",
+			Details:    details{},
+		}, nil
+	}
+	envs, err := listEnvironments(ctx, c, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var fs []finding
+	for _, j := range jobs {
+		if reason := unprotected(j, envs); reason != "" {
+			j.Reason = reason
+			fs = append(fs, j)
+		}
+	}
+	if len(fs) == 0 {
+		return &policydef.Result{
+			Enabled:    enabled,
+			Pass:       true,
+			NotifyText: "",
+			Details:    details{},
+		}, nil
+	}
+
+	var text string
+	last := ""
+	for _, f := range fs {
+		if f.Workflow != last {
+			text += fmt.Sprintf("`%v` (on %v):\n", f.Workflow, strings.Join(f.Triggers, ", "))
+			last = f.Workflow
+		}
+		text += fmt.Sprintf("- job `%v` %v\n", f.Job, f.Reason)
+	}
+	return &policydef.Result{
+		Enabled:    enabled,
+		Pass:       false,
+		NotifyText: fmt.Sprintf(notifyText, text),
+		Details: details{
+			Findings: fs,
+		},
+	}, nil
+}
+
+// deployJobs returns the deployment jobs of workflows run for pull requests,
+// sorted by workflow and job.
+func deployJobs(wfs []*workflowfiles.Workflow, mc *mergedConfig) []finding {
+	var fs []finding
+	for _, wf := range wfs {
+		triggers := prTriggers(wf.Workflow, mc.Triggers)
+		if len(triggers) == 0 {
+			continue
+		}
+		for id, j := range wf.Workflow.Jobs {
+			if j == nil {
+				continue
+			}
+			env := ""
+			if j.Environment != nil && j.Environment.Name != nil {
+				env = j.Environment.Name.Value
+			}
+			if env == "" && !usesDeployAction(j, mc.DeployActions) {
+				continue
+			}
+			fs = append(fs, finding{
+				Workflow:    wf.Path,
+				Job:         id,
+				Triggers:    triggers,
+				Environment: env,
+			})
+		}
+	}
+	sort.SliceStable(fs, func(i, j int) bool {
+		if fs[i].Workflow != fs[j].Workflow {
+			return fs[i].Workflow < fs[j].Workflow
+		}
+		return fs[i].Job < fs[j].Job
+	})
+	return fs
+}
+
+// unprotected returns why the deployment job j is reachable from pull
+// requests, or "" if its environment protects it.
+func unprotected(j finding, envs map[string]*github.Environment) string {
+	if j.Environment == "" {
+		return "deploys without an environment"
+	}
+	if strings.Contains(j.Environment, "${{") {
+		return fmt.Sprintf("uses environment `%v`, set by an expression that can not be verified", j.Environment)
+	}
+	env, ok := envs[strings.ToLower(j.Environment)]
+	if !ok {
+		return fmt.Sprintf("uses environment `%v`, which does not exist and is created without protection on first use", j.Environment)
+	}
+	for _, r := range env.ProtectionRules {
+		if r.GetType() == "required_reviewers" {
+			return ""
+		}
+	}
+	// Workflows triggered by pull_request run on the pull request's merge ref,
+	// which a deployment branch policy does not allow. Workflows triggered by
+	// pull_request_target run on the base branch, which it may allow.
+	if env.DeploymentBranchPolicy != nil && !slices.Contains(j.Triggers, "pull_request_target") {
+		return ""
+	}
+	return fmt.Sprintf("uses environment `%v`, which has no required reviewers", j.Environment)
+}
+
+// prTriggers returns the events in triggers that run wf, in order.
+func prTriggers(wf *actionlint.Workflow, triggers []string) []string {
+	var ts []string
+	for _, t := range triggers {
+		for _, e := range wf.On {
+			if e.EventName() == t {
+				ts = append(ts, t)
+				break
+			}
+		}
+	}
+	return ts
+}
+
+func usesDeployAction(j *actionlint.Job, deployActions []string) bool {
+	for _, s := range j.Steps {
+		if s == nil || s.Exec == nil {
+			continue
+		}
+		e, ok := s.Exec.(*actionlint.ExecAction)
+		if !ok || e.Uses == nil {
+			continue
+		}
+		name := strings.ToLower(strings.SplitN(e.Uses.Value, "@", 2)[0])
+		for _, d := range deployActions {
+			if name == strings.ToLower(d) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// listEnvironmentsReal returns the environments of a repo, keyed by lowercase
+// name as environment names are case insensitive.
+func listEnvironmentsReal(ctx context.Context, c *github.Client, owner, repo string) (map[string]*github.Environment, error) {
+	envs := make(map[string]*github.Environment)
+	opt := &github.EnvironmentListOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		er, resp, err := c.Repositories.ListEnvironments(ctx, owner, repo, opt)
+		if err != nil {
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				return envs, nil
+			}
+			return nil, err
+		}
+		for _, e := range er.Environments {
+			envs[strings.ToLower(e.GetName())] = e
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return envs, nil
+}
+
+// Fix implementing policydef.Policy.Fix(). Not supported.
+func (f ForkDeploy) Fix(ctx context.Context, c *github.Client, owner, repo string) error {
+	log.Warn().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Msg("Action fix is configured, but not implemented.")
+	return nil
+}
+
+// GetAction returns the configured action from Fork PR Deployments policy's
+// configuration stored in the org-level repo, default log. Implementing
+// policydef.Policy.GetAction()
+func (f ForkDeploy) GetAction(ctx context.Context, c *github.Client, owner, repo string) string {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	mc := mergeConfig(oc, orc, rc, repo)
+	return mc.Action
+}
+
+func getConfig(ctx context.Context, c *github.Client, owner, repo string) (*OrgConfig, *RepoConfig, *RepoConfig) {
+	oc := &OrgConfig{ // Fill out non-zero defaults
+		Action:        "log",
+		Triggers:      defaultTriggers,
+		DeployActions: defaultDeployActions,
+	}
+	if err := configFetchConfig(ctx, c, owner, "", configFile, config.OrgLevel, oc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	orc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.OrgRepoLevel, orc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgRepoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	rc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.RepoLevel, rc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "repoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	return oc, orc, rc
+}
+
+func mergeConfig(oc *OrgConfig, orc, rc *RepoConfig, repo string) *mergedConfig {
+	mc := &mergedConfig{
+		Action:        oc.Action,
+		Triggers:      oc.Triggers,
+		DeployActions: oc.DeployActions,
+	}
+	mc = mergeInRepoConfig(mc, orc, repo)
+
+	if !oc.OptConfig.DisableRepoOverride {
+		mc = mergeInRepoConfig(mc, rc, repo)
+	}
+	return mc
+}
+
+func mergeInRepoConfig(mc *mergedConfig, rc *RepoConfig, repo string) *mergedConfig {
+	if rc.Action != nil {
+		mc.Action = *rc.Action
+	}
+	if rc.DeployActions != nil {
+		mc.DeployActions = *rc.DeployActions
+	}
+	return mc
+}
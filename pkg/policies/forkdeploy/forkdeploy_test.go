@@ -0,0 +1,411 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forkdeploy
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/configtest"
+	"github.com/ossf/allstar/pkg/policies/workflowfiles"
+	"github.com/rhysd/actionlint"
+)
+
+func TestConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Org       OrgConfig
+		OrgRepo   RepoConfig
+		Repo      RepoConfig
+		ExpAction string
+		Exp       mergedConfig
+	}{
+		{
+			Name: "OrgOnly",
+			Org: OrgConfig{
+				Action:        "issue",
+				Triggers:      []string{"pull_request_target"},
+				DeployActions: []string{"actions/deploy-pages"},
+			},
+			OrgRepo:   RepoConfig{},
+			Repo:      RepoConfig{},
+			ExpAction: "issue",
+			Exp: mergedConfig{
+				Action:        "issue",
+				Triggers:      []string{"pull_request_target"},
+				DeployActions: []string{"actions/deploy-pages"},
+			},
+		},
+		{
+			Name: "OrgRepoOverOrg",
+			Org: OrgConfig{
+				Action:        "issue",
+				DeployActions: []string{"actions/deploy-pages"},
+			},
+			OrgRepo: RepoConfig{
+				Action:        github.String("log"),
+				DeployActions: &[]string{"my/deploy"},
+			},
+			Repo:      RepoConfig{},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action:        "log",
+				DeployActions: []string{"my/deploy"},
+			},
+		},
+		{
+			Name: "RepoOverAllOrg",
+			Org: OrgConfig{
+				Action: "issue",
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action:        github.String("email"),
+				DeployActions: &[]string{"my/deploy"},
+			},
+			ExpAction: "email",
+			Exp: mergedConfig{
+				Action:        "email",
+				DeployActions: []string{"my/deploy"},
+			},
+		},
+		{
+			Name: "RepoDisallowed",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					DisableRepoOverride: true,
+				},
+				Action:        "issue",
+				DeployActions: []string{"actions/deploy-pages"},
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action:        github.String("email"),
+				DeployActions: &[]string{"my/deploy"},
+			},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action:        "log",
+				DeployActions: []string{"actions/deploy-pages"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				switch ol {
+				case config.RepoLevel:
+					rc := out.(*RepoConfig)
+					*rc = test.Repo
+				case config.OrgRepoLevel:
+					orc := out.(*RepoConfig)
+					*orc = test.OrgRepo
+				case config.OrgLevel:
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+
+			f := ForkDeploy(true)
+			ctx := context.Background()
+
+			action := f.GetAction(ctx, nil, "", "thisrepo")
+			if action != test.ExpAction {
+				t.Errorf("Unexpected results. want %s, got %s", test.ExpAction, action)
+			}
+
+			oc, orc, rc := getConfig(ctx, nil, "", "thisrepo")
+			mc := mergeConfig(oc, orc, rc, "thisrepo")
+			if diff := cmp.Diff(&test.Exp, mc); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+const previewWorkflow = `name: Preview
+on: pull_request_target
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make test
+  preview:
+    runs-on: ubuntu-latest
+    environment: preview
+    steps:
+      - run: ./deploy.sh
+`
+
+const pagesWorkflow = `name: Pages
+on: [push, pull_request]
+jobs:
+  deploy:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/deploy-pages@v4
+`
+
+const stagingWorkflow = `name: Staging
+on: pull_request
+jobs:
+  deploy:
+    runs-on: ubuntu-latest
+    environment:
+      name: Staging
+      url: https://staging.example.com
+    steps:
+      - run: ./deploy.sh
+  dynamic:
+    runs-on: ubuntu-latest
+    environment: ${{ github.head_ref }}
+    steps:
+      - run: ./deploy.sh
+`
+
+const releaseWorkflow = `name: Release
+on: push
+jobs:
+  deploy:
+    runs-on: ubuntu-latest
+    environment: production
+    steps:
+      - uses: actions/deploy-pages@v4
+`
+
+func TestCheck(t *testing.T) {
+	reviewers := &github.Environment{
+		Name: github.String("preview"),
+		ProtectionRules: []*github.ProtectionRule{
+			{Type: github.String("required_reviewers")},
+		},
+	}
+	branches := &github.Environment{
+		Name:                   github.String("preview"),
+		DeploymentBranchPolicy: &github.BranchPolicy{ProtectedBranches: github.Bool(true)},
+	}
+	stagingBranches := &github.Environment{
+		Name:                   github.String("staging"),
+		DeploymentBranchPolicy: &github.BranchPolicy{ProtectedBranches: github.Bool(true)},
+	}
+
+	tests := []struct {
+		Name       string
+		Workflows  map[string]string
+		Envs       map[string]*github.Environment
+		Repo       RepoConfig
+		ExpPass    bool
+		ExpNotify  string
+		ExpDetails details
+	}{
+		{
+			Name:       "NoWorkflows",
+			ExpPass:    true,
+			ExpDetails: details{},
+		},
+		{
+			Name: "TrustedTrigger",
+			Workflows: map[string]string{
+				"release.yaml": releaseWorkflow,
+			},
+			ExpPass:    true,
+			ExpDetails: details{},
+		},
+		{
+			Name: "Unprotected",
+			Workflows: map[string]string{
+				"preview.yaml": previewWorkflow,
+				"pages.yaml":   pagesWorkflow,
+			},
+			Envs: map[string]*github.Environment{
+				"preview": {Name: github.String("preview")},
+			},
+			ExpPass:   false,
+			ExpNotify: "`.github/workflows/pages.yaml` (on pull_request):\n- job `deploy` deploys without an environment\n",
+			ExpDetails: details{
+				Findings: []finding{
+					{
+						Workflow: ".github/workflows/pages.yaml",
+						Job:      "deploy",
+						Triggers: []string{"pull_request"},
+						Reason:   "deploys without an environment",
+					},
+					{
+						Workflow:    ".github/workflows/preview.yaml",
+						Job:         "preview",
+						Triggers:    []string{"pull_request_target"},
+						Environment: "preview",
+						Reason:      "uses environment `preview`, which has no required reviewers",
+					},
+				},
+			},
+		},
+		{
+			Name: "RequiredReviewers",
+			Workflows: map[string]string{
+				"preview.yaml": previewWorkflow,
+			},
+			Envs: map[string]*github.Environment{
+				"preview": reviewers,
+			},
+			ExpPass:    true,
+			ExpDetails: details{},
+		},
+		{
+			Name: "BranchPolicyTarget",
+			Workflows: map[string]string{
+				"preview.yaml": previewWorkflow,
+			},
+			Envs: map[string]*github.Environment{
+				"preview": branches,
+			},
+			ExpPass: false,
+			ExpDetails: details{
+				Findings: []finding{
+					{
+						Workflow:    ".github/workflows/preview.yaml",
+						Job:         "preview",
+						Triggers:    []string{"pull_request_target"},
+						Environment: "preview",
+						Reason:      "uses environment `preview`, which has no required reviewers",
+					},
+				},
+			},
+		},
+		{
+			Name: "BranchPolicyPullRequest",
+			Workflows: map[string]string{
+				"staging.yaml": stagingWorkflow,
+			},
+			Envs: map[string]*github.Environment{
+				"staging": stagingBranches,
+			},
+			ExpPass: false,
+			ExpDetails: details{
+				Findings: []finding{
+					{
+						Workflow:    ".github/workflows/staging.yaml",
+						Job:         "dynamic",
+						Triggers:    []string{"pull_request"},
+						Environment: "${{ github.head_ref }}",
+						Reason:      "uses environment `${{ github.head_ref }}`, set by an expression that can not be verified",
+					},
+				},
+			},
+		},
+		{
+			Name: "MissingEnvironment",
+			Workflows: map[string]string{
+				"preview.yaml": previewWorkflow,
+			},
+			ExpPass: false,
+			ExpDetails: details{
+				Findings: []finding{
+					{
+						Workflow:    ".github/workflows/preview.yaml",
+						Job:         "preview",
+						Triggers:    []string{"pull_request_target"},
+						Environment: "preview",
+						Reason:      "uses environment `preview`, which does not exist and is created without protection on first use",
+					},
+				},
+			},
+		},
+		{
+			Name: "DeployActionsOverride",
+			Workflows: map[string]string{
+				"pages.yaml": pagesWorkflow,
+			},
+			Repo: RepoConfig{
+				DeployActions: &[]string{},
+			},
+			ExpPass:    true,
+			ExpDetails: details{},
+		},
+	}
+
+	configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+		c *github.Client, owner, repo string) (bool, error) {
+		return true, nil
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.RepoLevel {
+					rc := out.(*RepoConfig)
+					*rc = test.Repo
+				}
+				return nil
+			}
+			listWorkflows = func(ctx context.Context, c *github.Client, owner, repo, area string) ([]*workflowfiles.Workflow, error) {
+				var wfs []*workflowfiles.Workflow
+				for name, content := range test.Workflows {
+					wf, errs := actionlint.Parse([]byte(content))
+					if len(errs) > 0 {
+						t.Fatalf("Unexpected parse errors in %v: %v", name, errs)
+					}
+					wfs = append(wfs, &workflowfiles.Workflow{
+						Path:     ".github/workflows/" + name,
+						Workflow: wf,
+					})
+				}
+				return wfs, nil
+			}
+			listEnvironments = func(ctx context.Context, c *github.Client, owner, repo string) (map[string]*github.Environment, error) {
+				return test.Envs, nil
+			}
+
+			res, err := ForkDeploy(true).Check(context.Background(), nil, "thisorg", "thisrepo")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Pass != test.ExpPass {
+				t.Errorf("Unexpected pass: %v", res.Pass)
+			}
+			if test.ExpNotify != "" && !strings.Contains(res.NotifyText, test.ExpNotify) {
+				t.Errorf("Expected notify text to contain:\n%v\ngot:\n%v", test.ExpNotify, res.NotifyText)
+			}
+			if diff := cmp.Diff(test.ExpDetails, res.Details, cmp.AllowUnexported(details{})); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGeneratedPrecedence(t *testing.T) {
+	configtest.TestPrecedence(t, configtest.Precedence{
+		Org:   OrgConfig{},
+		Repo:  RepoConfig{},
+		Fetch: &configFetchConfig,
+		Merge: func() interface{} {
+			ctx := context.Background()
+			oc, orc, rc := getConfig(ctx, nil, "", configtest.Repo)
+			return mergeConfig(oc, orc, rc, configtest.Repo)
+		},
+	})
+}
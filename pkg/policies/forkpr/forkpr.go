@@ -0,0 +1,497 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package forkpr implements the Fork PR Workflows security policy. It checks
+// the repository GitHub Actions settings that control how workflows triggered
+// by pull requests from forks are run, and whether they can access secrets.
+package forkpr
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/policydef"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+const configFile = "fork_pr_workflows.yaml"
+const polName = "Fork PR Workflows"
+
+// Approval policies for workflows from fork pull requests, from weakest to
+// strongest.
+const (
+	approvalNewToGitHub  = "first_time_contributors_new_to_github"
+	approvalFirstTime    = "first_time_contributors"
+	approvalAllExternals = "all_external_contributors"
+)
+
+var approvalRank = map[string]int{
+	approvalNewToGitHub:  1,
+	approvalFirstTime:    2,
+	approvalAllExternals: 3,
+}
+
+const notifyText = `This policy requires that GitHub Actions workflows triggered by pull requests from forks need approval before running, and for private repositories, are not given secrets or write tokens.
+
+To fix this, from the main page of the repository go to Settings -> Actions -> General, and adjust the "Approval for running fork pull request workflows from contributors" and "Fork pull request workflows in private repositories" settings to match the organization policy.
+(For more information, see https://docs.github.com/en/repositories/managing-your-repositorys-settings-and-features/enabling-features-for-your-repository/managing-github-actions-settings-for-a-repository#controlling-changes-from-forks-to-workflows-in-public-repositories)`
+
+// OrgConfig is the org-level config definition for Fork PR Workflows.
+type OrgConfig struct {
+	// OptConfig is the standard org-level opt in/out config, RepoOverride
+	// applies to all config.
+	OptConfig config.OrgOptConfig `json:"optConfig"`
+
+	// Action defines which action to take, default log, other: issue...
+	Action string `json:"action"`
+
+	// ApprovalPolicy is the weakest allowed approval requirement for running
+	// workflows from fork pull requests. One of
+	// "first_time_contributors_new_to_github", "first_time_contributors", or
+	// "all_external_contributors", default "all_external_contributors"
+	// ("Require approval for all outside collaborators").
+	ApprovalPolicy string `json:"approvalPolicy"`
+
+	// AllowSecrets : set to true to allow workflows from fork pull requests to
+	// access secrets and variables in private repos, default false.
+	AllowSecrets bool `json:"allowSecrets"`
+
+	// AllowWriteTokens : set to true to allow workflows from fork pull
+	// requests to be given write tokens in private repos, default false.
+	AllowWriteTokens bool `json:"allowWriteTokens"`
+}
+
+// RepoConfig is the repo-level config for Fork PR Workflows.
+type RepoConfig struct {
+	// OptConfig is the standard repo-level opt in/out config.
+	OptConfig config.RepoOptConfig `json:"optConfig"`
+
+	// Action overrides the same setting in org-level, only if present.
+	Action *string `json:"action"`
+
+	// ApprovalPolicy overrides the same setting in org-level, only if present.
+	ApprovalPolicy *string `json:"approvalPolicy"`
+
+	// AllowSecrets overrides the same setting in org-level, only if present.
+	AllowSecrets *bool `json:"allowSecrets"`
+
+	// AllowWriteTokens overrides the same setting in org-level, only if
+	// present.
+	AllowWriteTokens *bool `json:"allowWriteTokens"`
+}
+
+type mergedConfig struct {
+	Action           string
+	ApprovalPolicy   string
+	AllowSecrets     bool
+	AllowWriteTokens bool
+}
+
+type details struct {
+	ApprovalPolicy       string
+	Private              bool
+	RunForkPRWorkflows   bool
+	SendSecrets          bool
+	SendWriteTokens      bool
+	RequireApproval      bool
+	SetAtOrgOrEnterprise bool
+}
+
+// forkPRApproval is the repo fork pull request contributor approval setting.
+type forkPRApproval struct {
+	ApprovalPolicy string `json:"approval_policy"`
+}
+
+// forkPRPrivate is the repo fork pull request workflow setting for private
+// repos.
+type forkPRPrivate struct {
+	RunWorkflowsFromForkPullRequests  bool `json:"run_workflows_from_fork_pull_requests"`
+	SendWriteTokensToWorkflows        bool `json:"send_write_tokens_to_workflows"`
+	SendSecretsAndVariables           bool `json:"send_secrets_and_variables"`
+	RequireApprovalForForkPRWorkflows bool `json:"require_approval_for_fork_pr_workflows"`
+}
+
+var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+
+var configIsEnabled func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig, c *github.Client, owner, repo string) (bool, error)
+
+func init() {
+	configFetchConfig = config.FetchConfig
+	configIsEnabled = config.IsEnabled
+}
+
+// actions is the subset of the GitHub API used, go-github does not provide the
+// fork pull request settings.
+type actions interface {
+	Get(context.Context, string, string) (*github.Repository,
+		*github.Response, error)
+	GetForkPRApproval(context.Context, string, string) (*forkPRApproval,
+		*github.Response, error)
+	EditForkPRApproval(context.Context, string, string, *forkPRApproval) (
+		*github.Response, error)
+	GetForkPRPrivate(context.Context, string, string) (*forkPRPrivate,
+		*github.Response, error)
+	EditForkPRPrivate(context.Context, string, string, *forkPRPrivate) (
+		*github.Response, error)
+}
+
+// ForkPR is the Fork PR Workflows policy object, implements policydef.Policy.
+type ForkPR bool
+
+// NewForkPR returns a new Fork PR Workflows policy.
+func NewForkPR() policydef.Policy {
+	var f ForkPR
+	return f
+}
+
+// Name returns the name of this policy, implementing policydef.Policy.Name()
+func (f ForkPR) Name() string {
+	return polName
+}
+
+// Check whether this policy is enabled or not
+func (f ForkPR) IsEnabled(ctx context.Context, c *github.Client, owner, repo string) (bool, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	return configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+}
+
+// Check performs the policy check for Fork PR Workflows based on the
+// configuration stored in the org/repo, implementing policydef.Policy.Check()
+func (f ForkPR) Check(ctx context.Context, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	return check(ctx, actionsClient{c}, c, owner, repo)
+}
+
+func check(ctx context.Context, act actions, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Bool("enabled", enabled).
+		Msg("Check repo enabled")
+
+	mc := mergeConfig(oc, orc, rc, repo)
+
+	r, _, err := act.Get(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	var d details
+	d.Private = r.GetPrivate()
+
+	pass := true
+	text := ""
+	if !d.Private {
+		a, _, err := act.GetForkPRApproval(ctx, owner, repo)
+		if err != nil {
+			return nil, err
+		}
+		d.ApprovalPolicy = a.ApprovalPolicy
+		if approvalRank[d.ApprovalPolicy] < approvalRank[mc.ApprovalPolicy] {
+			pass = false
+			text = text + fmt.Sprintf("Approval for fork pull request workflows is set to %q, but organization policy requires %q.\n",
+				d.ApprovalPolicy, mc.ApprovalPolicy)
+		}
+	} else {
+		p, rsp, err := act.GetForkPRPrivate(ctx, owner, repo)
+		if err != nil {
+			if rsp != nil && rsp.StatusCode == http.StatusConflict {
+				// Managed by the org or enterprise setting.
+				d.SetAtOrgOrEnterprise = true
+				return &policydef.Result{
+					Enabled:    enabled,
+					Pass:       true,
+					NotifyText: "",
+					Details:    d,
+				}, nil
+			}
+			return nil, err
+		}
+		d.RunForkPRWorkflows = p.RunWorkflowsFromForkPullRequests
+		d.SendSecrets = p.SendSecretsAndVariables
+		d.SendWriteTokens = p.SendWriteTokensToWorkflows
+		d.RequireApproval = p.RequireApprovalForForkPRWorkflows
+		if d.RunForkPRWorkflows {
+			if d.SendSecrets && !mc.AllowSecrets {
+				pass = false
+				text = text + "Workflows from fork pull requests are sent secrets and variables, but not by organization policy.\n"
+			}
+			if d.SendWriteTokens && !mc.AllowWriteTokens {
+				pass = false
+				text = text + "Workflows from fork pull requests are sent write tokens, but not by organization policy.\n"
+			}
+			if !d.RequireApproval {
+				pass = false
+				text = text + "Workflows from fork pull requests run without approval.\n"
+			}
+		}
+	}
+	if !pass {
+		text = text + "\n" + notifyText
+	}
+
+	return &policydef.Result{
+		Enabled:    enabled,
+		Pass:       pass,
+		NotifyText: text,
+		Details:    d,
+	}, nil
+}
+
+// Fix implementing policydef.Policy.Fix(). Updates the repo fork pull request
+// workflow settings to meet the policy.
+func (f ForkPR) Fix(ctx context.Context, c *github.Client, owner, repo string) error {
+	return fix(ctx, actionsClient{c}, c, owner, repo)
+}
+
+func fix(ctx context.Context, act actions, c *github.Client, owner,
+	repo string) error {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return nil
+	}
+	mc := mergeConfig(oc, orc, rc, repo)
+
+	r, _, err := act.Get(ctx, owner, repo)
+	if err != nil {
+		return err
+	}
+	if !r.GetPrivate() {
+		a, _, err := act.GetForkPRApproval(ctx, owner, repo)
+		if err != nil {
+			return err
+		}
+		if approvalRank[a.ApprovalPolicy] >= approvalRank[mc.ApprovalPolicy] {
+			return nil
+		}
+		rsp, err := act.EditForkPRApproval(ctx, owner, repo, &forkPRApproval{
+			ApprovalPolicy: mc.ApprovalPolicy,
+		})
+		if err != nil {
+			if rsp != nil && (rsp.StatusCode == http.StatusForbidden || rsp.StatusCode == http.StatusConflict) {
+				log.Warn().
+					Str("org", owner).
+					Str("repo", repo).
+					Str("area", polName).
+					Msg("Action set to fix, but fork pull request approval could not be updated.")
+				return nil
+			}
+			return err
+		}
+		log.Info().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", polName).
+			Str("approvalPolicy", mc.ApprovalPolicy).
+			Msg("Updated fork pull request approval with Fix action.")
+		return nil
+	}
+
+	p, rsp, err := act.GetForkPRPrivate(ctx, owner, repo)
+	if err != nil {
+		if rsp != nil && rsp.StatusCode == http.StatusConflict {
+			return nil
+		}
+		return err
+	}
+	if !p.RunWorkflowsFromForkPullRequests {
+		return nil
+	}
+	update := false
+	np := *p
+	if np.SendSecretsAndVariables && !mc.AllowSecrets {
+		np.SendSecretsAndVariables = false
+		update = true
+	}
+	if np.SendWriteTokensToWorkflows && !mc.AllowWriteTokens {
+		np.SendWriteTokensToWorkflows = false
+		update = true
+	}
+	if !np.RequireApprovalForForkPRWorkflows {
+		np.RequireApprovalForForkPRWorkflows = true
+		update = true
+	}
+	if !update {
+		return nil
+	}
+	if rsp, err := act.EditForkPRPrivate(ctx, owner, repo, &np); err != nil {
+		if rsp != nil && (rsp.StatusCode == http.StatusForbidden || rsp.StatusCode == http.StatusConflict) {
+			log.Warn().
+				Str("org", owner).
+				Str("repo", repo).
+				Str("area", polName).
+				Msg("Action set to fix, but private fork pull request workflow settings could not be updated.")
+			return nil
+		}
+		return err
+	}
+	log.Info().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Msg("Updated private fork pull request workflow settings with Fix action.")
+	return nil
+}
+
+// GetAction returns the configured action from Fork PR Workflows'
+// configuration stored in the org-level repo, default log. Implementing
+// policydef.Policy.GetAction()
+func (f ForkPR) GetAction(ctx context.Context, c *github.Client, owner, repo string) string {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	mc := mergeConfig(oc, orc, rc, repo)
+	return mc.Action
+}
+
+func getConfig(ctx context.Context, c *github.Client, owner, repo string) (*OrgConfig, *RepoConfig, *RepoConfig) {
+	oc := &OrgConfig{ // Fill out non-zero defaults
+		Action:         "log",
+		ApprovalPolicy: approvalAllExternals,
+	}
+	if err := configFetchConfig(ctx, c, owner, "", configFile, config.OrgLevel, oc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	orc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.OrgRepoLevel, orc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgRepoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	rc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.RepoLevel, rc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "repoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	return oc, orc, rc
+}
+
+func mergeConfig(oc *OrgConfig, orc, rc *RepoConfig, repo string) *mergedConfig {
+	mc := &mergedConfig{
+		Action:           oc.Action,
+		ApprovalPolicy:   oc.ApprovalPolicy,
+		AllowSecrets:     oc.AllowSecrets,
+		AllowWriteTokens: oc.AllowWriteTokens,
+	}
+	mc = mergeInRepoConfig(mc, orc, repo)
+
+	if !oc.OptConfig.DisableRepoOverride {
+		mc = mergeInRepoConfig(mc, rc, repo)
+	}
+	if _, ok := approvalRank[mc.ApprovalPolicy]; !ok {
+		log.Warn().
+			Str("repo", repo).
+			Str("area", polName).
+			Str("approvalPolicy", mc.ApprovalPolicy).
+			Msg("Unknown approval policy configured, using all_external_contributors.")
+		mc.ApprovalPolicy = approvalAllExternals
+	}
+	return mc
+}
+
+func mergeInRepoConfig(mc *mergedConfig, rc *RepoConfig, repo string) *mergedConfig {
+	if rc.Action != nil {
+		mc.Action = *rc.Action
+	}
+	if rc.ApprovalPolicy != nil {
+		mc.ApprovalPolicy = *rc.ApprovalPolicy
+	}
+	if rc.AllowSecrets != nil {
+		mc.AllowSecrets = *rc.AllowSecrets
+	}
+	if rc.AllowWriteTokens != nil {
+		mc.AllowWriteTokens = *rc.AllowWriteTokens
+	}
+	return mc
+}
+
+// actionsClient implements actions with a GitHub client.
+type actionsClient struct {
+	c *github.Client
+}
+
+func (a actionsClient) Get(ctx context.Context, owner, repo string) (
+	*github.Repository, *github.Response, error) {
+	return a.c.Repositories.Get(ctx, owner, repo)
+}
+
+func (a actionsClient) GetForkPRApproval(ctx context.Context, owner, repo string) (
+	*forkPRApproval, *github.Response, error) {
+	v := &forkPRApproval{}
+	rsp, err := a.do(ctx, "GET", owner, repo, "fork-pr-contributor-approval", nil, v)
+	if err != nil {
+		return nil, rsp, err
+	}
+	return v, rsp, nil
+}
+
+func (a actionsClient) EditForkPRApproval(ctx context.Context, owner, repo string,
+	v *forkPRApproval) (*github.Response, error) {
+	return a.do(ctx, "PUT", owner, repo, "fork-pr-contributor-approval", v, nil)
+}
+
+func (a actionsClient) GetForkPRPrivate(ctx context.Context, owner, repo string) (
+	*forkPRPrivate, *github.Response, error) {
+	v := &forkPRPrivate{}
+	rsp, err := a.do(ctx, "GET", owner, repo, "fork-pr-workflows-private-repos", nil, v)
+	if err != nil {
+		return nil, rsp, err
+	}
+	return v, rsp, nil
+}
+
+func (a actionsClient) EditForkPRPrivate(ctx context.Context, owner, repo string,
+	v *forkPRPrivate) (*github.Response, error) {
+	return a.do(ctx, "PUT", owner, repo, "fork-pr-workflows-private-repos", v, nil)
+}
+
+func (a actionsClient) do(ctx context.Context, method, owner, repo, setting string,
+	body, out interface{}) (*github.Response, error) {
+	u := fmt.Sprintf("repos/%v/%v/actions/permissions/%v", owner, repo, setting)
+	req, err := a.c.NewRequest(method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	return a.c.Do(ctx, req, out)
+}
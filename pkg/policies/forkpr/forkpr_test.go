@@ -0,0 +1,498 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forkpr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/configtest"
+)
+
+var get func(context.Context, string, string) (*github.Repository,
+	*github.Response, error)
+var getForkPRApproval func(context.Context, string, string) (*forkPRApproval,
+	*github.Response, error)
+var editForkPRApproval func(context.Context, string, string, *forkPRApproval) (
+	*github.Response, error)
+var getForkPRPrivate func(context.Context, string, string) (*forkPRPrivate,
+	*github.Response, error)
+var editForkPRPrivate func(context.Context, string, string, *forkPRPrivate) (
+	*github.Response, error)
+
+type mockActions struct{}
+
+func (m mockActions) Get(ctx context.Context, o, r string) (*github.Repository,
+	*github.Response, error) {
+	return get(ctx, o, r)
+}
+
+func (m mockActions) GetForkPRApproval(ctx context.Context, o, r string) (
+	*forkPRApproval, *github.Response, error) {
+	return getForkPRApproval(ctx, o, r)
+}
+
+func (m mockActions) EditForkPRApproval(ctx context.Context, o, r string,
+	a *forkPRApproval) (*github.Response, error) {
+	return editForkPRApproval(ctx, o, r, a)
+}
+
+func (m mockActions) GetForkPRPrivate(ctx context.Context, o, r string) (
+	*forkPRPrivate, *github.Response, error) {
+	return getForkPRPrivate(ctx, o, r)
+}
+
+func (m mockActions) EditForkPRPrivate(ctx context.Context, o, r string,
+	p *forkPRPrivate) (*github.Response, error) {
+	return editForkPRPrivate(ctx, o, r, p)
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Org       OrgConfig
+		OrgRepo   RepoConfig
+		Repo      RepoConfig
+		ExpAction string
+		Exp       mergedConfig
+	}{
+		{
+			Name: "OrgOnly",
+			Org: OrgConfig{
+				Action:         "issue",
+				ApprovalPolicy: approvalFirstTime,
+				AllowSecrets:   true,
+			},
+			OrgRepo:   RepoConfig{},
+			Repo:      RepoConfig{},
+			ExpAction: "issue",
+			Exp: mergedConfig{
+				Action:         "issue",
+				ApprovalPolicy: approvalFirstTime,
+				AllowSecrets:   true,
+			},
+		},
+		{
+			Name: "OrgRepoOverOrg",
+			Org: OrgConfig{
+				Action:         "issue",
+				ApprovalPolicy: approvalFirstTime,
+			},
+			OrgRepo: RepoConfig{
+				Action:           github.String("log"),
+				ApprovalPolicy:   github.String(approvalAllExternals),
+				AllowWriteTokens: github.Bool(true),
+			},
+			Repo:      RepoConfig{},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action:           "log",
+				ApprovalPolicy:   approvalAllExternals,
+				AllowWriteTokens: true,
+			},
+		},
+		{
+			Name: "RepoOverAllOrg",
+			Org: OrgConfig{
+				Action:         "issue",
+				ApprovalPolicy: approvalAllExternals,
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action:         github.String("email"),
+				ApprovalPolicy: github.String(approvalNewToGitHub),
+				AllowSecrets:   github.Bool(true),
+			},
+			ExpAction: "email",
+			Exp: mergedConfig{
+				Action:         "email",
+				ApprovalPolicy: approvalNewToGitHub,
+				AllowSecrets:   true,
+			},
+		},
+		{
+			Name: "RepoDisallowed",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					DisableRepoOverride: true,
+				},
+				Action:         "issue",
+				ApprovalPolicy: approvalAllExternals,
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action:         github.String("email"),
+				ApprovalPolicy: github.String(approvalNewToGitHub),
+				AllowSecrets:   github.Bool(true),
+			},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action:         "log",
+				ApprovalPolicy: approvalAllExternals,
+			},
+		},
+		{
+			Name: "UnknownApprovalPolicy",
+			Org: OrgConfig{
+				Action:         "issue",
+				ApprovalPolicy: "everyone",
+			},
+			OrgRepo:   RepoConfig{},
+			Repo:      RepoConfig{},
+			ExpAction: "issue",
+			Exp: mergedConfig{
+				Action:         "issue",
+				ApprovalPolicy: approvalAllExternals,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				switch ol {
+				case config.RepoLevel:
+					rc := out.(*RepoConfig)
+					*rc = test.Repo
+				case config.OrgRepoLevel:
+					orc := out.(*RepoConfig)
+					*orc = test.OrgRepo
+				case config.OrgLevel:
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+
+			f := ForkPR(true)
+			ctx := context.Background()
+
+			action := f.GetAction(ctx, nil, "", "thisrepo")
+			if action != test.ExpAction {
+				t.Errorf("Unexpected results. want %s, got %s", test.ExpAction, action)
+			}
+
+			oc, orc, rc := getConfig(ctx, nil, "", "thisrepo")
+			mc := mergeConfig(oc, orc, rc, "thisrepo")
+			if diff := cmp.Diff(&test.Exp, mc); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Org         OrgConfig
+		Private     bool
+		Approval    string
+		PrivateSet  forkPRPrivate
+		PrivateCode int
+		ExpPass     bool
+		ExpDetails  details
+	}{
+		{
+			Name:     "PublicApprovalAll",
+			Org:      OrgConfig{ApprovalPolicy: approvalAllExternals},
+			Approval: approvalAllExternals,
+			ExpPass:  true,
+			ExpDetails: details{
+				ApprovalPolicy: approvalAllExternals,
+			},
+		},
+		{
+			Name:     "PublicApprovalWeaker",
+			Org:      OrgConfig{ApprovalPolicy: approvalAllExternals},
+			Approval: approvalFirstTime,
+			ExpPass:  false,
+			ExpDetails: details{
+				ApprovalPolicy: approvalFirstTime,
+			},
+		},
+		{
+			Name:     "PublicApprovalStronger",
+			Org:      OrgConfig{ApprovalPolicy: approvalNewToGitHub},
+			Approval: approvalFirstTime,
+			ExpPass:  true,
+			ExpDetails: details{
+				ApprovalPolicy: approvalFirstTime,
+			},
+		},
+		{
+			Name:    "PrivateForkPRWorkflowsDisabled",
+			Org:     OrgConfig{},
+			Private: true,
+			PrivateSet: forkPRPrivate{
+				SendSecretsAndVariables: true,
+			},
+			ExpPass: true,
+			ExpDetails: details{
+				Private:     true,
+				SendSecrets: true,
+			},
+		},
+		{
+			Name:    "PrivateSecrets",
+			Org:     OrgConfig{},
+			Private: true,
+			PrivateSet: forkPRPrivate{
+				RunWorkflowsFromForkPullRequests:  true,
+				SendSecretsAndVariables:           true,
+				RequireApprovalForForkPRWorkflows: true,
+			},
+			ExpPass: false,
+			ExpDetails: details{
+				Private:            true,
+				RunForkPRWorkflows: true,
+				SendSecrets:        true,
+				RequireApproval:    true,
+			},
+		},
+		{
+			Name:    "PrivateSecretsAllowed",
+			Org:     OrgConfig{AllowSecrets: true},
+			Private: true,
+			PrivateSet: forkPRPrivate{
+				RunWorkflowsFromForkPullRequests:  true,
+				SendSecretsAndVariables:           true,
+				RequireApprovalForForkPRWorkflows: true,
+			},
+			ExpPass: true,
+			ExpDetails: details{
+				Private:            true,
+				RunForkPRWorkflows: true,
+				SendSecrets:        true,
+				RequireApproval:    true,
+			},
+		},
+		{
+			Name:    "PrivateNoApproval",
+			Org:     OrgConfig{},
+			Private: true,
+			PrivateSet: forkPRPrivate{
+				RunWorkflowsFromForkPullRequests: true,
+			},
+			ExpPass: false,
+			ExpDetails: details{
+				Private:            true,
+				RunForkPRWorkflows: true,
+			},
+		},
+		{
+			Name:        "PrivateSetAtOrg",
+			Org:         OrgConfig{},
+			Private:     true,
+			PrivateCode: http.StatusConflict,
+			ExpPass:     true,
+			ExpDetails: details{
+				Private:              true,
+				SetAtOrgOrEnterprise: true,
+			},
+		},
+	}
+
+	configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+		c *github.Client, owner, repo string) (bool, error) {
+		return true, nil
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.OrgLevel {
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+			get = func(context.Context, string, string) (*github.Repository,
+				*github.Response, error) {
+				return &github.Repository{Private: &test.Private}, nil, nil
+			}
+			getForkPRApproval = func(context.Context, string, string) (
+				*forkPRApproval, *github.Response, error) {
+				return &forkPRApproval{ApprovalPolicy: test.Approval}, nil, nil
+			}
+			getForkPRPrivate = func(context.Context, string, string) (
+				*forkPRPrivate, *github.Response, error) {
+				if test.PrivateCode != 0 {
+					return nil, &github.Response{
+						Response: &http.Response{StatusCode: test.PrivateCode},
+					}, errors.New("error")
+				}
+				p := test.PrivateSet
+				return &p, nil, nil
+			}
+
+			res, err := check(context.Background(), mockActions{}, nil, "", "thisrepo")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Pass != test.ExpPass {
+				t.Errorf("Unexpected pass: %v, notify text: %v", res.Pass, res.NotifyText)
+			}
+			if diff := cmp.Diff(test.ExpDetails, res.Details); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFix(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Org         OrgConfig
+		Private     bool
+		Approval    string
+		PrivateSet  forkPRPrivate
+		EditCode    int
+		ExpApproval *forkPRApproval
+		ExpPrivate  *forkPRPrivate
+	}{
+		{
+			Name:        "PublicNoChange",
+			Org:         OrgConfig{ApprovalPolicy: approvalAllExternals},
+			Approval:    approvalAllExternals,
+			ExpApproval: nil,
+		},
+		{
+			Name:     "PublicRequireApproval",
+			Org:      OrgConfig{ApprovalPolicy: approvalAllExternals},
+			Approval: approvalNewToGitHub,
+			ExpApproval: &forkPRApproval{
+				ApprovalPolicy: approvalAllExternals,
+			},
+		},
+		{
+			Name:     "PublicForbidden",
+			Org:      OrgConfig{ApprovalPolicy: approvalAllExternals},
+			Approval: approvalNewToGitHub,
+			EditCode: http.StatusForbidden,
+			ExpApproval: &forkPRApproval{
+				ApprovalPolicy: approvalAllExternals,
+			},
+		},
+		{
+			Name:    "PrivateNoChange",
+			Org:     OrgConfig{},
+			Private: true,
+			PrivateSet: forkPRPrivate{
+				RunWorkflowsFromForkPullRequests:  true,
+				RequireApprovalForForkPRWorkflows: true,
+			},
+			ExpPrivate: nil,
+		},
+		{
+			Name:    "PrivateRemoveSecrets",
+			Org:     OrgConfig{AllowWriteTokens: true},
+			Private: true,
+			PrivateSet: forkPRPrivate{
+				RunWorkflowsFromForkPullRequests: true,
+				SendSecretsAndVariables:          true,
+				SendWriteTokensToWorkflows:       true,
+			},
+			ExpPrivate: &forkPRPrivate{
+				RunWorkflowsFromForkPullRequests:  true,
+				SendWriteTokensToWorkflows:        true,
+				RequireApprovalForForkPRWorkflows: true,
+			},
+		},
+	}
+
+	configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+		c *github.Client, owner, repo string) (bool, error) {
+		return true, nil
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.OrgLevel {
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+			get = func(context.Context, string, string) (*github.Repository,
+				*github.Response, error) {
+				return &github.Repository{Private: &test.Private}, nil, nil
+			}
+			getForkPRApproval = func(context.Context, string, string) (
+				*forkPRApproval, *github.Response, error) {
+				return &forkPRApproval{ApprovalPolicy: test.Approval}, nil, nil
+			}
+			getForkPRPrivate = func(context.Context, string, string) (
+				*forkPRPrivate, *github.Response, error) {
+				p := test.PrivateSet
+				return &p, nil, nil
+			}
+			var gotApproval *forkPRApproval
+			editForkPRApproval = func(ctx context.Context, o, r string,
+				a *forkPRApproval) (*github.Response, error) {
+				gotApproval = a
+				if test.EditCode != 0 {
+					return &github.Response{
+						Response: &http.Response{StatusCode: test.EditCode},
+					}, errors.New("error")
+				}
+				return nil, nil
+			}
+			var gotPrivate *forkPRPrivate
+			editForkPRPrivate = func(ctx context.Context, o, r string,
+				p *forkPRPrivate) (*github.Response, error) {
+				gotPrivate = p
+				return nil, nil
+			}
+
+			if err := fix(context.Background(), mockActions{}, nil, "", "thisrepo"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.ExpApproval, gotApproval); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.ExpPrivate, gotPrivate); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGeneratedPrecedence(t *testing.T) {
+	configtest.TestPrecedence(t, configtest.Precedence{
+		Org:   OrgConfig{},
+		Repo:  RepoConfig{},
+		Fetch: &configFetchConfig,
+		Merge: func() interface{} {
+			ctx := context.Background()
+			oc, orc, rc := getConfig(ctx, nil, "", configtest.Repo)
+			return mergeConfig(oc, orc, rc, configtest.Repo)
+		},
+		// ApprovalPolicy is validated, and covered by TestConfigPrecedence.
+		Ignore: []string{"ApprovalPolicy"},
+	})
+}
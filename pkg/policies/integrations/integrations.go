@@ -0,0 +1,381 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package integrations implements the Required Integrations policy. It checks
+// that third-party GitHub Apps, such as code scanners, are installed on the
+// repository with the expected permissions.
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ossf/allstar/pkg/cache"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/policydef"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+const configFile = "required_integrations.yaml"
+const polName = "Required Integrations"
+
+const notifyText = `This policy requires that the listed GitHub Apps are installed on the repository, with at least the listed permissions, so that the repository is covered by the organization's required tooling, such as code scanners.
+
+To fix this, an organization owner must install the App on the repository, or accept the App's requested permissions, from the organization's "GitHub Apps" settings.
+(For more information, see https://docs.github.com/en/apps/using-github-apps/installing-a-github-app-from-a-third-party)`
+
+// permissionRank orders the permission levels.
+var permissionRank = map[string]int{
+	"read":  1,
+	"write": 2,
+	"admin": 3,
+}
+
+// OrgConfig is the org-level config definition for Required Integrations.
+type OrgConfig struct {
+	// OptConfig is the standard org-level opt in/out config, RepoOverride
+	// applies to all config.
+	OptConfig config.OrgOptConfig `json:"optConfig"`
+
+	// Action defines which action to take, default log, other: issue...
+	Action string `json:"action"`
+
+	// Integrations are the GitHub Apps required on repos. Each applies to the
+	// repos it selects.
+	Integrations []Integration `json:"integrations"`
+}
+
+// Integration is a GitHub App required on a set of repos.
+type Integration struct {
+	// App is the slug of the GitHub App, as in its page
+	// https://github.com/apps/<slug>.
+	App string `json:"app"`
+
+	// Permissions are the minimum permissions the App must be granted, keyed
+	// by permission name as in the GitHub API, ex: security_events: write.
+	Permissions map[string]string `json:"permissions"`
+
+	// Repos is a list of repo names the App is required on, default all
+	// repos. Globs are allowed.
+	Repos []string `json:"repos"`
+
+	// ExcludeRepos is a list of repo names the App is not required on, even if
+	// selected by Repos. Globs are allowed.
+	ExcludeRepos []string `json:"excludeRepos"`
+}
+
+// RepoConfig is the repo-level config for Required Integrations.
+type RepoConfig struct {
+	// OptConfig is the standard repo-level opt in/out config.
+	OptConfig config.RepoOptConfig `json:"optConfig"`
+
+	// Action overrides the same setting in org-level, only if present.
+	Action *string `json:"action"`
+}
+
+type mergedConfig struct {
+	Action   string
+	Required []Integration
+}
+
+type details struct {
+	Installed    []string
+	Missing      []string
+	Suspended    []string
+	Insufficient []string
+}
+
+var gc = cache.NewGlobCache(cache.DefaultSize)
+
+var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+
+var configIsEnabled func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig, c *github.Client, owner, repo string) (bool, error)
+
+var listInstallations func(context.Context, *github.Client, string) ([]*github.Installation, error)
+
+func init() {
+	configFetchConfig = config.FetchConfig
+	configIsEnabled = config.IsEnabled
+	listInstallations = listInstallationsReal
+}
+
+// Integrations is the Required Integrations policy object, implements
+// policydef.Policy.
+type Integrations bool
+
+// NewIntegrations returns a new Required Integrations policy.
+func NewIntegrations() policydef.Policy {
+	var i Integrations
+	return i
+}
+
+// Name returns the name of this policy, implementing policydef.Policy.Name()
+func (i Integrations) Name() string {
+	return polName
+}
+
+// Check whether this policy is enabled or not
+func (i Integrations) IsEnabled(ctx context.Context, c *github.Client, owner, repo string) (bool, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	return configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+}
+
+// Check performs the policy check for Required Integrations based on the
+// configuration stored in the org/repo, implementing policydef.Policy.Check()
+func (i Integrations) Check(ctx context.Context, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Bool("enabled", enabled).
+		Msg("Check repo enabled")
+
+	mc := mergeConfig(oc, orc, rc, repo)
+	var d details
+	if len(mc.Required) == 0 {
+		return &policydef.Result{
+			Enabled:    enabled,
+			Pass:       true,
+			NotifyText: "",
+			Details:    d,
+		}, nil
+	}
+
+	insts, err := listInstallations(ctx, c, owner)
+	if err != nil {
+		return nil, err
+	}
+	bySlug := make(map[string]*github.Installation)
+	for _, inst := range insts {
+		bySlug[strings.ToLower(inst.GetAppSlug())] = inst
+	}
+
+	var text strings.Builder
+	for _, in := range mc.Required {
+		inst, ok := bySlug[strings.ToLower(in.App)]
+		if !ok {
+			d.Missing = append(d.Missing, in.App)
+			fmt.Fprintf(&text, "- The %v App is not installed.\n", in.App)
+			continue
+		}
+		if inst.SuspendedAt != nil {
+			d.Suspended = append(d.Suspended, in.App)
+			fmt.Fprintf(&text, "- The %v App installation is suspended.\n", in.App)
+			continue
+		}
+		missing := missingPermissions(owner, repo, inst, in.Permissions)
+		if len(missing) > 0 {
+			d.Insufficient = append(d.Insufficient, in.App)
+			fmt.Fprintf(&text, "- The %v App is missing permissions: %v.\n", in.App, strings.Join(missing, ", "))
+			continue
+		}
+		d.Installed = append(d.Installed, in.App)
+	}
+
+	if text.Len() > 0 {
+		return &policydef.Result{
+			Enabled:    enabled,
+			Pass:       false,
+			NotifyText: "Required GitHub Apps are not installed as expected:\n" + text.String() + "\n" + notifyText,
+			Details:    d,
+		}, nil
+	}
+	return &policydef.Result{
+		Enabled:    enabled,
+		Pass:       true,
+		NotifyText: "",
+		Details:    d,
+	}, nil
+}
+
+// missingPermissions returns the permissions in want, as "name: level", that
+// inst is not granted at the level required.
+func missingPermissions(owner, repo string, inst *github.Installation, want map[string]string) []string {
+	var granted map[string]string
+	// InstallationPermissions has a field for each permission, tagged with
+	// the permission name used in the API and in config.
+	b, err := json.Marshal(inst.GetPermissions())
+	if err == nil {
+		err = json.Unmarshal(b, &granted)
+	}
+	if err != nil {
+		log.Warn().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", polName).
+			Str("app", inst.GetAppSlug()).
+			Err(err).
+			Msg("Unexpected error reading installation permissions.")
+	}
+	var missing []string
+	for name, level := range want {
+		rank, ok := permissionRank[strings.ToLower(level)]
+		if !ok {
+			log.Warn().
+				Str("org", owner).
+				Str("repo", repo).
+				Str("area", polName).
+				Str("permission", name).
+				Str("level", level).
+				Msg("Unknown permission level in config, ignoring.")
+			continue
+		}
+		if permissionRank[granted[name]] < rank {
+			missing = append(missing, fmt.Sprintf("%v: %v", name, level))
+		}
+	}
+	// Sort for stable issue text.
+	sort.Strings(missing)
+	return missing
+}
+
+// listInstallationsReal lists the GitHub App installations in the org. GitHub
+// does not expose which repositories another App's installation selected, so
+// the org's installations apply to all of its repositories.
+func listInstallationsReal(ctx context.Context, c *github.Client, owner string) ([]*github.Installation, error) {
+	var insts []*github.Installation
+	opt := &github.ListOptions{
+		PerPage: 100,
+	}
+	for {
+		is, resp, err := c.Organizations.ListInstallations(ctx, owner, opt)
+		if err != nil {
+			return nil, err
+		}
+		insts = append(insts, is.Installations...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return insts, nil
+}
+
+// Fix implementing policydef.Policy.Fix(). Not supported, Apps are installed
+// by organization owners.
+func (i Integrations) Fix(ctx context.Context, c *github.Client, owner, repo string) error {
+	log.Warn().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Msg("Action fix is configured, but not implemented.")
+	return nil
+}
+
+// GetAction returns the configured action from Required Integrations'
+// configuration stored in the org-level repo, default log. Implementing
+// policydef.Policy.GetAction()
+func (i Integrations) GetAction(ctx context.Context, c *github.Client, owner, repo string) string {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	mc := mergeConfig(oc, orc, rc, repo)
+	return mc.Action
+}
+
+func getConfig(ctx context.Context, c *github.Client, owner, repo string) (*OrgConfig, *RepoConfig, *RepoConfig) {
+	oc := &OrgConfig{ // Fill out non-zero defaults
+		Action: "log",
+	}
+	if err := configFetchConfig(ctx, c, owner, "", configFile, config.OrgLevel, oc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	orc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.OrgRepoLevel, orc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgRepoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	rc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.RepoLevel, rc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "repoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	return oc, orc, rc
+}
+
+func mergeConfig(oc *OrgConfig, orc, rc *RepoConfig, repo string) *mergedConfig {
+	mc := &mergedConfig{
+		Action:   oc.Action,
+		Required: selectIntegrations(repo, oc.Integrations, gc),
+	}
+	mc = mergeInRepoConfig(mc, orc, repo)
+
+	if !oc.OptConfig.DisableRepoOverride {
+		mc = mergeInRepoConfig(mc, rc, repo)
+	}
+	return mc
+}
+
+func mergeInRepoConfig(mc *mergedConfig, rc *RepoConfig, repo string) *mergedConfig {
+	if rc.Action != nil {
+		mc.Action = *rc.Action
+	}
+	return mc
+}
+
+// selectIntegrations returns the integrations required on repo.
+func selectIntegrations(repo string, is []Integration, gc *cache.GlobCache) []Integration {
+	var sel []Integration
+	for _, in := range is {
+		if (len(in.Repos) == 0 || matchAny(repo, in.Repos, gc)) && !matchAny(repo, in.ExcludeRepos, gc) {
+			sel = append(sel, in)
+		}
+	}
+	return sel
+}
+
+func matchAny(repo string, globs []string, gc *cache.GlobCache) bool {
+	for _, r := range globs {
+		g, err := gc.Compile(r)
+		if err != nil {
+			log.Warn().
+				Str("repo", repo).
+				Str("area", polName).
+				Str("glob", r).
+				Err(err).
+				Msg("Unexpected error compiling the glob.")
+		} else if g.Match(repo) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,309 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrations
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/configtest"
+)
+
+func TestConfigPrecedence(t *testing.T) {
+	snyk := Integration{
+		App:          "snyk",
+		Repos:        []string{"this*"},
+		ExcludeRepos: []string{"*-docs"},
+	}
+	scanner := Integration{
+		App:   "scanner",
+		Repos: []string{"otherrepo"},
+	}
+	tests := []struct {
+		Name      string
+		Org       OrgConfig
+		OrgRepo   RepoConfig
+		Repo      RepoConfig
+		ExpAction string
+		Exp       mergedConfig
+	}{
+		{
+			Name: "OrgOnly",
+			Org: OrgConfig{
+				Action:       "issue",
+				Integrations: []Integration{snyk, scanner},
+			},
+			OrgRepo:   RepoConfig{},
+			Repo:      RepoConfig{},
+			ExpAction: "issue",
+			Exp: mergedConfig{
+				Action:   "issue",
+				Required: []Integration{snyk},
+			},
+		},
+		{
+			Name: "OrgRepoOverOrg",
+			Org: OrgConfig{
+				Action: "issue",
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo:      RepoConfig{},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action: "log",
+			},
+		},
+		{
+			Name: "RepoOverAllOrg",
+			Org: OrgConfig{
+				Action: "issue",
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action: github.String("email"),
+			},
+			ExpAction: "email",
+			Exp: mergedConfig{
+				Action: "email",
+			},
+		},
+		{
+			Name: "RepoDisallowed",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					DisableRepoOverride: true,
+				},
+				Action: "issue",
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action: github.String("email"),
+			},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action: "log",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				switch ol {
+				case config.RepoLevel:
+					rc := out.(*RepoConfig)
+					*rc = test.Repo
+				case config.OrgRepoLevel:
+					orc := out.(*RepoConfig)
+					*orc = test.OrgRepo
+				case config.OrgLevel:
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+
+			i := Integrations(true)
+			ctx := context.Background()
+
+			action := i.GetAction(ctx, nil, "", "thisrepo")
+			if action != test.ExpAction {
+				t.Errorf("Unexpected results. want %s, got %s", test.ExpAction, action)
+			}
+
+			oc, orc, rc := getConfig(ctx, nil, "", "thisrepo")
+			mc := mergeConfig(oc, orc, rc, "thisrepo")
+			if diff := cmp.Diff(&test.Exp, mc); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSelectIntegrations(t *testing.T) {
+	is := []Integration{
+		{App: "all"},
+		{App: "services", Repos: []string{"svc-*"}},
+		{App: "nodocs", ExcludeRepos: []string{"*-docs"}},
+	}
+	tests := []struct {
+		Repo string
+		Exp  []string
+	}{
+		{"svc-api", []string{"all", "services", "nodocs"}},
+		{"svc-docs", []string{"all", "services"}},
+		{"website", []string{"all", "nodocs"}},
+	}
+	for _, test := range tests {
+		t.Run(test.Repo, func(t *testing.T) {
+			var got []string
+			for _, in := range selectIntegrations(test.Repo, is, gc) {
+				got = append(got, in.App)
+			}
+			if diff := cmp.Diff(test.Exp, got); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Integrations  []Integration
+		Installations []*github.Installation
+		ExpPass       bool
+		ExpNotify     string
+		ExpDetails    details
+	}{
+		{
+			Name:       "NoneRequired",
+			ExpPass:    true,
+			ExpDetails: details{},
+		},
+		{
+			Name: "Installed",
+			Integrations: []Integration{
+				{
+					App: "Snyk",
+					Permissions: map[string]string{
+						"security_events": "read",
+						"contents":        "read",
+					},
+				},
+			},
+			Installations: []*github.Installation{
+				{
+					AppSlug: github.String("snyk"),
+					Permissions: &github.InstallationPermissions{
+						SecurityEvents: github.String("write"),
+						Contents:       github.String("read"),
+					},
+				},
+			},
+			ExpPass: true,
+			ExpDetails: details{
+				Installed: []string{"Snyk"},
+			},
+		},
+		{
+			Name: "NotInstalled",
+			Integrations: []Integration{
+				{App: "snyk"},
+				{App: "scanner", Repos: []string{"otherrepo"}},
+			},
+			Installations: []*github.Installation{
+				{AppSlug: github.String("scanner")},
+			},
+			ExpPass:   false,
+			ExpNotify: "- The snyk App is not installed.\n",
+			ExpDetails: details{
+				Missing: []string{"snyk"},
+			},
+		},
+		{
+			Name: "SuspendedAndInsufficient",
+			Integrations: []Integration{
+				{App: "snyk"},
+				{
+					App: "scanner",
+					Permissions: map[string]string{
+						"security_events": "write",
+						"checks":          "write",
+						"contents":        "read",
+					},
+				},
+			},
+			Installations: []*github.Installation{
+				{
+					AppSlug:     github.String("snyk"),
+					SuspendedAt: &github.Timestamp{},
+				},
+				{
+					AppSlug: github.String("scanner"),
+					Permissions: &github.InstallationPermissions{
+						SecurityEvents: github.String("read"),
+						Contents:       github.String("read"),
+					},
+				},
+			},
+			ExpPass:   false,
+			ExpNotify: "- The snyk App installation is suspended.\n- The scanner App is missing permissions: checks: write, security_events: write.\n",
+			ExpDetails: details{
+				Suspended:    []string{"snyk"},
+				Insufficient: []string{"scanner"},
+			},
+		},
+	}
+
+	configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+		c *github.Client, owner, repo string) (bool, error) {
+		return true, nil
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.OrgLevel {
+					oc := out.(*OrgConfig)
+					oc.Integrations = test.Integrations
+				}
+				return nil
+			}
+			listInstallations = func(ctx context.Context, c *github.Client, owner string) ([]*github.Installation, error) {
+				return test.Installations, nil
+			}
+
+			res, err := Integrations(true).Check(context.Background(), nil, "thisorg", "thisrepo")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Pass != test.ExpPass {
+				t.Errorf("Unexpected pass: %v", res.Pass)
+			}
+			if test.ExpNotify != "" && !strings.Contains(res.NotifyText, test.ExpNotify) {
+				t.Errorf("Expected notify text to contain:\n%v\ngot:\n%v", test.ExpNotify, res.NotifyText)
+			}
+			if diff := cmp.Diff(test.ExpDetails, res.Details); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGeneratedPrecedence(t *testing.T) {
+	configtest.TestPrecedence(t, configtest.Precedence{
+		Org:   OrgConfig{},
+		Repo:  RepoConfig{},
+		Fetch: &configFetchConfig,
+		Merge: func() interface{} {
+			ctx := context.Background()
+			oc, orc, rc := getConfig(ctx, nil, "", configtest.Repo)
+			return mergeConfig(oc, orc, rc, configtest.Repo)
+		},
+	})
+}
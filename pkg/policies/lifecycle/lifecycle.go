@@ -0,0 +1,393 @@
+// Package lifecycle implements the Repository Lifecycle policy. It compares
+// the organization's repositories with those seen on the previous run, and
+// reports repositories that were created, deleted, or transferred in or out
+// without approval.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/policydef"
+	"github.com/ossf/allstar/pkg/state"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+const configFile = "repo_lifecycle.yaml"
+const polName = "Repository Lifecycle"
+
+const notifyText = `This policy reports repositories that were created, deleted, or transferred in or out of the organization without approval, so that security teams can review repository lifecycle changes.
+
+To approve a change ahead of time, set the organization custom property configured in the policy on the repository. Changes are reported for the number of days configured in the policy.
+(For more information, see https://docs.github.com/en/organizations/managing-organization-settings/managing-custom-properties-for-repositories-in-your-organization)`
+
+// OrgConfig is the org-level config definition for Repository Lifecycle.
+// There is no repo-level config, as it checks the organization's
+// repositories.
+type OrgConfig struct {
+	// Enabled : set to true to report the organization's repository changes,
+	// default false.
+	Enabled bool `json:"enabled"`
+
+	// Action defines which action to take, default log, other: issue...
+	Action string `json:"action"`
+
+	// ApprovalProperty is the name of an organization custom property. A
+	// change to a repository with a non-empty value for the property is
+	// approved, and not reported. For deleted and transferred out
+	// repositories, the value last seen is used.
+	ApprovalProperty string `json:"approvalProperty"`
+
+	// ReportDays is the number of days a change is reported after it is
+	// detected, default 7.
+	ReportDays int `json:"reportDays"`
+}
+
+type details struct {
+	// Baseline is set on the first run, when there is no previous snapshot
+	// to compare to.
+	Baseline bool
+	Changes  []string
+}
+
+// orgState is the state kept for an organization between runs.
+type orgState struct {
+	Snapshot *snapshot `json:"snapshot"`
+	Changes  []change  `json:"changes,omitempty"`
+}
+
+// snapshot is the organization's repositories seen on a run, by ID.
+type snapshot struct {
+	Taken time.Time          `json:"taken"`
+	Repos map[int64]repoInfo `json:"repos"`
+}
+
+type repoInfo struct {
+	Name     string    `json:"name"`
+	Created  time.Time `json:"created"`
+	Approved bool      `json:"approved,omitempty"`
+}
+
+// change is an unapproved change, reported until ReportDays after detected.
+type change struct {
+	Text     string    `json:"text"`
+	Detected time.Time `json:"detected"`
+}
+
+var mu sync.Mutex
+var store state.Interface = state.NewMemory()
+
+var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+
+var listRepos func(context.Context, *github.Client, string) ([]*github.Repository, error)
+
+var listApproved func(context.Context, *github.Client, string, string) (map[int64]bool, error)
+
+var getRepoByID func(context.Context, *github.Client, int64) (*github.Repository, error)
+
+var timeNow func() time.Time
+
+func init() {
+	configFetchConfig = config.FetchConfig
+	listRepos = listReposReal
+	listApproved = listApprovedReal
+	getRepoByID = getRepoByIDReal
+	timeNow = time.Now
+}
+
+// SetState sets the state store the organization snapshots and detected
+// changes are kept in. The default is in memory, so the first run after a
+// restart records a new baseline.
+func SetState(s state.Interface) {
+	mu.Lock()
+	defer mu.Unlock()
+	store = s
+}
+
+func getState() state.Interface {
+	mu.Lock()
+	defer mu.Unlock()
+	return store
+}
+
+func stateKey(owner string) string {
+	return state.Key("lifecycle", owner)
+}
+
+// Lifecycle is the Repository Lifecycle policy object, implements
+// policydef.OrgPolicy.
+type Lifecycle bool
+
+// NewLifecycle returns a new Repository Lifecycle policy.
+func NewLifecycle() policydef.OrgPolicy {
+	var l Lifecycle
+	return l
+}
+
+// Name returns the name of this policy, implementing
+// policydef.OrgPolicy.Name()
+func (l Lifecycle) Name() string {
+	return polName
+}
+
+// Check whether this policy is enabled or not
+func (l Lifecycle) IsEnabled(ctx context.Context, c *github.Client, owner string) (bool, error) {
+	oc := getConfig(ctx, c, owner)
+	return oc.Enabled, nil
+}
+
+// Check performs the policy check for Repository Lifecycle based on the
+// configuration stored in the org, implementing policydef.OrgPolicy.Check()
+func (l Lifecycle) Check(ctx context.Context, c *github.Client, owner string) (*policydef.Result, error) {
+	oc := getConfig(ctx, c, owner)
+	log.Info().
+		Str("org", owner).
+		Str("area", polName).
+		Bool("enabled", oc.Enabled).
+		Msg("Check org enabled")
+
+	var d details
+	if !oc.Enabled {
+		return &policydef.Result{
+			Enabled:    false,
+			Pass:       true,
+			NotifyText: "",
+			Details:    d,
+		}, nil
+	}
+
+	now := timeNow()
+	cur, err := takeSnapshot(ctx, c, owner, oc.ApprovalProperty, now)
+	if err != nil {
+		return nil, err
+	}
+
+	s := getState()
+	var st orgState
+	err = state.GetJSON(ctx, s, stateKey(owner), &st)
+	if err != nil && !errors.Is(err, state.ErrNotFound) {
+		return nil, err
+	}
+	var found []change
+	if st.Snapshot == nil {
+		d.Baseline = true
+	} else {
+		found, err = compare(ctx, c, owner, st.Snapshot, cur)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cutoff := now.AddDate(0, 0, -oc.ReportDays)
+	var kept []change
+	for _, ch := range append(st.Changes, found...) {
+		if ch.Detected.After(cutoff) {
+			kept = append(kept, ch)
+		}
+	}
+	st = orgState{
+		Snapshot: cur,
+		Changes:  kept,
+	}
+	if err := state.PutJSON(ctx, s, stateKey(owner), st); err != nil {
+		return nil, err
+	}
+
+	for _, ch := range kept {
+		d.Changes = append(d.Changes, fmt.Sprintf("%v (detected %v)", ch.Text, ch.Detected.UTC().Format("2006-01-02")))
+	}
+	if len(d.Changes) == 0 {
+		return &policydef.Result{
+			Enabled:    true,
+			Pass:       true,
+			NotifyText: "",
+			Details:    d,
+		}, nil
+	}
+	text := "Unapproved repository changes were detected in the organization:\n"
+	for _, ch := range d.Changes {
+		text = text + "- " + ch + "\n"
+	}
+	return &policydef.Result{
+		Enabled:    true,
+		Pass:       false,
+		NotifyText: text + "\n" + notifyText,
+		Details:    d,
+	}, nil
+}
+
+// takeSnapshot lists the organization's repositories, and whether each is
+// approved by the approval property.
+func takeSnapshot(ctx context.Context, c *github.Client, owner, property string, now time.Time) (*snapshot, error) {
+	repos, err := listRepos(ctx, c, owner)
+	if err != nil {
+		return nil, err
+	}
+	approved := map[int64]bool{}
+	if property != "" {
+		approved, err = listApproved(ctx, c, owner, property)
+		if err != nil {
+			return nil, err
+		}
+	}
+	s := &snapshot{
+		Taken: now,
+		Repos: make(map[int64]repoInfo, len(repos)),
+	}
+	for _, r := range repos {
+		s.Repos[r.GetID()] = repoInfo{
+			Name:     r.GetName(),
+			Created:  r.GetCreatedAt().Time,
+			Approved: approved[r.GetID()],
+		}
+	}
+	return s, nil
+}
+
+// compare returns the unapproved changes from prev to cur. Repositories that
+// are new, but were created before prev was taken, were transferred in.
+// Removed repositories still found under another owner were transferred out.
+func compare(ctx context.Context, c *github.Client, owner string, prev, cur *snapshot) ([]change, error) {
+	var found []change
+	for _, id := range sortedIDs(cur.Repos) {
+		ri := cur.Repos[id]
+		if _, ok := prev.Repos[id]; ok || ri.Approved {
+			continue
+		}
+		text := fmt.Sprintf("Repository %q was created.", ri.Name)
+		if ri.Created.Before(prev.Taken) {
+			text = fmt.Sprintf("Repository %q was transferred in.", ri.Name)
+		}
+		found = append(found, change{Text: text, Detected: cur.Taken})
+	}
+	for _, id := range sortedIDs(prev.Repos) {
+		ri := prev.Repos[id]
+		if _, ok := cur.Repos[id]; ok || ri.Approved {
+			continue
+		}
+		r, err := getRepoByID(ctx, c, id)
+		if err != nil {
+			return nil, err
+		}
+		text := fmt.Sprintf("Repository %q was deleted.", ri.Name)
+		if r != nil {
+			if r.GetOwner().GetLogin() == owner {
+				// Still in the org, but Allstar no longer has access.
+				continue
+			}
+			text = fmt.Sprintf("Repository %q was transferred out to %v.", ri.Name, r.GetFullName())
+		}
+		found = append(found, change{Text: text, Detected: cur.Taken})
+	}
+	return found, nil
+}
+
+func sortedIDs(repos map[int64]repoInfo) []int64 {
+	ids := make([]int64, 0, len(repos))
+	for id := range repos {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func listReposReal(ctx context.Context, c *github.Client, owner string) ([]*github.Repository, error) {
+	var repos []*github.Repository
+	opt := &github.RepositoryListByOrgOptions{
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+	for {
+		rs, resp, err := c.Repositories.ListByOrg(ctx, owner, opt)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, rs...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return repos, nil
+}
+
+func listApprovedReal(ctx context.Context, c *github.Client, owner, property string) (map[int64]bool, error) {
+	approved := make(map[int64]bool)
+	opt := &github.ListOptions{
+		PerPage: 100,
+	}
+	for {
+		vs, resp, err := c.Organizations.ListCustomPropertyValues(ctx, owner, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range vs {
+			for _, p := range v.Properties {
+				if p.PropertyName == property && p.GetValue() != "" {
+					approved[v.RepositoryID] = true
+				}
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return approved, nil
+}
+
+// getRepoByIDReal gets a repository by ID, or nil if it is not found.
+func getRepoByIDReal(ctx context.Context, c *github.Client, id int64) (*github.Repository, error) {
+	r, rsp, err := c.Repositories.GetByID(ctx, id)
+	if err != nil {
+		if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return r, nil
+}
+
+// Fix implementing policydef.OrgPolicy.Fix(). Not supported, changes are
+// reviewed by the security team.
+func (l Lifecycle) Fix(ctx context.Context, c *github.Client, owner string) error {
+	log.Warn().
+		Str("org", owner).
+		Str("area", polName).
+		Msg("Action fix is configured, but not implemented.")
+	return nil
+}
+
+// GetAction returns the configured action from Repository Lifecycle's
+// configuration stored in the org-level repo, default log. Implementing
+// policydef.OrgPolicy.GetAction()
+func (l Lifecycle) GetAction(ctx context.Context, c *github.Client, owner string) string {
+	oc := getConfig(ctx, c, owner)
+	return oc.Action
+}
+
+func getConfig(ctx context.Context, c *github.Client, owner string) *OrgConfig {
+	oc := &OrgConfig{ // Fill out non-zero defaults
+		Action:     "log",
+		ReportDays: 7,
+	}
+	if err := configFetchConfig(ctx, c, owner, "", configFile, config.OrgLevel, oc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("configLevel", "orgLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	return oc
+}
@@ -0,0 +1,184 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycle
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/policydef"
+	"github.com/ossf/allstar/pkg/state"
+)
+
+func TestCheck(t *testing.T) {
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	old := &github.Timestamp{Time: start.AddDate(-1, 0, 0)}
+	recent := &github.Timestamp{Time: start.Add(time.Hour)}
+	repo := func(id int64, name string, created *github.Timestamp) *github.Repository {
+		return &github.Repository{
+			ID:        github.Int64(id),
+			Name:      github.String(name),
+			CreatedAt: created,
+		}
+	}
+	base := []*github.Repository{
+		repo(1, ".allstar", old),
+		repo(2, "keep", old),
+		repo(3, "gone", old),
+		repo(4, "moved", old),
+		repo(5, "hidden", old),
+		repo(6, "approvedgone", old),
+	}
+	elsewhere := map[int64]*github.Repository{
+		4: {
+			FullName: github.String("otherorg/moved"),
+			Owner:    &github.User{Login: github.String("otherorg")},
+		},
+		5: {
+			FullName: github.String("thisorg/hidden"),
+			Owner:    &github.User{Login: github.String("thisorg")},
+		},
+	}
+	tests := []struct {
+		Name       string
+		Disabled   bool
+		Runs       [][]*github.Repository
+		Approved   map[int64]bool
+		Days       []int
+		ExpPass    bool
+		ExpDetails details
+	}{
+		{
+			Name:     "Disabled",
+			Disabled: true,
+			Runs:     [][]*github.Repository{base, append(base, repo(7, "new", recent))},
+			ExpPass:  true,
+		},
+		{
+			Name:       "Baseline",
+			Runs:       [][]*github.Repository{base},
+			ExpPass:    true,
+			ExpDetails: details{Baseline: true},
+		},
+		{
+			Name:    "Unchanged",
+			Runs:    [][]*github.Repository{base, base},
+			ExpPass: true,
+		},
+		{
+			Name: "Changes",
+			Runs: [][]*github.Repository{
+				base,
+				{
+					repo(1, ".allstar", old),
+					repo(2, "renamed", old),
+					repo(7, "new", recent),
+					repo(8, "transferred", old),
+					repo(9, "approvednew", recent),
+				},
+			},
+			Approved: map[int64]bool{6: true, 9: true},
+			ExpPass:  false,
+			ExpDetails: details{
+				Changes: []string{
+					`Repository "new" was created. (detected 2025-03-01)`,
+					`Repository "transferred" was transferred in. (detected 2025-03-01)`,
+					`Repository "gone" was deleted. (detected 2025-03-01)`,
+					`Repository "moved" was transferred out to otherorg/moved. (detected 2025-03-01)`,
+				},
+			},
+		},
+		{
+			Name: "Retained",
+			Runs: [][]*github.Repository{
+				base,
+				append(base, repo(7, "new", recent)),
+				append(base, repo(7, "new", recent)),
+			},
+			Days:    []int{0, 0, 6},
+			ExpPass: false,
+			ExpDetails: details{
+				Changes: []string{
+					`Repository "new" was created. (detected 2025-03-01)`,
+				},
+			},
+		},
+		{
+			Name: "Expired",
+			Runs: [][]*github.Repository{
+				base,
+				append(base, repo(7, "new", recent)),
+				append(base, repo(7, "new", recent)),
+			},
+			Days:    []int{0, 0, 8},
+			ExpPass: true,
+		},
+	}
+
+	getRepoByID = func(ctx context.Context, c *github.Client, id int64) (*github.Repository, error) {
+		return elsewhere[id], nil
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			SetState(state.NewMemory())
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				oc := out.(*OrgConfig)
+				oc.Enabled = !test.Disabled
+				oc.ApprovalProperty = "approved"
+				return nil
+			}
+			listApproved = func(ctx context.Context, c *github.Client, owner, property string) (map[int64]bool, error) {
+				if property != "approved" {
+					t.Errorf("Unexpected property: %v", property)
+				}
+				return test.Approved, nil
+			}
+			var res *policydef.Result
+			for i, run := range test.Runs {
+				days := 0
+				if test.Days != nil {
+					days = test.Days[i]
+				}
+				// Later runs are an hour after the first, so that repos
+				// created recently are after the baseline.
+				now := start.AddDate(0, 0, days)
+				if i > 0 && days == 0 {
+					now = now.Add(2 * time.Hour)
+				}
+				timeNow = func() time.Time { return now }
+				listRepos = func(ctx context.Context, c *github.Client, owner string) ([]*github.Repository, error) {
+					return run, nil
+				}
+				var err error
+				res, err = Lifecycle(true).Check(context.Background(), nil, "thisorg")
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			}
+			if res.Pass != test.ExpPass {
+				t.Errorf("Unexpected pass: %v", res.Pass)
+			}
+			if diff := cmp.Diff(test.ExpDetails, res.Details); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
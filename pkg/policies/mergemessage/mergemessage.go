@@ -0,0 +1,419 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mergemessage implements the Merge Commit Messages policy. It checks
+// the default title and message of squash merge and merge commits in the
+// repository "Pull Requests" settings, so that history follows the
+// organization's conventions.
+package mergemessage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/policydef"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+const configFile = "merge_commit_messages.yaml"
+const polName = "Merge Commit Messages"
+
+// Names of the repository settings, as reported in details.
+const (
+	settingSquashTitle   = "squash_merge_commit_title"
+	settingSquashMessage = "squash_merge_commit_message"
+	settingMergeTitle    = "merge_commit_title"
+	settingMergeMessage  = "merge_commit_message"
+)
+
+// validValues are the values accepted by GitHub for each setting.
+var validValues = map[string][]string{
+	settingSquashTitle:   {"PR_TITLE", "COMMIT_OR_PR_TITLE"},
+	settingSquashMessage: {"PR_BODY", "COMMIT_MESSAGES", "BLANK"},
+	settingMergeTitle:    {"PR_TITLE", "MERGE_MESSAGE"},
+	settingMergeMessage:  {"PR_BODY", "PR_TITLE", "BLANK"},
+}
+
+const notifyText = `This policy requires the default commit title and message of merged pull requests to follow the organization's conventions, so that the history of the default branch records what was changed, and why.
+
+To fix this, from the main page of the repository go to Settings -> General -> Pull Requests, and update the default commit message of "Allow merge commits" and "Allow squash merging".
+(For more information, see https://docs.github.com/en/repositories/configuring-branches-and-merges-in-your-repository/configuring-pull-request-merges/configuring-commit-squashing-for-pull-requests)`
+
+// OrgConfig is the org-level config definition for Merge Commit Messages.
+type OrgConfig struct {
+	// OptConfig is the standard org-level opt in/out config, RepoOverride
+	// applies to all config.
+	OptConfig config.OrgOptConfig `json:"optConfig"`
+
+	// Action defines which action to take, default log, other: issue...
+	Action string `json:"action"`
+
+	// SquashMergeCommitTitle is the required default title of squash merge
+	// commits, one of: PR_TITLE, COMMIT_OR_PR_TITLE. Default PR_TITLE, set to
+	// empty to not check.
+	SquashMergeCommitTitle string `json:"squashMergeCommitTitle"`
+
+	// SquashMergeCommitMessage is the required default message of squash
+	// merge commits, one of: PR_BODY, COMMIT_MESSAGES, BLANK. Default
+	// PR_BODY, set to empty to not check.
+	SquashMergeCommitMessage string `json:"squashMergeCommitMessage"`
+
+	// MergeCommitTitle is the required default title of merge commits, one
+	// of: PR_TITLE, MERGE_MESSAGE. Default empty, not checked.
+	MergeCommitTitle string `json:"mergeCommitTitle"`
+
+	// MergeCommitMessage is the required default message of merge commits,
+	// one of: PR_BODY, PR_TITLE, BLANK. Default empty, not checked.
+	MergeCommitMessage string `json:"mergeCommitMessage"`
+}
+
+// RepoConfig is the repo-level config for Merge Commit Messages.
+type RepoConfig struct {
+	// OptConfig is the standard repo-level opt in/out config.
+	OptConfig config.RepoOptConfig `json:"optConfig"`
+
+	// Action overrides the same setting in org-level, only if present.
+	Action *string `json:"action"`
+
+	// SquashMergeCommitTitle overrides the same setting in org-level, only if
+	// present.
+	SquashMergeCommitTitle *string `json:"squashMergeCommitTitle"`
+
+	// SquashMergeCommitMessage overrides the same setting in org-level, only
+	// if present.
+	SquashMergeCommitMessage *string `json:"squashMergeCommitMessage"`
+
+	// MergeCommitTitle overrides the same setting in org-level, only if
+	// present.
+	MergeCommitTitle *string `json:"mergeCommitTitle"`
+
+	// MergeCommitMessage overrides the same setting in org-level, only if
+	// present.
+	MergeCommitMessage *string `json:"mergeCommitMessage"`
+}
+
+type mergedConfig struct {
+	Action                   string
+	SquashMergeCommitTitle   string
+	SquashMergeCommitMessage string
+	MergeCommitTitle         string
+	MergeCommitMessage       string
+}
+
+type details struct {
+	SquashMergeCommitTitle   string
+	SquashMergeCommitMessage string
+	MergeCommitTitle         string
+	MergeCommitMessage       string
+	// Mismatched are the settings not set to the required value, as
+	// "setting: value, required: value".
+	Mismatched []string
+}
+
+var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+
+var configIsEnabled func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig, c *github.Client, owner, repo string) (bool, error)
+
+func init() {
+	configFetchConfig = config.FetchConfig
+	configIsEnabled = config.IsEnabled
+}
+
+type repositories interface {
+	Get(context.Context, string, string) (*github.Repository,
+		*github.Response, error)
+	Edit(context.Context, string, string, *github.Repository) (
+		*github.Repository, *github.Response, error)
+}
+
+// MergeMessage is the Merge Commit Messages policy object, implements
+// policydef.Policy.
+type MergeMessage bool
+
+// NewMergeMessage returns a new Merge Commit Messages policy.
+func NewMergeMessage() policydef.Policy {
+	var m MergeMessage
+	return m
+}
+
+// Name returns the name of this policy, implementing policydef.Policy.Name()
+func (m MergeMessage) Name() string {
+	return polName
+}
+
+// Check whether this policy is enabled or not
+func (m MergeMessage) IsEnabled(ctx context.Context, c *github.Client, owner, repo string) (bool, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	return configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+}
+
+// Check performs the policy check for Merge Commit Messages based on the
+// configuration stored in the org/repo, implementing policydef.Policy.Check()
+func (m MergeMessage) Check(ctx context.Context, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	return check(ctx, c.Repositories, c, owner, repo)
+}
+
+func check(ctx context.Context, rep repositories, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Bool("enabled", enabled).
+		Msg("Check repo enabled")
+
+	mc := mergeConfig(oc, orc, rc, repo)
+
+	r, _, err := rep.Get(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	d, _ := getDetails(r, mc)
+
+	if len(d.Mismatched) == 0 {
+		return &policydef.Result{
+			Enabled:    enabled,
+			Pass:       true,
+			NotifyText: "",
+			Details:    d,
+		}, nil
+	}
+	text := "The following merge commit settings do not follow the organization's conventions:\n"
+	for _, m := range d.Mismatched {
+		text = text + fmt.Sprintf("- %v\n", m)
+	}
+	return &policydef.Result{
+		Enabled:    enabled,
+		Pass:       false,
+		NotifyText: text + "\n" + notifyText,
+		Details:    d,
+	}, nil
+}
+
+// getDetails compares the settings of r to the configured values. It also
+// returns the settings to edit to fix the mismatches. The squash settings are
+// only checked if squash merging is allowed, and the merge commit settings if
+// merge commits are allowed. Settings not reported by GitHub, or configured
+// with a value GitHub does not accept, are not checked.
+func getDetails(r *github.Repository, mc *mergedConfig) (details, *github.Repository) {
+	d := details{
+		SquashMergeCommitTitle:   r.GetSquashMergeCommitTitle(),
+		SquashMergeCommitMessage: r.GetSquashMergeCommitMessage(),
+		MergeCommitTitle:         r.GetMergeCommitTitle(),
+		MergeCommitMessage:       r.GetMergeCommitMessage(),
+	}
+	edit := &github.Repository{}
+	compare := func(setting, got, want string, set **string) {
+		want = validate(setting, want, r.GetName())
+		if want == "" || got == "" || got == want {
+			return
+		}
+		d.Mismatched = append(d.Mismatched, fmt.Sprintf("%v: %v, required: %v", setting, got, want))
+		*set = github.String(want)
+	}
+	if r.GetAllowSquashMerge() {
+		compare(settingSquashTitle, d.SquashMergeCommitTitle, mc.SquashMergeCommitTitle, &edit.SquashMergeCommitTitle)
+		compare(settingSquashMessage, d.SquashMergeCommitMessage, mc.SquashMergeCommitMessage, &edit.SquashMergeCommitMessage)
+	}
+	if r.GetAllowMergeCommit() {
+		compare(settingMergeTitle, d.MergeCommitTitle, mc.MergeCommitTitle, &edit.MergeCommitTitle)
+		compare(settingMergeMessage, d.MergeCommitMessage, mc.MergeCommitMessage, &edit.MergeCommitMessage)
+	}
+	// GitHub requires the title and message of each kind to be updated
+	// together.
+	if edit.SquashMergeCommitTitle != nil || edit.SquashMergeCommitMessage != nil {
+		if edit.SquashMergeCommitTitle == nil {
+			edit.SquashMergeCommitTitle = r.SquashMergeCommitTitle
+		}
+		if edit.SquashMergeCommitMessage == nil {
+			edit.SquashMergeCommitMessage = r.SquashMergeCommitMessage
+		}
+	}
+	if edit.MergeCommitTitle != nil || edit.MergeCommitMessage != nil {
+		if edit.MergeCommitTitle == nil {
+			edit.MergeCommitTitle = r.MergeCommitTitle
+		}
+		if edit.MergeCommitMessage == nil {
+			edit.MergeCommitMessage = r.MergeCommitMessage
+		}
+	}
+	return d, edit
+}
+
+// Fix implementing policydef.Policy.Fix(). Updates the mismatched merge commit
+// settings on the repo.
+func (m MergeMessage) Fix(ctx context.Context, c *github.Client, owner, repo string) error {
+	return fix(ctx, c.Repositories, c, owner, repo)
+}
+
+func fix(ctx context.Context, rep repositories, c *github.Client, owner,
+	repo string) error {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return nil
+	}
+	mc := mergeConfig(oc, orc, rc, repo)
+
+	r, _, err := rep.Get(ctx, owner, repo)
+	if err != nil {
+		return err
+	}
+	d, edit := getDetails(r, mc)
+	if len(d.Mismatched) == 0 {
+		return nil
+	}
+	_, rsp, err := rep.Edit(ctx, owner, repo, edit)
+	if err != nil {
+		if rsp != nil && (rsp.StatusCode == http.StatusForbidden || rsp.StatusCode == http.StatusUnprocessableEntity) {
+			// Forbidden without administration write permission, unprocessable
+			// for a combination of title and message GitHub does not accept.
+			log.Warn().
+				Str("org", owner).
+				Str("repo", repo).
+				Str("area", polName).
+				Strs("mismatched", d.Mismatched).
+				Err(err).
+				Msg("Action set to fix, but merge commit settings could not be updated.")
+			return nil
+		}
+		return err
+	}
+	log.Info().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Strs("updated", d.Mismatched).
+		Msg("Updated merge commit settings with Fix action.")
+	return nil
+}
+
+// GetAction returns the configured action from Merge Commit Messages'
+// configuration stored in the org-level repo, default log. Implementing
+// policydef.Policy.GetAction()
+func (m MergeMessage) GetAction(ctx context.Context, c *github.Client, owner, repo string) string {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	mc := mergeConfig(oc, orc, rc, repo)
+	return mc.Action
+}
+
+func getConfig(ctx context.Context, c *github.Client, owner, repo string) (*OrgConfig, *RepoConfig, *RepoConfig) {
+	oc := &OrgConfig{ // Fill out non-zero defaults
+		Action:                   "log",
+		SquashMergeCommitTitle:   "PR_TITLE",
+		SquashMergeCommitMessage: "PR_BODY",
+	}
+	if err := configFetchConfig(ctx, c, owner, "", configFile, config.OrgLevel, oc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	orc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.OrgRepoLevel, orc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgRepoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	rc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.RepoLevel, rc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "repoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	return oc, orc, rc
+}
+
+func mergeConfig(oc *OrgConfig, orc, rc *RepoConfig, repo string) *mergedConfig {
+	mc := &mergedConfig{
+		Action:                   oc.Action,
+		SquashMergeCommitTitle:   oc.SquashMergeCommitTitle,
+		SquashMergeCommitMessage: oc.SquashMergeCommitMessage,
+		MergeCommitTitle:         oc.MergeCommitTitle,
+		MergeCommitMessage:       oc.MergeCommitMessage,
+	}
+	mc = mergeInRepoConfig(mc, orc, repo)
+
+	if !oc.OptConfig.DisableRepoOverride {
+		mc = mergeInRepoConfig(mc, rc, repo)
+	}
+	return mc
+}
+
+func mergeInRepoConfig(mc *mergedConfig, rc *RepoConfig, repo string) *mergedConfig {
+	if rc.Action != nil {
+		mc.Action = *rc.Action
+	}
+	if rc.SquashMergeCommitTitle != nil {
+		mc.SquashMergeCommitTitle = *rc.SquashMergeCommitTitle
+	}
+	if rc.SquashMergeCommitMessage != nil {
+		mc.SquashMergeCommitMessage = *rc.SquashMergeCommitMessage
+	}
+	if rc.MergeCommitTitle != nil {
+		mc.MergeCommitTitle = *rc.MergeCommitTitle
+	}
+	if rc.MergeCommitMessage != nil {
+		mc.MergeCommitMessage = *rc.MergeCommitMessage
+	}
+	return mc
+}
+
+// validate returns the configured value of setting, or empty, not checked, if
+// it is not a value accepted by GitHub.
+func validate(setting, value, repo string) string {
+	if value == "" {
+		return ""
+	}
+	for _, v := range validValues[setting] {
+		if v == value {
+			return value
+		}
+	}
+	log.Warn().
+		Str("repo", repo).
+		Str("area", polName).
+		Str("setting", setting).
+		Str("value", value).
+		Strs("valid", validValues[setting]).
+		Msg("Invalid config value, setting not checked.")
+	return ""
+}
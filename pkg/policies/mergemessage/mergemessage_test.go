@@ -0,0 +1,391 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mergemessage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/configtest"
+)
+
+var get func(context.Context, string, string) (*github.Repository,
+	*github.Response, error)
+var edit func(context.Context, string, string, *github.Repository) (
+	*github.Repository, *github.Response, error)
+
+type mockRepos struct{}
+
+func (m mockRepos) Get(ctx context.Context, o, r string) (*github.Repository,
+	*github.Response, error) {
+	return get(ctx, o, r)
+}
+
+func (m mockRepos) Edit(ctx context.Context, o, r string,
+	repo *github.Repository) (*github.Repository, *github.Response, error) {
+	return edit(ctx, o, r, repo)
+}
+
+func repository(squashTitle, squashMessage, mergeTitle, mergeMessage string) *github.Repository {
+	return &github.Repository{
+		AllowSquashMerge:         github.Bool(true),
+		AllowMergeCommit:         github.Bool(true),
+		SquashMergeCommitTitle:   github.String(squashTitle),
+		SquashMergeCommitMessage: github.String(squashMessage),
+		MergeCommitTitle:         github.String(mergeTitle),
+		MergeCommitMessage:       github.String(mergeMessage),
+	}
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Org       OrgConfig
+		OrgRepo   RepoConfig
+		Repo      RepoConfig
+		ExpAction string
+		Exp       mergedConfig
+	}{
+		{
+			Name: "OrgOnly",
+			Org: OrgConfig{
+				Action:                 "issue",
+				SquashMergeCommitTitle: "PR_TITLE",
+			},
+			OrgRepo:   RepoConfig{},
+			Repo:      RepoConfig{},
+			ExpAction: "issue",
+			Exp: mergedConfig{
+				Action:                 "issue",
+				SquashMergeCommitTitle: "PR_TITLE",
+			},
+		},
+		{
+			Name: "OrgRepoOverOrg",
+			Org: OrgConfig{
+				Action:                 "issue",
+				SquashMergeCommitTitle: "PR_TITLE",
+			},
+			OrgRepo: RepoConfig{
+				Action:                 github.String("log"),
+				SquashMergeCommitTitle: github.String("COMMIT_OR_PR_TITLE"),
+				MergeCommitMessage:     github.String("BLANK"),
+			},
+			Repo:      RepoConfig{},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action:                 "log",
+				SquashMergeCommitTitle: "COMMIT_OR_PR_TITLE",
+				MergeCommitMessage:     "BLANK",
+			},
+		},
+		{
+			Name: "RepoOverAllOrg",
+			Org: OrgConfig{
+				Action:                   "issue",
+				SquashMergeCommitMessage: "PR_BODY",
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action:                   github.String("email"),
+				SquashMergeCommitMessage: github.String("COMMIT_MESSAGES"),
+			},
+			ExpAction: "email",
+			Exp: mergedConfig{
+				Action:                   "email",
+				SquashMergeCommitMessage: "COMMIT_MESSAGES",
+			},
+		},
+		{
+			Name: "RepoDisallowed",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					DisableRepoOverride: true,
+				},
+				Action:                   "issue",
+				SquashMergeCommitMessage: "PR_BODY",
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action:                   github.String("email"),
+				SquashMergeCommitMessage: github.String("BLANK"),
+			},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action:                   "log",
+				SquashMergeCommitMessage: "PR_BODY",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				switch ol {
+				case config.RepoLevel:
+					rc := out.(*RepoConfig)
+					*rc = test.Repo
+				case config.OrgRepoLevel:
+					orc := out.(*RepoConfig)
+					*orc = test.OrgRepo
+				case config.OrgLevel:
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+
+			m := MergeMessage(true)
+			ctx := context.Background()
+
+			action := m.GetAction(ctx, nil, "", "thisrepo")
+			if action != test.ExpAction {
+				t.Errorf("Unexpected results. want %s, got %s", test.ExpAction, action)
+			}
+
+			oc, orc, rc := getConfig(ctx, nil, "", "thisrepo")
+			mc := mergeConfig(oc, orc, rc, "thisrepo")
+			if diff := cmp.Diff(&test.Exp, mc); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Org        OrgConfig
+		Repo       *github.Repository
+		ExpPass    bool
+		ExpDetails details
+	}{
+		{
+			Name: "Compliant",
+			Org: OrgConfig{
+				SquashMergeCommitTitle:   "PR_TITLE",
+				SquashMergeCommitMessage: "PR_BODY",
+			},
+			Repo:    repository("PR_TITLE", "PR_BODY", "MERGE_MESSAGE", "PR_TITLE"),
+			ExpPass: true,
+			ExpDetails: details{
+				SquashMergeCommitTitle:   "PR_TITLE",
+				SquashMergeCommitMessage: "PR_BODY",
+				MergeCommitTitle:         "MERGE_MESSAGE",
+				MergeCommitMessage:       "PR_TITLE",
+			},
+		},
+		{
+			Name: "Mismatched",
+			Org: OrgConfig{
+				SquashMergeCommitTitle:   "PR_TITLE",
+				SquashMergeCommitMessage: "PR_BODY",
+				MergeCommitTitle:         "PR_TITLE",
+			},
+			Repo:    repository("COMMIT_OR_PR_TITLE", "COMMIT_MESSAGES", "MERGE_MESSAGE", "PR_TITLE"),
+			ExpPass: false,
+			ExpDetails: details{
+				SquashMergeCommitTitle:   "COMMIT_OR_PR_TITLE",
+				SquashMergeCommitMessage: "COMMIT_MESSAGES",
+				MergeCommitTitle:         "MERGE_MESSAGE",
+				MergeCommitMessage:       "PR_TITLE",
+				Mismatched: []string{
+					"squash_merge_commit_title: COMMIT_OR_PR_TITLE, required: PR_TITLE",
+					"squash_merge_commit_message: COMMIT_MESSAGES, required: PR_BODY",
+					"merge_commit_title: MERGE_MESSAGE, required: PR_TITLE",
+				},
+			},
+		},
+		{
+			Name: "SquashNotAllowed",
+			Org: OrgConfig{
+				SquashMergeCommitTitle: "PR_TITLE",
+			},
+			Repo: &github.Repository{
+				AllowSquashMerge:       github.Bool(false),
+				SquashMergeCommitTitle: github.String("COMMIT_OR_PR_TITLE"),
+			},
+			ExpPass: true,
+			ExpDetails: details{
+				SquashMergeCommitTitle: "COMMIT_OR_PR_TITLE",
+			},
+		},
+		{
+			Name: "InvalidConfig",
+			Org: OrgConfig{
+				SquashMergeCommitTitle: "PR_BODY",
+			},
+			Repo:    repository("COMMIT_OR_PR_TITLE", "COMMIT_MESSAGES", "MERGE_MESSAGE", "PR_TITLE"),
+			ExpPass: true,
+			ExpDetails: details{
+				SquashMergeCommitTitle:   "COMMIT_OR_PR_TITLE",
+				SquashMergeCommitMessage: "COMMIT_MESSAGES",
+				MergeCommitTitle:         "MERGE_MESSAGE",
+				MergeCommitMessage:       "PR_TITLE",
+			},
+		},
+	}
+
+	configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+		c *github.Client, owner, repo string) (bool, error) {
+		return true, nil
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.OrgLevel {
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+			get = func(context.Context, string, string) (*github.Repository,
+				*github.Response, error) {
+				return test.Repo, nil, nil
+			}
+
+			res, err := check(context.Background(), mockRepos{}, nil, "", "thisrepo")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Pass != test.ExpPass {
+				t.Errorf("Unexpected pass: %v, notify text: %v", res.Pass, res.NotifyText)
+			}
+			if diff := cmp.Diff(test.ExpDetails, res.Details); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFix(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Org      OrgConfig
+		Repo     *github.Repository
+		EditCode int
+		Exp      *github.Repository
+	}{
+		{
+			Name: "NoChange",
+			Org: OrgConfig{
+				SquashMergeCommitTitle:   "PR_TITLE",
+				SquashMergeCommitMessage: "PR_BODY",
+			},
+			Repo: repository("PR_TITLE", "PR_BODY", "MERGE_MESSAGE", "PR_TITLE"),
+			Exp:  nil,
+		},
+		{
+			Name: "SquashMessage",
+			Org: OrgConfig{
+				SquashMergeCommitTitle:   "PR_TITLE",
+				SquashMergeCommitMessage: "PR_BODY",
+			},
+			Repo: repository("PR_TITLE", "COMMIT_MESSAGES", "MERGE_MESSAGE", "PR_TITLE"),
+			Exp: &github.Repository{
+				SquashMergeCommitTitle:   github.String("PR_TITLE"),
+				SquashMergeCommitMessage: github.String("PR_BODY"),
+			},
+		},
+		{
+			Name: "MergeTitle",
+			Org: OrgConfig{
+				MergeCommitTitle: "PR_TITLE",
+			},
+			Repo: repository("PR_TITLE", "PR_BODY", "MERGE_MESSAGE", "BLANK"),
+			Exp: &github.Repository{
+				MergeCommitTitle:   github.String("PR_TITLE"),
+				MergeCommitMessage: github.String("BLANK"),
+			},
+		},
+		{
+			Name: "Forbidden",
+			Org: OrgConfig{
+				SquashMergeCommitTitle: "PR_TITLE",
+			},
+			Repo:     repository("COMMIT_OR_PR_TITLE", "COMMIT_MESSAGES", "MERGE_MESSAGE", "PR_TITLE"),
+			EditCode: http.StatusForbidden,
+			Exp: &github.Repository{
+				SquashMergeCommitTitle:   github.String("PR_TITLE"),
+				SquashMergeCommitMessage: github.String("COMMIT_MESSAGES"),
+			},
+		},
+	}
+
+	configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+		c *github.Client, owner, repo string) (bool, error) {
+		return true, nil
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.OrgLevel {
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+			get = func(context.Context, string, string) (*github.Repository,
+				*github.Response, error) {
+				return test.Repo, nil, nil
+			}
+			var got *github.Repository
+			edit = func(ctx context.Context, o, r string,
+				repo *github.Repository) (*github.Repository, *github.Response, error) {
+				got = repo
+				if test.EditCode != 0 {
+					return nil, &github.Response{
+						Response: &http.Response{StatusCode: test.EditCode},
+					}, errors.New("error")
+				}
+				return repo, nil, nil
+			}
+
+			if err := fix(context.Background(), mockRepos{}, nil, "", "thisrepo"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.Exp, got); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGeneratedPrecedence(t *testing.T) {
+	configtest.TestPrecedence(t, configtest.Precedence{
+		Org:   OrgConfig{},
+		Repo:  RepoConfig{},
+		Fetch: &configFetchConfig,
+		Merge: func() interface{} {
+			ctx := context.Background()
+			oc, orc, rc := getConfig(ctx, nil, "", configtest.Repo)
+			return mergeConfig(oc, orc, rc, configtest.Repo)
+		},
+	})
+}
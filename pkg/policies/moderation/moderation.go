@@ -0,0 +1,261 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package moderation implements the Organization Moderation policy. It
+// reviews an organization's abuse handling posture: interaction limits, a
+// documented blocked user review process, and a moderation team. It is an
+// org-scope policy, run once per organization.
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/operator"
+	"github.com/ossf/allstar/pkg/policydef"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+const configFile = "moderation.yaml"
+const polName = "Organization Moderation"
+
+const notifyText = `This policy requires that the organization is prepared to handle abuse, such as spam or harassment in issues and pull requests.
+
+Interaction limits are set from the organization page under Settings -> Moderation -> Interaction limits. A blocked user review process should be documented in the file configured in the policy, and moderators added to the configured team.
+(For more information, see https://docs.github.com/en/communities/moderating-comments-and-conversations)`
+
+// OrgConfig is the org-level config definition for Organization Moderation.
+// There is no repo-level config, as it checks organization settings.
+type OrgConfig struct {
+	// Enabled : set to true to check the organization settings, default false.
+	Enabled bool `json:"enabled"`
+
+	// Action defines which action to take, default log, other: issue...
+	Action string `json:"action"`
+
+	// RequireInteractionLimits : set to true to require an organization
+	// interaction limit to be in effect, default false.
+	RequireInteractionLimits bool `json:"requireInteractionLimits"`
+
+	// ModerationProcessRepo is the repo containing ModerationProcessFile,
+	// default ".allstar".
+	ModerationProcessRepo string `json:"moderationProcessRepo"`
+
+	// ModerationProcessFile is the path of a file in ModerationProcessRepo
+	// documenting the blocked user review process, ex: "MODERATION.md". If
+	// set, the file must exist.
+	ModerationProcessFile string `json:"moderationProcessFile"`
+
+	// ModerationTeam is the slug of the team of organization moderators. If
+	// set, the team must exist and have at least MinModerators members.
+	ModerationTeam string `json:"moderationTeam"`
+
+	// MinModerators is the minimum number of members of ModerationTeam,
+	// default 1.
+	MinModerators int `json:"minModerators"`
+}
+
+type details struct {
+	InteractionLimit       string
+	ModerationProcessFound bool
+	ModerationTeamMembers  int
+	Missing                []string
+}
+
+var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+
+func init() {
+	configFetchConfig = config.FetchConfig
+}
+
+// orgs is the subset of the GitHub API used, spanning several go-github
+// services.
+type orgs interface {
+	GetRestrictionsForOrg(context.Context, string) (
+		*github.InteractionRestriction, *github.Response, error)
+	GetContents(context.Context, string, string, string,
+		*github.RepositoryContentGetOptions) (*github.RepositoryContent,
+		[]*github.RepositoryContent, *github.Response, error)
+	ListTeamMembersBySlug(context.Context, string, string,
+		*github.TeamListTeamMembersOptions) ([]*github.User, *github.Response, error)
+}
+
+// Moderation is the Organization Moderation policy object, implements
+// policydef.OrgPolicy.
+type Moderation bool
+
+// NewModeration returns a new Organization Moderation policy.
+func NewModeration() policydef.OrgPolicy {
+	var m Moderation
+	return m
+}
+
+// Name returns the name of this policy, implementing
+// policydef.OrgPolicy.Name()
+func (m Moderation) Name() string {
+	return polName
+}
+
+// Check whether this policy is enabled or not
+func (m Moderation) IsEnabled(ctx context.Context, c *github.Client, owner string) (bool, error) {
+	oc := getConfig(ctx, c, owner)
+	return oc.Enabled, nil
+}
+
+// Check performs the policy check for Organization Moderation based on the
+// configuration stored in the org, implementing policydef.OrgPolicy.Check()
+func (m Moderation) Check(ctx context.Context, c *github.Client, owner string) (*policydef.Result, error) {
+	return check(ctx, orgsClient{c}, c, owner)
+}
+
+func check(ctx context.Context, o orgs, c *github.Client, owner string) (*policydef.Result, error) {
+	oc := getConfig(ctx, c, owner)
+	log.Info().
+		Str("org", owner).
+		Str("area", polName).
+		Bool("enabled", oc.Enabled).
+		Msg("Check org enabled")
+
+	var d details
+	if !oc.Enabled {
+		return &policydef.Result{
+			Enabled:    false,
+			Pass:       true,
+			NotifyText: "",
+			Details:    d,
+		}, nil
+	}
+
+	if oc.RequireInteractionLimits {
+		ir, _, err := o.GetRestrictionsForOrg(ctx, owner)
+		if err != nil {
+			return nil, err
+		}
+		d.InteractionLimit = ir.GetLimit()
+		if d.InteractionLimit == "" {
+			d.Missing = append(d.Missing, "No organization interaction limit is in effect.")
+		}
+	}
+
+	if oc.ModerationProcessFile != "" {
+		_, _, rsp, err := o.GetContents(ctx, owner, oc.ModerationProcessRepo, oc.ModerationProcessFile, nil)
+		if err != nil && (rsp == nil || rsp.StatusCode != http.StatusNotFound) {
+			return nil, err
+		}
+		d.ModerationProcessFound = err == nil
+		if !d.ModerationProcessFound {
+			d.Missing = append(d.Missing, fmt.Sprintf("Blocked user review process file %q not found in %v.",
+				oc.ModerationProcessFile, oc.ModerationProcessRepo))
+		}
+	}
+
+	if oc.ModerationTeam != "" {
+		opt := &github.TeamListTeamMembersOptions{
+			ListOptions: github.ListOptions{PerPage: 100},
+		}
+		us, rsp, err := o.ListTeamMembersBySlug(ctx, owner, oc.ModerationTeam, opt)
+		if err != nil {
+			if rsp == nil || rsp.StatusCode != http.StatusNotFound {
+				return nil, err
+			}
+			d.Missing = append(d.Missing, fmt.Sprintf("Moderation team %q not found.", oc.ModerationTeam))
+		} else {
+			d.ModerationTeamMembers = len(us)
+			if d.ModerationTeamMembers < oc.MinModerators {
+				d.Missing = append(d.Missing, fmt.Sprintf("Moderation team %q has %v members, at least %v are required.",
+					oc.ModerationTeam, d.ModerationTeamMembers, oc.MinModerators))
+			}
+		}
+	}
+
+	if len(d.Missing) == 0 {
+		return &policydef.Result{
+			Enabled:    true,
+			Pass:       true,
+			NotifyText: "",
+			Details:    d,
+		}, nil
+	}
+	text := ""
+	for _, m := range d.Missing {
+		text = text + m + "\n"
+	}
+	return &policydef.Result{
+		Enabled:    true,
+		Pass:       false,
+		NotifyText: text + "\n" + notifyText,
+		Details:    d,
+	}, nil
+}
+
+// Fix implementing policydef.OrgPolicy.Fix(). Currently not supported,
+// moderation processes and teams need people.
+func (m Moderation) Fix(ctx context.Context, c *github.Client, owner string) error {
+	log.Warn().
+		Str("org", owner).
+		Str("area", polName).
+		Msg("Action fix is configured, but not implemented.")
+	return nil
+}
+
+// GetAction returns the configured action from Organization Moderation's
+// configuration stored in the org-level repo, default log. Implementing
+// policydef.OrgPolicy.GetAction()
+func (m Moderation) GetAction(ctx context.Context, c *github.Client, owner string) string {
+	oc := getConfig(ctx, c, owner)
+	return oc.Action
+}
+
+func getConfig(ctx context.Context, c *github.Client, owner string) *OrgConfig {
+	oc := &OrgConfig{ // Fill out non-zero defaults
+		Action:                "log",
+		ModerationProcessRepo: operator.OrgConfigRepo,
+		MinModerators:         1,
+	}
+	if err := configFetchConfig(ctx, c, owner, "", configFile, config.OrgLevel, oc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("configLevel", "orgLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	return oc
+}
+
+// orgsClient implements orgs with a GitHub client.
+type orgsClient struct {
+	c *github.Client
+}
+
+func (o orgsClient) GetRestrictionsForOrg(ctx context.Context, org string) (
+	*github.InteractionRestriction, *github.Response, error) {
+	return o.c.Interactions.GetRestrictionsForOrg(ctx, org)
+}
+
+func (o orgsClient) GetContents(ctx context.Context, owner, repo, path string,
+	opt *github.RepositoryContentGetOptions) (*github.RepositoryContent,
+	[]*github.RepositoryContent, *github.Response, error) {
+	return o.c.Repositories.GetContents(ctx, owner, repo, path, opt)
+}
+
+func (o orgsClient) ListTeamMembersBySlug(ctx context.Context, org, slug string,
+	opt *github.TeamListTeamMembersOptions) ([]*github.User, *github.Response, error) {
+	return o.c.Teams.ListTeamMembersBySlug(ctx, org, slug, opt)
+}
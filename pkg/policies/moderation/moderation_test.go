@@ -0,0 +1,187 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package moderation
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+)
+
+var getRestrictionsForOrg func(context.Context, string) (
+	*github.InteractionRestriction, *github.Response, error)
+var getContents func(context.Context, string, string, string,
+	*github.RepositoryContentGetOptions) (*github.RepositoryContent,
+	[]*github.RepositoryContent, *github.Response, error)
+var listTeamMembersBySlug func(context.Context, string, string,
+	*github.TeamListTeamMembersOptions) ([]*github.User, *github.Response, error)
+
+type mockOrgs struct{}
+
+func (m mockOrgs) GetRestrictionsForOrg(ctx context.Context, o string) (
+	*github.InteractionRestriction, *github.Response, error) {
+	return getRestrictionsForOrg(ctx, o)
+}
+
+func (m mockOrgs) GetContents(ctx context.Context, o, r, p string,
+	opt *github.RepositoryContentGetOptions) (*github.RepositoryContent,
+	[]*github.RepositoryContent, *github.Response, error) {
+	return getContents(ctx, o, r, p, opt)
+}
+
+func (m mockOrgs) ListTeamMembersBySlug(ctx context.Context, o, s string,
+	opt *github.TeamListTeamMembersOptions) ([]*github.User, *github.Response, error) {
+	return listTeamMembersBySlug(ctx, o, s, opt)
+}
+
+var notFound = &github.Response{
+	Response: &http.Response{StatusCode: http.StatusNotFound},
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Org         OrgConfig
+		Limit       string
+		FileExists  bool
+		TeamMembers []*github.User
+		TeamMissing bool
+		ExpPass     bool
+		ExpDetails  details
+	}{
+		{
+			Name: "Disabled",
+			Org: OrgConfig{
+				RequireInteractionLimits: true,
+			},
+			ExpPass: true,
+		},
+		{
+			Name: "NothingRequired",
+			Org: OrgConfig{
+				Enabled: true,
+			},
+			ExpPass: true,
+		},
+		{
+			Name: "AllPresent",
+			Org: OrgConfig{
+				Enabled:                  true,
+				RequireInteractionLimits: true,
+				ModerationProcessRepo:    ".allstar",
+				ModerationProcessFile:    "MODERATION.md",
+				ModerationTeam:           "mods",
+				MinModerators:            2,
+			},
+			Limit:       "existing_users",
+			FileExists:  true,
+			TeamMembers: []*github.User{{}, {}},
+			ExpPass:     true,
+			ExpDetails: details{
+				InteractionLimit:       "existing_users",
+				ModerationProcessFound: true,
+				ModerationTeamMembers:  2,
+			},
+		},
+		{
+			Name: "AllMissing",
+			Org: OrgConfig{
+				Enabled:                  true,
+				RequireInteractionLimits: true,
+				ModerationProcessRepo:    ".allstar",
+				ModerationProcessFile:    "MODERATION.md",
+				ModerationTeam:           "mods",
+				MinModerators:            1,
+			},
+			TeamMissing: true,
+			ExpPass:     false,
+			ExpDetails: details{
+				Missing: []string{
+					"No organization interaction limit is in effect.",
+					"Blocked user review process file \"MODERATION.md\" not found in .allstar.",
+					"Moderation team \"mods\" not found.",
+				},
+			},
+		},
+		{
+			Name: "TooFewModerators",
+			Org: OrgConfig{
+				Enabled:        true,
+				ModerationTeam: "mods",
+				MinModerators:  2,
+			},
+			TeamMembers: []*github.User{{}},
+			ExpPass:     false,
+			ExpDetails: details{
+				ModerationTeamMembers: 1,
+				Missing: []string{
+					"Moderation team \"mods\" has 1 members, at least 2 are required.",
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.OrgLevel {
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+			getRestrictionsForOrg = func(context.Context, string) (
+				*github.InteractionRestriction, *github.Response, error) {
+				ir := &github.InteractionRestriction{}
+				if test.Limit != "" {
+					ir.Limit = &test.Limit
+				}
+				return ir, nil, nil
+			}
+			getContents = func(context.Context, string, string, string,
+				*github.RepositoryContentGetOptions) (*github.RepositoryContent,
+				[]*github.RepositoryContent, *github.Response, error) {
+				if !test.FileExists {
+					return nil, nil, notFound, errors.New("404")
+				}
+				return &github.RepositoryContent{}, nil, nil, nil
+			}
+			listTeamMembersBySlug = func(context.Context, string, string,
+				*github.TeamListTeamMembersOptions) ([]*github.User, *github.Response, error) {
+				if test.TeamMissing {
+					return nil, notFound, errors.New("404")
+				}
+				return test.TeamMembers, nil, nil
+			}
+
+			res, err := check(context.Background(), mockOrgs{}, nil, "")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Pass != test.ExpPass {
+				t.Errorf("Unexpected pass: %v, notify text: %v", res.Pass, res.NotifyText)
+			}
+			if diff := cmp.Diff(test.ExpDetails, res.Details); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
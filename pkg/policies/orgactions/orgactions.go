@@ -0,0 +1,455 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package orgactions implements the Organization Actions Settings policy. It
+// checks the GitHub Actions settings of the organization, which are the
+// defaults and limits of the settings of all its repositories: workflow token
+// permissions, approval of fork pull request workflows, allowed actions, and
+// self-hosted runner access. It is an org-scope policy, run once per
+// organization.
+package orgactions
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/policydef"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+const configFile = "org_actions.yaml"
+const polName = "Organization Actions Settings"
+
+// Approval policies for workflows from fork pull requests, from weakest to
+// strongest.
+const (
+	approvalNewToGitHub  = "first_time_contributors_new_to_github"
+	approvalFirstTime    = "first_time_contributors"
+	approvalAllExternals = "all_external_contributors"
+)
+
+var approvalRank = map[string]int{
+	approvalNewToGitHub:  1,
+	approvalFirstTime:    2,
+	approvalAllExternals: 3,
+}
+
+const workflowPermissionsRead = "read"
+
+const notifyText = `This policy requires that the GitHub Actions settings of the organization limit what workflows can do by default, as every repository inherits them.
+
+To fix this, from the organization page go to Settings -> Actions -> General, and adjust the "Policies", "Approval for running fork pull request workflows from contributors", and "Workflow permissions" settings, then Settings -> Actions -> Runner groups, and disable access from public repositories to self-hosted runners.
+(For more information, see https://docs.github.com/en/organizations/managing-organization-settings/disabling-or-limiting-github-actions-for-your-organization)`
+
+// OrgConfig is the org-level config definition for Organization Actions
+// Settings. There is no repo-level config, as it checks organization
+// settings.
+type OrgConfig struct {
+	// Enabled : set to true to check the organization settings, default false.
+	Enabled bool `json:"enabled"`
+
+	// Action defines which action to take, default log, other: issue...
+	Action string `json:"action"`
+
+	// AllowWriteWorkflowPermissions : set to true to allow the default
+	// GITHUB_TOKEN permissions of workflows to be read and write, default
+	// false.
+	AllowWriteWorkflowPermissions bool `json:"allowWriteWorkflowPermissions"`
+
+	// AllowWorkflowPRApprovals : set to true to allow GitHub Actions to create
+	// and approve pull requests, default false.
+	AllowWorkflowPRApprovals bool `json:"allowWorkflowPRApprovals"`
+
+	// ForkPRApprovalPolicy is the weakest allowed approval requirement for
+	// running workflows from fork pull requests. One of
+	// "first_time_contributors_new_to_github", "first_time_contributors", or
+	// "all_external_contributors", default "all_external_contributors".
+	ForkPRApprovalPolicy string `json:"forkPRApprovalPolicy"`
+
+	// AllowAllActions : set to true to allow all actions and reusable
+	// workflows to be used, default false. Otherwise, only local actions, or
+	// selected actions, such as those by GitHub and verified creators, may be
+	// allowed.
+	AllowAllActions bool `json:"allowAllActions"`
+
+	// AllowPublicRepoRunners : set to true to allow runner groups to be used
+	// by public repositories, default false. Anyone can run code on the
+	// self-hosted runners of a public repository by opening a pull request.
+	AllowPublicRepoRunners bool `json:"allowPublicRepoRunners"`
+}
+
+type details struct {
+	EnabledRepositories  string
+	AllowedActions       string
+	WorkflowPermissions  string
+	CanApprovePRs        bool
+	ForkPRApprovalPolicy string
+	PublicRunnerGroups   []string
+}
+
+// forkPRApproval is the org fork pull request contributor approval setting.
+type forkPRApproval struct {
+	ApprovalPolicy string `json:"approval_policy"`
+}
+
+var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+
+func init() {
+	configFetchConfig = config.FetchConfig
+}
+
+// actions is the subset of the GitHub API used, go-github does not provide the
+// fork pull request approval setting.
+type actions interface {
+	GetActionsPermissions(context.Context, string) (*github.ActionsPermissions,
+		*github.Response, error)
+	GetDefaultWorkflowPermissionsInOrganization(context.Context, string) (
+		*github.DefaultWorkflowPermissionOrganization, *github.Response, error)
+	EditDefaultWorkflowPermissionsInOrganization(context.Context, string,
+		github.DefaultWorkflowPermissionOrganization) (
+		*github.DefaultWorkflowPermissionOrganization, *github.Response, error)
+	ListOrganizationRunnerGroups(context.Context, string,
+		*github.ListOrgRunnerGroupOptions) (*github.RunnerGroups,
+		*github.Response, error)
+	UpdateOrganizationRunnerGroup(context.Context, string, int64,
+		github.UpdateRunnerGroupRequest) (*github.RunnerGroup,
+		*github.Response, error)
+	GetForkPRApproval(context.Context, string) (*forkPRApproval,
+		*github.Response, error)
+	EditForkPRApproval(context.Context, string, *forkPRApproval) (
+		*github.Response, error)
+}
+
+// OrgActions is the Organization Actions Settings policy object, implements
+// policydef.OrgPolicy.
+type OrgActions bool
+
+// NewOrgActions returns a new Organization Actions Settings policy.
+func NewOrgActions() policydef.OrgPolicy {
+	var o OrgActions
+	return o
+}
+
+// Name returns the name of this policy, implementing
+// policydef.OrgPolicy.Name()
+func (o OrgActions) Name() string {
+	return polName
+}
+
+// Check whether this policy is enabled or not
+func (o OrgActions) IsEnabled(ctx context.Context, c *github.Client, owner string) (bool, error) {
+	oc := getConfig(ctx, c, owner)
+	return oc.Enabled, nil
+}
+
+// Check performs the policy check for Organization Actions Settings based on
+// the configuration stored in the org, implementing
+// policydef.OrgPolicy.Check()
+func (o OrgActions) Check(ctx context.Context, c *github.Client, owner string) (*policydef.Result, error) {
+	return check(ctx, actionsClient{c}, c, owner)
+}
+
+func check(ctx context.Context, act actions, c *github.Client, owner string) (*policydef.Result, error) {
+	oc := getConfig(ctx, c, owner)
+	log.Info().
+		Str("org", owner).
+		Str("area", polName).
+		Bool("enabled", oc.Enabled).
+		Msg("Check org enabled")
+	if !oc.Enabled {
+		return &policydef.Result{
+			Enabled:    false,
+			Pass:       true,
+			NotifyText: "",
+			Details:    details{},
+		}, nil
+	}
+
+	var d details
+	var problems []string
+
+	perms, _, err := act.GetActionsPermissions(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	d.EnabledRepositories = perms.GetEnabledRepositories()
+	d.AllowedActions = perms.GetAllowedActions()
+	if d.EnabledRepositories == "none" {
+		// No workflows can run in the organization.
+		return &policydef.Result{
+			Enabled:    true,
+			Pass:       true,
+			NotifyText: "",
+			Details:    d,
+		}, nil
+	}
+	if d.AllowedActions == "all" && !oc.AllowAllActions {
+		problems = append(problems, "All actions and reusable workflows are allowed, but organization policy requires only local or selected actions to be allowed.")
+	}
+
+	wp, _, err := act.GetDefaultWorkflowPermissionsInOrganization(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	d.WorkflowPermissions = wp.GetDefaultWorkflowPermissions()
+	d.CanApprovePRs = wp.GetCanApprovePullRequestReviews()
+	if d.WorkflowPermissions != workflowPermissionsRead && !oc.AllowWriteWorkflowPermissions {
+		problems = append(problems, fmt.Sprintf("The default workflow permissions are %q, but organization policy requires %q.",
+			d.WorkflowPermissions, workflowPermissionsRead))
+	}
+	if d.CanApprovePRs && !oc.AllowWorkflowPRApprovals {
+		problems = append(problems, "GitHub Actions are allowed to create and approve pull requests, but not by organization policy.")
+	}
+
+	a, rsp, err := act.GetForkPRApproval(ctx, owner)
+	if err != nil && !(rsp != nil && rsp.StatusCode == http.StatusNotFound) {
+		return nil, err
+	}
+	if err == nil {
+		d.ForkPRApprovalPolicy = a.ApprovalPolicy
+		if approvalRank[d.ForkPRApprovalPolicy] < approvalRank[oc.ForkPRApprovalPolicy] {
+			problems = append(problems, fmt.Sprintf("Approval for fork pull request workflows is set to %q, but organization policy requires %q.",
+				d.ForkPRApprovalPolicy, oc.ForkPRApprovalPolicy))
+		}
+	}
+
+	if !oc.AllowPublicRepoRunners {
+		groups, err := publicRunnerGroups(ctx, act, owner)
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range groups {
+			d.PublicRunnerGroups = append(d.PublicRunnerGroups, g.GetName())
+		}
+		if len(d.PublicRunnerGroups) > 0 {
+			problems = append(problems, fmt.Sprintf("Runner groups can be used by public repositories: %v.",
+				d.PublicRunnerGroups))
+		}
+	}
+
+	if len(problems) == 0 {
+		return &policydef.Result{
+			Enabled:    true,
+			Pass:       true,
+			NotifyText: "",
+			Details:    d,
+		}, nil
+	}
+	text := "The GitHub Actions settings of the organization do not meet the policy:\n"
+	for _, p := range problems {
+		text = text + fmt.Sprintf("- %v\n", p)
+	}
+	return &policydef.Result{
+		Enabled:    true,
+		Pass:       false,
+		NotifyText: text + "\n" + notifyText,
+		Details:    d,
+	}, nil
+}
+
+// publicRunnerGroups returns the runner groups of the organization that can
+// be used by public repositories.
+func publicRunnerGroups(ctx context.Context, act actions, owner string) ([]*github.RunnerGroup, error) {
+	var groups []*github.RunnerGroup
+	opt := &github.ListOrgRunnerGroupOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		rgs, rsp, err := act.ListOrganizationRunnerGroups(ctx, owner, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range rgs.RunnerGroups {
+			if g.GetAllowsPublicRepositories() {
+				groups = append(groups, g)
+			}
+		}
+		if rsp == nil || rsp.NextPage == 0 {
+			break
+		}
+		opt.Page = rsp.NextPage
+	}
+	return groups, nil
+}
+
+// Fix implementing policydef.OrgPolicy.Fix(). Updates the workflow
+// permissions, fork pull request approval, and runner group settings to meet
+// the policy. Allowed actions are not changed, as selecting the actions to
+// allow requires knowing which are used.
+func (o OrgActions) Fix(ctx context.Context, c *github.Client, owner string) error {
+	return fix(ctx, actionsClient{c}, c, owner)
+}
+
+func fix(ctx context.Context, act actions, c *github.Client, owner string) error {
+	oc := getConfig(ctx, c, owner)
+	if !oc.Enabled {
+		return nil
+	}
+
+	wp, _, err := act.GetDefaultWorkflowPermissionsInOrganization(ctx, owner)
+	if err != nil {
+		return err
+	}
+	nwp := github.DefaultWorkflowPermissionOrganization{
+		DefaultWorkflowPermissions:   wp.DefaultWorkflowPermissions,
+		CanApprovePullRequestReviews: wp.CanApprovePullRequestReviews,
+	}
+	update := false
+	if wp.GetDefaultWorkflowPermissions() != workflowPermissionsRead && !oc.AllowWriteWorkflowPermissions {
+		nwp.DefaultWorkflowPermissions = github.String(workflowPermissionsRead)
+		update = true
+	}
+	if wp.GetCanApprovePullRequestReviews() && !oc.AllowWorkflowPRApprovals {
+		nwp.CanApprovePullRequestReviews = github.Bool(false)
+		update = true
+	}
+	if update {
+		if _, _, err := act.EditDefaultWorkflowPermissionsInOrganization(ctx, owner, nwp); err != nil {
+			return err
+		}
+		log.Info().
+			Str("org", owner).
+			Str("area", polName).
+			Msg("Updated default workflow permissions with Fix action.")
+	}
+
+	a, rsp, err := act.GetForkPRApproval(ctx, owner)
+	if err != nil && !(rsp != nil && rsp.StatusCode == http.StatusNotFound) {
+		return err
+	}
+	if err == nil && approvalRank[a.ApprovalPolicy] < approvalRank[oc.ForkPRApprovalPolicy] {
+		if _, err := act.EditForkPRApproval(ctx, owner, &forkPRApproval{
+			ApprovalPolicy: oc.ForkPRApprovalPolicy,
+		}); err != nil {
+			return err
+		}
+		log.Info().
+			Str("org", owner).
+			Str("area", polName).
+			Str("approvalPolicy", oc.ForkPRApprovalPolicy).
+			Msg("Updated fork pull request approval with Fix action.")
+	}
+
+	if !oc.AllowPublicRepoRunners {
+		groups, err := publicRunnerGroups(ctx, act, owner)
+		if err != nil {
+			return err
+		}
+		for _, g := range groups {
+			if _, _, err := act.UpdateOrganizationRunnerGroup(ctx, owner, g.GetID(), github.UpdateRunnerGroupRequest{
+				AllowsPublicRepositories: github.Bool(false),
+			}); err != nil {
+				return err
+			}
+			log.Info().
+				Str("org", owner).
+				Str("area", polName).
+				Str("runnerGroup", g.GetName()).
+				Msg("Disabled public repository access to runner group with Fix action.")
+		}
+	}
+	return nil
+}
+
+// GetAction returns the configured action from Organization Actions
+// Settings' configuration stored in the org-level repo, default log.
+// Implementing policydef.OrgPolicy.GetAction()
+func (o OrgActions) GetAction(ctx context.Context, c *github.Client, owner string) string {
+	oc := getConfig(ctx, c, owner)
+	return oc.Action
+}
+
+func getConfig(ctx context.Context, c *github.Client, owner string) *OrgConfig {
+	oc := &OrgConfig{ // Fill out non-zero defaults
+		Action:               "log",
+		ForkPRApprovalPolicy: approvalAllExternals,
+	}
+	if err := configFetchConfig(ctx, c, owner, "", configFile, config.OrgLevel, oc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("configLevel", "orgLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	if _, ok := approvalRank[oc.ForkPRApprovalPolicy]; !ok {
+		log.Warn().
+			Str("org", owner).
+			Str("area", polName).
+			Str("approvalPolicy", oc.ForkPRApprovalPolicy).
+			Msg("Unknown approval policy configured, using all_external_contributors.")
+		oc.ForkPRApprovalPolicy = approvalAllExternals
+	}
+	return oc
+}
+
+// actionsClient implements actions with a GitHub client.
+type actionsClient struct {
+	c *github.Client
+}
+
+func (a actionsClient) GetActionsPermissions(ctx context.Context, owner string) (
+	*github.ActionsPermissions, *github.Response, error) {
+	return a.c.Actions.GetActionsPermissions(ctx, owner)
+}
+
+func (a actionsClient) GetDefaultWorkflowPermissionsInOrganization(ctx context.Context, owner string) (
+	*github.DefaultWorkflowPermissionOrganization, *github.Response, error) {
+	return a.c.Actions.GetDefaultWorkflowPermissionsInOrganization(ctx, owner)
+}
+
+func (a actionsClient) EditDefaultWorkflowPermissionsInOrganization(ctx context.Context, owner string,
+	p github.DefaultWorkflowPermissionOrganization) (*github.DefaultWorkflowPermissionOrganization, *github.Response, error) {
+	return a.c.Actions.EditDefaultWorkflowPermissionsInOrganization(ctx, owner, p)
+}
+
+func (a actionsClient) ListOrganizationRunnerGroups(ctx context.Context, owner string,
+	opt *github.ListOrgRunnerGroupOptions) (*github.RunnerGroups, *github.Response, error) {
+	return a.c.Actions.ListOrganizationRunnerGroups(ctx, owner, opt)
+}
+
+func (a actionsClient) UpdateOrganizationRunnerGroup(ctx context.Context, owner string, id int64,
+	r github.UpdateRunnerGroupRequest) (*github.RunnerGroup, *github.Response, error) {
+	return a.c.Actions.UpdateOrganizationRunnerGroup(ctx, owner, id, r)
+}
+
+func (a actionsClient) GetForkPRApproval(ctx context.Context, owner string) (
+	*forkPRApproval, *github.Response, error) {
+	v := &forkPRApproval{}
+	rsp, err := a.do(ctx, "GET", owner, "fork-pr-contributor-approval", nil, v)
+	if err != nil {
+		return nil, rsp, err
+	}
+	return v, rsp, nil
+}
+
+func (a actionsClient) EditForkPRApproval(ctx context.Context, owner string,
+	v *forkPRApproval) (*github.Response, error) {
+	return a.do(ctx, "PUT", owner, "fork-pr-contributor-approval", v, nil)
+}
+
+func (a actionsClient) do(ctx context.Context, method, owner, setting string,
+	body, out interface{}) (*github.Response, error) {
+	u := fmt.Sprintf("orgs/%v/actions/permissions/%v", owner, setting)
+	req, err := a.c.NewRequest(method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	return a.c.Do(ctx, req, out)
+}
@@ -0,0 +1,332 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orgactions
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+)
+
+var permissions *github.ActionsPermissions
+var workflowPermissions *github.DefaultWorkflowPermissionOrganization
+var editedWorkflowPermissions *github.DefaultWorkflowPermissionOrganization
+var runnerGroups []*github.RunnerGroup
+var updatedRunnerGroups []int64
+var approval *forkPRApproval
+var editedApproval *forkPRApproval
+
+type mockActions struct{}
+
+func (m mockActions) GetActionsPermissions(ctx context.Context, o string) (
+	*github.ActionsPermissions, *github.Response, error) {
+	return permissions, nil, nil
+}
+
+func (m mockActions) GetDefaultWorkflowPermissionsInOrganization(ctx context.Context, o string) (
+	*github.DefaultWorkflowPermissionOrganization, *github.Response, error) {
+	return workflowPermissions, nil, nil
+}
+
+func (m mockActions) EditDefaultWorkflowPermissionsInOrganization(ctx context.Context, o string,
+	p github.DefaultWorkflowPermissionOrganization) (*github.DefaultWorkflowPermissionOrganization, *github.Response, error) {
+	editedWorkflowPermissions = &p
+	return &p, nil, nil
+}
+
+func (m mockActions) ListOrganizationRunnerGroups(ctx context.Context, o string,
+	opt *github.ListOrgRunnerGroupOptions) (*github.RunnerGroups, *github.Response, error) {
+	return &github.RunnerGroups{RunnerGroups: runnerGroups}, &github.Response{}, nil
+}
+
+func (m mockActions) UpdateOrganizationRunnerGroup(ctx context.Context, o string, id int64,
+	r github.UpdateRunnerGroupRequest) (*github.RunnerGroup, *github.Response, error) {
+	updatedRunnerGroups = append(updatedRunnerGroups, id)
+	return nil, nil, nil
+}
+
+func (m mockActions) GetForkPRApproval(ctx context.Context, o string) (
+	*forkPRApproval, *github.Response, error) {
+	if approval == nil {
+		return nil, &github.Response{Response: &http.Response{StatusCode: http.StatusNotFound}},
+			&github.ErrorResponse{}
+	}
+	return approval, nil, nil
+}
+
+func (m mockActions) EditForkPRApproval(ctx context.Context, o string,
+	a *forkPRApproval) (*github.Response, error) {
+	editedApproval = a
+	return nil, nil
+}
+
+func setConfig(oc OrgConfig) {
+	configFetchConfig = func(ctx context.Context, c *github.Client,
+		owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+		if ol == config.OrgLevel {
+			*out.(*OrgConfig) = oc
+		}
+		return nil
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		Name                string
+		Org                 OrgConfig
+		Permissions         github.ActionsPermissions
+		WorkflowPermissions github.DefaultWorkflowPermissionOrganization
+		Approval            *forkPRApproval
+		RunnerGroups        []*github.RunnerGroup
+		ExpEnabled          bool
+		ExpPass             bool
+		ExpDetails          details
+	}{
+		{
+			Name:       "Disabled",
+			Org:        OrgConfig{},
+			ExpEnabled: false,
+			ExpPass:    true,
+		},
+		{
+			Name: "Pass",
+			Org:  OrgConfig{Enabled: true},
+			Permissions: github.ActionsPermissions{
+				EnabledRepositories: github.String("all"),
+				AllowedActions:      github.String("selected"),
+			},
+			WorkflowPermissions: github.DefaultWorkflowPermissionOrganization{
+				DefaultWorkflowPermissions: github.String("read"),
+			},
+			Approval: &forkPRApproval{ApprovalPolicy: approvalAllExternals},
+			RunnerGroups: []*github.RunnerGroup{
+				{Name: github.String("Default")},
+			},
+			ExpEnabled: true,
+			ExpPass:    true,
+			ExpDetails: details{
+				EnabledRepositories:  "all",
+				AllowedActions:       "selected",
+				WorkflowPermissions:  "read",
+				ForkPRApprovalPolicy: approvalAllExternals,
+			},
+		},
+		{
+			Name: "ActionsDisabled",
+			Org:  OrgConfig{Enabled: true},
+			Permissions: github.ActionsPermissions{
+				EnabledRepositories: github.String("none"),
+			},
+			ExpEnabled: true,
+			ExpPass:    true,
+			ExpDetails: details{
+				EnabledRepositories: "none",
+			},
+		},
+		{
+			Name: "AllActionsAndWrite",
+			Org:  OrgConfig{Enabled: true},
+			Permissions: github.ActionsPermissions{
+				EnabledRepositories: github.String("all"),
+				AllowedActions:      github.String("all"),
+			},
+			WorkflowPermissions: github.DefaultWorkflowPermissionOrganization{
+				DefaultWorkflowPermissions:   github.String("write"),
+				CanApprovePullRequestReviews: github.Bool(true),
+			},
+			Approval:   &forkPRApproval{ApprovalPolicy: approvalAllExternals},
+			ExpEnabled: true,
+			ExpPass:    false,
+			ExpDetails: details{
+				EnabledRepositories:  "all",
+				AllowedActions:       "all",
+				WorkflowPermissions:  "write",
+				CanApprovePRs:        true,
+				ForkPRApprovalPolicy: approvalAllExternals,
+			},
+		},
+		{
+			Name: "AllActionsAndWriteAllowed",
+			Org: OrgConfig{
+				Enabled:                       true,
+				AllowAllActions:               true,
+				AllowWriteWorkflowPermissions: true,
+				AllowWorkflowPRApprovals:      true,
+				ForkPRApprovalPolicy:          approvalAllExternals,
+			},
+			Permissions: github.ActionsPermissions{
+				EnabledRepositories: github.String("all"),
+				AllowedActions:      github.String("all"),
+			},
+			WorkflowPermissions: github.DefaultWorkflowPermissionOrganization{
+				DefaultWorkflowPermissions:   github.String("write"),
+				CanApprovePullRequestReviews: github.Bool(true),
+			},
+			Approval:   &forkPRApproval{ApprovalPolicy: approvalAllExternals},
+			ExpEnabled: true,
+			ExpPass:    true,
+			ExpDetails: details{
+				EnabledRepositories:  "all",
+				AllowedActions:       "all",
+				WorkflowPermissions:  "write",
+				CanApprovePRs:        true,
+				ForkPRApprovalPolicy: approvalAllExternals,
+			},
+		},
+		{
+			Name: "WeakForkApproval",
+			Org:  OrgConfig{Enabled: true},
+			Permissions: github.ActionsPermissions{
+				EnabledRepositories: github.String("all"),
+				AllowedActions:      github.String("local_only"),
+			},
+			WorkflowPermissions: github.DefaultWorkflowPermissionOrganization{
+				DefaultWorkflowPermissions: github.String("read"),
+			},
+			Approval:   &forkPRApproval{ApprovalPolicy: approvalNewToGitHub},
+			ExpEnabled: true,
+			ExpPass:    false,
+			ExpDetails: details{
+				EnabledRepositories:  "all",
+				AllowedActions:       "local_only",
+				WorkflowPermissions:  "read",
+				ForkPRApprovalPolicy: approvalNewToGitHub,
+			},
+		},
+		{
+			Name: "ForkApprovalNotFound",
+			Org:  OrgConfig{Enabled: true},
+			Permissions: github.ActionsPermissions{
+				EnabledRepositories: github.String("all"),
+				AllowedActions:      github.String("selected"),
+			},
+			WorkflowPermissions: github.DefaultWorkflowPermissionOrganization{
+				DefaultWorkflowPermissions: github.String("read"),
+			},
+			ExpEnabled: true,
+			ExpPass:    true,
+			ExpDetails: details{
+				EnabledRepositories: "all",
+				AllowedActions:      "selected",
+				WorkflowPermissions: "read",
+			},
+		},
+		{
+			Name: "PublicRunnerGroup",
+			Org:  OrgConfig{Enabled: true},
+			Permissions: github.ActionsPermissions{
+				EnabledRepositories: github.String("all"),
+				AllowedActions:      github.String("selected"),
+			},
+			WorkflowPermissions: github.DefaultWorkflowPermissionOrganization{
+				DefaultWorkflowPermissions: github.String("read"),
+			},
+			Approval: &forkPRApproval{ApprovalPolicy: approvalAllExternals},
+			RunnerGroups: []*github.RunnerGroup{
+				{Name: github.String("Default")},
+				{Name: github.String("gpu"), AllowsPublicRepositories: github.Bool(true)},
+			},
+			ExpEnabled: true,
+			ExpPass:    false,
+			ExpDetails: details{
+				EnabledRepositories:  "all",
+				AllowedActions:       "selected",
+				WorkflowPermissions:  "read",
+				ForkPRApprovalPolicy: approvalAllExternals,
+				PublicRunnerGroups:   []string{"gpu"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			setConfig(test.Org)
+			permissions = &test.Permissions
+			workflowPermissions = &test.WorkflowPermissions
+			approval = test.Approval
+			runnerGroups = test.RunnerGroups
+
+			res, err := check(context.Background(), mockActions{}, nil, "thisorg")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Enabled != test.ExpEnabled {
+				t.Errorf("Unexpected enabled: %v", res.Enabled)
+			}
+			if res.Pass != test.ExpPass {
+				t.Errorf("Unexpected pass: %v, text: %v", res.Pass, res.NotifyText)
+			}
+			if diff := cmp.Diff(test.ExpDetails, res.Details); diff != "" {
+				t.Errorf("Unexpected details. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFix(t *testing.T) {
+	setConfig(OrgConfig{Enabled: true, ForkPRApprovalPolicy: approvalAllExternals})
+	workflowPermissions = &github.DefaultWorkflowPermissionOrganization{
+		DefaultWorkflowPermissions:   github.String("write"),
+		CanApprovePullRequestReviews: github.Bool(true),
+	}
+	approval = &forkPRApproval{ApprovalPolicy: approvalFirstTime}
+	runnerGroups = []*github.RunnerGroup{
+		{ID: github.Int64(1), Name: github.String("Default")},
+		{ID: github.Int64(2), Name: github.String("gpu"), AllowsPublicRepositories: github.Bool(true)},
+	}
+	editedWorkflowPermissions = nil
+	editedApproval = nil
+	updatedRunnerGroups = nil
+
+	if err := fix(context.Background(), mockActions{}, nil, "thisorg"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expPerms := &github.DefaultWorkflowPermissionOrganization{
+		DefaultWorkflowPermissions:   github.String("read"),
+		CanApprovePullRequestReviews: github.Bool(false),
+	}
+	if diff := cmp.Diff(expPerms, editedWorkflowPermissions); diff != "" {
+		t.Errorf("Unexpected workflow permissions. (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(&forkPRApproval{ApprovalPolicy: approvalAllExternals}, editedApproval); diff != "" {
+		t.Errorf("Unexpected fork PR approval. (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]int64{2}, updatedRunnerGroups); diff != "" {
+		t.Errorf("Unexpected runner group updates. (-want +got):\n%s", diff)
+	}
+}
+
+func TestFixCompliant(t *testing.T) {
+	setConfig(OrgConfig{Enabled: true, ForkPRApprovalPolicy: approvalFirstTime})
+	workflowPermissions = &github.DefaultWorkflowPermissionOrganization{
+		DefaultWorkflowPermissions: github.String("read"),
+	}
+	approval = &forkPRApproval{ApprovalPolicy: approvalAllExternals}
+	runnerGroups = nil
+	editedWorkflowPermissions = nil
+	editedApproval = nil
+	updatedRunnerGroups = nil
+
+	if err := fix(context.Background(), mockActions{}, nil, "thisorg"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if editedWorkflowPermissions != nil || editedApproval != nil || updatedRunnerGroups != nil {
+		t.Errorf("Unexpected fix of compliant settings.")
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package orgsettings implements the Organization Repository Settings policy.
+// It checks the settings of the organization that control access to its
+// repositories: the base permission of members, and whether members can
+// create public repositories or fork private ones. It is an org-scope policy,
+// run once per organization.
+package orgsettings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/policydef"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+const configFile = "org_repo_settings.yaml"
+const polName = "Organization Repository Settings"
+
+const permissionRead = "read"
+
+// permissionRank orders the base repository permissions of members, from
+// weakest to strongest.
+var permissionRank = map[string]int{
+	"none":         0,
+	permissionRead: 1,
+	"write":        2,
+	"admin":        3,
+}
+
+const notifyText = `This policy requires that the settings of the organization limit the access of members to its repositories, and what they can do with them, by default.
+
+To fix this, an organization owner can go to Settings -> Member privileges, and adjust the "Base permissions", "Repository creation", and "Repository forking" settings.
+(For more information, see https://docs.github.com/en/organizations/managing-user-access-to-your-organizations-repositories/managing-repository-roles/setting-base-permissions-for-an-organization)`
+
+// OrgConfig is the org-level config definition for Organization Repository
+// Settings. There is no repo-level config, as it checks organization
+// settings.
+type OrgConfig struct {
+	// Enabled : set to true to check the organization settings, default false.
+	Enabled bool `json:"enabled"`
+
+	// Action defines which action to take, default log, other: issue...
+	Action string `json:"action"`
+
+	// MaxDefaultRepositoryPermission is the strongest allowed base permission
+	// of members on all repositories of the organization. One of "none",
+	// "read", "write", or "admin", default "read".
+	MaxDefaultRepositoryPermission string `json:"maxDefaultRepositoryPermission"`
+
+	// AllowMembersCreatePublicRepos : set to true to allow members to create
+	// public repositories, default false.
+	AllowMembersCreatePublicRepos bool `json:"allowMembersCreatePublicRepos"`
+
+	// AllowMembersForkPrivateRepos : set to true to allow members to fork
+	// private repositories, default false.
+	AllowMembersForkPrivateRepos bool `json:"allowMembersForkPrivateRepos"`
+}
+
+type details struct {
+	DefaultRepositoryPermission string
+	MembersCanCreatePublicRepos bool
+	MembersCanForkPrivateRepos  bool
+}
+
+var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+
+func init() {
+	configFetchConfig = config.FetchConfig
+}
+
+// orgs is the subset of the GitHub API used.
+type orgs interface {
+	Get(context.Context, string) (*github.Organization, *github.Response, error)
+	Edit(context.Context, string, *github.Organization) (*github.Organization,
+		*github.Response, error)
+}
+
+// OrgSettings is the Organization Repository Settings policy object,
+// implements policydef.OrgPolicy.
+type OrgSettings bool
+
+// NewOrgSettings returns a new Organization Repository Settings policy.
+func NewOrgSettings() policydef.OrgPolicy {
+	var o OrgSettings
+	return o
+}
+
+// Name returns the name of this policy, implementing
+// policydef.OrgPolicy.Name()
+func (o OrgSettings) Name() string {
+	return polName
+}
+
+// Check whether this policy is enabled or not
+func (o OrgSettings) IsEnabled(ctx context.Context, c *github.Client, owner string) (bool, error) {
+	oc := getConfig(ctx, c, owner)
+	return oc.Enabled, nil
+}
+
+// Check performs the policy check for Organization Repository Settings based
+// on the configuration stored in the org, implementing
+// policydef.OrgPolicy.Check()
+func (o OrgSettings) Check(ctx context.Context, c *github.Client, owner string) (*policydef.Result, error) {
+	return check(ctx, c.Organizations, c, owner)
+}
+
+func check(ctx context.Context, os orgs, c *github.Client, owner string) (*policydef.Result, error) {
+	oc := getConfig(ctx, c, owner)
+	log.Info().
+		Str("org", owner).
+		Str("area", polName).
+		Bool("enabled", oc.Enabled).
+		Msg("Check org enabled")
+	if !oc.Enabled {
+		return &policydef.Result{
+			Enabled:    false,
+			Pass:       true,
+			NotifyText: "",
+			Details:    details{},
+		}, nil
+	}
+
+	org, err := getOrg(ctx, os, owner)
+	if err != nil {
+		return nil, err
+	}
+	d := details{
+		DefaultRepositoryPermission: org.GetDefaultRepoPermission(),
+		MembersCanCreatePublicRepos: org.GetMembersCanCreatePublicRepos(),
+		MembersCanForkPrivateRepos:  org.GetMembersCanForkPrivateRepos(),
+	}
+	var problems []string
+	if tooPermissive(d.DefaultRepositoryPermission, oc.MaxDefaultRepositoryPermission) {
+		problems = append(problems, fmt.Sprintf("The base repository permission of members is %q, but organization policy allows at most %q.",
+			d.DefaultRepositoryPermission, oc.MaxDefaultRepositoryPermission))
+	}
+	if d.MembersCanCreatePublicRepos && !oc.AllowMembersCreatePublicRepos {
+		problems = append(problems, "Members can create public repositories, but not by organization policy.")
+	}
+	if d.MembersCanForkPrivateRepos && !oc.AllowMembersForkPrivateRepos {
+		problems = append(problems, "Members can fork private repositories, but not by organization policy.")
+	}
+
+	if len(problems) == 0 {
+		return &policydef.Result{
+			Enabled:    true,
+			Pass:       true,
+			NotifyText: "",
+			Details:    d,
+		}, nil
+	}
+	text := "The repository settings of the organization do not meet the policy:\n"
+	for _, p := range problems {
+		text = text + fmt.Sprintf("- %v\n", p)
+	}
+	return &policydef.Result{
+		Enabled:    true,
+		Pass:       false,
+		NotifyText: text + "\n" + notifyText,
+		Details:    d,
+	}, nil
+}
+
+// getOrg gets the organization, with its settings, which are only returned
+// with the organization Administration read permission.
+func getOrg(ctx context.Context, os orgs, owner string) (*github.Organization, error) {
+	org, _, err := os.Get(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	if org.DefaultRepoPermission == nil {
+		return nil, errors.New("repository settings of the organization not returned, the App needs the organization Administration read permission")
+	}
+	return org, nil
+}
+
+// tooPermissive returns whether permission is stronger than max.
+func tooPermissive(permission, max string) bool {
+	rank, ok := permissionRank[permission]
+	if !ok {
+		// Unknown permissions are assumed to be strong, to be reviewed.
+		return true
+	}
+	return rank > permissionRank[max]
+}
+
+// Fix implementing policydef.OrgPolicy.Fix(). Lowers the base repository
+// permission of members to the configured maximum, and disables public
+// repository creation and private repository forking by members, as
+// configured.
+func (o OrgSettings) Fix(ctx context.Context, c *github.Client, owner string) error {
+	return fix(ctx, c.Organizations, c, owner)
+}
+
+func fix(ctx context.Context, os orgs, c *github.Client, owner string) error {
+	oc := getConfig(ctx, c, owner)
+	if !oc.Enabled {
+		return nil
+	}
+	org, err := getOrg(ctx, os, owner)
+	if err != nil {
+		return err
+	}
+	// Only the changed settings are sent, the API leaves the others as is.
+	edit := &github.Organization{}
+	update := false
+	if tooPermissive(org.GetDefaultRepoPermission(), oc.MaxDefaultRepositoryPermission) {
+		edit.DefaultRepoPermission = github.String(oc.MaxDefaultRepositoryPermission)
+		update = true
+	}
+	if org.GetMembersCanCreatePublicRepos() && !oc.AllowMembersCreatePublicRepos {
+		edit.MembersCanCreatePublicRepos = github.Bool(false)
+		update = true
+	}
+	if org.GetMembersCanForkPrivateRepos() && !oc.AllowMembersForkPrivateRepos {
+		edit.MembersCanForkPrivateRepos = github.Bool(false)
+		update = true
+	}
+	if !update {
+		return nil
+	}
+	if _, _, err := os.Edit(ctx, owner, edit); err != nil {
+		return err
+	}
+	log.Info().
+		Str("org", owner).
+		Str("area", polName).
+		Msg("Updated organization repository settings with Fix action.")
+	return nil
+}
+
+// GetAction returns the configured action from Organization Repository
+// Settings' configuration stored in the org-level repo, default log.
+// Implementing policydef.OrgPolicy.GetAction()
+func (o OrgSettings) GetAction(ctx context.Context, c *github.Client, owner string) string {
+	oc := getConfig(ctx, c, owner)
+	return oc.Action
+}
+
+func getConfig(ctx context.Context, c *github.Client, owner string) *OrgConfig {
+	oc := &OrgConfig{ // Fill out non-zero defaults
+		Action:                         "log",
+		MaxDefaultRepositoryPermission: permissionRead,
+	}
+	if err := configFetchConfig(ctx, c, owner, "", configFile, config.OrgLevel, oc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("configLevel", "orgLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	if _, ok := permissionRank[oc.MaxDefaultRepositoryPermission]; !ok {
+		log.Warn().
+			Str("org", owner).
+			Str("area", polName).
+			Str("permission", oc.MaxDefaultRepositoryPermission).
+			Msg("Unknown repository permission configured, using read.")
+		oc.MaxDefaultRepositoryPermission = permissionRead
+	}
+	return oc
+}
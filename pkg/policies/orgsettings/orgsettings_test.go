@@ -0,0 +1,236 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orgsettings
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+)
+
+var org *github.Organization
+var edited *github.Organization
+
+type mockOrgs struct{}
+
+func (m mockOrgs) Get(ctx context.Context, o string) (*github.Organization, *github.Response, error) {
+	return org, nil, nil
+}
+
+func (m mockOrgs) Edit(ctx context.Context, o string, e *github.Organization) (
+	*github.Organization, *github.Response, error) {
+	edited = e
+	return e, nil, nil
+}
+
+func setConfig(oc OrgConfig) {
+	configFetchConfig = func(ctx context.Context, c *github.Client,
+		owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+		if ol == config.OrgLevel {
+			*out.(*OrgConfig) = oc
+		}
+		return nil
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Org        OrgConfig
+		Settings   github.Organization
+		ExpEnabled bool
+		ExpPass    bool
+		ExpErr     bool
+		ExpDetails details
+	}{
+		{
+			Name:       "Disabled",
+			Org:        OrgConfig{},
+			ExpEnabled: false,
+			ExpPass:    true,
+		},
+		{
+			Name: "Pass",
+			Org:  OrgConfig{Enabled: true, MaxDefaultRepositoryPermission: "read"},
+			Settings: github.Organization{
+				DefaultRepoPermission:       github.String("none"),
+				MembersCanCreatePublicRepos: github.Bool(false),
+				MembersCanForkPrivateRepos:  github.Bool(false),
+			},
+			ExpEnabled: true,
+			ExpPass:    true,
+			ExpDetails: details{DefaultRepositoryPermission: "none"},
+		},
+		{
+			Name: "WritePermission",
+			Org:  OrgConfig{Enabled: true, MaxDefaultRepositoryPermission: "read"},
+			Settings: github.Organization{
+				DefaultRepoPermission: github.String("write"),
+			},
+			ExpEnabled: true,
+			ExpPass:    false,
+			ExpDetails: details{DefaultRepositoryPermission: "write"},
+		},
+		{
+			Name: "WritePermissionAllowed",
+			Org:  OrgConfig{Enabled: true, MaxDefaultRepositoryPermission: "write"},
+			Settings: github.Organization{
+				DefaultRepoPermission: github.String("write"),
+			},
+			ExpEnabled: true,
+			ExpPass:    true,
+			ExpDetails: details{DefaultRepositoryPermission: "write"},
+		},
+		{
+			Name: "PublicReposAndForks",
+			Org:  OrgConfig{Enabled: true, MaxDefaultRepositoryPermission: "read"},
+			Settings: github.Organization{
+				DefaultRepoPermission:       github.String("read"),
+				MembersCanCreatePublicRepos: github.Bool(true),
+				MembersCanForkPrivateRepos:  github.Bool(true),
+			},
+			ExpEnabled: true,
+			ExpPass:    false,
+			ExpDetails: details{
+				DefaultRepositoryPermission: "read",
+				MembersCanCreatePublicRepos: true,
+				MembersCanForkPrivateRepos:  true,
+			},
+		},
+		{
+			Name: "PublicReposAndForksAllowed",
+			Org: OrgConfig{
+				Enabled:                        true,
+				MaxDefaultRepositoryPermission: "read",
+				AllowMembersCreatePublicRepos:  true,
+				AllowMembersForkPrivateRepos:   true,
+			},
+			Settings: github.Organization{
+				DefaultRepoPermission:       github.String("read"),
+				MembersCanCreatePublicRepos: github.Bool(true),
+				MembersCanForkPrivateRepos:  github.Bool(true),
+			},
+			ExpEnabled: true,
+			ExpPass:    true,
+			ExpDetails: details{
+				DefaultRepositoryPermission: "read",
+				MembersCanCreatePublicRepos: true,
+				MembersCanForkPrivateRepos:  true,
+			},
+		},
+		{
+			Name:     "NoPermission",
+			Org:      OrgConfig{Enabled: true, MaxDefaultRepositoryPermission: "read"},
+			Settings: github.Organization{},
+			ExpErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			setConfig(test.Org)
+			org = &test.Settings
+
+			res, err := check(context.Background(), mockOrgs{}, nil, "thisorg")
+			if test.ExpErr {
+				if err == nil {
+					t.Errorf("Expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Enabled != test.ExpEnabled {
+				t.Errorf("Unexpected enabled: %v", res.Enabled)
+			}
+			if res.Pass != test.ExpPass {
+				t.Errorf("Unexpected pass: %v, text: %v", res.Pass, res.NotifyText)
+			}
+			if diff := cmp.Diff(test.ExpDetails, res.Details); diff != "" {
+				t.Errorf("Unexpected details. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFix(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Org      OrgConfig
+		Settings github.Organization
+		Exp      *github.Organization
+	}{
+		{
+			Name: "All",
+			Org:  OrgConfig{Enabled: true, MaxDefaultRepositoryPermission: "read"},
+			Settings: github.Organization{
+				DefaultRepoPermission:       github.String("admin"),
+				MembersCanCreatePublicRepos: github.Bool(true),
+				MembersCanForkPrivateRepos:  github.Bool(true),
+			},
+			Exp: &github.Organization{
+				DefaultRepoPermission:       github.String("read"),
+				MembersCanCreatePublicRepos: github.Bool(false),
+				MembersCanForkPrivateRepos:  github.Bool(false),
+			},
+		},
+		{
+			Name: "OnlyPermission",
+			Org: OrgConfig{
+				Enabled:                        true,
+				MaxDefaultRepositoryPermission: "none",
+				AllowMembersCreatePublicRepos:  true,
+			},
+			Settings: github.Organization{
+				DefaultRepoPermission:       github.String("read"),
+				MembersCanCreatePublicRepos: github.Bool(true),
+			},
+			Exp: &github.Organization{
+				DefaultRepoPermission: github.String("none"),
+			},
+		},
+		{
+			Name: "Compliant",
+			Org:  OrgConfig{Enabled: true, MaxDefaultRepositoryPermission: "read"},
+			Settings: github.Organization{
+				DefaultRepoPermission: github.String("read"),
+			},
+		},
+		{
+			Name: "Disabled",
+			Org:  OrgConfig{MaxDefaultRepositoryPermission: "read"},
+			Settings: github.Organization{
+				DefaultRepoPermission: github.String("admin"),
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			setConfig(test.Org)
+			org = &test.Settings
+			edited = nil
+			if err := fix(context.Background(), mockOrgs{}, nil, "thisorg"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.Exp, edited); diff != "" {
+				t.Errorf("Unexpected edit. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -18,10 +18,13 @@ package outside
 import (
 	"context"
 	"fmt"
+	"time"
 
-	"github.com/gobwas/glob"
 	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/exemptions"
 	"github.com/ossf/allstar/pkg/policydef"
+	"github.com/ossf/allstar/pkg/revert"
+	"github.com/ossf/allstar/pkg/selector"
 
 	"github.com/google/go-github/v59/github"
 	"github.com/rs/zerolog/log"
@@ -32,6 +35,8 @@ const polName = "Outside Collaborators"
 
 const accessText = "Found %v outside collaborators with %v access.\n"
 
+const limitText = "Found %v outside collaborators with %v access, more than the %v allowed.\n"
+
 const accessExp = `This policy requires users with this access to be members of the organisation. That way you can easily audit who has access to your repo, and if an account is compromised it can quickly be denied access to organization resources. To fix this you should either remove the user from repository-based access, or add them to the organization. 
 
 * Remove the user from the repository-based access. From the main page of the repository, go to Settings -> Manage Access. 
@@ -48,6 +53,10 @@ OR
 * Exempt the user by adding an exemption to your organization-level Outside Collaborators configuration file.
 `
 
+const expiredText = "The exemption for %v with %v access expired on %v, and is no longer applied.\n"
+
+const expiringText = "The exemption for %v with %v access expires on %v, after which this policy will fail.\n"
+
 // OrgConfig is the org-level config definition for Outside Collaborators
 // security policy.
 type OrgConfig struct {
@@ -58,6 +67,10 @@ type OrgConfig struct {
 	// Action defines which action to take, default log, other: issue...
 	Action string `json:"action"`
 
+	// PullAllowed defines if outside collaborators are allowed to have
+	// read-only (pull or triage) access, default true.
+	PullAllowed bool `json:"pullAllowed"`
+
 	// PushAllowed defined if outside collaborators are allowed to have push
 	// access, default true.
 	PushAllowed bool `json:"pushAllowed"`
@@ -66,6 +79,14 @@ type OrgConfig struct {
 	// access, default false.
 	AdminAllowed bool `json:"adminAllowed"`
 
+	// MaxPush is the number of outside collaborators allowed to have push
+	// access, including admins, when PushAllowed. Default 0, no limit.
+	MaxPush int `json:"maxPush"`
+
+	// MaxAdmin is the number of outside collaborators allowed to have admin
+	// access, when AdminAllowed. Default 0, no limit.
+	MaxAdmin int `json:"maxAdmin"`
+
 	// Exemptions is a list of user-repo-access pairings to exempt.
 	// Exemptions are only defined at the org level because they should be made
 	// obvious to org security managers.
@@ -81,22 +102,32 @@ type RepoConfig struct {
 	// Action overrides the same setting in org-level, only if present.
 	Action *string `json:"action"`
 
+	// PullAllowed overrides the same setting in org-level, only if present.
+	PullAllowed *bool `json:"pullAllowed"`
+
 	// PushAllowed overrides the same setting in org-level, only if present.
 	PushAllowed *bool `json:"pushAllowed"`
 
 	// AdminAllowed overrides the same setting in org-level, only if present.
 	AdminAllowed *bool `json:"adminAllowed"`
+
+	// MaxPush overrides the same setting in org-level, only if present.
+	MaxPush *int `json:"maxPush"`
+
+	// MaxAdmin overrides the same setting in org-level, only if present.
+	MaxAdmin *int `json:"maxAdmin"`
 }
 
 type mergedConfig struct {
 	Action       string
+	PullAllowed  bool
 	PushAllowed  bool
 	AdminAllowed bool
+	MaxPush      int
+	MaxAdmin     int
 	Exemptions   []*OutsideExemption
 }
 
-type globCache map[string]glob.Glob
-
 // OutsideExemption is an exemption entry for the Outside Collaborators policy.
 type OutsideExemption struct {
 	// User is a GitHub username
@@ -105,14 +136,28 @@ type OutsideExemption struct {
 	// Repo is a GitHub repo name
 	Repo string `json:"repo"`
 
-	// Push allows push permission
+	// Repos is a set of RepoSelectors selecting more repos, by name,
+	// language, or topic, for the exemption to apply to.
+	Repos []*selector.RepoSelector `json:"repos"`
+
+	// Push allows push permission. Any exemption allows read-only
+	// permission.
 	Push bool `json:"push"`
 
 	// Admin allows admin permission
 	Admin bool `json:"admin"`
+
+	// Expires is an optional date, in the form YYYY-MM-DD, after which the
+	// exemption is no longer applied. The policy warns about the exemption
+	// in the two weeks before it expires.
+	Expires string `json:"expires"`
 }
 
 type details struct {
+	// OutsidePullCount and OutsidePullers are the outside collaborators with
+	// read-only access.
+	OutsidePullCount  int
+	OutsidePullers    []string
 	OutsidePushCount  int
 	OutsidePushers    []string
 	OutsideAdminCount int
@@ -120,15 +165,28 @@ type details struct {
 	OwnerCount        int
 	DirectOrgAdmins   []string
 	TeamAdmins        []string
+	// ExpiredExemptions are the users with exemptions for this repo that
+	// have expired.
+	ExpiredExemptions []string
+	// ExpiringExemptions are the users with exemptions for this repo that
+	// expire soon.
+	ExpiringExemptions []string
 }
 
 var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
 
 var configIsEnabled func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig, c *github.Client, owner, repo string) (bool, error)
 
+var timeNow func() time.Time
+
+var exemptionsApply func(context.Context, *github.Client, string, string, string, *policydef.Result) *policydef.Result
+
 func init() {
 	configFetchConfig = config.FetchConfig
 	configIsEnabled = config.IsEnabled
+	timeNow = time.Now
+	exemptionsApply = exemptions.Apply
+	revert.Register(polName, revertFix)
 }
 
 // Outside is the Outside Collaborators policy object, implements policydef.Policy.
@@ -146,17 +204,31 @@ func (o Outside) Name() string {
 }
 
 type repositories interface {
+	selector.Repositories
 	ListCollaborators(context.Context, string, string,
 		*github.ListCollaboratorsOptions) ([]*github.User, *github.Response, error)
 	ListTeams(context.Context, string, string, *github.ListOptions) (
 		[]*github.Team, *github.Response, error)
+	collaborators
+}
+
+type collaborators interface {
+	AddCollaborator(context.Context, string, string, string,
+		*github.RepositoryAddCollaboratorOptions) (*github.CollaboratorInvitation,
+		*github.Response, error)
+	RemoveCollaborator(context.Context, string, string, string) (
+		*github.Response, error)
 }
 
 // Check performs the policy check for Outside Collaborators based on the
 // configuration stored in the org/repo, implementing policydef.Policy.Check()
 func (o Outside) Check(ctx context.Context, c *github.Client, owner,
 	repo string) (*policydef.Result, error) {
-	return check(ctx, c.Repositories, c, owner, repo)
+	r, err := check(ctx, c.Repositories, c, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	return exemptionsApply(ctx, c, owner, repo, polName, r), nil
 }
 
 // Check whether this policy is enabled or not
@@ -175,23 +247,25 @@ func check(ctx context.Context, rep repositories, c *github.Client, owner,
 
 	mc := mergeConfig(oc, orc, rc, repo)
 
-	gc := globCache{}
-
 	var d details
-	outAdmins, err := getUsers(ctx, rep, owner, repo, "admin", "outside", mc.Exemptions, gc)
-	if err != nil {
-		return nil, err
-	}
-	outPushers, err := getUsers(ctx, rep, owner, repo, "push", "outside", mc.Exemptions, gc)
+	var expiryText string
+	mc.Exemptions = exemptionsFor(ctx, selector.NewRepo(rep, owner, repo), mc.Exemptions)
+	mc.Exemptions, expiryText = filterExpired(owner, repo, mc.Exemptions, timeNow(), &d)
+	outside, err := listUsers(ctx, rep, owner, repo, "outside")
 	if err != nil {
 		return nil, err
 	}
+	outAdmins := filterUsers(outside, "admin", mc.Exemptions)
+	outPushers := filterUsers(outside, "push", mc.Exemptions)
+	outPullers := filterUsers(outside, "pull", mc.Exemptions)
+	d.OutsidePullCount = len(outPullers)
+	d.OutsidePullers = outPullers
 	d.OutsideAdminCount = len(outAdmins)
 	d.OutsideAdmins = outAdmins
 	d.OutsidePushCount = len(outPushers)
 	d.OutsidePushers = outPushers
 
-	directAdmins, err := getUsers(ctx, rep, owner, repo, "admin", "direct", mc.Exemptions, gc)
+	directAdmins, err := getUsers(ctx, rep, owner, repo, "admin", "direct", mc.Exemptions)
 	if err != nil {
 		return nil, err
 	}
@@ -235,24 +309,122 @@ func check(ctx context.Context, rep repositories, c *github.Client, owner,
 	}
 
 	exp := false
+	if d.OutsidePullCount > 0 && !mc.PullAllowed {
+		rv.Pass = false
+		rv.NotifyText = rv.NotifyText +
+			fmt.Sprintf(accessText, d.OutsidePullCount, "read-only")
+		exp = true
+	}
 	if d.OutsidePushCount > 0 && !mc.PushAllowed {
 		rv.Pass = false
 		rv.NotifyText = rv.NotifyText +
 			fmt.Sprintf(accessText, d.OutsidePushCount, "push")
 		exp = true
+	} else if mc.MaxPush > 0 && d.OutsidePushCount > mc.MaxPush {
+		rv.Pass = false
+		rv.NotifyText = rv.NotifyText +
+			fmt.Sprintf(limitText, d.OutsidePushCount, "push", mc.MaxPush)
+		exp = true
 	}
 	if d.OutsideAdminCount > 0 && !mc.AdminAllowed {
 		rv.Pass = false
 		rv.NotifyText = rv.NotifyText +
 			fmt.Sprintf(accessText, d.OutsideAdminCount, "admin")
 		exp = true
+	} else if mc.MaxAdmin > 0 && d.OutsideAdminCount > mc.MaxAdmin {
+		rv.Pass = false
+		rv.NotifyText = rv.NotifyText +
+			fmt.Sprintf(limitText, d.OutsideAdminCount, "admin", mc.MaxAdmin)
+		exp = true
 	}
 	if exp {
 		rv.NotifyText = rv.NotifyText + accessExp
 	}
+	if expiryText != "" {
+		if rv.NotifyText != "" {
+			rv.NotifyText = rv.NotifyText + "\n"
+		}
+		rv.NotifyText = rv.NotifyText + expiryText
+	}
 	return rv, nil
 }
 
+// exemptionsFor returns the exemptions that apply to repo, those with a Repo
+// glob matching the repo name, or Repos selecting the repo.
+func exemptionsFor(ctx context.Context, repo *selector.Repo, ee []*OutsideExemption) []*OutsideExemption {
+	var rv []*OutsideExemption
+	for _, e := range ee {
+		if e.Repo != "" {
+			match, err := selector.MatchName(e.Repo, repo.Name)
+			if err != nil {
+				log.Warn().
+					Str("repo", repo.Name).
+					Str("glob", e.Repo).
+					Err(err).
+					Msg("Unexpected error compiling the glob.")
+			} else if match {
+				rv = append(rv, e)
+				continue
+			}
+		}
+		match, err := selector.MatchAny(ctx, e.Repos, repo)
+		if err != nil {
+			log.Warn().
+				Str("org", repo.Owner).
+				Str("repo", repo.Name).
+				Str("area", polName).
+				Str("user", e.User).
+				Err(err).
+				Msg("Unexpected error matching exemption repo selectors.")
+		}
+		if match {
+			rv = append(rv, e)
+		}
+	}
+	return rv
+}
+
+// filterExpired returns the exemptions for repo that have not expired, and
+// text describing the expired and soon expiring exemptions, which are also
+// added to d.
+func filterExpired(owner, repo string, ee []*OutsideExemption, now time.Time,
+	d *details) ([]*OutsideExemption, string) {
+	var rv []*OutsideExemption
+	var text string
+	for _, e := range ee {
+		status, err := config.CheckExpiry(e.Expires, now)
+		if err != nil {
+			log.Warn().
+				Str("org", owner).
+				Str("repo", repo).
+				Str("area", polName).
+				Str("user", e.User).
+				Err(err).
+				Msg("Invalid exemption expiry, ignoring exemption.")
+		}
+		if status != config.ExpiryLapsed {
+			rv = append(rv, e)
+		}
+		if status != config.ExpiryLapsed && status != config.ExpirySoon {
+			continue
+		}
+		access := "read-only"
+		if e.Admin {
+			access = "admin"
+		} else if e.Push {
+			access = "push"
+		}
+		if status == config.ExpiryLapsed {
+			d.ExpiredExemptions = append(d.ExpiredExemptions, e.User)
+			text = text + fmt.Sprintf(expiredText, e.User, access, e.Expires)
+		} else {
+			d.ExpiringExemptions = append(d.ExpiringExemptions, e.User)
+			text = text + fmt.Sprintf(expiringText, e.User, access, e.Expires)
+		}
+	}
+	return rv, text
+}
+
 func in(name string, list []string) bool {
 	for _, v := range list {
 		if v == name {
@@ -263,7 +435,15 @@ func in(name string, list []string) bool {
 }
 
 func getUsers(ctx context.Context, r repositories, owner, repo, perm,
-	aff string, exemptions []*OutsideExemption, gc globCache) ([]string, error) {
+	aff string, exemptions []*OutsideExemption) ([]string, error) {
+	users, err := listUsers(ctx, r, owner, repo, aff)
+	if err != nil {
+		return nil, err
+	}
+	return filterUsers(users, perm, exemptions), nil
+}
+
+func listUsers(ctx context.Context, r repositories, owner, repo, aff string) ([]*github.User, error) {
 	opt := &github.ListCollaboratorsOptions{
 		ListOptions: github.ListOptions{
 			PerPage: 100,
@@ -282,48 +462,158 @@ func getUsers(ctx context.Context, r repositories, owner, repo, perm,
 		}
 		opt.Page = resp.NextPage
 	}
+	return users, nil
+}
 
+// filterUsers returns the users with perm access, "admin", "push", or "pull"
+// for read-only access, that are not exempt.
+func filterUsers(users []*github.User, perm string, exemptions []*OutsideExemption) []string {
 	var rv []string
 	for _, u := range users {
-		if u.GetPermissions()[perm] {
-			if !isExempt(repo, u.GetLogin(), perm, exemptions, gc) {
+		if hasAccess(u, perm) {
+			if !isExempt(u.GetLogin(), perm, exemptions) {
 				rv = append(rv, u.GetLogin())
 			}
 		}
 	}
-	return rv, nil
+	return rv
 }
 
-func isExempt(repo, user, access string, ee []*OutsideExemption, gc globCache) bool {
+func hasAccess(u *github.User, access string) bool {
+	if access == "pull" {
+		return !u.GetPermissions()["push"] && !u.GetPermissions()["admin"]
+	}
+	return u.GetPermissions()[access]
+}
+
+// isExempt is passed the exemptions that apply to the repo, see exemptionsFor.
+func isExempt(user, access string, ee []*OutsideExemption) bool {
 	for _, e := range ee {
-		if !(((e.Push || e.Admin) && access == "push") || (e.Admin && access == "admin")) {
+		if !(access == "pull" || ((e.Push || e.Admin) && access == "push") || (e.Admin && access == "admin")) {
 			continue
 		}
-		g, err := gc.compileGlob(e.Repo)
-		if err != nil {
-			log.Warn().
-				Str("repo", repo).
-				Str("glob", e.Repo).
-				Err(err).
-				Msg("Unexpected error compiling the glob.")
-		} else if g.Match(repo) && e.User == user {
+		if e.User == user {
 			return true
 		}
 	}
 	return false
 }
 
-// Fix implementing policydef.Policy.Fix(). Currently not supported. Plan
-// to support this TODO.
+// Fix implementing policydef.Policy.Fix(). Downgrades the outside
+// collaborators with access that is not allowed to the highest access that
+// is, or removes them. Collaborators over MaxPush or MaxAdmin are not fixed,
+// as which of them keep their access is for the repo administrators to
+// decide.
 func (o Outside) Fix(ctx context.Context, c *github.Client, owner, repo string) error {
-	log.Warn().
-		Str("org", owner).
-		Str("repo", repo).
-		Str("area", polName).
-		Msg("Action fix is configured, but not implemented.")
+	return fix(ctx, c.Repositories, c, owner, repo)
+}
+
+func fix(ctx context.Context, rep repositories, c *github.Client, owner, repo string) error {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return nil
+	}
+	mc := mergeConfig(oc, orc, rc, repo)
+	var d details
+	mc.Exemptions = exemptionsFor(ctx, selector.NewRepo(rep, owner, repo), mc.Exemptions)
+	mc.Exemptions, _ = filterExpired(owner, repo, mc.Exemptions, timeNow(), &d)
+	users, err := listUsers(ctx, rep, owner, repo, "outside")
+	if err != nil {
+		return err
+	}
+	allowed := func(user, access string) bool {
+		switch access {
+		case "admin":
+			return mc.AdminAllowed || isExempt(user, access, mc.Exemptions)
+		case "push":
+			return mc.PushAllowed || isExempt(user, access, mc.Exemptions)
+		default:
+			return mc.PullAllowed || isExempt(user, access, mc.Exemptions)
+		}
+	}
+	var pushers, admins int
+	for _, u := range users {
+		login := u.GetLogin()
+		access := "pull"
+		if u.GetPermissions()["admin"] {
+			access = "admin"
+		} else if u.GetPermissions()["push"] {
+			access = "push"
+		}
+		target := access
+		for target != "" && !allowed(login, target) {
+			target = lower[target]
+		}
+		switch target {
+		case "admin":
+			admins++
+			pushers++
+		case "push":
+			pushers++
+		}
+		if target == access {
+			continue
+		}
+		if err := revert.Record(ctx, owner, repo, polName, login, priorAccess{Permission: permission(u)}); err != nil {
+			log.Error().
+				Str("org", owner).
+				Str("repo", repo).
+				Str("area", polName).
+				Str("user", login).
+				Err(err).
+				Msg("Unexpected error recording access before fix, it can not be reverted.")
+		}
+		if target == "" {
+			if _, err := rep.RemoveCollaborator(ctx, owner, repo, login); err != nil {
+				return err
+			}
+		} else {
+			if _, _, err := rep.AddCollaborator(ctx, owner, repo, login,
+				&github.RepositoryAddCollaboratorOptions{Permission: target}); err != nil {
+				return err
+			}
+		}
+		log.Info().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", polName).
+			Str("user", login).
+			Str("from", access).
+			Str("to", target).
+			Msg("Changed outside collaborator access.")
+	}
+	if (mc.MaxPush > 0 && pushers > mc.MaxPush) || (mc.MaxAdmin > 0 && admins > mc.MaxAdmin) {
+		log.Warn().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", polName).
+			Msg("Outside collaborators over the allowed number are not fixed.")
+	}
 	return nil
 }
 
+// lower is the next lower access, empty for none.
+var lower = map[string]string{
+	"admin": "push",
+	"push":  "pull",
+	"pull":  "",
+}
+
+// permission returns the highest permission of u, as named by the
+// collaborators API.
+func permission(u *github.User) string {
+	for _, p := range []string{"admin", "maintain", "push", "triage", "pull"} {
+		if u.GetPermissions()[p] {
+			return p
+		}
+	}
+	return ""
+}
+
 // GetAction returns the configured action from this policy's
 // configuration stored in the org-level repo, default log. Implementing
 // policydef.Policy.GetAction()
@@ -336,6 +626,7 @@ func (o Outside) GetAction(ctx context.Context, c *github.Client, owner, repo st
 func getConfig(ctx context.Context, c *github.Client, owner, repo string) (*OrgConfig, *RepoConfig, *RepoConfig) {
 	oc := &OrgConfig{ // Fill out non-zero defaults
 		Action:      "log",
+		PullAllowed: true,
 		PushAllowed: true,
 	}
 	if err := configFetchConfig(ctx, c, owner, "", configFile, config.OrgLevel, oc); err != nil {
@@ -376,8 +667,11 @@ func getConfig(ctx context.Context, c *github.Client, owner, repo string) (*OrgC
 func mergeConfig(oc *OrgConfig, orc *RepoConfig, rc *RepoConfig, repo string) *mergedConfig {
 	mc := &mergedConfig{
 		Action:       oc.Action,
+		PullAllowed:  oc.PullAllowed,
 		PushAllowed:  oc.PushAllowed,
 		AdminAllowed: oc.AdminAllowed,
+		MaxPush:      oc.MaxPush,
+		MaxAdmin:     oc.MaxAdmin,
 		Exemptions:   oc.Exemptions,
 	}
 	mc = mergeInRepoConfig(mc, orc, repo)
@@ -392,24 +686,20 @@ func mergeInRepoConfig(mc *mergedConfig, rc *RepoConfig, repo string) *mergedCon
 	if rc.Action != nil {
 		mc.Action = *rc.Action
 	}
+	if rc.PullAllowed != nil {
+		mc.PullAllowed = *rc.PullAllowed
+	}
 	if rc.PushAllowed != nil {
 		mc.PushAllowed = *rc.PushAllowed
 	}
 	if rc.AdminAllowed != nil {
 		mc.AdminAllowed = *rc.AdminAllowed
 	}
-	return mc
-}
-
-// compileGlob returns cached glob if present, otherwise attempts glob.Compile.
-func (g globCache) compileGlob(s string) (glob.Glob, error) {
-	if glob, ok := g[s]; ok {
-		return glob, nil
+	if rc.MaxPush != nil {
+		mc.MaxPush = *rc.MaxPush
 	}
-	c, err := glob.Compile(s)
-	if err != nil {
-		return nil, err
+	if rc.MaxAdmin != nil {
+		mc.MaxAdmin = *rc.MaxAdmin
 	}
-	g[s] = c
-	return c, nil
+	return mc
 }
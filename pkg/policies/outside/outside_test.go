@@ -16,12 +16,17 @@ package outside
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-github/v59/github"
 	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/configtest"
 	"github.com/ossf/allstar/pkg/policydef"
+	"github.com/ossf/allstar/pkg/revert"
+	"github.com/ossf/allstar/pkg/selector"
 )
 
 var listCollaborators func(context.Context, string, string,
@@ -36,6 +41,31 @@ func (m mockRepos) ListCollaborators(ctx context.Context, o, r string,
 	return listCollaborators(ctx, o, r, op)
 }
 
+func (m mockRepos) Get(ctx context.Context, owner, repo string) (*github.Repository,
+	*github.Response, error) {
+	return &github.Repository{Topics: []string{"sandbox"}}, nil, nil
+}
+
+func (m mockRepos) ListLanguages(ctx context.Context, owner, repo string) (map[string]int,
+	*github.Response, error) {
+	return nil, nil, nil
+}
+
+// changes are the collaborator changes made, "user:permission", or
+// "user:removed".
+var changes []string
+
+func (m mockRepos) AddCollaborator(ctx context.Context, owner, repo, user string,
+	opts *github.RepositoryAddCollaboratorOptions) (*github.CollaboratorInvitation, *github.Response, error) {
+	changes = append(changes, user+":"+opts.Permission)
+	return nil, nil, nil
+}
+
+func (m mockRepos) RemoveCollaborator(ctx context.Context, owner, repo, user string) (*github.Response, error) {
+	changes = append(changes, user+":removed")
+	return nil, nil
+}
+
 func (m mockRepos) ListTeams(ctx context.Context, owner string, repo string, opts *github.ListOptions) ([]*github.Team, *github.Response, error) {
 	return listTeams(ctx, owner, repo, opts)
 }
@@ -389,6 +419,44 @@ func TestCheck(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "Selector exemption allows admin",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					OptOutStrategy: true,
+				},
+				Exemptions: []*OutsideExemption{
+					{
+						User: alice,
+						Repos: []*selector.RepoSelector{
+							{Topics: []string{"sandbox"}},
+						},
+						Push:  true,
+						Admin: true,
+					},
+				},
+			},
+			Repo: RepoConfig{},
+			Users: []*github.User{
+				&github.User{
+					Login: &alice,
+					Permissions: map[string]bool{
+						"push":  true,
+						"admin": true,
+					},
+				},
+			},
+			cofigEnabled: true,
+			Exp: policydef.Result{
+				Enabled:    true,
+				Pass:       true,
+				NotifyText: "",
+				Details: details{
+					OutsideAdminCount: 0,
+					OutsideAdmins:     nil,
+				},
+			},
+		},
 		{
 			Name: "Exemption allows admin but not push",
 			Org: OrgConfig{
@@ -463,6 +531,118 @@ func TestCheck(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "Read-only blocked",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					OptOutStrategy: true,
+				},
+				PushAllowed: true,
+			},
+			Repo: RepoConfig{},
+			Users: []*github.User{
+				{
+					Login: &alice,
+					Permissions: map[string]bool{
+						"pull": true,
+					},
+				},
+				{
+					Login: &bob,
+					Permissions: map[string]bool{
+						"pull": true,
+						"push": true,
+					},
+				},
+			},
+			cofigEnabled: true,
+			Exp: policydef.Result{
+				Enabled:    true,
+				Pass:       false,
+				NotifyText: "Found 1 outside collaborators with read-only access.\nThis policy requires users with this access to be members of the organisation.",
+				Details: details{
+					OutsidePullCount: 1,
+					OutsidePullers:   []string{"alice"},
+					OutsidePushCount: 1,
+					OutsidePushers:   []string{"bob"},
+				},
+			},
+		},
+		{
+			Name: "Pushers over limit",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					OptOutStrategy: true,
+				},
+				PullAllowed: true,
+				PushAllowed: true,
+				MaxPush:     1,
+			},
+			Repo: RepoConfig{},
+			Users: []*github.User{
+				{
+					Login: &alice,
+					Permissions: map[string]bool{
+						"pull": true,
+						"push": true,
+					},
+				},
+				{
+					Login: &bob,
+					Permissions: map[string]bool{
+						"pull": true,
+						"push": true,
+					},
+				},
+			},
+			cofigEnabled: true,
+			Exp: policydef.Result{
+				Enabled:    true,
+				Pass:       false,
+				NotifyText: "Found 2 outside collaborators with push access, more than the 1 allowed.\nThis policy requires users with this access to be members of the organisation.",
+				Details: details{
+					OutsidePushCount: 2,
+					OutsidePushers:   []string{"alice", "bob"},
+				},
+			},
+		},
+		{
+			Name: "Pushers within repo limit",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					OptOutStrategy: true,
+				},
+				PushAllowed: true,
+				MaxPush:     1,
+			},
+			Repo: RepoConfig{
+				MaxPush: github.Int(2),
+			},
+			Users: []*github.User{
+				{
+					Login: &alice,
+					Permissions: map[string]bool{
+						"push": true,
+					},
+				},
+				{
+					Login: &bob,
+					Permissions: map[string]bool{
+						"push": true,
+					},
+				},
+			},
+			cofigEnabled: true,
+			Exp: policydef.Result{
+				Enabled:    true,
+				Pass:       true,
+				NotifyText: "",
+				Details: details{
+					OutsidePushCount: 2,
+					OutsidePushers:   []string{"alice", "bob"},
+				},
+			},
+		},
 		{
 			Name: "Exemption allows push on matching glob",
 			Org: OrgConfig{
@@ -532,8 +712,89 @@ func TestCheck(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "Exemption expiring soon still allows push",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					OptOutStrategy: true,
+				},
+				Exemptions: []*OutsideExemption{
+					{
+						User:    alice,
+						Repo:    "thisrepo",
+						Push:    true,
+						Expires: "2025-09-10",
+					},
+				},
+			},
+			Repo: RepoConfig{},
+			Users: []*github.User{
+				&github.User{
+					Login: &alice,
+					Permissions: map[string]bool{
+						"push":  true,
+						"admin": false,
+					},
+				},
+			},
+			cofigEnabled: true,
+			Exp: policydef.Result{
+				Enabled:    true,
+				Pass:       true,
+				NotifyText: "The exemption for alice with push access expires on 2025-09-10, after which this policy will fail.\n",
+				Details: details{
+					ExpiringExemptions: []string{"alice"},
+				},
+			},
+		},
+		{
+			Name: "Expired exemption does not allow push",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					OptOutStrategy: true,
+				},
+				Exemptions: []*OutsideExemption{
+					{
+						User:    alice,
+						Repo:    "thisrepo",
+						Push:    true,
+						Expires: "2025-08-31",
+					},
+					{
+						User:    bob,
+						Repo:    "otherrepo",
+						Push:    true,
+						Expires: "2025-08-31",
+					},
+				},
+			},
+			Repo: RepoConfig{},
+			Users: []*github.User{
+				&github.User{
+					Login: &alice,
+					Permissions: map[string]bool{
+						"push":  true,
+						"admin": false,
+					},
+				},
+			},
+			cofigEnabled: true,
+			Exp: policydef.Result{
+				Enabled:    true,
+				Pass:       false,
+				NotifyText: "Found 1 outside collaborators with push access.\nThis policy requires users with this access to be members of the organisation.",
+				Details: details{
+					OutsidePushCount:  1,
+					OutsidePushers:    []string{"alice"},
+					ExpiredExemptions: []string{"alice"},
+				},
+			},
+		},
 	}
 
+	timeNow = func() time.Time { return time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC) }
+	defer func() { timeNow = time.Now }()
+
 	for _, test := range tests {
 		t.Run(test.Name, func(t *testing.T) {
 			configFetchConfig = func(ctx context.Context, c *github.Client,
@@ -576,3 +837,155 @@ func trunc(s string, n int) string {
 	}
 	return s[:n]
 }
+
+func TestGeneratedPrecedence(t *testing.T) {
+	configtest.TestPrecedence(t, configtest.Precedence{
+		Org:   OrgConfig{},
+		Repo:  RepoConfig{},
+		Fetch: &configFetchConfig,
+		Merge: func() interface{} {
+			ctx := context.Background()
+			oc, orc, rc := getConfig(ctx, nil, "", configtest.Repo)
+			return mergeConfig(oc, orc, rc, configtest.Repo)
+		},
+	})
+}
+
+func TestFix(t *testing.T) {
+	user := func(login string, perms ...string) *github.User {
+		p := map[string]bool{"pull": true}
+		for _, v := range perms {
+			p[v] = true
+		}
+		return &github.User{Login: github.String(login), Permissions: p}
+	}
+	tests := []struct {
+		Name       string
+		Org        OrgConfig
+		Users      []*github.User
+		ExpChanges []string
+	}{
+		{
+			Name: "Allowed",
+			Org: OrgConfig{
+				PullAllowed: true,
+				PushAllowed: true,
+			},
+			Users: []*github.User{
+				user("alice"),
+				user("bob", "push"),
+			},
+		},
+		{
+			Name: "Downgrade admin to push",
+			Org: OrgConfig{
+				PullAllowed: true,
+				PushAllowed: true,
+			},
+			Users: []*github.User{
+				user("alice", "push", "admin"),
+				user("bob", "push"),
+			},
+			ExpChanges: []string{"alice:push"},
+		},
+		{
+			Name: "Downgrade to read-only",
+			Org: OrgConfig{
+				PullAllowed: true,
+			},
+			Users: []*github.User{
+				user("alice", "push", "admin"),
+				user("bob", "push", "maintain"),
+				user("carol"),
+			},
+			ExpChanges: []string{"alice:pull", "bob:pull"},
+		},
+		{
+			Name: "Remove",
+			Org:  OrgConfig{},
+			Users: []*github.User{
+				user("alice", "push"),
+				user("bob"),
+			},
+			ExpChanges: []string{"alice:removed", "bob:removed"},
+		},
+		{
+			Name: "Exempt",
+			Org: OrgConfig{
+				Exemptions: []*OutsideExemption{
+					{
+						User: "alice",
+						Repo: "thisrepo",
+						Push: true,
+					},
+				},
+			},
+			Users: []*github.User{
+				user("alice", "push", "admin"),
+			},
+			ExpChanges: []string{"alice:push"},
+		},
+		{
+			Name: "Over limit not fixed",
+			Org: OrgConfig{
+				PullAllowed: true,
+				PushAllowed: true,
+				MaxPush:     1,
+			},
+			Users: []*github.User{
+				user("alice", "push"),
+				user("bob", "push"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.OrgLevel {
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+			listCollaborators = func(c context.Context, o, r string,
+				op *github.ListCollaboratorsOptions) ([]*github.User, *github.Response, error) {
+				return test.Users, &github.Response{NextPage: 0}, nil
+			}
+			configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+				c *github.Client, owner, repo string) (bool, error) {
+				return true, nil
+			}
+			changes = nil
+
+			if err := fix(context.Background(), mockRepos{}, nil, "", "thisrepo"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.ExpChanges, changes); diff != "" {
+				t.Errorf("Unexpected changes (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRevertAccess(t *testing.T) {
+	changes = nil
+	s := revert.Snapshot{
+		Org:    "org",
+		Repo:   "thisrepo",
+		Policy: polName,
+		Target: "alice",
+		Prior:  json.RawMessage(`{"permission":"maintain"}`),
+	}
+	if err := revertAccess(context.Background(), mockRepos{}, s); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"alice:maintain"}, changes); diff != "" {
+		t.Errorf("Unexpected changes (-want +got):\n%s", diff)
+	}
+	s.Prior = json.RawMessage(`{}`)
+	if err := revertAccess(context.Background(), mockRepos{}, s); err == nil {
+		t.Errorf("Expected error without prior permission")
+	}
+}
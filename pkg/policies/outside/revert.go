@@ -0,0 +1,54 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outside
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/ossf/allstar/pkg/revert"
+
+	"github.com/google/go-github/v59/github"
+)
+
+// priorAccess is the access of an outside collaborator before a Fix action
+// changed it, recorded so that the change can be reverted, see the revert
+// package.
+type priorAccess struct {
+	// Permission is the permission of the collaborator, as named by the
+	// collaborators API.
+	Permission string `json:"permission"`
+}
+
+// revertFix restores the access of an outside collaborator, implementing
+// revert.Reverter. A removed collaborator is invited again, and has access
+// once they accept.
+func revertFix(ctx context.Context, c *github.Client, s revert.Snapshot) error {
+	return revertAccess(ctx, c.Repositories, s)
+}
+
+func revertAccess(ctx context.Context, rep collaborators, s revert.Snapshot) error {
+	var prior priorAccess
+	if err := json.Unmarshal(s.Prior, &prior); err != nil {
+		return err
+	}
+	if prior.Permission == "" {
+		return errors.New("no prior permission recorded")
+	}
+	_, _, err := rep.AddCollaborator(ctx, s.Org, s.Repo, s.Target,
+		&github.RepositoryAddCollaboratorOptions{Permission: prior.Permission})
+	return err
+}
@@ -0,0 +1,180 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policies
+
+import (
+	"github.com/ossf/allstar/pkg/config/operator"
+	"github.com/ossf/allstar/pkg/ghclients"
+)
+
+// basePermissions are needed regardless of the policies run, to list
+// repositories, read config, and open issues.
+var basePermissions = []string{"metadata:read", "contents:read", "issues:write"}
+
+type policyPermissions struct {
+	// check is needed to run the policy.
+	check []string
+	// fix is also needed when the policy action is "fix".
+	fix []string
+}
+
+// permissions are the GitHub App permissions needed by each policy, keyed by
+// policy name. Keep this in sync when a policy starts calling a new API.
+var permissions = map[string]policyPermissions{
+	"Binary Artifacts": {
+		fix: []string{"contents:write", "pull_requests:write"},
+	},
+	"Branch Protection": {
+		check: []string{"administration:read"},
+		fix:   []string{"administration:write"},
+	},
+	"CODEOWNERS": {
+		fix: []string{"contents:write", "pull_requests:write"},
+	},
+	"Outside Collaborators": {
+		check: []string{"members:read"},
+		fix:   []string{"administration:write"},
+	},
+	"OpenSSF Scorecard": {
+		check: []string{"administration:read", "actions:read"},
+	},
+	"SECURITY.md": {
+		fix: []string{"contents:write", "pull_requests:write"},
+	},
+	"Dangerous Workflow": {},
+	"GitHub Actions": {
+		check: []string{"actions:read"},
+		fix:   []string{"contents:write", "pull_requests:write", "workflows:write"},
+	},
+	"Repository Administrators": {
+		check: []string{"members:read"},
+		fix:   []string{"administration:write", "members:write"},
+	},
+	"Allowed Actions": {
+		check: []string{"administration:read"},
+		fix:   []string{"administration:write"},
+	},
+	"Security Triage Board": {
+		check: []string{"organization_projects:read"},
+		fix:   []string{"organization_projects:write"},
+	},
+	"Fork PR Workflows": {
+		check: []string{"administration:read"},
+		fix:   []string{"administration:write"},
+	},
+	"Secret Scanning": {
+		check: []string{"administration:read"},
+		fix:   []string{"administration:write"},
+	},
+	"Vulnerability Alerts": {
+		check: []string{"administration:read", "vulnerability_alerts:read"},
+		fix:   []string{"administration:write"},
+	},
+	"Organization Moderation": {
+		check: []string{"organization_administration:read", "members:read"},
+	},
+	"Code Scanning": {
+		check: []string{"security_events:read"},
+	},
+	"OpenSSF Best Practices":    {},
+	"Cache Poisoning":           {},
+	"Dependency Update Latency": {check: []string{"pull_requests:read"}},
+	"Fork PR Deployments": {
+		check: []string{"actions:read"},
+	},
+	"Published Actions": {},
+	"Required Integrations": {
+		check: []string{"organization_administration:read"},
+	},
+	"Repository Lifecycle": {},
+	"Status Check Freshness": {
+		check: []string{"administration:read", "checks:read", "statuses:read", "pull_requests:read"},
+	},
+	"External Access": {
+		check: []string{"administration:read", "members:read"},
+		fix:   []string{"administration:write"},
+	},
+	"Workflow Deprecations": {},
+	"Merge Commit Messages": {
+		check: []string{"administration:read"},
+		fix:   []string{"administration:write"},
+	},
+	"Config Protection": {
+		check: []string{"administration:read"},
+	},
+	"Release Signing Keys": {},
+	"Required Workflows": {
+		fix: []string{"contents:write", "pull_requests:write", "workflows:write"},
+	},
+	"Config Health": {},
+	"Organization Actions Settings": {
+		check: []string{"organization_administration:read", "organization_self_hosted_runners:read"},
+		fix:   []string{"organization_administration:write", "organization_self_hosted_runners:write"},
+	},
+	"Two-Factor Authentication": {
+		check: []string{"members:read"},
+	},
+	"Organization Repository Settings": {
+		check: []string{"organization_administration:read"},
+		fix:   []string{"organization_administration:write"},
+	},
+}
+
+// Permissions returns the GitHub App permissions needed to check the named
+// policy, and those additionally needed to fix it.
+func Permissions(name string) (check, fix []ghclients.Permission) {
+	pp := permissions[name]
+	return parsePermissions(pp.check, name), parsePermissions(pp.fix, name+" fix")
+}
+
+// RequiredPermissions returns the GitHub App permissions needed to run the
+// policies in filter, or all policies if filter is nil. Fix permissions are
+// returned separately, as they are only needed by organizations that set a
+// policy action to "fix".
+func RequiredPermissions(filter map[string]bool) (check, fix []ghclients.Permission) {
+	check = parsePermissions(basePermissions, "")
+	if operator.IssueOverflowCheckRun {
+		check = append(check, parsePermissions([]string{"checks:write"}, "ALLSTAR_ISSUE_OVERFLOW_CHECK_RUN")...)
+	}
+	var names []string
+	for _, p := range GetPolicies() {
+		names = append(names, p.Name())
+	}
+	for _, p := range GetOrgPolicies() {
+		names = append(names, p.Name())
+	}
+	for _, name := range names {
+		if filter != nil && !filter[name] {
+			continue
+		}
+		c, f := Permissions(name)
+		check = append(check, c...)
+		fix = append(fix, f...)
+	}
+	return check, fix
+}
+
+func parsePermissions(perms []string, forWhat string) []ghclients.Permission {
+	var l []ghclients.Permission
+	for _, s := range perms {
+		p, err := ghclients.ParsePermission(s, forWhat)
+		if err != nil {
+			// The table is verified by tests.
+			panic(err)
+		}
+		l = append(l, p)
+	}
+	return l
+}
@@ -0,0 +1,154 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policies
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/ghclients"
+)
+
+func TestPermissionsTable(t *testing.T) {
+	// Valid permission names are the fields of InstallationPermissions.
+	valid := make(map[string]bool)
+	pt := reflect.TypeOf(github.InstallationPermissions{})
+	for i := 0; i < pt.NumField(); i++ {
+		name, _, _ := strings.Cut(pt.Field(i).Tag.Get("json"), ",")
+		valid[name] = true
+	}
+
+	names := make(map[string]bool)
+	for _, p := range GetPolicies() {
+		names[p.Name()] = true
+	}
+	for _, p := range GetOrgPolicies() {
+		names[p.Name()] = true
+	}
+	for name := range names {
+		if _, ok := permissions[name]; !ok {
+			t.Errorf("Policy %q missing from the permissions table", name)
+		}
+	}
+	for name, pp := range permissions {
+		if !names[name] {
+			t.Errorf("Unknown policy %q in the permissions table", name)
+		}
+		for _, s := range append(append(append([]string{}, basePermissions...), pp.check...), pp.fix...) {
+			p, err := ghclients.ParsePermission(s, name)
+			if err != nil {
+				t.Errorf("Policy %q: %v", name, err)
+				continue
+			}
+			if !valid[p.Name] {
+				t.Errorf("Policy %q: unknown permission %q", name, p.Name)
+			}
+		}
+	}
+}
+
+func TestRequiredPermissions(t *testing.T) {
+	check, fix := RequiredPermissions(map[string]bool{"Branch Protection": true})
+	var got []string
+	for _, p := range check {
+		got = append(got, p.String())
+	}
+	want := []string{"metadata:read", "contents:read", "issues:write", "administration:read"}
+	if len(got) != len(want) {
+		t.Fatalf("Unexpected check permissions: %v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Unexpected check permissions: %v", got)
+		}
+	}
+	if len(fix) != 1 || fix[0].String() != "administration:write" || fix[0].For != "Branch Protection fix" {
+		t.Errorf("Unexpected fix permissions: %v", fix)
+	}
+
+	check, fix = RequiredPermissions(nil)
+	if len(check) <= len(basePermissions) || len(fix) == 0 {
+		t.Errorf("Expected permissions of all policies, got: %v, %v", check, fix)
+	}
+}
+
+// stubFixText is logged by the Fix method of policies without a fix.
+const stubFixText = "Action fix is configured, but not implemented."
+
+func TestFixPermissions(t *testing.T) {
+	dirs, err := os.ReadDir(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		fset := token.NewFileSet()
+		pkgs, err := parser.ParseDir(fset, d.Name(), func(fi os.FileInfo) bool {
+			return !strings.HasSuffix(fi.Name(), "_test.go")
+		}, 0)
+		if err != nil {
+			t.Fatalf("Parsing %v: %v", d.Name(), err)
+		}
+		for _, pkg := range pkgs {
+			var name string
+			fixes, stub := false, false
+			for fn, f := range pkg.Files {
+				src, err := os.ReadFile(fn)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ast.Inspect(f, func(n ast.Node) bool {
+					switch n := n.(type) {
+					case *ast.ValueSpec:
+						for i, id := range n.Names {
+							if id.Name != "polName" || i >= len(n.Values) {
+								continue
+							}
+							if l, ok := n.Values[i].(*ast.BasicLit); ok {
+								name, _ = strconv.Unquote(l.Value)
+							}
+						}
+					case *ast.FuncDecl:
+						if n.Recv == nil || n.Name.Name != "Fix" || n.Body == nil {
+							return false
+						}
+						fixes = true
+						body := src[fset.Position(n.Body.Pos()).Offset:fset.Position(n.Body.End()).Offset]
+						stub = strings.Contains(string(body), stubFixText)
+						return false
+					}
+					return true
+				})
+			}
+			if name == "" || !fixes || stub {
+				continue
+			}
+			if len(permissions[name].fix) == 0 {
+				t.Errorf("Policy %q in %v implements Fix, but declares no fix permissions",
+					name, filepath.Join("pkg/policies", d.Name()))
+			}
+		}
+	}
+}
@@ -19,12 +19,37 @@ package policies
 import (
 	"github.com/ossf/allstar/pkg/policies/action"
 	"github.com/ossf/allstar/pkg/policies/admin"
+	"github.com/ossf/allstar/pkg/policies/allowedactions"
+	"github.com/ossf/allstar/pkg/policies/bestpractices"
 	"github.com/ossf/allstar/pkg/policies/binary"
 	"github.com/ossf/allstar/pkg/policies/branch"
+	"github.com/ossf/allstar/pkg/policies/cachepoisoning"
+	"github.com/ossf/allstar/pkg/policies/checkfreshness"
 	"github.com/ossf/allstar/pkg/policies/codeowners"
+	"github.com/ossf/allstar/pkg/policies/codescanning"
+	"github.com/ossf/allstar/pkg/policies/confighealth"
+	"github.com/ossf/allstar/pkg/policies/configprotection"
+	"github.com/ossf/allstar/pkg/policies/deprecations"
+	"github.com/ossf/allstar/pkg/policies/externalaccess"
+	"github.com/ossf/allstar/pkg/policies/forkdeploy"
+	"github.com/ossf/allstar/pkg/policies/forkpr"
+	"github.com/ossf/allstar/pkg/policies/integrations"
+	"github.com/ossf/allstar/pkg/policies/lifecycle"
+	"github.com/ossf/allstar/pkg/policies/mergemessage"
+	"github.com/ossf/allstar/pkg/policies/moderation"
+	"github.com/ossf/allstar/pkg/policies/orgactions"
+	"github.com/ossf/allstar/pkg/policies/orgsettings"
 	"github.com/ossf/allstar/pkg/policies/outside"
+	"github.com/ossf/allstar/pkg/policies/publishedactions"
+	"github.com/ossf/allstar/pkg/policies/releasekeys"
+	"github.com/ossf/allstar/pkg/policies/requiredworkflows"
 	"github.com/ossf/allstar/pkg/policies/scorecard"
+	"github.com/ossf/allstar/pkg/policies/secretscanning"
 	"github.com/ossf/allstar/pkg/policies/security"
+	"github.com/ossf/allstar/pkg/policies/triageboard"
+	"github.com/ossf/allstar/pkg/policies/twofactor"
+	"github.com/ossf/allstar/pkg/policies/updatelatency"
+	"github.com/ossf/allstar/pkg/policies/vulnalerts"
 	"github.com/ossf/allstar/pkg/policies/workflow"
 	"github.com/ossf/allstar/pkg/policydef"
 )
@@ -41,5 +66,39 @@ func GetPolicies() []policydef.Policy {
 		workflow.NewWorkflow(),
 		action.NewAction(),
 		admin.NewAdmin(),
+		allowedactions.NewAllowedActions(),
+		forkpr.NewForkPR(),
+		secretscanning.NewSecretScanning(),
+		vulnalerts.NewVulnAlerts(),
+		codescanning.NewCodeScanning(),
+		bestpractices.NewBestPractices(),
+		cachepoisoning.NewCachePoisoning(),
+		updatelatency.NewUpdateLatency(),
+		forkdeploy.NewForkDeploy(),
+		publishedactions.NewPublishedActions(),
+		integrations.NewIntegrations(),
+		checkfreshness.NewCheckFreshness(),
+		externalaccess.NewExternalAccess(),
+		deprecations.NewDeprecations(),
+		mergemessage.NewMergeMessage(),
+		configprotection.NewConfigProtection(),
+		releasekeys.NewReleaseKeys(),
+		requiredworkflows.NewRequiredWorkflows(),
+		// Config Health reports problems found fetching the config of the
+		// other policies, so it must be last.
+		confighealth.NewConfigHealth(),
+	}
+}
+
+// GetOrgPolicies returns a slice of all org-scope policies in Allstar, which
+// are run once per organization.
+func GetOrgPolicies() []policydef.OrgPolicy {
+	return []policydef.OrgPolicy{
+		orgactions.NewOrgActions(),
+		twofactor.NewTwoFactor(),
+		orgsettings.NewOrgSettings(),
+		triageboard.NewTriageBoard(),
+		moderation.NewModeration(),
+		lifecycle.NewLifecycle(),
 	}
 }
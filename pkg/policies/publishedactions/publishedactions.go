@@ -0,0 +1,457 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package publishedactions implements the Published Actions policy, which
+// applies stricter requirements to repositories that publish a GitHub Action,
+// as detected by an action.yml file, since others run their code.
+package publishedactions
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/policies/workflowfiles"
+	"github.com/ossf/allstar/pkg/policydef"
+	"github.com/rhysd/actionlint"
+	"sigs.k8s.io/yaml"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+const configFile = "published_actions.yaml"
+const polName = "Published Actions"
+
+const notifyText = `This repository publishes a GitHub Action, defined in %v. Other repositories run the code of this Action in their workflows, so it must meet stricter requirements:
+
+%v
+A license tells users of the Action how they may use it, and a security policy tells them how to report vulnerabilities. Dependencies pinned to a full commit SHA prevent a compromised upstream Action from changing the code that runs in this repository, or in the workflows of users of a composite Action.
+
+For more information, see https://docs.github.com/en/actions/security-guides/security-hardening-for-github-actions#using-third-party-actions`
+
+// metadataPaths are the paths of the metadata file of an Action published
+// from a repository.
+var metadataPaths = []string{"action.yml", "action.yaml"}
+
+// securityPolicyPaths are the paths GitHub reads a security policy from.
+var securityPolicyPaths = []string{"SECURITY.md", ".github/SECURITY.md", "docs/SECURITY.md"}
+
+// commitSHARe matches a full length commit SHA.
+var commitSHARe = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// OrgConfig is the org-level config definition for Published Actions.
+type OrgConfig struct {
+	// OptConfig is the standard org-level opt in/out config, RepoOverride
+	// applies to all config.
+	OptConfig config.OrgOptConfig `json:"optConfig"`
+
+	// Action defines which action to take, default log, other: issue...
+	Action string `json:"action"`
+
+	// RequireLicense requires repositories that publish an Action to have a
+	// license, default true.
+	RequireLicense bool `json:"requireLicense"`
+
+	// RequireSecurityPolicy requires repositories that publish an Action to
+	// have a SECURITY.md, default true.
+	RequireSecurityPolicy bool `json:"requireSecurityPolicy"`
+
+	// RequirePinnedDependencies requires repositories that publish an Action to
+	// pin the Actions and reusable workflows used by their workflows, and by
+	// the steps of a composite Action, to a full commit SHA, default true.
+	RequirePinnedDependencies bool `json:"requirePinnedDependencies"`
+}
+
+// RepoConfig is the repo-level config for Published Actions.
+type RepoConfig struct {
+	// OptConfig is the standard repo-level opt in/out config.
+	OptConfig config.RepoOptConfig `json:"optConfig"`
+
+	// Action overrides the same setting in org-level, only if present.
+	Action *string `json:"action"`
+
+	// RequireLicense overrides the same setting in org-level, only if present.
+	RequireLicense *bool `json:"requireLicense"`
+
+	// RequireSecurityPolicy overrides the same setting in org-level, only if
+	// present.
+	RequireSecurityPolicy *bool `json:"requireSecurityPolicy"`
+
+	// RequirePinnedDependencies overrides the same setting in org-level, only
+	// if present.
+	RequirePinnedDependencies *bool `json:"requirePinnedDependencies"`
+}
+
+type mergedConfig struct {
+	Action                    string
+	RequireLicense            bool
+	RequireSecurityPolicy     bool
+	RequirePinnedDependencies bool
+}
+
+type details struct {
+	// Metadata is the path of the Action metadata file, empty if the repo
+	// does not publish an Action.
+	Metadata string
+	// License is the SPDX ID of the license, empty if there is none.
+	License string
+	// SecurityPolicy is the path of the security policy, empty if there is
+	// none.
+	SecurityPolicy string
+	// Unpinned are the dependencies not pinned to a commit SHA, as
+	// "path: uses".
+	Unpinned []string
+}
+
+// actionMetadata is the part of an Action metadata file read by this policy.
+type actionMetadata struct {
+	Runs struct {
+		Using string `json:"using"`
+		Steps []struct {
+			Uses string `json:"uses"`
+		} `json:"steps"`
+	} `json:"runs"`
+}
+
+var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+var configIsEnabled func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig, c *github.Client, owner, repo string) (bool, error)
+var getContent func(context.Context, *github.Client, string, string, string) (string, bool, error)
+var getLicense func(context.Context, *github.Client, string, string) (string, error)
+var listWorkflows func(context.Context, *github.Client, string, string, string) ([]*workflowfiles.Workflow, error)
+
+func init() {
+	configFetchConfig = config.FetchConfig
+	configIsEnabled = config.IsEnabled
+	getContent = getContentReal
+	getLicense = getLicenseReal
+	listWorkflows = workflowfiles.ListParsed
+}
+
+// PublishedActions is the Published Actions policy object, implements
+// policydef.Policy.
+type PublishedActions bool
+
+// NewPublishedActions returns a new Published Actions policy.
+func NewPublishedActions() policydef.Policy {
+	var p PublishedActions
+	return p
+}
+
+// Name returns the name of this policy, implementing policydef.Policy.Name()
+func (p PublishedActions) Name() string {
+	return polName
+}
+
+// Check whether this policy is enabled or not
+func (p PublishedActions) IsEnabled(ctx context.Context, c *github.Client, owner, repo string) (bool, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	return configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+}
+
+// Check performs the policy check for Published Actions policy based on the
+// configuration stored in the org/repo, implementing policydef.Policy.Check()
+func (p PublishedActions) Check(ctx context.Context, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Bool("enabled", enabled).
+		Msg("Check repo enabled")
+	mc := mergeConfig(oc, orc, rc, repo)
+
+	var d details
+	var metadata string
+	for _, mp := range metadataPaths {
+		content, ok, err := getContent(ctx, c, owner, repo, mp)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			d.Metadata = mp
+			metadata = content
+			break
+		}
+	}
+	if d.Metadata == "" {
+		// Not an Action, the requirements do not apply.
+		return &policydef.Result{
+			Enabled:    enabled,
+			Pass:       true,
+			NotifyText: "",
+			Details:    d,
+		}, nil
+	}
+
+	var text string
+	if mc.RequireLicense {
+		d.License, err = getLicense(ctx, c, owner, repo)
+		if err != nil {
+			return nil, err
+		}
+		if d.License == "" {
+			text += "- The repository does not have a license.\n"
+		}
+	}
+	if mc.RequireSecurityPolicy {
+		for _, sp := range securityPolicyPaths {
+			_, ok, err := getContent(ctx, c, owner, repo, sp)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				d.SecurityPolicy = sp
+				break
+			}
+		}
+		if d.SecurityPolicy == "" {
+			text += "- The repository does not have a security policy (SECURITY.md).\n"
+		}
+	}
+	if mc.RequirePinnedDependencies {
+		wfs, err := listWorkflows(ctx, c, owner, repo, polName)
+		if err != nil {
+			return nil, err
+		}
+		d.Unpinned = append(unpinnedMetadata(d.Metadata, metadata), unpinnedWorkflows(wfs)...)
+		if len(d.Unpinned) > 0 {
+			text += "- Dependencies are not pinned to a full commit SHA:\n"
+			for _, u := range d.Unpinned {
+				text += fmt.Sprintf("  - `%v`\n", u)
+			}
+		}
+	}
+
+	if text == "" {
+		return &policydef.Result{
+			Enabled:    enabled,
+			Pass:       true,
+			NotifyText: "",
+			Details:    d,
+		}, nil
+	}
+	return &policydef.Result{
+		Enabled:    enabled,
+		Pass:       false,
+		NotifyText: fmt.Sprintf(notifyText, d.Metadata, text),
+		Details:    d,
+	}, nil
+}
+
+// unpinnedMetadata returns the unpinned dependencies used by the steps of a
+// composite Action.
+func unpinnedMetadata(p, content string) []string {
+	var am actionMetadata
+	if err := yaml.Unmarshal([]byte(content), &am); err != nil {
+		log.Warn().
+			Str("area", polName).
+			Str("path", p).
+			Err(err).
+			Msg("Unable to parse Action metadata file, skipping.")
+		return nil
+	}
+	var us []string
+	for _, s := range am.Runs.Steps {
+		if !pinned(s.Uses) {
+			us = append(us, fmt.Sprintf("%v: %v", p, s.Uses))
+		}
+	}
+	return us
+}
+
+// unpinnedWorkflows returns the unpinned Actions and reusable workflows used
+// by wfs, sorted by workflow.
+func unpinnedWorkflows(wfs []*workflowfiles.Workflow) []string {
+	sort.SliceStable(wfs, func(i, j int) bool {
+		return wfs[i].Path < wfs[j].Path
+	})
+	var us []string
+	for _, wf := range wfs {
+		seen := make(map[string]bool)
+		var uses []string
+		for _, j := range wf.Workflow.Jobs {
+			if j == nil {
+				continue
+			}
+			if j.WorkflowCall != nil && j.WorkflowCall.Uses != nil {
+				uses = append(uses, j.WorkflowCall.Uses.Value)
+			}
+			for _, s := range j.Steps {
+				if s == nil || s.Exec == nil {
+					continue
+				}
+				e, ok := s.Exec.(*actionlint.ExecAction)
+				if !ok || e.Uses == nil {
+					continue
+				}
+				uses = append(uses, e.Uses.Value)
+			}
+		}
+		sort.Strings(uses)
+		for _, u := range uses {
+			if pinned(u) || seen[u] {
+				continue
+			}
+			seen[u] = true
+			us = append(us, fmt.Sprintf("%v: %v", wf.Path, u))
+		}
+	}
+	return us
+}
+
+// pinned returns whether uses refers to a local Action or workflow, a Docker
+// image, or is pinned to a full commit SHA.
+func pinned(uses string) bool {
+	if uses == "" || strings.HasPrefix(uses, "./") || strings.HasPrefix(uses, "docker://") {
+		return true
+	}
+	_, ref, ok := strings.Cut(uses, "@")
+	return ok && commitSHARe.MatchString(ref)
+}
+
+// getContentReal returns the content of the file at p in a repo, and whether
+// it exists.
+func getContentReal(ctx context.Context, c *github.Client, owner, repo, p string) (string, bool, error) {
+	fc, _, rsp, err := c.Repositories.GetContents(ctx, owner, repo, p, nil)
+	if err != nil {
+		if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	if fc == nil {
+		// p is a directory.
+		return "", false, nil
+	}
+	content, err := fc.GetContent()
+	if err != nil {
+		return "", false, err
+	}
+	return content, true, nil
+}
+
+// getLicenseReal returns the SPDX ID of the license of a repo, or "" if it has
+// none. A license GitHub can not identify is "NOASSERTION".
+func getLicenseReal(ctx context.Context, c *github.Client, owner, repo string) (string, error) {
+	l, rsp, err := c.Repositories.License(ctx, owner, repo)
+	if err != nil {
+		if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+			return "", nil
+		}
+		return "", err
+	}
+	return l.GetLicense().GetSPDXID(), nil
+}
+
+// Fix implementing policydef.Policy.Fix(). Not supported.
+func (p PublishedActions) Fix(ctx context.Context, c *github.Client, owner, repo string) error {
+	log.Warn().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Msg("Action fix is configured, but not implemented.")
+	return nil
+}
+
+// GetAction returns the configured action from Published Actions policy's
+// configuration stored in the org-level repo, default log. Implementing
+// policydef.Policy.GetAction()
+func (p PublishedActions) GetAction(ctx context.Context, c *github.Client, owner, repo string) string {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	mc := mergeConfig(oc, orc, rc, repo)
+	return mc.Action
+}
+
+func getConfig(ctx context.Context, c *github.Client, owner, repo string) (*OrgConfig, *RepoConfig, *RepoConfig) {
+	oc := &OrgConfig{ // Fill out non-zero defaults
+		Action:                    "log",
+		RequireLicense:            true,
+		RequireSecurityPolicy:     true,
+		RequirePinnedDependencies: true,
+	}
+	if err := configFetchConfig(ctx, c, owner, "", configFile, config.OrgLevel, oc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	orc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.OrgRepoLevel, orc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgRepoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	rc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.RepoLevel, rc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "repoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	return oc, orc, rc
+}
+
+func mergeConfig(oc *OrgConfig, orc, rc *RepoConfig, repo string) *mergedConfig {
+	mc := &mergedConfig{
+		Action:                    oc.Action,
+		RequireLicense:            oc.RequireLicense,
+		RequireSecurityPolicy:     oc.RequireSecurityPolicy,
+		RequirePinnedDependencies: oc.RequirePinnedDependencies,
+	}
+	mc = mergeInRepoConfig(mc, orc, repo)
+
+	if !oc.OptConfig.DisableRepoOverride {
+		mc = mergeInRepoConfig(mc, rc, repo)
+	}
+	return mc
+}
+
+func mergeInRepoConfig(mc *mergedConfig, rc *RepoConfig, repo string) *mergedConfig {
+	if rc.Action != nil {
+		mc.Action = *rc.Action
+	}
+	if rc.RequireLicense != nil {
+		mc.RequireLicense = *rc.RequireLicense
+	}
+	if rc.RequireSecurityPolicy != nil {
+		mc.RequireSecurityPolicy = *rc.RequireSecurityPolicy
+	}
+	if rc.RequirePinnedDependencies != nil {
+		mc.RequirePinnedDependencies = *rc.RequirePinnedDependencies
+	}
+	return mc
+}
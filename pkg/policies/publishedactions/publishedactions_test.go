@@ -0,0 +1,343 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package publishedactions
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/configtest"
+	"github.com/ossf/allstar/pkg/policies/workflowfiles"
+	"github.com/rhysd/actionlint"
+)
+
+func TestConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Org       OrgConfig
+		OrgRepo   RepoConfig
+		Repo      RepoConfig
+		ExpAction string
+		Exp       mergedConfig
+	}{
+		{
+			Name: "OrgOnly",
+			Org: OrgConfig{
+				Action:         "issue",
+				RequireLicense: true,
+			},
+			OrgRepo:   RepoConfig{},
+			Repo:      RepoConfig{},
+			ExpAction: "issue",
+			Exp: mergedConfig{
+				Action:         "issue",
+				RequireLicense: true,
+			},
+		},
+		{
+			Name: "OrgRepoOverOrg",
+			Org: OrgConfig{
+				Action:         "issue",
+				RequireLicense: true,
+			},
+			OrgRepo: RepoConfig{
+				Action:                    github.String("log"),
+				RequireLicense:            github.Bool(false),
+				RequirePinnedDependencies: github.Bool(true),
+			},
+			Repo:      RepoConfig{},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action:                    "log",
+				RequirePinnedDependencies: true,
+			},
+		},
+		{
+			Name: "RepoOverAllOrg",
+			Org: OrgConfig{
+				Action: "issue",
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action:                github.String("email"),
+				RequireSecurityPolicy: github.Bool(true),
+			},
+			ExpAction: "email",
+			Exp: mergedConfig{
+				Action:                "email",
+				RequireSecurityPolicy: true,
+			},
+		},
+		{
+			Name: "RepoDisallowed",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					DisableRepoOverride: true,
+				},
+				Action:         "issue",
+				RequireLicense: true,
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action:         github.String("email"),
+				RequireLicense: github.Bool(false),
+			},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action:         "log",
+				RequireLicense: true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				switch ol {
+				case config.RepoLevel:
+					rc := out.(*RepoConfig)
+					*rc = test.Repo
+				case config.OrgRepoLevel:
+					orc := out.(*RepoConfig)
+					*orc = test.OrgRepo
+				case config.OrgLevel:
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+
+			p := PublishedActions(true)
+			ctx := context.Background()
+
+			action := p.GetAction(ctx, nil, "", "thisrepo")
+			if action != test.ExpAction {
+				t.Errorf("Unexpected results. want %s, got %s", test.ExpAction, action)
+			}
+
+			oc, orc, rc := getConfig(ctx, nil, "", "thisrepo")
+			mc := mergeConfig(oc, orc, rc, "thisrepo")
+			if diff := cmp.Diff(&test.Exp, mc); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+const sha = "b4ffde65f46336ab88eb53be808477a3936bae11"
+
+const compositeAction = `name: My Action
+runs:
+  using: composite
+  steps:
+    - uses: actions/setup-go@v5
+    - uses: actions/cache@` + sha + `
+    - run: make
+      shell: bash
+`
+
+const nodeAction = `name: My Action
+runs:
+  using: node20
+  main: dist/index.js
+`
+
+const testWorkflow = `name: Test
+on: pull_request
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/checkout@v4
+      - uses: ./
+      - uses: docker://alpine:3
+  release:
+    uses: my/workflows/.github/workflows/release.yml@main
+`
+
+const pinnedWorkflow = `name: Test
+on: pull_request
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@` + sha + `
+      - uses: ./
+`
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Files      map[string]string
+		License    string
+		Workflows  map[string]string
+		Repo       RepoConfig
+		ExpPass    bool
+		ExpNotify  string
+		ExpDetails details
+	}{
+		{
+			Name:       "NotAnAction",
+			Workflows:  map[string]string{"test.yaml": testWorkflow},
+			ExpPass:    true,
+			ExpDetails: details{},
+		},
+		{
+			Name: "Compliant",
+			Files: map[string]string{
+				"action.yml":          nodeAction,
+				".github/SECURITY.md": "Report to security@example.com",
+			},
+			License:   "Apache-2.0",
+			Workflows: map[string]string{"test.yaml": pinnedWorkflow},
+			ExpPass:   true,
+			ExpDetails: details{
+				Metadata:       "action.yml",
+				License:        "Apache-2.0",
+				SecurityPolicy: ".github/SECURITY.md",
+			},
+		},
+		{
+			Name: "Missing",
+			Files: map[string]string{
+				"action.yaml": compositeAction,
+			},
+			Workflows: map[string]string{"test.yaml": testWorkflow},
+			ExpPass:   false,
+			ExpNotify: "- The repository does not have a license.\n- The repository does not have a security policy (SECURITY.md).\n- Dependencies are not pinned to a full commit SHA:\n  - `action.yaml: actions/setup-go@v5`\n",
+			ExpDetails: details{
+				Metadata: "action.yaml",
+				Unpinned: []string{
+					"action.yaml: actions/setup-go@v5",
+					".github/workflows/test.yaml: actions/checkout@v4",
+					".github/workflows/test.yaml: my/workflows/.github/workflows/release.yml@main",
+				},
+			},
+		},
+		{
+			Name: "RequirementsDisabled",
+			Files: map[string]string{
+				"action.yml": compositeAction,
+			},
+			Workflows: map[string]string{"test.yaml": testWorkflow},
+			Repo: RepoConfig{
+				RequireLicense:            github.Bool(false),
+				RequireSecurityPolicy:     github.Bool(false),
+				RequirePinnedDependencies: github.Bool(false),
+			},
+			ExpPass: true,
+			ExpDetails: details{
+				Metadata: "action.yml",
+			},
+		},
+	}
+
+	configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+		c *github.Client, owner, repo string) (bool, error) {
+		return true, nil
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.RepoLevel {
+					rc := out.(*RepoConfig)
+					*rc = test.Repo
+				}
+				return nil
+			}
+			getContent = func(ctx context.Context, c *github.Client, owner, repo, p string) (string, bool, error) {
+				content, ok := test.Files[p]
+				return content, ok, nil
+			}
+			getLicense = func(ctx context.Context, c *github.Client, owner, repo string) (string, error) {
+				return test.License, nil
+			}
+			listWorkflows = func(ctx context.Context, c *github.Client, owner, repo, area string) ([]*workflowfiles.Workflow, error) {
+				var wfs []*workflowfiles.Workflow
+				for name, content := range test.Workflows {
+					wf, errs := actionlint.Parse([]byte(content))
+					if len(errs) > 0 {
+						t.Fatalf("Unexpected parse errors in %v: %v", name, errs)
+					}
+					wfs = append(wfs, &workflowfiles.Workflow{
+						Path:     ".github/workflows/" + name,
+						Workflow: wf,
+					})
+				}
+				return wfs, nil
+			}
+
+			res, err := PublishedActions(true).Check(context.Background(), nil, "thisorg", "thisrepo")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Pass != test.ExpPass {
+				t.Errorf("Unexpected pass: %v", res.Pass)
+			}
+			if test.ExpNotify != "" && !strings.Contains(res.NotifyText, test.ExpNotify) {
+				t.Errorf("Expected notify text to contain:\n%v\ngot:\n%v", test.ExpNotify, res.NotifyText)
+			}
+			if diff := cmp.Diff(test.ExpDetails, res.Details); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPinned(t *testing.T) {
+	tests := []struct {
+		Uses string
+		Exp  bool
+	}{
+		{"actions/checkout@" + sha, true},
+		{"actions/checkout@v4", false},
+		{"actions/checkout@" + sha[:7], false},
+		{"actions/checkout", false},
+		{"./.github/actions/build", true},
+		{"docker://alpine:3", true},
+	}
+	for _, test := range tests {
+		t.Run(test.Uses, func(t *testing.T) {
+			if got := pinned(test.Uses); got != test.Exp {
+				t.Errorf("Unexpected results. want %v, got %v", test.Exp, got)
+			}
+		})
+	}
+}
+
+func TestGeneratedPrecedence(t *testing.T) {
+	configtest.TestPrecedence(t, configtest.Precedence{
+		Org:   OrgConfig{},
+		Repo:  RepoConfig{},
+		Fetch: &configFetchConfig,
+		Merge: func() interface{} {
+			ctx := context.Background()
+			oc, orc, rc := getConfig(ctx, nil, "", configtest.Repo)
+			return mergeConfig(oc, orc, rc, configtest.Repo)
+		},
+	})
+}
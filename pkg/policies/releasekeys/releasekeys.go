@@ -0,0 +1,470 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package releasekeys implements the Release Signing Keys policy. It checks
+// that the accounts that published the latest releases of a repository have
+// signing keys registered, so that the tags and artifacts they sign can be
+// verified by users.
+package releasekeys
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ossf/allstar/pkg/cache"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/policydef"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+const configFile = "release_signing_keys.yaml"
+const polName = "Release Signing Keys"
+
+// Key types that may be accepted as a signing identity.
+const (
+	keyTypeGPG = "gpg"
+	keyTypeSSH = "ssh"
+)
+
+const notifyText = `This policy requires that the accounts publishing releases of this repository have signing keys registered on GitHub, so that the release tags and artifacts they sign can be verified against their identity.
+
+To fix this, the release authors should add a GPG or SSH signing key to their account, from Settings -> SSH and GPG keys. GPG keys must be able to sign, not be expired, and have a verified email.
+(For more information, see https://docs.github.com/en/authentication/managing-commit-signature-verification/about-commit-signature-verification)`
+
+// OrgConfig is the org-level config definition for Release Signing Keys.
+type OrgConfig struct {
+	// OptConfig is the standard org-level opt in/out config, RepoOverride
+	// applies to all config.
+	OptConfig config.OrgOptConfig `json:"optConfig"`
+
+	// Action defines which action to take, default log, other: issue...
+	Action string `json:"action"`
+
+	// LatestReleases is the number of latest releases whose authors are
+	// checked, default 1.
+	LatestReleases int `json:"latestReleases"`
+
+	// KeyTypes are the types of signing keys accepted, "gpg" and "ssh",
+	// default both.
+	KeyTypes []string `json:"keyTypes"`
+
+	// ExemptAuthors is a list of account logins that may publish releases
+	// without signing keys, such as release automation accounts. Globs are
+	// allowed. Bot accounts are always exempt, as they can not register keys.
+	ExemptAuthors []string `json:"exemptAuthors"`
+
+	// RepoSelectors overrides LatestReleases and ExemptAuthors for the repos
+	// selected. The first selector matching a repo applies.
+	RepoSelectors []ReleaseSelector `json:"repoSelectors"`
+}
+
+// ReleaseSelector sets the release checks of a set of repos.
+type ReleaseSelector struct {
+	// Repos is a list of repo names. Globs are allowed.
+	Repos []string `json:"repos"`
+
+	// LatestReleases overrides the org-level setting for the selected repos,
+	// if set.
+	LatestReleases int `json:"latestReleases"`
+
+	// ExemptAuthors overrides the org-level setting for the selected repos,
+	// if set.
+	ExemptAuthors []string `json:"exemptAuthors"`
+}
+
+// RepoConfig is the repo-level config for Release Signing Keys.
+type RepoConfig struct {
+	// OptConfig is the standard repo-level opt in/out config.
+	OptConfig config.RepoOptConfig `json:"optConfig"`
+
+	// Action overrides the same setting in org-level, only if present.
+	Action *string `json:"action"`
+
+	// LatestReleases overrides the same setting in org-level, only if
+	// present.
+	LatestReleases *int `json:"latestReleases"`
+
+	// KeyTypes overrides the same setting in org-level, only if present.
+	KeyTypes []string `json:"keyTypes"`
+
+	// ExemptAuthors overrides the same setting in org-level, only if present.
+	ExemptAuthors []string `json:"exemptAuthors"`
+}
+
+type mergedConfig struct {
+	Action         string
+	LatestReleases int
+	KeyTypes       []string
+	ExemptAuthors  []string
+}
+
+type details struct {
+	Releases []releaseDetails
+	// Unverified are the release authors with no signing keys.
+	Unverified []string
+}
+
+type releaseDetails struct {
+	Tag     string
+	Author  string
+	Exempt  bool
+	GPGKeys int
+	SSHKeys int
+}
+
+var gc = cache.NewGlobCache(cache.DefaultSize)
+
+var timeNow = time.Now
+
+var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+
+var configIsEnabled func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig, c *github.Client, owner, repo string) (bool, error)
+
+func init() {
+	configFetchConfig = config.FetchConfig
+	configIsEnabled = config.IsEnabled
+}
+
+// releases is the subset of the GitHub API used, spanning the repositories
+// and users go-github services.
+type releases interface {
+	ListReleases(context.Context, string, string, *github.ListOptions) (
+		[]*github.RepositoryRelease, *github.Response, error)
+	ListGPGKeys(context.Context, string, *github.ListOptions) (
+		[]*github.GPGKey, *github.Response, error)
+	ListSSHSigningKeys(context.Context, string, *github.ListOptions) (
+		[]*github.SSHSigningKey, *github.Response, error)
+}
+
+// ReleaseKeys is the Release Signing Keys policy object, implements
+// policydef.Policy.
+type ReleaseKeys bool
+
+// NewReleaseKeys returns a new Release Signing Keys policy.
+func NewReleaseKeys() policydef.Policy {
+	var r ReleaseKeys
+	return r
+}
+
+// Name returns the name of this policy, implementing policydef.Policy.Name()
+func (r ReleaseKeys) Name() string {
+	return polName
+}
+
+// Check whether this policy is enabled or not
+func (r ReleaseKeys) IsEnabled(ctx context.Context, c *github.Client, owner, repo string) (bool, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	return configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+}
+
+// Check performs the policy check for Release Signing Keys based on the
+// configuration stored in the org/repo, implementing policydef.Policy.Check()
+func (r ReleaseKeys) Check(ctx context.Context, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	return check(ctx, releasesClient{c}, c, owner, repo)
+}
+
+func check(ctx context.Context, rel releases, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Bool("enabled", enabled).
+		Msg("Check repo enabled")
+
+	mc := mergeConfig(oc, orc, rc, repo)
+
+	// Drafts are listed, but not published, so request more than needed.
+	rs, _, err := rel.ListReleases(ctx, owner, repo, &github.ListOptions{
+		PerPage: 100,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Key types are not case sensitive.
+	gpg := slices.ContainsFunc(mc.KeyTypes, func(t string) bool { return strings.EqualFold(t, keyTypeGPG) })
+	ssh := slices.ContainsFunc(mc.KeyTypes, func(t string) bool { return strings.EqualFold(t, keyTypeSSH) })
+	var d details
+	// Authors of several releases are only checked once.
+	checked := make(map[string]*releaseDetails)
+	for _, r := range rs {
+		if r.GetDraft() {
+			continue
+		}
+		if len(d.Releases) >= mc.LatestReleases {
+			break
+		}
+		author := r.GetAuthor()
+		rd := releaseDetails{
+			Tag:    r.GetTagName(),
+			Author: author.GetLogin(),
+		}
+		if prev, ok := checked[rd.Author]; ok {
+			rd.Exempt = prev.Exempt
+			rd.GPGKeys = prev.GPGKeys
+			rd.SSHKeys = prev.SSHKeys
+			d.Releases = append(d.Releases, rd)
+			continue
+		}
+		rd.Exempt = author.GetType() == "Bot" || matches(mc.ExemptAuthors, rd.Author, repo)
+		if !rd.Exempt {
+			if gpg {
+				rd.GPGKeys, err = countGPGKeys(ctx, rel, rd.Author)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if ssh {
+				rd.SSHKeys, err = countSSHKeys(ctx, rel, rd.Author)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if rd.GPGKeys+rd.SSHKeys == 0 {
+				d.Unverified = append(d.Unverified, rd.Author)
+			}
+		}
+		checked[rd.Author] = &rd
+		d.Releases = append(d.Releases, rd)
+	}
+
+	if len(d.Unverified) == 0 {
+		return &policydef.Result{
+			Enabled:    enabled,
+			Pass:       true,
+			NotifyText: "",
+			Details:    d,
+		}, nil
+	}
+	text := "The latest releases were published by accounts with no signing keys registered:\n"
+	for _, rd := range d.Releases {
+		for _, u := range d.Unverified {
+			if rd.Author == u {
+				text = text + fmt.Sprintf("- `%v` published by @%v\n", rd.Tag, rd.Author)
+			}
+		}
+	}
+	return &policydef.Result{
+		Enabled:    enabled,
+		Pass:       false,
+		NotifyText: text + "\n" + notifyText,
+		Details:    d,
+	}, nil
+}
+
+// countGPGKeys returns the number of GPG keys of user that can sign, are not
+// expired, and have a verified email.
+func countGPGKeys(ctx context.Context, rel releases, user string) (int, error) {
+	keys, _, err := rel.ListGPGKeys(ctx, user, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, k := range keys {
+		if gpgKeyValid(k) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func gpgKeyValid(k *github.GPGKey) bool {
+	if k.ExpiresAt != nil && !k.GetExpiresAt().After(timeNow()) {
+		return false
+	}
+	canSign := k.GetCanSign()
+	for _, s := range k.Subkeys {
+		canSign = canSign || (s.GetCanSign() &&
+			(s.ExpiresAt == nil || s.GetExpiresAt().After(timeNow())))
+	}
+	if !canSign {
+		return false
+	}
+	for _, e := range k.Emails {
+		if e.GetVerified() {
+			return true
+		}
+	}
+	return false
+}
+
+// countSSHKeys returns the number of SSH signing keys of user.
+func countSSHKeys(ctx context.Context, rel releases, user string) (int, error) {
+	keys, _, err := rel.ListSSHSigningKeys(ctx, user, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return 0, err
+	}
+	return len(keys), nil
+}
+
+func matches(s []string, e, repo string) bool {
+	for _, v := range s {
+		g, err := gc.Compile(v)
+		if err != nil {
+			log.Warn().
+				Str("repo", repo).
+				Str("area", polName).
+				Str("glob", v).
+				Err(err).
+				Msg("Unexpected error compiling the glob.")
+		} else if g.Match(e) {
+			return true
+		}
+	}
+	return false
+}
+
+// Fix implementing policydef.Policy.Fix(). Not supported, signing keys can
+// only be added by the release authors.
+func (r ReleaseKeys) Fix(ctx context.Context, c *github.Client, owner, repo string) error {
+	log.Warn().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Msg("Action fix is configured, but not implemented.")
+	return nil
+}
+
+// GetAction returns the configured action from Release Signing Keys'
+// configuration stored in the org-level repo, default log. Implementing
+// policydef.Policy.GetAction()
+func (r ReleaseKeys) GetAction(ctx context.Context, c *github.Client, owner, repo string) string {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	mc := mergeConfig(oc, orc, rc, repo)
+	return mc.Action
+}
+
+func getConfig(ctx context.Context, c *github.Client, owner, repo string) (*OrgConfig, *RepoConfig, *RepoConfig) {
+	oc := &OrgConfig{ // Fill out non-zero defaults
+		Action:         "log",
+		LatestReleases: 1,
+		KeyTypes:       []string{keyTypeGPG, keyTypeSSH},
+	}
+	if err := configFetchConfig(ctx, c, owner, "", configFile, config.OrgLevel, oc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	orc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.OrgRepoLevel, orc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgRepoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	rc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.RepoLevel, rc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "repoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	return oc, orc, rc
+}
+
+func mergeConfig(oc *OrgConfig, orc, rc *RepoConfig, repo string) *mergedConfig {
+	mc := &mergedConfig{
+		Action:         oc.Action,
+		LatestReleases: oc.LatestReleases,
+		KeyTypes:       oc.KeyTypes,
+		ExemptAuthors:  oc.ExemptAuthors,
+	}
+	if s := selectRepo(repo, oc.RepoSelectors); s != nil {
+		if s.LatestReleases > 0 {
+			mc.LatestReleases = s.LatestReleases
+		}
+		if s.ExemptAuthors != nil {
+			mc.ExemptAuthors = s.ExemptAuthors
+		}
+	}
+	mc = mergeInRepoConfig(mc, orc, repo)
+
+	if !oc.OptConfig.DisableRepoOverride {
+		mc = mergeInRepoConfig(mc, rc, repo)
+	}
+	if mc.LatestReleases < 1 {
+		mc.LatestReleases = 1
+	}
+	return mc
+}
+
+func mergeInRepoConfig(mc *mergedConfig, rc *RepoConfig, repo string) *mergedConfig {
+	if rc.Action != nil {
+		mc.Action = *rc.Action
+	}
+	if rc.LatestReleases != nil {
+		mc.LatestReleases = *rc.LatestReleases
+	}
+	if rc.KeyTypes != nil {
+		mc.KeyTypes = rc.KeyTypes
+	}
+	if rc.ExemptAuthors != nil {
+		mc.ExemptAuthors = rc.ExemptAuthors
+	}
+	return mc
+}
+
+// selectRepo returns the first selector matching repo, or nil.
+func selectRepo(repo string, ss []ReleaseSelector) *ReleaseSelector {
+	for i, s := range ss {
+		if matches(s.Repos, repo, repo) {
+			return &ss[i]
+		}
+	}
+	return nil
+}
+
+// releasesClient implements releases with a GitHub client.
+type releasesClient struct {
+	c *github.Client
+}
+
+func (r releasesClient) ListReleases(ctx context.Context, owner, repo string,
+	opt *github.ListOptions) ([]*github.RepositoryRelease, *github.Response, error) {
+	return r.c.Repositories.ListReleases(ctx, owner, repo, opt)
+}
+
+func (r releasesClient) ListGPGKeys(ctx context.Context, user string,
+	opt *github.ListOptions) ([]*github.GPGKey, *github.Response, error) {
+	return r.c.Users.ListGPGKeys(ctx, user, opt)
+}
+
+func (r releasesClient) ListSSHSigningKeys(ctx context.Context, user string,
+	opt *github.ListOptions) ([]*github.SSHSigningKey, *github.Response, error) {
+	return r.c.Users.ListSSHSigningKeys(ctx, user, opt)
+}
@@ -0,0 +1,271 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package releasekeys
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/configtest"
+)
+
+var releaseList []*github.RepositoryRelease
+var gpgKeys map[string][]*github.GPGKey
+var sshKeys map[string][]*github.SSHSigningKey
+var keyLookups int
+
+type mockReleases struct{}
+
+func (m mockReleases) ListReleases(ctx context.Context, o, r string,
+	opt *github.ListOptions) ([]*github.RepositoryRelease, *github.Response, error) {
+	return releaseList, nil, nil
+}
+
+func (m mockReleases) ListGPGKeys(ctx context.Context, u string,
+	opt *github.ListOptions) ([]*github.GPGKey, *github.Response, error) {
+	keyLookups++
+	return gpgKeys[u], nil, nil
+}
+
+func (m mockReleases) ListSSHSigningKeys(ctx context.Context, u string,
+	opt *github.ListOptions) ([]*github.SSHSigningKey, *github.Response, error) {
+	return sshKeys[u], nil, nil
+}
+
+func release(tag, author, authorType string) *github.RepositoryRelease {
+	return &github.RepositoryRelease{
+		TagName: github.String(tag),
+		Author: &github.User{
+			Login: github.String(author),
+			Type:  github.String(authorType),
+		},
+	}
+}
+
+func gpgKey(canSign, verified bool, expires time.Time) *github.GPGKey {
+	k := &github.GPGKey{
+		CanSign: github.Bool(canSign),
+		Emails: []*github.GPGEmail{
+			{Email: github.String("a@example.com"), Verified: github.Bool(verified)},
+		},
+	}
+	if !expires.IsZero() {
+		k.ExpiresAt = &github.Timestamp{Time: expires}
+	}
+	return k
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	configtest.TestPrecedence(t, configtest.Precedence{
+		Org:   OrgConfig{},
+		Repo:  RepoConfig{},
+		Fetch: &configFetchConfig,
+		Merge: func() interface{} {
+			ctx := context.Background()
+			oc, orc, rc := getConfig(ctx, nil, "", configtest.Repo)
+			return mergeConfig(oc, orc, rc, configtest.Repo)
+		},
+		Ignore: []string{"LatestReleases", "RepoSelectors"},
+	})
+}
+
+func TestRepoSelectors(t *testing.T) {
+	oc := &OrgConfig{
+		Action:         "log",
+		LatestReleases: 1,
+		ExemptAuthors:  []string{"release-bot"},
+		RepoSelectors: []ReleaseSelector{
+			{Repos: []string{"lib-*"}, LatestReleases: 5},
+			{Repos: []string{"lib-*", "tools"}, ExemptAuthors: []string{}},
+		},
+	}
+	tests := []struct {
+		Repo string
+		Exp  mergedConfig
+	}{
+		{"app", mergedConfig{Action: "log", LatestReleases: 1, ExemptAuthors: []string{"release-bot"}}},
+		{"lib-a", mergedConfig{Action: "log", LatestReleases: 5, ExemptAuthors: []string{"release-bot"}}},
+		{"tools", mergedConfig{Action: "log", LatestReleases: 1, ExemptAuthors: []string{}}},
+	}
+	for _, test := range tests {
+		t.Run(test.Repo, func(t *testing.T) {
+			mc := mergeConfig(oc, &RepoConfig{}, &RepoConfig{}, test.Repo)
+			if diff := cmp.Diff(&test.Exp, mc); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	tests := []struct {
+		Name          string
+		Org           OrgConfig
+		Releases      []*github.RepositoryRelease
+		GPG           map[string][]*github.GPGKey
+		SSH           map[string][]*github.SSHSigningKey
+		ExpPass       bool
+		ExpUnverified []string
+		ExpLookups    int
+	}{
+		{
+			Name:     "NoReleases",
+			Org:      OrgConfig{LatestReleases: 1, KeyTypes: []string{"gpg", "ssh"}},
+			ExpPass:  true,
+			Releases: nil,
+		},
+		{
+			Name:     "GPGKey",
+			Org:      OrgConfig{LatestReleases: 1, KeyTypes: []string{"gpg", "ssh"}},
+			Releases: []*github.RepositoryRelease{release("v1", "alice", "User")},
+			GPG: map[string][]*github.GPGKey{
+				"alice": {gpgKey(true, true, time.Time{})},
+			},
+			ExpPass:    true,
+			ExpLookups: 1,
+		},
+		{
+			Name:     "SSHKey",
+			Org:      OrgConfig{LatestReleases: 1, KeyTypes: []string{"gpg", "ssh"}},
+			Releases: []*github.RepositoryRelease{release("v1", "alice", "User")},
+			SSH: map[string][]*github.SSHSigningKey{
+				"alice": {{Key: github.String("ssh-ed25519 AAAA")}},
+			},
+			ExpPass:    true,
+			ExpLookups: 1,
+		},
+		{
+			Name:     "SSHKeyNotAccepted",
+			Org:      OrgConfig{LatestReleases: 1, KeyTypes: []string{"gpg"}},
+			Releases: []*github.RepositoryRelease{release("v1", "alice", "User")},
+			SSH: map[string][]*github.SSHSigningKey{
+				"alice": {{Key: github.String("ssh-ed25519 AAAA")}},
+			},
+			ExpPass:       false,
+			ExpUnverified: []string{"alice"},
+			ExpLookups:    1,
+		},
+		{
+			Name: "InvalidGPGKeys",
+			Org:  OrgConfig{LatestReleases: 3, KeyTypes: []string{"gpg", "ssh"}},
+			Releases: []*github.RepositoryRelease{
+				release("v3", "alice", "User"),
+				release("v2", "bob", "User"),
+				release("v1", "carol", "User"),
+			},
+			GPG: map[string][]*github.GPGKey{
+				"alice": {gpgKey(false, true, time.Time{})},
+				"bob":   {gpgKey(true, false, time.Time{})},
+				"carol": {gpgKey(true, true, now.Add(-time.Hour))},
+			},
+			ExpPass:       false,
+			ExpUnverified: []string{"alice", "bob", "carol"},
+			ExpLookups:    3,
+		},
+		{
+			Name: "LatestOnly",
+			Org:  OrgConfig{LatestReleases: 1, KeyTypes: []string{"gpg", "ssh"}},
+			Releases: []*github.RepositoryRelease{
+				release("v2", "alice", "User"),
+				release("v1", "bob", "User"),
+			},
+			GPG: map[string][]*github.GPGKey{
+				"alice": {gpgKey(true, true, time.Time{})},
+			},
+			ExpPass:    true,
+			ExpLookups: 1,
+		},
+		{
+			Name: "DraftsSkipped",
+			Org:  OrgConfig{LatestReleases: 1, KeyTypes: []string{"gpg", "ssh"}},
+			Releases: []*github.RepositoryRelease{
+				{TagName: github.String("v3"), Draft: github.Bool(true), Author: &github.User{Login: github.String("bob")}},
+				release("v2", "alice", "User"),
+			},
+			GPG: map[string][]*github.GPGKey{
+				"alice": {gpgKey(true, true, time.Time{})},
+			},
+			ExpPass:    true,
+			ExpLookups: 1,
+		},
+		{
+			Name: "ExemptAndBots",
+			Org: OrgConfig{LatestReleases: 3, KeyTypes: []string{"gpg", "ssh"},
+				ExemptAuthors: []string{"release-*"}},
+			Releases: []*github.RepositoryRelease{
+				release("v3", "github-actions[bot]", "Bot"),
+				release("v2", "release-automation", "User"),
+				release("v1", "alice", "User"),
+			},
+			ExpPass:       false,
+			ExpUnverified: []string{"alice"},
+			ExpLookups:    1,
+		},
+		{
+			Name: "AuthorCheckedOnce",
+			Org:  OrgConfig{LatestReleases: 3, KeyTypes: []string{"gpg", "ssh"}},
+			Releases: []*github.RepositoryRelease{
+				release("v3", "alice", "User"),
+				release("v2", "alice", "User"),
+				release("v1", "alice", "User"),
+			},
+			ExpPass:       false,
+			ExpUnverified: []string{"alice"},
+			ExpLookups:    1,
+		},
+	}
+
+	configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+		c *github.Client, owner, repo string) (bool, error) {
+		return true, nil
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.OrgLevel {
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+			releaseList = test.Releases
+			gpgKeys = test.GPG
+			sshKeys = test.SSH
+			keyLookups = 0
+
+			res, err := check(context.Background(), mockReleases{}, nil, "thisorg", "thisrepo")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Pass != test.ExpPass {
+				t.Errorf("Unexpected pass: %v, text: %v", res.Pass, res.NotifyText)
+			}
+			if diff := cmp.Diff(test.ExpUnverified, res.Details.(details).Unverified); diff != "" {
+				t.Errorf("Unexpected unverified authors. (-want +got):\n%s", diff)
+			}
+			if keyLookups != test.ExpLookups {
+				t.Errorf("Unexpected key lookups: %v, want %v", keyLookups, test.ExpLookups)
+			}
+		})
+	}
+}
@@ -0,0 +1,530 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package requiredworkflows implements the Required Workflows policy. It
+// checks that workflows the organization requires, such as Scorecard or CodeQL
+// analysis, are present in each repository, optionally with the exact content
+// of the organization's template, similar to GitHub's required workflows.
+package requiredworkflows
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/ossf/allstar/pkg/cache"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/policydef"
+	"github.com/ossf/allstar/pkg/pullrequest"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+const configFile = "required_workflows.yaml"
+const polName = "Required Workflows"
+
+// fixBranch is the branch the Fix action proposes the workflows from.
+const fixBranch = "allstar/required-workflows"
+
+// defaultTemplateRepo is the repository of GitHub's organization workflow
+// templates.
+const defaultTemplateRepo = ".github"
+
+// templateDir is the directory of GitHub's organization workflow templates.
+const templateDir = "workflow-templates"
+
+// defaultBranchPlaceholder is replaced with the default branch of the
+// repository in templates, as in GitHub's workflow templates.
+const defaultBranchPlaceholder = "$default-branch"
+
+const notifyText = `This organization requires some GitHub Actions workflows in every repository, such as security analysis, so that they run consistently across the organization.
+
+To fix this, add the workflows listed above, copied from the organization's templates in the %v repository.`
+
+// OrgConfig is the org-level config definition for Required Workflows.
+type OrgConfig struct {
+	// OptConfig is the standard org-level opt in/out config, RepoOverride
+	// applies to all config.
+	OptConfig config.OrgOptConfig `json:"optConfig"`
+
+	// Action defines which action to take, default log, other: issue...
+	Action string `json:"action"`
+
+	// Workflows are the workflows required in the repositories.
+	Workflows []Workflow `json:"workflows"`
+
+	// TemplateRepo is the repository of the organization holding the
+	// templates of the required workflows, default ".github", where GitHub
+	// reads organization workflow templates from.
+	TemplateRepo string `json:"templateRepo"`
+}
+
+// Workflow is a required workflow.
+type Workflow struct {
+	// Path is the path of the workflow in the repository, ex:
+	// ".github/workflows/scorecard.yml".
+	Path string `json:"path"`
+
+	// Template is the path of the workflow template in the TemplateRepo,
+	// which the fix action proposes. Default
+	// "workflow-templates/<file name of Path>". "$default-branch" in the
+	// template is replaced with the default branch of the repository.
+	Template string `json:"template"`
+
+	// SHA256 is the hex encoded SHA-256 hash of the required content of the
+	// workflow. Default empty, any content is accepted.
+	SHA256 string `json:"sha256"`
+
+	// MatchTemplate requires the content of the workflow to be the same as
+	// its template. Default false, any content is accepted.
+	MatchTemplate bool `json:"matchTemplate"`
+
+	// Repos limits the requirement to these repos. Globs are allowed. Default
+	// empty, all repos.
+	Repos []string `json:"repos"`
+}
+
+// RepoConfig is the repo-level config for Required Workflows.
+type RepoConfig struct {
+	// OptConfig is the standard repo-level opt in/out config.
+	OptConfig config.RepoOptConfig `json:"optConfig"`
+
+	// Action overrides the same setting in org-level, only if present.
+	Action *string `json:"action"`
+
+	// ExemptWorkflows are the paths of required workflows not required in
+	// this repository.
+	ExemptWorkflows []string `json:"exemptWorkflows"`
+}
+
+type mergedConfig struct {
+	Action          string
+	Workflows       []Workflow
+	TemplateRepo    string
+	ExemptWorkflows []string
+}
+
+type details struct {
+	// Missing are the paths of the required workflows not in the repository.
+	Missing []string
+	// Mismatched are the paths of the required workflows without the
+	// required content.
+	Mismatched []string
+}
+
+var gc = cache.NewGlobCache(cache.DefaultSize)
+
+var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+
+var configIsEnabled func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig, c *github.Client, owner, repo string) (bool, error)
+
+var pullrequestEnsure func(context.Context, *github.Client, string, string, string, *pullrequest.Request) (*github.PullRequest, error)
+
+func init() {
+	configFetchConfig = config.FetchConfig
+	configIsEnabled = config.IsEnabled
+	pullrequestEnsure = pullrequest.Ensure
+}
+
+type repositories interface {
+	Get(context.Context, string, string) (*github.Repository,
+		*github.Response, error)
+	GetContents(context.Context, string, string, string,
+		*github.RepositoryContentGetOptions) (*github.RepositoryContent,
+		[]*github.RepositoryContent, *github.Response, error)
+}
+
+// RequiredWorkflows is the Required Workflows policy object, implements
+// policydef.Policy.
+type RequiredWorkflows bool
+
+// NewRequiredWorkflows returns a new Required Workflows policy.
+func NewRequiredWorkflows() policydef.Policy {
+	var r RequiredWorkflows
+	return r
+}
+
+// Name returns the name of this policy, implementing policydef.Policy.Name()
+func (r RequiredWorkflows) Name() string {
+	return polName
+}
+
+// Check whether this policy is enabled or not
+func (r RequiredWorkflows) IsEnabled(ctx context.Context, c *github.Client, owner, repo string) (bool, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	return configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+}
+
+// Check performs the policy check for Required Workflows based on the
+// configuration stored in the org/repo, implementing policydef.Policy.Check()
+func (r RequiredWorkflows) Check(ctx context.Context, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	return check(ctx, c.Repositories, c, owner, repo)
+}
+
+func check(ctx context.Context, rep repositories, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Bool("enabled", enabled).
+		Msg("Check repo enabled")
+
+	mc := mergeConfig(oc, orc, rc, repo)
+	d, err := compare(ctx, rep, owner, repo, mc)
+	if err != nil {
+		return nil, err
+	}
+	if len(d.Missing)+len(d.Mismatched) == 0 {
+		return &policydef.Result{
+			Enabled:    enabled,
+			Pass:       true,
+			NotifyText: "",
+			Details:    d,
+		}, nil
+	}
+	var text string
+	if len(d.Missing) > 0 {
+		text = text + "Required workflows are missing:\n"
+		for _, p := range d.Missing {
+			text = text + fmt.Sprintf("- `%v`\n", p)
+		}
+	}
+	if len(d.Mismatched) > 0 {
+		text = text + "Required workflows do not have the content required by the organization:\n"
+		for _, p := range d.Mismatched {
+			text = text + fmt.Sprintf("- `%v`\n", p)
+		}
+	}
+	return &policydef.Result{
+		Enabled:    enabled,
+		Pass:       false,
+		NotifyText: text + "\n" + fmt.Sprintf(notifyText, mc.TemplateRepo),
+		Details:    d,
+	}, nil
+}
+
+// compare compares the workflows of the repo with the required workflows.
+func compare(ctx context.Context, rep repositories, owner, repo string, mc *mergedConfig) (details, error) {
+	var d details
+	t := newTemplates(rep, owner, repo, mc.TemplateRepo)
+	for _, w := range required(mc, repo) {
+		content, found, err := getFile(ctx, rep, owner, repo, w.Path)
+		if err != nil {
+			return d, err
+		}
+		if !found {
+			d.Missing = append(d.Missing, w.Path)
+			continue
+		}
+		if w.SHA256 != "" && !strings.EqualFold(hash(content), w.SHA256) {
+			d.Mismatched = append(d.Mismatched, w.Path)
+			continue
+		}
+		if w.MatchTemplate {
+			tmpl, found, err := t.get(ctx, templatePath(w))
+			if err != nil {
+				return d, err
+			}
+			if !found {
+				logTemplateNotFound(owner, repo, mc.TemplateRepo, templatePath(w))
+				continue
+			}
+			if !bytes.Equal(content, tmpl) {
+				d.Mismatched = append(d.Mismatched, w.Path)
+			}
+		}
+	}
+	return d, nil
+}
+
+// required returns the workflows required in repo.
+func required(mc *mergedConfig, repo string) []Workflow {
+	var ws []Workflow
+	for _, w := range mc.Workflows {
+		if len(w.Repos) > 0 && !matches(w.Repos, repo) {
+			continue
+		}
+		exempt := false
+		for _, e := range mc.ExemptWorkflows {
+			if e == w.Path {
+				exempt = true
+			}
+		}
+		if !exempt {
+			ws = append(ws, w)
+		}
+	}
+	return ws
+}
+
+func templatePath(w Workflow) string {
+	if w.Template != "" {
+		return w.Template
+	}
+	return path.Join(templateDir, path.Base(w.Path))
+}
+
+func hash(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+// getFile returns the content of the file at p, and whether it was found.
+func getFile(ctx context.Context, rep repositories, owner, repo, p string) ([]byte, bool, error) {
+	fc, _, rsp, err := rep.GetContents(ctx, owner, repo, p, nil)
+	if err != nil {
+		if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	if fc == nil {
+		// A directory.
+		return nil, false, nil
+	}
+	s, err := fc.GetContent()
+	if err != nil {
+		return nil, false, err
+	}
+	return []byte(s), true, nil
+}
+
+// templates gets the templates of the required workflows, rendered for the
+// repo, once each.
+type templates struct {
+	rep          repositories
+	owner        string
+	repo         string
+	templateRepo string
+	branch       string
+	cache        map[string][]byte
+}
+
+func newTemplates(rep repositories, owner, repo, templateRepo string) *templates {
+	return &templates{
+		rep:          rep,
+		owner:        owner,
+		repo:         repo,
+		templateRepo: templateRepo,
+		cache:        make(map[string][]byte),
+	}
+}
+
+// get returns the template at p, rendered for the repo, and whether it was
+// found.
+func (t *templates) get(ctx context.Context, p string) ([]byte, bool, error) {
+	if b, ok := t.cache[p]; ok {
+		return b, b != nil, nil
+	}
+	b, found, err := getFile(ctx, t.rep, t.owner, t.templateRepo, p)
+	if err != nil {
+		return nil, false, err
+	}
+	if found && bytes.Contains(b, []byte(defaultBranchPlaceholder)) {
+		if t.branch == "" {
+			r, _, err := t.rep.Get(ctx, t.owner, t.repo)
+			if err != nil {
+				return nil, false, err
+			}
+			t.branch = r.GetDefaultBranch()
+		}
+		b = bytes.ReplaceAll(b, []byte(defaultBranchPlaceholder), []byte(t.branch))
+	}
+	t.cache[p] = b
+	return b, found, nil
+}
+
+func logTemplateNotFound(owner, repo, templateRepo, p string) {
+	log.Warn().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Str("templateRepo", templateRepo).
+		Str("template", p).
+		Msg("Required workflow template not found.")
+}
+
+func matches(s []string, repo string) bool {
+	for _, v := range s {
+		g, err := gc.Compile(v)
+		if err != nil {
+			log.Warn().
+				Str("repo", repo).
+				Str("area", polName).
+				Str("glob", v).
+				Err(err).
+				Msg("Unexpected error compiling the glob.")
+		} else if g.Match(repo) {
+			return true
+		}
+	}
+	return false
+}
+
+// Fix implementing policydef.Policy.Fix(). Opens a pull request adding the
+// missing workflows, and replacing those without the required content, from
+// their templates.
+func (r RequiredWorkflows) Fix(ctx context.Context, c *github.Client, owner, repo string) error {
+	return fix(ctx, c.Repositories, c, owner, repo)
+}
+
+func fix(ctx context.Context, rep repositories, c *github.Client, owner, repo string) error {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return nil
+	}
+	mc := mergeConfig(oc, orc, rc, repo)
+	d, err := compare(ctx, rep, owner, repo, mc)
+	if err != nil {
+		return err
+	}
+	fixPaths := append(append([]string{}, d.Missing...), d.Mismatched...)
+	if len(fixPaths) == 0 {
+		return nil
+	}
+	t := newTemplates(rep, owner, repo, mc.TemplateRepo)
+	var files []pullrequest.File
+	var added []string
+	for _, w := range required(mc, repo) {
+		if !slices.Contains(fixPaths, w.Path) {
+			continue
+		}
+		tp := templatePath(w)
+		b, found, err := t.get(ctx, tp)
+		if err != nil {
+			return err
+		}
+		if !found {
+			logTemplateNotFound(owner, repo, mc.TemplateRepo, tp)
+			continue
+		}
+		if w.SHA256 != "" && !strings.EqualFold(hash(b), w.SHA256) {
+			log.Warn().
+				Str("org", owner).
+				Str("repo", repo).
+				Str("area", polName).
+				Str("templateRepo", mc.TemplateRepo).
+				Str("template", tp).
+				Msg("Required workflow template does not match the configured sha256, not proposing it.")
+			continue
+		}
+		files = append(files, pullrequest.File{Path: w.Path, Content: b})
+		added = append(added, fmt.Sprintf("- `%v`", w.Path))
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	_, err = pullrequestEnsure(ctx, c, owner, repo, polName, &pullrequest.Request{
+		Branch: fixBranch,
+		Title:  "Add required workflows",
+		Body: "This adds the workflows required by the organization, from its templates in the " +
+			mc.TemplateRepo + " repository:\n\n" + strings.Join(added, "\n"),
+		Files: files,
+	})
+	return err
+}
+
+// GetAction returns the configured action from Required Workflows'
+// configuration stored in the org-level repo, default log. Implementing
+// policydef.Policy.GetAction()
+func (r RequiredWorkflows) GetAction(ctx context.Context, c *github.Client, owner, repo string) string {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	mc := mergeConfig(oc, orc, rc, repo)
+	return mc.Action
+}
+
+func getConfig(ctx context.Context, c *github.Client, owner, repo string) (*OrgConfig, *RepoConfig, *RepoConfig) {
+	oc := &OrgConfig{ // Fill out non-zero defaults
+		Action:       "log",
+		TemplateRepo: defaultTemplateRepo,
+	}
+	if err := configFetchConfig(ctx, c, owner, "", configFile, config.OrgLevel, oc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	orc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.OrgRepoLevel, orc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgRepoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	rc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.RepoLevel, rc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "repoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	return oc, orc, rc
+}
+
+func mergeConfig(oc *OrgConfig, orc, rc *RepoConfig, repo string) *mergedConfig {
+	mc := &mergedConfig{
+		Action:       oc.Action,
+		Workflows:    oc.Workflows,
+		TemplateRepo: oc.TemplateRepo,
+	}
+	if mc.TemplateRepo == "" {
+		mc.TemplateRepo = defaultTemplateRepo
+	}
+	mc = mergeInRepoConfig(mc, orc, repo)
+
+	if !oc.OptConfig.DisableRepoOverride {
+		mc = mergeInRepoConfig(mc, rc, repo)
+	}
+	return mc
+}
+
+func mergeInRepoConfig(mc *mergedConfig, rc *RepoConfig, repo string) *mergedConfig {
+	if rc.Action != nil {
+		mc.Action = *rc.Action
+	}
+	if rc.ExemptWorkflows != nil {
+		mc.ExemptWorkflows = rc.ExemptWorkflows
+	}
+	return mc
+}
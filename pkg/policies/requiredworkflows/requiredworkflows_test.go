@@ -0,0 +1,369 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package requiredworkflows
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/configtest"
+	"github.com/ossf/allstar/pkg/pullrequest"
+)
+
+// files are the files of the repos, keyed by "repo/path".
+var files map[string]string
+
+type mockRepos struct{}
+
+func (m mockRepos) Get(ctx context.Context, owner, repo string) (*github.Repository,
+	*github.Response, error) {
+	return &github.Repository{DefaultBranch: github.String("trunk")}, nil, nil
+}
+
+func (m mockRepos) GetContents(ctx context.Context, owner, repo, p string,
+	opts *github.RepositoryContentGetOptions) (*github.RepositoryContent,
+	[]*github.RepositoryContent, *github.Response, error) {
+	s, ok := files[repo+"/"+p]
+	if !ok {
+		return nil, nil, &github.Response{Response: &http.Response{StatusCode: http.StatusNotFound}},
+			&github.ErrorResponse{}
+	}
+	return &github.RepositoryContent{Content: github.String(s)}, nil, nil, nil
+}
+
+const scorecard = ".github/workflows/scorecard.yml"
+const codeql = ".github/workflows/codeql.yml"
+
+const scorecardTemplate = "on:\n  push:\n    branches: [ $default-branch ]\n"
+const scorecardRendered = "on:\n  push:\n    branches: [ trunk ]\n"
+
+func TestConfigPrecedence(t *testing.T) {
+	configtest.TestPrecedence(t, configtest.Precedence{
+		Org:   OrgConfig{},
+		Repo:  RepoConfig{},
+		Fetch: &configFetchConfig,
+		Merge: func() interface{} {
+			ctx := context.Background()
+			oc, orc, rc := getConfig(ctx, nil, "", configtest.Repo)
+			return mergeConfig(oc, orc, rc, configtest.Repo)
+		},
+		Ignore: []string{"Workflows", "TemplateRepo"},
+	})
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Org       OrgConfig
+		Repo      RepoConfig
+		Files     map[string]string
+		ExpPass   bool
+		ExpDetail details
+	}{
+		{
+			Name: "NoneRequired",
+			Org: OrgConfig{
+				Action: "log",
+			},
+			ExpPass: true,
+		},
+		{
+			Name: "Present",
+			Org: OrgConfig{
+				Workflows: []Workflow{{Path: scorecard}},
+			},
+			Files: map[string]string{
+				"thisrepo/" + scorecard: "anything",
+			},
+			ExpPass: true,
+		},
+		{
+			Name: "Missing",
+			Org: OrgConfig{
+				Workflows: []Workflow{{Path: scorecard}, {Path: codeql}},
+			},
+			Files: map[string]string{
+				"thisrepo/" + scorecard: "anything",
+			},
+			ExpPass: false,
+			ExpDetail: details{
+				Missing: []string{codeql},
+			},
+		},
+		{
+			Name: "NotRequiredInRepo",
+			Org: OrgConfig{
+				Workflows: []Workflow{{Path: codeql, Repos: []string{"sdk-*"}}},
+			},
+			ExpPass: true,
+		},
+		{
+			Name: "RequiredInRepo",
+			Org: OrgConfig{
+				Workflows: []Workflow{{Path: codeql, Repos: []string{"this*"}}},
+			},
+			ExpPass: false,
+			ExpDetail: details{
+				Missing: []string{codeql},
+			},
+		},
+		{
+			Name: "Exempt",
+			Org: OrgConfig{
+				Workflows: []Workflow{{Path: codeql}},
+			},
+			Repo: RepoConfig{
+				ExemptWorkflows: []string{codeql},
+			},
+			ExpPass: true,
+		},
+		{
+			Name: "ExemptDisabled",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					DisableRepoOverride: true,
+				},
+				Workflows: []Workflow{{Path: codeql}},
+			},
+			Repo: RepoConfig{
+				ExemptWorkflows: []string{codeql},
+			},
+			ExpPass: false,
+			ExpDetail: details{
+				Missing: []string{codeql},
+			},
+		},
+		{
+			Name: "HashMatch",
+			Org: OrgConfig{
+				Workflows: []Workflow{{Path: scorecard, SHA256: hash([]byte("abc"))}},
+			},
+			Files: map[string]string{
+				"thisrepo/" + scorecard: "abc",
+			},
+			ExpPass: true,
+		},
+		{
+			Name: "HashMismatch",
+			Org: OrgConfig{
+				Workflows: []Workflow{{Path: scorecard, SHA256: hash([]byte("abc"))}},
+			},
+			Files: map[string]string{
+				"thisrepo/" + scorecard: "abcd",
+			},
+			ExpPass: false,
+			ExpDetail: details{
+				Mismatched: []string{scorecard},
+			},
+		},
+		{
+			Name: "TemplateMatch",
+			Org: OrgConfig{
+				Workflows: []Workflow{{Path: scorecard, MatchTemplate: true}},
+			},
+			Files: map[string]string{
+				"thisrepo/" + scorecard:                    scorecardRendered,
+				".github/workflow-templates/scorecard.yml": scorecardTemplate,
+			},
+			ExpPass: true,
+		},
+		{
+			Name: "TemplateMismatch",
+			Org: OrgConfig{
+				Workflows: []Workflow{{Path: scorecard, MatchTemplate: true}},
+			},
+			Files: map[string]string{
+				"thisrepo/" + scorecard:                    scorecardTemplate,
+				".github/workflow-templates/scorecard.yml": scorecardTemplate,
+			},
+			ExpPass: false,
+			ExpDetail: details{
+				Mismatched: []string{scorecard},
+			},
+		},
+		{
+			Name: "TemplateMissing",
+			Org: OrgConfig{
+				Workflows: []Workflow{{Path: scorecard, MatchTemplate: true}},
+			},
+			Files: map[string]string{
+				"thisrepo/" + scorecard: "anything",
+			},
+			ExpPass: true,
+		},
+		{
+			Name: "TemplateRepoAndPath",
+			Org: OrgConfig{
+				TemplateRepo: "templates",
+				Workflows: []Workflow{{
+					Path:          scorecard,
+					Template:      "ci/scorecard.yml",
+					MatchTemplate: true,
+				}},
+			},
+			Files: map[string]string{
+				"thisrepo/" + scorecard:      "other",
+				"templates/ci/scorecard.yml": "other",
+			},
+			ExpPass: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				switch v := out.(type) {
+				case *OrgConfig:
+					if ol == config.OrgLevel {
+						test.Org.Action = "log"
+						if test.Org.TemplateRepo == "" {
+							test.Org.TemplateRepo = defaultTemplateRepo
+						}
+						*v = test.Org
+					}
+				case *RepoConfig:
+					if ol == config.RepoLevel {
+						*v = test.Repo
+					}
+				}
+				return nil
+			}
+			configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+				c *github.Client, owner, repo string) (bool, error) {
+				return true, nil
+			}
+			files = test.Files
+
+			res, err := check(context.Background(), mockRepos{}, nil, "thisorg", "thisrepo")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Pass != test.ExpPass {
+				t.Errorf("Unexpected pass, want %v got %v: %v", test.ExpPass, res.Pass, res.NotifyText)
+			}
+			if diff := cmp.Diff(test.ExpDetail, res.Details); diff != "" {
+				t.Errorf("Unexpected details (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFix(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Org      OrgConfig
+		Files    map[string]string
+		ExpFiles []pullrequest.File
+	}{
+		{
+			Name: "Passing",
+			Org: OrgConfig{
+				Workflows: []Workflow{{Path: scorecard}},
+			},
+			Files: map[string]string{
+				"thisrepo/" + scorecard:                    "anything",
+				".github/workflow-templates/scorecard.yml": scorecardTemplate,
+			},
+		},
+		{
+			Name: "AddMissing",
+			Org: OrgConfig{
+				Workflows: []Workflow{{Path: scorecard}, {Path: codeql}},
+			},
+			Files: map[string]string{
+				".github/workflow-templates/scorecard.yml": scorecardTemplate,
+				".github/workflow-templates/codeql.yml":    "codeql",
+			},
+			ExpFiles: []pullrequest.File{
+				{Path: scorecard, Content: []byte(scorecardRendered)},
+				{Path: codeql, Content: []byte("codeql")},
+			},
+		},
+		{
+			Name: "ReplaceMismatched",
+			Org: OrgConfig{
+				Workflows: []Workflow{{Path: scorecard, MatchTemplate: true}},
+			},
+			Files: map[string]string{
+				"thisrepo/" + scorecard:                    "old",
+				".github/workflow-templates/scorecard.yml": scorecardTemplate,
+			},
+			ExpFiles: []pullrequest.File{
+				{Path: scorecard, Content: []byte(scorecardRendered)},
+			},
+		},
+		{
+			Name: "TemplateMissing",
+			Org: OrgConfig{
+				Workflows: []Workflow{{Path: scorecard}, {Path: codeql}},
+			},
+			Files: map[string]string{
+				".github/workflow-templates/codeql.yml": "codeql",
+			},
+			ExpFiles: []pullrequest.File{
+				{Path: codeql, Content: []byte("codeql")},
+			},
+		},
+		{
+			Name: "TemplateHashMismatch",
+			Org: OrgConfig{
+				Workflows: []Workflow{{Path: codeql, SHA256: hash([]byte("codeql v2"))}},
+			},
+			Files: map[string]string{
+				".github/workflow-templates/codeql.yml": "codeql",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if v, ok := out.(*OrgConfig); ok {
+					test.Org.Action = "fix"
+					test.Org.TemplateRepo = defaultTemplateRepo
+					*v = test.Org
+				}
+				return nil
+			}
+			configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+				c *github.Client, owner, repo string) (bool, error) {
+				return true, nil
+			}
+			files = test.Files
+			var got []pullrequest.File
+			pullrequestEnsure = func(ctx context.Context, c *github.Client, owner, repo, policy string,
+				pr *pullrequest.Request) (*github.PullRequest, error) {
+				if pr.Branch != fixBranch || policy != polName {
+					t.Errorf("Unexpected pull request: %v", pr)
+				}
+				got = pr.Files
+				return &github.PullRequest{}, nil
+			}
+
+			if err := fix(context.Background(), mockRepos{}, nil, "thisorg", "thisrepo"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.ExpFiles, got); diff != "" {
+				t.Errorf("Unexpected files (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
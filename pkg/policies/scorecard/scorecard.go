@@ -19,6 +19,9 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/google/go-github/v59/github"
 	"github.com/ossf/scorecard/v5/checker"
@@ -57,6 +60,19 @@ type OrgConfig struct {
 	// policy will pass. The default is checker.MaxResultScore:
 	// https://pkg.go.dev/github.com/ossf/scorecard/v5/checker#pkg-constants
 	Threshold int `json:"threshold"`
+
+	// CheckThresholds sets the passing score of individual checks, overriding
+	// Threshold for those checks. The key is the check name, and the value is
+	// a comparison operator (one of ">=", ">", "==", "<=", "<") followed by a
+	// score, for example:
+	//
+	//   checkThresholds:
+	//     Token-Permissions: ">= 7"
+	//     Dangerous-Workflow: "== 10"
+	//
+	// A score without an operator is treated as ">=". Checks listed here are
+	// run even if they are not listed in Checks.
+	CheckThresholds map[string]string `json:"checkThresholds"`
 }
 
 // RepoConfig is the repo-level config for this policy.
@@ -72,17 +88,112 @@ type RepoConfig struct {
 
 	// Threshold overrides the same setting in org-level, only if present.
 	Threshold *int `json:"threshold"`
+
+	// CheckThresholds overrides the same setting in org-level, only if present.
+	CheckThresholds *map[string]string `json:"checkThresholds"`
 }
 
 type mergedConfig struct {
-	Action    string
-	Checks    []string
-	Threshold int
+	Action          string
+	Checks          []string
+	Threshold       int
+	CheckThresholds map[string]string
 }
 
 type details struct {
 	// Findings key is the check name, and value are logs from Scorecards.
 	Findings map[string][]string
+
+	// Scores key is the check name, and value is the score from Scorecards.
+	Scores map[string]int
+}
+
+// threshold is a passing score requirement for a check.
+type threshold struct {
+	op    string
+	score int
+}
+
+var thresholdOps = []string{">=", "<=", "==", ">", "<"}
+
+func parseThreshold(s string) (threshold, error) {
+	s = strings.TrimSpace(s)
+	t := threshold{op: ">="}
+	for _, op := range thresholdOps {
+		if strings.HasPrefix(s, op) {
+			t.op = op
+			s = strings.TrimSpace(strings.TrimPrefix(s, op))
+			break
+		}
+	}
+	score, err := strconv.Atoi(s)
+	if err != nil {
+		return t, fmt.Errorf("invalid score %q: %w", s, err)
+	}
+	t.score = score
+	return t, nil
+}
+
+func (t threshold) pass(score int) bool {
+	switch t.op {
+	case ">":
+		return score > t.score
+	case "==":
+		return score == t.score
+	case "<=":
+		return score <= t.score
+	case "<":
+		return score < t.score
+	default:
+		return score >= t.score
+	}
+}
+
+func (t threshold) String() string {
+	return fmt.Sprintf("%v %v", t.op, t.score)
+}
+
+// checkThreshold returns the threshold for check n, falling back to the
+// overall threshold if none, or an invalid one, is configured.
+func checkThreshold(mc *mergedConfig, n, owner, repo string) threshold {
+	def := threshold{op: ">=", score: mc.Threshold}
+	s, ok := mc.CheckThresholds[n]
+	if !ok {
+		return def
+	}
+	t, err := parseThreshold(s)
+	if err != nil {
+		log.Warn().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", polName).
+			Str("check", n).
+			Err(err).
+			Msg("Invalid scorecard check threshold, using overall threshold.")
+		return def
+	}
+	return t
+}
+
+// checkNames returns the configured checks, followed by any checks only
+// listed in CheckThresholds, in sorted order.
+func checkNames(mc *mergedConfig) []string {
+	names := append([]string{}, mc.Checks...)
+	var extra []string
+	for n := range mc.CheckThresholds {
+		found := false
+		for _, c := range mc.Checks {
+			if c == n {
+				found = true
+				break
+			}
+		}
+		if !found {
+			extra = append(extra, n)
+		}
+	}
+	sort.Strings(extra)
+	return append(names, extra...)
 }
 
 var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
@@ -149,8 +260,9 @@ func (b Scorecard) Check(ctx context.Context, c *github.Client, owner,
 	var notify string
 	pass := true
 	f := make(map[string][]string)
+	scores := make(map[string]int)
 
-	for _, n := range mc.Checks {
+	for _, n := range checkNames(mc) {
 
 		_, ok := checksAllChecks[n]
 		if !ok {
@@ -210,17 +322,20 @@ func (b Scorecard) Check(ctx context.Context, c *github.Client, owner,
 		if len(logs) > 0 {
 			f[n] = logs
 		}
-		if res.Score < mc.Threshold && res.Score != checker.InconclusiveResultScore {
+		scores[n] = res.Score
+		t := checkThreshold(mc, n, owner, repo)
+		if !t.pass(res.Score) && res.Score != checker.InconclusiveResultScore {
 			pass = false
 			if notify == "" {
 				notify = `Project is out of compliance with OpenSSF Scorecard policy.
 
 **Rule Description**
 This is a generic passthrough policy that runs the configured checks from OpenSSF Scorecard. Please see the [OpenSSF Scorecard documentation](https://github.com/ossf/scorecard/blob/main/docs/checks.md) for more information on each check.
-The score was %v, and the passing threshold is %v.
+
 `
-				notify = fmt.Sprintf(notify, res.Score, mc.Threshold)
 			}
+			notify += fmt.Sprintf("The %v score was %v, and the passing threshold is %v.\n\n",
+				n, res.Score, t)
 			if len(logs) > 10 {
 				notify += fmt.Sprintf(
 					"**First 10 Results from policy: %v : %v**\n\n%v"+
@@ -239,6 +354,7 @@ The score was %v, and the passing threshold is %v.
 		NotifyText: notify,
 		Details: details{
 			Findings: f,
+			Scores:   scores,
 		},
 	}, nil
 }
@@ -328,9 +444,10 @@ func getConfig(ctx context.Context, c *github.Client, owner, repo string) (*OrgC
 
 func mergeConfig(oc *OrgConfig, orc, rc *RepoConfig, repo string) *mergedConfig {
 	mc := &mergedConfig{
-		Action:    oc.Action,
-		Checks:    oc.Checks,
-		Threshold: oc.Threshold,
+		Action:          oc.Action,
+		Checks:          oc.Checks,
+		Threshold:       oc.Threshold,
+		CheckThresholds: oc.CheckThresholds,
 	}
 	mc = mergeInRepoConfig(mc, orc, repo)
 
@@ -350,5 +467,8 @@ func mergeInRepoConfig(mc *mergedConfig, rc *RepoConfig, repo string) *mergedCon
 	if rc.Threshold != nil {
 		mc.Threshold = *rc.Threshold
 	}
+	if rc.CheckThresholds != nil {
+		mc.CheckThresholds = *rc.CheckThresholds
+	}
 	return mc
 }
@@ -22,6 +22,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-github/v59/github"
 	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/configtest"
 	"github.com/ossf/allstar/pkg/scorecard"
 	"github.com/ossf/scorecard/v5/checker"
 	"github.com/ossf/scorecard/v5/clients"
@@ -142,7 +143,10 @@ func TestCheck(t *testing.T) {
 		OrgRepo RepoConfig
 		Repo    RepoConfig
 		Result  checker.CheckResult
-		ExpPass bool
+		// Results, if set, are returned for each check run in order.
+		Results   []checker.CheckResult
+		ExpPass   bool
+		ExpScores map[string]int
 	}{
 		{
 			Name: "Pass",
@@ -168,7 +172,77 @@ func TestCheck(t *testing.T) {
 			Result: checker.CheckResult{
 				Score: 7,
 			},
+			ExpPass:   false,
+			ExpScores: map[string]int{"test": 7},
+		},
+		{
+			Name: "CheckThresholdPass",
+			Org: OrgConfig{
+				Checks:    []string{"test"},
+				Threshold: 10,
+				CheckThresholds: map[string]string{
+					"test": ">= 7",
+				},
+			},
+			Result: checker.CheckResult{
+				Score: 7,
+			},
+			ExpPass:   true,
+			ExpScores: map[string]int{"test": 7},
+		},
+		{
+			Name: "CheckThresholdOnly",
+			Org: OrgConfig{
+				Threshold: 10,
+				CheckThresholds: map[string]string{
+					"test":  ">= 7",
+					"other": "== 10",
+				},
+			},
+			Results: []checker.CheckResult{
+				{Score: 9},
+				{Score: 8},
+			},
 			ExpPass: false,
+			ExpScores: map[string]int{
+				"other": 9,
+				"test":  8,
+			},
+		},
+		{
+			Name: "RepoCheckThresholds",
+			Org: OrgConfig{
+				Checks:    []string{"test"},
+				Threshold: 10,
+				CheckThresholds: map[string]string{
+					"test": "10",
+				},
+			},
+			Repo: RepoConfig{
+				CheckThresholds: &map[string]string{
+					"test": "5",
+				},
+			},
+			Result: checker.CheckResult{
+				Score: 5,
+			},
+			ExpPass:   true,
+			ExpScores: map[string]int{"test": 5},
+		},
+		{
+			Name: "InvalidCheckThreshold",
+			Org: OrgConfig{
+				Checks:    []string{"test"},
+				Threshold: 8,
+				CheckThresholds: map[string]string{
+					"test": "high",
+				},
+			},
+			Result: checker.CheckResult{
+				Score: 7,
+			},
+			ExpPass:   false,
+			ExpScores: map[string]int{"test": 7},
 		},
 	}
 	for _, test := range tests {
@@ -199,9 +273,16 @@ func TestCheck(t *testing.T) {
 			}
 			checksAllChecks = checker.CheckNameToFnMap{}
 			checksAllChecks["test"] = checker.Check{}
+			checksAllChecks["other"] = checker.Check{}
+			runs := 0
 			scRun = func(context.Context, clients.Repo, ...sc.Option) (sc.Result, error) {
+				res := test.Result
+				if test.Results != nil {
+					res = test.Results[runs]
+				}
+				runs++
 				return sc.Result{
-					Checks: []checker.CheckResult{test.Result},
+					Checks: []checker.CheckResult{res},
 				}, nil
 			}
 			s := NewScorecard()
@@ -212,6 +293,58 @@ func TestCheck(t *testing.T) {
 			if res.Pass != test.ExpPass {
 				t.Errorf("Expected pass: %v, got: %v", test.ExpPass, res.Pass)
 			}
+			if test.ExpScores != nil {
+				if diff := cmp.Diff(test.ExpScores, res.Details.(details).Scores); diff != "" {
+					t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+				}
+			}
 		})
 	}
 }
+
+func TestParseThreshold(t *testing.T) {
+	tests := []struct {
+		In       string
+		Score    int
+		ExpPass  bool
+		ExpError bool
+	}{
+		{In: "7", Score: 7, ExpPass: true},
+		{In: ">= 7", Score: 6, ExpPass: false},
+		{In: ">7", Score: 7, ExpPass: false},
+		{In: "== 10", Score: 10, ExpPass: true},
+		{In: "== 10", Score: 9, ExpPass: false},
+		{In: "<= 3", Score: 3, ExpPass: true},
+		{In: "< 3", Score: 3, ExpPass: false},
+		{In: "> seven", ExpError: true},
+	}
+	for _, test := range tests {
+		th, err := parseThreshold(test.In)
+		if test.ExpError {
+			if err == nil {
+				t.Errorf("Expected error for %q", test.In)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %v", test.In, err)
+			continue
+		}
+		if got := th.pass(test.Score); got != test.ExpPass {
+			t.Errorf("Unexpected result for %q with score %v: %v", test.In, test.Score, got)
+		}
+	}
+}
+
+func TestGeneratedPrecedence(t *testing.T) {
+	configtest.TestPrecedence(t, configtest.Precedence{
+		Org:   OrgConfig{},
+		Repo:  RepoConfig{},
+		Fetch: &configFetchConfig,
+		Merge: func() interface{} {
+			ctx := context.Background()
+			oc, orc, rc := getConfig(ctx, nil, "", configtest.Repo)
+			return mergeConfig(oc, orc, rc, configtest.Repo)
+		},
+	})
+}
@@ -0,0 +1,358 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package secretscanning implements the Secret Scanning security policy. It
+// checks that secret scanning and push protection are enabled in the
+// repository "Code security" settings.
+package secretscanning
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/policydef"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+const configFile = "secret_scanning.yaml"
+const polName = "Secret Scanning"
+
+const statusEnabled = "enabled"
+
+// Names of the security_and_analysis settings, as reported in details.
+const (
+	settingSecretScanning = "secret_scanning"
+	settingPushProtection = "secret_scanning_push_protection"
+	settingValidityChecks = "secret_scanning_validity_checks"
+)
+
+const notifyText = `This policy requires that secret scanning, and push protection to block commits containing secrets, are enabled for this repository.
+
+To fix this, from the main page of the repository go to Settings -> Code security, and enable "Secret Protection" / "Secret scanning" and "Push protection".
+(For more information, see https://docs.github.com/en/code-security/secret-scanning/enabling-secret-scanning-features/enabling-secret-scanning-for-your-repository)`
+
+// OrgConfig is the org-level config definition for Secret Scanning.
+type OrgConfig struct {
+	// OptConfig is the standard org-level opt in/out config, RepoOverride
+	// applies to all config.
+	OptConfig config.OrgOptConfig `json:"optConfig"`
+
+	// Action defines which action to take, default log, other: issue...
+	Action string `json:"action"`
+
+	// RequireSecretScanning : set to true to require secret scanning, default
+	// true.
+	RequireSecretScanning bool `json:"requireSecretScanning"`
+
+	// RequirePushProtection : set to true to require secret scanning push
+	// protection, default true.
+	RequirePushProtection bool `json:"requirePushProtection"`
+
+	// RequireValidityChecks : set to true to require validity checks of
+	// detected secrets, default false.
+	RequireValidityChecks bool `json:"requireValidityChecks"`
+}
+
+// RepoConfig is the repo-level config for Secret Scanning.
+type RepoConfig struct {
+	// OptConfig is the standard repo-level opt in/out config.
+	OptConfig config.RepoOptConfig `json:"optConfig"`
+
+	// Action overrides the same setting in org-level, only if present.
+	Action *string `json:"action"`
+
+	// RequireSecretScanning overrides the same setting in org-level, only if
+	// present.
+	RequireSecretScanning *bool `json:"requireSecretScanning"`
+
+	// RequirePushProtection overrides the same setting in org-level, only if
+	// present.
+	RequirePushProtection *bool `json:"requirePushProtection"`
+
+	// RequireValidityChecks overrides the same setting in org-level, only if
+	// present.
+	RequireValidityChecks *bool `json:"requireValidityChecks"`
+}
+
+type mergedConfig struct {
+	Action                string
+	RequireSecretScanning bool
+	RequirePushProtection bool
+	RequireValidityChecks bool
+}
+
+type details struct {
+	SecretScanning string
+	PushProtection string
+	ValidityChecks string
+	Missing        []string
+}
+
+var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+
+var configIsEnabled func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig, c *github.Client, owner, repo string) (bool, error)
+
+func init() {
+	configFetchConfig = config.FetchConfig
+	configIsEnabled = config.IsEnabled
+}
+
+type repositories interface {
+	Get(context.Context, string, string) (*github.Repository,
+		*github.Response, error)
+	Edit(context.Context, string, string, *github.Repository) (
+		*github.Repository, *github.Response, error)
+}
+
+// SecretScanning is the Secret Scanning policy object, implements
+// policydef.Policy.
+type SecretScanning bool
+
+// NewSecretScanning returns a new Secret Scanning policy.
+func NewSecretScanning() policydef.Policy {
+	var s SecretScanning
+	return s
+}
+
+// Name returns the name of this policy, implementing policydef.Policy.Name()
+func (s SecretScanning) Name() string {
+	return polName
+}
+
+// Check whether this policy is enabled or not
+func (s SecretScanning) IsEnabled(ctx context.Context, c *github.Client, owner, repo string) (bool, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	return configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+}
+
+// Check performs the policy check for Secret Scanning based on the
+// configuration stored in the org/repo, implementing policydef.Policy.Check()
+func (s SecretScanning) Check(ctx context.Context, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	return check(ctx, c.Repositories, c, owner, repo)
+}
+
+func check(ctx context.Context, rep repositories, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Bool("enabled", enabled).
+		Msg("Check repo enabled")
+
+	mc := mergeConfig(oc, orc, rc, repo)
+
+	r, _, err := rep.Get(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	d := getDetails(r.GetSecurityAndAnalysis(), mc)
+
+	if len(d.Missing) == 0 {
+		return &policydef.Result{
+			Enabled:    enabled,
+			Pass:       true,
+			NotifyText: "",
+			Details:    d,
+		}, nil
+	}
+	text := "The following secret scanning settings are required, but not enabled:\n"
+	for _, m := range d.Missing {
+		text = text + fmt.Sprintf("- %v\n", m)
+	}
+	return &policydef.Result{
+		Enabled:    enabled,
+		Pass:       false,
+		NotifyText: text + "\n" + notifyText,
+		Details:    d,
+	}, nil
+}
+
+func getDetails(sa *github.SecurityAndAnalysis, mc *mergedConfig) details {
+	var d details
+	d.SecretScanning = sa.GetSecretScanning().GetStatus()
+	d.PushProtection = sa.GetSecretScanningPushProtection().GetStatus()
+	d.ValidityChecks = sa.GetSecretScanningValidityChecks().GetStatus()
+	if mc.RequireSecretScanning && d.SecretScanning != statusEnabled {
+		d.Missing = append(d.Missing, settingSecretScanning)
+	}
+	if mc.RequirePushProtection && d.PushProtection != statusEnabled {
+		d.Missing = append(d.Missing, settingPushProtection)
+	}
+	if mc.RequireValidityChecks && d.ValidityChecks != statusEnabled {
+		d.Missing = append(d.Missing, settingValidityChecks)
+	}
+	return d
+}
+
+// Fix implementing policydef.Policy.Fix(). Enables the missing secret scanning
+// settings on the repo.
+func (s SecretScanning) Fix(ctx context.Context, c *github.Client, owner, repo string) error {
+	return fix(ctx, c.Repositories, c, owner, repo)
+}
+
+func fix(ctx context.Context, rep repositories, c *github.Client, owner,
+	repo string) error {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return nil
+	}
+	mc := mergeConfig(oc, orc, rc, repo)
+
+	r, _, err := rep.Get(ctx, owner, repo)
+	if err != nil {
+		return err
+	}
+	d := getDetails(r.GetSecurityAndAnalysis(), mc)
+	if len(d.Missing) == 0 {
+		return nil
+	}
+	sa := &github.SecurityAndAnalysis{}
+	for _, m := range d.Missing {
+		switch m {
+		case settingSecretScanning:
+			sa.SecretScanning = &github.SecretScanning{
+				Status: github.String(statusEnabled),
+			}
+		case settingPushProtection:
+			sa.SecretScanningPushProtection = &github.SecretScanningPushProtection{
+				Status: github.String(statusEnabled),
+			}
+		case settingValidityChecks:
+			sa.SecretScanningValidityChecks = &github.SecretScanningValidityChecks{
+				Status: github.String(statusEnabled),
+			}
+		}
+	}
+	_, rsp, err := rep.Edit(ctx, owner, repo, &github.Repository{
+		SecurityAndAnalysis: sa,
+	})
+	if err != nil {
+		if rsp != nil && (rsp.StatusCode == http.StatusForbidden || rsp.StatusCode == http.StatusUnprocessableEntity) {
+			// Forbidden without administration write permission, unprocessable
+			// when the repo does not have secret scanning available.
+			log.Warn().
+				Str("org", owner).
+				Str("repo", repo).
+				Str("area", polName).
+				Strs("missing", d.Missing).
+				Err(err).
+				Msg("Action set to fix, but secret scanning settings could not be updated.")
+			return nil
+		}
+		return err
+	}
+	log.Info().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Strs("enabled", d.Missing).
+		Msg("Enabled secret scanning settings with Fix action.")
+	return nil
+}
+
+// GetAction returns the configured action from Secret Scanning's
+// configuration stored in the org-level repo, default log. Implementing
+// policydef.Policy.GetAction()
+func (s SecretScanning) GetAction(ctx context.Context, c *github.Client, owner, repo string) string {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	mc := mergeConfig(oc, orc, rc, repo)
+	return mc.Action
+}
+
+func getConfig(ctx context.Context, c *github.Client, owner, repo string) (*OrgConfig, *RepoConfig, *RepoConfig) {
+	oc := &OrgConfig{ // Fill out non-zero defaults
+		Action:                "log",
+		RequireSecretScanning: true,
+		RequirePushProtection: true,
+	}
+	if err := configFetchConfig(ctx, c, owner, "", configFile, config.OrgLevel, oc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	orc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.OrgRepoLevel, orc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgRepoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	rc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.RepoLevel, rc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "repoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	return oc, orc, rc
+}
+
+func mergeConfig(oc *OrgConfig, orc, rc *RepoConfig, repo string) *mergedConfig {
+	mc := &mergedConfig{
+		Action:                oc.Action,
+		RequireSecretScanning: oc.RequireSecretScanning,
+		RequirePushProtection: oc.RequirePushProtection,
+		RequireValidityChecks: oc.RequireValidityChecks,
+	}
+	mc = mergeInRepoConfig(mc, orc, repo)
+
+	if !oc.OptConfig.DisableRepoOverride {
+		mc = mergeInRepoConfig(mc, rc, repo)
+	}
+	return mc
+}
+
+func mergeInRepoConfig(mc *mergedConfig, rc *RepoConfig, repo string) *mergedConfig {
+	if rc.Action != nil {
+		mc.Action = *rc.Action
+	}
+	if rc.RequireSecretScanning != nil {
+		mc.RequireSecretScanning = *rc.RequireSecretScanning
+	}
+	if rc.RequirePushProtection != nil {
+		mc.RequirePushProtection = *rc.RequirePushProtection
+	}
+	if rc.RequireValidityChecks != nil {
+		mc.RequireValidityChecks = *rc.RequireValidityChecks
+	}
+	return mc
+}
@@ -0,0 +1,379 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secretscanning
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/configtest"
+)
+
+var get func(context.Context, string, string) (*github.Repository,
+	*github.Response, error)
+var edit func(context.Context, string, string, *github.Repository) (
+	*github.Repository, *github.Response, error)
+
+type mockRepos struct{}
+
+func (m mockRepos) Get(ctx context.Context, o, r string) (*github.Repository,
+	*github.Response, error) {
+	return get(ctx, o, r)
+}
+
+func (m mockRepos) Edit(ctx context.Context, o, r string,
+	repo *github.Repository) (*github.Repository, *github.Response, error) {
+	return edit(ctx, o, r, repo)
+}
+
+func securityAndAnalysis(ss, pp, vc string) *github.SecurityAndAnalysis {
+	sa := &github.SecurityAndAnalysis{}
+	if ss != "" {
+		sa.SecretScanning = &github.SecretScanning{Status: github.String(ss)}
+	}
+	if pp != "" {
+		sa.SecretScanningPushProtection = &github.SecretScanningPushProtection{Status: github.String(pp)}
+	}
+	if vc != "" {
+		sa.SecretScanningValidityChecks = &github.SecretScanningValidityChecks{Status: github.String(vc)}
+	}
+	return sa
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Org       OrgConfig
+		OrgRepo   RepoConfig
+		Repo      RepoConfig
+		ExpAction string
+		Exp       mergedConfig
+	}{
+		{
+			Name: "OrgOnly",
+			Org: OrgConfig{
+				Action:                "issue",
+				RequireSecretScanning: true,
+			},
+			OrgRepo:   RepoConfig{},
+			Repo:      RepoConfig{},
+			ExpAction: "issue",
+			Exp: mergedConfig{
+				Action:                "issue",
+				RequireSecretScanning: true,
+			},
+		},
+		{
+			Name: "OrgRepoOverOrg",
+			Org: OrgConfig{
+				Action:                "issue",
+				RequireSecretScanning: true,
+			},
+			OrgRepo: RepoConfig{
+				Action:                github.String("log"),
+				RequirePushProtection: github.Bool(true),
+			},
+			Repo:      RepoConfig{},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action:                "log",
+				RequireSecretScanning: true,
+				RequirePushProtection: true,
+			},
+		},
+		{
+			Name: "RepoOverAllOrg",
+			Org: OrgConfig{
+				Action:                "issue",
+				RequireSecretScanning: true,
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action:                github.String("email"),
+				RequireSecretScanning: github.Bool(false),
+				RequireValidityChecks: github.Bool(true),
+			},
+			ExpAction: "email",
+			Exp: mergedConfig{
+				Action:                "email",
+				RequireValidityChecks: true,
+			},
+		},
+		{
+			Name: "RepoDisallowed",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					DisableRepoOverride: true,
+				},
+				Action:                "issue",
+				RequireSecretScanning: true,
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action:                github.String("email"),
+				RequireSecretScanning: github.Bool(false),
+			},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action:                "log",
+				RequireSecretScanning: true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				switch ol {
+				case config.RepoLevel:
+					rc := out.(*RepoConfig)
+					*rc = test.Repo
+				case config.OrgRepoLevel:
+					orc := out.(*RepoConfig)
+					*orc = test.OrgRepo
+				case config.OrgLevel:
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+
+			s := SecretScanning(true)
+			ctx := context.Background()
+
+			action := s.GetAction(ctx, nil, "", "thisrepo")
+			if action != test.ExpAction {
+				t.Errorf("Unexpected results. want %s, got %s", test.ExpAction, action)
+			}
+
+			oc, orc, rc := getConfig(ctx, nil, "", "thisrepo")
+			mc := mergeConfig(oc, orc, rc, "thisrepo")
+			if diff := cmp.Diff(&test.Exp, mc); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Org        OrgConfig
+		SA         *github.SecurityAndAnalysis
+		ExpPass    bool
+		ExpDetails details
+	}{
+		{
+			Name: "AllEnabled",
+			Org: OrgConfig{
+				RequireSecretScanning: true,
+				RequirePushProtection: true,
+			},
+			SA:      securityAndAnalysis("enabled", "enabled", ""),
+			ExpPass: true,
+			ExpDetails: details{
+				SecretScanning: "enabled",
+				PushProtection: "enabled",
+			},
+		},
+		{
+			Name: "PushProtectionDisabled",
+			Org: OrgConfig{
+				RequireSecretScanning: true,
+				RequirePushProtection: true,
+			},
+			SA:      securityAndAnalysis("enabled", "disabled", ""),
+			ExpPass: false,
+			ExpDetails: details{
+				SecretScanning: "enabled",
+				PushProtection: "disabled",
+				Missing:        []string{"secret_scanning_push_protection"},
+			},
+		},
+		{
+			Name: "PushProtectionNotRequired",
+			Org: OrgConfig{
+				RequireSecretScanning: true,
+			},
+			SA:      securityAndAnalysis("enabled", "disabled", ""),
+			ExpPass: true,
+			ExpDetails: details{
+				SecretScanning: "enabled",
+				PushProtection: "disabled",
+			},
+		},
+		{
+			Name: "NotAvailable",
+			Org: OrgConfig{
+				RequireSecretScanning: true,
+				RequirePushProtection: true,
+				RequireValidityChecks: true,
+			},
+			SA:      nil,
+			ExpPass: false,
+			ExpDetails: details{
+				Missing: []string{
+					"secret_scanning",
+					"secret_scanning_push_protection",
+					"secret_scanning_validity_checks",
+				},
+			},
+		},
+	}
+
+	configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+		c *github.Client, owner, repo string) (bool, error) {
+		return true, nil
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.OrgLevel {
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+			get = func(context.Context, string, string) (*github.Repository,
+				*github.Response, error) {
+				return &github.Repository{SecurityAndAnalysis: test.SA}, nil, nil
+			}
+
+			res, err := check(context.Background(), mockRepos{}, nil, "", "thisrepo")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Pass != test.ExpPass {
+				t.Errorf("Unexpected pass: %v, notify text: %v", res.Pass, res.NotifyText)
+			}
+			if diff := cmp.Diff(test.ExpDetails, res.Details); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFix(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Org      OrgConfig
+		SA       *github.SecurityAndAnalysis
+		EditCode int
+		Exp      *github.SecurityAndAnalysis
+	}{
+		{
+			Name: "NoChange",
+			Org: OrgConfig{
+				RequireSecretScanning: true,
+				RequirePushProtection: true,
+			},
+			SA:  securityAndAnalysis("enabled", "enabled", ""),
+			Exp: nil,
+		},
+		{
+			Name: "EnablePushProtection",
+			Org: OrgConfig{
+				RequireSecretScanning: true,
+				RequirePushProtection: true,
+			},
+			SA:  securityAndAnalysis("enabled", "disabled", ""),
+			Exp: securityAndAnalysis("", "enabled", ""),
+		},
+		{
+			Name: "EnableAll",
+			Org: OrgConfig{
+				RequireSecretScanning: true,
+				RequirePushProtection: true,
+				RequireValidityChecks: true,
+			},
+			SA:  securityAndAnalysis("disabled", "disabled", "disabled"),
+			Exp: securityAndAnalysis("enabled", "enabled", "enabled"),
+		},
+		{
+			Name: "NotAvailable",
+			Org: OrgConfig{
+				RequireSecretScanning: true,
+			},
+			SA:       nil,
+			EditCode: http.StatusUnprocessableEntity,
+			Exp:      securityAndAnalysis("enabled", "", ""),
+		},
+	}
+
+	configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+		c *github.Client, owner, repo string) (bool, error) {
+		return true, nil
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.OrgLevel {
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+			get = func(context.Context, string, string) (*github.Repository,
+				*github.Response, error) {
+				return &github.Repository{SecurityAndAnalysis: test.SA}, nil, nil
+			}
+			var got *github.SecurityAndAnalysis
+			edit = func(ctx context.Context, o, r string,
+				repo *github.Repository) (*github.Repository, *github.Response, error) {
+				got = repo.SecurityAndAnalysis
+				if test.EditCode != 0 {
+					return nil, &github.Response{
+						Response: &http.Response{StatusCode: test.EditCode},
+					}, errors.New("error")
+				}
+				return repo, nil, nil
+			}
+
+			if err := fix(context.Background(), mockRepos{}, nil, "", "thisrepo"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.Exp, got); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGeneratedPrecedence(t *testing.T) {
+	configtest.TestPrecedence(t, configtest.Precedence{
+		Org:   OrgConfig{},
+		Repo:  RepoConfig{},
+		Fetch: &configFetchConfig,
+		Merge: func() interface{} {
+			ctx := context.Background()
+			oc, orc, rc := getConfig(ctx, nil, "", configtest.Repo)
+			return mergeConfig(oc, orc, rc, configtest.Repo)
+		},
+	})
+}
@@ -18,10 +18,13 @@ package security
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"strings"
 
 	"github.com/ossf/allstar/pkg/config"
 	"github.com/ossf/allstar/pkg/config/operator"
 	"github.com/ossf/allstar/pkg/policydef"
+	"github.com/ossf/allstar/pkg/pullrequest"
 
 	"github.com/google/go-github/v59/github"
 	"github.com/rs/zerolog/log"
@@ -31,6 +34,36 @@ import (
 const configFile = "security.yaml"
 const polName = "SECURITY.md"
 
+// orgPolicyRepo is the repository an organization-level SECURITY.md is
+// inherited from.
+const orgPolicyRepo = ".github"
+
+// fixBranch is the branch the Fix action proposes a SECURITY.md from.
+const fixBranch = "allstar/security-policy"
+
+// policyPaths are the locations GitHub looks for a security policy, in order.
+var policyPaths = []string{"SECURITY.md", ".github/SECURITY.md", "docs/SECURITY.md"}
+
+// defaultTemplate is the SECURITY.md proposed by the Fix action, rendered with
+// the Owner and Repo.
+const defaultTemplate = `# Security Policy
+
+## Reporting a Vulnerability
+
+Please do not report security vulnerabilities through public issues.
+
+Contact the maintainers privately by [reporting a
+vulnerability](https://github.com/{{.Owner}}/{{.Repo}}/security/advisories/new)
+through GitHub. Include a description of the issue, the steps to reproduce it,
+and the affected versions.
+
+## Disclosure Policy
+
+The maintainers will acknowledge the report, investigate, and prepare a fix.
+The vulnerability will be disclosed in a security advisory once a fixed release
+is available.
+`
+
 const notifyText = `A SECURITY.md file can give users information about what constitutes a vulnerability and how to report one securely so that information about a bug is not publicly visible. Examples of secure reporting methods include using an issue tracker with private issue support, or encrypted email with a published key.
 
 To fix this, add a SECURITY.md file that explains how to handle vulnerabilities found in your repository. Go to https://github.com/%v/%v/security/policy to enable.
@@ -46,7 +79,24 @@ type OrgConfig struct {
 	// Action defines which action to take, default log, other: issue...
 	Action string `json:"action"`
 
-	//TODO add default contents for "fix" action
+	// RequireRepoPolicy : set to true to require a SECURITY.md in the
+	// repository itself. By default a SECURITY.md inherited from the
+	// organization's .github repository is accepted.
+	RequireRepoPolicy bool `json:"requireRepoPolicy"`
+
+	// MinLength is the minimum length, in characters, of the SECURITY.md,
+	// default 0, not checked.
+	MinLength int `json:"minLength"`
+
+	// RequiredSections is a list of words or phrases that must each appear in
+	// a heading of the SECURITY.md, ignoring case, ex: ["contact",
+	// "disclosure"]. Default none.
+	RequiredSections []string `json:"requiredSections"`
+
+	// FixTemplate is the SECURITY.md content proposed in a pull request by the
+	// fix action. It is a Go text/template with the .Owner and .Repo fields,
+	// default is a short policy with reporting and disclosure sections.
+	FixTemplate string `json:"fixTemplate"`
 }
 
 // RepoConfig is the repo-level config for Branch Protection
@@ -56,30 +106,59 @@ type RepoConfig struct {
 
 	// Action overrides the same setting in org-level, only if present.
 	Action *string `json:"action"`
+
+	// RequireRepoPolicy overrides the same setting in org-level, only if
+	// present.
+	RequireRepoPolicy *bool `json:"requireRepoPolicy"`
+
+	// MinLength overrides the same setting in org-level, only if present.
+	MinLength *int `json:"minLength"`
+
+	// RequiredSections overrides the same setting in org-level, only if
+	// present.
+	RequiredSections []string `json:"requiredSections"`
 }
 
 type mergedConfig struct {
-	Action string
+	Action            string
+	RequireRepoPolicy bool
+	MinLength         int
+	RequiredSections  []string
+	FixTemplate       string
 }
 
 type details struct {
-	Enabled bool
-	URL     string
+	Enabled         bool
+	URL             string
+	OrgPolicy       bool
+	Path            string
+	Length          int
+	MissingSections []string
 }
 
 var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
 
 var configIsEnabled func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig, c *github.Client, owner, repo string) (bool, error)
 
+var pullrequestEnsure func(context.Context, *github.Client, string, string, string, *pullrequest.Request) (*github.PullRequest, error)
+
 func init() {
 	configFetchConfig = config.FetchConfig
 	configIsEnabled = config.IsEnabled
+	pullrequestEnsure = pullrequest.Ensure
 }
 
 type v4client interface {
 	Query(context.Context, interface{}, map[string]interface{}) error
 }
 
+// repositories is the subset of the GitHub Repositories API used.
+type repositories interface {
+	GetContents(context.Context, string, string, string,
+		*github.RepositoryContentGetOptions) (*github.RepositoryContent,
+		[]*github.RepositoryContent, *github.Response, error)
+}
+
 // Security is the SECURITY.md policy object, implements policydef.Policy.
 type Security bool
 
@@ -105,7 +184,7 @@ func (s Security) Check(ctx context.Context, c *github.Client, owner,
 	} else {
 		v4c = githubv4.NewEnterpriseClient(operator.GitHubEnterpriseUrl+"/api/graphql", c.Client())
 	}
-	return check(ctx, c, v4c, owner, repo)
+	return check(ctx, c, reposClient{c}, v4c, owner, repo)
 }
 
 // Check whether this policy is enabled or not
@@ -114,7 +193,7 @@ func (s Security) IsEnabled(ctx context.Context, c *github.Client, owner, repo s
 	return configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
 }
 
-func check(ctx context.Context, c *github.Client, v4c v4client, owner,
+func check(ctx context.Context, c *github.Client, rep repositories, v4c v4client, owner,
 	repo string) (*policydef.Result, error) {
 	oc, orc, rc := getConfig(ctx, c, owner, repo)
 	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
@@ -141,37 +220,169 @@ func check(ctx context.Context, c *github.Client, v4c v4client, owner,
 	if err := v4c.Query(ctx, &q, variables); err != nil {
 		return nil, err
 	}
+	d := details{
+		Enabled: q.Repository.IsSecurityPolicyEnabled,
+		URL:     q.Repository.SecurityPolicyUrl,
+	}
 	if !q.Repository.IsSecurityPolicyEnabled {
 		return &policydef.Result{
 			Enabled:    enabled,
 			Pass:       false,
 			NotifyText: "Security policy not enabled.\n" + fmt.Sprintf(notifyText, owner, repo),
-			Details: details{
-				Enabled: false,
-				URL:     q.Repository.SecurityPolicyUrl,
-			},
+			Details:    d,
+		}, nil
+	}
+
+	mc := mergeConfig(oc, orc, rc, repo)
+	if !mc.RequireRepoPolicy && mc.MinLength == 0 && len(mc.RequiredSections) == 0 {
+		// Nothing else to check, GitHub reports an inherited organization
+		// policy as enabled.
+		return &policydef.Result{
+			Enabled:    enabled,
+			Pass:       true,
+			NotifyText: "",
+			Details:    d,
+		}, nil
+	}
+
+	content, path, err := getPolicy(ctx, rep, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		d.OrgPolicy = true
+		if mc.RequireRepoPolicy {
+			return &policydef.Result{
+				Enabled: enabled,
+				Pass:    false,
+				NotifyText: "Security policy is inherited from the organization, but a repository SECURITY.md is required.\n" +
+					fmt.Sprintf(notifyText, owner, repo),
+				Details: d,
+			}, nil
+		}
+		content, path, err = getPolicy(ctx, rep, owner, orgPolicyRepo)
+		if err != nil {
+			return nil, err
+		}
+	}
+	d.Path = path
+	d.Length = len(strings.TrimSpace(content))
+	d.MissingSections = missingSections(content, mc.RequiredSections)
+
+	text := ""
+	if d.Length < mc.MinLength {
+		text = text + fmt.Sprintf("Security policy is %v characters, at least %v are required.\n", d.Length, mc.MinLength)
+	}
+	for _, m := range d.MissingSections {
+		text = text + fmt.Sprintf("Security policy is missing a %q section.\n", m)
+	}
+	if text != "" {
+		return &policydef.Result{
+			Enabled:    enabled,
+			Pass:       false,
+			NotifyText: text + fmt.Sprintf(notifyText, owner, repo),
+			Details:    d,
 		}, nil
 	}
 	return &policydef.Result{
 		Enabled:    enabled,
 		Pass:       true,
 		NotifyText: "",
-		Details: details{
-			Enabled: true,
-			URL:     q.Repository.SecurityPolicyUrl,
-		},
+		Details:    d,
 	}, nil
 }
 
-// Fix implementing policydef.Policy.Fix(). Currently not supported. Plan
-// to support this TODO.
+// getPolicy returns the content and path of the SECURITY.md in the repo, or
+// an empty path if there is none.
+func getPolicy(ctx context.Context, rep repositories, owner, repo string) (string, string, error) {
+	for _, p := range policyPaths {
+		fc, _, rsp, err := rep.GetContents(ctx, owner, repo, p, nil)
+		if err != nil {
+			if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+				continue
+			}
+			return "", "", err
+		}
+		if fc == nil {
+			// A directory.
+			continue
+		}
+		content, err := fc.GetContent()
+		if err != nil {
+			return "", "", err
+		}
+		return content, p, nil
+	}
+	return "", "", nil
+}
+
+// missingSections returns the required sections not found in a Markdown
+// heading of the content.
+func missingSections(content string, required []string) []string {
+	var headings []string
+	for _, l := range strings.Split(content, "\n") {
+		l = strings.TrimSpace(l)
+		if strings.HasPrefix(l, "#") {
+			headings = append(headings, strings.ToLower(l))
+		}
+	}
+	var missing []string
+	for _, r := range required {
+		found := false
+		for _, h := range headings {
+			if strings.Contains(h, strings.ToLower(r)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, r)
+		}
+	}
+	return missing
+}
+
+// Fix implementing policydef.Policy.Fix(). Opens a pull request adding a
+// SECURITY.md from the configured template, if the repository has none.
 func (s Security) Fix(ctx context.Context, c *github.Client, owner, repo string) error {
-	log.Warn().
-		Str("org", owner).
-		Str("repo", repo).
-		Str("area", polName).
-		Msg("Action fix is configured, but not implemented.")
-	return nil
+	return fix(ctx, c, reposClient{c}, owner, repo)
+}
+
+func fix(ctx context.Context, c *github.Client, rep repositories, owner, repo string) error {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return nil
+	}
+	mc := mergeConfig(oc, orc, rc, repo)
+
+	_, path, err := getPolicy(ctx, rep, owner, repo)
+	if err != nil {
+		return err
+	}
+	if path != "" {
+		// Not replacing an existing policy, it needs the maintainers' attention.
+		return nil
+	}
+	tmpl := mc.FixTemplate
+	if tmpl == "" {
+		tmpl = defaultTemplate
+	}
+	f, err := pullrequest.Render("SECURITY.md", tmpl, pullrequest.TemplateData{Owner: owner, Repo: repo})
+	if err != nil {
+		return err
+	}
+	_, err = pullrequestEnsure(ctx, c, owner, repo, polName, &pullrequest.Request{
+		Branch: fixBranch,
+		Title:  "Add SECURITY.md",
+		Body: "This adds a security policy explaining how to report vulnerabilities. " +
+			"Please review and update the contact and disclosure details before merging.",
+		Files: []pullrequest.File{f},
+	})
+	return err
 }
 
 // GetAction returns the configured action from SECURITY.md policy's
@@ -224,7 +435,11 @@ func getConfig(ctx context.Context, c *github.Client, owner, repo string) (*OrgC
 
 func mergeConfig(oc *OrgConfig, orc *RepoConfig, rc *RepoConfig, repo string) *mergedConfig {
 	mc := &mergedConfig{
-		Action: oc.Action,
+		Action:            oc.Action,
+		RequireRepoPolicy: oc.RequireRepoPolicy,
+		MinLength:         oc.MinLength,
+		RequiredSections:  oc.RequiredSections,
+		FixTemplate:       oc.FixTemplate,
 	}
 	mc = mergeInRepoConfig(mc, orc, repo)
 
@@ -238,5 +453,25 @@ func mergeInRepoConfig(mc *mergedConfig, rc *RepoConfig, repo string) *mergedCon
 	if rc.Action != nil {
 		mc.Action = *rc.Action
 	}
+	if rc.RequireRepoPolicy != nil {
+		mc.RequireRepoPolicy = *rc.RequireRepoPolicy
+	}
+	if rc.MinLength != nil {
+		mc.MinLength = *rc.MinLength
+	}
+	if rc.RequiredSections != nil {
+		mc.RequiredSections = rc.RequiredSections
+	}
 	return mc
 }
+
+// reposClient implements repositories with a GitHub client.
+type reposClient struct {
+	c *github.Client
+}
+
+func (r reposClient) GetContents(ctx context.Context, owner, repo, path string,
+	opt *github.RepositoryContentGetOptions) (*github.RepositoryContent,
+	[]*github.RepositoryContent, *github.Response, error) {
+	return r.c.Repositories.GetContents(ctx, owner, repo, path, opt)
+}
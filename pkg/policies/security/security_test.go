@@ -16,12 +16,17 @@ package security
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-github/v59/github"
 	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/configtest"
 	"github.com/ossf/allstar/pkg/policydef"
+	"github.com/ossf/allstar/pkg/pullrequest"
 )
 
 var query func(context.Context, interface{}, map[string]interface{}) error
@@ -32,6 +37,48 @@ func (m mockClient) Query(ctx context.Context, q interface{}, v map[string]inter
 	return query(ctx, q, v)
 }
 
+var getContents func(context.Context, string, string, string,
+	*github.RepositoryContentGetOptions) (*github.RepositoryContent,
+	[]*github.RepositoryContent, *github.Response, error)
+
+type mockRepos struct{}
+
+func (m mockRepos) GetContents(ctx context.Context, o, r, p string,
+	opt *github.RepositoryContentGetOptions) (*github.RepositoryContent,
+	[]*github.RepositoryContent, *github.Response, error) {
+	return getContents(ctx, o, r, p, opt)
+}
+
+var notFound = &github.Response{
+	Response: &http.Response{StatusCode: http.StatusNotFound},
+}
+
+// mockFiles returns a getContents serving files keyed by "repo/path".
+func mockFiles(files map[string]string) func(context.Context, string, string, string,
+	*github.RepositoryContentGetOptions) (*github.RepositoryContent,
+	[]*github.RepositoryContent, *github.Response, error) {
+	return func(ctx context.Context, o, r, p string,
+		opt *github.RepositoryContentGetOptions) (*github.RepositoryContent,
+		[]*github.RepositoryContent, *github.Response, error) {
+		c, ok := files[r+"/"+p]
+		if !ok {
+			return nil, nil, notFound, errors.New("404")
+		}
+		return &github.RepositoryContent{Content: &c}, nil, nil, nil
+	}
+}
+
+const goodPolicy = `# Security Policy
+
+## Contact
+
+Report vulnerabilities at security@example.com.
+
+## Disclosure Policy
+
+We disclose after a fix is released.
+`
+
 func TestConfigPrecedence(t *testing.T) {
 	tests := []struct {
 		Name      string
@@ -83,6 +130,30 @@ func TestConfigPrecedence(t *testing.T) {
 				Action: "email",
 			},
 		},
+		{
+			Name: "ContentChecks",
+			Org: OrgConfig{
+				Action:           "issue",
+				MinLength:        100,
+				RequiredSections: []string{"contact"},
+				FixTemplate:      "# Security",
+			},
+			OrgRepo: RepoConfig{
+				RequireRepoPolicy: github.Bool(true),
+			},
+			Repo: RepoConfig{
+				MinLength:        github.Int(50),
+				RequiredSections: []string{"contact", "disclosure"},
+			},
+			ExpAction: "issue",
+			Exp: mergedConfig{
+				Action:            "issue",
+				RequireRepoPolicy: true,
+				MinLength:         50,
+				RequiredSections:  []string{"contact", "disclosure"},
+				FixTemplate:       "# Security",
+			},
+		},
 		{
 			Name: "RepoDisallowed",
 			Org: OrgConfig{
@@ -236,7 +307,7 @@ func TestCheck(t *testing.T) {
 				c *github.Client, owner, repo string) (bool, error) {
 				return test.cofigEnabled, nil
 			}
-			res, err := check(context.Background(), nil, mockClient{}, "", "thisrepo")
+			res, err := check(context.Background(), nil, mockRepos{}, mockClient{}, "", "thisrepo")
 			if err != nil {
 				t.Fatalf("Unexpected error: %v", err)
 			}
@@ -254,3 +325,192 @@ func trunc(s string, n int) string {
 	}
 	return s[:n]
 }
+
+func TestCheckContent(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Org        OrgConfig
+		Files      map[string]string
+		ExpPass    bool
+		ExpDetails details
+	}{
+		{
+			Name: "RepoPolicy",
+			Org: OrgConfig{
+				RequireRepoPolicy: true,
+				MinLength:         50,
+				RequiredSections:  []string{"Contact", "disclosure"},
+			},
+			Files:   map[string]string{"thisrepo/.github/SECURITY.md": goodPolicy},
+			ExpPass: true,
+			ExpDetails: details{
+				Enabled: true,
+				Path:    ".github/SECURITY.md",
+				Length:  len(strings.TrimSpace(goodPolicy)),
+			},
+		},
+		{
+			Name:    "OrgPolicyNotAllowed",
+			Org:     OrgConfig{RequireRepoPolicy: true},
+			Files:   map[string]string{".github/SECURITY.md": goodPolicy},
+			ExpPass: false,
+			ExpDetails: details{
+				Enabled:   true,
+				OrgPolicy: true,
+			},
+		},
+		{
+			Name: "OrgPolicyAllowed",
+			Org: OrgConfig{
+				RequiredSections: []string{"contact"},
+			},
+			Files:   map[string]string{".github/SECURITY.md": goodPolicy},
+			ExpPass: true,
+			ExpDetails: details{
+				Enabled:   true,
+				OrgPolicy: true,
+				Path:      "SECURITY.md",
+				Length:    len(strings.TrimSpace(goodPolicy)),
+			},
+		},
+		{
+			Name: "TooShortMissingSections",
+			Org: OrgConfig{
+				MinLength:        50,
+				RequiredSections: []string{"contact", "disclosure"},
+			},
+			// Body text mentioning a section is not a heading.
+			Files:   map[string]string{"thisrepo/SECURITY.md": "# Security\nContact us.\n"},
+			ExpPass: false,
+			ExpDetails: details{
+				Enabled:         true,
+				Path:            "SECURITY.md",
+				Length:          22,
+				MissingSections: []string{"contact", "disclosure"},
+			},
+		},
+	}
+
+	configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+		c *github.Client, owner, repo string) (bool, error) {
+		return true, nil
+	}
+	query = func(ctx context.Context, q interface{}, v map[string]interface{}) error {
+		qc := q.(*struct {
+			Repository struct {
+				SecurityPolicyUrl       string
+				IsSecurityPolicyEnabled bool
+			} `graphql:"repository(owner: $owner, name: $name)"`
+		})
+		qc.Repository.IsSecurityPolicyEnabled = true
+		return nil
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.OrgLevel {
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+			getContents = mockFiles(test.Files)
+
+			res, err := check(context.Background(), nil, mockRepos{}, mockClient{}, "", "thisrepo")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Pass != test.ExpPass {
+				t.Errorf("Unexpected pass: %v, notify text: %v", res.Pass, res.NotifyText)
+			}
+			if diff := cmp.Diff(test.ExpDetails, res.Details); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFix(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Org        OrgConfig
+		Files      map[string]string
+		ExpContent string
+		ExpPR      bool
+	}{
+		{
+			Name:  "OpensPR",
+			Org:   OrgConfig{},
+			ExpPR: true,
+		},
+		{
+			Name:       "CustomTemplate",
+			Org:        OrgConfig{FixTemplate: "# Security for {{.Owner}}/{{.Repo}}\n"},
+			ExpContent: "# Security for org/thisrepo\n",
+			ExpPR:      true,
+		},
+		{
+			Name:  "ExistingPolicy",
+			Org:   OrgConfig{},
+			Files: map[string]string{"thisrepo/docs/SECURITY.md": goodPolicy},
+		},
+	}
+
+	configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+		c *github.Client, owner, repo string) (bool, error) {
+		return true, nil
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.OrgLevel {
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+			getContents = mockFiles(test.Files)
+			var content string
+			gotPR := false
+			pullrequestEnsure = func(ctx context.Context, c *github.Client, owner, repo, policy string,
+				pr *pullrequest.Request) (*github.PullRequest, error) {
+				gotPR = true
+				if pr.Branch != fixBranch || len(pr.Files) != 1 || pr.Files[0].Path != "SECURITY.md" {
+					t.Errorf("Unexpected pull request: %v", pr)
+				}
+				content = string(pr.Files[0].Content)
+				return &github.PullRequest{}, nil
+			}
+
+			if err := fix(context.Background(), nil, mockRepos{}, "org", "thisrepo"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if gotPR != test.ExpPR {
+				t.Errorf("Unexpected pull request, want %v got %v", test.ExpPR, gotPR)
+			}
+			if test.ExpContent != "" && content != test.ExpContent {
+				t.Errorf("Unexpected content, want %q got %q", test.ExpContent, content)
+			}
+			if test.ExpPR && !strings.Contains(content, "# Security") {
+				t.Errorf("Unexpected content: %q", content)
+			}
+		})
+	}
+}
+
+func TestGeneratedPrecedence(t *testing.T) {
+	configtest.TestPrecedence(t, configtest.Precedence{
+		Org:   OrgConfig{},
+		Repo:  RepoConfig{},
+		Fetch: &configFetchConfig,
+		Merge: func() interface{} {
+			ctx := context.Background()
+			oc, orc, rc := getConfig(ctx, nil, "", configtest.Repo)
+			return mergeConfig(oc, orc, rc, configtest.Repo)
+		},
+	})
+}
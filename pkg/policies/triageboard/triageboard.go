@@ -0,0 +1,398 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package triageboard implements the Security Triage Board policy. It checks
+// that the organization's security triage project exists, and that the open
+// Allstar issues of the organization are on it. It is an org-scope policy, run
+// once per organization.
+package triageboard
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/operator"
+	"github.com/ossf/allstar/pkg/policydef"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+	"github.com/shurcooL/githubv4"
+)
+
+const configFile = "triage_board.yaml"
+const polName = "Security Triage Board"
+
+const notConfiguredText = `No security triage project board is configured for the organization.
+
+Set "projectNumber" in the org-level %v config to the number of the organization's GitHub Project used to triage security issues.`
+
+const missingText = `The security triage project board #%v does not exist in the organization, or Allstar does not have access to it.
+
+Create the project, or update "projectNumber" in the org-level %v config. See https://docs.github.com/en/issues/planning-and-tracking-with-projects/creating-projects/creating-a-project`
+
+const closedText = `The security triage project board %q (#%v) is closed. Reopen it, or update "projectNumber" in the org-level %v config.`
+
+const notOnBoardText = `The following Allstar issues are not on the security triage project board %q (#%v):
+%v
+Add them to the project, or set the action to "fix" to have Allstar add them. Allstar issues missing from the board usually indicate broken project automation.`
+
+// unresolvedProject is the GraphQL error returned for a missing project.
+const unresolvedProject = "Could not resolve to a ProjectV2"
+
+// OrgConfig is the org-level config definition for Security Triage Board.
+// There is no repo-level config, as the project belongs to the organization.
+type OrgConfig struct {
+	// Enabled : set to true to check the organization's project, default
+	// false.
+	Enabled bool `json:"enabled"`
+
+	// Action defines which action to take, default log, other: issue...
+	Action string `json:"action"`
+
+	// ProjectNumber is the number of the organization-level GitHub Project
+	// used to triage security issues, as found in the project URL:
+	// https://github.com/orgs/<org>/projects/<number>
+	ProjectNumber int `json:"projectNumber"`
+
+	// RequireIssuesOnBoard : set to true to require that the open Allstar
+	// issues of the organization are items on the project board, default
+	// true.
+	RequireIssuesOnBoard bool `json:"requireIssuesOnBoard"`
+}
+
+type details struct {
+	ProjectNumber int
+	ProjectTitle  string
+	ProjectURL    string
+	ProjectExists bool
+	ProjectClosed bool
+	// MissingIssues are the issues not on the board, as "repo#number".
+	MissingIssues []string
+}
+
+type projectQuery struct {
+	Organization struct {
+		ProjectV2 struct {
+			ID     githubv4.ID
+			Title  string
+			URL    string
+			Closed bool
+		} `graphql:"projectV2(number: $number)"`
+	} `graphql:"organization(login: $owner)"`
+}
+
+type issuesQuery struct {
+	Search struct {
+		Nodes []struct {
+			Issue issueNode `graphql:"... on Issue"`
+		}
+	} `graphql:"search(query: $query, type: ISSUE, first: 100)"`
+}
+
+type issueNode struct {
+	ID         githubv4.ID
+	Number     int
+	Title      string
+	Repository struct {
+		Name string
+	}
+	ProjectItems struct {
+		Nodes []struct {
+			Project struct {
+				ID githubv4.ID
+			}
+		}
+	} `graphql:"projectItems(first: 50)"`
+}
+
+// AddProjectV2ItemByIdInput is the input to the addProjectV2ItemById
+// mutation. It is exported and named as such because the GraphQL client uses
+// the type name as the input type name, and the vendored githubv4 predates
+// Projects V2.
+type AddProjectV2ItemByIdInput struct {
+	ProjectID githubv4.ID `json:"projectId"`
+	ContentID githubv4.ID `json:"contentId"`
+}
+
+var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+
+var configGetOrgConfig func(context.Context, *github.Client, string) *config.OrgConfig
+
+func init() {
+	configFetchConfig = config.FetchConfig
+	configGetOrgConfig = config.GetOrgConfig
+}
+
+type v4client interface {
+	Query(context.Context, interface{}, map[string]interface{}) error
+	Mutate(context.Context, interface{}, githubv4.Input, map[string]interface{}) error
+}
+
+// TriageBoard is the Security Triage Board policy object, implements
+// policydef.OrgPolicy.
+type TriageBoard bool
+
+// NewTriageBoard returns a new Security Triage Board policy.
+func NewTriageBoard() policydef.OrgPolicy {
+	var t TriageBoard
+	return t
+}
+
+// Name returns the name of this policy, implementing
+// policydef.OrgPolicy.Name()
+func (t TriageBoard) Name() string {
+	return polName
+}
+
+func newV4Client(c *github.Client) v4client {
+	if operator.GitHubEnterpriseUrl == "" {
+		return githubv4.NewClient(c.Client())
+	}
+	return githubv4.NewEnterpriseClient(operator.GitHubEnterpriseUrl+"/api/graphql", c.Client())
+}
+
+// Check whether this policy is enabled or not
+func (t TriageBoard) IsEnabled(ctx context.Context, c *github.Client, owner string) (bool, error) {
+	oc := getConfig(ctx, c, owner)
+	return oc.Enabled, nil
+}
+
+// Check performs the policy check for Security Triage Board policy based on
+// the configuration stored in the org, implementing
+// policydef.OrgPolicy.Check()
+func (t TriageBoard) Check(ctx context.Context, c *github.Client, owner string) (*policydef.Result, error) {
+	return check(ctx, c, newV4Client(c), owner)
+}
+
+func check(ctx context.Context, c *github.Client, v4c v4client, owner string) (*policydef.Result, error) {
+	oc := getConfig(ctx, c, owner)
+	log.Info().
+		Str("org", owner).
+		Str("area", polName).
+		Bool("enabled", oc.Enabled).
+		Msg("Check org enabled")
+	if !oc.Enabled {
+		return &policydef.Result{
+			Enabled:    false,
+			Pass:       true,
+			NotifyText: "",
+			Details:    details{},
+		}, nil
+	}
+
+	d := details{
+		ProjectNumber: oc.ProjectNumber,
+	}
+	if oc.ProjectNumber == 0 {
+		return &policydef.Result{
+			Enabled:    true,
+			Pass:       false,
+			NotifyText: fmt.Sprintf(notConfiguredText, configFile),
+			Details:    d,
+		}, nil
+	}
+
+	id, err := getProject(ctx, v4c, owner, oc.ProjectNumber, &d)
+	if err != nil {
+		return nil, err
+	}
+	if !d.ProjectExists {
+		return &policydef.Result{
+			Enabled:    true,
+			Pass:       false,
+			NotifyText: fmt.Sprintf(missingText, oc.ProjectNumber, configFile),
+			Details:    d,
+		}, nil
+	}
+	if d.ProjectClosed {
+		return &policydef.Result{
+			Enabled:    true,
+			Pass:       false,
+			NotifyText: fmt.Sprintf(closedText, d.ProjectTitle, oc.ProjectNumber, configFile),
+			Details:    d,
+		}, nil
+	}
+	if !oc.RequireIssuesOnBoard {
+		return &policydef.Result{
+			Enabled:    true,
+			Pass:       true,
+			NotifyText: "",
+			Details:    d,
+		}, nil
+	}
+
+	missing, err := getMissingIssues(ctx, c, v4c, owner, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) == 0 {
+		return &policydef.Result{
+			Enabled:    true,
+			Pass:       true,
+			NotifyText: "",
+			Details:    d,
+		}, nil
+	}
+	var list string
+	for _, i := range missing {
+		ref := fmt.Sprintf("%v#%v", i.Repository.Name, i.Number)
+		d.MissingIssues = append(d.MissingIssues, ref)
+		list = list + fmt.Sprintf("- %v/%v\n", owner, ref)
+	}
+	return &policydef.Result{
+		Enabled:    true,
+		Pass:       false,
+		NotifyText: fmt.Sprintf(notOnBoardText, d.ProjectTitle, oc.ProjectNumber, list),
+		Details:    d,
+	}, nil
+}
+
+// getProject looks up the org-level project, filling in d, and returns its
+// node ID.
+func getProject(ctx context.Context, v4c v4client, owner string, number int, d *details) (githubv4.ID, error) {
+	var q projectQuery
+	variables := map[string]interface{}{
+		"owner":  githubv4.String(owner),
+		"number": githubv4.Int(number),
+	}
+	if err := v4c.Query(ctx, &q, variables); err != nil {
+		if strings.Contains(err.Error(), unresolvedProject) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	p := q.Organization.ProjectV2
+	d.ProjectExists = true
+	d.ProjectTitle = p.Title
+	d.ProjectURL = p.URL
+	d.ProjectClosed = p.Closed
+	return p.ID, nil
+}
+
+// getMissingIssues returns the open Allstar issues in the organization that
+// are not items in the project. Only the first 100 issues found are looked
+// at.
+func getMissingIssues(ctx context.Context, c *github.Client, v4c v4client, owner string, projectID githubv4.ID) ([]issueNode, error) {
+	var q issuesQuery
+	variables := map[string]interface{}{
+		"query": githubv4.String(fmt.Sprintf("org:%v is:issue is:open label:%q", owner, getIssueLabel(ctx, c, owner))),
+	}
+	if err := v4c.Query(ctx, &q, variables); err != nil {
+		return nil, err
+	}
+	var missing []issueNode
+	for _, n := range q.Search.Nodes {
+		var found bool
+		for _, pi := range n.Issue.ProjectItems.Nodes {
+			if pi.Project.ID == projectID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, n.Issue)
+		}
+	}
+	return missing, nil
+}
+
+func getIssueLabel(ctx context.Context, c *github.Client, owner string) string {
+	oc := configGetOrgConfig(ctx, c, owner)
+	if len(oc.IssueLabel) > 0 {
+		return oc.IssueLabel
+	}
+	return operator.GitHubIssueLabel
+}
+
+// Fix implementing policydef.OrgPolicy.Fix(). Adds any open Allstar issues of
+// the organization that are missing from the project board. A missing or
+// closed board can not be fixed.
+func (t TriageBoard) Fix(ctx context.Context, c *github.Client, owner string) error {
+	return fix(ctx, c, newV4Client(c), owner)
+}
+
+func fix(ctx context.Context, c *github.Client, v4c v4client, owner string) error {
+	oc := getConfig(ctx, c, owner)
+	if !oc.Enabled || oc.ProjectNumber == 0 || !oc.RequireIssuesOnBoard {
+		return nil
+	}
+	var d details
+	id, err := getProject(ctx, v4c, owner, oc.ProjectNumber, &d)
+	if err != nil {
+		return err
+	}
+	if !d.ProjectExists || d.ProjectClosed {
+		log.Warn().
+			Str("org", owner).
+			Str("area", polName).
+			Int("projectNumber", oc.ProjectNumber).
+			Msg("Security triage project is missing or closed, can not fix.")
+		return nil
+	}
+	missing, err := getMissingIssues(ctx, c, v4c, owner, id)
+	if err != nil {
+		return err
+	}
+	for _, i := range missing {
+		var m struct {
+			AddProjectV2ItemById struct {
+				Item struct {
+					ID githubv4.ID
+				}
+			} `graphql:"addProjectV2ItemById(input: $input)"`
+		}
+		input := AddProjectV2ItemByIdInput{
+			ProjectID: id,
+			ContentID: i.ID,
+		}
+		if err := v4c.Mutate(ctx, &m, input, nil); err != nil {
+			return err
+		}
+		log.Info().
+			Str("org", owner).
+			Str("repo", i.Repository.Name).
+			Str("area", polName).
+			Int("issueNumber", i.Number).
+			Int("projectNumber", oc.ProjectNumber).
+			Msg("Added issue to security triage project.")
+	}
+	return nil
+}
+
+// GetAction returns the configured action from Security Triage Board policy's
+// configuration stored in the org-level repo, default log. Implementing
+// policydef.OrgPolicy.GetAction()
+func (t TriageBoard) GetAction(ctx context.Context, c *github.Client, owner string) string {
+	oc := getConfig(ctx, c, owner)
+	return oc.Action
+}
+
+func getConfig(ctx context.Context, c *github.Client, owner string) *OrgConfig {
+	oc := &OrgConfig{ // Fill out non-zero defaults
+		Action:               "log",
+		RequireIssuesOnBoard: true,
+	}
+	if err := configFetchConfig(ctx, c, owner, "", configFile, config.OrgLevel, oc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("configLevel", "orgLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	return oc
+}
@@ -0,0 +1,359 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triageboard
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/policydef"
+	"github.com/shurcooL/githubv4"
+)
+
+var query func(context.Context, interface{}, map[string]interface{}) error
+var mutate func(context.Context, interface{}, githubv4.Input, map[string]interface{}) error
+
+type mockClient struct{}
+
+func (m mockClient) Query(ctx context.Context, q interface{}, v map[string]interface{}) error {
+	return query(ctx, q, v)
+}
+
+func (m mockClient) Mutate(ctx context.Context, q interface{}, i githubv4.Input, v map[string]interface{}) error {
+	return mutate(ctx, q, i, v)
+}
+
+type project struct {
+	Exists bool
+	Closed bool
+}
+
+type issue struct {
+	Repo       string
+	Number     int
+	Title      string
+	ProjectIDs []string
+}
+
+func mockQuery(t *testing.T, p project, is []issue) func(context.Context, interface{}, map[string]interface{}) error {
+	return func(ctx context.Context, q interface{}, v map[string]interface{}) error {
+		switch qc := q.(type) {
+		case *projectQuery:
+			if !p.Exists {
+				return errors.New("Could not resolve to a ProjectV2 with the number 1.")
+			}
+			qc.Organization.ProjectV2.ID = "P_1"
+			qc.Organization.ProjectV2.Title = "Security Triage"
+			qc.Organization.ProjectV2.Closed = p.Closed
+		case *issuesQuery:
+			if q := string(v["query"].(githubv4.String)); !strings.Contains(q, `label:"allstar"`) {
+				t.Errorf("Unexpected search query: %v", q)
+			}
+			for _, i := range is {
+				n := issueNode{
+					ID:     "I_" + i.Title,
+					Number: i.Number,
+					Title:  i.Title,
+				}
+				n.Repository.Name = i.Repo
+				for _, pid := range i.ProjectIDs {
+					n.ProjectItems.Nodes = append(n.ProjectItems.Nodes, struct {
+						Project struct {
+							ID githubv4.ID
+						}
+					}{Project: struct {
+						ID githubv4.ID
+					}{ID: pid}})
+				}
+				qc.Search.Nodes = append(qc.Search.Nodes, struct {
+					Issue issueNode `graphql:"... on Issue"`
+				}{Issue: n})
+			}
+		default:
+			t.Errorf("Query() called with unexpected query structure.")
+		}
+		return nil
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Org     OrgConfig
+		Project project
+		Issues  []issue
+		Exp     policydef.Result
+	}{
+		{
+			Name: "Disabled",
+			Org: OrgConfig{
+				ProjectNumber: 1,
+			},
+			Exp: policydef.Result{
+				Enabled:    false,
+				Pass:       true,
+				NotifyText: "",
+				Details:    details{},
+			},
+		},
+		{
+			Name: "NotConfigured",
+			Org: OrgConfig{
+				Enabled:              true,
+				RequireIssuesOnBoard: true,
+			},
+			Exp: policydef.Result{
+				Enabled:    true,
+				Pass:       false,
+				NotifyText: "No security triage project board is configured",
+				Details:    details{},
+			},
+		},
+		{
+			Name: "ProjectMissing",
+			Org: OrgConfig{
+				Enabled:              true,
+				ProjectNumber:        1,
+				RequireIssuesOnBoard: true,
+			},
+			Exp: policydef.Result{
+				Enabled:    true,
+				Pass:       false,
+				NotifyText: "The security triage project board #1 does not exist",
+				Details: details{
+					ProjectNumber: 1,
+				},
+			},
+		},
+		{
+			Name: "ProjectClosed",
+			Org: OrgConfig{
+				Enabled:              true,
+				ProjectNumber:        1,
+				RequireIssuesOnBoard: true,
+			},
+			Project: project{Exists: true, Closed: true},
+			Exp: policydef.Result{
+				Enabled:    true,
+				Pass:       false,
+				NotifyText: "The security triage project board \"Security Triage\" (#1) is closed",
+				Details: details{
+					ProjectNumber: 1,
+					ProjectTitle:  "Security Triage",
+					ProjectExists: true,
+					ProjectClosed: true,
+				},
+			},
+		},
+		{
+			Name: "ExistsNoIssueCheck",
+			Org: OrgConfig{
+				Enabled:       true,
+				ProjectNumber: 1,
+			},
+			Project: project{Exists: true},
+			Issues: []issue{
+				{Repo: "thisrepo", Number: 3, Title: "Security Policy violation Branch Protection"},
+			},
+			Exp: policydef.Result{
+				Enabled:    true,
+				Pass:       true,
+				NotifyText: "",
+				Details: details{
+					ProjectNumber: 1,
+					ProjectTitle:  "Security Triage",
+					ProjectExists: true,
+				},
+			},
+		},
+		{
+			Name: "IssuesOnBoard",
+			Org: OrgConfig{
+				Enabled:              true,
+				ProjectNumber:        1,
+				RequireIssuesOnBoard: true,
+			},
+			Project: project{Exists: true},
+			Issues: []issue{
+				{Repo: "thisrepo", Number: 3, Title: "Security Policy violation Branch Protection", ProjectIDs: []string{"P_2", "P_1"}},
+			},
+			Exp: policydef.Result{
+				Enabled:    true,
+				Pass:       true,
+				NotifyText: "",
+				Details: details{
+					ProjectNumber: 1,
+					ProjectTitle:  "Security Triage",
+					ProjectExists: true,
+				},
+			},
+		},
+		{
+			Name: "IssuesMissing",
+			Org: OrgConfig{
+				Enabled:              true,
+				ProjectNumber:        1,
+				RequireIssuesOnBoard: true,
+			},
+			Project: project{Exists: true},
+			Issues: []issue{
+				{Repo: "thisrepo", Number: 3, Title: "Security Policy violation Branch Protection", ProjectIDs: []string{"P_1"}},
+				{Repo: "thisrepo", Number: 4, Title: "Security Policy violation SECURITY.md", ProjectIDs: []string{"P_2"}},
+				{Repo: "otherrepo", Number: 5, Title: "Security Policy violation CODEOWNERS"},
+			},
+			Exp: policydef.Result{
+				Enabled:    true,
+				Pass:       false,
+				NotifyText: "The following Allstar issues are not on the security triage project board \"Security Triage\" (#1):\n- org/thisrepo#4\n- org/otherrepo#5\n",
+				Details: details{
+					ProjectNumber: 1,
+					ProjectTitle:  "Security Triage",
+					ProjectExists: true,
+					MissingIssues: []string{"thisrepo#4", "otherrepo#5"},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.OrgLevel {
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+			configGetOrgConfig = func(context.Context, *github.Client, string) *config.OrgConfig {
+				return &config.OrgConfig{IssueLabel: "allstar"}
+			}
+			query = mockQuery(t, test.Project, test.Issues)
+			res, err := check(context.Background(), nil, mockClient{}, "org")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			c := cmp.Comparer(func(x, y string) bool { return trunc(x, 40) == trunc(y, 40) })
+			if diff := cmp.Diff(&test.Exp, res, c); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFix(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Org      OrgConfig
+		Project  project
+		Issues   []issue
+		ExpAdded []githubv4.ID
+	}{
+		{
+			Name: "AddsMissing",
+			Org: OrgConfig{
+				Enabled:              true,
+				ProjectNumber:        1,
+				RequireIssuesOnBoard: true,
+			},
+			Project: project{Exists: true},
+			Issues: []issue{
+				{Repo: "thisrepo", Number: 3, Title: "a", ProjectIDs: []string{"P_1"}},
+				{Repo: "otherrepo", Number: 4, Title: "b"},
+			},
+			ExpAdded: []githubv4.ID{"I_b"},
+		},
+		{
+			Name: "ProjectMissing",
+			Org: OrgConfig{
+				Enabled:              true,
+				ProjectNumber:        1,
+				RequireIssuesOnBoard: true,
+			},
+			Issues: []issue{
+				{Repo: "thisrepo", Number: 4, Title: "b"},
+			},
+		},
+		{
+			Name: "NotRequired",
+			Org: OrgConfig{
+				Enabled:       true,
+				ProjectNumber: 1,
+			},
+			Project: project{Exists: true},
+			Issues: []issue{
+				{Repo: "thisrepo", Number: 4, Title: "b"},
+			},
+		},
+		{
+			Name: "Disabled",
+			Org: OrgConfig{
+				ProjectNumber:        1,
+				RequireIssuesOnBoard: true,
+			},
+			Project: project{Exists: true},
+			Issues: []issue{
+				{Repo: "thisrepo", Number: 4, Title: "b"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.OrgLevel {
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+			configGetOrgConfig = func(context.Context, *github.Client, string) *config.OrgConfig {
+				return &config.OrgConfig{IssueLabel: "allstar"}
+			}
+			query = mockQuery(t, test.Project, test.Issues)
+			var added []githubv4.ID
+			mutate = func(ctx context.Context, m interface{}, i githubv4.Input, v map[string]interface{}) error {
+				in, ok := i.(AddProjectV2ItemByIdInput)
+				if !ok {
+					t.Fatalf("Mutate() called with unexpected input.")
+				}
+				if in.ProjectID != "P_1" {
+					t.Errorf("Unexpected project: %v", in.ProjectID)
+				}
+				added = append(added, in.ContentID)
+				return nil
+			}
+			if err := fix(context.Background(), nil, mockClient{}, "org"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.ExpAdded, added); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func trunc(s string, n int) string {
+	if n >= len(s) {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,223 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package twofactor implements the Two-Factor Authentication policy. It checks
+// that the organization requires its members to enable two-factor
+// authentication. It is an org-scope policy, run once per organization.
+package twofactor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/policydef"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+const configFile = "two_factor.yaml"
+const polName = "Two-Factor Authentication"
+
+// filter2FADisabled lists the members without two-factor authentication.
+const filter2FADisabled = "2fa_disabled"
+
+const notifyText = `This policy requires that the organization requires two-factor authentication, so that a stolen password is not enough to take over the account of a member, and push to or administer the repositories of the organization.
+
+To fix this, an organization owner can go to Settings -> Authentication security, and check "Require two-factor authentication for everyone in the organization". Members without two-factor authentication are removed from the organization when it is enabled, ask them to enable it first.
+(For more information, see https://docs.github.com/en/organizations/keeping-your-organization-secure/managing-two-factor-authentication-for-your-organization/requiring-two-factor-authentication-in-your-organization)`
+
+// OrgConfig is the org-level config definition for Two-Factor
+// Authentication. There is no repo-level config, as it checks organization
+// settings.
+type OrgConfig struct {
+	// Enabled : set to true to check the organization settings, default false.
+	Enabled bool `json:"enabled"`
+
+	// Action defines which action to take, default log, other: issue...
+	Action string `json:"action"`
+
+	// ListMembers : set to false to not list the members without two-factor
+	// authentication in the result, default true. Only organization owners
+	// can list them, the list is left out if it is not allowed.
+	ListMembers bool `json:"listMembers"`
+}
+
+type details struct {
+	TwoFactorRequirementEnabled bool
+	MembersWithout2FA           []string
+}
+
+var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+
+func init() {
+	configFetchConfig = config.FetchConfig
+}
+
+// orgs is the subset of the GitHub API used.
+type orgs interface {
+	Get(context.Context, string) (*github.Organization, *github.Response, error)
+	ListMembers(context.Context, string, *github.ListMembersOptions) (
+		[]*github.User, *github.Response, error)
+}
+
+// TwoFactor is the Two-Factor Authentication policy object, implements
+// policydef.OrgPolicy.
+type TwoFactor bool
+
+// NewTwoFactor returns a new Two-Factor Authentication policy.
+func NewTwoFactor() policydef.OrgPolicy {
+	var t TwoFactor
+	return t
+}
+
+// Name returns the name of this policy, implementing
+// policydef.OrgPolicy.Name()
+func (t TwoFactor) Name() string {
+	return polName
+}
+
+// Check whether this policy is enabled or not
+func (t TwoFactor) IsEnabled(ctx context.Context, c *github.Client, owner string) (bool, error) {
+	oc := getConfig(ctx, c, owner)
+	return oc.Enabled, nil
+}
+
+// Check performs the policy check for Two-Factor Authentication based on the
+// configuration stored in the org, implementing policydef.OrgPolicy.Check()
+func (t TwoFactor) Check(ctx context.Context, c *github.Client, owner string) (*policydef.Result, error) {
+	return check(ctx, c.Organizations, c, owner)
+}
+
+func check(ctx context.Context, o orgs, c *github.Client, owner string) (*policydef.Result, error) {
+	oc := getConfig(ctx, c, owner)
+	log.Info().
+		Str("org", owner).
+		Str("area", polName).
+		Bool("enabled", oc.Enabled).
+		Msg("Check org enabled")
+	if !oc.Enabled {
+		return &policydef.Result{
+			Enabled:    false,
+			Pass:       true,
+			NotifyText: "",
+			Details:    details{},
+		}, nil
+	}
+
+	org, _, err := o.Get(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	// The setting is only returned with the organization Administration read
+	// permission.
+	if org.TwoFactorRequirementEnabled == nil {
+		return nil, errors.New("two-factor requirement of the organization not returned, the App needs the organization Administration read permission")
+	}
+	d := details{
+		TwoFactorRequirementEnabled: org.GetTwoFactorRequirementEnabled(),
+	}
+	if oc.ListMembers {
+		d.MembersWithout2FA = membersWithout2FA(ctx, o, owner)
+	}
+	if d.TwoFactorRequirementEnabled {
+		return &policydef.Result{
+			Enabled:    true,
+			Pass:       true,
+			NotifyText: "",
+			Details:    d,
+		}, nil
+	}
+
+	text := "The organization does not require two-factor authentication.\n"
+	if len(d.MembersWithout2FA) > 0 {
+		text = text + "Members without two-factor authentication, who would be removed when it is required:\n"
+		for _, m := range d.MembersWithout2FA {
+			text = text + fmt.Sprintf("- %v\n", m)
+		}
+	}
+	return &policydef.Result{
+		Enabled:    true,
+		Pass:       false,
+		NotifyText: text + "\n" + notifyText,
+		Details:    d,
+	}, nil
+}
+
+// membersWithout2FA returns the logins of the members of the organization
+// without two-factor authentication. Errors are logged, and return nil, as
+// only organization owners can list them.
+func membersWithout2FA(ctx context.Context, o orgs, owner string) []string {
+	var logins []string
+	opt := &github.ListMembersOptions{
+		Filter:      filter2FADisabled,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		ms, rsp, err := o.ListMembers(ctx, owner, opt)
+		if err != nil {
+			log.Warn().
+				Str("org", owner).
+				Str("area", polName).
+				Err(err).
+				Msg("Unable to list members without two-factor authentication.")
+			return nil
+		}
+		for _, m := range ms {
+			logins = append(logins, m.GetLogin())
+		}
+		if rsp == nil || rsp.NextPage == 0 {
+			break
+		}
+		opt.Page = rsp.NextPage
+	}
+	return logins
+}
+
+// Fix implementing policydef.OrgPolicy.Fix(). Currently not supported, the
+// GitHub API can not require two-factor authentication.
+func (t TwoFactor) Fix(ctx context.Context, c *github.Client, owner string) error {
+	log.Warn().
+		Str("org", owner).
+		Str("area", polName).
+		Msg("Action fix is configured, but not implemented.")
+	return nil
+}
+
+// GetAction returns the configured action from Two-Factor Authentication's
+// configuration stored in the org-level repo, default log. Implementing
+// policydef.OrgPolicy.GetAction()
+func (t TwoFactor) GetAction(ctx context.Context, c *github.Client, owner string) string {
+	oc := getConfig(ctx, c, owner)
+	return oc.Action
+}
+
+func getConfig(ctx context.Context, c *github.Client, owner string) *OrgConfig {
+	oc := &OrgConfig{ // Fill out non-zero defaults
+		Action:      "log",
+		ListMembers: true,
+	}
+	if err := configFetchConfig(ctx, c, owner, "", configFile, config.OrgLevel, oc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("configLevel", "orgLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	return oc
+}
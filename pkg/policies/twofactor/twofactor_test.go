@@ -0,0 +1,146 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package twofactor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+)
+
+var org *github.Organization
+var members []*github.User
+var membersErr error
+var membersFilter string
+
+type mockOrgs struct{}
+
+func (m mockOrgs) Get(ctx context.Context, o string) (*github.Organization, *github.Response, error) {
+	return org, nil, nil
+}
+
+func (m mockOrgs) ListMembers(ctx context.Context, o string, opt *github.ListMembersOptions) (
+	[]*github.User, *github.Response, error) {
+	membersFilter = opt.Filter
+	return members, &github.Response{}, membersErr
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Org        OrgConfig
+		Required   *bool
+		Members    []*github.User
+		MembersErr error
+		ExpEnabled bool
+		ExpPass    bool
+		ExpErr     bool
+		ExpDetails details
+	}{
+		{
+			Name:       "Disabled",
+			Org:        OrgConfig{},
+			ExpEnabled: false,
+			ExpPass:    true,
+		},
+		{
+			Name:       "Required",
+			Org:        OrgConfig{Enabled: true, ListMembers: true},
+			Required:   github.Bool(true),
+			ExpEnabled: true,
+			ExpPass:    true,
+			ExpDetails: details{TwoFactorRequirementEnabled: true},
+		},
+		{
+			Name:     "NotRequired",
+			Org:      OrgConfig{Enabled: true, ListMembers: true},
+			Required: github.Bool(false),
+			Members: []*github.User{
+				{Login: github.String("alice")},
+				{Login: github.String("bob")},
+			},
+			ExpEnabled: true,
+			ExpPass:    false,
+			ExpDetails: details{MembersWithout2FA: []string{"alice", "bob"}},
+		},
+		{
+			Name:     "NotRequiredNoList",
+			Org:      OrgConfig{Enabled: true},
+			Required: github.Bool(false),
+			Members: []*github.User{
+				{Login: github.String("alice")},
+			},
+			ExpEnabled: true,
+			ExpPass:    false,
+			ExpDetails: details{},
+		},
+		{
+			Name:       "NotRequiredListForbidden",
+			Org:        OrgConfig{Enabled: true, ListMembers: true},
+			Required:   github.Bool(false),
+			MembersErr: errors.New("forbidden"),
+			ExpEnabled: true,
+			ExpPass:    false,
+			ExpDetails: details{},
+		},
+		{
+			Name:   "NoPermission",
+			Org:    OrgConfig{Enabled: true, ListMembers: true},
+			ExpErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.OrgLevel {
+					*out.(*OrgConfig) = test.Org
+				}
+				return nil
+			}
+			org = &github.Organization{TwoFactorRequirementEnabled: test.Required}
+			members = test.Members
+			membersErr = test.MembersErr
+			membersFilter = ""
+
+			res, err := check(context.Background(), mockOrgs{}, nil, "thisorg")
+			if test.ExpErr {
+				if err == nil {
+					t.Errorf("Expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Enabled != test.ExpEnabled {
+				t.Errorf("Unexpected enabled: %v", res.Enabled)
+			}
+			if res.Pass != test.ExpPass {
+				t.Errorf("Unexpected pass: %v, text: %v", res.Pass, res.NotifyText)
+			}
+			if diff := cmp.Diff(test.ExpDetails, res.Details); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+			if test.Org.Enabled && test.Org.ListMembers && membersFilter != filter2FADisabled {
+				t.Errorf("Unexpected members filter: %q", membersFilter)
+			}
+		})
+	}
+}
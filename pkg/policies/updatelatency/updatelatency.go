@@ -0,0 +1,381 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package updatelatency implements the Dependency Update Latency policy. It
+// measures how long automated dependency update pull requests, such as from
+// Dependabot or Renovate, stay open before they are merged.
+package updatelatency
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/policydef"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+const configFile = "dependency_update_latency.yaml"
+const polName = "Dependency Update Latency"
+
+// maxPages limits the number of pages of pull requests listed for each state.
+const maxPages = 10
+
+const day = 24 * time.Hour
+
+const notifyText = `This policy requires that automated dependency update pull requests are merged in a timely manner, so that security updates reach the repository.
+
+Review and merge, or close, the open dependency update pull requests. Consider enabling auto-merge for updates that pass CI.`
+
+// OrgConfig is the org-level config definition for Dependency Update
+// Latency.
+type OrgConfig struct {
+	// OptConfig is the standard org-level opt in/out config, RepoOverride
+	// applies to all config.
+	OptConfig config.OrgOptConfig `json:"optConfig"`
+
+	// Action defines which action to take, default log, other: issue...
+	Action string `json:"action"`
+
+	// Authors is the list of pull request author logins that open dependency
+	// updates, default: "dependabot[bot]" and "renovate[bot]".
+	Authors []string `json:"authors"`
+
+	// WindowDays is the number of days of pull requests, by creation date,
+	// that are measured, default 90. Open pull requests are always measured.
+	WindowDays int `json:"windowDays"`
+
+	// Percentile is the percentile of latency compared to MaxDays, default 50
+	// (the median).
+	Percentile int `json:"percentile"`
+
+	// MaxDays is the latency allowed at Percentile, in days, default 14.
+	MaxDays int `json:"maxDays"`
+
+	// MinPullRequests is the number of pull requests needed to measure
+	// latency, the policy passes with fewer, default 3.
+	MinPullRequests int `json:"minPullRequests"`
+}
+
+// RepoConfig is the repo-level config for Dependency Update Latency.
+type RepoConfig struct {
+	// OptConfig is the standard repo-level opt in/out config.
+	OptConfig config.RepoOptConfig `json:"optConfig"`
+
+	// Action overrides the same setting in org-level, only if present.
+	Action *string `json:"action"`
+
+	// MaxDays overrides the same setting in org-level, only if present.
+	MaxDays *int `json:"maxDays"`
+}
+
+type mergedConfig struct {
+	Action          string
+	Authors         []string
+	WindowDays      int
+	Percentile      int
+	MaxDays         int
+	MinPullRequests int
+}
+
+type details struct {
+	// PullRequests is the number of pull requests measured.
+	PullRequests int
+
+	// Open is the number of those pull requests still open.
+	Open int
+
+	// LatencyDays is the latency at the configured percentile, in days.
+	LatencyDays float64
+
+	// OldestOpen lists the URLs of the longest open pull requests.
+	OldestOpen []string
+}
+
+var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+
+var configIsEnabled func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig, c *github.Client, owner, repo string) (bool, error)
+
+var now func() time.Time
+
+func init() {
+	configFetchConfig = config.FetchConfig
+	configIsEnabled = config.IsEnabled
+	now = time.Now
+}
+
+type pulls interface {
+	List(context.Context, string, string, *github.PullRequestListOptions) (
+		[]*github.PullRequest, *github.Response, error)
+}
+
+// UpdateLatency is the Dependency Update Latency policy object, implements
+// policydef.Policy.
+type UpdateLatency bool
+
+// NewUpdateLatency returns a new Dependency Update Latency policy.
+func NewUpdateLatency() policydef.Policy {
+	var u UpdateLatency
+	return u
+}
+
+// Name returns the name of this policy, implementing policydef.Policy.Name()
+func (u UpdateLatency) Name() string {
+	return polName
+}
+
+// Check whether this policy is enabled or not
+func (u UpdateLatency) IsEnabled(ctx context.Context, c *github.Client, owner, repo string) (bool, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	return configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+}
+
+// Check performs the policy check for Dependency Update Latency based on the
+// configuration stored in the org/repo, implementing policydef.Policy.Check()
+func (u UpdateLatency) Check(ctx context.Context, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	return check(ctx, c.PullRequests, c, owner, repo)
+}
+
+func check(ctx context.Context, p pulls, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Bool("enabled", enabled).
+		Msg("Check repo enabled")
+
+	mc := mergeConfig(oc, orc, rc, repo)
+
+	t := now()
+	prs, err := listUpdates(ctx, p, owner, repo, mc, t.Add(-time.Duration(mc.WindowDays)*day))
+	if err != nil {
+		return nil, err
+	}
+
+	var d details
+	var latencies []time.Duration
+	var open []*github.PullRequest
+	for _, pr := range prs {
+		switch {
+		case pr.GetState() == "open":
+			latencies = append(latencies, t.Sub(pr.GetCreatedAt().Time))
+			open = append(open, pr)
+		case pr.MergedAt != nil:
+			latencies = append(latencies, pr.GetMergedAt().Sub(pr.GetCreatedAt().Time))
+		}
+	}
+	d.PullRequests = len(latencies)
+	d.Open = len(open)
+	sort.Slice(open, func(i, j int) bool {
+		return open[i].GetCreatedAt().Before(open[j].GetCreatedAt().Time)
+	})
+	for i, pr := range open {
+		if i == 5 {
+			break
+		}
+		d.OldestOpen = append(d.OldestOpen, pr.GetHTMLURL())
+	}
+
+	if d.PullRequests < mc.MinPullRequests {
+		return &policydef.Result{
+			Enabled:    enabled,
+			Pass:       true,
+			NotifyText: "",
+			Details:    d,
+		}, nil
+	}
+
+	latency := percentile(latencies, mc.Percentile)
+	d.LatencyDays = math.Round(latency.Hours()/24*10) / 10
+	if latency <= time.Duration(mc.MaxDays)*day {
+		return &policydef.Result{
+			Enabled:    enabled,
+			Pass:       true,
+			NotifyText: "",
+			Details:    d,
+		}, nil
+	}
+
+	text := fmt.Sprintf("Dependency update pull requests stay open %.1f days at the %v percentile, "+
+		"more than the %v days allowed. (%v pull requests measured, %v still open.)\n",
+		d.LatencyDays, mc.Percentile, mc.MaxDays, d.PullRequests, d.Open)
+	if len(d.OldestOpen) > 0 {
+		text += "\nLongest open:\n"
+		for _, u := range d.OldestOpen {
+			text += fmt.Sprintf("- %v\n", u)
+		}
+	}
+	text += "\n" + notifyText
+
+	return &policydef.Result{
+		Enabled:    enabled,
+		Pass:       false,
+		NotifyText: text,
+		Details:    d,
+	}, nil
+}
+
+// listUpdates lists the pull requests opened by mc.Authors that are open, or
+// were created after since.
+func listUpdates(ctx context.Context, p pulls, owner, repo string, mc *mergedConfig,
+	since time.Time) ([]*github.PullRequest, error) {
+	authors := make(map[string]bool)
+	for _, a := range mc.Authors {
+		authors[a] = true
+	}
+	var rv []*github.PullRequest
+	for _, state := range []string{"open", "closed"} {
+		opt := &github.PullRequestListOptions{
+			State:       state,
+			Sort:        "created",
+			Direction:   "desc",
+			ListOptions: github.ListOptions{PerPage: 100},
+		}
+	pages:
+		for i := 0; i < maxPages; i++ {
+			prs, rsp, err := p.List(ctx, owner, repo, opt)
+			if err != nil {
+				return nil, err
+			}
+			for _, pr := range prs {
+				if state == "closed" && pr.GetCreatedAt().Before(since) {
+					break pages
+				}
+				if authors[pr.GetUser().GetLogin()] {
+					rv = append(rv, pr)
+				}
+			}
+			if rsp == nil || rsp.NextPage == 0 {
+				break
+			}
+			opt.Page = rsp.NextPage
+		}
+	}
+	return rv, nil
+}
+
+// percentile returns the nearest-rank percentile p of ds.
+func percentile(ds []time.Duration, p int) time.Duration {
+	if len(ds) == 0 {
+		return 0
+	}
+	s := append([]time.Duration{}, ds...)
+	sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+	rank := int(math.Ceil(float64(p) / 100 * float64(len(s))))
+	rank = min(max(rank, 1), len(s))
+	return s[rank-1]
+}
+
+// Fix implementing policydef.Policy.Fix(). Not implemented, merging updates
+// is left to the maintainers.
+func (u UpdateLatency) Fix(ctx context.Context, c *github.Client, owner, repo string) error {
+	log.Warn().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Msg("Action fix is configured, but not implemented.")
+	return nil
+}
+
+// GetAction returns the configured action from Dependency Update Latency's
+// configuration stored in the org-level repo, default log. Implementing
+// policydef.Policy.GetAction()
+func (u UpdateLatency) GetAction(ctx context.Context, c *github.Client, owner, repo string) string {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	mc := mergeConfig(oc, orc, rc, repo)
+	return mc.Action
+}
+
+func getConfig(ctx context.Context, c *github.Client, owner, repo string) (*OrgConfig, *RepoConfig, *RepoConfig) {
+	oc := &OrgConfig{ // Fill out non-zero defaults
+		Action:          "log",
+		Authors:         []string{"dependabot[bot]", "renovate[bot]"},
+		WindowDays:      90,
+		Percentile:      50,
+		MaxDays:         14,
+		MinPullRequests: 3,
+	}
+	if err := configFetchConfig(ctx, c, owner, "", configFile, config.OrgLevel, oc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	orc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.OrgRepoLevel, orc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgRepoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	rc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.RepoLevel, rc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "repoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	return oc, orc, rc
+}
+
+func mergeConfig(oc *OrgConfig, orc, rc *RepoConfig, repo string) *mergedConfig {
+	mc := &mergedConfig{
+		Action:          oc.Action,
+		Authors:         oc.Authors,
+		WindowDays:      oc.WindowDays,
+		Percentile:      oc.Percentile,
+		MaxDays:         oc.MaxDays,
+		MinPullRequests: oc.MinPullRequests,
+	}
+	mc = mergeInRepoConfig(mc, orc, repo)
+
+	if !oc.OptConfig.DisableRepoOverride {
+		mc = mergeInRepoConfig(mc, rc, repo)
+	}
+	return mc
+}
+
+func mergeInRepoConfig(mc *mergedConfig, rc *RepoConfig, repo string) *mergedConfig {
+	if rc.Action != nil {
+		mc.Action = *rc.Action
+	}
+	if rc.MaxDays != nil {
+		mc.MaxDays = *rc.MaxDays
+	}
+	return mc
+}
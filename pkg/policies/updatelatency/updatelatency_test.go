@@ -0,0 +1,314 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package updatelatency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/configtest"
+)
+
+var listPulls func(context.Context, string, string, *github.PullRequestListOptions) (
+	[]*github.PullRequest, *github.Response, error)
+
+type mockPulls struct{}
+
+func (m mockPulls) List(ctx context.Context, o, r string, opt *github.PullRequestListOptions) (
+	[]*github.PullRequest, *github.Response, error) {
+	return listPulls(ctx, o, r, opt)
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Org       OrgConfig
+		OrgRepo   RepoConfig
+		Repo      RepoConfig
+		ExpAction string
+		Exp       mergedConfig
+	}{
+		{
+			Name: "OrgOnly",
+			Org: OrgConfig{
+				Action:     "issue",
+				Authors:    []string{"dependabot[bot]"},
+				WindowDays: 30,
+				Percentile: 90,
+				MaxDays:    7,
+			},
+			OrgRepo:   RepoConfig{},
+			Repo:      RepoConfig{},
+			ExpAction: "issue",
+			Exp: mergedConfig{
+				Action:     "issue",
+				Authors:    []string{"dependabot[bot]"},
+				WindowDays: 30,
+				Percentile: 90,
+				MaxDays:    7,
+			},
+		},
+		{
+			Name: "OrgRepoOverOrg",
+			Org: OrgConfig{
+				Action:  "issue",
+				MaxDays: 7,
+			},
+			OrgRepo: RepoConfig{
+				Action:  github.String("log"),
+				MaxDays: github.Int(30),
+			},
+			Repo:      RepoConfig{},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action:  "log",
+				MaxDays: 30,
+			},
+		},
+		{
+			Name: "RepoOverAllOrg",
+			Org: OrgConfig{
+				Action:  "issue",
+				MaxDays: 7,
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action:  github.String("email"),
+				MaxDays: github.Int(30),
+			},
+			ExpAction: "email",
+			Exp: mergedConfig{
+				Action:  "email",
+				MaxDays: 30,
+			},
+		},
+		{
+			Name: "RepoDisallowed",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					DisableRepoOverride: true,
+				},
+				Action:  "issue",
+				MaxDays: 7,
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action:  github.String("email"),
+				MaxDays: github.Int(30),
+			},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action:  "log",
+				MaxDays: 7,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				switch ol {
+				case config.RepoLevel:
+					rc := out.(*RepoConfig)
+					*rc = test.Repo
+				case config.OrgRepoLevel:
+					orc := out.(*RepoConfig)
+					*orc = test.OrgRepo
+				case config.OrgLevel:
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+
+			u := UpdateLatency(true)
+			ctx := context.Background()
+
+			action := u.GetAction(ctx, nil, "", "thisrepo")
+			if action != test.ExpAction {
+				t.Errorf("Unexpected results. want %s, got %s", test.ExpAction, action)
+			}
+
+			oc, orc, rc := getConfig(ctx, nil, "", "thisrepo")
+			mc := mergeConfig(oc, orc, rc, "thisrepo")
+			if diff := cmp.Diff(&test.Exp, mc); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	t0 := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(d int) *github.Timestamp {
+		return &github.Timestamp{Time: t0.Add(-time.Duration(d) * day)}
+	}
+	pr := func(author string, created, merged int, open bool) *github.PullRequest {
+		p := &github.PullRequest{
+			User:      &github.User{Login: github.String(author)},
+			CreatedAt: daysAgo(created),
+			HTMLURL:   github.String("https://github.com/thisorg/thisrepo/pull/" + author),
+			State:     github.String("closed"),
+		}
+		if open {
+			p.State = github.String("open")
+		} else if merged >= 0 {
+			p.MergedAt = daysAgo(merged)
+		}
+		return p
+	}
+
+	tests := []struct {
+		Name       string
+		Org        OrgConfig
+		Open       []*github.PullRequest
+		Closed     []*github.PullRequest
+		ExpPass    bool
+		ExpDetails details
+	}{
+		{
+			Name: "FastMerges",
+			Closed: []*github.PullRequest{
+				pr("dependabot[bot]", 10, 9, false),
+				pr("dependabot[bot]", 20, 18, false),
+				pr("renovate[bot]", 30, 27, false),
+			},
+			ExpPass: true,
+			ExpDetails: details{
+				PullRequests: 3,
+				LatencyDays:  2,
+			},
+		},
+		{
+			Name: "SlowOpen",
+			Open: []*github.PullRequest{
+				pr("dependabot[bot]", 40, 0, true),
+				pr("dependabot[bot]", 30, 0, true),
+			},
+			Closed: []*github.PullRequest{
+				pr("dependabot[bot]", 20, 18, false),
+				// Closed without merge is not measured.
+				pr("dependabot[bot]", 25, -1, false),
+				// Other authors are not measured.
+				pr("someone", 50, 0, false),
+				// Created before the window, listing stops.
+				pr("renovate[bot]", 100, 99, false),
+			},
+			ExpPass: false,
+			ExpDetails: details{
+				PullRequests: 3,
+				Open:         2,
+				LatencyDays:  30,
+				OldestOpen: []string{
+					"https://github.com/thisorg/thisrepo/pull/dependabot[bot]",
+					"https://github.com/thisorg/thisrepo/pull/dependabot[bot]",
+				},
+			},
+		},
+		{
+			Name: "TooFew",
+			Open: []*github.PullRequest{
+				pr("dependabot[bot]", 60, 0, true),
+			},
+			ExpPass: true,
+			ExpDetails: details{
+				PullRequests: 1,
+				Open:         1,
+				OldestOpen: []string{
+					"https://github.com/thisorg/thisrepo/pull/dependabot[bot]",
+				},
+			},
+		},
+		{
+			Name: "Percentile",
+			Org: OrgConfig{
+				Percentile: 90,
+			},
+			Closed: []*github.PullRequest{
+				pr("dependabot[bot]", 10, 9, false),
+				pr("dependabot[bot]", 20, 19, false),
+				pr("dependabot[bot]", 80, 50, false),
+			},
+			ExpPass: false,
+			ExpDetails: details{
+				PullRequests: 3,
+				LatencyDays:  30,
+			},
+		},
+	}
+
+	configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+		c *github.Client, owner, repo string) (bool, error) {
+		return true, nil
+	}
+	now = func() time.Time { return t0 }
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.OrgLevel && test.Org.Percentile != 0 {
+					oc := out.(*OrgConfig)
+					oc.Percentile = test.Org.Percentile
+				}
+				return nil
+			}
+			listPulls = func(ctx context.Context, o, r string, opt *github.PullRequestListOptions) (
+				[]*github.PullRequest, *github.Response, error) {
+				if opt.Sort != "created" || opt.Direction != "desc" {
+					t.Errorf("Unexpected list options: %v", opt)
+				}
+				if opt.State == "open" {
+					return test.Open, &github.Response{}, nil
+				}
+				return test.Closed, &github.Response{}, nil
+			}
+
+			res, err := check(context.Background(), mockPulls{}, nil, "thisorg", "thisrepo")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Pass != test.ExpPass {
+				t.Errorf("Unexpected pass: %v", res.Pass)
+				t.Logf("NotifyText:\n%s", res.NotifyText)
+			}
+			if diff := cmp.Diff(test.ExpDetails, res.Details); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGeneratedPrecedence(t *testing.T) {
+	configtest.TestPrecedence(t, configtest.Precedence{
+		Org:   OrgConfig{},
+		Repo:  RepoConfig{},
+		Fetch: &configFetchConfig,
+		Merge: func() interface{} {
+			ctx := context.Background()
+			oc, orc, rc := getConfig(ctx, nil, "", configtest.Repo)
+			return mergeConfig(oc, orc, rc, configtest.Repo)
+		},
+	})
+}
@@ -0,0 +1,364 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vulnalerts implements the Vulnerability Alerts security policy. It
+// checks that Dependabot alerts and Dependabot security updates are enabled.
+package vulnalerts
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ossf/allstar/pkg/cache"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/policydef"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+const configFile = "vulnerability_alerts.yaml"
+const polName = "Vulnerability Alerts"
+
+const notifyText = `This policy requires that Dependabot alerts, and Dependabot security updates, are enabled so that vulnerable dependencies of this repository are reported and fixed.
+
+To fix this, from the main page of the repository go to Settings -> Code security, and enable "Dependabot alerts" and "Dependabot security updates".
+(For more information, see https://docs.github.com/en/code-security/dependabot/dependabot-alerts/configuring-dependabot-alerts)`
+
+// OrgConfig is the org-level config definition for Vulnerability Alerts.
+type OrgConfig struct {
+	// OptConfig is the standard org-level opt in/out config, RepoOverride
+	// applies to all config.
+	OptConfig config.OrgOptConfig `json:"optConfig"`
+
+	// Action defines which action to take, default log, other: issue...
+	Action string `json:"action"`
+
+	// RequireSecurityUpdates : set to true to also require Dependabot security
+	// updates (automated security fixes), default true.
+	RequireSecurityUpdates bool `json:"requireSecurityUpdates"`
+
+	// Exemptions is a list of repo names exempt from this policy. Globs are
+	// allowed. Exemptions are only defined at the org level because they
+	// should be made obvious to org security managers.
+	Exemptions []string `json:"exemptions"`
+}
+
+// RepoConfig is the repo-level config for Vulnerability Alerts.
+type RepoConfig struct {
+	// OptConfig is the standard repo-level opt in/out config.
+	OptConfig config.RepoOptConfig `json:"optConfig"`
+
+	// Action overrides the same setting in org-level, only if present.
+	Action *string `json:"action"`
+
+	// RequireSecurityUpdates overrides the same setting in org-level, only if
+	// present.
+	RequireSecurityUpdates *bool `json:"requireSecurityUpdates"`
+}
+
+type mergedConfig struct {
+	Action                 string
+	RequireSecurityUpdates bool
+	Exemptions             []string
+}
+
+type details struct {
+	Exempt                bool
+	VulnerabilityAlerts   bool
+	SecurityUpdates       bool
+	SecurityUpdatesPaused bool
+}
+
+var gc = cache.NewGlobCache(cache.DefaultSize)
+
+var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+
+var configIsEnabled func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig, c *github.Client, owner, repo string) (bool, error)
+
+func init() {
+	configFetchConfig = config.FetchConfig
+	configIsEnabled = config.IsEnabled
+}
+
+type repositories interface {
+	GetVulnerabilityAlerts(context.Context, string, string) (bool,
+		*github.Response, error)
+	EnableVulnerabilityAlerts(context.Context, string, string) (
+		*github.Response, error)
+	GetAutomatedSecurityFixes(context.Context, string, string) (
+		*github.AutomatedSecurityFixes, *github.Response, error)
+	EnableAutomatedSecurityFixes(context.Context, string, string) (
+		*github.Response, error)
+}
+
+// VulnAlerts is the Vulnerability Alerts policy object, implements
+// policydef.Policy.
+type VulnAlerts bool
+
+// NewVulnAlerts returns a new Vulnerability Alerts policy.
+func NewVulnAlerts() policydef.Policy {
+	var v VulnAlerts
+	return v
+}
+
+// Name returns the name of this policy, implementing policydef.Policy.Name()
+func (v VulnAlerts) Name() string {
+	return polName
+}
+
+// Check whether this policy is enabled or not
+func (v VulnAlerts) IsEnabled(ctx context.Context, c *github.Client, owner, repo string) (bool, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	return configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+}
+
+// Check performs the policy check for Vulnerability Alerts based on the
+// configuration stored in the org/repo, implementing policydef.Policy.Check()
+func (v VulnAlerts) Check(ctx context.Context, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	return check(ctx, c.Repositories, c, owner, repo)
+}
+
+func check(ctx context.Context, rep repositories, c *github.Client, owner,
+	repo string) (*policydef.Result, error) {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", polName).
+		Bool("enabled", enabled).
+		Msg("Check repo enabled")
+
+	mc := mergeConfig(oc, orc, rc, repo)
+
+	var d details
+	if isExempt(repo, mc.Exemptions, gc) {
+		d.Exempt = true
+		return &policydef.Result{
+			Enabled:    enabled,
+			Pass:       true,
+			NotifyText: "",
+			Details:    d,
+		}, nil
+	}
+
+	d, err = getDetails(ctx, rep, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	pass := true
+	text := ""
+	if !d.VulnerabilityAlerts {
+		pass = false
+		text = text + "Dependabot alerts are not enabled.\n"
+	}
+	if mc.RequireSecurityUpdates && (!d.SecurityUpdates || d.SecurityUpdatesPaused) {
+		pass = false
+		text = text + "Dependabot security updates are not enabled.\n"
+	}
+	if !pass {
+		text = text + "\n" + notifyText
+	}
+
+	return &policydef.Result{
+		Enabled:    enabled,
+		Pass:       pass,
+		NotifyText: text,
+		Details:    d,
+	}, nil
+}
+
+func getDetails(ctx context.Context, rep repositories, owner, repo string) (details, error) {
+	var d details
+	va, _, err := rep.GetVulnerabilityAlerts(ctx, owner, repo)
+	if err != nil {
+		return d, err
+	}
+	d.VulnerabilityAlerts = va
+	asf, rsp, err := rep.GetAutomatedSecurityFixes(ctx, owner, repo)
+	if err != nil {
+		if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+			// Not enabled, or not available without vulnerability alerts.
+			return d, nil
+		}
+		return d, err
+	}
+	d.SecurityUpdates = asf.GetEnabled()
+	d.SecurityUpdatesPaused = asf.GetPaused()
+	return d, nil
+}
+
+// Fix implementing policydef.Policy.Fix(). Enables Dependabot alerts, and
+// security updates if required.
+func (v VulnAlerts) Fix(ctx context.Context, c *github.Client, owner, repo string) error {
+	return fix(ctx, c.Repositories, c, owner, repo)
+}
+
+func fix(ctx context.Context, rep repositories, c *github.Client, owner,
+	repo string) error {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	enabled, err := configIsEnabled(ctx, oc.OptConfig, orc.OptConfig, rc.OptConfig, c, owner, repo)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return nil
+	}
+	mc := mergeConfig(oc, orc, rc, repo)
+	if isExempt(repo, mc.Exemptions, gc) {
+		return nil
+	}
+
+	d, err := getDetails(ctx, rep, owner, repo)
+	if err != nil {
+		return err
+	}
+	if !d.VulnerabilityAlerts {
+		if rsp, err := rep.EnableVulnerabilityAlerts(ctx, owner, repo); err != nil {
+			if rsp != nil && (rsp.StatusCode == http.StatusForbidden || rsp.StatusCode == http.StatusUnprocessableEntity) {
+				log.Warn().
+					Str("org", owner).
+					Str("repo", repo).
+					Str("area", polName).
+					Err(err).
+					Msg("Action set to fix, but Dependabot alerts could not be enabled.")
+				return nil
+			}
+			return err
+		}
+		log.Info().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", polName).
+			Msg("Enabled Dependabot alerts with Fix action.")
+	}
+	// Security updates can't be unpaused through the API, leave paused to
+	// the repo admins.
+	if mc.RequireSecurityUpdates && !d.SecurityUpdates {
+		if rsp, err := rep.EnableAutomatedSecurityFixes(ctx, owner, repo); err != nil {
+			if rsp != nil && (rsp.StatusCode == http.StatusForbidden || rsp.StatusCode == http.StatusUnprocessableEntity) {
+				log.Warn().
+					Str("org", owner).
+					Str("repo", repo).
+					Str("area", polName).
+					Err(err).
+					Msg("Action set to fix, but Dependabot security updates could not be enabled.")
+				return nil
+			}
+			return err
+		}
+		log.Info().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", polName).
+			Msg("Enabled Dependabot security updates with Fix action.")
+	}
+	return nil
+}
+
+// GetAction returns the configured action from Vulnerability Alerts'
+// configuration stored in the org-level repo, default log. Implementing
+// policydef.Policy.GetAction()
+func (v VulnAlerts) GetAction(ctx context.Context, c *github.Client, owner, repo string) string {
+	oc, orc, rc := getConfig(ctx, c, owner, repo)
+	mc := mergeConfig(oc, orc, rc, repo)
+	return mc.Action
+}
+
+func getConfig(ctx context.Context, c *github.Client, owner, repo string) (*OrgConfig, *RepoConfig, *RepoConfig) {
+	oc := &OrgConfig{ // Fill out non-zero defaults
+		Action:                 "log",
+		RequireSecurityUpdates: true,
+	}
+	if err := configFetchConfig(ctx, c, owner, "", configFile, config.OrgLevel, oc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	orc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.OrgRepoLevel, orc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgRepoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	rc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.RepoLevel, rc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "repoLevel").
+			Str("area", polName).
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	return oc, orc, rc
+}
+
+func mergeConfig(oc *OrgConfig, orc, rc *RepoConfig, repo string) *mergedConfig {
+	mc := &mergedConfig{
+		Action:                 oc.Action,
+		RequireSecurityUpdates: oc.RequireSecurityUpdates,
+		Exemptions:             oc.Exemptions,
+	}
+	mc = mergeInRepoConfig(mc, orc, repo)
+
+	if !oc.OptConfig.DisableRepoOverride {
+		mc = mergeInRepoConfig(mc, rc, repo)
+	}
+	return mc
+}
+
+func mergeInRepoConfig(mc *mergedConfig, rc *RepoConfig, repo string) *mergedConfig {
+	if rc.Action != nil {
+		mc.Action = *rc.Action
+	}
+	if rc.RequireSecurityUpdates != nil {
+		mc.RequireSecurityUpdates = *rc.RequireSecurityUpdates
+	}
+	return mc
+}
+
+func isExempt(repo string, ee []string, gc *cache.GlobCache) bool {
+	for _, e := range ee {
+		g, err := gc.Compile(e)
+		if err != nil {
+			log.Warn().
+				Str("repo", repo).
+				Str("area", polName).
+				Str("glob", e).
+				Err(err).
+				Msg("Unexpected error compiling the glob.")
+		} else if g.Match(repo) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,411 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulnalerts
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/configtest"
+)
+
+var getVulnerabilityAlerts func(context.Context, string, string) (bool,
+	*github.Response, error)
+var enableVulnerabilityAlerts func(context.Context, string, string) (
+	*github.Response, error)
+var getAutomatedSecurityFixes func(context.Context, string, string) (
+	*github.AutomatedSecurityFixes, *github.Response, error)
+var enableAutomatedSecurityFixes func(context.Context, string, string) (
+	*github.Response, error)
+
+type mockRepos struct{}
+
+func (m mockRepos) GetVulnerabilityAlerts(ctx context.Context, o, r string) (bool,
+	*github.Response, error) {
+	return getVulnerabilityAlerts(ctx, o, r)
+}
+
+func (m mockRepos) EnableVulnerabilityAlerts(ctx context.Context, o, r string) (
+	*github.Response, error) {
+	return enableVulnerabilityAlerts(ctx, o, r)
+}
+
+func (m mockRepos) GetAutomatedSecurityFixes(ctx context.Context, o, r string) (
+	*github.AutomatedSecurityFixes, *github.Response, error) {
+	return getAutomatedSecurityFixes(ctx, o, r)
+}
+
+func (m mockRepos) EnableAutomatedSecurityFixes(ctx context.Context, o, r string) (
+	*github.Response, error) {
+	return enableAutomatedSecurityFixes(ctx, o, r)
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Org       OrgConfig
+		OrgRepo   RepoConfig
+		Repo      RepoConfig
+		ExpAction string
+		Exp       mergedConfig
+	}{
+		{
+			Name: "OrgOnly",
+			Org: OrgConfig{
+				Action:                 "issue",
+				RequireSecurityUpdates: true,
+				Exemptions:             []string{"sandbox-*"},
+			},
+			OrgRepo:   RepoConfig{},
+			Repo:      RepoConfig{},
+			ExpAction: "issue",
+			Exp: mergedConfig{
+				Action:                 "issue",
+				RequireSecurityUpdates: true,
+				Exemptions:             []string{"sandbox-*"},
+			},
+		},
+		{
+			Name: "OrgRepoOverOrg",
+			Org: OrgConfig{
+				Action:                 "issue",
+				RequireSecurityUpdates: true,
+			},
+			OrgRepo: RepoConfig{
+				Action:                 github.String("log"),
+				RequireSecurityUpdates: github.Bool(false),
+			},
+			Repo:      RepoConfig{},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action: "log",
+			},
+		},
+		{
+			Name: "RepoOverAllOrg",
+			Org: OrgConfig{
+				Action: "issue",
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action:                 github.String("email"),
+				RequireSecurityUpdates: github.Bool(true),
+			},
+			ExpAction: "email",
+			Exp: mergedConfig{
+				Action:                 "email",
+				RequireSecurityUpdates: true,
+			},
+		},
+		{
+			Name: "RepoDisallowed",
+			Org: OrgConfig{
+				OptConfig: config.OrgOptConfig{
+					DisableRepoOverride: true,
+				},
+				Action:                 "issue",
+				RequireSecurityUpdates: true,
+			},
+			OrgRepo: RepoConfig{
+				Action: github.String("log"),
+			},
+			Repo: RepoConfig{
+				Action:                 github.String("email"),
+				RequireSecurityUpdates: github.Bool(false),
+			},
+			ExpAction: "log",
+			Exp: mergedConfig{
+				Action:                 "log",
+				RequireSecurityUpdates: true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				switch ol {
+				case config.RepoLevel:
+					rc := out.(*RepoConfig)
+					*rc = test.Repo
+				case config.OrgRepoLevel:
+					orc := out.(*RepoConfig)
+					*orc = test.OrgRepo
+				case config.OrgLevel:
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+
+			v := VulnAlerts(true)
+			ctx := context.Background()
+
+			action := v.GetAction(ctx, nil, "", "thisrepo")
+			if action != test.ExpAction {
+				t.Errorf("Unexpected results. want %s, got %s", test.ExpAction, action)
+			}
+
+			oc, orc, rc := getConfig(ctx, nil, "", "thisrepo")
+			mc := mergeConfig(oc, orc, rc, "thisrepo")
+			if diff := cmp.Diff(&test.Exp, mc); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Org        OrgConfig
+		Alerts     bool
+		Fixes      *github.AutomatedSecurityFixes
+		ExpPass    bool
+		ExpDetails details
+	}{
+		{
+			Name:   "AllEnabled",
+			Org:    OrgConfig{RequireSecurityUpdates: true},
+			Alerts: true,
+			Fixes: &github.AutomatedSecurityFixes{
+				Enabled: github.Bool(true),
+				Paused:  github.Bool(false),
+			},
+			ExpPass: true,
+			ExpDetails: details{
+				VulnerabilityAlerts: true,
+				SecurityUpdates:     true,
+			},
+		},
+		{
+			Name:    "AlertsDisabled",
+			Org:     OrgConfig{RequireSecurityUpdates: true},
+			Alerts:  false,
+			Fixes:   nil,
+			ExpPass: false,
+			ExpDetails: details{
+				VulnerabilityAlerts: false,
+			},
+		},
+		{
+			Name:    "SecurityUpdatesNotRequired",
+			Org:     OrgConfig{},
+			Alerts:  true,
+			Fixes:   nil,
+			ExpPass: true,
+			ExpDetails: details{
+				VulnerabilityAlerts: true,
+			},
+		},
+		{
+			Name:   "SecurityUpdatesPaused",
+			Org:    OrgConfig{RequireSecurityUpdates: true},
+			Alerts: true,
+			Fixes: &github.AutomatedSecurityFixes{
+				Enabled: github.Bool(true),
+				Paused:  github.Bool(true),
+			},
+			ExpPass: false,
+			ExpDetails: details{
+				VulnerabilityAlerts:   true,
+				SecurityUpdates:       true,
+				SecurityUpdatesPaused: true,
+			},
+		},
+		{
+			Name: "Exempt",
+			Org: OrgConfig{
+				RequireSecurityUpdates: true,
+				Exemptions:             []string{"this*"},
+			},
+			Alerts:  false,
+			ExpPass: true,
+			ExpDetails: details{
+				Exempt: true,
+			},
+		},
+	}
+
+	configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+		c *github.Client, owner, repo string) (bool, error) {
+		return true, nil
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.OrgLevel {
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+			getVulnerabilityAlerts = func(context.Context, string, string) (bool,
+				*github.Response, error) {
+				return test.Alerts, nil, nil
+			}
+			getAutomatedSecurityFixes = func(context.Context, string, string) (
+				*github.AutomatedSecurityFixes, *github.Response, error) {
+				if test.Fixes == nil {
+					return nil, &github.Response{
+						Response: &http.Response{StatusCode: http.StatusNotFound},
+					}, errors.New("404")
+				}
+				return test.Fixes, nil, nil
+			}
+
+			res, err := check(context.Background(), mockRepos{}, nil, "", "thisrepo")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Pass != test.ExpPass {
+				t.Errorf("Unexpected pass: %v, notify text: %v", res.Pass, res.NotifyText)
+			}
+			if diff := cmp.Diff(test.ExpDetails, res.Details); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFix(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Org        OrgConfig
+		Alerts     bool
+		Fixes      *github.AutomatedSecurityFixes
+		EnableCode int
+		ExpAlerts  bool
+		ExpFixes   bool
+	}{
+		{
+			Name:   "NoChange",
+			Org:    OrgConfig{RequireSecurityUpdates: true},
+			Alerts: true,
+			Fixes: &github.AutomatedSecurityFixes{
+				Enabled: github.Bool(true),
+			},
+		},
+		{
+			Name:      "EnableBoth",
+			Org:       OrgConfig{RequireSecurityUpdates: true},
+			Alerts:    false,
+			ExpAlerts: true,
+			ExpFixes:  true,
+		},
+		{
+			Name:      "EnableAlertsOnly",
+			Org:       OrgConfig{},
+			Alerts:    false,
+			ExpAlerts: true,
+		},
+		{
+			Name:       "Forbidden",
+			Org:        OrgConfig{RequireSecurityUpdates: true},
+			Alerts:     false,
+			EnableCode: http.StatusForbidden,
+			ExpAlerts:  true,
+		},
+		{
+			Name: "Exempt",
+			Org: OrgConfig{
+				RequireSecurityUpdates: true,
+				Exemptions:             []string{"thisrepo"},
+			},
+			Alerts: false,
+		},
+	}
+
+	configIsEnabled = func(ctx context.Context, o config.OrgOptConfig, orc, r config.RepoOptConfig,
+		c *github.Client, owner, repo string) (bool, error) {
+		return true, nil
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client,
+				owner, repo, path string, ol config.ConfigLevel, out interface{}) error {
+				if ol == config.OrgLevel {
+					oc := out.(*OrgConfig)
+					*oc = test.Org
+				}
+				return nil
+			}
+			getVulnerabilityAlerts = func(context.Context, string, string) (bool,
+				*github.Response, error) {
+				return test.Alerts, nil, nil
+			}
+			getAutomatedSecurityFixes = func(context.Context, string, string) (
+				*github.AutomatedSecurityFixes, *github.Response, error) {
+				if test.Fixes == nil {
+					return nil, &github.Response{
+						Response: &http.Response{StatusCode: http.StatusNotFound},
+					}, errors.New("404")
+				}
+				return test.Fixes, nil, nil
+			}
+			gotAlerts := false
+			enableVulnerabilityAlerts = func(context.Context, string, string) (
+				*github.Response, error) {
+				gotAlerts = true
+				if test.EnableCode != 0 {
+					return &github.Response{
+						Response: &http.Response{StatusCode: test.EnableCode},
+					}, errors.New("error")
+				}
+				return nil, nil
+			}
+			gotFixes := false
+			enableAutomatedSecurityFixes = func(context.Context, string, string) (
+				*github.Response, error) {
+				gotFixes = true
+				return nil, nil
+			}
+
+			if err := fix(context.Background(), mockRepos{}, nil, "", "thisrepo"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if gotAlerts != test.ExpAlerts {
+				t.Errorf("Unexpected enable alerts, want %v got %v", test.ExpAlerts, gotAlerts)
+			}
+			if gotFixes != test.ExpFixes {
+				t.Errorf("Unexpected enable security updates, want %v got %v", test.ExpFixes, gotFixes)
+			}
+		})
+	}
+}
+
+func TestGeneratedPrecedence(t *testing.T) {
+	configtest.TestPrecedence(t, configtest.Precedence{
+		Org:   OrgConfig{},
+		Repo:  RepoConfig{},
+		Fetch: &configFetchConfig,
+		Merge: func() interface{} {
+			ctx := context.Background()
+			oc, orc, rc := getConfig(ctx, nil, "", configtest.Repo)
+			return mergeConfig(oc, orc, rc, configtest.Repo)
+		},
+	})
+}
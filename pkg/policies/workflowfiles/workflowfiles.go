@@ -0,0 +1,110 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package workflowfiles reads the GitHub Actions workflow files of a repo, for
+// the policies that check them.
+package workflowfiles
+
+import (
+	"context"
+	"net/http"
+	"path"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rhysd/actionlint"
+	"github.com/rs/zerolog/log"
+)
+
+// Dir is the directory workflow files are read from.
+const Dir = ".github/workflows"
+
+// MaxFiles is the maximum number of workflow files read.
+const MaxFiles = 50
+
+// File is a workflow file.
+type File struct {
+	// Path is the path of the file in the repo.
+	Path string
+	// Content is the content of the file.
+	Content string
+}
+
+// Workflow is a parsed workflow file.
+type Workflow struct {
+	// Path is the path of the file in the repo.
+	Path     string
+	Workflow *actionlint.Workflow
+}
+
+// List returns the workflow files of a repo, up to MaxFiles, or none if it
+// has no workflows directory.
+func List(ctx context.Context, c *github.Client, owner, repo string) ([]File, error) {
+	_, dir, rsp, err := c.Repositories.GetContents(ctx, owner, repo, Dir, nil)
+	if err != nil {
+		if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(dir) > MaxFiles {
+		dir = dir[:MaxFiles]
+	}
+	var fs []File
+	for _, f := range dir {
+		if f.GetType() != "file" {
+			continue
+		}
+		if ext := path.Ext(f.GetName()); ext != ".yml" && ext != ".yaml" {
+			continue
+		}
+		fc, _, _, err := c.Repositories.GetContents(ctx, owner, repo, f.GetPath(), nil)
+		if err != nil {
+			return nil, err
+		}
+		content, err := fc.GetContent()
+		if err != nil {
+			return nil, err
+		}
+		fs = append(fs, File{Path: f.GetPath(), Content: content})
+	}
+	return fs, nil
+}
+
+// ListParsed returns the parsed workflow files of a repo, see List. Files that
+// can not be parsed are skipped, and logged under area, the policy name.
+func ListParsed(ctx context.Context, c *github.Client, owner, repo, area string) ([]*Workflow, error) {
+	fs, err := List(ctx, c, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	var wfs []*Workflow
+	for _, f := range fs {
+		wf, errs := actionlint.Parse([]byte(f.Content))
+		if wf == nil {
+			log.Warn().
+				Str("org", owner).
+				Str("repo", repo).
+				Str("area", area).
+				Str("path", f.Path).
+				Int("errors", len(errs)).
+				Msg("Unable to parse workflow file, skipping.")
+			continue
+		}
+		wfs = append(wfs, &Workflow{
+			Path:     f.Path,
+			Workflow: wf,
+		})
+	}
+	return wfs, nil
+}
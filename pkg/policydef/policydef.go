@@ -45,6 +45,12 @@ type Result struct {
 	// Details are logged on failure. it should be serializable to json and allow
 	// useful log querying.
 	Details interface{}
+
+	// APICalls is the number of GitHub API requests made to evaluate the
+	// policy, and APICost is how many of them counted against the rate
+	// limit. They are set by Allstar, policies do not need to set them.
+	APICalls int
+	APICost  int
 }
 
 // Policy is the interface that policies must implement to be included in
@@ -72,3 +78,28 @@ type Policy interface {
 	// validation is needed by the policy, it will be done centrally.
 	GetAction(ctx context.Context, c *github.Client, owner, repo string) string
 }
+
+// OrgPolicy is the interface that org-scope policies must implement to be
+// included in Allstar. Org-scope policies check the settings of an
+// organization, rather than of its repositories, so they are run once per
+// organization on each enforcement run. Their config is only read from the
+// org-level config repository.
+type OrgPolicy interface {
+
+	// Name must return the human readable name of the policy.
+	Name() string
+
+	// Check whether this policy is enabled on the organization or not.
+	IsEnabled(ctx context.Context, c *github.Client, owner string) (bool, error)
+
+	// Check checks whether the provided organization is in compliance with the
+	// policy or not. See Result for more details on the return value.
+	Check(ctx context.Context, c *github.Client, owner string) (*Result, error)
+
+	// Fix should modify the organization settings to be in compliance with the
+	// policy. Fix is optional and the policy may simply return.
+	Fix(ctx context.Context, c *github.Client, owner string) error
+
+	// GetAction must return the configured action from the policy's config.
+	GetAction(ctx context.Context, c *github.Client, owner string) string
+}
@@ -0,0 +1,281 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pullrequest handles opening pull requests that propose file changes
+// for policy Fix actions.
+package pullrequest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+// File is a file to write on the pull request branch.
+type File struct {
+	// Path is the path of the file in the repository.
+	Path string
+
+	// Content is the full new content of the file.
+	Content []byte
+
+	// Delete deletes the file instead, Content is ignored.
+	Delete bool
+}
+
+// Request describes a pull request to open.
+type Request struct {
+	// Branch is the branch the changes are committed to, eg:
+	// "allstar/security-policy". It is created from the default branch if it
+	// does not exist.
+	Branch string
+
+	// Title is the title of the pull request.
+	Title string
+
+	// Body is the description of the pull request.
+	Body string
+
+	// Message is the commit message used for each file, defaults to Title.
+	Message string
+
+	// Files are the files to create, update, or delete on Branch.
+	Files []File
+
+	// ReopenDeclined opens a new pull request even if the last one from
+	// Branch was closed without merging. By default a declined change is not
+	// proposed again.
+	ReopenDeclined bool
+}
+
+// bodyFooter is appended to the pull request body, with the policy name, so
+// that maintainers know where the change comes from.
+const bodyFooter = "\n\n---\n_This pull request was opened by the Allstar %s policy fix action. If this change is not wanted, close the pull request and Allstar will not propose it again._"
+
+// repositories is the subset of the GitHub API used, spanning several
+// go-github services.
+type repositories interface {
+	Get(context.Context, string, string) (*github.Repository,
+		*github.Response, error)
+	GetContents(context.Context, string, string, string,
+		*github.RepositoryContentGetOptions) (*github.RepositoryContent,
+		[]*github.RepositoryContent, *github.Response, error)
+	CreateFile(context.Context, string, string, string,
+		*github.RepositoryContentFileOptions) (*github.RepositoryContentResponse,
+		*github.Response, error)
+	UpdateFile(context.Context, string, string, string,
+		*github.RepositoryContentFileOptions) (*github.RepositoryContentResponse,
+		*github.Response, error)
+	DeleteFile(context.Context, string, string, string,
+		*github.RepositoryContentFileOptions) (*github.RepositoryContentResponse,
+		*github.Response, error)
+	GetRef(context.Context, string, string, string) (*github.Reference,
+		*github.Response, error)
+	CreateRef(context.Context, string, string, *github.Reference) (
+		*github.Reference, *github.Response, error)
+	ListPullRequests(context.Context, string, string,
+		*github.PullRequestListOptions) ([]*github.PullRequest,
+		*github.Response, error)
+	CreatePullRequest(context.Context, string, string, *github.NewPullRequest) (
+		*github.PullRequest, *github.Response, error)
+}
+
+// Ensure ensures a pull request is open from the requested branch to the
+// default branch of the provided repo. If one is already open, nothing is
+// changed, so that maintainers' edits to the branch are kept. If the last one
+// was closed without merging, nothing is changed either, unless
+// ReopenDeclined is set. Otherwise the branch is created if needed, the files
+// are written to it, and the pull request is opened. The policy name is used
+// for logging, and in the pull request body.
+//
+// Returns the opened pull request, or nil if none was opened. If the
+// installation does not have the contents:write permission a warning is logged
+// and no error is returned.
+func Ensure(ctx context.Context, c *github.Client, owner, repo, policy string, pr *Request) (*github.PullRequest, error) {
+	return ensure(ctx, reposClient{c}, owner, repo, policy, pr)
+}
+
+func ensure(ctx context.Context, rep repositories, owner, repo, policy string, pr *Request) (*github.PullRequest, error) {
+	status, last, err := getStatus(ctx, rep, owner, repo, pr.Branch)
+	if err != nil {
+		return nil, err
+	}
+	if status == StatusOpen {
+		return nil, nil
+	}
+	if status == StatusClosed && !pr.ReopenDeclined {
+		log.Info().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("area", policy).
+			Int("pr", last.GetNumber()).
+			Msg("Fix pull request was closed without merging, not opening again.")
+		return nil, nil
+	}
+
+	r, _, err := rep.Get(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	base := r.GetDefaultBranch()
+	ref, _, err := rep.GetRef(ctx, owner, repo, "heads/"+base)
+	if err != nil {
+		return nil, err
+	}
+	_, rsp, err := rep.CreateRef(ctx, owner, repo, &github.Reference{
+		Ref:    github.String("refs/heads/" + pr.Branch),
+		Object: ref.GetObject(),
+	})
+	if err != nil && (rsp == nil || rsp.StatusCode != http.StatusUnprocessableEntity) {
+		// 422 is an existing branch from an earlier attempt, reuse it.
+		if rsp != nil && rsp.StatusCode == http.StatusForbidden {
+			log.Warn().
+				Str("org", owner).
+				Str("repo", repo).
+				Str("area", policy).
+				Err(err).
+				Msg("Action set to fix, but did not accept contents:write permissions update.")
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	message := pr.Message
+	if message == "" {
+		message = pr.Title
+	}
+	for _, f := range pr.Files {
+		if err := writeFile(ctx, rep, owner, repo, pr.Branch, message, f); err != nil {
+			return nil, err
+		}
+	}
+
+	opened, _, err := rep.CreatePullRequest(ctx, owner, repo, &github.NewPullRequest{
+		Title: github.String(pr.Title),
+		Head:  github.String(pr.Branch),
+		Base:  github.String(base),
+		Body:  github.String(pr.Body + fmt.Sprintf(bodyFooter, policy)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Info().
+		Str("org", owner).
+		Str("repo", repo).
+		Str("area", policy).
+		Int("pr", opened.GetNumber()).
+		Msg("Opened pull request with Fix action.")
+	return opened, nil
+}
+
+// writeFile creates, updates, or deletes f on branch, unless it already has
+// the requested content.
+func writeFile(ctx context.Context, rep repositories, owner, repo, branch, message string, f File) error {
+	opt := &github.RepositoryContentFileOptions{
+		Message: github.String(message),
+		Content: f.Content,
+		Branch:  github.String(branch),
+	}
+	fc, _, rsp, err := rep.GetContents(ctx, owner, repo, f.Path, &github.RepositoryContentGetOptions{
+		Ref: branch,
+	})
+	if err != nil {
+		if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+			if f.Delete {
+				return nil
+			}
+			_, _, err := rep.CreateFile(ctx, owner, repo, f.Path, opt)
+			return err
+		}
+		return err
+	}
+	if fc == nil {
+		return fmt.Errorf("%v is a directory", f.Path)
+	}
+	if f.Delete {
+		opt.Content = nil
+		opt.SHA = fc.SHA
+		_, _, err = rep.DeleteFile(ctx, owner, repo, f.Path, opt)
+		return err
+	}
+	existing, err := fc.GetContent()
+	if err != nil {
+		return err
+	}
+	if bytes.Equal([]byte(existing), f.Content) {
+		return nil
+	}
+	opt.SHA = fc.SHA
+	_, _, err = rep.UpdateFile(ctx, owner, repo, f.Path, opt)
+	return err
+}
+
+// reposClient implements repositories with a GitHub client.
+type reposClient struct {
+	c *github.Client
+}
+
+func (r reposClient) Get(ctx context.Context, owner, repo string) (
+	*github.Repository, *github.Response, error) {
+	return r.c.Repositories.Get(ctx, owner, repo)
+}
+
+func (r reposClient) GetContents(ctx context.Context, owner, repo, path string,
+	opt *github.RepositoryContentGetOptions) (*github.RepositoryContent,
+	[]*github.RepositoryContent, *github.Response, error) {
+	return r.c.Repositories.GetContents(ctx, owner, repo, path, opt)
+}
+
+func (r reposClient) CreateFile(ctx context.Context, owner, repo, path string,
+	opt *github.RepositoryContentFileOptions) (*github.RepositoryContentResponse,
+	*github.Response, error) {
+	return r.c.Repositories.CreateFile(ctx, owner, repo, path, opt)
+}
+
+func (r reposClient) UpdateFile(ctx context.Context, owner, repo, path string,
+	opt *github.RepositoryContentFileOptions) (*github.RepositoryContentResponse,
+	*github.Response, error) {
+	return r.c.Repositories.UpdateFile(ctx, owner, repo, path, opt)
+}
+
+func (r reposClient) DeleteFile(ctx context.Context, owner, repo, path string,
+	opt *github.RepositoryContentFileOptions) (*github.RepositoryContentResponse,
+	*github.Response, error) {
+	return r.c.Repositories.DeleteFile(ctx, owner, repo, path, opt)
+}
+
+func (r reposClient) GetRef(ctx context.Context, owner, repo, ref string) (
+	*github.Reference, *github.Response, error) {
+	return r.c.Git.GetRef(ctx, owner, repo, ref)
+}
+
+func (r reposClient) CreateRef(ctx context.Context, owner, repo string,
+	ref *github.Reference) (*github.Reference, *github.Response, error) {
+	return r.c.Git.CreateRef(ctx, owner, repo, ref)
+}
+
+func (r reposClient) ListPullRequests(ctx context.Context, owner, repo string,
+	opt *github.PullRequestListOptions) ([]*github.PullRequest,
+	*github.Response, error) {
+	return r.c.PullRequests.List(ctx, owner, repo, opt)
+}
+
+func (r reposClient) CreatePullRequest(ctx context.Context, owner, repo string,
+	pr *github.NewPullRequest) (*github.PullRequest, *github.Response, error) {
+	return r.c.PullRequests.Create(ctx, owner, repo, pr)
+}
@@ -0,0 +1,290 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pullrequest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+)
+
+var getContents func(context.Context, string, string, string,
+	*github.RepositoryContentGetOptions) (*github.RepositoryContent,
+	[]*github.RepositoryContent, *github.Response, error)
+var createFile func(context.Context, string, string, string,
+	*github.RepositoryContentFileOptions) (*github.RepositoryContentResponse,
+	*github.Response, error)
+var updateFile func(context.Context, string, string, string,
+	*github.RepositoryContentFileOptions) (*github.RepositoryContentResponse,
+	*github.Response, error)
+var deleteFile func(context.Context, string, string, string,
+	*github.RepositoryContentFileOptions) (*github.RepositoryContentResponse,
+	*github.Response, error)
+var createRef func(context.Context, string, string, *github.Reference) (
+	*github.Reference, *github.Response, error)
+var listPullRequests func(context.Context, string, string,
+	*github.PullRequestListOptions) ([]*github.PullRequest,
+	*github.Response, error)
+var createPullRequest func(context.Context, string, string, *github.NewPullRequest) (
+	*github.PullRequest, *github.Response, error)
+
+type mockRepos struct{}
+
+func (m mockRepos) Get(ctx context.Context, o, r string) (*github.Repository,
+	*github.Response, error) {
+	return &github.Repository{DefaultBranch: github.String("main")}, nil, nil
+}
+
+func (m mockRepos) GetContents(ctx context.Context, o, r, p string,
+	opt *github.RepositoryContentGetOptions) (*github.RepositoryContent,
+	[]*github.RepositoryContent, *github.Response, error) {
+	return getContents(ctx, o, r, p, opt)
+}
+
+func (m mockRepos) CreateFile(ctx context.Context, o, r, p string,
+	opt *github.RepositoryContentFileOptions) (*github.RepositoryContentResponse,
+	*github.Response, error) {
+	return createFile(ctx, o, r, p, opt)
+}
+
+func (m mockRepos) UpdateFile(ctx context.Context, o, r, p string,
+	opt *github.RepositoryContentFileOptions) (*github.RepositoryContentResponse,
+	*github.Response, error) {
+	return updateFile(ctx, o, r, p, opt)
+}
+
+func (m mockRepos) DeleteFile(ctx context.Context, o, r, p string,
+	opt *github.RepositoryContentFileOptions) (*github.RepositoryContentResponse,
+	*github.Response, error) {
+	return deleteFile(ctx, o, r, p, opt)
+}
+
+func (m mockRepos) GetRef(ctx context.Context, o, r, ref string) (*github.Reference,
+	*github.Response, error) {
+	return &github.Reference{Object: &github.GitObject{SHA: github.String("abc")}}, nil, nil
+}
+
+func (m mockRepos) CreateRef(ctx context.Context, o, r string, ref *github.Reference) (
+	*github.Reference, *github.Response, error) {
+	return createRef(ctx, o, r, ref)
+}
+
+func (m mockRepos) ListPullRequests(ctx context.Context, o, r string,
+	opt *github.PullRequestListOptions) ([]*github.PullRequest,
+	*github.Response, error) {
+	return listPullRequests(ctx, o, r, opt)
+}
+
+func (m mockRepos) CreatePullRequest(ctx context.Context, o, r string,
+	pr *github.NewPullRequest) (*github.PullRequest, *github.Response, error) {
+	return createPullRequest(ctx, o, r, pr)
+}
+
+func TestEnsure(t *testing.T) {
+	tests := []struct {
+		Name string
+		// Branch is the existing content on the branch, keyed by path.
+		Branch     map[string]string
+		PRs        []*github.PullRequest
+		Reopen     bool
+		RefCode    int
+		ExpCreated []string
+		ExpUpdated []string
+		ExpDeleted []string
+		ExpPR      bool
+	}{
+		{
+			Name: "OpensPR",
+			Branch: map[string]string{
+				"d.bin": "binary",
+			},
+			ExpCreated: []string{"a.txt", "b/c.txt"},
+			ExpDeleted: []string{"d.bin"},
+			ExpPR:      true,
+		},
+		{
+			Name: "ExistingBranch",
+			Branch: map[string]string{
+				"a.txt":   "new a",
+				"b/c.txt": "old c",
+			},
+			RefCode:    http.StatusUnprocessableEntity,
+			ExpUpdated: []string{"b/c.txt"},
+			ExpPR:      true,
+		},
+		{
+			Name: "ExistingPR",
+			PRs:  []*github.PullRequest{{State: github.String("open")}},
+		},
+		{
+			Name: "DeclinedPR",
+			PRs:  []*github.PullRequest{{State: github.String("closed")}},
+		},
+		{
+			Name:       "ReopenDeclinedPR",
+			PRs:        []*github.PullRequest{{State: github.String("closed")}},
+			Reopen:     true,
+			ExpCreated: []string{"a.txt", "b/c.txt"},
+			ExpPR:      true,
+		},
+		{
+			Name: "MergedPR",
+			PRs: []*github.PullRequest{{
+				State:    github.String("closed"),
+				MergedAt: &github.Timestamp{},
+			}},
+			ExpCreated: []string{"a.txt", "b/c.txt"},
+			ExpPR:      true,
+		},
+		{
+			Name:    "Forbidden",
+			RefCode: http.StatusForbidden,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			listPullRequests = func(ctx context.Context, o, r string,
+				opt *github.PullRequestListOptions) ([]*github.PullRequest,
+				*github.Response, error) {
+				if opt.Head != "org:allstar/test" || opt.State != "all" {
+					t.Errorf("Unexpected options: %v", opt)
+				}
+				return test.PRs, nil, nil
+			}
+			createRef = func(ctx context.Context, o, r string, ref *github.Reference) (
+				*github.Reference, *github.Response, error) {
+				if ref.GetRef() != "refs/heads/allstar/test" || ref.GetObject().GetSHA() != "abc" {
+					t.Errorf("Unexpected ref: %v", ref)
+				}
+				if test.RefCode != 0 {
+					return nil, &github.Response{
+						Response: &http.Response{StatusCode: test.RefCode},
+					}, errors.New("error")
+				}
+				return ref, nil, nil
+			}
+			getContents = func(ctx context.Context, o, r, p string,
+				opt *github.RepositoryContentGetOptions) (*github.RepositoryContent,
+				[]*github.RepositoryContent, *github.Response, error) {
+				if opt.Ref != "allstar/test" {
+					t.Errorf("Unexpected ref: %v", opt.Ref)
+				}
+				c, ok := test.Branch[p]
+				if !ok {
+					return nil, nil, &github.Response{
+						Response: &http.Response{StatusCode: http.StatusNotFound},
+					}, errors.New("404")
+				}
+				return &github.RepositoryContent{Content: &c, SHA: github.String("sha-" + p)}, nil, nil, nil
+			}
+			var created, updated []string
+			createFile = func(ctx context.Context, o, r, p string,
+				opt *github.RepositoryContentFileOptions) (*github.RepositoryContentResponse,
+				*github.Response, error) {
+				if opt.GetBranch() != "allstar/test" || opt.GetMessage() != "Test title" {
+					t.Errorf("Unexpected options: %v", opt)
+				}
+				created = append(created, p)
+				return nil, nil, nil
+			}
+			updateFile = func(ctx context.Context, o, r, p string,
+				opt *github.RepositoryContentFileOptions) (*github.RepositoryContentResponse,
+				*github.Response, error) {
+				if opt.GetSHA() != "sha-"+p {
+					t.Errorf("Unexpected SHA: %v", opt.GetSHA())
+				}
+				updated = append(updated, p)
+				return nil, nil, nil
+			}
+			var deleted []string
+			deleteFile = func(ctx context.Context, o, r, p string,
+				opt *github.RepositoryContentFileOptions) (*github.RepositoryContentResponse,
+				*github.Response, error) {
+				if opt.GetSHA() != "sha-"+p {
+					t.Errorf("Unexpected SHA: %v", opt.GetSHA())
+				}
+				deleted = append(deleted, p)
+				return nil, nil, nil
+			}
+			gotPR := false
+			createPullRequest = func(ctx context.Context, o, r string,
+				pr *github.NewPullRequest) (*github.PullRequest, *github.Response, error) {
+				gotPR = true
+				if pr.GetHead() != "allstar/test" || pr.GetBase() != "main" {
+					t.Errorf("Unexpected pull request head/base: %v/%v", pr.GetHead(), pr.GetBase())
+				}
+				if !strings.HasPrefix(pr.GetBody(), "Test body\n\n---\n") || !strings.Contains(pr.GetBody(), "Allstar Test policy") {
+					t.Errorf("Unexpected pull request body: %q", pr.GetBody())
+				}
+				return &github.PullRequest{Number: github.Int(1)}, nil, nil
+			}
+
+			pr, err := ensure(context.Background(), mockRepos{}, "org", "thisrepo", "Test", &Request{
+				Branch: "allstar/test",
+				Title:  "Test title",
+				Body:   "Test body",
+				Files: []File{
+					{Path: "a.txt", Content: []byte("new a")},
+					{Path: "b/c.txt", Content: []byte("new c")},
+					{Path: "d.bin", Delete: true},
+				},
+				ReopenDeclined: test.Reopen,
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if gotPR != test.ExpPR {
+				t.Errorf("Unexpected pull request, want %v got %v", test.ExpPR, gotPR)
+			}
+			if (pr != nil) != test.ExpPR {
+				t.Errorf("Unexpected return: %v", pr)
+			}
+			if diff := cmp.Diff(test.ExpCreated, created); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.ExpUpdated, updated); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.ExpDeleted, deleted); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	f, err := Render(".github/CODEOWNERS", "* @{{.Owner}}/{{.Repo}}-maintainers\n",
+		TemplateData{Owner: "org", Repo: "thisrepo"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	exp := File{Path: ".github/CODEOWNERS", Content: []byte("* @org/thisrepo-maintainers\n")}
+	if diff := cmp.Diff(exp, f); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+
+	if _, err := Render("a.txt", "{{.Owner", TemplateData{}); err == nil {
+		t.Errorf("Expected parse error")
+	}
+	if _, err := Render("a.txt", "{{.Team}}", map[string]string{}); err == nil {
+		t.Errorf("Expected missing key error")
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pullrequest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v59/github"
+)
+
+// Status is the status of the most recent pull request from a fix branch.
+type Status string
+
+const (
+	// StatusNone is no pull request was opened from the branch.
+	StatusNone Status = "none"
+
+	// StatusOpen is the pull request is open.
+	StatusOpen Status = "open"
+
+	// StatusMerged is the pull request was merged.
+	StatusMerged Status = "merged"
+
+	// StatusClosed is the pull request was closed without merging, the
+	// maintainers declined the change.
+	StatusClosed Status = "closed"
+)
+
+// GetStatus returns the status of the most recent pull request from branch to
+// the provided repo, and the pull request if there is one. Policies may use it
+// to mention a pending fix in their results.
+func GetStatus(ctx context.Context, c *github.Client, owner, repo, branch string) (Status, *github.PullRequest, error) {
+	return getStatus(ctx, reposClient{c}, owner, repo, branch)
+}
+
+func getStatus(ctx context.Context, rep repositories, owner, repo, branch string) (Status, *github.PullRequest, error) {
+	prs, _, err := rep.ListPullRequests(ctx, owner, repo, &github.PullRequestListOptions{
+		State:       "all",
+		Head:        fmt.Sprintf("%v:%v", owner, branch),
+		Sort:        "created",
+		Direction:   "desc",
+		ListOptions: github.ListOptions{PerPage: 1},
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	if len(prs) == 0 {
+		return StatusNone, nil, nil
+	}
+	pr := prs[0]
+	switch {
+	case pr.GetState() == "open":
+		return StatusOpen, pr, nil
+	case pr.MergedAt != nil:
+		return StatusMerged, pr, nil
+	default:
+		return StatusClosed, pr, nil
+	}
+}
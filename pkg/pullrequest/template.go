@@ -0,0 +1,45 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pullrequest
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// TemplateData is the data file templates are rendered with.
+type TemplateData struct {
+	// Owner is the organization or user owning the repository.
+	Owner string
+
+	// Repo is the name of the repository.
+	Repo string
+}
+
+// Render renders the Go text/template tmpl with data, and returns it as the
+// content of the file at path. Policies use it to generate files from their
+// configurable fix templates.
+func Render(path, tmpl string, data interface{}) (File, error) {
+	t, err := template.New(path).Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return File{}, fmt.Errorf("parsing template for %v: %w", path, err)
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return File{}, fmt.Errorf("rendering template for %v: %w", path, err)
+	}
+	return File{Path: path, Content: b.Bytes()}, nil
+}
@@ -0,0 +1,223 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package revert records the settings that fix actions change, before they
+// change them, so that a fix that proves disruptive can be reverted with
+// "allstar revert".
+//
+// Policies call Record with the prior settings of each target they fix, eg: a
+// branch, and Register a Reverter that restores them. Snapshots are kept in the
+// state store, under "revert/<org>/<repo>/<policy>/", for maxAge.
+package revert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ossf/allstar/pkg/audit"
+	"github.com/ossf/allstar/pkg/enforceid"
+	"github.com/ossf/allstar/pkg/state"
+
+	"github.com/google/go-github/v59/github"
+)
+
+// maxAge is how long snapshots are kept, older snapshots are pruned when a
+// new one is recorded for the same repo and policy.
+const maxAge = 90 * 24 * time.Hour
+
+// timeFormat is the fixed width time prefix of snapshot keys, so that keys
+// sort in the order snapshots were recorded.
+const timeFormat = "20060102T150405.000000000Z"
+
+// Snapshot is the settings of a target before a fix action changed them.
+type Snapshot struct {
+	// Key is where the snapshot is stored.
+	Key string `json:"-"`
+
+	Time          time.Time `json:"time"`
+	RunID         string    `json:"runId,omitempty"`
+	EnforcementID string    `json:"enforcementId,omitempty"`
+	Org           string    `json:"org"`
+	Repo          string    `json:"repo"`
+	Policy        string    `json:"policy"`
+
+	// Target is what the fix changed, eg: a branch name.
+	Target string `json:"target"`
+
+	// Prior is the settings of the target before the fix, in the form
+	// recorded by the policy.
+	Prior json.RawMessage `json:"prior"`
+}
+
+// Reverter restores the prior settings of snapshot s.
+type Reverter func(ctx context.Context, c *github.Client, s Snapshot) error
+
+var reverters = make(map[string]Reverter)
+var revertersMu sync.Mutex
+
+// Register makes the fixes of the named policy revertible with r. It panics
+// if the policy is registered twice.
+func Register(policy string, r Reverter) {
+	revertersMu.Lock()
+	defer revertersMu.Unlock()
+	if _, ok := reverters[policy]; ok {
+		panic(fmt.Sprintf("revert: Register called twice for policy %v", policy))
+	}
+	reverters[policy] = r
+}
+
+var mu sync.Mutex
+var store state.Interface
+
+var timeNow = time.Now
+
+// SetState sets the state store snapshots are kept in. Nothing is recorded
+// until it is set.
+func SetState(s state.Interface) {
+	mu.Lock()
+	defer mu.Unlock()
+	store = s
+}
+
+func getState() (state.Interface, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if store == nil {
+		return nil, errors.New("revert: state store not set")
+	}
+	return store, nil
+}
+
+func prefix(org, repo, policy string) string {
+	if policy == "" {
+		return state.Key("revert", org, repo) + "/"
+	}
+	return state.Key("revert", org, repo, policy) + "/"
+}
+
+// Record records prior, the settings of target on the repo before the named
+// policy fixes it, made with ctx. It is a no-op if no state store is set.
+func Record(ctx context.Context, org, repo, policy, target string, prior interface{}) error {
+	mu.Lock()
+	s := store
+	mu.Unlock()
+	if s == nil {
+		return nil
+	}
+	b, err := json.Marshal(prior)
+	if err != nil {
+		return err
+	}
+	now := timeNow().UTC()
+	snap := Snapshot{
+		Time:          now,
+		RunID:         enforceid.Run(ctx),
+		EnforcementID: enforceid.Evaluation(ctx),
+		Org:           org,
+		Repo:          repo,
+		Policy:        policy,
+		Target:        target,
+		Prior:         b,
+	}
+	key := prefix(org, repo, policy) + now.Format(timeFormat) + "-" + enforceid.New()
+	if err := state.PutJSON(ctx, s, key, snap); err != nil {
+		return err
+	}
+	return prune(ctx, s, prefix(org, repo, policy), now.Add(-maxAge))
+}
+
+// prune deletes the snapshots under p recorded before cutoff.
+func prune(ctx context.Context, s state.Interface, p string, cutoff time.Time) error {
+	keys, err := s.List(ctx, p)
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		ts, _, _ := strings.Cut(strings.TrimPrefix(k, p), "-")
+		t, err := time.Parse(timeFormat, ts)
+		if err != nil || !t.Before(cutoff) {
+			continue
+		}
+		if err := s.Delete(ctx, k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns the snapshots recorded on the repo since the provided time,
+// newest first, which is the order they are reverted in. If policy is empty,
+// the snapshots of all policies are returned.
+func List(ctx context.Context, org, repo, policy string, since time.Time) ([]Snapshot, error) {
+	s, err := getState()
+	if err != nil {
+		return nil, err
+	}
+	keys, err := s.List(ctx, prefix(org, repo, policy))
+	if err != nil {
+		return nil, err
+	}
+	var snaps []Snapshot
+	for i := len(keys) - 1; i >= 0; i-- {
+		var snap Snapshot
+		if err := state.GetJSON(ctx, s, keys[i], &snap); err != nil {
+			if errors.Is(err, state.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		if snap.Time.Before(since) {
+			continue
+		}
+		snap.Key = keys[i]
+		snaps = append(snaps, snap)
+	}
+	// Keys are sorted by time within a policy only.
+	sort.SliceStable(snaps, func(i, j int) bool {
+		return snaps[i].Time.After(snaps[j].Time)
+	})
+	return snaps, nil
+}
+
+// Revert restores the prior settings of snaps, in order, and deletes each
+// snapshot once restored, so that running it again continues where it
+// stopped. It stops at the first error, returning the number of snapshots
+// restored.
+func Revert(ctx context.Context, c *github.Client, snaps []Snapshot) (int, error) {
+	s, err := getState()
+	if err != nil {
+		return 0, err
+	}
+	for i, snap := range snaps {
+		revertersMu.Lock()
+		r, ok := reverters[snap.Policy]
+		revertersMu.Unlock()
+		if !ok {
+			return i, fmt.Errorf("revert: fixes of policy %q can not be reverted", snap.Policy)
+		}
+		if err := r(audit.WithPolicy(ctx, snap.Policy), c, snap); err != nil {
+			return i, fmt.Errorf("reverting %v %q on %v/%v: %w", snap.Policy, snap.Target, snap.Org, snap.Repo, err)
+		}
+		if err := s.Delete(ctx, snap.Key); err != nil {
+			return i, err
+		}
+	}
+	return len(snaps), nil
+}
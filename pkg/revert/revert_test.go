@@ -0,0 +1,190 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package revert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ossf/allstar/pkg/state"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+)
+
+func setup(t *testing.T) (*state.Memory, *time.Time) {
+	t.Helper()
+	m := state.NewMemory()
+	SetState(m)
+	now := time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() {
+		SetState(nil)
+		timeNow = time.Now
+		revertersMu.Lock()
+		delete(reverters, "Test Policy")
+		delete(reverters, "Other Policy")
+		revertersMu.Unlock()
+	})
+	return m, &now
+}
+
+func TestRecordNoState(t *testing.T) {
+	SetState(nil)
+	if err := Record(context.Background(), "acme", "app", "Test Policy", "main", 1); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, err := List(context.Background(), "acme", "app", "", time.Time{}); err == nil {
+		t.Errorf("Expected error listing without a state store")
+	}
+}
+
+func TestRecordList(t *testing.T) {
+	ctx := context.Background()
+	_, now := setup(t)
+	record := func(policy, target string, prior int) {
+		t.Helper()
+		if err := Record(ctx, "acme", "app", policy, target, prior); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		*now = now.Add(time.Hour)
+	}
+	record("Test Policy", "main", 1)
+	record("Other Policy", "main", 2)
+	record("Test Policy", "dev", 3)
+	if err := Record(ctx, "acme", "app2", "Test Policy", "main", 4); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	targets := func(snaps []Snapshot) []string {
+		var l []string
+		for _, s := range snaps {
+			l = append(l, s.Policy+"/"+s.Target+"/"+string(s.Prior))
+		}
+		return l
+	}
+	start := time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		Name   string
+		Policy string
+		Since  time.Time
+		Exp    []string
+	}{
+		{
+			Name: "All",
+			Exp:  []string{"Test Policy/dev/3", "Other Policy/main/2", "Test Policy/main/1"},
+		},
+		{
+			Name:   "Policy",
+			Policy: "Test Policy",
+			Exp:    []string{"Test Policy/dev/3", "Test Policy/main/1"},
+		},
+		{
+			Name:  "Since",
+			Since: start.Add(time.Hour),
+			Exp:   []string{"Test Policy/dev/3", "Other Policy/main/2"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			snaps, err := List(ctx, "acme", "app", test.Policy, test.Since)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.Exp, targets(snaps)); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRecordPrunes(t *testing.T) {
+	ctx := context.Background()
+	m, now := setup(t)
+	if err := Record(ctx, "acme", "app", "Test Policy", "main", 1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	*now = now.Add(maxAge + time.Hour)
+	if err := Record(ctx, "acme", "app", "Test Policy", "main", 2); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	keys, err := m.List(ctx, "revert/")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(keys) != 1 {
+		t.Errorf("Expected old snapshot pruned, got keys: %v", keys)
+	}
+}
+
+func TestRevert(t *testing.T) {
+	ctx := context.Background()
+	m, now := setup(t)
+	var reverted []string
+	Register("Test Policy", func(ctx context.Context, c *github.Client, s Snapshot) error {
+		var prior int
+		if err := json.Unmarshal(s.Prior, &prior); err != nil {
+			return err
+		}
+		if prior == 3 {
+			return errors.New("boom")
+		}
+		reverted = append(reverted, s.Target)
+		return nil
+	})
+	for i, target := range []string{"a", "b"} {
+		if err := Record(ctx, "acme", "app", "Test Policy", target, i+1); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		*now = now.Add(time.Minute)
+	}
+	snaps, err := List(ctx, "acme", "app", "", time.Time{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	n, err := Revert(ctx, nil, snaps)
+	if err != nil || n != 2 {
+		t.Fatalf("Unexpected results: %v, %v", n, err)
+	}
+	if diff := cmp.Diff([]string{"b", "a"}, reverted); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+	if keys, _ := m.List(ctx, "revert/"); len(keys) != 0 {
+		t.Errorf("Expected reverted snapshots deleted, got keys: %v", keys)
+	}
+
+	if err := Record(ctx, "acme", "app", "Test Policy", "c", 3); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := Record(ctx, "acme", "app", "Unknown Policy", "d", 4); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	snaps, _ = List(ctx, "acme", "app", "", time.Time{})
+	n, err = Revert(ctx, nil, snaps)
+	if n != 0 || err == nil || !strings.Contains(err.Error(), "can not be reverted") {
+		t.Errorf("Expected error for unregistered policy, got: %v, %v", n, err)
+	}
+	n, err = Revert(ctx, nil, snaps[1:])
+	if n != 0 || err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Expected revert error, got: %v, %v", n, err)
+	}
+	if keys, _ := m.List(ctx, "revert/"); len(keys) != 2 {
+		t.Errorf("Expected failed snapshots kept, got keys: %v", keys)
+	}
+}
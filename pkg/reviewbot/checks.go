@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/bradleyfalzon/ghinstallation/v2"
@@ -14,6 +16,12 @@ import (
 // re-requested reviews should remove last review
 // - fire event
 
+var listReviews func(context.Context, *github.Client, string, string, int) ([]*github.PullRequestReview, error)
+
+func init() {
+	listReviews = listReviewsReal
+}
+
 type PullRequestInfo struct {
 	owner          string
 	repo           string
@@ -23,8 +31,8 @@ type PullRequestInfo struct {
 	number         int
 }
 
-func runPRCheck(config Config, pr PullRequestInfo) error {
-	tr, err := ghinstallation.NewKeyFromFile(http.DefaultTransport, appID, pr.installationId, config.GitHub.PrivateKeyPath)
+func runPRCheck(config Config, key []byte, pr PullRequestInfo) error {
+	tr, err := ghinstallation.New(http.DefaultTransport, config.GitHub.AppId, pr.installationId, key)
 	if err != nil {
 		log.Error().Interface("pr", pr).Err(err).Msg("Could not read key")
 		return err
@@ -33,80 +41,192 @@ func runPRCheck(config Config, pr PullRequestInfo) error {
 	client := github.NewClient(&http.Client{Transport: tr})
 	ctx := context.Background()
 
-	// TODO: get repo-level overwrites, if available
-	minReviewsRequired := config.MinReviewsRequired
+	mc := getConfig(ctx, client, config, pr.owner, pr.repo)
+	minReviewsRequired := mc.MinReviewsRequired
 
-	// List of approvers to verify
-	var approvalCandidates = map[string]bool{
-		// Add PR Creator as someone to check
-		pr.user: true,
+	if isExempt(mc, pr.user) {
+		log.Info().Interface("pr", pr).Msg("Pull request author is exempt from review")
+		return publishResult(ctx, client, pr, "success", "Pull request author is exempt from review",
+			fmt.Sprintf("%s is exempt from review by configuration", pr.user), "Author exempt from review")
 	}
 
-	optListReviews := &github.ListOptions{PerPage: 100}
+	reviews, err := listReviews(ctx, client, pr.owner, pr.repo, pr.number)
+	if err != nil {
+		log.Error().Interface("pr", pr).Err(err).Msg("Could not list reviews")
+		return err
+	}
 
-	// Check reviews
-	for {
-		reviews, resp, err := client.PullRequests.ListReviews(ctx, pr.owner, pr.repo, pr.number, optListReviews)
+	// List of approvers to verify
+	approvers := approversFrom(mc, pr, reviews)
+
+	// Points for approval
+	var points uint64 = 0
+
+	for _, login := range approvers {
+		permissionLevel, _, err := client.Repositories.GetPermissionLevel(ctx, pr.owner, pr.repo, login)
 		if err != nil {
-			log.Error().Interface("pr", pr).Err(err).Msg("Could not list reviews")
 			return err
 		}
 
-		for _, review := range reviews {
-			login := review.GetUser().GetLogin()
-			association := review.GetAuthorAssociation()
-			state := review.GetState()
-
-			// Ignore accounts without association with the repo and comments
-			if association == "NONE" || state == "COMMENTED" {
-				continue
-			}
+		permission := permissionLevel.GetPermission()
+		isAuthorized := permission == "admin" || permission == "write"
 
-			log.Debug().Interface("pr", pr).Str("login", login).Str("association", association).Str("state", state).Msg("Found a review candidate")
+		log.Debug().Interface("pr", pr).Str("login", login).Str("permission", permission).Msg("Approver Authorization")
 
-			if state == "APPROVED" {
-				approvalCandidates[login] = true
-			} else {
-				delete(approvalCandidates, login)
-			}
+		if isAuthorized {
+			points++
 		}
+	}
 
-		if resp.NextPage == 0 {
-			break
-		}
+	log.Info().Interface("pr", pr).Uint64("points", points).Msg("Check's State")
 
-		optListReviews.Page = resp.NextPage
+	text := fmt.Sprintf("PR has %d authorized approvals, %d required", points, minReviewsRequired)
+	approvals := fmt.Sprintf("%d/%d approvals", points, minReviewsRequired)
+
+	if points < minReviewsRequired {
+		delta := minReviewsRequired - points
+		deltaMessage := fmt.Sprintf("need %d more approval(s)", delta)
+		return publishResult(ctx, client, pr, "failure",
+			"Pull request does not have enough authorized approvals - "+deltaMessage, text, approvals+", "+deltaMessage)
 	}
 
-	// Points for approval
-	var points uint64 = 0
+	if len(mc.RequiredTeams) > 0 {
+		missing, err := missingTeamApproval(ctx, client, mc, pr, approvers)
+		if err != nil {
+			log.Error().Interface("pr", pr).Err(err).Msg("Could not check team approval")
+			return err
+		}
+		if len(missing) > 0 {
+			return publishResult(ctx, client, pr, "failure",
+				"Pull request needs approval from team(s): "+strings.Join(missing, ", "), text,
+				approvals+", needs team approval: "+strings.Join(missing, ", "))
+		}
+	}
 
-	for login := range approvalCandidates {
-		permissionLevel, _, err := client.Repositories.GetPermissionLevel(ctx, pr.owner, pr.repo, login)
+	if mc.RequireCodeOwnerReview {
+		missing, err := missingCodeOwnerApproval(ctx, client, pr, approvers)
 		if err != nil {
+			log.Error().Interface("pr", pr).Err(err).Msg("Could not check code owner approval")
 			return err
 		}
+		if len(missing) > 0 {
+			text = fmt.Sprintf("%s\n\nFiles without code owner approval:\n%s", text, formatFiles(missing))
+			return publishResult(ctx, client, pr, "failure",
+				fmt.Sprintf("Pull request needs code owner approval for %d file(s)", len(missing)), text,
+				fmt.Sprintf("%s, needs code owner approval for %d file(s)", approvals, len(missing)))
+		}
+	}
 
-		permission := permissionLevel.GetPermission()
-		isAuthorized := permission == "admin" || permission == "write"
+	return publishResult(ctx, client, pr, "success", "Pull request has enough authorized approvals", text, approvals)
+}
 
-		log.Debug().Interface("pr", pr).Str("login", login).Str("permission", permission).Msg("Approver Authorization")
+// approversFrom returns the users whose latest review of the pull request is
+// an approval, sorted. Approvals from the pull request author and ignored
+// approvers do not count, and neither do approvals of an earlier commit when
+// stale approvals are dismissed.
+func approversFrom(mc *mergedConfig, pr PullRequestInfo, reviews []*github.PullRequestReview) []string {
+	approved := make(map[string]bool)
+	for _, review := range reviews {
+		login := review.GetUser().GetLogin()
+		association := review.GetAuthorAssociation()
+		state := review.GetState()
+
+		// Ignore accounts without association with the repo and comments
+		if association == "NONE" || state == "COMMENTED" {
+			continue
+		}
 
-		if isAuthorized {
-			points++
+		if strings.EqualFold(login, pr.user) || matchesAny(mc.IgnoredApprovers, login) {
+			log.Debug().Interface("pr", pr).Str("login", login).Msg("Ignoring review")
+			continue
+		}
+
+		log.Debug().Interface("pr", pr).Str("login", login).Str("association", association).Str("state", state).Msg("Found a review candidate")
 
-			if points == minReviewsRequired {
-				// no need to waste resources - we have enough authorized approvers
-				break
-			}
+		stale := mc.DismissStaleApprovals && review.GetCommitID() != pr.headSHA
+		if state == "APPROVED" && !stale {
+			approved[login] = true
+		} else {
+			delete(approved, login)
 		}
 	}
+	approvers := make([]string, 0, len(approved))
+	for login := range approved {
+		approvers = append(approvers, login)
+	}
+	sort.Strings(approvers)
+	return approvers
+}
 
-	log.Info().Interface("pr", pr).Uint64("points", points).Msg("Check's State")
+// missingTeamApproval returns the required teams without an approval from any
+// of their members.
+func missingTeamApproval(ctx context.Context, c *github.Client, mc *mergedConfig, pr PullRequestInfo, approvers []string) ([]string, error) {
+	var missing []string
+	for _, t := range mc.RequiredTeams {
+		ok, err := hasApproved(ctx, c, teamOwner(pr.owner, t), approvers)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			missing = append(missing, t)
+		}
+	}
+	return missing, nil
+}
+
+// teamOwner returns the team, "team" in the repo's org or "org/team", as a
+// CODEOWNERS style "@org/team".
+func teamOwner(org, team string) string {
+	team = strings.TrimPrefix(team, "@")
+	if !strings.Contains(team, "/") {
+		team = org + "/" + team
+	}
+	return "@" + team
+}
+
+func listReviewsReal(ctx context.Context, c *github.Client, owner, repo string, number int) ([]*github.PullRequestReview, error) {
+	var all []*github.PullRequestReview
+	optListReviews := &github.ListOptions{PerPage: 100}
+	for {
+		reviews, resp, err := c.PullRequests.ListReviews(ctx, owner, repo, number, optListReviews)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, reviews...)
+		if resp.NextPage == 0 {
+			break
+		}
+		optListReviews.Page = resp.NextPage
+	}
+	return all, nil
+}
+
+// maxStatusDescription is the maximum length of a commit status description,
+// as limited by GitHub.
+const maxStatusDescription = 140
+
+// maxListedFiles is the maximum number of files listed in the check run text.
+const maxListedFiles = 50
+
+func formatFiles(files []string) string {
+	var b strings.Builder
+	for i, f := range files {
+		if i == maxListedFiles {
+			fmt.Fprintf(&b, "- ... and %d more\n", len(files)-maxListedFiles)
+			break
+		}
+		fmt.Fprintf(&b, "- %s\n", f)
+	}
+	return b.String()
+}
 
+// publishResult creates a check run and sets a commit status on the head
+// commit of the pull request. The status description is short, such as the
+// count of qualifying approvals, as GitHub limits its length.
+func publishResult(ctx context.Context, client *github.Client, pr PullRequestInfo, conclusion, summary, text, description string) error {
 	statusComplete := "completed"
 	titlePrefix := "⭐️ Allstar Pull Request Review Bot - "
-	text := fmt.Sprintf("PR has %d authorized approvals, %d required", points, minReviewsRequired)
+	title := titlePrefix + conclusion
 	timestamp := github.Timestamp{
 		Time: time.Now(),
 	}
@@ -114,41 +234,42 @@ func runPRCheck(config Config, pr PullRequestInfo) error {
 	check := github.CreateCheckRunOptions{
 		Name:        "Allstar Review Bot",
 		Status:      &statusComplete,
+		Conclusion:  &conclusion,
 		CompletedAt: &timestamp,
 		Output: &github.CheckRunOutput{
-			Text: &text,
+			Title:   &title,
+			Summary: &summary,
+			Text:    &text,
 		},
 		HeadSHA: pr.headSHA,
 	}
 
-	if points >= minReviewsRequired {
-		conclusion := "success"
-		title := titlePrefix + conclusion
-		summary := "Pull request has enough authorized approvals"
-
-		check.Conclusion = &conclusion
-		check.Output.Title = &title
-		check.Output.Summary = &summary
-	} else {
-		conclusion := "failure"
-		title := titlePrefix + conclusion
-
-		delta := minReviewsRequired - points
-		deltaMessage := fmt.Sprintf("need %d more approval(s)", delta)
+	checkRun, _, err := client.Checks.CreateCheckRun(ctx, pr.owner, pr.repo, check)
+	if err != nil {
+		return err
+	}
 
-		summary := "Pull request does not have enough authorized approvals - " + deltaMessage
+	log.Info().Interface("pr", pr).Interface("Check Run", checkRun).Msg("Created Check Run")
 
-		check.Conclusion = &conclusion
-		check.Output.Title = &title
-		check.Output.Summary = &summary
+	// Also set a commit status, which branch protection can require without
+	// selecting the app
+	if len(description) > maxStatusDescription {
+		description = description[:maxStatusDescription-3] + "..."
 	}
-
-	checkRun, _, err := client.Checks.CreateCheckRun(ctx, pr.owner, pr.repo, check)
+	state := "success"
+	if conclusion != "success" {
+		state = "failure"
+	}
+	_, _, err = client.Repositories.CreateStatus(ctx, pr.owner, pr.repo, pr.headSHA, &github.RepoStatus{
+		State:       &state,
+		Description: &description,
+		Context:     github.String(StatusContext),
+	})
 	if err != nil {
 		return err
 	}
 
-	log.Info().Interface("pr", pr).Interface("Check Run", checkRun).Msg("Created Check Run")
+	log.Info().Interface("pr", pr).Str("state", state).Msg("Created Commit Status")
 
 	return nil
 }
@@ -0,0 +1,122 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewbot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+)
+
+func review(login, state, commit string) *github.PullRequestReview {
+	return &github.PullRequestReview{
+		User:              &github.User{Login: github.String(login)},
+		State:             github.String(state),
+		CommitID:          github.String(commit),
+		AuthorAssociation: github.String("MEMBER"),
+	}
+}
+
+func TestApproversFrom(t *testing.T) {
+	pr := PullRequestInfo{user: "author", headSHA: "head"}
+	reviews := []*github.PullRequestReview{
+		review("author", "APPROVED", "head"),
+		review("alice", "APPROVED", "old"),
+		review("bob", "APPROVED", "head"),
+		review("bob", "COMMENTED", "head"),
+		review("carol", "APPROVED", "old"),
+		review("carol", "CHANGES_REQUESTED", "head"),
+		review("dave", "CHANGES_REQUESTED", "old"),
+		review("dave", "APPROVED", "head"),
+		review("approve-bot", "APPROVED", "head"),
+		{
+			User:              &github.User{Login: github.String("stranger")},
+			State:             github.String("APPROVED"),
+			CommitID:          github.String("head"),
+			AuthorAssociation: github.String("NONE"),
+		},
+	}
+	tests := []struct {
+		Name string
+		MC   mergedConfig
+		Exp  []string
+	}{
+		{
+			Name: "Default",
+			Exp:  []string{"alice", "approve-bot", "bob", "dave"},
+		},
+		{
+			Name: "IgnoredApprovers",
+			MC: mergedConfig{
+				IgnoredApprovers: []string{"*-bot"},
+			},
+			Exp: []string{"alice", "bob", "dave"},
+		},
+		{
+			Name: "DismissStale",
+			MC: mergedConfig{
+				DismissStaleApprovals: true,
+			},
+			Exp: []string{"approve-bot", "bob", "dave"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got := approversFrom(&test.MC, pr, reviews)
+			if diff := cmp.Diff(test.Exp, got); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMissingTeamApproval(t *testing.T) {
+	members := map[string][]string{
+		"thisorg/security": {"alice"},
+		"thisorg/release":  {"bob"},
+		"otherorg/legal":   {"carol"},
+	}
+	isTeamMember = func(ctx context.Context, c *github.Client, org, team, user string) (bool, error) {
+		for _, m := range members[org+"/"+team] {
+			if m == user {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	mc := &mergedConfig{
+		RequiredTeams: []string{"security", "@thisorg/release", "otherorg/legal"},
+	}
+	pr := PullRequestInfo{owner: "thisorg"}
+	tests := []struct {
+		Approvers []string
+		Exp       []string
+	}{
+		{nil, []string{"security", "@thisorg/release", "otherorg/legal"}},
+		{[]string{"alice", "carol"}, []string{"@thisorg/release"}},
+		{[]string{"alice", "bob", "carol"}, nil},
+	}
+	for _, test := range tests {
+		got, err := missingTeamApproval(context.Background(), nil, mc, pr, test.Approvers)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if diff := cmp.Diff(test.Exp, got); diff != "" {
+			t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewbot
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/ossf/allstar/pkg/codeowners"
+
+	"github.com/gobwas/glob"
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+// ownerRule is a CODEOWNERS rule, with its pattern compiled.
+type ownerRule struct {
+	pattern string
+	globs   []glob.Glob
+	owners  []string
+}
+
+var getCodeowners func(context.Context, *github.Client, string, string, string) ([]byte, error)
+var listPRFiles func(context.Context, *github.Client, string, string, int) ([]string, error)
+var isTeamMember func(context.Context, *github.Client, string, string, string) (bool, error)
+
+func init() {
+	getCodeowners = getCodeownersReal
+	listPRFiles = listPRFilesReal
+	isTeamMember = isTeamMemberReal
+}
+
+// missingCodeOwnerApproval returns the files changed by the pull request that
+// have code owners, but no approval from any of them. Approvers are users, a
+// code owner team is satisfied by an approval from one of its members.
+func missingCodeOwnerApproval(ctx context.Context, c *github.Client, pr PullRequestInfo, approvers []string) ([]string, error) {
+	co, err := getCodeowners(ctx, c, pr.owner, pr.repo, pr.headSHA)
+	if err != nil {
+		return nil, err
+	}
+	if co == nil {
+		log.Info().Interface("pr", pr).Msg("Code owner review required, but no CODEOWNERS file found")
+		return nil, nil
+	}
+	rules := compileRules(codeowners.Parse(string(co)))
+	files, err := listPRFiles(ctx, c, pr.owner, pr.repo, pr.number)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache whether each owner has approved, as many files share owners.
+	approved := make(map[string]bool)
+	ownerApproved := func(owner string) (bool, error) {
+		if a, ok := approved[owner]; ok {
+			return a, nil
+		}
+		a, err := hasApproved(ctx, c, owner, approvers)
+		if err != nil {
+			return false, err
+		}
+		approved[owner] = a
+		return a, nil
+	}
+
+	var missing []string
+	for _, f := range files {
+		owners := ownersOf(rules, f)
+		if len(owners) == 0 {
+			continue
+		}
+		ok := false
+		for _, o := range owners {
+			a, err := ownerApproved(o)
+			if err != nil {
+				return nil, err
+			}
+			if a {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			missing = append(missing, f)
+		}
+	}
+	return missing, nil
+}
+
+// hasApproved returns whether owner, a "@user" or "@org/team", is one of the
+// approvers or has one of them as a member.
+func hasApproved(ctx context.Context, c *github.Client, owner string, approvers []string) (bool, error) {
+	name := strings.TrimPrefix(owner, "@")
+	org, team, isTeam := strings.Cut(name, "/")
+	for _, a := range approvers {
+		if !isTeam {
+			if strings.EqualFold(a, name) {
+				return true, nil
+			}
+			continue
+		}
+		m, err := isTeamMember(ctx, c, org, team, a)
+		if err != nil {
+			return false, err
+		}
+		if m {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// compileRules compiles the patterns of CODEOWNERS rules. Invalid patterns and
+// email owners, which can't be matched to reviewers, are skipped.
+func compileRules(rules []codeowners.Rule) []ownerRule {
+	var ors []ownerRule
+	for _, r := range rules {
+		gs, err := compilePattern(r.Pattern)
+		if err != nil {
+			log.Warn().
+				Str("pattern", r.Pattern).
+				Err(err).
+				Msg("Unexpected error compiling CODEOWNERS pattern.")
+			continue
+		}
+		or := ownerRule{pattern: r.Pattern, globs: gs}
+		for _, o := range r.Owners {
+			if strings.HasPrefix(o, "@") {
+				or.owners = append(or.owners, o)
+			}
+		}
+		ors = append(ors, or)
+	}
+	return ors
+}
+
+// compilePattern compiles a CODEOWNERS pattern, which follows gitignore
+// rules: a pattern without a leading or middle "/" matches at any depth, a
+// trailing "/" only matches directories, and a matching directory matches
+// everything under it.
+func compilePattern(p string) ([]glob.Glob, error) {
+	dirOnly := strings.HasSuffix(p, "/")
+	p = strings.TrimSuffix(p, "/")
+	anchored := strings.Contains(p, "/")
+	p = strings.TrimPrefix(p, "/")
+
+	var ps []string
+	if !dirOnly {
+		ps = append(ps, p)
+	}
+	ps = append(ps, p+"/**")
+	if !anchored {
+		n := len(ps)
+		for i := 0; i < n; i++ {
+			ps = append(ps, "**/"+ps[i])
+		}
+	}
+	gs := make([]glob.Glob, 0, len(ps))
+	for _, a := range ps {
+		g, err := glob.Compile(a, '/')
+		if err != nil {
+			return nil, err
+		}
+		gs = append(gs, g)
+	}
+	return gs, nil
+}
+
+// ownersOf returns the owners of file, from the last matching rule.
+func ownersOf(rules []ownerRule, file string) []string {
+	for i := len(rules) - 1; i >= 0; i-- {
+		for _, g := range rules[i].globs {
+			if g.Match(file) {
+				return rules[i].owners
+			}
+		}
+	}
+	return nil
+}
+
+// getCodeownersReal returns the contents of the first CODEOWNERS file found
+// at ref, or nil if there is none.
+func getCodeownersReal(ctx context.Context, c *github.Client, owner, repo, ref string) ([]byte, error) {
+	for _, p := range codeowners.Paths {
+		f, _, rsp, err := c.Repositories.GetContents(ctx, owner, repo, p,
+			&github.RepositoryContentGetOptions{Ref: ref})
+		if err != nil {
+			if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+				continue
+			}
+			return nil, err
+		}
+		if f == nil {
+			continue
+		}
+		s, err := f.GetContent()
+		if err != nil {
+			return nil, err
+		}
+		return []byte(s), nil
+	}
+	return nil, nil
+}
+
+func listPRFilesReal(ctx context.Context, c *github.Client, owner, repo string, number int) ([]string, error) {
+	var files []string
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		fs, resp, err := c.PullRequests.ListFiles(ctx, owner, repo, number, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range fs {
+			files = append(files, f.GetFilename())
+			// A rename needs approval for the old path as well.
+			if f.GetPreviousFilename() != "" {
+				files = append(files, f.GetPreviousFilename())
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return files, nil
+}
+
+func isTeamMemberReal(ctx context.Context, c *github.Client, org, team, user string) (bool, error) {
+	m, rsp, err := c.Teams.GetTeamMembershipBySlug(ctx, org, team, user)
+	if err != nil {
+		if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return m.GetState() == "active", nil
+}
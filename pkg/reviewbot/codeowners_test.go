@@ -0,0 +1,115 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewbot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/codeowners"
+)
+
+const testCodeowners = `# Default owners
+*                @thisorg/maintainers
+
+*.go             @gopher     # Go code
+/docs/           @writer
+build/           @builder someone@example.com
+/cmd/tool/main.go @toolowner
+vendor/
+`
+
+func TestOwnersOf(t *testing.T) {
+	rules := compileRules(codeowners.Parse(testCodeowners))
+	tests := map[string][]string{
+		"README.md":              {"@thisorg/maintainers"},
+		"main.go":                {"@gopher"},
+		"pkg/a/b.go":             {"@gopher"},
+		"docs/index.md":          {"@writer"},
+		"docs/sub/page.md":       {"@writer"},
+		"pkg/docs/index.md":      {"@thisorg/maintainers"},
+		"build/Makefile":         {"@builder"},
+		"pkg/build/script.sh":    {"@builder"},
+		"build":                  {"@thisorg/maintainers"},
+		"cmd/tool/main.go":       {"@toolowner"},
+		"pkg/cmd/tool/main.go":   {"@gopher"},
+		"vendor/mod/file.go":     nil,
+		"pkg/vendor/mod/file.go": nil,
+	}
+	for file, exp := range tests {
+		if diff := cmp.Diff(exp, ownersOf(rules, file)); diff != "" {
+			t.Errorf("Unexpected owners of %v. (-want +got):\n%s", file, diff)
+		}
+	}
+}
+
+func TestMissingCodeOwnerApproval(t *testing.T) {
+	listPRFiles = func(ctx context.Context, c *github.Client, owner, repo string, number int) ([]string, error) {
+		return []string{"README.md", "main.go", "docs/index.md", "vendor/x.go"}, nil
+	}
+	isTeamMember = func(ctx context.Context, c *github.Client, org, team, user string) (bool, error) {
+		return org == "thisorg" && team == "maintainers" && user == "maint", nil
+	}
+	tests := []struct {
+		Name       string
+		Codeowners string
+		Approvers  []string
+		Exp        []string
+	}{
+		{
+			Name:       "NoCodeowners",
+			Codeowners: "",
+			Approvers:  nil,
+			Exp:        nil,
+		},
+		{
+			Name:       "NoApprovers",
+			Codeowners: testCodeowners,
+			Approvers:  nil,
+			Exp:        []string{"README.md", "main.go", "docs/index.md"},
+		},
+		{
+			Name:       "Partial",
+			Codeowners: testCodeowners,
+			Approvers:  []string{"Gopher", "maint"},
+			Exp:        []string{"docs/index.md"},
+		},
+		{
+			Name:       "All",
+			Codeowners: testCodeowners,
+			Approvers:  []string{"gopher", "maint", "writer"},
+			Exp:        nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			getCodeowners = func(ctx context.Context, c *github.Client, owner, repo, ref string) ([]byte, error) {
+				if test.Codeowners == "" {
+					return nil, nil
+				}
+				return []byte(test.Codeowners), nil
+			}
+			missing, err := missingCodeOwnerApproval(context.Background(), nil, PullRequestInfo{}, test.Approvers)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.Exp, missing); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
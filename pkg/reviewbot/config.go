@@ -0,0 +1,304 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewbot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ossf/allstar/pkg/cache"
+	"github.com/ossf/allstar/pkg/config"
+	"github.com/ossf/allstar/pkg/config/operator"
+
+	"github.com/google/go-github/v59/github"
+	"github.com/rs/zerolog/log"
+)
+
+// configFile is the name of the Review Bot config file, read from the same
+// locations as Allstar's policy config files.
+const configFile = "reviewbot.yaml"
+
+// configTTL is how long a repo's merged config is cached. Config is reloaded
+// sooner when a push to a config location is received.
+const configTTL = 10 * time.Minute
+
+// OrgConfig is the org-level config definition for Review Bot.
+type OrgConfig struct {
+	// MinReviewsRequired overrides the global minimum number of authorized
+	// approvals, only if present.
+	MinReviewsRequired *uint64 `json:"minReviewsRequired"`
+
+	// ExemptAuthors is a list of pull request authors that do not need
+	// reviews, such as bots, eg: "dependabot[bot]". The "*" and "?" wildcards
+	// are allowed, eg: "*[bot]".
+	ExemptAuthors []string `json:"exemptAuthors"`
+
+	// RequireCodeOwnerReview requires an approval from a code owner of each
+	// changed file with owners in CODEOWNERS, in addition to
+	// MinReviewsRequired.
+	RequireCodeOwnerReview bool `json:"requireCodeOwnerReview"`
+
+	// RequiredTeams is a list of teams that must each approve, by a member's
+	// approval, in addition to MinReviewsRequired. Teams are named by slug,
+	// eg: "security", or "org/security" for a team of another org.
+	RequiredTeams []string `json:"requiredTeams"`
+
+	// IgnoredApprovers is a list of users whose approvals are not counted,
+	// such as bots that approve automatically. The "*" and "?" wildcards are
+	// allowed. Approvals from the pull request author are never counted.
+	IgnoredApprovers []string `json:"ignoredApprovers"`
+
+	// DismissStaleApprovals : set to true to only count approvals of the
+	// current head commit, so that pushing new commits requires approval
+	// again.
+	DismissStaleApprovals bool `json:"dismissStaleApprovals"`
+
+	// DisableRepoOverride : set to true to disallow repos from overriding
+	// this config in their own config file.
+	DisableRepoOverride bool `json:"disableRepoOverride"`
+}
+
+// RepoConfig is the repo-level config for Review Bot.
+type RepoConfig struct {
+	// MinReviewsRequired overrides the same setting in org-level, only if
+	// present.
+	MinReviewsRequired *uint64 `json:"minReviewsRequired"`
+
+	// ExemptAuthors overrides the same setting in org-level, only if present.
+	ExemptAuthors []string `json:"exemptAuthors"`
+
+	// RequireCodeOwnerReview overrides the same setting in org-level, only if
+	// present.
+	RequireCodeOwnerReview *bool `json:"requireCodeOwnerReview"`
+
+	// RequiredTeams overrides the same setting in org-level, only if present.
+	RequiredTeams []string `json:"requiredTeams"`
+
+	// IgnoredApprovers overrides the same setting in org-level, only if
+	// present.
+	IgnoredApprovers []string `json:"ignoredApprovers"`
+
+	// DismissStaleApprovals overrides the same setting in org-level, only if
+	// present.
+	DismissStaleApprovals *bool `json:"dismissStaleApprovals"`
+}
+
+type mergedConfig struct {
+	MinReviewsRequired     uint64
+	ExemptAuthors          []string
+	RequireCodeOwnerReview bool
+	RequiredTeams          []string
+	IgnoredApprovers       []string
+	DismissStaleApprovals  bool
+}
+
+type cachedConfig struct {
+	mc      *mergedConfig
+	fetched time.Time
+}
+
+var gc = cache.NewGlobCache(cache.DefaultSize)
+
+// bracketQuoter keeps brackets, common in bot logins, from being read as glob
+// character classes.
+var bracketQuoter = strings.NewReplacer("[", `\[`, "]", `\]`)
+
+// configs caches merged configs by "owner/repo".
+var configs = make(map[string]cachedConfig)
+var configsMu sync.Mutex
+
+var configFetchConfig func(context.Context, *github.Client, string, string, string, config.ConfigLevel, interface{}) error
+var timeNow func() time.Time
+
+func init() {
+	configFetchConfig = config.FetchConfig
+	timeNow = time.Now
+}
+
+// getConfig returns the merged config for the repo, from the org and repo
+// config files, with the global config as defaults.
+func getConfig(ctx context.Context, c *github.Client, global Config, owner, repo string) *mergedConfig {
+	key := strings.ToLower(fmt.Sprintf("%s/%s", owner, repo))
+	configsMu.Lock()
+	cc, ok := configs[key]
+	configsMu.Unlock()
+	if ok && timeNow().Before(cc.fetched.Add(configTTL)) {
+		return cc.mc
+	}
+	oc, orc, rc := fetchConfigs(ctx, c, owner, repo)
+	mc := mergeConfig(global, oc, orc, rc)
+	configsMu.Lock()
+	configs[key] = cachedConfig{mc: mc, fetched: timeNow()}
+	configsMu.Unlock()
+	return mc
+}
+
+// invalidateConfig drops the cached config of the repo, or of all repos of the
+// owner if repo is empty, so that it is reloaded on the next pull request.
+func invalidateConfig(owner, repo string) {
+	configsMu.Lock()
+	defer configsMu.Unlock()
+	if repo != "" {
+		delete(configs, strings.ToLower(fmt.Sprintf("%s/%s", owner, repo)))
+		return
+	}
+	prefix := strings.ToLower(owner) + "/"
+	for k := range configs {
+		if strings.HasPrefix(k, prefix) {
+			delete(configs, k)
+		}
+	}
+	// The org config repo may have been created or deleted.
+	config.ClearInstLoc(owner)
+}
+
+// handlePush reloads config changed by a push to the default branch. A push
+// to the org config repo, or the .github repo it may fall back to, reloads
+// all repos of the owner. A push changing the repo config directory reloads
+// the repo.
+func handlePush(event *github.PushEvent) {
+	r := event.GetRepo()
+	if event.GetRef() != "refs/heads/"+r.GetDefaultBranch() {
+		return
+	}
+	owner := r.GetOwner().GetLogin()
+	if owner == "" {
+		owner = r.GetOwner().GetName()
+	}
+	if r.GetName() == operator.OrgConfigRepo || r.GetName() == ".github" {
+		log.Info().
+			Str("org", owner).
+			Str("repo", r.GetName()).
+			Msg("Org config repo changed, reloading config.")
+		invalidateConfig(owner, "")
+		return
+	}
+	for _, cm := range event.Commits {
+		for _, files := range [][]string{cm.Added, cm.Modified, cm.Removed} {
+			for _, f := range files {
+				if strings.HasPrefix(f, operator.RepoConfigDir+"/") {
+					log.Info().
+						Str("org", owner).
+						Str("repo", r.GetName()).
+						Msg("Repo config changed, reloading config.")
+					invalidateConfig(owner, r.GetName())
+					return
+				}
+			}
+		}
+	}
+}
+
+func fetchConfigs(ctx context.Context, c *github.Client, owner, repo string) (*OrgConfig, *RepoConfig, *RepoConfig) {
+	oc := &OrgConfig{}
+	if err := configFetchConfig(ctx, c, owner, "", configFile, config.OrgLevel, oc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgLevel").
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	orc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.OrgRepoLevel, orc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "orgRepoLevel").
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	rc := &RepoConfig{}
+	if err := configFetchConfig(ctx, c, owner, repo, configFile, config.RepoLevel, rc); err != nil {
+		log.Error().
+			Str("org", owner).
+			Str("repo", repo).
+			Str("configLevel", "repoLevel").
+			Str("file", configFile).
+			Err(err).
+			Msg("Unexpected config error, using defaults.")
+	}
+	return oc, orc, rc
+}
+
+func mergeConfig(global Config, oc *OrgConfig, orc, rc *RepoConfig) *mergedConfig {
+	mc := &mergedConfig{
+		MinReviewsRequired:     global.MinReviewsRequired,
+		ExemptAuthors:          oc.ExemptAuthors,
+		RequireCodeOwnerReview: oc.RequireCodeOwnerReview,
+		RequiredTeams:          oc.RequiredTeams,
+		IgnoredApprovers:       oc.IgnoredApprovers,
+		DismissStaleApprovals:  oc.DismissStaleApprovals,
+	}
+	if oc.MinReviewsRequired != nil {
+		mc.MinReviewsRequired = *oc.MinReviewsRequired
+	}
+	mc = mergeInRepoConfig(mc, orc)
+
+	if !oc.DisableRepoOverride {
+		mc = mergeInRepoConfig(mc, rc)
+	}
+	return mc
+}
+
+func mergeInRepoConfig(mc *mergedConfig, rc *RepoConfig) *mergedConfig {
+	if rc.MinReviewsRequired != nil {
+		mc.MinReviewsRequired = *rc.MinReviewsRequired
+	}
+	if rc.ExemptAuthors != nil {
+		mc.ExemptAuthors = rc.ExemptAuthors
+	}
+	if rc.RequireCodeOwnerReview != nil {
+		mc.RequireCodeOwnerReview = *rc.RequireCodeOwnerReview
+	}
+	if rc.RequiredTeams != nil {
+		mc.RequiredTeams = rc.RequiredTeams
+	}
+	if rc.IgnoredApprovers != nil {
+		mc.IgnoredApprovers = rc.IgnoredApprovers
+	}
+	if rc.DismissStaleApprovals != nil {
+		mc.DismissStaleApprovals = *rc.DismissStaleApprovals
+	}
+	return mc
+}
+
+// isExempt returns whether the pull request author is exempt from review.
+func isExempt(mc *mergedConfig, user string) bool {
+	return matchesAny(mc.ExemptAuthors, user)
+}
+
+// matchesAny returns whether the user matches any of the globs.
+func matchesAny(globs []string, user string) bool {
+	for _, a := range globs {
+		g, err := gc.Compile(bracketQuoter.Replace(strings.ToLower(a)))
+		if err != nil {
+			log.Warn().
+				Str("glob", a).
+				Err(err).
+				Msg("Unexpected error compiling the glob.")
+			continue
+		}
+		if g.Match(strings.ToLower(user)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,218 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewbot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/config"
+)
+
+func TestConfigPrecedence(t *testing.T) {
+	one := uint64(1)
+	three := uint64(3)
+	tests := []struct {
+		Name      string
+		Org       OrgConfig
+		OrgRepo   RepoConfig
+		Repo      RepoConfig
+		ExpConfig mergedConfig
+	}{
+		{
+			Name: "Global",
+			ExpConfig: mergedConfig{
+				MinReviewsRequired: 2,
+			},
+		},
+		{
+			Name: "OrgOnly",
+			Org: OrgConfig{
+				MinReviewsRequired:     &one,
+				ExemptAuthors:          []string{"*[bot]"},
+				RequireCodeOwnerReview: true,
+			},
+			ExpConfig: mergedConfig{
+				MinReviewsRequired:     1,
+				ExemptAuthors:          []string{"*[bot]"},
+				RequireCodeOwnerReview: true,
+			},
+		},
+		{
+			Name: "OrgRepoOverOrg",
+			Org: OrgConfig{
+				MinReviewsRequired: &one,
+				ExemptAuthors:      []string{"*[bot]"},
+			},
+			OrgRepo: RepoConfig{
+				MinReviewsRequired:     &three,
+				ExemptAuthors:          []string{},
+				RequireCodeOwnerReview: github.Bool(true),
+				RequiredTeams:          []string{"security"},
+				IgnoredApprovers:       []string{"approve-bot"},
+				DismissStaleApprovals:  github.Bool(true),
+			},
+			ExpConfig: mergedConfig{
+				MinReviewsRequired:     3,
+				ExemptAuthors:          []string{},
+				RequireCodeOwnerReview: true,
+				RequiredTeams:          []string{"security"},
+				IgnoredApprovers:       []string{"approve-bot"},
+				DismissStaleApprovals:  true,
+			},
+		},
+		{
+			Name: "RepoOverAll",
+			Org: OrgConfig{
+				MinReviewsRequired:     &one,
+				RequireCodeOwnerReview: true,
+				RequiredTeams:          []string{"security"},
+				DismissStaleApprovals:  true,
+			},
+			OrgRepo: RepoConfig{
+				MinReviewsRequired: &three,
+			},
+			Repo: RepoConfig{
+				MinReviewsRequired:     &one,
+				RequireCodeOwnerReview: github.Bool(false),
+				RequiredTeams:          []string{"security", "thisorg/release"},
+				DismissStaleApprovals:  github.Bool(false),
+			},
+			ExpConfig: mergedConfig{
+				MinReviewsRequired: 1,
+				RequiredTeams:      []string{"security", "thisorg/release"},
+			},
+		},
+		{
+			Name: "RepoDisallowed",
+			Org: OrgConfig{
+				MinReviewsRequired:  &three,
+				DisableRepoOverride: true,
+			},
+			Repo: RepoConfig{
+				MinReviewsRequired: &one,
+			},
+			ExpConfig: mergedConfig{
+				MinReviewsRequired: 3,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configFetchConfig = func(ctx context.Context, c *github.Client, owner, repo, name string, cl config.ConfigLevel, out interface{}) error {
+				switch v := out.(type) {
+				case *OrgConfig:
+					*v = test.Org
+				case *RepoConfig:
+					if cl == config.OrgRepoLevel {
+						*v = test.OrgRepo
+					} else {
+						*v = test.Repo
+					}
+				}
+				return nil
+			}
+			invalidateConfig("thisorg", "")
+			mc := getConfig(context.Background(), nil, Config{MinReviewsRequired: 2}, "thisorg", "thisrepo")
+			if diff := cmp.Diff(&test.ExpConfig, mc); diff != "" {
+				t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConfigReload(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	minReviews := uint64(1)
+	fetches := 0
+	configFetchConfig = func(ctx context.Context, c *github.Client, owner, repo, name string, cl config.ConfigLevel, out interface{}) error {
+		if oc, ok := out.(*OrgConfig); ok {
+			fetches++
+			oc.MinReviewsRequired = &minReviews
+		}
+		return nil
+	}
+	get := func() uint64 {
+		return getConfig(context.Background(), nil, Config{}, "thisorg", "thisrepo").MinReviewsRequired
+	}
+	push := func(repo, ref string, files ...string) {
+		handlePush(&github.PushEvent{
+			Ref: github.String(ref),
+			Repo: &github.PushEventRepository{
+				Name:          github.String(repo),
+				DefaultBranch: github.String("main"),
+				Owner:         &github.User{Login: github.String("thisorg")},
+			},
+			Commits: []*github.HeadCommit{{Modified: files}},
+		})
+	}
+
+	invalidateConfig("thisorg", "")
+	if got := get(); got != 1 || fetches != 1 {
+		t.Fatalf("Unexpected config: %v, fetches: %v", got, fetches)
+	}
+
+	minReviews = 2
+	if got := get(); got != 1 || fetches != 1 {
+		t.Errorf("Expected cached config, got: %v, fetches: %v", got, fetches)
+	}
+
+	push("thisrepo", "refs/heads/other", ".allstar/reviewbot.yaml")
+	push("thisrepo", "refs/heads/main", "README.md")
+	push("otherrepo", "refs/heads/main", ".allstar/reviewbot.yaml")
+	if got := get(); got != 1 || fetches != 1 {
+		t.Errorf("Expected cached config, got: %v, fetches: %v", got, fetches)
+	}
+
+	push("thisrepo", "refs/heads/main", ".allstar/reviewbot.yaml")
+	if got := get(); got != 2 || fetches != 2 {
+		t.Errorf("Expected reloaded config, got: %v, fetches: %v", got, fetches)
+	}
+
+	minReviews = 3
+	push(".allstar", "refs/heads/main", "reviewbot.yaml")
+	if got := get(); got != 3 || fetches != 3 {
+		t.Errorf("Expected reloaded config, got: %v, fetches: %v", got, fetches)
+	}
+
+	minReviews = 4
+	now = now.Add(configTTL)
+	if got := get(); got != 4 || fetches != 4 {
+		t.Errorf("Expected expired config, got: %v, fetches: %v", got, fetches)
+	}
+}
+
+func TestIsExempt(t *testing.T) {
+	mc := &mergedConfig{
+		ExemptAuthors: []string{"*[bot]", "release-robot"},
+	}
+	tests := map[string]bool{
+		"dependabot[bot]": true,
+		"Renovate[bot]":   true,
+		"release-robot":   true,
+		"release-robot2":  false,
+		"someuser":        false,
+		"robot":           false,
+	}
+	for user, exp := range tests {
+		if got := isExempt(mc, user); got != exp {
+			t.Errorf("Unexpected exemption of %v: %v", user, got)
+		}
+	}
+}
@@ -1,19 +1,24 @@
 package reviewbot
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"path/filepath"
+	"time"
 
+	"github.com/bradleyfalzon/ghinstallation/v2"
 	"github.com/google/go-github/v59/github"
+	"github.com/ossf/allstar/pkg/ghclients"
 	"github.com/rs/zerolog/log"
 )
 
-const secretToken = "FooBar"
-const appID = 169668
+// StatusContext is the context of the commit status set on pull requests,
+// which branch protection can require.
+const StatusContext = "allstar/reviewbot"
 
 type Config struct {
 	// Configuration for GitHub
-	// TODO: future: option to get the below values from Secret Manager. See: `setKeySecret` in pkg/config/operator/operator.go
 	GitHub struct {
 		// The GitHub App's id.
 		// See: https://docs.github.com/en/developers/apps/building-github-apps/authenticating-with-github-apps#authenticating-as-a-github-app
@@ -22,8 +27,22 @@ type Config struct {
 		// Path to private key
 		PrivateKeyPath string
 
+		// Secret containing the private key, used instead of PrivateKeyPath
+		// if set. See ghclients.NewSecret for the supported sources.
+		PrivateKeySecret string
+
 		// See https://docs.github.com/en/developers/webhooks-and-events/webhooks/securing-your-webhooks
 		SecretToken string
+
+		// Secret containing the webhook secret token, used instead of
+		// SecretToken if set. See ghclients.NewSecret for the supported
+		// sources.
+		SecretTokenSecret string
+
+		// How long secrets are used before they are read again, to pick up
+		// rotated secrets. Secrets are also read again on SIGHUP. Zero
+		// disables the TTL.
+		SecretTTL time.Duration
 	}
 
 	// The global minimum reviews required for approval
@@ -33,8 +52,35 @@ type Config struct {
 	Port uint64
 }
 
+// requiredPermissions are the GitHub App permissions reviewbot needs, to read
+// config, CODEOWNERS, pull requests, and team members, and to publish results.
+var requiredPermissions = []string{
+	"metadata:read",
+	"contents:read",
+	"pull_requests:read",
+	"members:read",
+	"checks:write",
+	"statuses:write",
+}
+
+var newAppClient func(appID int64, key []byte) (*github.Client, error)
+
+func init() {
+	newAppClient = newAppClientReal
+}
+
+func newAppClientReal(appID int64, key []byte) (*github.Client, error) {
+	tr, err := ghinstallation.NewAppsTransport(http.DefaultTransport, appID, key)
+	if err != nil {
+		return nil, err
+	}
+	return github.NewClient(&http.Client{Transport: tr}), nil
+}
+
 type WebookHandler struct {
 	config Config
+	key    *ghclients.Secret
+	token  *ghclients.Secret
 }
 
 // Handle GitHub Webhooks for Review Bot.
@@ -44,7 +90,20 @@ type WebookHandler struct {
 //	config := Config{...}
 //	reviewbot.HandleWebhooks(&config)
 func HandleWebhooks(config *Config) error {
-	w := WebookHandler{*config}
+	ctx := context.Background()
+	key, token, err := loadSecrets(ctx, config)
+	if err != nil {
+		return err
+	}
+	if err := verifyApp(ctx, config.GitHub.AppId, key.Value(ctx)); err != nil {
+		return err
+	}
+	ghclients.ReloadOnSIGHUP(ctx, key, token)
+	w := WebookHandler{
+		config: *config,
+		key:    key,
+		token:  token,
+	}
 
 	http.HandleFunc("/", w.HandleRoot)
 
@@ -56,7 +115,7 @@ func HandleWebhooks(config *Config) error {
 // Handle the root path
 func (h *WebookHandler) HandleRoot(w http.ResponseWriter, r *http.Request) {
 	// Validate payload
-	payload, err := github.ValidatePayload(r, []byte(secretToken))
+	payload, err := github.ValidatePayload(r, h.token.Value(r.Context()))
 	if err != nil {
 		log.Error().Interface("payload", payload).Err(err).Msg("Got an invalid payload")
 		w.WriteHeader(400)
@@ -90,6 +149,19 @@ func (h *WebookHandler) HandleRoot(w http.ResponseWriter, r *http.Request) {
 			headSHA:        event.PullRequest.GetHead().GetSHA(),
 			number:         event.GetPullRequest().GetNumber(),
 		}
+	case *github.PullRequestReviewEvent:
+		pr = PullRequestInfo{
+			owner:          event.GetRepo().GetOwner().GetLogin(),
+			repo:           event.GetRepo().GetName(),
+			user:           event.GetPullRequest().GetUser().GetLogin(),
+			installationId: event.GetInstallation().GetID(),
+			headSHA:        event.GetPullRequest().GetHead().GetSHA(),
+			number:         event.GetPullRequest().GetNumber(),
+		}
+	case *github.PushEvent:
+		// Reload config if changed
+		handlePush(event)
+		return
 	default:
 		log.Warn().Interface("event", event).Msg("Unknown event")
 		w.WriteHeader(400)
@@ -102,7 +174,7 @@ func (h *WebookHandler) HandleRoot(w http.ResponseWriter, r *http.Request) {
 	log.Info().Interface("pr", pr).Msg("Handling Pull Request Review Event")
 
 	// Run PR Check
-	err = runPRCheck(h.config, pr)
+	err = runPRCheck(h.config, h.key.Value(r.Context()), pr)
 	if err != nil {
 		log.Error().Interface("pr", pr).Err(err).Msg("Error handling webhook")
 		w.WriteHeader(500)
@@ -112,3 +184,63 @@ func (h *WebookHandler) HandleRoot(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// verifyApp verifies the GitHub App ID and private key, and that the app has
+// the permissions reviewbot needs, so that misconfiguration fails at startup
+// rather than on each webhook.
+func verifyApp(ctx context.Context, appID int64, key []byte) error {
+	c, err := newAppClient(appID, key)
+	if err != nil {
+		return fmt.Errorf("while reading private key: %w", err)
+	}
+	var need []ghclients.Permission
+	for _, s := range requiredPermissions {
+		p, err := ghclients.ParsePermission(s, "reviewbot")
+		if err != nil {
+			return err
+		}
+		need = append(need, p)
+	}
+	app, missing, err := ghclients.VerifyApp(ctx, c, need)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return ghclients.MissingPermissionsError(app, missing)
+	}
+	return nil
+}
+
+// loadSecrets reads the private key and webhook secret token. The private key
+// is read from PrivateKeySecret, or else from the file at PrivateKeyPath, so
+// that a key rotated in place is also picked up.
+func loadSecrets(ctx context.Context, config *Config) (key, token *ghclients.Secret, err error) {
+	keySource := config.GitHub.PrivateKeySecret
+	if keySource == "" {
+		if config.GitHub.PrivateKeyPath == "" {
+			return nil, nil, fmt.Errorf("no private key configured")
+		}
+		path, err := filepath.Abs(config.GitHub.PrivateKeyPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		keySource = "file://" + filepath.ToSlash(path) + "?decoder=bytes"
+	}
+	key, err = ghclients.NewSecret(ctx, keySource, config.GitHub.SecretTTL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("while reading private key: %w", err)
+	}
+	if config.GitHub.SecretTokenSecret == "" {
+		token = ghclients.NewStaticSecret([]byte(config.GitHub.SecretToken))
+	} else {
+		token, err = ghclients.NewSecret(ctx, config.GitHub.SecretTokenSecret, config.GitHub.SecretTTL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("while reading secret token: %w", err)
+		}
+	}
+	// An empty token would accept unsigned payloads.
+	if len(token.Value(ctx)) == 0 {
+		return nil, nil, fmt.Errorf("no webhook secret token configured")
+	}
+	return key, token, nil
+}
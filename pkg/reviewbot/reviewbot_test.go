@@ -0,0 +1,68 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reviewbot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v59/github"
+)
+
+func TestVerifyApp(t *testing.T) {
+	perms := `"metadata": "read", "contents": "read", "pull_requests": "read", "members": "read", "checks": "write"`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/app" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"id": 1, "slug": "reviewbot", "permissions": {%s}}`, perms)
+	}))
+	defer srv.Close()
+	newAppClient = func(appID int64, key []byte) (*github.Client, error) {
+		c := github.NewClient(nil)
+		c.BaseURL, _ = url.Parse(srv.URL + "/")
+		return c, nil
+	}
+	defer func() { newAppClient = newAppClientReal }()
+
+	err := verifyApp(context.Background(), 1, nil)
+	if err == nil || !strings.Contains(err.Error(), "statuses:write") {
+		t.Errorf("Expected missing statuses:write, got: %v", err)
+	}
+
+	perms += `, "statuses": "write"`
+	if err := verifyApp(context.Background(), 1, nil); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestLoadSecretsEmptyToken(t *testing.T) {
+	config := &Config{}
+	config.GitHub.PrivateKeySecret = "env:REVIEWBOT_TEST_KEY"
+	t.Setenv("REVIEWBOT_TEST_KEY", "key")
+	if _, _, err := loadSecrets(context.Background(), config); err == nil {
+		t.Errorf("Expected error with no webhook secret token")
+	}
+	config.GitHub.SecretToken = "token"
+	if _, _, err := loadSecrets(context.Background(), config); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
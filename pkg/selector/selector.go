@@ -0,0 +1,240 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package selector selects repositories by name, language, and topic, for use
+// in policy and Allstar config.
+package selector
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/ossf/allstar/pkg/cache"
+
+	"github.com/google/go-github/v59/github"
+)
+
+// ExcludeDepthLimit is the number of levels of nested Exclude selectors that
+// are evaluated. Deeper exclusions are ignored.
+const ExcludeDepthLimit = 3
+
+// significantBytes is the number of bytes of a language in a repository for it
+// to be significant, along with the top language.
+const significantBytes = 3000
+
+var gc = cache.NewGlobCache(cache.DefaultSize)
+
+// RepoSelector specifies a selection of repos. A repo is selected if it
+// matches all of the fields that are set.
+type RepoSelector struct {
+	// Name is the repo name in glob format.
+	Name string `json:"name"`
+
+	// Language is a set of programming languages. The repo is selected if any
+	// of them is significantly present, either the top language of the repo or
+	// more than 3000 bytes of it.
+	Language []string `json:"language"`
+
+	// Topics is a set of repo topics. The repo is selected if it has any of
+	// them.
+	Topics []string `json:"topics"`
+
+	// Exclude is a set of RepoSelectors targeting repos that should
+	// not be matched by this selector.
+	Exclude []*RepoSelector `json:"exclude"`
+}
+
+// Repositories is used to look up the details of a repo, implemented by
+// github.RepositoriesService.
+type Repositories interface {
+	Get(context.Context, string, string) (*github.Repository,
+		*github.Response, error)
+	ListLanguages(context.Context, string, string) (map[string]int,
+		*github.Response, error)
+}
+
+// Repo is a repo to match against RepoSelectors. The details of the repo are
+// only looked up when a selector needs them, and are cached for the life of
+// the Repo, so a Repo should be created for each run of a policy.
+type Repo struct {
+	Owner string
+	Name  string
+
+	r     Repositories
+	mu    sync.Mutex
+	repo  *github.Repository
+	langs map[string]int
+}
+
+// NewRepo returns a Repo for owner/name, looking up its details with r.
+func NewRepo(r Repositories, owner, name string) *Repo {
+	return &Repo{
+		Owner: owner,
+		Name:  name,
+		r:     r,
+	}
+}
+
+// WithRepository sets the details of the repo, when already looked up by the
+// caller, and returns r.
+func (r *Repo) WithRepository(repo *github.Repository) *Repo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.repo = repo
+	return r
+}
+
+func (r *Repo) topics(ctx context.Context) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.repo == nil {
+		repo, _, err := r.r.Get(ctx, r.Owner, r.Name)
+		if err != nil {
+			return nil, err
+		}
+		r.repo = repo
+	}
+	return r.repo.Topics, nil
+}
+
+func (r *Repo) languages(ctx context.Context) (map[string]int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.langs == nil {
+		langs, _, err := r.r.ListLanguages(ctx, r.Owner, r.Name)
+		if err != nil {
+			return nil, err
+		}
+		if langs == nil {
+			langs = map[string]int{}
+		}
+		r.langs = langs
+	}
+	return r.langs, nil
+}
+
+// Match returns whether repo is selected by rs. A nil RepoSelector selects
+// all repos.
+func (rs *RepoSelector) Match(ctx context.Context, repo *Repo) (bool, error) {
+	return rs.match(ctx, repo, ExcludeDepthLimit)
+}
+
+func (rs *RepoSelector) match(ctx context.Context, repo *Repo, excludeDepth int) (bool, error) {
+	if rs == nil {
+		return true, nil
+	}
+	if rs.Name != "" {
+		match, err := MatchName(rs.Name, repo.Name)
+		if err != nil || !match {
+			return false, err
+		}
+	}
+	if rs.Language != nil {
+		langs, err := repo.languages(ctx)
+		if err != nil {
+			return false, err
+		}
+		if !LanguageSatisfied(langs, rs.Language) {
+			return false, nil
+		}
+	}
+	if rs.Topics != nil {
+		topics, err := repo.topics(ctx)
+		if err != nil {
+			return false, err
+		}
+		if !anyEqualFold(rs.Topics, topics) {
+			return false, nil
+		}
+	}
+	// Check if covered by exclusion case
+	if excludeDepth != 0 {
+		for _, exc := range rs.Exclude {
+			match, err := exc.match(ctx, repo, excludeDepth-1)
+			if err != nil {
+				// API error? Ignore exclusion
+				continue
+			}
+			if match {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+// MatchAny returns whether repo is selected by any of ss. Selectors that
+// return an error are skipped, and the first error is returned if no selector
+// matches.
+func MatchAny(ctx context.Context, ss []*RepoSelector, repo *Repo) (bool, error) {
+	var rerr error
+	for _, rs := range ss {
+		match, err := rs.Match(ctx, repo)
+		if err != nil {
+			if rerr == nil {
+				rerr = err
+			}
+			continue
+		}
+		if match {
+			return true, nil
+		}
+	}
+	return false, rerr
+}
+
+// MatchName returns whether the repo name matches glob, using a shared cache
+// of compiled globs.
+func MatchName(glob, name string) (bool, error) {
+	g, err := gc.Compile(glob)
+	if err != nil {
+		return false, err
+	}
+	return g.Match(name), nil
+}
+
+// LanguageSatisfied determines from a map of languages to bytes whether the
+// queried languages are significantly present.
+func LanguageSatisfied(langs map[string]int, want []string) bool {
+	topLangBytes := 0
+	topLang := ""
+
+	var significantLanguages []string
+
+	for l, b := range langs {
+		if topLang == "" || topLangBytes < b {
+			topLang = l
+			topLangBytes = b
+		}
+		if b > significantBytes {
+			significantLanguages = append(significantLanguages, l)
+		}
+	}
+
+	significantLanguages = append(significantLanguages, topLang)
+
+	return anyEqualFold(want, significantLanguages)
+}
+
+func anyEqualFold(want, have []string) bool {
+	for _, w := range want {
+		for _, h := range have {
+			if strings.EqualFold(w, h) {
+				return true
+			}
+		}
+	}
+	return false
+}
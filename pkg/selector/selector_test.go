@@ -0,0 +1,185 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selector
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-github/v59/github"
+)
+
+type mockRepos struct {
+	repo  *github.Repository
+	langs map[string]int
+	err   error
+	gets  int
+	lists int
+}
+
+func (m *mockRepos) Get(ctx context.Context, owner, repo string) (*github.Repository,
+	*github.Response, error) {
+	m.gets++
+	return m.repo, nil, m.err
+}
+
+func (m *mockRepos) ListLanguages(ctx context.Context, owner, repo string) (map[string]int,
+	*github.Response, error) {
+	m.lists++
+	return m.langs, nil, m.err
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Selector *RepoSelector
+		Expect   bool
+	}{
+		{
+			Name:     "Nil",
+			Selector: nil,
+			Expect:   true,
+		},
+		{
+			Name:     "Empty",
+			Selector: &RepoSelector{},
+			Expect:   true,
+		},
+		{
+			Name:     "Name",
+			Selector: &RepoSelector{Name: "api-*"},
+			Expect:   true,
+		},
+		{
+			Name:     "NameMismatch",
+			Selector: &RepoSelector{Name: "web-*"},
+			Expect:   false,
+		},
+		{
+			Name:     "Topic",
+			Selector: &RepoSelector{Topics: []string{"frontend", "Critical"}},
+			Expect:   true,
+		},
+		{
+			Name:     "TopicMismatch",
+			Selector: &RepoSelector{Topics: []string{"frontend"}},
+			Expect:   false,
+		},
+		{
+			Name:     "TopLanguage",
+			Selector: &RepoSelector{Language: []string{"go"}},
+			Expect:   true,
+		},
+		{
+			Name:     "SignificantLanguage",
+			Selector: &RepoSelector{Language: []string{"shell"}},
+			Expect:   true,
+		},
+		{
+			Name:     "InsignificantLanguage",
+			Selector: &RepoSelector{Language: []string{"makefile"}},
+			Expect:   false,
+		},
+		{
+			Name:     "AllFields",
+			Selector: &RepoSelector{Name: "api-*", Topics: []string{"critical"}, Language: []string{"python"}},
+			Expect:   false,
+		},
+		{
+			Name: "Exclude",
+			Selector: &RepoSelector{
+				Topics:  []string{"critical"},
+				Exclude: []*RepoSelector{{Name: "*-server"}},
+			},
+			Expect: false,
+		},
+		{
+			Name: "ExcludeMismatch",
+			Selector: &RepoSelector{
+				Topics:  []string{"critical"},
+				Exclude: []*RepoSelector{{Name: "*-client"}},
+			},
+			Expect: true,
+		},
+		{
+			Name: "ExcludeExclude",
+			Selector: &RepoSelector{
+				Exclude: []*RepoSelector{{
+					Name:    "*-server",
+					Exclude: []*RepoSelector{{Language: []string{"go"}}},
+				}},
+			},
+			Expect: true,
+		},
+	}
+	m := &mockRepos{
+		repo: &github.Repository{
+			Topics: []string{"critical", "backend"},
+		},
+		langs: map[string]int{
+			"Go":       50000,
+			"Shell":    4000,
+			"Makefile": 200,
+		},
+	}
+	repo := NewRepo(m, "acme", "api-server")
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			got, err := test.Selector.Match(context.Background(), repo)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != test.Expect {
+				t.Errorf("Unexpected match: %v", got)
+			}
+		})
+	}
+	if m.gets != 1 || m.lists != 1 {
+		t.Errorf("Expected repo details to be looked up once, got: %v gets, %v lists", m.gets, m.lists)
+	}
+}
+
+func TestMatchAny(t *testing.T) {
+	m := &mockRepos{err: errors.New("api error")}
+	repo := NewRepo(m, "acme", "api-server")
+	ss := []*RepoSelector{
+		{Language: []string{"go"}},
+		{Name: "api-*"},
+	}
+	if got, err := MatchAny(context.Background(), ss, repo); err != nil || !got {
+		t.Errorf("Expected match, got: %v, %v", got, err)
+	}
+	if got, err := MatchAny(context.Background(), ss[:1], repo); err == nil || got {
+		t.Errorf("Expected error, got: %v, %v", got, err)
+	}
+	if got, err := MatchAny(context.Background(), nil, repo); err != nil || got {
+		t.Errorf("Expected no match, got: %v, %v", got, err)
+	}
+}
+
+func TestWithRepository(t *testing.T) {
+	m := &mockRepos{}
+	repo := NewRepo(m, "acme", "web").WithRepository(&github.Repository{
+		Topics: []string{"frontend"},
+	})
+	got, err := (&RepoSelector{Topics: []string{"frontend"}}).Match(context.Background(), repo)
+	if err != nil || !got {
+		t.Errorf("Expected match, got: %v, %v", got, err)
+	}
+	if m.gets != 0 {
+		t.Errorf("Unexpected repo lookup")
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bucket is an object storage state backend, each key stored as an
+// object, suitable for deployments without a persistent disk. Importing it
+// registers the "gs" (Google Cloud Storage), "s3" (Amazon S3), and "file"
+// (local directory) schemes, eg: "gs://my-bucket?prefix=allstar/". URLs are
+// opened with gocloud.dev/blob, see its documentation for the supported
+// parameters.
+package bucket
+
+import (
+	"context"
+	"io"
+
+	"github.com/ossf/allstar/pkg/state"
+
+	"gocloud.dev/blob"
+	_ "gocloud.dev/blob/fileblob"
+	_ "gocloud.dev/blob/gcsblob"
+	_ "gocloud.dev/blob/s3blob"
+	"gocloud.dev/gcerrors"
+)
+
+func init() {
+	for _, scheme := range []string{"gs", "s3", "file"} {
+		state.Register(scheme, func(ctx context.Context, url string) (state.Interface, error) {
+			return Open(ctx, url)
+		})
+	}
+}
+
+// Bucket is a state.Interface backed by an object storage bucket.
+type Bucket struct {
+	b *blob.Bucket
+}
+
+// Open opens the bucket at url.
+func Open(ctx context.Context, url string) (*Bucket, error) {
+	b, err := blob.OpenBucket(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return &Bucket{b: b}, nil
+}
+
+// Get implements state.Interface.
+func (b *Bucket) Get(ctx context.Context, key string) ([]byte, error) {
+	v, err := b.b.ReadAll(ctx, key)
+	if gcerrors.Code(err) == gcerrors.NotFound {
+		return nil, state.ErrNotFound
+	}
+	return v, err
+}
+
+// Put implements state.Interface.
+func (b *Bucket) Put(ctx context.Context, key string, value []byte) error {
+	return b.b.WriteAll(ctx, key, value, nil)
+}
+
+// Delete implements state.Interface.
+func (b *Bucket) Delete(ctx context.Context, key string) error {
+	err := b.b.Delete(ctx, key)
+	if gcerrors.Code(err) == gcerrors.NotFound {
+		return nil
+	}
+	return err
+}
+
+// List implements state.Interface. Objects are listed in lexicographical
+// order.
+func (b *Bucket) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	it := b.b.List(&blob.ListOptions{Prefix: prefix})
+	for {
+		o, err := it.Next(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, o.Key)
+	}
+	return keys, nil
+}
+
+// Close implements state.Interface.
+func (b *Bucket) Close() error {
+	return b.b.Close()
+}
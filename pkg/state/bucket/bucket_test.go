@@ -0,0 +1,32 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bucket
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ossf/allstar/pkg/state"
+	"github.com/ossf/allstar/pkg/state/statetest"
+)
+
+func TestBucket(t *testing.T) {
+	s, err := state.Open(context.Background(), "file://"+t.TempDir()+"?metadata=skip")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer s.Close()
+	statetest.Run(t, s)
+}
@@ -0,0 +1,118 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sqlite is an embedded SQLite state backend, suitable for
+// single-instance deployments with a persistent disk. Importing it registers
+// the "sqlite" scheme, eg: "sqlite:///var/lib/allstar/state.db".
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ossf/allstar/pkg/state"
+
+	_ "modernc.org/sqlite"
+)
+
+const scheme = "sqlite"
+
+const schema = `
+CREATE TABLE IF NOT EXISTS state (
+	key     TEXT PRIMARY KEY,
+	value   BLOB NOT NULL,
+	updated INTEGER NOT NULL
+);
+`
+
+func init() {
+	state.Register(scheme, func(ctx context.Context, url string) (state.Interface, error) {
+		return Open(ctx, strings.TrimPrefix(url, scheme+"://"))
+	})
+}
+
+// DB is a state.Interface backed by a SQLite database file.
+type DB struct {
+	db *sql.DB
+}
+
+// Open opens, or creates, the SQLite database at path. Use ":memory:" for a
+// database that is not persisted.
+func Open(ctx context.Context, path string) (*DB, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer, and each connection to ":memory:" is a
+	// separate database.
+	db.SetMaxOpenConns(1)
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &DB{db: db}, nil
+}
+
+// Get implements state.Interface.
+func (d *DB) Get(ctx context.Context, key string) ([]byte, error) {
+	var v []byte
+	err := d.db.QueryRowContext(ctx, "SELECT value FROM state WHERE key = ?", key).Scan(&v)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, state.ErrNotFound
+	}
+	return v, err
+}
+
+// Put implements state.Interface.
+func (d *DB) Put(ctx context.Context, key string, value []byte) error {
+	_, err := d.db.ExecContext(ctx,
+		"INSERT INTO state (key, value, updated) VALUES (?, ?, ?) ON CONFLICT (key) DO UPDATE SET value = excluded.value, updated = excluded.updated",
+		key, value, time.Now().UnixNano())
+	return err
+}
+
+// Delete implements state.Interface.
+func (d *DB) Delete(ctx context.Context, key string) error {
+	_, err := d.db.ExecContext(ctx, "DELETE FROM state WHERE key = ?", key)
+	return err
+}
+
+// List implements state.Interface.
+func (d *DB) List(ctx context.Context, prefix string) ([]string, error) {
+	// Compare with substr rather than LIKE, which treats % and _ in the
+	// escaped keys as wildcards.
+	rows, err := d.db.QueryContext(ctx,
+		"SELECT key FROM state WHERE substr(key, 1, length(?)) = ? ORDER BY key", prefix, prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var keys []string
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// Close implements state.Interface.
+func (d *DB) Close() error {
+	return d.db.Close()
+}
@@ -0,0 +1,59 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/ossf/allstar/pkg/state"
+	"github.com/ossf/allstar/pkg/state/statetest"
+)
+
+func TestDB(t *testing.T) {
+	db, err := Open(context.Background(), ":memory:")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer db.Close()
+	statetest.Run(t, db)
+}
+
+func TestReopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "state.db")
+	s, err := state.Open(ctx, "sqlite://"+path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := s.Put(ctx, "k", []byte("v")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s.Close()
+
+	s, err = state.Open(ctx, "sqlite://"+path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer s.Close()
+	v, err := s.Get(ctx, "k")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(v) != "v" {
+		t.Errorf("Unexpected value: %q", v)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package state defines the interface used to persist state across
+// enforcement runs and restarts, such as when a violation was first seen, so
+// that it does not need to be rediscovered each run.
+//
+// State is stored as values under slash separated keys, built with Key, eg:
+// "enforce/firstseen/acme/repo/Branch Protection". Each feature uses its own
+// top-level key.
+//
+// Backends implement Interface and register an opener for their URL scheme
+// with Register, as with the storage package. The in-memory backend, "mem://",
+// is always registered. See the bucket and sqlite subpackages for persistent
+// backends.
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound is returned when no value is stored at a key.
+var ErrNotFound = errors.New("state: not found")
+
+// Interface is implemented by state backends. Implementations must be safe for
+// concurrent use.
+type Interface interface {
+	// Get returns the value stored at key. Returns ErrNotFound if there is
+	// none.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Put stores value at key, replacing any previous value.
+	Put(ctx context.Context, key string, value []byte) error
+
+	// Delete removes the value stored at key. Deleting a missing key is not
+	// an error.
+	Delete(ctx context.Context, key string) error
+
+	// List returns the keys starting with prefix, sorted.
+	List(ctx context.Context, prefix string) ([]string, error)
+
+	// Close releases any resources held by the backend.
+	Close() error
+}
+
+// Key joins parts into a key. Each part is escaped, so that owner, repo, and
+// policy names containing a slash do not collide with other keys.
+func Key(parts ...string) string {
+	escaped := make([]string, len(parts))
+	for i, p := range parts {
+		escaped[i] = url.PathEscape(p)
+	}
+	return strings.Join(escaped, "/")
+}
+
+// GetJSON decodes the JSON value stored at key into v. Returns ErrNotFound if
+// there is none.
+func GetJSON(ctx context.Context, s Interface, key string, v interface{}) error {
+	b, err := s.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// PutJSON stores v at key, encoded as JSON.
+func PutJSON(ctx context.Context, s Interface, key string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, key, b)
+}
+
+// Opener opens a backend from a URL, eg: "sqlite:///var/lib/allstar/state.db".
+type Opener func(ctx context.Context, url string) (Interface, error)
+
+var openers = make(map[string]Opener)
+var openersMu sync.Mutex
+
+func init() {
+	Register("mem", func(ctx context.Context, url string) (Interface, error) {
+		return NewMemory(), nil
+	})
+}
+
+// Register makes a backend available to Open for URLs with the provided
+// scheme. It panics if the scheme is registered twice.
+func Register(scheme string, o Opener) {
+	openersMu.Lock()
+	defer openersMu.Unlock()
+	if _, ok := openers[scheme]; ok {
+		panic(fmt.Sprintf("state: Register called twice for scheme %v", scheme))
+	}
+	openers[scheme] = o
+}
+
+// Open opens the backend registered for the scheme of url.
+func Open(ctx context.Context, url string) (Interface, error) {
+	scheme, _, ok := strings.Cut(url, "://")
+	if !ok {
+		return nil, fmt.Errorf("state: invalid URL %q, expected scheme://", url)
+	}
+	openersMu.Lock()
+	o, ok := openers[scheme]
+	openersMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("state: unknown scheme %q, registered: %v", scheme, schemes())
+	}
+	return o(ctx, url)
+}
+
+func schemes() []string {
+	openersMu.Lock()
+	defer openersMu.Unlock()
+	var s []string
+	for k := range openers {
+		s = append(s, k)
+	}
+	sort.Strings(s)
+	return s
+}
+
+// Memory is an in-memory Interface, for tests and single-instance
+// deployments that do not need state to survive restarts.
+type Memory struct {
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+// NewMemory returns an empty in-memory backend.
+func NewMemory() *Memory {
+	return &Memory{
+		values: make(map[string][]byte),
+	}
+}
+
+// Get implements Interface.
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.values[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte{}, v...), nil
+}
+
+// Put implements Interface.
+func (m *Memory) Put(ctx context.Context, key string, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[key] = append([]byte{}, value...)
+	return nil
+}
+
+// Delete implements Interface.
+func (m *Memory) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.values, key)
+	return nil
+}
+
+// List implements Interface.
+func (m *Memory) List(ctx context.Context, prefix string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []string
+	for k := range m.values {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Close implements Interface.
+func (m *Memory) Close() error {
+	return nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ossf/allstar/pkg/state"
+	"github.com/ossf/allstar/pkg/state/statetest"
+)
+
+func TestMemory(t *testing.T) {
+	statetest.Run(t, state.NewMemory())
+}
+
+func TestOpen(t *testing.T) {
+	var gotURL string
+	state.Register("test", func(ctx context.Context, url string) (state.Interface, error) {
+		gotURL = url
+		return nil, nil
+	})
+
+	if _, err := state.Open(context.Background(), "test://somewhere"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if gotURL != "test://somewhere" {
+		t.Errorf("Unexpected url passed to opener: %v", gotURL)
+	}
+	if s, err := state.Open(context.Background(), "mem://"); err != nil || s == nil {
+		t.Errorf("Unexpected error opening memory backend: %v", err)
+	}
+	if _, err := state.Open(context.Background(), "other://somewhere"); err == nil {
+		t.Errorf("Expected error for unknown scheme")
+	}
+	if _, err := state.Open(context.Background(), "/path/only"); err == nil {
+		t.Errorf("Expected error for missing scheme")
+	}
+}
+
+func TestKey(t *testing.T) {
+	if got := state.Key("enforce", "acme", "a/b", "Branch Protection"); got != "enforce/acme/a%2Fb/Branch%20Protection" {
+		t.Errorf("Unexpected key: %q", got)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package statetest provides a conformance test for state backends.
+package statetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ossf/allstar/pkg/state"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// Run tests that the empty backend s implements state.Interface.
+func Run(t *testing.T, s state.Interface) {
+	ctx := context.Background()
+	a := state.Key("enforce", "acme", "repo")
+	b := state.Key("enforce", "acme", "repo/other")
+	c := state.Key("issue", "acme", "repo")
+
+	if _, err := s.Get(ctx, a); !errors.Is(err, state.ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound for missing key, got: %v", err)
+	}
+	for k, v := range map[string]string{a: "one", b: "two", c: "three"} {
+		if err := s.Put(ctx, k, []byte(v)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := s.Put(ctx, a, []byte("updated")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got, err := s.Get(ctx, a)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(got) != "updated" {
+		t.Errorf("Unexpected value: %q", got)
+	}
+
+	keys, err := s.List(ctx, state.Key("enforce")+"/")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{a, b}, keys); diff != "" {
+		t.Errorf("Unexpected keys. (-want +got):\n%s", diff)
+	}
+
+	if err := s.Delete(ctx, a); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := s.Delete(ctx, a); err != nil {
+		t.Fatalf("Unexpected error deleting missing key: %v", err)
+	}
+	if _, err := s.Get(ctx, a); !errors.Is(err, state.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for deleted key, got: %v", err)
+	}
+
+	var v struct{ Count int }
+	if err := state.PutJSON(ctx, s, c, struct{ Count int }{3}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := state.GetJSON(ctx, s, c, &v); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if v.Count != 3 {
+		t.Errorf("Unexpected JSON value: %+v", v)
+	}
+}
@@ -0,0 +1,265 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sqlite is an embedded SQLite results storage backend, suitable for
+// single-instance deployments. Importing it registers the "sqlite" scheme, eg:
+// "sqlite:///var/lib/allstar/results.db".
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ossf/allstar/pkg/storage"
+
+	_ "modernc.org/sqlite"
+)
+
+const scheme = "sqlite"
+
+const schema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id       INTEGER PRIMARY KEY AUTOINCREMENT,
+	run_id   TEXT NOT NULL DEFAULT '',
+	started  INTEGER NOT NULL,
+	finished INTEGER NOT NULL,
+	policy   TEXT NOT NULL,
+	repo     TEXT NOT NULL,
+	summary  TEXT NOT NULL,
+	error    TEXT NOT NULL,
+	counts   TEXT NOT NULL DEFAULT '{}'
+);
+CREATE INDEX IF NOT EXISTS runs_started ON runs (started);
+CREATE TABLE IF NOT EXISTS results (
+	run_id INTEGER NOT NULL,
+	owner  TEXT NOT NULL,
+	repo   TEXT NOT NULL,
+	policy TEXT NOT NULL,
+	pass   INTEGER NOT NULL,
+	enforcement_id TEXT NOT NULL DEFAULT '',
+	grace_period INTEGER NOT NULL DEFAULT 0,
+	api_calls INTEGER NOT NULL DEFAULT 0,
+	api_cost INTEGER NOT NULL DEFAULT 0,
+	issue_fallback TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS results_run_id ON results (run_id);
+`
+
+// migrations add the columns missing from databases created by earlier
+// versions, by table.
+var migrations = []struct {
+	table, column, def string
+}{
+	{"runs", "run_id", "TEXT NOT NULL DEFAULT ''"},
+	{"runs", "counts", "TEXT NOT NULL DEFAULT '{}'"},
+	{"results", "enforcement_id", "TEXT NOT NULL DEFAULT ''"},
+	{"results", "grace_period", "INTEGER NOT NULL DEFAULT 0"},
+	{"results", "api_calls", "INTEGER NOT NULL DEFAULT 0"},
+	{"results", "api_cost", "INTEGER NOT NULL DEFAULT 0"},
+	{"results", "issue_fallback", "TEXT NOT NULL DEFAULT ''"},
+}
+
+func init() {
+	storage.Register(scheme, func(ctx context.Context, url string) (storage.Interface, error) {
+		return Open(ctx, strings.TrimPrefix(url, scheme+"://"))
+	})
+}
+
+// DB is a storage.Interface backed by a SQLite database file.
+type DB struct {
+	db *sql.DB
+}
+
+// Open opens, or creates, the SQLite database at path. Use ":memory:" for a
+// database that is not persisted.
+func Open(ctx context.Context, path string) (*DB, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer, and each connection to ":memory:" is a
+	// separate database.
+	db.SetMaxOpenConns(1)
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &DB{db: db}, nil
+}
+
+func migrate(ctx context.Context, db *sql.DB) error {
+	for _, m := range migrations {
+		var n int
+		if err := db.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", m.table, m.column).Scan(&n); err != nil {
+			return err
+		}
+		if n > 0 {
+			continue
+		}
+		if _, err := db.ExecContext(ctx,
+			fmt.Sprintf("ALTER TABLE %v ADD COLUMN %v %v", m.table, m.column, m.def)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveRunResult implements storage.Interface.
+func (d *DB) SaveRunResult(ctx context.Context, r *storage.RunResult) error {
+	summary, err := json.Marshal(r.Summary)
+	if err != nil {
+		return err
+	}
+	counts, err := json.Marshal(r.Counts)
+	if err != nil {
+		return err
+	}
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	res, err := tx.ExecContext(ctx,
+		"INSERT INTO runs (run_id, started, finished, policy, repo, summary, counts, error) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		r.RunID, r.Started.UnixNano(), r.Finished.UnixNano(), r.Policy, r.Repo, string(summary), string(counts), r.Error)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.PrepareContext(ctx,
+		"INSERT INTO results (run_id, owner, repo, policy, pass, enforcement_id, grace_period, api_calls, api_cost, issue_fallback) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, pr := range r.Results {
+		if _, err := stmt.ExecContext(ctx, id, pr.Owner, pr.Repo, pr.Policy, pr.Pass, pr.EnforcementID, pr.GracePeriod, pr.APICalls, pr.APICost, pr.IssueFallback); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	r.ID = id
+	return nil
+}
+
+// GetLatest implements storage.Interface.
+func (d *DB) GetLatest(ctx context.Context) (*storage.RunResult, error) {
+	runs, err := d.listRuns(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, storage.ErrNotFound
+	}
+	r := runs[0]
+	rows, err := d.db.QueryContext(ctx,
+		"SELECT owner, repo, policy, pass, enforcement_id, grace_period, api_calls, api_cost, issue_fallback FROM results WHERE run_id = ? ORDER BY rowid", r.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var pr storage.PolicyResult
+		if err := rows.Scan(&pr.Owner, &pr.Repo, &pr.Policy, &pr.Pass, &pr.EnforcementID, &pr.GracePeriod, &pr.APICalls, &pr.APICost, &pr.IssueFallback); err != nil {
+			return nil, err
+		}
+		r.Results = append(r.Results, pr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ListRuns implements storage.Interface.
+func (d *DB) ListRuns(ctx context.Context, limit int) ([]*storage.RunResult, error) {
+	return d.listRuns(ctx, limit)
+}
+
+func (d *DB) listRuns(ctx context.Context, limit int) ([]*storage.RunResult, error) {
+	rows, err := d.db.QueryContext(ctx,
+		"SELECT id, run_id, started, finished, policy, repo, summary, counts, error FROM runs ORDER BY started DESC, id DESC LIMIT ?",
+		limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var runs []*storage.RunResult
+	for rows.Next() {
+		var r storage.RunResult
+		var started, finished int64
+		var summary, counts string
+		if err := rows.Scan(&r.ID, &r.RunID, &started, &finished, &r.Policy, &r.Repo, &summary, &counts, &r.Error); err != nil {
+			return nil, err
+		}
+		r.Started = time.Unix(0, started).UTC()
+		r.Finished = time.Unix(0, finished).UTC()
+		if err := json.Unmarshal([]byte(summary), &r.Summary); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(counts), &r.Counts); err != nil {
+			return nil, err
+		}
+		runs = append(runs, &r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// Prune implements storage.Interface.
+func (d *DB) Prune(ctx context.Context, before time.Time) (int, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	b := before.UnixNano()
+	if _, err := tx.ExecContext(ctx,
+		"DELETE FROM results WHERE run_id IN (SELECT id FROM runs WHERE started < ?)", b); err != nil {
+		return 0, err
+	}
+	res, err := tx.ExecContext(ctx, "DELETE FROM runs WHERE started < ?", b)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
+
+// Close implements storage.Interface.
+func (d *DB) Close() error {
+	return d.db.Close()
+}
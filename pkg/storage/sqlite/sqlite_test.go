@@ -0,0 +1,211 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ossf/allstar/pkg/storage"
+)
+
+func TestStorage(t *testing.T) {
+	ctx := context.Background()
+	db, err := Open(ctx, ":memory:")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.GetLatest(ctx); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	base := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	runs := []*storage.RunResult{
+		{
+			Started:  base,
+			Finished: base.Add(time.Minute),
+			Summary:  map[string]map[string]int{"Branch Protection": {"totalFailed": 1}},
+			Results: []storage.PolicyResult{
+				{Owner: "org", Repo: "a", Policy: "Branch Protection", Pass: false},
+			},
+		},
+		{
+			RunID:    "run1",
+			Started:  base.Add(time.Hour),
+			Finished: base.Add(time.Hour + time.Minute),
+			Policy:   "SECURITY.md",
+			Repo:     "org/b",
+			Summary:  map[string]map[string]int{},
+			Counts: storage.RunCounts{
+				Skipped: 1,
+				Errors:  map[string]int{"org/c": 3},
+			},
+			Results: []storage.PolicyResult{
+				{Owner: "org", Repo: "b", Policy: "SECURITY.md", Pass: true, EnforcementID: "eval1", APICalls: 3, APICost: 2},
+				{Owner: "org", Repo: "b", Policy: "CODEOWNERS", Pass: false, EnforcementID: "eval1", GracePeriod: true},
+			},
+			Error: "context canceled",
+		},
+	}
+	for i, r := range runs {
+		if err := db.SaveRunResult(ctx, r); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if r.ID != int64(i+1) {
+			t.Errorf("Unexpected ID: %v", r.ID)
+		}
+	}
+
+	latest, err := db.GetLatest(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(runs[1], latest); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+
+	list, err := db.ListRuns(ctx, 10)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var ids []int64
+	for _, r := range list {
+		if r.Results != nil {
+			t.Errorf("Expected ListRuns to not populate Results")
+		}
+		ids = append(ids, r.ID)
+	}
+	if diff := cmp.Diff([]int64{2, 1}, ids); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+
+	n, err := db.Prune(ctx, base.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 pruned run, got %v", n)
+	}
+	list, err = db.ListRuns(ctx, 10)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(list) != 1 || list[0].ID != 2 {
+		t.Errorf("Unexpected runs after prune: %+v", list)
+	}
+	var count int
+	if err := db.db.QueryRow("SELECT COUNT(*) FROM results").Scan(&count); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected results of pruned run to be deleted, %v remain", count)
+	}
+}
+
+func TestOpenURL(t *testing.T) {
+	ctx := context.Background()
+	p := filepath.Join(t.TempDir(), "results.db")
+	s, err := storage.Open(ctx, "sqlite://"+p)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	r := &storage.RunResult{Started: time.Now(), Finished: time.Now()}
+	if err := s.SaveRunResult(ctx, r); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Results persist across opens.
+	db, err := Open(ctx, p)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer db.Close()
+	latest, err := db.GetLatest(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if latest.ID != r.ID {
+		t.Errorf("Unexpected ID: %v, want %v", latest.ID, r.ID)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	p := filepath.Join(t.TempDir(), "results.db")
+	old, err := sql.Open("sqlite", p)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Schema before run and enforcement IDs, grace periods, and API costs, were
+	// added.
+	if _, err := old.ExecContext(ctx, `
+CREATE TABLE runs (
+	id       INTEGER PRIMARY KEY AUTOINCREMENT,
+	started  INTEGER NOT NULL,
+	finished INTEGER NOT NULL,
+	policy   TEXT NOT NULL,
+	repo     TEXT NOT NULL,
+	summary  TEXT NOT NULL,
+	error    TEXT NOT NULL
+);
+CREATE TABLE results (
+	run_id INTEGER NOT NULL,
+	owner  TEXT NOT NULL,
+	repo   TEXT NOT NULL,
+	policy TEXT NOT NULL,
+	pass   INTEGER NOT NULL
+);
+INSERT INTO runs (started, finished, policy, repo, summary, error) VALUES (1, 2, '', '', '{}', '');
+INSERT INTO results (run_id, owner, repo, policy, pass) VALUES (1, 'org', 'a', 'CODEOWNERS', 1);
+`); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	old.Close()
+
+	db, err := Open(ctx, p)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer db.Close()
+	latest, err := db.GetLatest(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	exp := []storage.PolicyResult{{Owner: "org", Repo: "a", Policy: "CODEOWNERS", Pass: true}}
+	if diff := cmp.Diff(exp, latest.Results); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+	r := &storage.RunResult{RunID: "run", Started: time.Now(), Finished: time.Now()}
+	if err := db.SaveRunResult(ctx, r); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	latest, err = db.GetLatest(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if latest.RunID != "run" {
+		t.Errorf("Unexpected run ID: %q", latest.RunID)
+	}
+}
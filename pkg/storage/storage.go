@@ -0,0 +1,194 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package storage defines the interface used to persist the results of
+// enforcement runs, for reporting.
+//
+// Backends implement Interface and register an opener for their URL scheme
+// with Register, usually from an init function. The binary then only needs to
+// import the backend package, as with database/sql drivers. See the sqlite
+// subpackage for an example.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when no stored run matches a request.
+var ErrNotFound = errors.New("storage: not found")
+
+// PolicyResult is the result of one policy on one repository.
+type PolicyResult struct {
+	Owner  string `json:"owner"`
+	Repo   string `json:"repo"`
+	Policy string `json:"policy"`
+	Pass   bool   `json:"pass"`
+
+	// GracePeriod is set when the policy failed on a new repository in its
+	// grace period, so no action was taken, or when the violation is in the
+	// policy's grace period, so the issue or fix action was held back.
+	GracePeriod bool `json:"gracePeriod,omitempty"`
+
+	// EnforcementID is the ID of the evaluation of the repository, as
+	// included in issues, notifications, and logs.
+	EnforcementID string `json:"enforcementId,omitempty"`
+
+	// APICalls is the number of GitHub API requests made to evaluate the
+	// policy, and APICost is how many of them counted against the rate
+	// limit.
+	APICalls int `json:"apiCalls,omitempty"`
+	APICost  int `json:"apiCost,omitempty"`
+
+	// IssueFallback is set when the policy failed with the issue action on a
+	// repository with issues disabled, to where and why the violation was
+	// reported instead.
+	IssueFallback string `json:"issueFallback,omitempty"`
+}
+
+// RunCounts are the counts of a run that are not the result of a policy.
+type RunCounts struct {
+	// Skipped is the number of repos where running policies failed with an
+	// error, and were skipped.
+	Skipped int `json:"skipped,omitempty"`
+
+	// Errors lists the skipped repos, as "owner/repo", with the number of
+	// attempts made on each.
+	Errors map[string]int `json:"errors,omitempty"`
+
+	// Excluded is the number of repos excluded by the operator repo allow and
+	// deny lists.
+	Excluded int `json:"excluded,omitempty"`
+
+	// Suspended is the number of suspended installations.
+	Suspended int `json:"suspended,omitempty"`
+
+	// NotMonitored lists the accounts of the suspended installations, which
+	// are not monitored.
+	NotMonitored []string `json:"notMonitored,omitempty"`
+}
+
+// Add adds the counts of o to c.
+func (c *RunCounts) Add(o RunCounts) {
+	c.Skipped += o.Skipped
+	for repo, attempts := range o.Errors {
+		if c.Errors == nil {
+			c.Errors = make(map[string]int)
+		}
+		c.Errors[repo] = attempts
+	}
+	c.Excluded += o.Excluded
+	c.Suspended += o.Suspended
+	c.NotMonitored = append(c.NotMonitored, o.NotMonitored...)
+}
+
+// RunResult is the result of one enforcement run across all installations.
+type RunResult struct {
+	// ID is assigned by the backend when the run is saved.
+	ID int64 `json:"id"`
+
+	// RunID is the unique ID of the run, as included in logs.
+	RunID string `json:"runId,omitempty"`
+
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+
+	// Policy and Repo are the filters the run was limited to, if any.
+	Policy string `json:"policy,omitempty"`
+	Repo   string `json:"repo,omitempty"`
+
+	// Summary holds the aggregated counts of each policy returned by
+	// enforce.EnforceAll, keyed by policy name.
+	Summary map[string]map[string]int `json:"summary"`
+
+	// Counts holds the counts of the run that are not the result of a
+	// policy, such as the skipped repos.
+	Counts RunCounts `json:"counts"`
+
+	// Results holds the result of each enabled policy on each repository.
+	Results []PolicyResult `json:"results,omitempty"`
+
+	// Error is set if the run did not complete.
+	Error string `json:"error,omitempty"`
+}
+
+// Interface is implemented by results storage backends. Implementations must
+// be safe for concurrent use.
+type Interface interface {
+	// SaveRunResult stores r, including its Results, and sets r.ID.
+	SaveRunResult(ctx context.Context, r *RunResult) error
+
+	// GetLatest returns the most recently started run, including its Results.
+	// Returns ErrNotFound if no runs are stored.
+	GetLatest(ctx context.Context) (*RunResult, error)
+
+	// ListRuns returns up to limit runs, most recently started first. Results
+	// are not populated, use GetLatest for those.
+	ListRuns(ctx context.Context, limit int) ([]*RunResult, error)
+
+	// Prune deletes runs started before the provided time, and returns the
+	// number deleted.
+	Prune(ctx context.Context, before time.Time) (int, error)
+
+	// Close releases any resources held by the backend.
+	Close() error
+}
+
+// Opener opens a backend from a URL, eg: "sqlite:///var/lib/allstar.db".
+type Opener func(ctx context.Context, url string) (Interface, error)
+
+var openers = make(map[string]Opener)
+var openersMu sync.Mutex
+
+// Register makes a backend available to Open for URLs with the provided
+// scheme. It panics if the scheme is registered twice.
+func Register(scheme string, o Opener) {
+	openersMu.Lock()
+	defer openersMu.Unlock()
+	if _, ok := openers[scheme]; ok {
+		panic(fmt.Sprintf("storage: Register called twice for scheme %v", scheme))
+	}
+	openers[scheme] = o
+}
+
+// Open opens the backend registered for the scheme of url.
+func Open(ctx context.Context, url string) (Interface, error) {
+	scheme, _, ok := strings.Cut(url, "://")
+	if !ok {
+		return nil, fmt.Errorf("storage: invalid URL %q, expected scheme://", url)
+	}
+	openersMu.Lock()
+	o, ok := openers[scheme]
+	openersMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("storage: unknown scheme %q, registered: %v", scheme, schemes())
+	}
+	return o(ctx, url)
+}
+
+func schemes() []string {
+	openersMu.Lock()
+	defer openersMu.Unlock()
+	var s []string
+	for k := range openers {
+		s = append(s, k)
+	}
+	sort.Strings(s)
+	return s
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 Allstar Authors
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestOpen(t *testing.T) {
+	var gotURL string
+	Register("test", func(ctx context.Context, url string) (Interface, error) {
+		gotURL = url
+		return nil, nil
+	})
+	defer func() {
+		openersMu.Lock()
+		delete(openers, "test")
+		openersMu.Unlock()
+	}()
+
+	if _, err := Open(context.Background(), "test://somewhere"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if gotURL != "test://somewhere" {
+		t.Errorf("Unexpected url passed to opener: %v", gotURL)
+	}
+	if _, err := Open(context.Background(), "other://somewhere"); err == nil {
+		t.Errorf("Expected error for unknown scheme")
+	}
+	if _, err := Open(context.Background(), "/path/only"); err == nil {
+		t.Errorf("Expected error for missing scheme")
+	}
+}
+
+func TestRegisterTwice(t *testing.T) {
+	o := func(ctx context.Context, url string) (Interface, error) {
+		return nil, nil
+	}
+	Register("twice", o)
+	defer func() {
+		openersMu.Lock()
+		delete(openers, "twice")
+		openersMu.Unlock()
+	}()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected panic registering a scheme twice")
+		}
+	}()
+	Register("twice", o)
+}
+
+func TestRunCountsAdd(t *testing.T) {
+	var c RunCounts
+	c.Add(RunCounts{
+		Skipped: 1,
+		Errors:  map[string]int{"org/a": 3},
+	})
+	c.Add(RunCounts{
+		Skipped:      1,
+		Errors:       map[string]int{"org/b": 1},
+		Excluded:     2,
+		Suspended:    1,
+		NotMonitored: []string{"other"},
+	})
+	want := RunCounts{
+		Skipped:      2,
+		Errors:       map[string]int{"org/a": 3, "org/b": 1},
+		Excluded:     2,
+		Suspended:    1,
+		NotMonitored: []string{"other"},
+	}
+	if diff := cmp.Diff(want, c); diff != "" {
+		t.Errorf("Unexpected results. (-want +got):\n%s", diff)
+	}
+}